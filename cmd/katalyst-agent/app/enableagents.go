@@ -24,7 +24,9 @@ import (
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/agent"
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/agent/qrm"
 	_ "github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/cpu"
+	_ "github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/io"
 	_ "github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory"
+	_ "github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/network"
 )
 
 // AgentStarter is used to start katalyst agents
@@ -48,6 +50,7 @@ func init() {
 	agentInitializers.Store(qrm.QRMPluginNameCPU, AgentStarter{Init: qrm.InitQRMCPUPlugins})
 	agentInitializers.Store(qrm.QRMPluginNameMemory, AgentStarter{Init: qrm.InitQRMMemoryPlugins})
 	agentInitializers.Store(qrm.QRMPluginNameNetwork, AgentStarter{Init: qrm.InitQRMNetworkPlugins})
+	agentInitializers.Store(qrm.QRMPluginNameIO, AgentStarter{Init: qrm.InitQRMIOPlugins})
 }
 
 // RegisterAgentInitializer is used to register user-defined agents
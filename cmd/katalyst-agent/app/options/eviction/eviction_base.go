@@ -102,6 +102,7 @@ type EvictionPluginsOptions struct {
 	*ReclaimedResourcesEvictionPluginOptions
 	*MemoryPressureEvictionPluginOptions
 	*CPUPressureEvictionPluginOptions
+	*PIDPressureEvictionPluginOptions
 }
 
 func NewEvictionPluginsOptions() *EvictionPluginsOptions {
@@ -109,6 +110,7 @@ func NewEvictionPluginsOptions() *EvictionPluginsOptions {
 		ReclaimedResourcesEvictionPluginOptions: NewReclaimedResourcesEvictionPluginOptions(),
 		MemoryPressureEvictionPluginOptions:     NewMemoryPressureEvictionPluginOptions(),
 		CPUPressureEvictionPluginOptions:        NewCPUPressureEvictionPluginOptions(),
+		PIDPressureEvictionPluginOptions:        NewPIDPressureEvictionPluginOptions(),
 	}
 }
 
@@ -116,6 +118,7 @@ func (o *EvictionPluginsOptions) AddFlags(fss *cliflag.NamedFlagSets) {
 	o.ReclaimedResourcesEvictionPluginOptions.AddFlags(fss)
 	o.MemoryPressureEvictionPluginOptions.AddFlags(fss)
 	o.CPUPressureEvictionPluginOptions.AddFlags(fss)
+	o.PIDPressureEvictionPluginOptions.AddFlags(fss)
 }
 
 // ApplyTo fills up config with options
@@ -125,6 +128,7 @@ func (o *EvictionPluginsOptions) ApplyTo(c *evictionconfig.EvictionPluginsConfig
 		o.ReclaimedResourcesEvictionPluginOptions.ApplyTo(c.ReclaimedResourcesEvictionPluginConfiguration),
 		o.MemoryPressureEvictionPluginOptions.ApplyTo(c.MemoryPressureEvictionPluginConfiguration),
 		o.CPUPressureEvictionPluginOptions.ApplyTo(c.CPUPressureEvictionPluginConfiguration),
+		o.PIDPressureEvictionPluginOptions.ApplyTo(c.PIDPressureEvictionPluginConfiguration),
 	)
 	return errors.NewAggregate(errList)
 }
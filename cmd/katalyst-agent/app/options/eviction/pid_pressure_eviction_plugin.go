@@ -0,0 +1,60 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eviction
+
+import (
+	cliflag "k8s.io/component-base/cli/flag"
+
+	evictionconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/eviction"
+)
+
+const (
+	defaultPIDPressureEvictionThreshold             = 0.8
+	defaultPIDPressureEvictionPodGracePeriodSeconds = -1
+)
+
+// PIDPressureEvictionPluginOptions is the options of PIDPressureEvictionPlugin
+type PIDPressureEvictionPluginOptions struct {
+	PIDPressureEvictionThreshold             float64
+	PIDPressureEvictionPodGracePeriodSeconds int64
+}
+
+// NewPIDPressureEvictionPluginOptions returns a new PIDPressureEvictionPluginOptions
+func NewPIDPressureEvictionPluginOptions() *PIDPressureEvictionPluginOptions {
+	return &PIDPressureEvictionPluginOptions{
+		PIDPressureEvictionThreshold:             defaultPIDPressureEvictionThreshold,
+		PIDPressureEvictionPodGracePeriodSeconds: defaultPIDPressureEvictionPodGracePeriodSeconds,
+	}
+}
+
+// AddFlags parses the flags to PIDPressureEvictionPluginOptions
+func (o *PIDPressureEvictionPluginOptions) AddFlags(fss *cliflag.NamedFlagSets) {
+	fs := fss.FlagSet("eviction-pid-pressure")
+
+	fs.Float64Var(&o.PIDPressureEvictionThreshold, "pid-pressure-eviction-threshold",
+		o.PIDPressureEvictionThreshold,
+		"if the ratio of allocated pids in kernel.pid_max is greater than this threshold, "+
+			"reclaimed_cores pods with most pids will be evicted")
+	fs.Int64Var(&o.PIDPressureEvictionPodGracePeriodSeconds, "pid-pressure-eviction-pod-grace-period-seconds",
+		o.PIDPressureEvictionPodGracePeriodSeconds,
+		"the grace period of pid pressure eviction, and the default grace period of pods is used if it's not positive")
+}
+
+// ApplyTo applies PIDPressureEvictionPluginOptions to PIDPressureEvictionPluginConfiguration
+func (o *PIDPressureEvictionPluginOptions) ApplyTo(c *evictionconfig.PIDPressureEvictionPluginConfiguration) error {
+	c.PIDPressureEvictionThreshold = o.PIDPressureEvictionThreshold
+	c.PIDPressureEvictionPodGracePeriodSeconds = o.PIDPressureEvictionPodGracePeriodSeconds
+	return nil
+}
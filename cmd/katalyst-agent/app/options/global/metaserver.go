@@ -17,12 +17,16 @@ limitations under the License.
 package global
 
 import (
+	"fmt"
 	"time"
 
 	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/sets"
 	cliflag "k8s.io/component-base/cli/flag"
 
 	"github.com/kubewharf/katalyst-core/pkg/config/agent/global"
+	"github.com/kubewharf/katalyst-core/pkg/util/external/rdt"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
 )
 
 const (
@@ -41,12 +45,33 @@ const (
 	defaultRuntimePodCacheSyncPeriod    = 30 * time.Second
 	defaultKubeletPodCacheSyncMaxRate   = 5
 	defaultKubeletPodCacheSyncBurstBulk = 1
+	defaultKubeletSecurePortEnabled     = false
+	defaultKubeletSecurePort            = 10250
+	defaultAPIAuthTokenFile             = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultEnableAPIServerPodFallback   = true
+	defaultAPIServerFallbackGracePeriod = 2 * time.Minute
 )
 
 const (
 	defaultCheckpointManagerDir = "/var/lib/katalyst/metaserver/checkpoints"
 )
 
+const (
+	defaultMetricsFetcher   = "malachite"
+	defaultNativeProcFSRoot = "/proc"
+	defaultNativeSysFSRoot  = "/sys"
+	defaultNativeCgroupRoot = "/sys/fs/cgroup"
+	defaultResctrlRoot      = rdt.DefaultResctrlRootPath
+	defaultMetricsMaxAge    = time.Minute
+
+	defaultMetricsSampleInterval    = "5s"
+	defaultMetricsMinSampleInterval = time.Second
+)
+
+// metricsScopes are scopes of metrics which can be sampled with their own intervals
+var metricsScopes = sets.NewString(metric.MetricScopeNode, metric.MetricScopeNuma,
+	metric.MetricScopeCPU, metric.MetricScopeDevice, metric.MetricScopeContainer)
+
 type MetaServerOptions struct {
 	CNRCacheTTL                    time.Duration
 	CustomNodeConfigCacheTTL       time.Duration
@@ -60,10 +85,30 @@ type MetaServerOptions struct {
 	KubeletPodCacheSyncMaxRate   int
 	KubeletPodCacheSyncBurstBulk int
 
+	KubeletSecurePortEnabled bool
+	KubeletSecurePort        int
+	APIAuthTokenFile         string
+
+	KubeletCAFile                string
+	KubeletInsecureSkipTLSVerify bool
+
 	RemoteRuntimeEndpoint     string
 	RuntimePodCacheSyncPeriod time.Duration
 
+	EnableAPIServerPodFallback   bool
+	APIServerFallbackGracePeriod time.Duration
+
 	CheckpointManagerDir string
+
+	MetricsFetcher   string
+	NativeProcFSRoot string
+	NativeSysFSRoot  string
+	NativeCgroupRoot string
+	ResctrlRoot      string
+	MetricsMaxAge    time.Duration
+
+	MetricsSampleIntervals   map[string]string
+	MetricsMinSampleInterval time.Duration
 }
 
 func NewMetaServerOptions() *MetaServerOptions {
@@ -80,7 +125,26 @@ func NewMetaServerOptions() *MetaServerOptions {
 		RuntimePodCacheSyncPeriod:      defaultRuntimePodCacheSyncPeriod,
 		KubeletPodCacheSyncMaxRate:     defaultKubeletPodCacheSyncMaxRate,
 		KubeletPodCacheSyncBurstBulk:   defaultKubeletPodCacheSyncBurstBulk,
+		KubeletSecurePortEnabled:       defaultKubeletSecurePortEnabled,
+		KubeletSecurePort:              defaultKubeletSecurePort,
+		APIAuthTokenFile:               defaultAPIAuthTokenFile,
+		EnableAPIServerPodFallback:     defaultEnableAPIServerPodFallback,
+		APIServerFallbackGracePeriod:   defaultAPIServerFallbackGracePeriod,
 		CheckpointManagerDir:           defaultCheckpointManagerDir,
+		MetricsFetcher:                 defaultMetricsFetcher,
+		NativeProcFSRoot:               defaultNativeProcFSRoot,
+		NativeSysFSRoot:                defaultNativeSysFSRoot,
+		NativeCgroupRoot:               defaultNativeCgroupRoot,
+		ResctrlRoot:                    defaultResctrlRoot,
+		MetricsMaxAge:                  defaultMetricsMaxAge,
+		MetricsSampleIntervals: map[string]string{
+			"node":      defaultMetricsSampleInterval,
+			"numa":      defaultMetricsSampleInterval,
+			"cpu":       defaultMetricsSampleInterval,
+			"device":    defaultMetricsSampleInterval,
+			"container": defaultMetricsSampleInterval,
+		},
+		MetricsMinSampleInterval: defaultMetricsMinSampleInterval,
 	}
 }
 
@@ -102,6 +166,18 @@ func (o *MetaServerOptions) AddFlags(fss *cliflag.NamedFlagSets) {
 		"The grace time of meta server config checkpoint")
 	fs.IntVar(&o.KubeletReadOnlyPort, "kubelet-read-only-port", o.KubeletReadOnlyPort,
 		"The read-only port for the kubelet to serve")
+	fs.BoolVar(&o.KubeletSecurePortEnabled, "kubelet-secure-port-enabled", o.KubeletSecurePortEnabled,
+		"Whether to talk to kubelet through its authenticated port instead of the read-only port")
+	fs.IntVar(&o.KubeletSecurePort, "kubelet-secure-port", o.KubeletSecurePort,
+		"The authenticated port for the kubelet to serve")
+	fs.StringVar(&o.APIAuthTokenFile, "api-auth-token-file", o.APIAuthTokenFile,
+		"The file of bearer token used to authenticate to kubelet authenticated port")
+	fs.StringVar(&o.KubeletCAFile, "kubelet-ca-file", o.KubeletCAFile,
+		"The file of CA certificates used to verify serving certificate of kubelet authenticated port, "+
+			"and system CA certificates are used if it's empty")
+	fs.BoolVar(&o.KubeletInsecureSkipTLSVerify, "kubelet-insecure-skip-tls-verify", o.KubeletInsecureSkipTLSVerify,
+		"Whether to skip verifying serving certificate of kubelet authenticated port, which leaks the bearer token "+
+			"to anyone able to intercept the connection, and it can't be used along with kubelet-ca-file")
 	fs.StringVar(&o.RemoteRuntimeEndpoint, "remote-runtime-endpoint", o.RemoteRuntimeEndpoint,
 		"The endpoint of remote runtime service")
 	fs.DurationVar(&o.KubeletPodCacheSyncPeriod, "kubelet-pod-cache-sync-period", o.KubeletPodCacheSyncPeriod,
@@ -112,8 +188,32 @@ func (o *MetaServerOptions) AddFlags(fss *cliflag.NamedFlagSets) {
 		"The max rate for kubelet pod sync")
 	fs.IntVar(&o.KubeletPodCacheSyncBurstBulk, "kubelet-pod-cache-sync-burst-bulk", o.KubeletPodCacheSyncBurstBulk,
 		"The burst bulk for kubelet pod sync")
+	fs.BoolVar(&o.EnableAPIServerPodFallback, "enable-apiserver-pod-fallback", o.EnableAPIServerPodFallback,
+		"Whether to watch pods of this node from apiserver, and serve pods from it when kubelet is unavailable")
+	fs.DurationVar(&o.APIServerFallbackGracePeriod, "apiserver-pod-fallback-grace-period", o.APIServerFallbackGracePeriod,
+		"The period during which pods last synced from kubelet are still served after kubelet becomes unavailable, "+
+			"before falling back to pods from apiserver")
 	fs.StringVar(&o.CheckpointManagerDir, "checkpoint-manager-directory", o.CheckpointManagerDir,
 		"The checkpoint manager directory")
+	fs.StringVar(&o.MetricsFetcher, "metrics-fetcher", o.MetricsFetcher,
+		"The type of metrics fetcher, malachite to collect metrics from malachite, "+
+			"native to collect metrics directly from procfs, sysfs and cgroupfs, "+
+			"or kubelet to collect node and container metrics from kubelet summary api and cadvisor")
+	fs.StringVar(&o.NativeProcFSRoot, "native-metrics-procfs-root", o.NativeProcFSRoot,
+		"The root path of procfs for native metrics fetcher")
+	fs.StringVar(&o.NativeSysFSRoot, "native-metrics-sysfs-root", o.NativeSysFSRoot,
+		"The root path of sysfs for native metrics fetcher")
+	fs.StringVar(&o.NativeCgroupRoot, "native-metrics-cgroup-root", o.NativeCgroupRoot,
+		"The root path of cgroupfs for native metrics fetcher")
+	fs.StringVar(&o.ResctrlRoot, "resctrl-root", o.ResctrlRoot,
+		"The mount point of resctrl filesystem used to control RDT resources")
+	fs.DurationVar(&o.MetricsMaxAge, "metrics-max-age", o.MetricsMaxAge,
+		"The max age of metrics trusted by consumers, and metrics older than it are regarded as stale")
+	fs.StringToStringVar(&o.MetricsSampleIntervals, "metrics-sample-intervals", o.MetricsSampleIntervals,
+		"The sampling interval of each metrics scope, should be formatted as 'node=5s,numa=5s,cpu=5s,device=5s,container=5s', "+
+			"and each interval should be less than metrics max age")
+	fs.DurationVar(&o.MetricsMinSampleInterval, "metrics-min-sample-interval", o.MetricsMinSampleInterval,
+		"The min sampling interval of metrics fetcher, which bounds the faster cadence asked by consumers")
 }
 
 // ApplyTo fills up config with options
@@ -125,11 +225,52 @@ func (o *MetaServerOptions) ApplyTo(c *global.MetaServerConfiguration) error {
 	c.ConfigSkipFailedInitialization = o.ConfigSkipFailedInitialization
 	c.ConfigCheckpointGraceTime = o.ConfigCheckpointGraceTime
 	c.KubeletReadOnlyPort = o.KubeletReadOnlyPort
+	c.KubeletSecurePortEnabled = o.KubeletSecurePortEnabled
+	c.KubeletSecurePort = o.KubeletSecurePort
+	c.APIAuthTokenFile = o.APIAuthTokenFile
+	c.KubeletCAFile = o.KubeletCAFile
+	c.KubeletInsecureSkipTLSVerify = o.KubeletInsecureSkipTLSVerify
 	c.RemoteRuntimeEndpoint = o.RemoteRuntimeEndpoint
 	c.KubeletPodCacheSyncPeriod = o.KubeletPodCacheSyncPeriod
 	c.RuntimePodCacheSyncPeriod = o.RuntimePodCacheSyncPeriod
 	c.KubeletPodCacheSyncMaxRate = rate.Limit(o.KubeletPodCacheSyncMaxRate)
 	c.KubeletPodCacheSyncBurstBulk = o.KubeletPodCacheSyncBurstBulk
+	c.EnableAPIServerPodFallback = o.EnableAPIServerPodFallback
+	c.APIServerFallbackGracePeriod = o.APIServerFallbackGracePeriod
 	c.CheckpointManagerDir = o.CheckpointManagerDir
+	c.MetricsFetcher = o.MetricsFetcher
+	c.NativeProcFSRoot = o.NativeProcFSRoot
+	c.NativeSysFSRoot = o.NativeSysFSRoot
+	c.NativeCgroupRoot = o.NativeCgroupRoot
+	c.ResctrlRoot = o.ResctrlRoot
+	c.MetricsMaxAge = o.MetricsMaxAge
+
+	if o.KubeletCAFile != "" && o.KubeletInsecureSkipTLSVerify {
+		return fmt.Errorf("kubelet ca file can't be specified with kubelet insecure skip tls verify")
+	}
+
+	if o.MetricsMinSampleInterval <= 0 {
+		return fmt.Errorf("invalid metrics min sample interval: %v", o.MetricsMinSampleInterval)
+	}
+	c.MetricsMinSampleInterval = o.MetricsMinSampleInterval
+
+	for scope, value := range o.MetricsSampleIntervals {
+		if !metricsScopes.Has(scope) {
+			return fmt.Errorf("unknown metrics scope %v, should be one of %v", scope, metricsScopes.List())
+		}
+
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid sample interval %v of metrics scope %v: %v", value, scope, err)
+		} else if interval < o.MetricsMinSampleInterval {
+			return fmt.Errorf("sample interval %v of metrics scope %v is less than min sample interval %v",
+				interval, scope, o.MetricsMinSampleInterval)
+		} else if o.MetricsMaxAge > 0 && interval >= o.MetricsMaxAge {
+			// metrics would always be regarded as stale before they are sampled again
+			return fmt.Errorf("sample interval %v of metrics scope %v is not less than metrics max age %v",
+				interval, scope, o.MetricsMaxAge)
+		}
+		c.MetricsSampleIntervals[scope] = interval
+	}
 	return nil
 }
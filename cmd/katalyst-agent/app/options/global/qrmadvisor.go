@@ -26,6 +26,8 @@ import (
 type QRMAdvisorOptions struct {
 	CPUAdvisorSocketAbsPath string
 	CPUPluginSocketAbsPath  string
+
+	MemoryAdvisorSocketAbsPath string
 }
 
 // NewQRMAdvisorOptions creates a new options with a default config
@@ -33,6 +35,8 @@ func NewQRMAdvisorOptions() *QRMAdvisorOptions {
 	return &QRMAdvisorOptions{
 		CPUAdvisorSocketAbsPath: "/var/lib/katalyst/qrm_advisor/cpu_advisor.sock",
 		CPUPluginSocketAbsPath:  "/var/lib/katalyst/qrm_advisor/cpu_plugin.sock",
+
+		MemoryAdvisorSocketAbsPath: "/var/lib/katalyst/qrm_advisor/memory_advisor.sock",
 	}
 }
 
@@ -42,11 +46,13 @@ func (o *QRMAdvisorOptions) AddFlags(fss *cliflag.NamedFlagSets) {
 
 	fs.StringVar(&o.CPUAdvisorSocketAbsPath, "cpu-advisor-sock-abs-path", o.CPUAdvisorSocketAbsPath, "absolute path of socket file for cpu advisor served in sys-advisor")
 	fs.StringVar(&o.CPUPluginSocketAbsPath, "cpu-plugin-sock-abs-path", o.CPUPluginSocketAbsPath, "absolute path of socket file for cpu plugin to communicate with cpu advisor")
+	fs.StringVar(&o.MemoryAdvisorSocketAbsPath, "memory-advisor-sock-abs-path", o.MemoryAdvisorSocketAbsPath, "absolute path of socket file for memory advisor served in sys-advisor")
 }
 
 // ApplyTo fills up config with options
 func (o *QRMAdvisorOptions) ApplyTo(c *global.QRMAdvisorConfiguration) error {
 	c.CPUAdvisorSocketAbsPath = o.CPUAdvisorSocketAbsPath
 	c.CPUPluginSocketAbsPath = o.CPUPluginSocketAbsPath
+	c.MemoryAdvisorSocketAbsPath = o.MemoryAdvisorSocketAbsPath
 	return nil
 }
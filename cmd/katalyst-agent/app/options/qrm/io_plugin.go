@@ -0,0 +1,150 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrm
+
+import (
+	"fmt"
+	"time"
+
+	cliflag "k8s.io/component-base/cli/flag"
+
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+)
+
+const (
+	minIOWeight = 1
+	maxIOWeight = 10000
+)
+
+type IOOptions struct {
+	PolicyName              string
+	IOWeight                IOWeightOptions
+	PodLevelIOWeightAnnoKey string
+	PodLevelIOMaxAnnoKey    string
+	IOThrottleDevices       []string
+
+	EnableDynamicIOCap   bool
+	DynamicIOCapPeriod   time.Duration
+	IOUtilThreshold      float64
+	ReclaimedIOCapMinBps uint64
+	ReclaimedIOCapMaxBps uint64
+}
+
+type IOWeightOptions struct {
+	// ReclaimedCores is the io weight for reclaimed_cores
+	ReclaimedCores uint64
+	// SharedCores is the io weight for shared_cores
+	SharedCores uint64
+	// DedicatedCores is the io weight for dedicated_cores
+	DedicatedCores uint64
+	// SystemCores is the io weight for system_cores
+	SystemCores uint64
+}
+
+func NewIOOptions() *IOOptions {
+	return &IOOptions{
+		PolicyName: "dynamic",
+		IOWeight: IOWeightOptions{
+			ReclaimedCores: 10,
+			SharedCores:    100,
+			DedicatedCores: 100,
+			SystemCores:    100,
+		},
+		PodLevelIOWeightAnnoKey: "katalyst.kubewharf.io/io_weight",
+		PodLevelIOMaxAnnoKey:    "katalyst.kubewharf.io/io_max",
+		EnableDynamicIOCap:      false,
+		DynamicIOCapPeriod:      10 * time.Second,
+		IOUtilThreshold:         80,
+		ReclaimedIOCapMinBps:    10 << 20,
+		ReclaimedIOCapMaxBps:    500 << 20,
+	}
+}
+
+func (o *IOOptions) AddFlags(fss *cliflag.NamedFlagSets) {
+	fs := fss.FlagSet("io_resource_plugin")
+
+	fs.StringVar(&o.PolicyName, "io-resource-plugin-policy",
+		o.PolicyName, "The policy io resource plugin should use")
+	fs.Uint64Var(&o.IOWeight.ReclaimedCores, "io-resource-plugin-weight-reclaimed-cores",
+		o.IOWeight.ReclaimedCores, "io weight in [1, 10000] for reclaimed_cores")
+	fs.Uint64Var(&o.IOWeight.SharedCores, "io-resource-plugin-weight-shared-cores",
+		o.IOWeight.SharedCores, "io weight in [1, 10000] for shared_cores")
+	fs.Uint64Var(&o.IOWeight.DedicatedCores, "io-resource-plugin-weight-dedicated-cores",
+		o.IOWeight.DedicatedCores, "io weight in [1, 10000] for dedicated_cores")
+	fs.Uint64Var(&o.IOWeight.SystemCores, "io-resource-plugin-weight-system-cores",
+		o.IOWeight.SystemCores, "io weight in [1, 10000] for system_cores")
+	fs.StringVar(&o.PodLevelIOWeightAnnoKey, "io-resource-plugin-weight-annotation-key",
+		o.PodLevelIOWeightAnnoKey, "The annotation key of pod-level io weight")
+	fs.StringVar(&o.PodLevelIOMaxAnnoKey, "io-resource-plugin-max-annotation-key",
+		o.PodLevelIOMaxAnnoKey, "The annotation key of pod-level io throughput limits")
+	fs.StringSliceVar(&o.IOThrottleDevices, "io-resource-plugin-throttle-devices",
+		o.IOThrottleDevices, "The names of block devices on which io throughput limits are applied")
+	fs.BoolVar(&o.EnableDynamicIOCap, "enable-io-dynamic-cap",
+		o.EnableDynamicIOCap, "if set true, io throughput of reclaimed_cores is capped according to device utilization")
+	fs.DurationVar(&o.DynamicIOCapPeriod, "io-dynamic-cap-period",
+		o.DynamicIOCapPeriod, "The period to adjust io throughput cap of reclaimed_cores")
+	fs.Float64Var(&o.IOUtilThreshold, "io-dynamic-cap-util-threshold",
+		o.IOUtilThreshold, "The device utilization in (0, 100] percent above which io throughput cap of reclaimed_cores is decreased")
+	fs.Uint64Var(&o.ReclaimedIOCapMinBps, "io-dynamic-cap-min-bps",
+		o.ReclaimedIOCapMinBps, "The minimum io throughput cap of reclaimed_cores in bytes per second")
+	fs.Uint64Var(&o.ReclaimedIOCapMaxBps, "io-dynamic-cap-max-bps",
+		o.ReclaimedIOCapMaxBps, "The maximum io throughput cap of reclaimed_cores in bytes per second")
+}
+
+func (o *IOOptions) ApplyTo(conf *qrmconfig.IOQRMPluginConfig) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	conf.PolicyName = o.PolicyName
+	conf.IOWeight.ReclaimedCores = o.IOWeight.ReclaimedCores
+	conf.IOWeight.SharedCores = o.IOWeight.SharedCores
+	conf.IOWeight.DedicatedCores = o.IOWeight.DedicatedCores
+	conf.IOWeight.SystemCores = o.IOWeight.SystemCores
+	conf.PodLevelIOWeightAnnoKey = o.PodLevelIOWeightAnnoKey
+	conf.PodLevelIOMaxAnnoKey = o.PodLevelIOMaxAnnoKey
+	conf.IOThrottleDevices = o.IOThrottleDevices
+	conf.EnableDynamicIOCap = o.EnableDynamicIOCap
+	conf.DynamicIOCapPeriod = o.DynamicIOCapPeriod
+	conf.IOUtilThreshold = o.IOUtilThreshold
+	conf.ReclaimedIOCapMinBps = o.ReclaimedIOCapMinBps
+	conf.ReclaimedIOCapMaxBps = o.ReclaimedIOCapMaxBps
+
+	return nil
+}
+
+// validate checks io weights, the io throughput cap range and the device utilization threshold,
+// since invalid weights are rejected by the kernel and an inverted range breaks the cap adjustment
+func (o *IOOptions) validate() error {
+	for qosLevel, weight := range map[string]uint64{
+		"reclaimed_cores": o.IOWeight.ReclaimedCores,
+		"shared_cores":    o.IOWeight.SharedCores,
+		"dedicated_cores": o.IOWeight.DedicatedCores,
+		"system_cores":    o.IOWeight.SystemCores,
+	} {
+		if weight < minIOWeight || weight > maxIOWeight {
+			return fmt.Errorf("io weight %d for %s is out of range [%d, %d]", weight, qosLevel, minIOWeight, maxIOWeight)
+		}
+	}
+
+	if o.ReclaimedIOCapMinBps > o.ReclaimedIOCapMaxBps {
+		return fmt.Errorf("io dynamic cap min bps %d is larger than max bps %d", o.ReclaimedIOCapMinBps, o.ReclaimedIOCapMaxBps)
+	}
+
+	if o.IOUtilThreshold <= 0 || o.IOUtilThreshold > 100 {
+		return fmt.Errorf("io dynamic cap util threshold %v is out of range (0, 100]", o.IOUtilThreshold)
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package qrm
 
 import (
+	"time"
+
 	cliflag "k8s.io/component-base/cli/flag"
 
 	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
@@ -26,6 +28,79 @@ type MemoryOptions struct {
 	PolicyName                string
 	ReservedMemoryGB          uint64
 	SkipMemoryStateCorruption bool
+	EnableSysAdvisor          bool
+
+	ProactiveReclaimOptions
+	MemoryProtectionOptions
+	SwapControlOptions
+	RDTOptions
+	WatermarkTunerOptions
+	NumaMigrationOptions
+	PIDLimitOptions
+}
+
+type ProactiveReclaimOptions struct {
+	EnableProactiveReclaim            bool
+	ProactiveReclaimPeriod            time.Duration
+	ProactiveReclaimInactiveFileRatio float64
+	ProactiveReclaimMaxBytesPerRound  uint64
+	ProactiveReclaimMinBytes          uint64
+	ProactiveReclaimPSIAvg10Threshold float64
+}
+
+type MemoryProtectionOptions struct {
+	EnableMemoryProtection   bool
+	MemoryProtectionPeriod   time.Duration
+	MemoryProtectionBySPD    bool
+	MemoryProtectionMaxRatio float64
+}
+
+type SwapControlOptions struct {
+	EnableSwapControl bool
+	SwapControlPeriod time.Duration
+	SwapSwappiness    uint64
+}
+
+type RDTOptions struct {
+	EnableRDT                  bool
+	RDTPeriod                  time.Duration
+	RDTReclaimedCoresL3Ways    int
+	RDTReclaimedCoresMBPercent int
+}
+
+type WatermarkTunerOptions struct {
+	EnableWatermarkTuner                   bool
+	WatermarkTunerPeriod                   time.Duration
+	WatermarkScaleFactorMin                int64
+	WatermarkScaleFactorMax                int64
+	WatermarkScaleFactorStep               int64
+	WatermarkTunerAllocstallRateThreshold  float64
+	WatermarkTunerKswapdStealRateThreshold float64
+	EnableMinFreeKbytesTuning              bool
+	MinFreeKbytesMax                       int64
+	MinFreeKbytesStep                      int64
+}
+
+type NumaMigrationOptions struct {
+	EnableNumaMigration               bool
+	NumaMigrationPeriod               time.Duration
+	NumaMigrationBytesPerSecond       uint64
+	NumaMigrationRemoteRatioThreshold float64
+}
+
+type PIDLimitOptions struct {
+	EnablePIDLimit              bool
+	PIDLimitPeriod              time.Duration
+	PIDLimitReclaimedCoresRatio float64
+	PIDLimitAnnotationKey       string
+	ContainerPIDLimit           ContainerPIDLimitOptions
+}
+
+type ContainerPIDLimitOptions struct {
+	ReclaimedCores int64
+	SharedCores    int64
+	DedicatedCores int64
+	SystemCores    int64
 }
 
 func NewMemoryOptions() *MemoryOptions {
@@ -33,6 +108,56 @@ func NewMemoryOptions() *MemoryOptions {
 		PolicyName:                "dynamic",
 		ReservedMemoryGB:          0,
 		SkipMemoryStateCorruption: false,
+		EnableSysAdvisor:          false,
+		ProactiveReclaimOptions: ProactiveReclaimOptions{
+			EnableProactiveReclaim:            false,
+			ProactiveReclaimPeriod:            30 * time.Second,
+			ProactiveReclaimInactiveFileRatio: 0.2,
+			ProactiveReclaimMaxBytesPerRound:  1 << 30,
+			ProactiveReclaimMinBytes:          16 << 20,
+			ProactiveReclaimPSIAvg10Threshold: 1,
+		},
+		MemoryProtectionOptions: MemoryProtectionOptions{
+			EnableMemoryProtection:   false,
+			MemoryProtectionPeriod:   time.Minute,
+			MemoryProtectionBySPD:    false,
+			MemoryProtectionMaxRatio: 0.6,
+		},
+		SwapControlOptions: SwapControlOptions{
+			EnableSwapControl: false,
+			SwapControlPeriod: time.Minute,
+			SwapSwappiness:    60,
+		},
+		RDTOptions: RDTOptions{
+			EnableRDT:                  false,
+			RDTPeriod:                  30 * time.Second,
+			RDTReclaimedCoresL3Ways:    0,
+			RDTReclaimedCoresMBPercent: 0,
+		},
+		WatermarkTunerOptions: WatermarkTunerOptions{
+			EnableWatermarkTuner:                   false,
+			WatermarkTunerPeriod:                   10 * time.Second,
+			WatermarkScaleFactorMin:                10,
+			WatermarkScaleFactorMax:                400,
+			WatermarkScaleFactorStep:               20,
+			WatermarkTunerAllocstallRateThreshold:  1,
+			WatermarkTunerKswapdStealRateThreshold: 2000,
+			EnableMinFreeKbytesTuning:              false,
+			MinFreeKbytesMax:                       4 << 20,
+			MinFreeKbytesStep:                      64 << 10,
+		},
+		NumaMigrationOptions: NumaMigrationOptions{
+			EnableNumaMigration:               false,
+			NumaMigrationPeriod:               30 * time.Second,
+			NumaMigrationBytesPerSecond:       64 << 20,
+			NumaMigrationRemoteRatioThreshold: 0.1,
+		},
+		PIDLimitOptions: PIDLimitOptions{
+			EnablePIDLimit:              false,
+			PIDLimitPeriod:              30 * time.Second,
+			PIDLimitReclaimedCoresRatio: 0.2,
+			PIDLimitAnnotationKey:       "katalyst.kubewharf.io/pids_max",
+		},
 	}
 }
 
@@ -45,11 +170,131 @@ func (o *MemoryOptions) AddFlags(fss *cliflag.NamedFlagSets) {
 		o.ReservedMemoryGB, "reserved memory(GB) for system agents")
 	fs.BoolVar(&o.SkipMemoryStateCorruption, "skip-memory-state-corruption",
 		o.SkipMemoryStateCorruption, "if set true, we will skip memory state corruption")
-
+	fs.BoolVar(&o.EnableSysAdvisor, "memory-resource-plugin-advisor",
+		o.EnableSysAdvisor, "if set true, memory advices calculated by sys-advisor will be executed")
+	fs.BoolVar(&o.EnableProactiveReclaim, "enable-memory-proactive-reclaim",
+		o.EnableProactiveReclaim, "if set true, page caches of reclaimed_cores containers will be reclaimed proactively")
+	fs.DurationVar(&o.ProactiveReclaimPeriod, "memory-proactive-reclaim-period",
+		o.ProactiveReclaimPeriod, "the interval between two rounds of proactive reclaim")
+	fs.Float64Var(&o.ProactiveReclaimInactiveFileRatio, "memory-proactive-reclaim-inactive-file-ratio",
+		o.ProactiveReclaimInactiveFileRatio, "the ratio of inactive file pages to be reclaimed for each container in one round")
+	fs.Uint64Var(&o.ProactiveReclaimMaxBytesPerRound, "memory-proactive-reclaim-max-bytes-per-round",
+		o.ProactiveReclaimMaxBytesPerRound, "the max total bytes to be reclaimed in one round")
+	fs.Uint64Var(&o.ProactiveReclaimMinBytes, "memory-proactive-reclaim-min-bytes",
+		o.ProactiveReclaimMinBytes, "containers with reclaim target less than this will be skipped")
+	fs.Float64Var(&o.ProactiveReclaimPSIAvg10Threshold, "memory-proactive-reclaim-psi-avg10-threshold",
+		o.ProactiveReclaimPSIAvg10Threshold, "proactive reclaim backs off if memory psi some avg10 exceeds this threshold")
+	fs.BoolVar(&o.EnableMemoryProtection, "enable-memory-protection",
+		o.EnableMemoryProtection, "if set true, memory.min/memory.low will be set for dedicated_cores/shared_cores containers")
+	fs.DurationVar(&o.MemoryProtectionPeriod, "memory-protection-period",
+		o.MemoryProtectionPeriod, "the interval between two rounds of memory protection setting")
+	fs.BoolVar(&o.MemoryProtectionBySPD, "memory-protection-by-spd",
+		o.MemoryProtectionBySPD, "if set true, working sets declared in spd will be protected rather than requests")
+	fs.Float64Var(&o.MemoryProtectionMaxRatio, "memory-protection-max-ratio",
+		o.MemoryProtectionMaxRatio, "the max ratio of node memory capacity that can be protected in total")
+	fs.BoolVar(&o.EnableSwapControl, "enable-memory-swap-control",
+		o.EnableSwapControl, "if set true, only reclaimed_cores containers are allowed to swap unless specified in memory enhancement")
+	fs.DurationVar(&o.SwapControlPeriod, "memory-swap-control-period",
+		o.SwapControlPeriod, "the interval between two rounds of swap control")
+	fs.Uint64Var(&o.SwapSwappiness, "memory-swap-swappiness",
+		o.SwapSwappiness, "the memory.swappiness for containers allowed to swap, and it only works for cgroupv1")
+	fs.BoolVar(&o.EnableRDT, "enable-memory-rdt",
+		o.EnableRDT, "if set true, reclaimed_cores containers will be confined to a slice of LLC and memory bandwidth by RDT")
+	fs.DurationVar(&o.RDTPeriod, "memory-rdt-period",
+		o.RDTPeriod, "the interval between two rounds of rdt setting")
+	fs.IntVar(&o.RDTReclaimedCoresL3Ways, "memory-rdt-reclaimed-cores-l3-ways",
+		o.RDTReclaimedCoresL3Ways, "the number of LLC ways that reclaimed_cores can use, and 0 means no limit")
+	fs.IntVar(&o.RDTReclaimedCoresMBPercent, "memory-rdt-reclaimed-cores-mb-percent",
+		o.RDTReclaimedCoresMBPercent, "the percentage of memory bandwidth that reclaimed_cores can use, and 0 means no limit")
+	fs.BoolVar(&o.EnableWatermarkTuner, "enable-memory-watermark-tuner",
+		o.EnableWatermarkTuner, "if set true, vm.watermark_scale_factor will be tuned by allocation stalls and kswapd activity")
+	fs.DurationVar(&o.WatermarkTunerPeriod, "memory-watermark-tuner-period",
+		o.WatermarkTunerPeriod, "the interval between two rounds of watermark tuning")
+	fs.Int64Var(&o.WatermarkScaleFactorMin, "memory-watermark-scale-factor-min",
+		o.WatermarkScaleFactorMin, "the lower bound of vm.watermark_scale_factor")
+	fs.Int64Var(&o.WatermarkScaleFactorMax, "memory-watermark-scale-factor-max",
+		o.WatermarkScaleFactorMax, "the upper bound of vm.watermark_scale_factor")
+	fs.Int64Var(&o.WatermarkScaleFactorStep, "memory-watermark-scale-factor-step",
+		o.WatermarkScaleFactorStep, "the delta of vm.watermark_scale_factor in one round")
+	fs.Float64Var(&o.WatermarkTunerAllocstallRateThreshold, "memory-watermark-tuner-allocstall-rate-threshold",
+		o.WatermarkTunerAllocstallRateThreshold, "watermarks will be raised if allocation stalls per second exceed this threshold")
+	fs.Float64Var(&o.WatermarkTunerKswapdStealRateThreshold, "memory-watermark-tuner-kswapd-steal-rate-threshold",
+		o.WatermarkTunerKswapdStealRateThreshold, "watermarks will be lowered if pages stolen by kswapd per second are below this threshold")
+	fs.BoolVar(&o.EnableMinFreeKbytesTuning, "enable-memory-min-free-kbytes-tuning",
+		o.EnableMinFreeKbytesTuning, "if set true, vm.min_free_kbytes will be raised after vm.watermark_scale_factor reaches its upper bound")
+	fs.Int64Var(&o.MinFreeKbytesMax, "memory-min-free-kbytes-max",
+		o.MinFreeKbytesMax, "the upper bound of vm.min_free_kbytes")
+	fs.Int64Var(&o.MinFreeKbytesStep, "memory-min-free-kbytes-step",
+		o.MinFreeKbytesStep, "the delta of vm.min_free_kbytes in one round")
+	fs.BoolVar(&o.EnableNumaMigration, "enable-memory-numa-migration",
+		o.EnableNumaMigration, "if set true, remote pages of non-NUMA-binding containers will be migrated towards their cpuset.mems")
+	fs.DurationVar(&o.NumaMigrationPeriod, "memory-numa-migration-period",
+		o.NumaMigrationPeriod, "the interval between two rounds of numa migration")
+	fs.Uint64Var(&o.NumaMigrationBytesPerSecond, "memory-numa-migration-bytes-per-second",
+		o.NumaMigrationBytesPerSecond, "the bandwidth budget of numa migration in bytes per second")
+	fs.Float64Var(&o.NumaMigrationRemoteRatioThreshold, "memory-numa-migration-remote-ratio-threshold",
+		o.NumaMigrationRemoteRatioThreshold, "containers with remote pages ratio above this threshold will be migrated")
+	fs.BoolVar(&o.EnablePIDLimit, "enable-pid-limit",
+		o.EnablePIDLimit, "if set true, pids.max will be set for reclaimed_cores and containers per QoS level or pod-level pids.max annotation, otherwise pids.max set before will be restored")
+	fs.DurationVar(&o.PIDLimitPeriod, "pid-limit-period",
+		o.PIDLimitPeriod, "the interval between two rounds of pid limit setting")
+	fs.Float64Var(&o.PIDLimitReclaimedCoresRatio, "pid-limit-reclaimed-cores-ratio",
+		o.PIDLimitReclaimedCoresRatio, "the ratio of kernel.pid_max shared by all reclaimed_cores containers, and 0 means no limit")
+	fs.StringVar(&o.PIDLimitAnnotationKey, "pid-limit-annotation-key",
+		o.PIDLimitAnnotationKey, "the annotation key of pod-level pids.max applied to each container of the pod")
+	fs.Int64Var(&o.ContainerPIDLimit.ReclaimedCores, "pid-limit-container-reclaimed-cores",
+		o.ContainerPIDLimit.ReclaimedCores, "pids.max of each reclaimed_cores container, and 0 means no limit")
+	fs.Int64Var(&o.ContainerPIDLimit.SharedCores, "pid-limit-container-shared-cores",
+		o.ContainerPIDLimit.SharedCores, "pids.max of each shared_cores container, and 0 means no limit")
+	fs.Int64Var(&o.ContainerPIDLimit.DedicatedCores, "pid-limit-container-dedicated-cores",
+		o.ContainerPIDLimit.DedicatedCores, "pids.max of each dedicated_cores container, and 0 means no limit")
+	fs.Int64Var(&o.ContainerPIDLimit.SystemCores, "pid-limit-container-system-cores",
+		o.ContainerPIDLimit.SystemCores, "pids.max of each system_cores container, and 0 means no limit")
 }
+
 func (o *MemoryOptions) ApplyTo(conf *qrmconfig.MemoryQRMPluginConfig) error {
 	conf.PolicyName = o.PolicyName
 	conf.ReservedMemoryGB = o.ReservedMemoryGB
 	conf.SkipMemoryStateCorruption = o.SkipMemoryStateCorruption
+	conf.EnableSysAdvisor = o.EnableSysAdvisor
+	conf.EnableProactiveReclaim = o.EnableProactiveReclaim
+	conf.ProactiveReclaimPeriod = o.ProactiveReclaimPeriod
+	conf.ProactiveReclaimInactiveFileRatio = o.ProactiveReclaimInactiveFileRatio
+	conf.ProactiveReclaimMaxBytesPerRound = o.ProactiveReclaimMaxBytesPerRound
+	conf.ProactiveReclaimMinBytes = o.ProactiveReclaimMinBytes
+	conf.ProactiveReclaimPSIAvg10Threshold = o.ProactiveReclaimPSIAvg10Threshold
+	conf.EnableMemoryProtection = o.EnableMemoryProtection
+	conf.MemoryProtectionPeriod = o.MemoryProtectionPeriod
+	conf.MemoryProtectionBySPD = o.MemoryProtectionBySPD
+	conf.MemoryProtectionMaxRatio = o.MemoryProtectionMaxRatio
+	conf.EnableSwapControl = o.EnableSwapControl
+	conf.SwapControlPeriod = o.SwapControlPeriod
+	conf.SwapSwappiness = o.SwapSwappiness
+	conf.EnableRDT = o.EnableRDT
+	conf.RDTPeriod = o.RDTPeriod
+	conf.RDTReclaimedCoresL3Ways = o.RDTReclaimedCoresL3Ways
+	conf.RDTReclaimedCoresMBPercent = o.RDTReclaimedCoresMBPercent
+	conf.EnableWatermarkTuner = o.EnableWatermarkTuner
+	conf.WatermarkTunerPeriod = o.WatermarkTunerPeriod
+	conf.WatermarkScaleFactorMin = o.WatermarkScaleFactorMin
+	conf.WatermarkScaleFactorMax = o.WatermarkScaleFactorMax
+	conf.WatermarkScaleFactorStep = o.WatermarkScaleFactorStep
+	conf.WatermarkTunerAllocstallRateThreshold = o.WatermarkTunerAllocstallRateThreshold
+	conf.WatermarkTunerKswapdStealRateThreshold = o.WatermarkTunerKswapdStealRateThreshold
+	conf.EnableMinFreeKbytesTuning = o.EnableMinFreeKbytesTuning
+	conf.MinFreeKbytesMax = o.MinFreeKbytesMax
+	conf.MinFreeKbytesStep = o.MinFreeKbytesStep
+	conf.EnableNumaMigration = o.EnableNumaMigration
+	conf.NumaMigrationPeriod = o.NumaMigrationPeriod
+	conf.NumaMigrationBytesPerSecond = o.NumaMigrationBytesPerSecond
+	conf.NumaMigrationRemoteRatioThreshold = o.NumaMigrationRemoteRatioThreshold
+	conf.EnablePIDLimit = o.EnablePIDLimit
+	conf.PIDLimitPeriod = o.PIDLimitPeriod
+	conf.PIDLimitReclaimedCoresRatio = o.PIDLimitReclaimedCoresRatio
+	conf.PIDLimitAnnotationKey = o.PIDLimitAnnotationKey
+	conf.ContainerPIDLimit.ReclaimedCores = o.ContainerPIDLimit.ReclaimedCores
+	conf.ContainerPIDLimit.SharedCores = o.ContainerPIDLimit.SharedCores
+	conf.ContainerPIDLimit.DedicatedCores = o.ContainerPIDLimit.DedicatedCores
+	conf.ContainerPIDLimit.SystemCores = o.ContainerPIDLimit.SystemCores
 	return nil
 }
@@ -22,10 +22,17 @@ import (
 )
 
 type NetworkOptions struct {
-	PolicyName                    string
-	NetClass                      NetClassOptions
-	PodLevelNetClassAnnoKey       string
-	PodLevelNetAttributesAnnoKeys string
+	PolicyName                     string
+	NetClass                       NetClassOptions
+	PodLevelNetClassAnnoKey        string
+	PodLevelNetAttributesAnnoKeys  string
+	SkipNetworkStateCorruption     bool
+	NICNameAllocationAnnotationKey string
+	NICNumaAllocationAnnotationKey string
+	ReservedBandwidth              uint64
+	DefaultNICSpeed                int
+	TCShapingNICs                  []string
+	BandwidthEnforcerName          string
 }
 
 type NetClassOptions struct {
@@ -41,9 +48,15 @@ type NetClassOptions struct {
 
 func NewNetworkOptions() *NetworkOptions {
 	return &NetworkOptions{
-		PolicyName:                    "dynamic",
-		PodLevelNetClassAnnoKey:       consts.PodAnnotationNetClassKey,
-		PodLevelNetAttributesAnnoKeys: "",
+		PolicyName:                     "dynamic",
+		PodLevelNetClassAnnoKey:        consts.PodAnnotationNetClassKey,
+		PodLevelNetAttributesAnnoKeys:  "",
+		SkipNetworkStateCorruption:     false,
+		NICNameAllocationAnnotationKey: "qrm.katalyst.kubewharf.io/nic_name",
+		NICNumaAllocationAnnotationKey: "qrm.katalyst.kubewharf.io/nic_numa_nodes",
+		ReservedBandwidth:              0,
+		DefaultNICSpeed:                10000,
+		BandwidthEnforcerName:          "noop",
 	}
 }
 
@@ -64,6 +77,20 @@ func (o *NetworkOptions) AddFlags(fss *cliflag.NamedFlagSets) {
 		o.PodLevelNetClassAnnoKey, "The annotation key of pod-level net class")
 	fs.StringVar(&o.PodLevelNetAttributesAnnoKeys, "network-resource-plugin-net-attributes-keys",
 		o.PodLevelNetAttributesAnnoKeys, "The annotation keys of pod-level network attributes, separated by commas")
+	fs.BoolVar(&o.SkipNetworkStateCorruption, "skip-network-state-corruption",
+		o.SkipNetworkStateCorruption, "if set true, we will skip network state corruption")
+	fs.StringVar(&o.NICNameAllocationAnnotationKey, "network-resource-plugin-nic-name-annotation-key",
+		o.NICNameAllocationAnnotationKey, "The annotation key of the NIC assigned to a container in allocation results")
+	fs.StringVar(&o.NICNumaAllocationAnnotationKey, "network-resource-plugin-nic-numa-annotation-key",
+		o.NICNumaAllocationAnnotationKey, "The annotation key of NUMA nodes of the NIC assigned to a container in allocation results")
+	fs.Uint64Var(&o.ReservedBandwidth, "network-resource-plugin-reserved-bandwidth",
+		o.ReservedBandwidth, "The bandwidth (in Mbps) reserved on each NIC, which can't be allocated to containers")
+	fs.IntVar(&o.DefaultNICSpeed, "network-resource-plugin-default-nic-speed",
+		o.DefaultNICSpeed, "The speed (in Mbps) of NICs whose speed is unknown, and no bandwidth can be allocated on them if it's not positive")
+	fs.StringSliceVar(&o.TCShapingNICs, "network-resource-plugin-tc-shaping-nics",
+		o.TCShapingNICs, "The NICs whose egress traffic of host-network pods is shaped by net classes with tc on cgroup v2, and it's disabled if empty")
+	fs.StringVar(&o.BandwidthEnforcerName, "network-resource-plugin-bandwidth-enforcer",
+		o.BandwidthEnforcerName, "The name of enforcer to limit bandwidth of containers")
 }
 
 func (o *NetworkOptions) ApplyTo(conf *qrmconfig.NetworkQRMPluginConfig) error {
@@ -74,6 +101,13 @@ func (o *NetworkOptions) ApplyTo(conf *qrmconfig.NetworkQRMPluginConfig) error {
 	conf.NetClass.SystemCores = o.NetClass.SystemCores
 	conf.PodLevelNetClassAnnoKey = o.PodLevelNetClassAnnoKey
 	conf.PodLevelNetAttributesAnnoKeys = o.PodLevelNetAttributesAnnoKeys
+	conf.SkipNetworkStateCorruption = o.SkipNetworkStateCorruption
+	conf.NICNameAllocationAnnotationKey = o.NICNameAllocationAnnotationKey
+	conf.NICNumaAllocationAnnotationKey = o.NICNumaAllocationAnnotationKey
+	conf.ReservedBandwidth = o.ReservedBandwidth
+	conf.DefaultNICSpeed = o.DefaultNICSpeed
+	conf.TCShapingNICs = o.TCShapingNICs
+	conf.BandwidthEnforcerName = o.BandwidthEnforcerName
 
 	return nil
 }
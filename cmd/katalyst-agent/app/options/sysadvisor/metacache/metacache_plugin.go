@@ -26,17 +26,24 @@ import (
 
 const (
 	defaultMetaCacheSyncPeriod = 5
+
+	defaultContainerHistogramHalfLife         = 24 * time.Hour
+	defaultContainerHistogramCheckpointPeriod = 10 * time.Minute
 )
 
 // MetaCachePluginOptions holds the configurations for metacache plugin.
 type MetaCachePluginOptions struct {
-	SyncPeriod time.Duration
+	SyncPeriod                         time.Duration
+	ContainerHistogramHalfLife         time.Duration
+	ContainerHistogramCheckpointPeriod time.Duration
 }
 
 // NewMetaCachePluginOptions creates a new Options with a default config.
 func NewMetaCachePluginOptions() *MetaCachePluginOptions {
 	return &MetaCachePluginOptions{
-		SyncPeriod: defaultMetaCacheSyncPeriod * time.Second,
+		SyncPeriod:                         defaultMetaCacheSyncPeriod * time.Second,
+		ContainerHistogramHalfLife:         defaultContainerHistogramHalfLife,
+		ContainerHistogramCheckpointPeriod: defaultContainerHistogramCheckpointPeriod,
 	}
 }
 
@@ -45,10 +52,16 @@ func (o *MetaCachePluginOptions) AddFlags(fss *cliflag.NamedFlagSets) {
 	fs := fss.FlagSet("meta_cache_plugin")
 
 	fs.DurationVar(&o.SyncPeriod, "metacache-sync-period", o.SyncPeriod, "Period for metacache plugin to sync")
+	fs.DurationVar(&o.ContainerHistogramHalfLife, "metacache-container-histogram-half-life", o.ContainerHistogramHalfLife,
+		"Duration for weights of container usage histogram samples to decay by half")
+	fs.DurationVar(&o.ContainerHistogramCheckpointPeriod, "metacache-container-histogram-checkpoint-period", o.ContainerHistogramCheckpointPeriod,
+		"Period to persist container usage histograms into checkpoint")
 }
 
 // ApplyTo fills up config with options
 func (o *MetaCachePluginOptions) ApplyTo(c *metacache.MetaCachePluginConfiguration) error {
 	c.SyncPeriod = o.SyncPeriod
+	c.ContainerHistogramHalfLife = o.ContainerHistogramHalfLife
+	c.ContainerHistogramCheckpointPeriod = o.ContainerHistogramCheckpointPeriod
 	return nil
 }
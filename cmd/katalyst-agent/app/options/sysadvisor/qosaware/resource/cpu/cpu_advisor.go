@@ -17,9 +17,11 @@ limitations under the License.
 package cpu
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config/agent/sysadvisor/qosaware/resource/cpu"
@@ -29,6 +31,11 @@ import (
 type CPUAdvisorOptions struct {
 	CPUProvisionPolicyPriority map[string]string
 	CPUHeadroomPolicyPriority  map[string]string
+	IndicatorTargetMergeRule   string
+
+	*CPUProvisionPolicyRamaOptions
+	*CPUProvisionPolicyDedicatedOptions
+	*CPUHeadroomPolicyPercentileOptions
 }
 
 // NewCPUAdvisorOptions creates a new Options with a default config
@@ -37,11 +44,17 @@ func NewCPUAdvisorOptions() *CPUAdvisorOptions {
 		CPUProvisionPolicyPriority: map[string]string{
 			string(types.QoSRegionTypeShare):                  string(types.CPUProvisionPolicyCanonical),
 			string(types.QoSRegionTypeDedicatedNumaExclusive): string(types.CPUProvisionPolicyCanonical),
+			string(types.QoSRegionTypeDedicated):              string(types.CPUProvisionPolicyDedicated),
 		},
 		CPUHeadroomPolicyPriority: map[string]string{
 			string(types.QoSRegionTypeShare):                  string(types.CPUHeadroomPolicyCanonical),
 			string(types.QoSRegionTypeDedicatedNumaExclusive): string(types.CPUHeadroomPolicyCanonical),
+			string(types.QoSRegionTypeDedicated):              string(types.CPUHeadroomPolicyDedicated),
 		},
+		IndicatorTargetMergeRule:           string(types.IndicatorTargetMergeRuleStrictest),
+		CPUProvisionPolicyRamaOptions:      NewCPUProvisionPolicyRamaOptions(),
+		CPUProvisionPolicyDedicatedOptions: NewCPUProvisionPolicyDedicatedOptions(),
+		CPUHeadroomPolicyPercentileOptions: NewCPUHeadroomPolicyPercentileOptions(),
 	}
 }
 
@@ -49,10 +62,16 @@ func NewCPUAdvisorOptions() *CPUAdvisorOptions {
 func (o *CPUAdvisorOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringToStringVar(&o.CPUProvisionPolicyPriority, "cpu-provision-policy-priority", o.CPUProvisionPolicyPriority,
 		"policies of each region type for cpu advisor to update resource provision, sorted by priority descending order, "+
-			"should be formatted as 'share=rama/canonical,dedicated-numa-exclusive=rama/canonical'")
+			"should be formatted as 'share=rama/canonical,dedicated-numa-exclusive=rama/canonical,dedicated=dedicated'")
 	fs.StringToStringVar(&o.CPUHeadroomPolicyPriority, "cpu-headroom-policy-priority", o.CPUHeadroomPolicyPriority,
 		"policies of each region type for cpu advisor to estimate resource headroom, sorted by priority descending order, "+
-			"should be formatted as 'share=rama/canonical,dedicated-numa-exclusive=rama/canonical'")
+			"should be formatted as 'share=rama/canonical,dedicated-numa-exclusive=rama/canonical,dedicated=dedicated'")
+	fs.StringVar(&o.IndicatorTargetMergeRule, "cpu-indicator-target-merge-rule", o.IndicatorTargetMergeRule,
+		"rule to merge indicator targets of multiple services in a region, should be one of 'strictest' and 'loosest'")
+
+	o.CPUProvisionPolicyRamaOptions.AddFlags(fs)
+	o.CPUProvisionPolicyDedicatedOptions.AddFlags(fs)
+	o.CPUHeadroomPolicyPercentileOptions.AddFlags(fs)
 }
 
 // ApplyTo fills up config with options
@@ -73,5 +92,16 @@ func (o *CPUAdvisorOptions) ApplyTo(c *cpu.CPUAdvisorConfiguration) error {
 		}
 	}
 
-	return nil
+	switch rule := types.IndicatorTargetMergeRule(o.IndicatorTargetMergeRule); rule {
+	case types.IndicatorTargetMergeRuleStrictest, types.IndicatorTargetMergeRuleLoosest:
+		c.IndicatorTargetMergeRule = rule
+	default:
+		return fmt.Errorf("unsupported indicator target merge rule: %v", o.IndicatorTargetMergeRule)
+	}
+
+	var errList []error
+	errList = append(errList, o.CPUProvisionPolicyRamaOptions.ApplyTo(c.CPUProvisionPolicyRamaConfiguration))
+	errList = append(errList, o.CPUProvisionPolicyDedicatedOptions.ApplyTo(c.CPUProvisionPolicyDedicatedConfiguration))
+	errList = append(errList, o.CPUHeadroomPolicyPercentileOptions.ApplyTo(c.CPUHeadroomPolicyPercentileConfiguration))
+	return errors.NewAggregate(errList)
 }
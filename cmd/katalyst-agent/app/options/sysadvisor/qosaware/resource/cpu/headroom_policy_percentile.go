@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/kubewharf/katalyst-core/pkg/config/agent/sysadvisor/qosaware/resource/cpu"
+)
+
+// CPUHeadroomPolicyPercentileOptions holds the configurations for percentile cpu headroom policy
+type CPUHeadroomPolicyPercentileOptions struct {
+	Percentile        float64
+	SafetyMarginRatio float64
+}
+
+// NewCPUHeadroomPolicyPercentileOptions creates a new Options with a default config
+func NewCPUHeadroomPolicyPercentileOptions() *CPUHeadroomPolicyPercentileOptions {
+	return &CPUHeadroomPolicyPercentileOptions{
+		Percentile:        0.95,
+		SafetyMarginRatio: 0.1,
+	}
+}
+
+// AddFlags adds flags to the specified FlagSet.
+func (o *CPUHeadroomPolicyPercentileOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.Float64Var(&o.Percentile, "cpu-headroom-percentile", o.Percentile,
+		"percentile of cpu usage history regarded as container requirement for percentile cpu headroom policy, should be in (0, 1]")
+	fs.Float64Var(&o.SafetyMarginRatio, "cpu-headroom-percentile-safety-margin-ratio", o.SafetyMarginRatio,
+		"ratio of safety margin added upon the percentile of cpu usage history for percentile cpu headroom policy")
+}
+
+// ApplyTo fills up config with options
+func (o *CPUHeadroomPolicyPercentileOptions) ApplyTo(c *cpu.CPUHeadroomPolicyPercentileConfiguration) error {
+	if o.Percentile <= 0 || o.Percentile > 1 {
+		return fmt.Errorf("invalid cpu headroom percentile: %v", o.Percentile)
+	} else if o.SafetyMarginRatio < 0 {
+		return fmt.Errorf("invalid cpu headroom percentile safety margin ratio: %v", o.SafetyMarginRatio)
+	}
+
+	c.Percentile = o.Percentile
+	c.SafetyMarginRatio = o.SafetyMarginRatio
+	return nil
+}
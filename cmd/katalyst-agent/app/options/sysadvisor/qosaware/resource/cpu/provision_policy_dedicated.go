@@ -0,0 +1,54 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/kubewharf/katalyst-core/pkg/config/agent/sysadvisor/qosaware/resource/cpu"
+)
+
+// CPUProvisionPolicyDedicatedOptions holds the configurations for dedicated cpu provision policy
+type CPUProvisionPolicyDedicatedOptions struct {
+	SafetyMarginRatio float64
+}
+
+// NewCPUProvisionPolicyDedicatedOptions creates a new Options with a default config
+func NewCPUProvisionPolicyDedicatedOptions() *CPUProvisionPolicyDedicatedOptions {
+	return &CPUProvisionPolicyDedicatedOptions{
+		SafetyMarginRatio: 0.2,
+	}
+}
+
+// AddFlags adds flags to the specified FlagSet.
+func (o *CPUProvisionPolicyDedicatedOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.Float64Var(&o.SafetyMarginRatio, "cpu-provision-dedicated-safety-margin-ratio", o.SafetyMarginRatio,
+		"ratio of safety margin added upon cpu usage of dedicated cores containers without numa binding, "+
+			"and the rest of their exclusive cpus can be lent to reclaimed pods")
+}
+
+// ApplyTo fills up config with options
+func (o *CPUProvisionPolicyDedicatedOptions) ApplyTo(c *cpu.CPUProvisionPolicyDedicatedConfiguration) error {
+	if o.SafetyMarginRatio < 0 {
+		return fmt.Errorf("invalid cpu provision dedicated safety margin ratio: %v", o.SafetyMarginRatio)
+	}
+
+	c.SafetyMarginRatio = o.SafetyMarginRatio
+	return nil
+}
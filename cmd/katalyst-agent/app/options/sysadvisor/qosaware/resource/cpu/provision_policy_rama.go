@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	workloadapis "github.com/kubewharf/katalyst-api/pkg/apis/workload/v1alpha1"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config/agent/sysadvisor/qosaware/resource/cpu"
+)
+
+const (
+	pidParamsSeparator         = "/"
+	pidParamsKeyValueSeparator = ":"
+)
+
+// CPUProvisionPolicyRamaOptions holds the configurations for rama cpu provision policy
+type CPUProvisionPolicyRamaOptions struct {
+	PIDParameters map[string]string
+}
+
+// NewCPUProvisionPolicyRamaOptions creates a new Options with a default config
+func NewCPUProvisionPolicyRamaOptions() *CPUProvisionPolicyRamaOptions {
+	return &CPUProvisionPolicyRamaOptions{
+		PIDParameters: map[string]string{
+			string(workloadapis.TargetIndicatorNameCPUSchedWait): "kpp:10/kpn:1/kdp:0/kdn:0/ki:0" +
+				"/adjustment_upper_bound:8/adjustment_lower_bound:-2" +
+				"/deadband_upper_pct:0.02/deadband_lower_pct:0.2" +
+				"/integral_upper_bound:10/integral_lower_bound:-10",
+		},
+	}
+}
+
+// AddFlags adds flags to the specified FlagSet.
+func (o *CPUProvisionPolicyRamaOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringToStringVar(&o.PIDParameters, "cpu-provision-rama-pid-parameters", o.PIDParameters,
+		"pid controller parameters of each indicator for rama cpu provision policy, should be formatted as "+
+			"'cpu_sched_wait=kpp:10/kpn:1/kdp:0/kdn:0/ki:0/adjustment_upper_bound:8/adjustment_lower_bound:-2/"+
+			"deadband_upper_pct:0.02/deadband_lower_pct:0.2/integral_upper_bound:10/integral_lower_bound:-10', "+
+			"and parameters not specified are regarded as zero")
+}
+
+// ApplyTo fills up config with options
+func (o *CPUProvisionPolicyRamaOptions) ApplyTo(c *cpu.CPUProvisionPolicyRamaConfiguration) error {
+	for indicatorName, paramsStr := range o.PIDParameters {
+		params, err := parseFirstOrderPIDParams(paramsStr)
+		if err != nil {
+			return fmt.Errorf("parse pid parameters of indicator %v failed: %v", indicatorName, err)
+		}
+		c.PIDParameters[indicatorName] = params
+	}
+	return nil
+}
+
+// parseFirstOrderPIDParams parses pid parameters formatted as 'kpp:10/kpn:1/...'
+func parseFirstOrderPIDParams(paramsStr string) (types.FirstOrderPIDParams, error) {
+	params := types.FirstOrderPIDParams{}
+	fields := map[string]*float64{
+		"kpp":                    &params.Kpp,
+		"kpn":                    &params.Kpn,
+		"kdp":                    &params.Kdp,
+		"kdn":                    &params.Kdn,
+		"ki":                     &params.Ki,
+		"adjustment_upper_bound": &params.AdjustmentUpperBound,
+		"adjustment_lower_bound": &params.AdjustmentLowerBound,
+		"deadband_upper_pct":     &params.DeadbandUpperPct,
+		"deadband_lower_pct":     &params.DeadbandLowerPct,
+		"integral_upper_bound":   &params.IntegralUpperBound,
+		"integral_lower_bound":   &params.IntegralLowerBound,
+	}
+
+	for _, kv := range strings.Split(paramsStr, pidParamsSeparator) {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+
+		pair := strings.SplitN(kv, pidParamsKeyValueSeparator, 2)
+		if len(pair) != 2 {
+			return params, fmt.Errorf("invalid pid parameter %q", kv)
+		}
+
+		field, ok := fields[strings.TrimSpace(pair[0])]
+		if !ok {
+			return params, fmt.Errorf("unknown pid parameter %q", pair[0])
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(pair[1]), 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid value of pid parameter %q: %v", pair[0], err)
+		}
+		*field = value
+	}
+
+	if params.AdjustmentLowerBound > params.AdjustmentUpperBound {
+		return params, fmt.Errorf("adjustment lower bound %v is larger than upper bound %v",
+			params.AdjustmentLowerBound, params.AdjustmentUpperBound)
+	} else if params.IntegralLowerBound > params.IntegralUpperBound {
+		return params, fmt.Errorf("integral lower bound %v is larger than upper bound %v",
+			params.IntegralLowerBound, params.IntegralUpperBound)
+	}
+	return params, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/kubewharf/katalyst-core/pkg/config/agent/sysadvisor/qosaware/resource/memory"
+)
+
+// MemoryHeadroomPolicyPercentileOptions holds the configurations for percentile memory headroom policy
+type MemoryHeadroomPolicyPercentileOptions struct {
+	Percentile        float64
+	SafetyMarginRatio float64
+}
+
+// NewMemoryHeadroomPolicyPercentileOptions creates a new Options with a default config
+func NewMemoryHeadroomPolicyPercentileOptions() *MemoryHeadroomPolicyPercentileOptions {
+	return &MemoryHeadroomPolicyPercentileOptions{
+		Percentile:        0.99,
+		SafetyMarginRatio: 0.1,
+	}
+}
+
+// AddFlags adds flags to the specified FlagSet.
+func (o *MemoryHeadroomPolicyPercentileOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.Float64Var(&o.Percentile, "memory-headroom-percentile", o.Percentile,
+		"percentile of memory usage history regarded as container requirement for percentile memory headroom policy, should be in (0, 1]")
+	fs.Float64Var(&o.SafetyMarginRatio, "memory-headroom-percentile-safety-margin-ratio", o.SafetyMarginRatio,
+		"ratio of safety margin added upon the percentile of memory usage history for percentile memory headroom policy")
+}
+
+// ApplyTo fills up config with options
+func (o *MemoryHeadroomPolicyPercentileOptions) ApplyTo(c *memory.MemoryHeadroomPolicyPercentileConfiguration) error {
+	if o.Percentile <= 0 || o.Percentile > 1 {
+		return fmt.Errorf("invalid memory headroom percentile: %v", o.Percentile)
+	} else if o.SafetyMarginRatio < 0 {
+		return fmt.Errorf("invalid memory headroom percentile safety margin ratio: %v", o.SafetyMarginRatio)
+	}
+
+	c.Percentile = o.Percentile
+	c.SafetyMarginRatio = o.SafetyMarginRatio
+	return nil
+}
@@ -18,6 +18,7 @@ package memory
 
 import (
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config/agent/sysadvisor/qosaware/resource/memory"
@@ -25,13 +26,25 @@ import (
 
 // MemoryAdvisorOptions holds the configurations for memory advisor in qos aware plugin
 type MemoryAdvisorOptions struct {
-	MemoryHeadroomPolicyPriority []string
+	MemoryHeadroomPolicyPriority    []string
+	MemoryProvisionPolicyPriority   []string
+	ReclaimedCacheLimitBytes        uint64
+	DropCacheNumaFreeRatioThreshold float64
+
+	*MemoryHeadroomPolicyPercentileOptions
+	*MemoryProvisionPolicyCanonicalOptions
 }
 
 // NewMemoryAdvisorOptions creates a new Options with a default config
 func NewMemoryAdvisorOptions() *MemoryAdvisorOptions {
 	return &MemoryAdvisorOptions{
-		MemoryHeadroomPolicyPriority: []string{string(types.MemoryHeadroomPolicyCanonical)},
+		MemoryHeadroomPolicyPriority:    []string{string(types.MemoryHeadroomPolicyCanonical)},
+		MemoryProvisionPolicyPriority:   []string{string(types.MemoryProvisionPolicyCanonical)},
+		ReclaimedCacheLimitBytes:        0,
+		DropCacheNumaFreeRatioThreshold: 0,
+
+		MemoryHeadroomPolicyPercentileOptions: NewMemoryHeadroomPolicyPercentileOptions(),
+		MemoryProvisionPolicyCanonicalOptions: NewMemoryProvisionPolicyCanonicalOptions(),
 	}
 }
 
@@ -39,6 +52,15 @@ func NewMemoryAdvisorOptions() *MemoryAdvisorOptions {
 func (o *MemoryAdvisorOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&o.MemoryHeadroomPolicyPriority, "memory-headroom-policy-priority", o.MemoryHeadroomPolicyPriority,
 		"policy memory advisor to estimate resource headroom, sorted by priority descending order, should be formatted as 'policy1,policy2'")
+	fs.StringSliceVar(&o.MemoryProvisionPolicyPriority, "memory-provision-policy-priority", o.MemoryProvisionPolicyPriority,
+		"policy memory advisor to provision resource for reclaimed_cores pods, sorted by priority descending order, should be formatted as 'policy1,policy2'")
+	fs.Uint64Var(&o.ReclaimedCacheLimitBytes, "memory-advisor-reclaimed-cache-limit-bytes", o.ReclaimedCacheLimitBytes,
+		"file cache of each reclaimed_cores container will be capped by memory.high, and 0 means no limit")
+	fs.Float64Var(&o.DropCacheNumaFreeRatioThreshold, "memory-advisor-drop-cache-numa-free-ratio-threshold", o.DropCacheNumaFreeRatioThreshold,
+		"cache of reclaimed_cores containers will be dropped if free memory ratio of a numa node is below this threshold, and 0 means never drop")
+
+	o.MemoryHeadroomPolicyPercentileOptions.AddFlags(fs)
+	o.MemoryProvisionPolicyCanonicalOptions.AddFlags(fs)
 }
 
 // ApplyTo fills up config with options
@@ -46,5 +68,14 @@ func (o *MemoryAdvisorOptions) ApplyTo(c *memory.MemoryAdvisorConfiguration) err
 	for _, policy := range o.MemoryHeadroomPolicyPriority {
 		c.MemoryHeadroomPolicies = append(c.MemoryHeadroomPolicies, types.MemoryHeadroomPolicyName(policy))
 	}
-	return nil
+	for _, policy := range o.MemoryProvisionPolicyPriority {
+		c.MemoryProvisionPolicies = append(c.MemoryProvisionPolicies, types.MemoryProvisionPolicyName(policy))
+	}
+	c.ReclaimedCacheLimitBytes = o.ReclaimedCacheLimitBytes
+	c.DropCacheNumaFreeRatioThreshold = o.DropCacheNumaFreeRatioThreshold
+
+	var errList []error
+	errList = append(errList, o.MemoryHeadroomPolicyPercentileOptions.ApplyTo(c.MemoryHeadroomPolicyPercentileConfiguration))
+	errList = append(errList, o.MemoryProvisionPolicyCanonicalOptions.ApplyTo(c.MemoryProvisionPolicyCanonicalConfiguration))
+	return errors.NewAggregate(errList)
 }
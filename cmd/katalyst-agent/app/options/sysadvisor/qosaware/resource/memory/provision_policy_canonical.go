@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/kubewharf/katalyst-core/pkg/config/agent/sysadvisor/qosaware/resource/memory"
+)
+
+// MemoryProvisionPolicyCanonicalOptions holds the configurations for canonical memory provision policy
+type MemoryProvisionPolicyCanonicalOptions struct {
+	ReservedFreeRatio            float64
+	PSIAvg10Threshold            float64
+	PressureHistorySize          int
+	PressureBackoffRatio         float64
+	MaxShrinkBytes               int64
+	MinReclaimedMemoryLimitBytes int64
+}
+
+// NewMemoryProvisionPolicyCanonicalOptions creates a new Options with a default config
+func NewMemoryProvisionPolicyCanonicalOptions() *MemoryProvisionPolicyCanonicalOptions {
+	return &MemoryProvisionPolicyCanonicalOptions{
+		ReservedFreeRatio:    0.05,
+		PSIAvg10Threshold:    10,
+		PressureHistorySize:  10,
+		PressureBackoffRatio: 0.1,
+		MaxShrinkBytes:       1 << 30,
+	}
+}
+
+// AddFlags adds flags to the specified FlagSet.
+func (o *MemoryProvisionPolicyCanonicalOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.Float64Var(&o.ReservedFreeRatio, "memory-provision-canonical-reserved-free-ratio", o.ReservedFreeRatio,
+		"ratio of memory of each numa node kept free from reclaimed_cores pods for canonical memory provision policy, should be in [0, 1)")
+	fs.Float64Var(&o.PSIAvg10Threshold, "memory-provision-canonical-psi-avg10-threshold", o.PSIAvg10Threshold,
+		"memory is regarded under pressure if memory PSI (some avg10) of any container on the numa nodes is above this threshold for canonical memory provision policy")
+	fs.IntVar(&o.PressureHistorySize, "memory-provision-canonical-pressure-history-size", o.PressureHistorySize,
+		"reclaimed memory limit won't grow if memory pressure happens in this number of latest updates for canonical memory provision policy")
+	fs.Float64Var(&o.PressureBackoffRatio, "memory-provision-canonical-pressure-backoff-ratio", o.PressureBackoffRatio,
+		"ratio by which reclaimed memory limit shrinks each time memory is under pressure for canonical memory provision policy, should be in [0, 1)")
+	fs.Int64Var(&o.MaxShrinkBytes, "memory-provision-canonical-max-shrink-bytes", o.MaxShrinkBytes,
+		"reclaimed memory limit won't be set lower than usage of reclaimed_cores pods minus this value in one update "+
+			"for canonical memory provision policy, and 0 means unbounded")
+	fs.Int64Var(&o.MinReclaimedMemoryLimitBytes, "memory-provision-canonical-min-reclaimed-memory-limit-bytes", o.MinReclaimedMemoryLimitBytes,
+		"minimum reclaimed memory limit for canonical memory provision policy")
+}
+
+// ApplyTo fills up config with options
+func (o *MemoryProvisionPolicyCanonicalOptions) ApplyTo(c *memory.MemoryProvisionPolicyCanonicalConfiguration) error {
+	if o.ReservedFreeRatio < 0 || o.ReservedFreeRatio >= 1 {
+		return fmt.Errorf("invalid memory provision canonical reserved free ratio: %v", o.ReservedFreeRatio)
+	} else if o.PressureHistorySize < 0 {
+		return fmt.Errorf("invalid memory provision canonical pressure history size: %v", o.PressureHistorySize)
+	} else if o.PressureBackoffRatio < 0 || o.PressureBackoffRatio >= 1 {
+		return fmt.Errorf("invalid memory provision canonical pressure backoff ratio: %v", o.PressureBackoffRatio)
+	} else if o.MaxShrinkBytes < 0 {
+		return fmt.Errorf("invalid memory provision canonical max shrink bytes: %v", o.MaxShrinkBytes)
+	} else if o.MinReclaimedMemoryLimitBytes < 0 {
+		return fmt.Errorf("invalid memory provision canonical min reclaimed memory limit bytes: %v", o.MinReclaimedMemoryLimitBytes)
+	}
+
+	c.ReservedFreeRatio = o.ReservedFreeRatio
+	c.PSIAvg10Threshold = o.PSIAvg10Threshold
+	c.PressureHistorySize = o.PressureHistorySize
+	c.PressureBackoffRatio = o.PressureBackoffRatio
+	c.MaxShrinkBytes = o.MaxShrinkBytes
+	c.MinReclaimedMemoryLimitBytes = o.MinReclaimedMemoryLimitBytes
+	return nil
+}
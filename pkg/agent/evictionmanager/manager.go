@@ -43,6 +43,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/client"
 	pkgconfig "github.com/kubewharf/katalyst-core/pkg/config"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
 	"github.com/kubewharf/katalyst-core/pkg/util/native"
@@ -94,12 +95,15 @@ type EvictionManger struct {
 	thresholdsFirstObservedAt map[string]thresholdObservedAt
 }
 
-var InnerEvictionPluginsDisabledByDefault = sets.NewString()
+// InnerEvictionPluginsDisabledByDefault contains inner plugins that should be enabled explicitly,
+// since their threshold should be tuned according to workloads on the node
+var InnerEvictionPluginsDisabledByDefault = sets.NewString("pid-pressure")
 
 func NewInnerEvictionPluginInitializers() map[string]plugin.InitFunc {
 	innerEvictionPluginInitializers := make(map[string]plugin.InitFunc)
 	innerEvictionPluginInitializers["reclaimed-resources"] = plugin.NewReclaimedResourcesEvictionPlugin
 	innerEvictionPluginInitializers["memory-pressure"] = plugin.NewMemoryPressureEvictionPlugin
+	innerEvictionPluginInitializers["pid-pressure"] = plugin.NewPIDPressureEvictionPlugin
 	return innerEvictionPluginInitializers
 }
 
@@ -166,6 +170,7 @@ func (m *EvictionManger) sync(ctx context.Context) {
 	_ = m.emitter.StoreInt64(MetricsNameRunningPodCNT, int64(len(activePods)), metrics.MetricTypeNameRaw)
 
 	pods := native.FilterOutSkipEvictionPods(activePods, m.conf.EvictionSkippedAnnotationKeys, m.conf.EvictionSkippedLabelKeys)
+	pods = filterOutStalePods(pods)
 	klog.Infof("[eviction manager] currently, there are %v candidate pods", len(pods))
 	_ = m.emitter.StoreInt64(MetricsNameCandidatePodCNT, int64(len(pods)), metrics.MetricTypeNameRaw)
 
@@ -518,6 +523,23 @@ func thresholdsMetGracePeriod(thresholdsObservedAt map[string]thresholdObservedA
 	return results
 }
 
+// filterOutStalePods returns pods that are not stale, since pods served from apiserver when kubelet is
+// unavailable may lag behind the node (e.g. already terminated), and evicting them can't be trusted
+func filterOutStalePods(pods []*v1.Pod) []*v1.Pod {
+	var filteredPods []*v1.Pod
+	for _, p := range pods {
+		if pod.IsPodStale(p) {
+			continue
+		}
+		filteredPods = append(filteredPods, p)
+	}
+
+	if staleCount := len(pods) - len(filteredPods); staleCount > 0 {
+		klog.Warningf("[eviction manager] skip %d stale pods", staleCount)
+	}
+	return filteredPods
+}
+
 // filterOutCandidatePodsWithForcePods returns candidateEvictPods that are not forced to be evicted
 func filterOutCandidatePodsWithForcePods(candidateEvictPods, forceEvictPods map[string]*rule.RuledEvictPod) map[string]*rule.RuledEvictPod {
 	ret := make(map[string]*rule.RuledEvictPod)
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	"k8s.io/klog/v2"
 
@@ -31,6 +32,8 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
 	"github.com/kubewharf/katalyst-core/pkg/util/native"
 	"github.com/kubewharf/katalyst-core/pkg/util/process"
@@ -88,6 +91,7 @@ const (
 	errMsgGetContainerNumaMetrics   = "[memory-pressure-eviction-plugin] failed to get container numa metric, metric name: %s, pod uid: %s, container name: %s, numa id: %d, err: %v"
 	errMsgGetContainerSystemMetrics = "[memory-pressure-eviction-plugin] failed to get container system metric, metric name: %s, pod uid: %s, container name: %s, err: %v"
 	errMsgCheckReclaimedPodFailed   = "[memory-pressure-eviction-plugin] failed to check reclaimed pod, pod: %s/%s, err: %v"
+	errMsgGetPodSwapUsage           = "[memory-pressure-eviction-plugin] failed to get swap usage, pod: %s/%s, err: %v"
 )
 
 // MemoryPressureEvictionPlugin implements the EvictPlugin interface.
@@ -96,6 +100,7 @@ type MemoryPressureEvictionPlugin struct {
 	emitter                   metrics.MetricEmitter
 	reclaimedPodFilter        func(pod *v1.Pod) (bool, error)
 	evictionManagerSyncPeriod time.Duration
+	metricsMaxAge             time.Duration
 	pluginName                string
 	*process.StopControl
 	metaServer *metaserver.MetaServer
@@ -109,6 +114,9 @@ type MemoryPressureEvictionPlugin struct {
 	isUnderSystemPressure          bool
 	kswapdStealPreviousCycle       float64
 	systemKswapdRateExceedTimes    int
+
+	// podSwapUsageGetter returns the swap usage of a pod in bytes
+	podSwapUsageGetter func(pod *v1.Pod) (float64, error)
 }
 
 // NewMemoryPressureEvictionPlugin returns a new MemoryPressureEvictionPlugin
@@ -121,11 +129,13 @@ func NewMemoryPressureEvictionPlugin(_ *client.GenericClientSet, _ events.EventR
 		StopControl:                    process.NewStopControl(time.Time{}),
 		metaServer:                     metaServer,
 		evictionManagerSyncPeriod:      conf.EvictionManagerSyncPeriod,
+		metricsMaxAge:                  conf.MetricsMaxAge,
 		memoryEvictionPluginConfig:     conf.MemoryPressureEvictionPluginConfiguration,
 		reclaimedPodFilter:             conf.CheckReclaimedQoSForPod,
 		numaActionMap:                  make(map[int]int),
 		numaFreeBelowWatermarkTimesMap: make(map[int]int),
 	}
+	plugin.podSwapUsageGetter = plugin.getPodSwapUsageFromCgroup
 
 	return plugin
 }
@@ -399,10 +409,11 @@ func (m *MemoryPressureEvictionPlugin) detectSystemKswapdStealPressure() {
 }
 
 // getWatermarkMetrics returns system-water mark related metrics (config)
-// if numa node is specified, return config in this numa; otherwise return system-level config
+// if numa node is specified, return config in this numa; otherwise return system-level config;
+// stale metrics are regarded as failures, so that no eviction is triggered based on them
 func (m *MemoryPressureEvictionPlugin) getWatermarkMetrics(numaID int) (free, total, scaleFactor float64, err error) {
 	if numaID >= 0 {
-		free, err = m.metaServer.GetNumaMetric(numaID, consts.MetricMemFreeNuma)
+		free, err = m.metaServer.GetNumaMetricWithMaxAge(numaID, consts.MetricMemFreeNuma, m.metricsMaxAge)
 		if err != nil {
 			return 0, 0, 0, fmt.Errorf(errMsgGetNumaMetrics, consts.MetricMemFreeNuma, numaID, err)
 		}
@@ -412,7 +423,7 @@ func (m *MemoryPressureEvictionPlugin) getWatermarkMetrics(numaID int) (free, to
 				metricsTagKeyMetricName: consts.MetricMemFreeNuma,
 			})...)
 
-		total, err = m.metaServer.GetNumaMetric(numaID, consts.MetricMemTotalNuma)
+		total, err = m.metaServer.GetNumaMetricWithMaxAge(numaID, consts.MetricMemTotalNuma, m.metricsMaxAge)
 		if err != nil {
 			return 0, 0, 0, fmt.Errorf(errMsgGetNumaMetrics, consts.MetricMemTotalNuma, numaID, err)
 		}
@@ -422,7 +433,7 @@ func (m *MemoryPressureEvictionPlugin) getWatermarkMetrics(numaID int) (free, to
 				metricsTagKeyMetricName: consts.MetricMemTotalNuma,
 			})...)
 	} else {
-		free, err = m.metaServer.GetNodeMetric(consts.MetricMemFreeSystem)
+		free, err = m.metaServer.GetNodeMetricWithMaxAge(consts.MetricMemFreeSystem, m.metricsMaxAge)
 		if err != nil {
 			return 0, 0, 0, fmt.Errorf(errMsgGetSystemMetrics, consts.MetricMemFreeSystem, err)
 		}
@@ -431,7 +442,7 @@ func (m *MemoryPressureEvictionPlugin) getWatermarkMetrics(numaID int) (free, to
 				metricsTagKeyMetricName: consts.MetricMemFreeSystem,
 			})...)
 
-		total, err = m.metaServer.GetNodeMetric(consts.MetricMemTotalSystem)
+		total, err = m.metaServer.GetNodeMetricWithMaxAge(consts.MetricMemTotalSystem, m.metricsMaxAge)
 		if err != nil {
 			return 0, 0, 0, fmt.Errorf(errMsgGetSystemMetrics, consts.MetricMemTotalSystem, err)
 		}
@@ -441,7 +452,7 @@ func (m *MemoryPressureEvictionPlugin) getWatermarkMetrics(numaID int) (free, to
 			})...)
 	}
 
-	scaleFactor, err = m.metaServer.GetNodeMetric(consts.MetricMemScaleFactorSystem)
+	scaleFactor, err = m.metaServer.GetNodeMetricWithMaxAge(consts.MetricMemScaleFactorSystem, m.metricsMaxAge)
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf(errMsgGetSystemMetrics, consts.MetricMemScaleFactorSystem, err)
 	}
@@ -454,7 +465,7 @@ func (m *MemoryPressureEvictionPlugin) getWatermarkMetrics(numaID int) (free, to
 }
 
 func (m *MemoryPressureEvictionPlugin) getSystemKswapdStealMetrics() (float64, error) {
-	kswapdSteal, err := m.metaServer.GetNodeMetric(consts.MetricMemKswapdstealSystem)
+	kswapdSteal, err := m.metaServer.GetNodeMetricWithMaxAge(consts.MetricMemKswapdstealSystem, m.metricsMaxAge)
 	if err != nil {
 		return 0, fmt.Errorf("[memory-pressure-eviction-plugin] failed to get mem.kswapdsteal.system, err: %v", err)
 	}
@@ -470,7 +481,7 @@ func (m *MemoryPressureEvictionPlugin) selectPodsToEvict(activePods []*v1.Pod, t
 	action int, rankingMetrics []string, podToEvictMap map[string]*v1.Pod) {
 	filteredPods := m.filterPods(activePods, action)
 	if filteredPods != nil {
-		general.NewMultiSorter(m.getEvictionCmpFuncs(rankingMetrics, numaID)...).Sort(native.NewPodSourceImpList(filteredPods))
+		general.NewMultiSorter(m.getEvictionCmpFuncs(rankingMetrics, numaID, filteredPods)...).Sort(native.NewPodSourceImpList(filteredPods))
 		for i := 0; uint64(i) < general.MinUInt64(topN, uint64(len(filteredPods))); i++ {
 			podToEvictMap[string(filteredPods[i].UID)] = filteredPods[i]
 		}
@@ -488,10 +499,34 @@ func (m *MemoryPressureEvictionPlugin) filterPods(pods []*v1.Pod, action int) []
 	}
 }
 
-// getEvictionCmpFuncs returns a comparison function list to judge the eviction order of different pods
-func (m *MemoryPressureEvictionPlugin) getEvictionCmpFuncs(rankingMetrics []string, numaID int) []general.CmpFunc {
+// podSwapUsage records the swap usage of a pod, or the error to get it
+type podSwapUsage struct {
+	usage float64
+	err   error
+}
+
+// getEvictionCmpFuncs returns a comparison function list to judge the eviction order of the given pods;
+// metrics that need cgroup I/O (i.e. swap usage) are collected for all pods in advance rather than
+// in the comparison functions, which are called O(nlogn) times.
+func (m *MemoryPressureEvictionPlugin) getEvictionCmpFuncs(rankingMetrics []string, numaID int, pods []*v1.Pod) []general.CmpFunc {
 	cmpFuncs := make([]general.CmpFunc, 0, len(rankingMetrics))
 
+	podSwapUsages := make(map[types.UID]podSwapUsage)
+	for _, metric := range rankingMetrics {
+		if metric != evictionconfig.FakeMetricSwapUsage {
+			continue
+		}
+
+		for _, pod := range pods {
+			usage, err := m.podSwapUsageGetter(pod)
+			if err != nil {
+				klog.Errorf(errMsgGetPodSwapUsage, pod.Namespace, pod.Name, err)
+			}
+			podSwapUsages[pod.UID] = podSwapUsage{usage: usage, err: err}
+		}
+		break
+	}
+
 	for _, metric := range rankingMetrics {
 		currentMetric := metric
 		cmpFuncs = append(cmpFuncs, func(s1, s2 interface{}) int {
@@ -518,6 +553,17 @@ func (m *MemoryPressureEvictionPlugin) getEvictionCmpFuncs(rankingMetrics []stri
 			case evictionconfig.FakeMetricPriority:
 				// prioritize evicting the pod whose priority is lower
 				return general.ReverseCmpFunc(native.PodPriorityCmpFunc)(p1, p2)
+			case evictionconfig.FakeMetricSwapUsage:
+				p1Swap, ok1 := podSwapUsages[p1.UID]
+				p2Swap, ok2 := podSwapUsages[p2.UID]
+				if !ok1 || !ok2 || p1Swap.err != nil || p2Swap.err != nil {
+					// prioritize evicting the pod for which no stats were found
+					return general.CmpBool(!ok1 || p1Swap.err != nil, !ok2 || p2Swap.err != nil)
+				}
+
+				// prioritize evicting the pod which swaps more, since swap only absorbs bursts
+				// and it's the first to suffer from performance degradation
+				return general.CmpFloat64(p1Swap.usage, p2Swap.usage)
 			default:
 				p1Metric, p1Found := m.getPodMetric(p1, currentMetric, numaID)
 				p2Metric, p2Found := m.getPodMetric(p2, currentMetric, numaID)
@@ -552,7 +598,7 @@ func (m *MemoryPressureEvictionPlugin) getPodMetric(pod *v1.Pod, metricName stri
 		var containerMetricValue float64
 		var err error
 		if numaID >= 0 {
-			containerMetricValue, err = m.metaServer.GetContainerNumaMetric(string(pod.UID), container.Name, strconv.Itoa(numaID), metricName)
+			containerMetricValue, err = m.metaServer.GetContainerNumaMetricWithMaxAge(string(pod.UID), container.Name, strconv.Itoa(numaID), metricName, m.metricsMaxAge)
 			if err != nil {
 				klog.Errorf(errMsgGetContainerNumaMetrics, metricName, pod.UID, container.Name, numaID, err)
 				return 0, false
@@ -565,7 +611,7 @@ func (m *MemoryPressureEvictionPlugin) getPodMetric(pod *v1.Pod, metricName stri
 					metricsTagKeyMetricName:    metricName,
 				})...)
 		} else {
-			containerMetricValue, err = m.metaServer.GetContainerMetric(string(pod.UID), container.Name, metricName)
+			containerMetricValue, err = m.metaServer.GetContainerMetricWithMaxAge(string(pod.UID), container.Name, metricName, m.metricsMaxAge)
 			if err != nil {
 				klog.Errorf(errMsgGetContainerSystemMetrics, metricName, pod.UID, container.Name, err)
 				return 0, false
@@ -591,3 +637,37 @@ func (m *MemoryPressureEvictionPlugin) getPodMetric(pod *v1.Pod, metricName stri
 
 	return podMetricValue, true
 }
+
+// getPodSwapUsageFromCgroup returns the swap usage of a pod by summing swap usages of all containers,
+// and it's read from cgroup directly since swap usage is not provided by meta-server.
+func (m *MemoryPressureEvictionPlugin) getPodSwapUsageFromCgroup(pod *v1.Pod) (float64, error) {
+	if pod == nil {
+		return 0, fmt.Errorf("nil pod")
+	}
+
+	var podSwapUsage float64
+	for _, container := range pod.Spec.Containers {
+		containerID, err := m.metaServer.GetContainerID(string(pod.UID), container.Name)
+		if err != nil {
+			return 0, err
+		}
+
+		cgroupMetrics, err := cgroupcmutils.GetMetricsForContainer(string(pod.UID), containerID,
+			map[string]struct{}{common.CgroupSubsysMemory: {}})
+		if err != nil {
+			return 0, err
+		} else if cgroupMetrics.Memory == nil {
+			return 0, fmt.Errorf("nil memory metrics for container: %s", container.Name)
+		}
+
+		podSwapUsage += float64(cgroupMetrics.Memory.SwapUsage)
+	}
+
+	_ = m.emitter.StoreFloat64(metricsNamePodMetric, podSwapUsage, metrics.MetricTypeNameRaw,
+		metrics.ConvertMapToTags(map[string]string{
+			metricsTagKeyPodUID:     string(pod.UID),
+			metricsTagKeyMetricName: evictionconfig.FakeMetricSwapUsage,
+		})...)
+
+	return podSwapUsage, nil
+}
@@ -16,6 +16,7 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"testing"
 	"time"
@@ -23,7 +24,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	testingclock "k8s.io/utils/clock/testing"
 
 	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
 	pluginapi "github.com/kubewharf/katalyst-api/pkg/protocol/evictionplugin/v1alpha1"
@@ -113,6 +116,8 @@ func TestThresholdMet(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, plugin)
 
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	plugin.metaServer.MetricsFetcher = metric.NewFakeMetricsFetcherWithClock(metrics.DummyMetrics{}, fakeClock)
 	fakeMetricsFetcher := plugin.metaServer.MetricsFetcher.(*metric.FakeMetricsFetcher)
 	assert.NotNil(t, fakeMetricsFetcher)
 
@@ -296,6 +301,18 @@ func TestThresholdMet(t *testing.T) {
 			assert.Equal(t, tt.wantSystemAction, plugin.systemAction)
 		})
 	}
+	// no pressure should be detected based on stale metrics
+	plugin.metricsMaxAge = time.Minute
+	fakeClock.Step(2 * time.Minute)
+	metResp, err := plugin.ThresholdMet(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, pluginapi.ThresholdMetType_NOT_MET, metResp.MetType)
+	assert.False(t, plugin.isUnderNumaPressure)
+	assert.False(t, plugin.isUnderSystemPressure)
+	for _, action := range plugin.numaActionMap {
+		assert.Equal(t, actionNoop, action)
+	}
+	assert.Equal(t, actionNoop, plugin.systemAction)
 }
 
 func TestGetTopEvictionPods(t *testing.T) {
@@ -622,7 +639,7 @@ func TestMultiSorter(t *testing.T) {
 		fakeMetricsFetcher.SetContainerMetric(string(pod.UID), pod.Spec.Containers[0].Name, consts.MetricMemUsageContainer, podUsageSystem[i])
 	}
 	general.NewMultiSorter(plugin.getEvictionCmpFuncs(plugin.memoryEvictionPluginConfig.DynamicConf.SystemEvictionRankingMetrics(),
-		nonExistNumaID)...).Sort(native.NewPodSourceImpList(pods))
+		nonExistNumaID, pods)...).Sort(native.NewPodSourceImpList(pods))
 
 	wantPodNameList := []string{
 		"pod-2",
@@ -638,3 +655,51 @@ func TestMultiSorter(t *testing.T) {
 		assert.Equal(t, wantPodNameList[i], pods[i].Name)
 	}
 }
+
+func TestSwapUsageSorter(t *testing.T) {
+	plugin, err := makeMemoryPressureEvictionPlugin(makeConf())
+	assert.NoError(t, err)
+	assert.NotNil(t, plugin)
+
+	podSwapUsage := map[string]float64{
+		"000001": 1 * 1024 * 1024 * 1024,
+		"000002": 3 * 1024 * 1024 * 1024,
+		"000003": 2 * 1024 * 1024 * 1024,
+	}
+	getterCalls := 0
+	plugin.podSwapUsageGetter = func(pod *v1.Pod) (float64, error) {
+		getterCalls++
+		usage, ok := podSwapUsage[string(pod.UID)]
+		if !ok {
+			return 0, fmt.Errorf("swap usage of pod %s not found", pod.Name)
+		}
+		return usage, nil
+	}
+
+	pods := make([]*v1.Pod, 0, 4)
+	for i := 1; i <= 4; i++ {
+		pods = append(pods, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				UID:  types.UID(fmt.Sprintf("00000%d", i)),
+				Name: fmt.Sprintf("pod-%d", i),
+				Annotations: map[string]string{
+					apiconsts.PodAnnotationQoSLevelKey: apiconsts.PodAnnotationQoSLevelReclaimedCores,
+				},
+			},
+			Spec: v1.PodSpec{
+				Priority:   &lowPriority,
+				Containers: []v1.Container{{Name: "c"}},
+			},
+		})
+	}
+
+	general.NewMultiSorter(plugin.getEvictionCmpFuncs([]string{evictionconfig.FakeMetricSwapUsage},
+		nonExistNumaID, pods)...).Sort(native.NewPodSourceImpList(pods))
+
+	wantPodNameList := []string{"pod-4", "pod-2", "pod-3", "pod-1"}
+	for i := range pods {
+		assert.Equal(t, wantPodNameList[i], pods[i].Name)
+	}
+	// swap usage is collected once for each pod rather than in each comparison
+	assert.Equal(t, len(pods), getterCalls)
+}
@@ -0,0 +1,207 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
+
+	pluginapi "github.com/kubewharf/katalyst-api/pkg/protocol/evictionplugin/v1alpha1"
+	"github.com/kubewharf/katalyst-core/pkg/client"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	evictionconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/eviction"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+	"github.com/kubewharf/katalyst-core/pkg/util/native"
+	"github.com/kubewharf/katalyst-core/pkg/util/process"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+const (
+	EvictionPluginNamePIDPressure = "pid-pressure-eviction-plugin"
+	evictionScopePID              = "pid"
+
+	sysctlPIDMax = "kernel.pid_max"
+
+	metricsNamePIDUsageRatio = "pid_usage_ratio"
+)
+
+// PIDPressureEvictionPlugin implements the EvictPlugin interface.
+// It evicts reclaimed_cores pods with most pids when allocated pids of
+// the node are approaching kernel.pid_max, since new processes and threads
+// can't be created on the node after pids are exhausted.
+type PIDPressureEvictionPlugin struct {
+	*process.StopControl
+
+	pluginName         string
+	emitter            metrics.MetricEmitter
+	procFS             *procfs.ProcFS
+	reclaimedPodFilter func(pod *v1.Pod) (bool, error)
+
+	pidEvictionPluginConfig *evictionconfig.PIDPressureEvictionPluginConfiguration
+
+	// podPIDsGetter returns the number of pids in a pod
+	podPIDsGetter func(pod *v1.Pod) (uint64, error)
+}
+
+// NewPIDPressureEvictionPlugin returns a new PIDPressureEvictionPlugin
+func NewPIDPressureEvictionPlugin(_ *client.GenericClientSet, _ events.EventRecorder,
+	_ *metaserver.MetaServer, emitter metrics.MetricEmitter, conf *config.Configuration) EvictionPlugin {
+	return &PIDPressureEvictionPlugin{
+		StopControl:             process.NewStopControl(time.Time{}),
+		pluginName:              EvictionPluginNamePIDPressure,
+		emitter:                 emitter,
+		procFS:                  procfs.NewDefaultProcFS(),
+		reclaimedPodFilter:      conf.CheckReclaimedQoSForPod,
+		pidEvictionPluginConfig: conf.PIDPressureEvictionPluginConfiguration,
+		podPIDsGetter:           getPodPIDsFromCgroup,
+	}
+}
+
+// Name returns the name of PIDPressureEvictionPlugin
+func (p *PIDPressureEvictionPlugin) Name() string {
+	if p == nil {
+		return ""
+	}
+
+	return p.pluginName
+}
+
+// ThresholdMet determines whether to evict pods based on the ratio of allocated pids
+func (p *PIDPressureEvictionPlugin) ThresholdMet(_ context.Context) (*pluginapi.ThresholdMetResponse, error) {
+	pidMax, err := p.procFS.ReadSysctlInt(sysctlPIDMax)
+	if err != nil {
+		return nil, fmt.Errorf("read %s failed with error: %v", sysctlPIDMax, err)
+	} else if pidMax <= 0 {
+		return nil, fmt.Errorf("invalid %s: %d", sysctlPIDMax, pidMax)
+	}
+
+	threads, err := p.procFS.ReadThreadCount()
+	if err != nil {
+		return nil, fmt.Errorf("read thread count failed with error: %v", err)
+	}
+
+	ratio := float64(threads) / float64(pidMax)
+	_ = p.emitter.StoreFloat64(metricsNamePIDUsageRatio, ratio, metrics.MetricTypeNameRaw)
+
+	threshold := p.pidEvictionPluginConfig.PIDPressureEvictionThreshold
+	if ratio <= threshold {
+		return &pluginapi.ThresholdMetResponse{
+			MetType: pluginapi.ThresholdMetType_NOT_MET,
+		}, nil
+	}
+
+	klog.Infof("[pid-pressure-eviction-plugin] ThresholdMet, threads: %d, pid_max: %d, ratio: %.4f, threshold: %.4f",
+		threads, pidMax, ratio, threshold)
+	_ = p.emitter.StoreInt64(metricsNameThresholdMet, 1, metrics.MetricTypeNameCount,
+		metrics.MetricTag{Key: metricsTagKeyEvictionScope, Val: evictionScopePID})
+
+	return &pluginapi.ThresholdMetResponse{
+		ThresholdValue:    threshold,
+		ObservedValue:     ratio,
+		ThresholdOperator: pluginapi.ThresholdOperator_GREATER_THAN,
+		MetType:           pluginapi.ThresholdMetType_HARD_MET,
+		EvictionScope:     evictionScopePID,
+	}, nil
+}
+
+// GetTopEvictionPods gets topN reclaimed_cores pods with most pids to evict
+func (p *PIDPressureEvictionPlugin) GetTopEvictionPods(_ context.Context, request *pluginapi.GetTopEvictionPodsRequest) (*pluginapi.GetTopEvictionPodsResponse, error) {
+	if request == nil {
+		return nil, fmt.Errorf("GetTopEvictionPods got nil request")
+	}
+
+	if len(request.ActivePods) == 0 || request.TopN == 0 {
+		return &pluginapi.GetTopEvictionPodsResponse{}, nil
+	}
+
+	podPIDs := make(map[string]uint64)
+	candidates := make([]*v1.Pod, 0, len(request.ActivePods))
+	for _, pod := range request.ActivePods {
+		if pod == nil {
+			continue
+		}
+
+		if isReclaimed, err := p.reclaimedPodFilter(pod); err != nil {
+			klog.Errorf("[pid-pressure-eviction-plugin] check reclaimed pod: %s/%s failed with error: %v",
+				pod.Namespace, pod.Name, err)
+			continue
+		} else if !isReclaimed {
+			continue
+		}
+
+		pids, err := p.podPIDsGetter(pod)
+		if err != nil {
+			klog.Errorf("[pid-pressure-eviction-plugin] get pids of pod: %s/%s failed with error: %v",
+				pod.Namespace, pod.Name, err)
+			continue
+		}
+
+		podPIDs[string(pod.UID)] = pids
+		candidates = append(candidates, pod)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return podPIDs[string(candidates[i].UID)] > podPIDs[string(candidates[j].UID)]
+	})
+
+	if uint64(len(candidates)) > request.TopN {
+		candidates = candidates[:request.TopN]
+	}
+
+	_ = p.emitter.StoreInt64(metricsNameNumberOfTargetPods, int64(len(candidates)), metrics.MetricTypeNameRaw,
+		metrics.MetricTag{Key: metricsTagKeyEvictionScope, Val: evictionScopePID})
+	klog.Infof("[pid-pressure-eviction-plugin] GetTopEvictionPods result, targetPods: %+v",
+		native.GetNamespacedNameListFromSlice(candidates))
+
+	resp := &pluginapi.GetTopEvictionPodsResponse{
+		TargetPods: candidates,
+	}
+	if gracePeriod := p.pidEvictionPluginConfig.PIDPressureEvictionPodGracePeriodSeconds; gracePeriod > 0 {
+		resp.DeletionOptions = &pluginapi.DeletionOptions{
+			GracePeriodSeconds: gracePeriod,
+		}
+	}
+
+	return resp, nil
+}
+
+func (p *PIDPressureEvictionPlugin) GetEvictPods(_ context.Context, request *pluginapi.GetEvictPodsRequest) (*pluginapi.GetEvictPodsResponse, error) {
+	if request == nil {
+		return nil, fmt.Errorf("GetEvictPods got nil request")
+	}
+
+	return &pluginapi.GetEvictPodsResponse{}, nil
+}
+
+// getPodPIDsFromCgroup returns pids.current of the pod-level cgroup
+func getPodPIDsFromCgroup(pod *v1.Pod) (uint64, error) {
+	if pod == nil {
+		return 0, fmt.Errorf("nil pod")
+	}
+
+	stats, err := cgroupcmutils.GetPidsStatsForPod(string(pod.UID))
+	if err != nil {
+		return 0, err
+	}
+	return stats.Current, nil
+}
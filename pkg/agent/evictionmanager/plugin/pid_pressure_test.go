@@ -0,0 +1,112 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	pluginapi "github.com/kubewharf/katalyst-api/pkg/protocol/evictionplugin/v1alpha1"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+func makePIDPressureEvictionPlugin(t *testing.T, pidMax, threads string, podPIDs map[types.UID]uint64) *PIDPressureEvictionPlugin {
+	root, err := ioutil.TempDir("", "pid-pressure")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(root) })
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys", "kernel"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "sys", "kernel", "pid_max"), []byte(pidMax+"\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "loadavg"), []byte("0.10 0.20 0.30 1/"+threads+" 12345\n"), 0o644))
+
+	conf := config.NewConfiguration()
+	conf.PIDPressureEvictionThreshold = 0.8
+	conf.PIDPressureEvictionPodGracePeriodSeconds = 30
+
+	plugin := NewPIDPressureEvictionPlugin(nil, nil, nil, metrics.DummyMetrics{}, conf).(*PIDPressureEvictionPlugin)
+	plugin.procFS = procfs.NewProcFS(root)
+	plugin.podPIDsGetter = func(pod *v1.Pod) (uint64, error) {
+		return podPIDs[pod.UID], nil
+	}
+	return plugin
+}
+
+func makePIDPressureTestPod(uid, name, qosLevel string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:  types.UID(uid),
+			Name: name,
+			Annotations: map[string]string{
+				apiconsts.PodAnnotationQoSLevelKey: qosLevel,
+			},
+		},
+	}
+}
+
+func TestPIDPressureEvictionPlugin_ThresholdMet(t *testing.T) {
+	t.Parallel()
+
+	plugin := makePIDPressureEvictionPlugin(t, "10000", "7000", nil)
+	resp, err := plugin.ThresholdMet(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, pluginapi.ThresholdMetType_NOT_MET, resp.MetType)
+
+	plugin = makePIDPressureEvictionPlugin(t, "10000", "9000", nil)
+	resp, err = plugin.ThresholdMet(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, pluginapi.ThresholdMetType_HARD_MET, resp.MetType)
+	assert.Equal(t, evictionScopePID, resp.EvictionScope)
+	assert.InDelta(t, 0.9, resp.ObservedValue, 1e-6)
+}
+
+func TestPIDPressureEvictionPlugin_GetTopEvictionPods(t *testing.T) {
+	t.Parallel()
+
+	pods := []*v1.Pod{
+		makePIDPressureTestPod("pod-1", "reclaimed-small", apiconsts.PodAnnotationQoSLevelReclaimedCores),
+		makePIDPressureTestPod("pod-2", "shared-large", apiconsts.PodAnnotationQoSLevelSharedCores),
+		makePIDPressureTestPod("pod-3", "reclaimed-large", apiconsts.PodAnnotationQoSLevelReclaimedCores),
+		makePIDPressureTestPod("pod-4", "reclaimed-medium", apiconsts.PodAnnotationQoSLevelReclaimedCores),
+	}
+	plugin := makePIDPressureEvictionPlugin(t, "10000", "9000", map[types.UID]uint64{
+		"pod-1": 10,
+		"pod-2": 5000,
+		"pod-3": 2000,
+		"pod-4": 500,
+	})
+
+	resp, err := plugin.GetTopEvictionPods(context.TODO(), &pluginapi.GetTopEvictionPodsRequest{
+		ActivePods: pods,
+		TopN:       2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*v1.Pod{pods[2], pods[3]}, resp.TargetPods)
+	assert.Equal(t, int64(30), resp.DeletionOptions.GracePeriodSeconds)
+
+	_, err = plugin.GetTopEvictionPods(context.TODO(), nil)
+	assert.Error(t, err)
+}
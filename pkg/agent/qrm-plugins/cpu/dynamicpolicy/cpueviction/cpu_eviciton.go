@@ -18,6 +18,7 @@ package cpueviction
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -39,6 +40,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	utilmetric "github.com/kubewharf/katalyst-core/pkg/util/metric"
 	"github.com/kubewharf/katalyst-core/pkg/util/native"
 )
 
@@ -112,6 +114,7 @@ type cpuPressureEvictionPlugin struct {
 	syncPeriod                               time.Duration
 	evictionColdPeriod                       time.Duration
 	lastEvictionTime                         time.Time
+	metricsMaxAge                            time.Duration
 
 	sync.Mutex
 }
@@ -134,6 +137,7 @@ func newCPUPressureEvictionPlugin(emitter metrics.MetricEmitter, metaServer *met
 		minCPUSuppressionToleranceDuration:       conf.MinCPUSuppressionToleranceDuration,
 		syncPeriod:                               conf.CPUPressureEvictionSyncPeriod,
 		evictionColdPeriod:                       conf.CPUPressureEvictionColdPeriod,
+		metricsMaxAge:                            conf.MetricsMaxAge,
 	}
 
 	plugin.poolMetricCollectHandlers = map[string]PoolMetricCollectHandler{
@@ -206,8 +210,10 @@ func (p *cpuPressureEvictionPlugin) collectMetrics(ctx context.Context) {
 
 	collectTime := time.Now().UnixNano()
 
-	// handle containers
+	// handle containers, and pool metrics are not collected if metrics of
+	// any container in the pool are stale, since the sum would be underestimated
 	poolsMetric := make(map[string]map[string]float64)
+	stalePoolsMetric := make(map[string]sets.String)
 	for podUID, entry := range entries {
 		if entry.IsPoolEntry() {
 			continue
@@ -225,10 +231,16 @@ func (p *cpuPressureEvictionPlugin) collectMetrics(ctx context.Context) {
 			poolName := containerEntry.OwnerPoolName
 
 			for _, metricName := range handleMetrics.UnsortedList() {
-				value, err := p.metaServer.GetContainerMetric(podUID, containerName, metricName)
+				value, err := p.metaServer.GetContainerMetricWithMaxAge(podUID, containerName, metricName, p.metricsMaxAge)
 				if err != nil {
 					klog.Errorf("[cpu-pressure-eviction-plugin.collectMetrics] GetContainerMetric for pod: %s, "+
 						"container: %s failed with error: %v", podUID, containerName, err)
+					if errors.Is(err, utilmetric.ErrMetricDataStale) {
+						if stalePoolsMetric[poolName] == nil {
+							stalePoolsMetric[poolName] = sets.NewString()
+						}
+						stalePoolsMetric[poolName].Insert(metricName)
+					}
 					continue
 				}
 
@@ -270,6 +282,10 @@ func (p *cpuPressureEvictionPlugin) collectMetrics(ctx context.Context) {
 
 			if _, found := poolsMetric[poolName][metricName]; !found {
 				continue
+			} else if stalePoolsMetric[poolName].Has(metricName) {
+				klog.Warningf("[cpu-pressure-eviction-plugin.collectMetrics] skip collecting metric: %s for pool: %s "+
+					"with stale container metrics", metricName, poolName)
+				continue
 			}
 
 			handler(metricName, poolsMetric[poolName][metricName], poolEntry, collectTime)
@@ -2382,6 +2382,132 @@ func machineStateMatch(state1, state2 state.NUMANodeMap) (bool, error) {
 	return true, nil
 }
 
+func TestAllocateByQoSAwareServerListAndWatchRespWithLentDedicatedCPUs(t *testing.T) {
+	as := require.New(t)
+	cpuTopology, err := machine.GenerateDummyCPUTopology(16, 2, 2)
+	as.Nil(err)
+
+	tmpDir, err := ioutil.TempDir("", "checkpoint")
+	as.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	dynamicPolicy, err := getTestDynamicPolicyWithInitialization(cpuTopology, tmpDir)
+	as.Nil(err)
+	dynamicPolicy.reclaimedResourceConfig.SetEnableReclaim(true)
+	as.Nil(dynamicPolicy.initReservePool())
+
+	// dedicated_cores container without numa binding takes 4 cpus on each numa
+	podUID, testName := string(uuid.NewUUID()), "test"
+	available := cpuTopology.CPUDetails.CPUs().Difference(dynamicPolicy.reservedCPUs)
+	assignments := map[int]machine.CPUSet{
+		0: machine.NewCPUSet(available.Intersection(cpuTopology.CPUDetails.CPUsInNUMANodes(0)).ToSliceInt()[:4]...),
+		1: machine.NewCPUSet(available.Intersection(cpuTopology.CPUDetails.CPUsInNUMANodes(1)).ToSliceInt()[:4]...),
+	}
+	dedicatedCPUs := assignments[0].Union(assignments[1])
+	dynamicPolicy.state.SetAllocationInfo(podUID, testName, &state.AllocationInfo{
+		PodUid:                           podUID,
+		PodNamespace:                     testName,
+		PodName:                          testName,
+		ContainerName:                    testName,
+		ContainerType:                    pluginapi.ContainerType_MAIN.String(),
+		OwnerPoolName:                    state.PoolNameDedicated,
+		AllocationResult:                 dedicatedCPUs.Clone(),
+		OriginalAllocationResult:         dedicatedCPUs.Clone(),
+		TopologyAwareAssignments:         util.DeepCopyTopologyAwareAssignments(assignments),
+		OriginalTopologyAwareAssignments: util.DeepCopyTopologyAwareAssignments(assignments),
+		Labels: map[string]string{
+			consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelDedicatedCores,
+		},
+		Annotations: map[string]string{
+			consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelDedicatedCores,
+		},
+		QoSLevel:        consts.PodAnnotationQoSLevelDedicatedCores,
+		RequestQuantity: 8,
+	})
+
+	// one cpu on numa 0 is lent to reclaim pool, which is shared by reclaim pool and the container
+	lwResp := &advisorapi.ListAndWatchResponse{
+		Entries: map[string]*advisorapi.CalculationEntries{
+			state.PoolNameReserve: {
+				Entries: map[string]*advisorapi.CalculationInfo{
+					"": {
+						OwnerPoolName: state.PoolNameReserve,
+						CalculationResultsByNumas: map[int64]*advisorapi.NumaCalculationResult{
+							-1: {Blocks: []*advisorapi.Block{{Result: uint64(dynamicPolicy.reservedCPUs.Size()), BlockId: "reserve"}}},
+						},
+					},
+				},
+			},
+			state.PoolNameReclaim: {
+				Entries: map[string]*advisorapi.CalculationInfo{
+					"": {
+						OwnerPoolName: state.PoolNameReclaim,
+						CalculationResultsByNumas: map[int64]*advisorapi.NumaCalculationResult{
+							-1: {Blocks: []*advisorapi.Block{{Result: 4, BlockId: "reclaim"}}},
+							0: {
+								Blocks: []*advisorapi.Block{
+									{
+										Result:  1,
+										BlockId: "lent",
+										OverlapTargets: []*advisorapi.OverlapTarget{
+											{
+												OverlapTargetPodUid:        podUID,
+												OverlapTargetContainerName: testName,
+												OverlapType:                advisorapi.OverlapType_OverlapWithPod,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			podUID: {
+				Entries: map[string]*advisorapi.CalculationInfo{
+					testName: {
+						OwnerPoolName: state.PoolNameDedicated,
+						CalculationResultsByNumas: map[int64]*advisorapi.NumaCalculationResult{
+							0: {
+								Blocks: []*advisorapi.Block{
+									{
+										Result:  1,
+										BlockId: "lent",
+										OverlapTargets: []*advisorapi.OverlapTarget{
+											{
+												OverlapTargetPoolName: state.PoolNameReclaim,
+												OverlapType:           advisorapi.OverlapType_OverlapWithPool,
+											},
+										},
+									},
+									{Result: 3, BlockId: "dedicated-0"},
+								},
+							},
+							1: {Blocks: []*advisorapi.Block{{Result: 4, BlockId: "dedicated-1"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+	as.Nil(dynamicPolicy.allocateByCPUAdvisorServerListAndWatchResp(lwResp))
+
+	dedicatedInfo := dynamicPolicy.state.GetAllocationInfo(podUID, testName)
+	as.NotNil(dedicatedInfo)
+	as.Equal(8, dedicatedInfo.AllocationResult.Size())
+	as.Equal(4, dedicatedInfo.TopologyAwareAssignments[0].Size())
+	as.Equal(4, dedicatedInfo.TopologyAwareAssignments[1].Size())
+
+	reclaimInfo := dynamicPolicy.state.GetAllocationInfo(state.PoolNameReclaim, "")
+	as.NotNil(reclaimInfo)
+	as.Equal(5, reclaimInfo.AllocationResult.Size())
+	as.True(reclaimInfo.AllocationResult.Intersection(dynamicPolicy.reservedCPUs).IsEmpty())
+
+	lentCPUs := reclaimInfo.AllocationResult.Intersection(dedicatedInfo.AllocationResult)
+	as.Equal(1, lentCPUs.Size())
+	as.True(lentCPUs.IsSubsetOf(cpuTopology.CPUDetails.CPUsInNUMANodes(0)))
+}
+
 func entriesMatch(entries1, entries2 state.PodEntries) (bool, error) {
 	if len(entries1) != len(entries2) {
 		return false, nil
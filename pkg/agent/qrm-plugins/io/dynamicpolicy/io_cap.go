@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+const (
+	metricNameIODeviceUtil      = "io_device_util"
+	metricNameIOReclaimedCapBps = "io_reclaimed_cap_bps"
+)
+
+// adjustReclaimedIOCap caps io throughput of all reclaimed_cores containers on each throttled
+// device, and the cap is adjusted in an AIMD way: it's halved if utilization of the device
+// exceeds the threshold, otherwise it grows by a tenth of the range between min and max cap.
+// utilization is the percentage of busy time measured from io ticks in /proc/diskstats.
+func (p *DynamicPolicy) adjustReclaimedIOCap() {
+	diskStats, err := p.procFS.ReadDiskStats()
+	if err != nil {
+		klog.Errorf("[IODynamicPolicy.adjustReclaimedIOCap] read disk stats failed, err: %v", err)
+		return
+	}
+	now := time.Now()
+
+	lastDiskStats, lastDiskStatsTime := p.lastDiskStats, p.lastDiskStatsTime
+	p.lastDiskStats, p.lastDiskStatsTime = diskStats, now
+	if lastDiskStats == nil {
+		return
+	}
+
+	ioData := &common.IOData{DeviceLimits: make(map[string]*common.IODeviceLimit)}
+	for device, deviceNumber := range getDeviceNumbers(diskStats, p.throttleDevices) {
+		util, ok := getDeviceUtil(lastDiskStats[device], diskStats[device], now.Sub(lastDiskStatsTime))
+		if !ok {
+			continue
+		}
+
+		ioCap := adjustIOCap(p.reclaimedIOCaps[device], util, p.dynamicIOCapConf)
+		p.reclaimedIOCaps[device] = ioCap
+		ioData.DeviceLimits[deviceNumber] = &common.IODeviceLimit{
+			ReadBps:  ioCap,
+			WriteBps: ioCap,
+		}
+
+		tags := metrics.ConvertMapToTags(map[string]string{"device": device})
+		_ = p.emitter.StoreFloat64(metricNameIODeviceUtil, util, metrics.MetricTypeNameRaw, tags...)
+		_ = p.emitter.StoreInt64(metricNameIOReclaimedCapBps, int64(ioCap), metrics.MetricTypeNameRaw, tags...)
+	}
+
+	if len(ioData.DeviceLimits) == 0 {
+		return
+	}
+
+	absCgroupPath := common.GetAbsCgroupPath(common.CgroupSubsysBlkIO, p.reclaimRelativeRootCgroupPath)
+	if err := p.applyReclaimedIOFunc(absCgroupPath, ioData); err != nil {
+		klog.Errorf("[IODynamicPolicy.adjustReclaimedIOCap] apply io cap to %s failed, err: %v", absCgroupPath, err)
+		return
+	}
+	klog.V(4).Infof("[IODynamicPolicy.adjustReclaimedIOCap] apply io cap to %s successfully, caps: %+v", absCgroupPath, p.reclaimedIOCaps)
+}
+
+// getDeviceUtil returns the percentage of time the device has been busy during the interval
+func getDeviceUtil(last, current *procfs.DiskStat, interval time.Duration) (float64, bool) {
+	if last == nil || current == nil || interval <= 0 || current.IOTicks < last.IOTicks {
+		return 0, false
+	}
+
+	util := float64(current.IOTicks-last.IOTicks) * 100 / float64(interval.Milliseconds())
+	if util > 100 {
+		util = 100
+	}
+	return util, true
+}
+
+// adjustIOCap returns the next cap according to utilization of the device,
+// and the cap starts from the max cap if it hasn't been set yet
+func adjustIOCap(current uint64, util float64, conf qrmconfig.DynamicIOCapConfig) uint64 {
+	if current == 0 || current > conf.ReclaimedIOCapMaxBps {
+		current = conf.ReclaimedIOCapMaxBps
+	}
+
+	if util > conf.IOUtilThreshold {
+		next := current / 2
+		if next < conf.ReclaimedIOCapMinBps {
+			next = conf.ReclaimedIOCapMinBps
+		}
+		return next
+	}
+
+	var step uint64
+	if conf.ReclaimedIOCapMaxBps > conf.ReclaimedIOCapMinBps {
+		step = (conf.ReclaimedIOCapMaxBps - conf.ReclaimedIOCapMinBps) / 10
+	}
+	if current+step > conf.ReclaimedIOCapMaxBps {
+		return conf.ReclaimedIOCapMaxBps
+	}
+	return current + step
+}
@@ -0,0 +1,431 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	pluginapi "k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-api/pkg/plugins/skeleton"
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/agent"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/util"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+	"github.com/kubewharf/katalyst-core/pkg/config/generic"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/native"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+const (
+	// IOResourcePluginPolicyNameDynamic is the policy name of dynamic io resource plugin
+	IOResourcePluginPolicyNameDynamic = "dynamic"
+	// ResourceNameIO is the resource name of disk io
+	ResourceNameIO = "io"
+)
+
+// DynamicPolicy is the dynamic io policy
+type DynamicPolicy struct {
+	sync.RWMutex
+
+	name       string
+	stopCh     chan struct{}
+	started    bool
+	qosConfig  *generic.QoSConfiguration
+	emitter    metrics.MetricEmitter
+	metaServer *metaserver.MetaServer
+	procFS     *procfs.ProcFS
+
+	ioWeightMap             map[string]uint64
+	podLevelIOWeightAnnoKey string
+	podLevelIOMaxAnnoKey    string
+	throttleDevices         []string
+	applyIOFunc             func(podUID, containerId string, data *common.IOData) error
+
+	// io throughput of all reclaimed_cores containers is capped at the root cgroup of
+	// reclaimed_cores, and the cap of each device is adjusted by its utilization
+	dynamicIOCapConf              qrmconfig.DynamicIOCapConfig
+	reclaimRelativeRootCgroupPath string
+	applyReclaimedIOFunc          func(absCgroupPath string, data *common.IOData) error
+	lastDiskStats                 map[string]*procfs.DiskStat
+	lastDiskStatsTime             time.Time
+	reclaimedIOCaps               map[string]uint64
+}
+
+// NewDynamicPolicy returns a dynamic io policy
+func NewDynamicPolicy(agentCtx *agent.GenericContext, conf *config.Configuration, _ interface{}, agentName string) (bool, agent.Component, error) {
+	wrappedEmitter := agentCtx.EmitterPool.GetDefaultMetricsEmitter().WithTags(agentName, metrics.MetricTag{
+		Key: util.QRMPluginPolicyTagName,
+		Val: IOResourcePluginPolicyNameDynamic,
+	})
+
+	policyImplement := &DynamicPolicy{
+		qosConfig:                     conf.QoSConfiguration,
+		emitter:                       wrappedEmitter,
+		metaServer:                    agentCtx.MetaServer,
+		procFS:                        procfs.NewDefaultProcFS(),
+		stopCh:                        make(chan struct{}),
+		name:                          fmt.Sprintf("%s_%s", agentName, IOResourcePluginPolicyNameDynamic),
+		ioWeightMap:                   make(map[string]uint64),
+		podLevelIOWeightAnnoKey:       conf.PodLevelIOWeightAnnoKey,
+		podLevelIOMaxAnnoKey:          conf.PodLevelIOMaxAnnoKey,
+		throttleDevices:               conf.IOThrottleDevices,
+		applyIOFunc:                   cgroupcmutils.ApplyIOForContainer,
+		dynamicIOCapConf:              conf.DynamicIOCapConfig,
+		reclaimRelativeRootCgroupPath: conf.ReclaimRelativeRootCgroupPath,
+		applyReclaimedIOFunc:          cgroupcmutils.ApplyIOWithAbsolutePath,
+		reclaimedIOCaps:               make(map[string]uint64),
+	}
+
+	policyImplement.ioWeightMap[apiconsts.PodAnnotationQoSLevelReclaimedCores] = conf.IOWeight.ReclaimedCores
+	policyImplement.ioWeightMap[apiconsts.PodAnnotationQoSLevelSharedCores] = conf.IOWeight.SharedCores
+	policyImplement.ioWeightMap[apiconsts.PodAnnotationQoSLevelDedicatedCores] = conf.IOWeight.DedicatedCores
+	policyImplement.ioWeightMap[apiconsts.PodAnnotationQoSLevelSystemCores] = conf.IOWeight.SystemCores
+
+	klog.Infof("[io-resource-plugin] apply configs, "+
+		"ioWeightMap: %+v, "+
+		"podLevelIOWeightAnnoKey: %s, "+
+		"podLevelIOMaxAnnoKey: %s, "+
+		"throttleDevices: %+v, "+
+		"dynamicIOCapConf: %+v",
+		policyImplement.ioWeightMap,
+		policyImplement.podLevelIOWeightAnnoKey,
+		policyImplement.podLevelIOMaxAnnoKey,
+		policyImplement.throttleDevices,
+		policyImplement.dynamicIOCapConf)
+
+	pluginWrapper, err := skeleton.NewRegistrationPluginWrapper(
+		policyImplement,
+		conf.QRMPluginSocketDirs, nil)
+	if err != nil {
+		return false, agent.ComponentStub{}, fmt.Errorf("dynamic policy new plugin wrapper failed with error: %v", err)
+	}
+
+	return true, &agent.PluginWrapper{GenericPlugin: pluginWrapper}, nil
+}
+
+// Start starts this plugin
+func (p *DynamicPolicy) Start() (err error) {
+	klog.Infof("IODynamicPolicy start called")
+
+	p.Lock()
+
+	defer func() {
+		if err == nil {
+			p.started = true
+		}
+
+		p.Unlock()
+	}()
+
+	if p.started {
+		klog.Infof("[IODynamicPolicy.Start] DynamicPolicy is already started")
+		return nil
+	}
+
+	p.stopCh = make(chan struct{})
+
+	go wait.Until(func() {
+		_ = p.emitter.StoreInt64(util.MetricNameHeartBeat, 1, metrics.MetricTypeNameRaw)
+	}, time.Second*30, p.stopCh)
+
+	go wait.Until(p.applyIO, 5*time.Second, p.stopCh)
+
+	if p.dynamicIOCapConf.EnableDynamicIOCap {
+		go wait.Until(p.adjustReclaimedIOCap, p.dynamicIOCapConf.DynamicIOCapPeriod, p.stopCh)
+	}
+
+	return nil
+}
+
+// Stop stops this plugin
+func (p *DynamicPolicy) Stop() error {
+	p.Lock()
+	defer func() {
+		p.started = false
+		p.Unlock()
+
+		klog.Infof("[IODynamicPolicy.Stop] DynamicPolicy stopped")
+	}()
+
+	if !p.started {
+		klog.Warningf("[IODynamicPolicy.Stop] DynamicPolicy already stopped")
+		return nil
+	}
+	close(p.stopCh)
+	return nil
+}
+
+// Name returns the name of this plugin
+func (p *DynamicPolicy) Name() string {
+	return p.name
+}
+
+// ResourceName returns resource names managed by this plugin
+func (p *DynamicPolicy) ResourceName() string {
+	return ResourceNameIO
+}
+
+// GetTopologyHints returns hints of corresponding resources
+func (p *DynamicPolicy) GetTopologyHints(ctx context.Context, req *pluginapi.ResourceRequest) (*pluginapi.ResourceHintsResponse, error) {
+	return &pluginapi.ResourceHintsResponse{}, nil
+}
+
+// RemovePod is called when pod is deleted, and io settings are removed along with its cgroups
+func (p *DynamicPolicy) RemovePod(ctx context.Context, req *pluginapi.RemovePodRequest) (*pluginapi.RemovePodResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("RemovePod got nil req")
+	}
+
+	return &pluginapi.RemovePodResponse{}, nil
+}
+
+// GetResourcesAllocation returns allocation results of corresponding resources
+func (p *DynamicPolicy) GetResourcesAllocation(ctx context.Context, req *pluginapi.GetResourcesAllocationRequest) (*pluginapi.GetResourcesAllocationResponse, error) {
+	return &pluginapi.GetResourcesAllocationResponse{}, nil
+}
+
+// GetTopologyAwareResources returns allocation results of corresponding resources as topology aware format
+func (p *DynamicPolicy) GetTopologyAwareResources(ctx context.Context, req *pluginapi.GetTopologyAwareResourcesRequest) (*pluginapi.GetTopologyAwareResourcesResponse, error) {
+	return &pluginapi.GetTopologyAwareResourcesResponse{}, nil
+}
+
+// GetTopologyAwareAllocatableResources returns corresponding allocatable resources as topology aware format
+func (p *DynamicPolicy) GetTopologyAwareAllocatableResources(ctx context.Context, req *pluginapi.GetTopologyAwareAllocatableResourcesRequest) (*pluginapi.GetTopologyAwareAllocatableResourcesResponse, error) {
+	return &pluginapi.GetTopologyAwareAllocatableResourcesResponse{}, nil
+}
+
+// GetResourcePluginOptions returns options to be communicated with Resource Manager
+func (p *DynamicPolicy) GetResourcePluginOptions(context.Context, *pluginapi.Empty) (*pluginapi.ResourcePluginOptions, error) {
+	return &pluginapi.ResourcePluginOptions{
+		PreStartRequired:      false,
+		WithTopologyAlignment: false,
+		NeedReconcile:         false,
+	}, nil
+}
+
+// Allocate is called during pod admit so that the resource
+// plugin can allocate corresponding resource for the container
+// according to resource request
+func (p *DynamicPolicy) Allocate(ctx context.Context, req *pluginapi.ResourceRequest) (resp *pluginapi.ResourceAllocationResponse, respErr error) {
+	if req == nil {
+		return nil, fmt.Errorf("Allocate got nil req")
+	}
+
+	return &pluginapi.ResourceAllocationResponse{
+		PodUid:         req.PodUid,
+		PodNamespace:   req.PodNamespace,
+		PodName:        req.PodName,
+		ContainerName:  req.ContainerName,
+		ContainerType:  req.ContainerType,
+		ContainerIndex: req.ContainerIndex,
+		PodRole:        req.PodRole,
+		PodType:        req.PodType,
+		ResourceName:   ResourceNameIO,
+		AllocationResult: &pluginapi.ResourceAllocation{
+			ResourceAllocation: map[string]*pluginapi.ResourceAllocationInfo{
+				ResourceNameIO: {},
+			},
+		},
+		Labels:      general.DeepCopyMap(req.Labels),
+		Annotations: general.DeepCopyMap(req.Annotations),
+	}, nil
+}
+
+// PreStartContainer is called, if indicated by resource plugin during registeration phase,
+// before each container start. Resource plugin can run resource specific operations
+// such as resetting the resource before making resources available to the container
+func (p *DynamicPolicy) PreStartContainer(context.Context, *pluginapi.PreStartContainerRequest) (*pluginapi.PreStartContainerResponse, error) {
+	return &pluginapi.PreStartContainerResponse{}, nil
+}
+
+// applyIO sets io weight and io throughput limits of all containers according to
+// their QoS levels and pod-level annotations
+func (p *DynamicPolicy) applyIO() {
+	if p.metaServer == nil {
+		klog.Errorf("[IODynamicPolicy.applyIO] nil metaServer")
+		return
+	}
+	ctx := context.Background()
+	podList, err := p.metaServer.GetPodList(ctx, nil)
+	if err != nil {
+		klog.Errorf("[IODynamicPolicy.applyIO] get pod list failed, err: %v", err)
+		return
+	}
+
+	var deviceNumbers map[string]string
+	if len(p.throttleDevices) > 0 {
+		diskStats, err := p.procFS.ReadDiskStats()
+		if err != nil {
+			klog.Errorf("[IODynamicPolicy.applyIO] read disk stats failed, err: %v", err)
+		} else {
+			deviceNumbers = getDeviceNumbers(diskStats, p.throttleDevices)
+		}
+	}
+
+	for _, pod := range podList {
+		ioData, err := p.getIOData(pod, deviceNumbers)
+		if err != nil {
+			klog.Errorf("[IODynamicPolicy.applyIO] get io data failed, pod: %s, err: %s", native.GenerateUniqObjectNameKey(pod), err)
+			continue
+		}
+
+		for _, container := range pod.Spec.Containers {
+			go func(podUID, containerName string, ioData *common.IOData) {
+				containerID, err := p.metaServer.GetContainerID(podUID, containerName)
+				if err != nil {
+					klog.Errorf("[IODynamicPolicy.applyIO] get container id failed, pod: %s, container: %s(%s), err: %v",
+						podUID, containerName, containerID, err)
+					return
+				}
+
+				exist, err := common.IsContainerCgroupExist(podUID, containerID)
+				if err != nil {
+					klog.Errorf("[IODynamicPolicy.applyIO] check if container cgroup exists failed, pod: %s, container: %s(%s), err: %v",
+						podUID, containerName, containerID, err)
+					return
+				}
+				if !exist {
+					klog.Infof("[IODynamicPolicy.applyIO] container cgroup does not exist, pod: %s, container: %s(%s)", podUID, containerName, containerID)
+					return
+				}
+
+				err = p.applyIOFunc(podUID, containerID, ioData)
+				if err != nil {
+					klog.Errorf("[IODynamicPolicy.applyIO] apply io failed, pod: %s, container: %s(%s), ioData: %+v, err: %v",
+						podUID, containerName, containerID, *ioData, err)
+					return
+				}
+
+				klog.V(4).Infof("[IODynamicPolicy.applyIO] apply io successfully, pod: %s, container: %s(%s), ioData: %+v", podUID, containerName, containerID, *ioData)
+			}(string(pod.UID), container.Name, ioData)
+		}
+	}
+}
+
+// getIOData returns io weight and io throughput limits for containers in the pod,
+// and pod-level annotations take precedence over QoS level
+func (p *DynamicPolicy) getIOData(pod *v1.Pod, deviceNumbers map[string]string) (*common.IOData, error) {
+	ioData := &common.IOData{}
+
+	if weightStr, ok := pod.Annotations[p.podLevelIOWeightAnnoKey]; ok {
+		weight, err := strconv.ParseUint(weightStr, 10, 64)
+		if err != nil || weight < common.IOWeightMin || weight > common.IOWeightMax {
+			return nil, fmt.Errorf("invalid io weight %q in annotation %s", weightStr, p.podLevelIOWeightAnnoKey)
+		}
+		ioData.Weight = weight
+	} else {
+		qosLevel, err := p.qosConfig.GetQoSLevelForPod(pod)
+		if err != nil {
+			return nil, err
+		}
+		ioData.Weight = p.getIOWeightByQoS(qosLevel)
+	}
+
+	if len(deviceNumbers) == 0 {
+		return ioData, nil
+	}
+
+	// limits of throttled devices are always set, and they are unlimited without the annotation,
+	// so that limits set before are reset once the annotation is removed
+	limit := &common.IODeviceLimit{}
+	if maxStr, ok := pod.Annotations[p.podLevelIOMaxAnnoKey]; ok {
+		var err error
+		limit, err = parseIOMaxAnnotation(maxStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid io max %q in annotation %s: %v", maxStr, p.podLevelIOMaxAnnoKey, err)
+		}
+	}
+
+	ioData.DeviceLimits = make(map[string]*common.IODeviceLimit, len(deviceNumbers))
+	for _, deviceNumber := range deviceNumbers {
+		deviceLimit := *limit
+		ioData.DeviceLimits[deviceNumber] = &deviceLimit
+	}
+
+	return ioData, nil
+}
+
+func (p *DynamicPolicy) getIOWeightByQoS(qosLevel string) uint64 {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.ioWeightMap[qosLevel]
+}
+
+// parseIOMaxAnnotation parses io throughput limits formatted as "rbps=N wbps=N riops=N wiops=N",
+// and both omitted keys and "max" mean unlimited
+func parseIOMaxAnnotation(value string) (*common.IODeviceLimit, error) {
+	limit := &common.IODeviceLimit{}
+	for _, field := range strings.Fields(value) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+
+		var val uint64
+		if kv[1] != "max" {
+			var err error
+			val, err = strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of field %q: %v", field, err)
+			}
+		}
+
+		switch kv[0] {
+		case "rbps":
+			limit.ReadBps = val
+		case "wbps":
+			limit.WriteBps = val
+		case "riops":
+			limit.ReadIOPS = val
+		case "wiops":
+			limit.WriteIOPS = val
+		default:
+			return nil, fmt.Errorf("unknown key %q", kv[0])
+		}
+	}
+	return limit, nil
+}
+
+// getDeviceNumbers returns "major:minor" of the given devices, and devices not found are skipped
+func getDeviceNumbers(diskStats map[string]*procfs.DiskStat, devices []string) map[string]string {
+	deviceNumbers := make(map[string]string, len(devices))
+	for _, device := range devices {
+		stat, ok := diskStats[device]
+		if !ok || stat == nil {
+			klog.Warningf("[IODynamicPolicy.getDeviceNumbers] device %s not found", device)
+			continue
+		}
+		deviceNumbers[device] = fmt.Sprintf("%d:%d", stat.Major, stat.Minor)
+	}
+	return deviceNumbers
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	pluginapi "k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
+
+	"github.com/kubewharf/katalyst-api/pkg/consts"
+	katalyst_base "github.com/kubewharf/katalyst-core/cmd/base"
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/agent"
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/util"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	metaserveragent "github.com/kubewharf/katalyst-core/pkg/metaserver/agent"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+func generateTestConfiguration(t *testing.T) *config.Configuration {
+	testConfiguration, err := options.NewOptions().Config()
+	require.NoError(t, err)
+	require.NotNil(t, testConfiguration)
+	return testConfiguration
+}
+
+func makeTestGenericContext(t *testing.T) *agent.GenericContext {
+	genericCtx, err := katalyst_base.GenerateFakeGenericContext([]runtime.Object{})
+	assert.NoError(t, err)
+
+	return &agent.GenericContext{
+		GenericContext: genericCtx,
+		MetaServer: &metaserver.MetaServer{
+			MetaAgent: &metaserveragent.MetaAgent{},
+		},
+		PluginManager: nil,
+	}
+}
+
+func makeDynamicPolicy(t *testing.T, procRoot string) *DynamicPolicy {
+	conf := generateTestConfiguration(t)
+	agentCtx := makeTestGenericContext(t)
+	wrappedEmitter := agentCtx.EmitterPool.GetDefaultMetricsEmitter().WithTags(IOResourcePluginPolicyNameDynamic, metrics.MetricTag{
+		Key: util.QRMPluginPolicyTagName,
+		Val: IOResourcePluginPolicyNameDynamic,
+	})
+
+	return &DynamicPolicy{
+		qosConfig:  conf.QoSConfiguration,
+		emitter:    wrappedEmitter,
+		metaServer: agentCtx.MetaServer,
+		procFS:     procfs.NewProcFS(procRoot),
+		stopCh:     make(chan struct{}),
+		name:       fmt.Sprintf("%s_%s", "qrm_io_plugin", IOResourcePluginPolicyNameDynamic),
+		ioWeightMap: map[string]uint64{
+			consts.PodAnnotationQoSLevelReclaimedCores: conf.IOWeight.ReclaimedCores,
+			consts.PodAnnotationQoSLevelSharedCores:    conf.IOWeight.SharedCores,
+			consts.PodAnnotationQoSLevelDedicatedCores: conf.IOWeight.DedicatedCores,
+			consts.PodAnnotationQoSLevelSystemCores:    conf.IOWeight.SystemCores,
+		},
+		podLevelIOWeightAnnoKey:       conf.PodLevelIOWeightAnnoKey,
+		podLevelIOMaxAnnoKey:          conf.PodLevelIOMaxAnnoKey,
+		throttleDevices:               []string{"sda"},
+		dynamicIOCapConf:              conf.DynamicIOCapConfig,
+		reclaimRelativeRootCgroupPath: conf.ReclaimRelativeRootCgroupPath,
+		reclaimedIOCaps:               make(map[string]uint64),
+	}
+}
+
+func writeDiskStats(t *testing.T, procRoot string, ioTicks uint64) {
+	content := fmt.Sprintf("   8       0 sda 100 0 800 10 200 0 1600 20 0 %d 30\n"+
+		"   8       1 sda1 100 0 800 10 200 0 1600 20 0 %d 30\n", ioTicks, ioTicks)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(procRoot, "diskstats"), []byte(content), 0644))
+}
+
+func TestNewDynamicPolicy(t *testing.T) {
+	needToRun, policy, err := NewDynamicPolicy(makeTestGenericContext(t), generateTestConfiguration(t), nil, IOResourcePluginPolicyNameDynamic)
+	assert.NoError(t, err)
+	assert.NotNil(t, policy)
+	assert.True(t, needToRun)
+}
+
+func TestAllocate(t *testing.T) {
+	policy := makeDynamicPolicy(t, t.TempDir())
+
+	req := &pluginapi.ResourceRequest{
+		PodUid:         string(uuid.NewUUID()),
+		PodNamespace:   "test",
+		PodName:        "test",
+		ContainerName:  "test",
+		ContainerType:  pluginapi.ContainerType_MAIN,
+		ContainerIndex: 0,
+		ResourceName:   ResourceNameIO,
+		Annotations: map[string]string{
+			consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+		},
+	}
+
+	resp, err := policy.Allocate(context.TODO(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, req.PodUid, resp.PodUid)
+	assert.Equal(t, ResourceNameIO, resp.ResourceName)
+	assert.Contains(t, resp.AllocationResult.ResourceAllocation, ResourceNameIO)
+	assert.Equal(t, req.Annotations, resp.Annotations)
+}
+
+func TestGetIOData(t *testing.T) {
+	policy := makeDynamicPolicy(t, t.TempDir())
+	deviceNumbers := map[string]string{"sda": "8:0"}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    *common.IOData
+		expectErr   bool
+	}{
+		{
+			name: "weight of reclaimed_cores",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelReclaimedCores,
+			},
+			expected: &common.IOData{
+				Weight: 10,
+				DeviceLimits: map[string]*common.IODeviceLimit{
+					"8:0": {},
+				},
+			},
+		},
+		{
+			name: "weight of shared_cores",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+			},
+			expected: &common.IOData{
+				Weight: 100,
+				DeviceLimits: map[string]*common.IODeviceLimit{
+					"8:0": {},
+				},
+			},
+		},
+		{
+			name: "pod-level weight and io max",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+				policy.podLevelIOWeightAnnoKey:  "500",
+				policy.podLevelIOMaxAnnoKey:     "rbps=1048576 wiops=max",
+			},
+			expected: &common.IOData{
+				Weight: 500,
+				DeviceLimits: map[string]*common.IODeviceLimit{
+					"8:0": {ReadBps: 1048576},
+				},
+			},
+		},
+		{
+			name: "invalid pod-level weight",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+				policy.podLevelIOWeightAnnoKey:  "20000",
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid pod-level io max",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+				policy.podLevelIOMaxAnnoKey:     "rbps=abc",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "test",
+					UID:         uuid.NewUUID(),
+					Annotations: tc.annotations,
+				},
+			}
+
+			ioData, err := policy.getIOData(pod, deviceNumbers)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, ioData)
+		})
+	}
+}
+
+func TestParseIOMaxAnnotation(t *testing.T) {
+	limit, err := parseIOMaxAnnotation("rbps=100 wbps=200 riops=max wiops=400")
+	assert.NoError(t, err)
+	assert.Equal(t, &common.IODeviceLimit{ReadBps: 100, WriteBps: 200, WriteIOPS: 400}, limit)
+
+	_, err = parseIOMaxAnnotation("rbps")
+	assert.Error(t, err)
+
+	_, err = parseIOMaxAnnotation("bps=100")
+	assert.Error(t, err)
+}
+
+func TestAdjustIOCap(t *testing.T) {
+	conf := qrmconfig.DynamicIOCapConfig{
+		IOUtilThreshold:      80,
+		ReclaimedIOCapMinBps: 100,
+		ReclaimedIOCapMaxBps: 1100,
+	}
+
+	testCases := []struct {
+		name     string
+		current  uint64
+		util     float64
+		expected uint64
+	}{
+		{name: "start from max cap", current: 0, util: 10, expected: 1100},
+		{name: "halve the cap", current: 1000, util: 90, expected: 500},
+		{name: "halve the cap to min", current: 150, util: 90, expected: 100},
+		{name: "grow the cap", current: 500, util: 50, expected: 600},
+		{name: "grow the cap to max", current: 1050, util: 50, expected: 1100},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, adjustIOCap(tc.current, tc.util, conf))
+		})
+	}
+}
+
+func TestAdjustReclaimedIOCap(t *testing.T) {
+	procRoot := t.TempDir()
+	policy := makeDynamicPolicy(t, procRoot)
+	policy.dynamicIOCapConf = qrmconfig.DynamicIOCapConfig{
+		EnableDynamicIOCap:   true,
+		IOUtilThreshold:      80,
+		ReclaimedIOCapMinBps: 100,
+		ReclaimedIOCapMaxBps: 1000,
+	}
+
+	var appliedPath string
+	var appliedData *common.IOData
+	policy.applyReclaimedIOFunc = func(absCgroupPath string, data *common.IOData) error {
+		appliedPath, appliedData = absCgroupPath, data
+		return nil
+	}
+
+	writeDiskStats(t, procRoot, 1000)
+	policy.adjustReclaimedIOCap()
+	assert.Nil(t, appliedData)
+
+	// the device is always busy since the last round
+	policy.lastDiskStatsTime = time.Now().Add(-time.Second)
+	writeDiskStats(t, procRoot, 3000)
+	policy.adjustReclaimedIOCap()
+	assert.Equal(t, common.GetAbsCgroupPath(common.CgroupSubsysBlkIO, policy.reclaimRelativeRootCgroupPath), appliedPath)
+	assert.Equal(t, &common.IOData{
+		DeviceLimits: map[string]*common.IODeviceLimit{
+			"8:0": {ReadBps: 500, WriteBps: 500},
+		},
+	}, appliedData)
+
+	_ = os.Remove(filepath.Join(procRoot, "diskstats"))
+	appliedData = nil
+	policy.adjustReclaimedIOCap()
+	assert.Nil(t, appliedData)
+}
@@ -0,0 +1,368 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	cpustate "github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/cpu/dynamicpolicy/state"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/memoryadvisor"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+	"github.com/kubewharf/katalyst-core/pkg/util/process"
+)
+
+// lwMemoryAdvisorServer calls ListAndWatch of MemoryAdvisorServer and executes memory advices
+func (p *DynamicPolicy) lwMemoryAdvisorServer(stopCh <-chan struct{}) error {
+	conn, err := process.Dial(p.memoryAdvisorSocketAbsPath, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("get memory advisor connection with socket: %s failed with error: %v", p.memoryAdvisorSocketAbsPath, err)
+	}
+	defer conn.Close()
+
+	// memory.high and memory limit of reclaim pool are only kept while the advices are streamed,
+	// otherwise they may cap the containers forever
+	defer p.resetMemoryHigh(nil)
+	defer p.resetReclaimPoolMemoryLimit()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			klog.Info("[MemoryDynamicPolicy.lwMemoryAdvisorServer] received stop signal, stop calling ListAndWatch of MemoryAdvisorServer")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := memoryadvisor.NewMemoryAdvisorClient(conn).ListAndWatch(ctx, &memoryadvisor.Empty{})
+	if err != nil {
+		return fmt.Errorf("call ListAndWatch of MemoryAdvisorServer failed with error: %v", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return fmt.Errorf("receive ListAndWatch response of MemoryAdvisorServer failed with error: %v", err)
+		}
+
+		p.handleMemoryAdvices(resp)
+	}
+}
+
+// handleMemoryAdvices executes memory advices of each container and pool, and memory.high of
+// containers not advised any more is reset, so is memory limit of reclaim pool
+func (p *DynamicPolicy) handleMemoryAdvices(resp *memoryadvisor.ListAndWatchResponse) {
+	advisedMemoryHigh := make(map[string]sets.String)
+	defer func() {
+		p.resetMemoryHigh(advisedMemoryHigh)
+	}()
+
+	if _, ok := resp.GetEntries()[cpustate.PoolNameReclaim]; !ok {
+		p.resetReclaimPoolMemoryLimit()
+	}
+
+	for podUID, entries := range resp.GetEntries() {
+		for containerName, advice := range entries.GetEntries() {
+			if advice == nil {
+				continue
+			}
+
+			if containerName == memoryadvisor.FakedContainerName {
+				if err := p.handlePoolMemoryAdvice(podUID, advice); err != nil {
+					klog.Errorf("[MemoryDynamicPolicy.handleMemoryAdvices] handle memory advice of pool: %s failed with error: %v",
+						podUID, err)
+				}
+				continue
+			}
+
+			containerID, err := p.metaServer.GetContainerID(podUID, containerName)
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.handleMemoryAdvices] get container id of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+				continue
+			}
+
+			if _, ok := advice.GetValues()[memoryadvisor.ControlKnobKeyMemoryHigh]; ok {
+				if advisedMemoryHigh[podUID] == nil {
+					advisedMemoryHigh[podUID] = sets.NewString()
+				}
+				advisedMemoryHigh[podUID].Insert(containerName)
+			}
+
+			if err := p.handleAdvisorMemoryHigh(podUID, containerName, containerID, advice); err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.handleMemoryAdvices] handle memory high of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+			}
+
+			if err := p.handleAdvisorDropCache(podUID, containerName, containerID, advice); err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.handleMemoryAdvices] handle drop cache of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+			}
+		}
+	}
+}
+
+// handlePoolMemoryAdvice executes memory advices of pools, and only memory limit of reclaim pool
+// is supported for now, which is set to the root cgroup of reclaimed_cores containers; the limit
+// is reset to unlimited if it's not advised or not positive. memory limit of each numa node is
+// enforced by handleReclaimPoolNumaMemoryLimit.
+func (p *DynamicPolicy) handlePoolMemoryAdvice(poolName string, advice *memoryadvisor.MemoryAdvice) error {
+	if poolName != cpustate.PoolNameReclaim || p.reclaimRelativeRootCgroupPath == "" {
+		return nil
+	}
+
+	if err := p.handleReclaimPoolNumaMemoryLimit(advice); err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.handlePoolMemoryAdvice] handle numa memory limit of pool: %s failed with error: %v",
+			poolName, err)
+	}
+
+	memoryLimit, ok, err := advice.GetBytes(memoryadvisor.ControlKnobKeyMemoryLimit)
+	if err != nil {
+		return err
+	} else if !ok || memoryLimit <= 0 {
+		p.resetReclaimPoolMemoryLimit()
+		return nil
+	}
+
+	klog.Infof("[MemoryDynamicPolicy.handlePoolMemoryAdvice] set memory limit of pool: %s to %d", poolName, memoryLimit)
+	return cgroupcmutils.ApplyMemoryWithRelativePath(p.reclaimRelativeRootCgroupPath, &common.MemoryData{LimitInBytes: memoryLimit})
+}
+
+// handleReclaimPoolNumaMemoryLimit enforces memory limit of reclaim pool on each numa node. Since memory
+// cgroup can't limit usage per numa node, file cache of the root cgroup of reclaimed_cores containers
+// exceeding the limit on a numa node is dropped asynchronously by a thread bound to cpus of the numa node,
+// and it's skipped if the last dropping hasn't finished yet; anonymous pages are left to the total limit.
+func (p *DynamicPolicy) handleReclaimPoolNumaMemoryLimit(advice *memoryadvisor.MemoryAdvice) error {
+	numaLimits := make(map[int]int64)
+	for _, numaID := range p.topology.CPUDetails.NUMANodes().ToSliceInt() {
+		limit, ok, err := advice.GetBytes(memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyMemoryLimit, numaID))
+		if err != nil {
+			return err
+		} else if ok {
+			numaLimits[numaID] = limit
+		}
+	}
+
+	if len(numaLimits) == 0 {
+		return nil
+	}
+
+	memoryAbsCGPath := common.GetAbsCgroupPath(common.CgroupSubsysMemory, p.reclaimRelativeRootCgroupPath)
+	numaStat, err := cgroupcmutils.GetNumaStatWithAbsolutePath(memoryAbsCGPath)
+	if err != nil {
+		return err
+	}
+
+	dropBytesNuma := getNumaMemoryLimitExcess(numaStat, numaLimits)
+	if len(dropBytesNuma) == 0 {
+		return nil
+	}
+
+	if !atomic.CompareAndSwapInt32(&p.reclaimPoolNumaLimitRunning, 0, 1) {
+		klog.Infof("[MemoryDynamicPolicy.handleReclaimPoolNumaMemoryLimit] pool: %s is dropping cache, skip it", cpustate.PoolNameReclaim)
+		return nil
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&p.reclaimPoolNumaLimitRunning, 0)
+
+		for numaID, dropBytes := range dropBytesNuma {
+			cpus := p.topology.CPUDetails.CPUsInNUMANodes(numaID).ToSliceInt()
+			p.swapKnobLock.Lock()
+			reclaimed, err := cgroupcmutils.DropFileCacheOnCPUsWithAbsolutePath(memoryAbsCGPath, dropBytes, cpus)
+			p.swapKnobLock.Unlock()
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.handleReclaimPoolNumaMemoryLimit] drop cache of pool: %s on numa: %d failed with error: %v",
+					cpustate.PoolNameReclaim, numaID, err)
+				continue
+			}
+
+			klog.Infof("[MemoryDynamicPolicy.handleReclaimPoolNumaMemoryLimit] drop cache of pool: %s on numa: %d successfully, target: %d, reclaimed: %d",
+				cpustate.PoolNameReclaim, numaID, dropBytes, reclaimed)
+		}
+	}()
+
+	return nil
+}
+
+// getNumaMemoryLimitExcess returns bytes of file cache to be dropped on each numa node to bring memory
+// usage down to the limit, which is bounded by file cache on the numa node; negative limits are ignored
+func getNumaMemoryLimitExcess(numaStat map[int]*common.MemoryNumaMetrics, numaLimits map[int]int64) map[int]int64 {
+	excess := make(map[int]int64)
+	for numaID, limit := range numaLimits {
+		stat := numaStat[numaID]
+		if limit < 0 || stat == nil {
+			continue
+		}
+
+		used := int64(stat.Anon + stat.File)
+		if used <= limit {
+			continue
+		}
+
+		dropBytes := used - limit
+		if dropBytes > int64(stat.File) {
+			dropBytes = int64(stat.File)
+		}
+		if dropBytes > 0 {
+			excess[numaID] = dropBytes
+		}
+	}
+	return excess
+}
+
+// resetReclaimPoolMemoryLimit resets memory limit of reclaim pool to unlimited,
+// and it's a no-op if the limit isn't set
+func (p *DynamicPolicy) resetReclaimPoolMemoryLimit() {
+	if p.reclaimRelativeRootCgroupPath == "" {
+		return
+	}
+
+	err := cgroupcmutils.ApplyMemoryWithRelativePath(p.reclaimRelativeRootCgroupPath, &common.MemoryData{LimitInBytes: -1})
+	if err != nil {
+		klog.Warningf("[MemoryDynamicPolicy.resetReclaimPoolMemoryLimit] reset memory limit of pool: %s failed with error: %v",
+			cpustate.PoolNameReclaim, err)
+	}
+}
+
+// handleAdvisorMemoryHigh sets memory.high to cap file cache, and it only works for cgroupv2;
+// containers with memory.high applied are recorded to be reset when they're not advised any more
+func (p *DynamicPolicy) handleAdvisorMemoryHigh(podUID, containerName, containerID string, advice *memoryadvisor.MemoryAdvice) error {
+	memoryHigh, ok, err := advice.GetBytes(memoryadvisor.ControlKnobKeyMemoryHigh)
+	if err != nil || !ok {
+		return err
+	}
+
+	if !common.IsCgroup2UnifiedMode() {
+		return fmt.Errorf("memory.high is not supported by cgroupv1")
+	}
+
+	p.memoryHighLock.Lock()
+	if p.memoryHighContainers[podUID] == nil {
+		p.memoryHighContainers[podUID] = make(map[string]string)
+	}
+	p.memoryHighContainers[podUID][containerName] = containerID
+	p.memoryHighLock.Unlock()
+
+	return cgroupcmutils.ApplyMemoryForContainer(podUID, containerID, &common.MemoryData{HighInBytes: memoryHigh})
+}
+
+// resetMemoryHigh resets memory.high to max for recorded containers not in the advised ones,
+// and all recorded containers are reset if advised is nil
+func (p *DynamicPolicy) resetMemoryHigh(advised map[string]sets.String) {
+	p.memoryHighLock.Lock()
+	defer p.memoryHighLock.Unlock()
+
+	for podUID, containers := range p.memoryHighContainers {
+		for containerName, containerID := range containers {
+			if advised[podUID].Has(containerName) {
+				continue
+			}
+
+			// the container may have been removed, so it's not retried on failure
+			err := cgroupcmutils.ApplyMemoryForContainer(podUID, containerID, &common.MemoryData{HighInBytes: -1})
+			if err != nil {
+				klog.Warningf("[MemoryDynamicPolicy.resetMemoryHigh] reset memory high of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+			} else {
+				klog.Infof("[MemoryDynamicPolicy.resetMemoryHigh] reset memory high of pod: %s container: %s", podUID, containerName)
+			}
+			delete(containers, containerName)
+		}
+
+		if len(containers) == 0 {
+			delete(p.memoryHighContainers, podUID)
+		}
+	}
+}
+
+// handleAdvisorDropCache drops file cache of the container on each advised numa node asynchronously,
+// and it's skipped if the last dropping of the same container hasn't finished yet
+func (p *DynamicPolicy) handleAdvisorDropCache(podUID, containerName, containerID string, advice *memoryadvisor.MemoryAdvice) error {
+	dropBytesNuma := make(map[int]int64)
+	for _, numaID := range p.topology.CPUDetails.NUMANodes().ToSliceInt() {
+		dropBytes, ok, err := advice.GetBytes(memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyDropCache, numaID))
+		if err != nil {
+			return err
+		} else if ok && dropBytes > 0 {
+			dropBytesNuma[numaID] = dropBytes
+		}
+	}
+
+	if len(dropBytesNuma) == 0 {
+		return nil
+	}
+
+	memoryAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysMemory, podUID, containerID)
+	if err != nil {
+		return err
+	}
+
+	p.dropCacheLock.Lock()
+	defer p.dropCacheLock.Unlock()
+
+	if p.droppingCache[podUID][containerName] {
+		klog.Infof("[MemoryDynamicPolicy.handleAdvisorDropCache] pod: %s container: %s is dropping cache, skip it",
+			podUID, containerName)
+		return nil
+	}
+
+	if p.droppingCache[podUID] == nil {
+		p.droppingCache[podUID] = make(map[string]bool)
+	}
+	p.droppingCache[podUID][containerName] = true
+
+	go func() {
+		defer func() {
+			p.dropCacheLock.Lock()
+			delete(p.droppingCache[podUID], containerName)
+			if len(p.droppingCache[podUID]) == 0 {
+				delete(p.droppingCache, podUID)
+			}
+			p.dropCacheLock.Unlock()
+		}()
+
+		// reclaim is triggered by a thread bound to cpus of the numa node, so that page cache
+		// on the numa node is reclaimed first; anonymous pages are never reclaimed here, and swap
+		// control is held off meanwhile so that the swap knobs overridden during dropping are
+		// neither changed under it nor restored to stale values
+		for numaID, dropBytes := range dropBytesNuma {
+			cpus := p.topology.CPUDetails.CPUsInNUMANodes(numaID).ToSliceInt()
+			p.swapKnobLock.Lock()
+			reclaimed, err := cgroupcmutils.DropFileCacheOnCPUsWithAbsolutePath(memoryAbsCGPath, dropBytes, cpus)
+			p.swapKnobLock.Unlock()
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.handleAdvisorDropCache] drop cache of pod: %s container: %s on numa: %d failed with error: %v",
+					podUID, containerName, numaID, err)
+				continue
+			}
+
+			klog.Infof("[MemoryDynamicPolicy.handleAdvisorDropCache] drop cache of pod: %s container: %s on numa: %d successfully, target: %d, reclaimed: %d",
+				podUID, containerName, numaID, dropBytes, reclaimed)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+)
+
+func TestGetNumaMemoryLimitExcess(t *testing.T) {
+	t.Parallel()
+
+	numaStat := map[int]*common.MemoryNumaMetrics{
+		0: {Anon: 2 << 30, File: 3 << 30},
+		1: {Anon: 4 << 30, File: 1 << 30},
+		2: {Anon: 1 << 30, File: 1 << 30},
+	}
+
+	// numa 0 drops the excess, numa 1 is bounded by its file cache, numa 2 is under the limit,
+	// and negative limits or numas without stats are ignored
+	require.Equal(t, map[int]int64{0: 1 << 30, 1: 1 << 30}, getNumaMemoryLimitExcess(numaStat, map[int]int64{
+		0: 4 << 30,
+		1: 2 << 30,
+		2: 2 << 30,
+		3: 0,
+	}))
+	require.Equal(t, map[int]int64{0: 3 << 30, 1: 1 << 30, 2: 1 << 30}, getNumaMemoryLimitExcess(numaStat, map[int]int64{
+		0: 0,
+		1: 0,
+		2: 0,
+	}))
+	require.Empty(t, getNumaMemoryLimitExcess(numaStat, map[int]int64{0: -1, 1: -1}))
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	workloadapis "github.com/kubewharf/katalyst-api/pkg/apis/workload/v1alpha1"
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/native"
+)
+
+const (
+	metricNameMemoryProtectionBytes = "memory_protection_bytes"
+)
+
+// protectionCandidate is a dedicated_cores or shared_cores container whose
+// working set should be protected from reclaim
+type protectionCandidate struct {
+	podUID        string
+	podNamespace  string
+	podName       string
+	containerName string
+	qosLevel      string
+
+	protection int64
+}
+
+// memoryProtection records bytes protected by memory.min and memory.low of a cgroup
+type memoryProtection struct {
+	min int64
+	low int64
+}
+
+// setMemoryProtection sets memory.min for dedicated_cores and memory.low for shared_cores,
+// and protected bytes come from container requests or from working sets declared in SPD;
+// the total protection is capped by a ratio of node memory capacity so that it can't starve the kernel.
+// For cgroupv2, protection of a cgroup is bounded by that of its ancestors, so pod, QoS-level
+// and kubernetes root cgroups are set with the sum of protection of containers in them.
+func (p *DynamicPolicy) setMemoryProtection() {
+	candidates := p.getMemoryProtectionCandidates()
+
+	capacity := p.getMemoryCapacity()
+	scaleMemoryProtection(candidates, int64(float64(capacity)*p.memoryProtectionConf.MemoryProtectionMaxRatio))
+
+	cgroupRoot := common.GetCgroupRootPath(common.CgroupSubsysMemory)
+	ancestors := make(map[string]*memoryProtection)
+	if common.IsCgroup2UnifiedMode() {
+		// kubernetes root cgroups are always set, so that stale protection is cleared
+		// after all protected containers in them are gone
+		for _, rootPath := range common.GetKubernetesCgroupRootPathWithSubSys(common.CgroupSubsysMemory) {
+			if general.IsPathExists(rootPath) {
+				ancestors[rootPath] = &memoryProtection{}
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		containerID, err := p.metaServer.GetContainerID(candidate.podUID, candidate.containerName)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setMemoryProtection] get container id of pod: %s container: %s failed with error: %v",
+				candidate.podUID, candidate.containerName, err)
+			continue
+		}
+
+		memoryAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysMemory, candidate.podUID, containerID)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setMemoryProtection] get cgroup path of pod: %s/%s, container: %s failed with error: %v",
+				candidate.podNamespace, candidate.podName, candidate.containerName, err)
+			continue
+		}
+
+		// zero protection is written as well to clear protection set before
+		protection := memoryProtection{}
+		data := &common.MemoryData{}
+		switch candidate.qosLevel {
+		case apiconsts.PodAnnotationQoSLevelDedicatedCores:
+			protection.min = candidate.protection
+			data.MinInBytesPtr = &protection.min
+		default:
+			protection.low = candidate.protection
+			data.LowInBytesPtr = &protection.low
+		}
+
+		err = cgroupcmutils.ApplyMemoryWithAbsolutePath(memoryAbsCGPath, data)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setMemoryProtection] apply memory protection for pod: %s/%s, container: %s failed with error: %v",
+				candidate.podNamespace, candidate.podName, candidate.containerName, err)
+			continue
+		}
+
+		if common.IsCgroup2UnifiedMode() {
+			propagateMemoryProtection(cgroupRoot, memoryAbsCGPath, protection, ancestors)
+		}
+
+		_ = p.emitter.StoreInt64(metricNameMemoryProtectionBytes, candidate.protection, metrics.MetricTypeNameRaw,
+			metrics.ConvertMapToTags(map[string]string{
+				"podNamespace":  candidate.podNamespace,
+				"podName":       candidate.podName,
+				"containerName": candidate.containerName,
+				"qosLevel":      candidate.qosLevel,
+			})...)
+	}
+
+	for ancestorPath, protection := range ancestors {
+		err := cgroupcmutils.ApplyMemoryWithAbsolutePath(ancestorPath, &common.MemoryData{
+			MinInBytesPtr: &protection.min,
+			LowInBytesPtr: &protection.low,
+		})
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setMemoryProtection] apply memory protection for cgroup: %s failed with error: %v",
+				ancestorPath, err)
+		}
+	}
+}
+
+// propagateMemoryProtection adds protection of a container to all its ancestor cgroups under cgroupRoot
+func propagateMemoryProtection(cgroupRoot, containerPath string, protection memoryProtection,
+	ancestors map[string]*memoryProtection) {
+	for dir := filepath.Dir(containerPath); dir != cgroupRoot && strings.HasPrefix(dir, cgroupRoot+"/"); dir = filepath.Dir(dir) {
+		ancestor, ok := ancestors[dir]
+		if !ok {
+			ancestor = &memoryProtection{}
+			ancestors[dir] = ancestor
+		}
+
+		ancestor.min += protection.min
+		ancestor.low += protection.low
+	}
+}
+
+// getMemoryProtectionCandidates collects containers of dedicated_cores and shared_cores
+// pods along with the bytes they should be protected
+func (p *DynamicPolicy) getMemoryProtectionCandidates() []*protectionCandidate {
+	p.RLock()
+	var candidates []*protectionCandidate
+	for podUID, containerEntries := range p.state.GetPodResourceEntries()[v1.ResourceMemory] {
+		for containerName, allocationInfo := range containerEntries {
+			if allocationInfo == nil || containerName == "" ||
+				(allocationInfo.QoSLevel != apiconsts.PodAnnotationQoSLevelDedicatedCores &&
+					allocationInfo.QoSLevel != apiconsts.PodAnnotationQoSLevelSharedCores) {
+				continue
+			}
+
+			candidates = append(candidates, &protectionCandidate{
+				podUID:        podUID,
+				podNamespace:  allocationInfo.PodNamespace,
+				podName:       allocationInfo.PodName,
+				containerName: containerName,
+				qosLevel:      allocationInfo.QoSLevel,
+			})
+		}
+	}
+	p.RUnlock()
+
+	validCandidates := make([]*protectionCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		pod, err := p.metaServer.GetPod(context.Background(), candidate.podUID)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.getMemoryProtectionCandidates] get pod: %s/%s failed with error: %v",
+				candidate.podNamespace, candidate.podName, err)
+			continue
+		}
+
+		candidate.protection = getContainerMemoryRequest(pod, candidate.containerName)
+		if p.memoryProtectionConf.MemoryProtectionBySPD {
+			spd, err := p.metaServer.GetSPD(context.Background(), pod)
+			if err != nil {
+				klog.V(4).Infof("[MemoryDynamicPolicy.getMemoryProtectionCandidates] get spd for pod: %s/%s failed with error: %v",
+					candidate.podNamespace, candidate.podName, err)
+			} else if workingSet, ok := getContainerWorkingSetFromSPD(spd, candidate.containerName); ok {
+				candidate.protection = workingSet
+			}
+		}
+
+		validCandidates = append(validCandidates, candidate)
+	}
+
+	return validCandidates
+}
+
+// getMemoryCapacity returns the total memory size of all numa nodes
+func (p *DynamicPolicy) getMemoryCapacity() uint64 {
+	p.RLock()
+	defer p.RUnlock()
+
+	var capacity uint64
+	for _, numaState := range p.state.GetMachineState()[v1.ResourceMemory] {
+		if numaState != nil {
+			capacity += numaState.TotalMemSize
+		}
+	}
+	return capacity
+}
+
+// getContainerMemoryRequest returns memory request of the given container in bytes
+func getContainerMemoryRequest(pod *v1.Pod, containerName string) int64 {
+	for _, container := range pod.Spec.Containers {
+		if container.Name == containerName {
+			quantity := native.GetMemoryQuantity(container.Resources.Requests)
+			return quantity.Value()
+		}
+	}
+	return 0
+}
+
+// getContainerWorkingSetFromSPD returns working set of the given container declared in
+// aggregated metrics of SPD, and the max-aggregated metrics is preferred if it exists.
+func getContainerWorkingSetFromSPD(spd *workloadapis.ServiceProfileDescriptor, containerName string) (int64, bool) {
+	if spd == nil {
+		return 0, false
+	}
+
+	workingSets := make(map[workloadapis.Aggregator]int64)
+	for _, aggMetrics := range spd.Status.AggMetrics {
+		for _, podMetrics := range aggMetrics.Items {
+			for _, containerMetrics := range podMetrics.Containers {
+				if containerMetrics.Name != containerName {
+					continue
+				}
+
+				quantity, ok := containerMetrics.Usage[v1.ResourceMemory]
+				if !ok {
+					continue
+				}
+
+				if current, ok := workingSets[aggMetrics.Aggregator]; !ok || quantity.Value() > current {
+					workingSets[aggMetrics.Aggregator] = quantity.Value()
+				}
+			}
+		}
+	}
+
+	for _, aggregator := range []workloadapis.Aggregator{workloadapis.Max, workloadapis.Avg} {
+		if workingSet, ok := workingSets[aggregator]; ok {
+			return workingSet, true
+		}
+	}
+	return 0, false
+}
+
+// scaleMemoryProtection scales down protection of all candidates proportionally
+// if the total protection exceeds the node-wide cap.
+func scaleMemoryProtection(candidates []*protectionCandidate, maxProtection int64) {
+	var total int64
+	for _, candidate := range candidates {
+		if candidate.protection < 0 {
+			candidate.protection = 0
+		}
+		total += candidate.protection
+	}
+
+	if maxProtection < 0 {
+		maxProtection = 0
+	}
+
+	if total <= maxProtection {
+		return
+	}
+
+	for _, candidate := range candidates {
+		candidate.protection = int64(float64(candidate.protection) * float64(maxProtection) / float64(total))
+	}
+}
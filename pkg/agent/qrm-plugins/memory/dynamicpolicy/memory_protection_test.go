@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+
+	workloadapis "github.com/kubewharf/katalyst-api/pkg/apis/workload/v1alpha1"
+)
+
+func TestScaleMemoryProtection(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                string
+		protections         []int64
+		maxProtection       int64
+		expectedProtections []int64
+	}{
+		{
+			name:                "under cap",
+			protections:         []int64{100, 200},
+			maxProtection:       1000,
+			expectedProtections: []int64{100, 200},
+		},
+		{
+			name:                "scaled down proportionally",
+			protections:         []int64{300, 600, 100},
+			maxProtection:       500,
+			expectedProtections: []int64{150, 300, 50},
+		},
+		{
+			name:                "negative cap disables protection",
+			protections:         []int64{300, -1},
+			maxProtection:       -1,
+			expectedProtections: []int64{0, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			candidates := make([]*protectionCandidate, 0, len(tc.protections))
+			for _, protection := range tc.protections {
+				candidates = append(candidates, &protectionCandidate{protection: protection})
+			}
+
+			scaleMemoryProtection(candidates, tc.maxProtection)
+			for i, candidate := range candidates {
+				require.Equal(t, tc.expectedProtections[i], candidate.protection)
+			}
+		})
+	}
+}
+
+func TestGetContainerWorkingSetFromSPD(t *testing.T) {
+	t.Parallel()
+
+	makeAggMetrics := func(aggregator workloadapis.Aggregator, values ...string) workloadapis.AggPodMetrics {
+		aggMetrics := workloadapis.AggPodMetrics{Aggregator: aggregator}
+		for _, value := range values {
+			aggMetrics.Items = append(aggMetrics.Items, metricsv1beta1.PodMetrics{
+				Containers: []metricsv1beta1.ContainerMetrics{
+					{
+						Name:  "c1",
+						Usage: v1.ResourceList{v1.ResourceMemory: resource.MustParse(value)},
+					},
+				},
+			})
+		}
+		return aggMetrics
+	}
+
+	_, ok := getContainerWorkingSetFromSPD(nil, "c1")
+	require.False(t, ok)
+
+	spd := &workloadapis.ServiceProfileDescriptor{
+		Status: workloadapis.ServiceProfileDescriptorStatus{
+			AggMetrics: []workloadapis.AggPodMetrics{
+				makeAggMetrics(workloadapis.Avg, "1Gi"),
+				makeAggMetrics(workloadapis.Max, "2Gi", "3Gi"),
+			},
+		},
+	}
+
+	workingSet, ok := getContainerWorkingSetFromSPD(spd, "c1")
+	require.True(t, ok)
+	require.Equal(t, int64(3<<30), workingSet)
+
+	_, ok = getContainerWorkingSetFromSPD(spd, "c2")
+	require.False(t, ok)
+
+	spd.Status.AggMetrics = spd.Status.AggMetrics[:1]
+	workingSet, ok = getContainerWorkingSetFromSPD(spd, "c1")
+	require.True(t, ok)
+	require.Equal(t, int64(1<<30), workingSet)
+}
+
+func TestPropagateMemoryProtection(t *testing.T) {
+	t.Parallel()
+
+	root := "/sys/fs/cgroup"
+	ancestors := map[string]*memoryProtection{
+		"/sys/fs/cgroup/kubepods/besteffort": {},
+	}
+
+	propagateMemoryProtection(root, "/sys/fs/cgroup/kubepods/pod1/c1", memoryProtection{min: 100}, ancestors)
+	propagateMemoryProtection(root, "/sys/fs/cgroup/kubepods/pod1/c2", memoryProtection{min: 50}, ancestors)
+	propagateMemoryProtection(root, "/sys/fs/cgroup/kubepods/burstable/pod2/c1", memoryProtection{low: 200}, ancestors)
+
+	require.Equal(t, map[string]*memoryProtection{
+		"/sys/fs/cgroup/kubepods":                {min: 150, low: 200},
+		"/sys/fs/cgroup/kubepods/pod1":           {min: 150},
+		"/sys/fs/cgroup/kubepods/burstable":      {low: 200},
+		"/sys/fs/cgroup/kubepods/burstable/pod2": {low: 200},
+		"/sys/fs/cgroup/kubepods/besteffort":     {},
+	}, ancestors)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memoryadvisor
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// control knobs of memory advice, and values of all knobs are in bytes
+const (
+	// ControlKnobKeyMemoryHigh caps memory usage (mainly file cache) of a container by memory.high
+	ControlKnobKeyMemoryHigh = "memory_high"
+	// ControlKnobKeyDropCache asks to drop the given bytes of file cache from a container, and it's
+	// only used as numa-level knob (i.e. keyed by GetNumaControlKnobKey) to drop cache on the numa node
+	ControlKnobKeyDropCache = "drop_cache"
+	// ControlKnobKeyMemoryLimit caps memory usage of a pool by memory limit, and it caps memory
+	// usage of the pool on the numa node if it's keyed by GetNumaControlKnobKey
+	ControlKnobKeyMemoryLimit = "memory_limit"
+)
+
+// FakedContainerName represents a placeholder since pool entry has no container-level
+const FakedContainerName = ""
+
+// GetNumaControlKnobKey returns the key of control knob which only works on the given numa node
+func GetNumaControlKnobKey(controlKnob string, numaID int) string {
+	return fmt.Sprintf("%s_numa%d", controlKnob, numaID)
+}
+
+// NewListAndWatchResponse converts memory advices keyed by podUID, container name and control
+// knob name, and pool advices keyed by pool name and control knob name to ListAndWatchResponse;
+// pool advices are keyed by pool name and FakedContainerName in the response
+func NewListAndWatchResponse(advices map[string]map[string]map[string]string,
+	poolAdvices map[string]map[string]string) *ListAndWatchResponse {
+	resp := &ListAndWatchResponse{Entries: make(map[string]*MemoryAdviceEntries, len(advices)+len(poolAdvices))}
+	for podUID, containerAdvices := range advices {
+		entries := &MemoryAdviceEntries{Entries: make(map[string]*MemoryAdvice, len(containerAdvices))}
+		for containerName, values := range containerAdvices {
+			entries.Entries[containerName] = &MemoryAdvice{Values: values}
+		}
+		resp.Entries[podUID] = entries
+	}
+	for poolName, values := range poolAdvices {
+		resp.Entries[poolName] = &MemoryAdviceEntries{
+			Entries: map[string]*MemoryAdvice{FakedContainerName: {Values: values}},
+		}
+	}
+	return resp
+}
+
+// GetBytes parses the value of the given control knob in bytes
+func (m *MemoryAdvice) GetBytes(controlKnob string) (int64, bool, error) {
+	value, ok := m.GetValues()[controlKnob]
+	if !ok {
+		return 0, false, nil
+	}
+
+	bytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, true, fmt.Errorf("parse %s: %s failed with error: %v", controlKnob, value, err)
+	}
+	return bytes, true, nil
+}
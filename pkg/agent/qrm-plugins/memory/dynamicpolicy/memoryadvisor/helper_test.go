@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memoryadvisor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAndWatchResponse(t *testing.T) {
+	t.Parallel()
+
+	resp := NewListAndWatchResponse(map[string]map[string]map[string]string{
+		"pod1": {
+			"c1": {
+				ControlKnobKeyMemoryHigh: "1073741824",
+				ControlKnobKeyDropCache:  "invalid",
+			},
+		},
+	}, map[string]map[string]string{
+		"reclaim": {
+			ControlKnobKeyMemoryLimit:                           "2147483648",
+			GetNumaControlKnobKey(ControlKnobKeyMemoryLimit, 0): "1073741824",
+		},
+	})
+
+	data, err := resp.Marshal()
+	require.NoError(t, err)
+
+	decoded := &ListAndWatchResponse{}
+	require.NoError(t, decoded.Unmarshal(data))
+	require.Equal(t, resp.String(), decoded.String())
+
+	advice := decoded.GetEntries()["pod1"].GetEntries()["c1"]
+	memoryHigh, ok, err := advice.GetBytes(ControlKnobKeyMemoryHigh)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(1<<30), memoryHigh)
+
+	_, ok, err = advice.GetBytes(ControlKnobKeyDropCache)
+	require.Error(t, err)
+	require.True(t, ok)
+
+	_, ok, err = (&MemoryAdvice{}).GetBytes(ControlKnobKeyDropCache)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	poolAdvice := decoded.GetEntries()["reclaim"].GetEntries()[FakedContainerName]
+	numaMemoryLimit, ok, err := poolAdvice.GetBytes(GetNumaControlKnobKey(ControlKnobKeyMemoryLimit, 0))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(1<<30), numaMemoryLimit)
+}
@@ -0,0 +1,1364 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/ // Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: memory.proto
+
+package memoryadvisor
+
+import (
+	context "context"
+	fmt "fmt"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+	reflect "reflect"
+	strings "strings"
+
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type Empty struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Empty) Reset()      { *m = Empty{} }
+func (*Empty) ProtoMessage() {}
+func (*Empty) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8535a169ff00080f, []int{0}
+}
+func (m *Empty) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Empty) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Empty.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Empty) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Empty.Merge(m, src)
+}
+func (m *Empty) XXX_Size() int {
+	return m.Size()
+}
+func (m *Empty) XXX_DiscardUnknown() {
+	xxx_messageInfo_Empty.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Empty proto.InternalMessageInfo
+
+type ListAndWatchResponse struct {
+	Entries              map[string]*MemoryAdviceEntries `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                        `json:"-"`
+	XXX_sizecache        int32                           `json:"-"`
+}
+
+func (m *ListAndWatchResponse) Reset()      { *m = ListAndWatchResponse{} }
+func (*ListAndWatchResponse) ProtoMessage() {}
+func (*ListAndWatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8535a169ff00080f, []int{1}
+}
+func (m *ListAndWatchResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ListAndWatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ListAndWatchResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ListAndWatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAndWatchResponse.Merge(m, src)
+}
+func (m *ListAndWatchResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ListAndWatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAndWatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAndWatchResponse proto.InternalMessageInfo
+
+func (m *ListAndWatchResponse) GetEntries() map[string]*MemoryAdviceEntries {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type MemoryAdviceEntries struct {
+	Entries              map[string]*MemoryAdvice `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *MemoryAdviceEntries) Reset()      { *m = MemoryAdviceEntries{} }
+func (*MemoryAdviceEntries) ProtoMessage() {}
+func (*MemoryAdviceEntries) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8535a169ff00080f, []int{2}
+}
+func (m *MemoryAdviceEntries) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MemoryAdviceEntries) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MemoryAdviceEntries.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MemoryAdviceEntries) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MemoryAdviceEntries.Merge(m, src)
+}
+func (m *MemoryAdviceEntries) XXX_Size() int {
+	return m.Size()
+}
+func (m *MemoryAdviceEntries) XXX_DiscardUnknown() {
+	xxx_messageInfo_MemoryAdviceEntries.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MemoryAdviceEntries proto.InternalMessageInfo
+
+func (m *MemoryAdviceEntries) GetEntries() map[string]*MemoryAdvice {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type MemoryAdvice struct {
+	Values               map[string]string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *MemoryAdvice) Reset()      { *m = MemoryAdvice{} }
+func (*MemoryAdvice) ProtoMessage() {}
+func (*MemoryAdvice) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8535a169ff00080f, []int{3}
+}
+func (m *MemoryAdvice) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MemoryAdvice) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MemoryAdvice.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MemoryAdvice) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MemoryAdvice.Merge(m, src)
+}
+func (m *MemoryAdvice) XXX_Size() int {
+	return m.Size()
+}
+func (m *MemoryAdvice) XXX_DiscardUnknown() {
+	xxx_messageInfo_MemoryAdvice.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MemoryAdvice proto.InternalMessageInfo
+
+func (m *MemoryAdvice) GetValues() map[string]string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Empty)(nil), "memoryadvisor.Empty")
+	proto.RegisterType((*ListAndWatchResponse)(nil), "memoryadvisor.ListAndWatchResponse")
+	proto.RegisterMapType((map[string]*MemoryAdviceEntries)(nil), "memoryadvisor.ListAndWatchResponse.EntriesEntry")
+	proto.RegisterType((*MemoryAdviceEntries)(nil), "memoryadvisor.MemoryAdviceEntries")
+	proto.RegisterMapType((map[string]*MemoryAdvice)(nil), "memoryadvisor.MemoryAdviceEntries.EntriesEntry")
+	proto.RegisterType((*MemoryAdvice)(nil), "memoryadvisor.MemoryAdvice")
+	proto.RegisterMapType((map[string]string)(nil), "memoryadvisor.MemoryAdvice.ValuesEntry")
+}
+
+func init() { proto.RegisterFile("memory.proto", fileDescriptor_8535a169ff00080f) }
+
+var fileDescriptor_8535a169ff00080f = []byte{
+	// 411 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xbf, 0x8f, 0xd3, 0x30,
+	0x14, 0xc7, 0xeb, 0x3b, 0xdd, 0x9d, 0xce, 0xcd, 0x49, 0xc8, 0x74, 0xa8, 0x82, 0x14, 0x55, 0x61,
+	0xa0, 0x4b, 0xe3, 0xa3, 0x2c, 0x07, 0x0b, 0x3a, 0xa4, 0x1b, 0x40, 0xdc, 0x92, 0x81, 0x93, 0x40,
+	0x3a, 0xc9, 0x49, 0xdc, 0xd4, 0xca, 0x0f, 0x07, 0xdb, 0x29, 0xf2, 0xc6, 0xcc, 0xc4, 0xbf, 0xc3,
+	0xca, 0xd4, 0x91, 0x91, 0x91, 0x86, 0x7f, 0x04, 0xd5, 0x69, 0x85, 0x5b, 0x95, 0x96, 0x29, 0xef,
+	0xe5, 0xbd, 0xef, 0xf7, 0x7d, 0x9e, 0xfc, 0xa0, 0x53, 0xd0, 0x82, 0x0b, 0x1d, 0x54, 0x82, 0x2b,
+	0x8e, 0x2e, 0xda, 0x8c, 0x24, 0x33, 0x26, 0xb9, 0x70, 0x47, 0x29, 0x53, 0xd3, 0x3a, 0x0a, 0x62,
+	0x5e, 0xe0, 0x94, 0xa7, 0x1c, 0x9b, 0xae, 0xa8, 0x9e, 0x98, 0xcc, 0x24, 0x26, 0x6a, 0xd5, 0xfe,
+	0x19, 0x3c, 0xb9, 0x29, 0x2a, 0xa5, 0xfd, 0xef, 0x00, 0xf6, 0xde, 0x32, 0xa9, 0xae, 0xcb, 0xe4,
+	0x8e, 0xa8, 0x78, 0x1a, 0x52, 0x59, 0xf1, 0x52, 0x52, 0xf4, 0x06, 0x9e, 0xd1, 0x52, 0x09, 0x46,
+	0x65, 0x1f, 0x0c, 0x8e, 0x87, 0xdd, 0xf1, 0x65, 0xb0, 0x31, 0x31, 0xd8, 0xa5, 0x0a, 0x6e, 0x5a,
+	0xc9, 0xf2, 0xa3, 0xc3, 0xb5, 0x81, 0x7b, 0x0f, 0x1d, 0xbb, 0x80, 0x1e, 0xc0, 0xe3, 0x8c, 0xea,
+	0x3e, 0x18, 0x80, 0xe1, 0x79, 0xb8, 0x0c, 0xd1, 0x15, 0x3c, 0x99, 0x91, 0xbc, 0xa6, 0xfd, 0xa3,
+	0x01, 0x18, 0x76, 0xc7, 0xfe, 0xd6, 0xac, 0x5b, 0x93, 0x5d, 0x27, 0x33, 0x16, 0xd3, 0x95, 0x53,
+	0xd8, 0x0a, 0x5e, 0x1c, 0x5d, 0x01, 0xff, 0x1b, 0x80, 0x0f, 0x77, 0xb4, 0xa0, 0xd7, 0xdb, 0x3b,
+	0xe0, 0xc3, 0xbe, 0xff, 0x58, 0xe1, 0xee, 0xe0, 0x0a, 0x4f, 0x37, 0x57, 0x78, 0xb4, 0x67, 0x94,
+	0xcd, 0xfe, 0x05, 0x40, 0xc7, 0xae, 0xa1, 0x97, 0xf0, 0xd4, 0x54, 0xd7, 0xcc, 0x4f, 0xf6, 0x18,
+	0x05, 0xef, 0x4c, 0x67, 0xcb, 0xba, 0x92, 0xb9, 0xcf, 0x61, 0xd7, 0xfa, 0xbd, 0x83, 0xb4, 0x67,
+	0x93, 0x9e, 0x5b, 0x30, 0xe3, 0x7b, 0x78, 0xf1, 0xd7, 0x5e, 0x72, 0x81, 0x6e, 0xa1, 0x63, 0xbf,
+	0x33, 0xea, 0x6d, 0xc1, 0x98, 0x23, 0x72, 0x1f, 0xff, 0xc7, 0x69, 0xf8, 0x9d, 0x4b, 0xf0, 0x4a,
+	0xcf, 0x17, 0x1e, 0xf8, 0xb9, 0xf0, 0x3a, 0x9f, 0x1b, 0x0f, 0xcc, 0x1b, 0x0f, 0xfc, 0x68, 0x3c,
+	0xf0, 0xab, 0xf1, 0xc0, 0xd7, 0xdf, 0x5e, 0xe7, 0xfd, 0x07, 0xeb, 0x86, 0xb3, 0x3a, 0xa2, 0x9f,
+	0xa6, 0x44, 0x4c, 0x70, 0x46, 0x14, 0xc9, 0xb5, 0x54, 0xa3, 0x98, 0x0b, 0x8a, 0xab, 0x2c, 0xc5,
+	0x24, 0xa5, 0xa5, 0xc2, 0x1f, 0x45, 0x31, 0xaa, 0xf2, 0x3a, 0x65, 0xa5, 0xc4, 0xed, 0x78, 0x9c,
+	0xe8, 0x92, 0x14, 0x2c, 0xae, 0x78, 0xce, 0x62, 0x8d, 0x37, 0x98, 0xa2, 0x53, 0x73, 0xf8, 0xcf,
+	0xfe, 0x0c, 0x00, 0x6a, 0xc4, 0x75, 0x52, 0x46, 0x03, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// MemoryAdvisorClient is the client API for MemoryAdvisor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MemoryAdvisorClient interface {
+	ListAndWatch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (MemoryAdvisor_ListAndWatchClient, error)
+}
+
+type memoryAdvisorClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMemoryAdvisorClient(cc *grpc.ClientConn) MemoryAdvisorClient {
+	return &memoryAdvisorClient{cc}
+}
+
+func (c *memoryAdvisorClient) ListAndWatch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (MemoryAdvisor_ListAndWatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_MemoryAdvisor_serviceDesc.Streams[0], "/memoryadvisor.MemoryAdvisor/ListAndWatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &memoryAdvisorListAndWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MemoryAdvisor_ListAndWatchClient interface {
+	Recv() (*ListAndWatchResponse, error)
+	grpc.ClientStream
+}
+
+type memoryAdvisorListAndWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *memoryAdvisorListAndWatchClient) Recv() (*ListAndWatchResponse, error) {
+	m := new(ListAndWatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// MemoryAdvisorServer is the server API for MemoryAdvisor service.
+type MemoryAdvisorServer interface {
+	ListAndWatch(*Empty, MemoryAdvisor_ListAndWatchServer) error
+}
+
+// UnimplementedMemoryAdvisorServer can be embedded to have forward compatible implementations.
+type UnimplementedMemoryAdvisorServer struct {
+}
+
+func (*UnimplementedMemoryAdvisorServer) ListAndWatch(req *Empty, srv MemoryAdvisor_ListAndWatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListAndWatch not implemented")
+}
+
+func RegisterMemoryAdvisorServer(s *grpc.Server, srv MemoryAdvisorServer) {
+	s.RegisterService(&_MemoryAdvisor_serviceDesc, srv)
+}
+
+func _MemoryAdvisor_ListAndWatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemoryAdvisorServer).ListAndWatch(m, &memoryAdvisorListAndWatchServer{stream})
+}
+
+type MemoryAdvisor_ListAndWatchServer interface {
+	Send(*ListAndWatchResponse) error
+	grpc.ServerStream
+}
+
+type memoryAdvisorListAndWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *memoryAdvisorListAndWatchServer) Send(m *ListAndWatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _MemoryAdvisor_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "memoryadvisor.MemoryAdvisor",
+	HandlerType: (*MemoryAdvisorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListAndWatch",
+			Handler:       _MemoryAdvisor_ListAndWatch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "memory.proto",
+}
+
+func (m *Empty) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Empty) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Empty) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *ListAndWatchResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ListAndWatchResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ListAndWatchResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for k := range m.Entries {
+			v := m.Entries[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintMemory(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintMemory(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintMemory(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MemoryAdviceEntries) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MemoryAdviceEntries) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MemoryAdviceEntries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for k := range m.Entries {
+			v := m.Entries[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintMemory(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintMemory(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintMemory(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MemoryAdvice) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MemoryAdvice) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MemoryAdvice) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for k := range m.Values {
+			v := m.Values[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintMemory(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintMemory(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintMemory(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintMemory(dAtA []byte, offset int, v uint64) int {
+	offset -= sovMemory(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Empty) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ListAndWatchResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for k, v := range m.Entries {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovMemory(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovMemory(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovMemory(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *MemoryAdviceEntries) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Entries) > 0 {
+		for k, v := range m.Entries {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovMemory(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovMemory(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovMemory(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *MemoryAdvice) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for k, v := range m.Values {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovMemory(uint64(len(k))) + 1 + len(v) + sovMemory(uint64(len(v)))
+			n += mapEntrySize + 1 + sovMemory(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func sovMemory(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozMemory(x uint64) (n int) {
+	return sovMemory(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *Empty) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Empty{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ListAndWatchResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForEntries := make([]string, 0, len(this.Entries))
+	for k, _ := range this.Entries {
+		keysForEntries = append(keysForEntries, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForEntries)
+	mapStringForEntries := "map[string]*MemoryAdviceEntries{"
+	for _, k := range keysForEntries {
+		mapStringForEntries += fmt.Sprintf("%v: %v,", k, this.Entries[k])
+	}
+	mapStringForEntries += "}"
+	s := strings.Join([]string{`&ListAndWatchResponse{`,
+		`Entries:` + mapStringForEntries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MemoryAdviceEntries) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForEntries := make([]string, 0, len(this.Entries))
+	for k, _ := range this.Entries {
+		keysForEntries = append(keysForEntries, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForEntries)
+	mapStringForEntries := "map[string]*MemoryAdvice{"
+	for _, k := range keysForEntries {
+		mapStringForEntries += fmt.Sprintf("%v: %v,", k, this.Entries[k])
+	}
+	mapStringForEntries += "}"
+	s := strings.Join([]string{`&MemoryAdviceEntries{`,
+		`Entries:` + mapStringForEntries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MemoryAdvice) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForValues := make([]string, 0, len(this.Values))
+	for k, _ := range this.Values {
+		keysForValues = append(keysForValues, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForValues)
+	mapStringForValues := "map[string]string{"
+	for _, k := range keysForValues {
+		mapStringForValues += fmt.Sprintf("%v: %v,", k, this.Values[k])
+	}
+	mapStringForValues += "}"
+	s := strings.Join([]string{`&MemoryAdvice{`,
+		`Values:` + mapStringForValues + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringMemory(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *Empty) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMemory
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Empty: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Empty: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMemory(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMemory
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ListAndWatchResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMemory
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ListAndWatchResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ListAndWatchResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Entries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMemory
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMemory
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMemory
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Entries == nil {
+				m.Entries = make(map[string]*MemoryAdviceEntries)
+			}
+			var mapkey string
+			var mapvalue *MemoryAdviceEntries
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMemory
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMemory
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthMemory
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMemory
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthMemory
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &MemoryAdviceEntries{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipMemory(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Entries[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMemory(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMemory
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MemoryAdviceEntries) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMemory
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MemoryAdviceEntries: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MemoryAdviceEntries: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Entries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMemory
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMemory
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMemory
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Entries == nil {
+				m.Entries = make(map[string]*MemoryAdvice)
+			}
+			var mapkey string
+			var mapvalue *MemoryAdvice
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMemory
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMemory
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthMemory
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMemory
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthMemory
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &MemoryAdvice{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipMemory(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Entries[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMemory(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMemory
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MemoryAdvice) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMemory
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MemoryAdvice: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MemoryAdvice: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMemory
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMemory
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMemory
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Values == nil {
+				m.Values = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowMemory
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMemory
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthMemory
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowMemory
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthMemory
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipMemory(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthMemory
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Values[mapkey] = mapvalue
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMemory(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthMemory
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipMemory(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowMemory
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowMemory
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowMemory
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthMemory
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupMemory
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthMemory
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthMemory        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowMemory          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupMemory = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,350 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+const (
+	metricNameMemoryNumaRemoteRatio   = "memory_numa_remote_ratio"
+	metricNameMemoryNumaMigratedBytes = "memory_numa_migrated_bytes"
+)
+
+// numaMigrationCandidate is a container with pages left on NUMAs out of its cpuset.mems
+type numaMigrationCandidate struct {
+	podUID        string
+	podNamespace  string
+	podName       string
+	containerName string
+	containerID   string
+
+	// remoteNumas are NUMAs holding pages of the container but out of its cpuset.mems,
+	// and targetNumas are its cpuset.mems
+	remoteNumas machine.CPUSet
+	targetNumas machine.CPUSet
+
+	remoteBytes uint64
+	remoteRatio float64
+}
+
+// migrateNumaMemory migrates remote pages of non-NUMA-binding containers towards their cpuset.mems,
+// since cpuset.mems may be changed (e.g. shared pools are resized) after pages have been allocated,
+// and remote accesses to those pages hurt latency. notice that
+// 1. only containers whose remote ratio exceeds the threshold will be migrated
+// 2. migration is throttled by the bandwidth budget to avoid disturbing workloads
+// 3. rounds are serialized, and a round is skipped if the previous one is still running
+// 4. for a certain given pod/container, only one migration is on the flight
+// 5. migration is done asynchronously by migrate_pages for each process of the container
+func (p *DynamicPolicy) migrateNumaMemory() {
+	if !atomic.CompareAndSwapInt32(&p.numaMigrationRunning, 0, 1) {
+		klog.Infof("[MemoryDynamicPolicy.migrateNumaMemory] the previous round is still running, skip this round")
+		return
+	}
+
+	candidates := p.getNumaMigrationCandidates()
+	budget := p.numaMigrationConf.NumaMigrationBytesPerSecond * uint64(p.numaMigrationConf.NumaMigrationPeriod.Seconds())
+	selected := selectNumaMigrationCandidates(candidates, p.numaMigrationConf.NumaMigrationRemoteRatioThreshold, budget)
+	if len(selected) == 0 {
+		atomic.StoreInt32(&p.numaMigrationRunning, 0)
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&p.numaMigrationRunning, 0)
+
+		throttler := newNumaMigrationThrottler(p.numaMigrationConf.NumaMigrationBytesPerSecond, budget, p.stopCh)
+		for _, candidate := range selected {
+			if !p.markMigratingMemory(candidate.podUID, candidate.containerName) {
+				continue
+			}
+
+			p.migrateContainerNumaMemory(candidate, throttler)
+			p.unmarkMigratingMemory(candidate.podUID, candidate.containerName)
+
+			if throttler.exhausted() {
+				return
+			}
+		}
+	}()
+}
+
+// markMigratingMemory marks the container as migrating, and it returns false
+// if the container is already being migrated (e.g. triggered by allocation)
+func (p *DynamicPolicy) markMigratingMemory(podUID, containerName string) bool {
+	p.migrateMemoryLock.Lock()
+	defer p.migrateMemoryLock.Unlock()
+
+	if p.migratingMemory[podUID][containerName] {
+		return false
+	}
+
+	if p.migratingMemory[podUID] == nil {
+		p.migratingMemory[podUID] = make(map[string]bool)
+	}
+	p.migratingMemory[podUID][containerName] = true
+	return true
+}
+
+func (p *DynamicPolicy) unmarkMigratingMemory(podUID, containerName string) {
+	p.migrateMemoryLock.Lock()
+	defer p.migrateMemoryLock.Unlock()
+
+	delete(p.migratingMemory[podUID], containerName)
+	if len(p.migratingMemory[podUID]) == 0 {
+		delete(p.migratingMemory, podUID)
+	}
+}
+
+// numaMigrationThrottler throttles migration by the bandwidth budget; since pages can only be
+// migrated by process and numa node, it waits after each migration for the time the migrated
+// bytes take under the bandwidth, and the round is stopped once the budget is used up.
+type numaMigrationThrottler struct {
+	bytesPerSecond uint64
+	budget         uint64
+	used           uint64
+	stopCh         <-chan struct{}
+}
+
+func newNumaMigrationThrottler(bytesPerSecond, budget uint64, stopCh <-chan struct{}) *numaMigrationThrottler {
+	return &numaMigrationThrottler{
+		bytesPerSecond: bytesPerSecond,
+		budget:         budget,
+		stopCh:         stopCh,
+	}
+}
+
+// consume accounts the migrated bytes and waits for the bandwidth,
+// and it returns false if migration should be stopped
+func (t *numaMigrationThrottler) consume(bytes uint64) bool {
+	t.used += bytes
+	if t.bytesPerSecond > 0 && bytes > 0 {
+		delay := time.Duration(float64(bytes) / float64(t.bytesPerSecond) * float64(time.Second))
+		select {
+		case <-time.After(delay):
+		case <-t.stopCh:
+			return false
+		}
+	}
+	return !t.exhausted()
+}
+
+func (t *numaMigrationThrottler) exhausted() bool {
+	return t.used >= t.budget
+}
+
+// getNumaMigrationCandidates collects non-NUMA-binding containers along with their
+// remote pages measured from memory.numa_stat, and remote ratio metrics are emitted here
+func (p *DynamicPolicy) getNumaMigrationCandidates() []*numaMigrationCandidate {
+	p.RLock()
+	var candidates []*numaMigrationCandidate
+	for podUID, containerEntries := range p.state.GetPodResourceEntries()[v1.ResourceMemory] {
+		for containerName, allocationInfo := range containerEntries {
+			if allocationInfo == nil || containerName == "" {
+				continue
+			} else if allocationInfo.QoSLevel == apiconsts.PodAnnotationQoSLevelDedicatedCores &&
+				allocationInfo.Annotations[apiconsts.PodAnnotationMemoryEnhancementNumaBinding] == apiconsts.PodAnnotationMemoryEnhancementNumaBindingEnable {
+				continue
+			}
+
+			candidates = append(candidates, &numaMigrationCandidate{
+				podUID:        podUID,
+				podNamespace:  allocationInfo.PodNamespace,
+				podName:       allocationInfo.PodName,
+				containerName: containerName,
+			})
+		}
+	}
+	p.RUnlock()
+
+	validCandidates := make([]*numaMigrationCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		containerID, err := p.metaServer.GetContainerID(candidate.podUID, candidate.containerName)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.getNumaMigrationCandidates] get container id of pod: %s container: %s failed with error: %v",
+				candidate.podUID, candidate.containerName, err)
+			continue
+		}
+		candidate.containerID = containerID
+
+		cpusetStats, err := cgroupcmutils.GetCPUSetForContainer(candidate.podUID, containerID)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.getNumaMigrationCandidates] get cpuset of pod: %s/%s, container: %s failed with error: %v",
+				candidate.podNamespace, candidate.podName, candidate.containerName, err)
+			continue
+		}
+
+		candidate.targetNumas, err = machine.Parse(cpusetStats.Mems)
+		if err != nil || candidate.targetNumas.IsEmpty() {
+			klog.Errorf("[MemoryDynamicPolicy.getNumaMigrationCandidates] parse cpuset.mems %q of pod: %s/%s, container: %s failed with error: %v",
+				cpusetStats.Mems, candidate.podNamespace, candidate.podName, candidate.containerName, err)
+			continue
+		}
+
+		numaStat, err := cgroupcmutils.GetNumaStatForContainer(candidate.podUID, containerID)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.getNumaMigrationCandidates] get numa stat of pod: %s/%s, container: %s failed with error: %v",
+				candidate.podNamespace, candidate.podName, candidate.containerName, err)
+			continue
+		}
+
+		candidate.remoteNumas, candidate.remoteBytes, candidate.remoteRatio = getRemoteNumaMemory(numaStat, candidate.targetNumas)
+		_ = p.emitter.StoreFloat64(metricNameMemoryNumaRemoteRatio, candidate.remoteRatio, metrics.MetricTypeNameRaw,
+			metrics.ConvertMapToTags(map[string]string{
+				"podNamespace":  candidate.podNamespace,
+				"podName":       candidate.podName,
+				"containerName": candidate.containerName,
+			})...)
+
+		validCandidates = append(validCandidates, candidate)
+	}
+	return validCandidates
+}
+
+// migrateContainerNumaMemory migrates pages of processes in the container from remote NUMAs
+// to its cpuset.mems one remote NUMA at a time, and the migrated bytes are measured by
+// memory.numa_stat after each migration to be throttled
+func (p *DynamicPolicy) migrateContainerNumaMemory(candidate *numaMigrationCandidate, throttler *numaMigrationThrottler) {
+	memoryAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysMemory, candidate.podUID, candidate.containerID)
+	if err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.migrateContainerNumaMemory] get memory cgroup path of pod: %s/%s, container: %s failed with error: %v",
+			candidate.podNamespace, candidate.podName, candidate.containerName, err)
+		return
+	}
+
+	pids, err := cgroupcmutils.GetPidsWithAbsolutePath(memoryAbsCGPath)
+	if err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.migrateContainerNumaMemory] get pids of pod: %s/%s, container: %s failed with error: %v",
+			candidate.podNamespace, candidate.podName, candidate.containerName, err)
+		return
+	}
+
+	klog.Infof("[MemoryDynamicPolicy.migrateContainerNumaMemory] start to migrate %d bytes of pod: %s/%s, container: %s from numas: %s to numas: %s",
+		candidate.remoteBytes, candidate.podNamespace, candidate.podName, candidate.containerName,
+		candidate.remoteNumas.String(), candidate.targetNumas.String())
+
+	remoteBytes := candidate.remoteBytes
+	var migratedBytes uint64
+	defer func() {
+		klog.Infof("[MemoryDynamicPolicy.migrateContainerNumaMemory] migrated %d bytes of pod: %s/%s, container: %s",
+			migratedBytes, candidate.podNamespace, candidate.podName, candidate.containerName)
+		_ = p.emitter.StoreInt64(metricNameMemoryNumaMigratedBytes, int64(migratedBytes), metrics.MetricTypeNameCount,
+			metrics.ConvertMapToTags(map[string]string{
+				"podNamespace":  candidate.podNamespace,
+				"podName":       candidate.podName,
+				"containerName": candidate.containerName,
+			})...)
+	}()
+
+	for _, pidStr := range pids {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			continue
+		}
+
+		for _, numaID := range candidate.remoteNumas.ToSliceInt() {
+			// processes may exit during migration, so failures are just logged
+			if _, err := machine.MigratePages(pid, machine.NewCPUSet(numaID), candidate.targetNumas); err != nil {
+				klog.Warningf("[MemoryDynamicPolicy.migrateContainerNumaMemory] migrate pages of pid: %d in pod: %s/%s, container: %s failed with error: %v",
+					pid, candidate.podNamespace, candidate.podName, candidate.containerName, err)
+				continue
+			}
+
+			numaStat, err := cgroupcmutils.GetNumaStatWithAbsolutePath(memoryAbsCGPath)
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.migrateContainerNumaMemory] get numa stat of pod: %s/%s, container: %s failed with error: %v",
+					candidate.podNamespace, candidate.podName, candidate.containerName, err)
+				return
+			}
+
+			var migrated uint64
+			_, currentRemoteBytes, _ := getRemoteNumaMemory(numaStat, candidate.targetNumas)
+			if currentRemoteBytes < remoteBytes {
+				migrated = remoteBytes - currentRemoteBytes
+			}
+			remoteBytes = currentRemoteBytes
+			migratedBytes += migrated
+
+			if !throttler.consume(migrated) {
+				return
+			}
+		}
+	}
+}
+
+// getRemoteNumaMemory returns NUMAs holding anonymous pages out of targetNumas, along with the
+// remote bytes and the ratio of remote bytes in all anonymous bytes; file pages are not counted
+// since page cache not mapped by processes can't be migrated by migrate_pages
+func getRemoteNumaMemory(numaStat map[int]*common.MemoryNumaMetrics, targetNumas machine.CPUSet) (machine.CPUSet, uint64, float64) {
+	remoteNumas := machine.NewCPUSet()
+	var remoteBytes, totalBytes uint64
+	for numaID, numaMetrics := range numaStat {
+		if numaMetrics == nil {
+			continue
+		}
+
+		bytes := numaMetrics.Anon
+		totalBytes += bytes
+		if bytes > 0 && !targetNumas.Contains(numaID) {
+			remoteNumas.Add(numaID)
+			remoteBytes += bytes
+		}
+	}
+
+	if totalBytes == 0 {
+		return remoteNumas, 0, 0
+	}
+	return remoteNumas, remoteBytes, float64(remoteBytes) / float64(totalBytes)
+}
+
+// selectNumaMigrationCandidates selects containers whose remote ratio exceeds the threshold,
+// and containers with higher remote ratio are preferred until the budget is used up; a container
+// exceeding the whole budget is still selected if it comes first, otherwise it would never be
+// migrated, and its migration will be stopped by the throttler once the budget is used up.
+func selectNumaMigrationCandidates(candidates []*numaMigrationCandidate, ratioThreshold float64, budget uint64) []*numaMigrationCandidate {
+	var selected []*numaMigrationCandidate
+	for _, candidate := range candidates {
+		if candidate.remoteBytes > 0 && candidate.remoteRatio > ratioThreshold {
+			selected = append(selected, candidate)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].remoteRatio > selected[j].remoteRatio
+	})
+
+	var used uint64
+	for i, candidate := range selected {
+		if i > 0 && used+candidate.remoteBytes > budget {
+			return selected[:i]
+		}
+		used += candidate.remoteBytes
+	}
+	return selected
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+func TestGetRemoteNumaMemory(t *testing.T) {
+	t.Parallel()
+
+	numaStat := map[int]*common.MemoryNumaMetrics{
+		0: {Anon: 6 << 30, File: 2 << 30},
+		1: {Anon: 1 << 30, File: 1 << 30},
+		2: {Anon: 0, File: 0},
+		3: nil,
+	}
+
+	remoteNumas, remoteBytes, remoteRatio := getRemoteNumaMemory(numaStat, machine.NewCPUSet(0))
+	require.True(t, remoteNumas.Equals(machine.NewCPUSet(1)))
+	require.Equal(t, uint64(1<<30), remoteBytes)
+	require.InDelta(t, 1.0/7, remoteRatio, 1e-6)
+
+	// numas only holding file pages are not remote
+	remoteNumas, remoteBytes, _ = getRemoteNumaMemory(map[int]*common.MemoryNumaMetrics{
+		0: {Anon: 1 << 30},
+		1: {File: 1 << 30},
+	}, machine.NewCPUSet(0))
+	require.True(t, remoteNumas.IsEmpty())
+	require.Equal(t, uint64(0), remoteBytes)
+
+	remoteNumas, remoteBytes, remoteRatio = getRemoteNumaMemory(numaStat, machine.NewCPUSet(0, 1))
+	require.True(t, remoteNumas.IsEmpty())
+	require.Equal(t, uint64(0), remoteBytes)
+	require.Equal(t, float64(0), remoteRatio)
+
+	_, remoteBytes, remoteRatio = getRemoteNumaMemory(map[int]*common.MemoryNumaMetrics{}, machine.NewCPUSet(0))
+	require.Equal(t, uint64(0), remoteBytes)
+	require.Equal(t, float64(0), remoteRatio)
+}
+
+func TestSelectNumaMigrationCandidates(t *testing.T) {
+	t.Parallel()
+
+	newCandidate := func(name string, remoteBytes uint64, remoteRatio float64) *numaMigrationCandidate {
+		return &numaMigrationCandidate{containerName: name, remoteBytes: remoteBytes, remoteRatio: remoteRatio}
+	}
+
+	testCases := []struct {
+		name       string
+		candidates []*numaMigrationCandidate
+		threshold  float64
+		budget     uint64
+		expected   []string
+	}{
+		{
+			name: "containers below threshold are skipped",
+			candidates: []*numaMigrationCandidate{
+				newCandidate("c1", 1<<20, 0.05),
+				newCandidate("c2", 1<<20, 0.5),
+				newCandidate("c3", 0, 0),
+			},
+			threshold: 0.1,
+			budget:    1 << 30,
+			expected:  []string{"c2"},
+		},
+		{
+			name: "higher remote ratio is preferred within budget",
+			candidates: []*numaMigrationCandidate{
+				newCandidate("c1", 300<<20, 0.3),
+				newCandidate("c2", 600<<20, 0.9),
+				newCandidate("c3", 200<<20, 0.6),
+			},
+			threshold: 0.1,
+			budget:    800 << 20,
+			expected:  []string{"c2", "c3"},
+		},
+		{
+			name: "the first container is selected even if it exceeds the budget",
+			candidates: []*numaMigrationCandidate{
+				newCandidate("c1", 2<<30, 0.8),
+				newCandidate("c2", 100<<20, 0.5),
+			},
+			threshold: 0.1,
+			budget:    1 << 30,
+			expected:  []string{"c1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var selected []string
+			for _, candidate := range selectNumaMigrationCandidates(tc.candidates, tc.threshold, tc.budget) {
+				selected = append(selected, candidate.containerName)
+			}
+			require.Equal(t, tc.expected, selected)
+		})
+	}
+}
+
+func TestNumaMigrationThrottler(t *testing.T) {
+	t.Parallel()
+
+	throttler := newNumaMigrationThrottler(1<<30, 2<<20, make(chan struct{}))
+	start := time.Now()
+	require.True(t, throttler.consume(1<<20))
+	require.False(t, throttler.exhausted())
+	require.False(t, throttler.consume(1<<20))
+	require.True(t, throttler.exhausted())
+	// 2MB under 1GB/s takes nearly 2ms
+	require.True(t, time.Since(start) >= time.Millisecond)
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+	throttler = newNumaMigrationThrottler(1, 1<<30, stopCh)
+	require.False(t, throttler.consume(1<<20))
+}
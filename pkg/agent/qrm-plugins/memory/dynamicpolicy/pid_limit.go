@@ -0,0 +1,418 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
+	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager/checksum"
+	cmerrors "k8s.io/kubernetes/pkg/kubelet/checkpointmanager/errors"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+)
+
+const (
+	sysctlPIDMax = "kernel.pid_max"
+
+	metricNamePIDLimitReclaimedCores = "pid_limit_reclaimed_cores"
+
+	pidLimitUnlimited = "max"
+
+	pidLimitCheckpointName = "memory_plugin_pid_limit"
+
+	// pidLimitReclaimedCoresKey is the key of the parent cgroup of reclaimed_cores in pidLimitRecorder,
+	// and containers are keyed by pod uid and container name
+	pidLimitReclaimedCoresKey = "reclaimed_cores"
+)
+
+// pidLimitRecord is the original pids.max of a cgroup before it's limited, and -1 means max
+type pidLimitRecord struct {
+	CgroupPath  string `json:"cgroupPath"`
+	OriginalMax int64  `json:"originalMax"`
+}
+
+// pidLimitCheckpoint persists records of cgroups limited by the plugin
+type pidLimitCheckpoint struct {
+	Records  map[string]pidLimitRecord `json:"records"`
+	Checksum checksum.Checksum         `json:"checksum"`
+}
+
+var _ checkpointmanager.Checkpoint = &pidLimitCheckpoint{}
+
+func (cp *pidLimitCheckpoint) MarshalCheckpoint() ([]byte, error) {
+	cp.Checksum = 0
+	cp.Checksum = checksum.New(cp)
+	return json.Marshal(*cp)
+}
+
+func (cp *pidLimitCheckpoint) UnmarshalCheckpoint(blob []byte) error {
+	return json.Unmarshal(blob, cp)
+}
+
+func (cp *pidLimitCheckpoint) VerifyChecksum() error {
+	ck := cp.Checksum
+	cp.Checksum = 0
+	err := ck.Verify(cp)
+	cp.Checksum = ck
+	return err
+}
+
+// pidLimitRecorder records original pids.max of cgroups before they're limited by the plugin, so that
+// only those cgroups are restored, and pids.max set by others (e.g. pids limit of runtimes or pod pids
+// limit of kubelet) is never touched; records are checkpointed to survive restarts.
+type pidLimitRecorder struct {
+	mutex sync.Mutex
+
+	checkpointManager checkpointmanager.CheckpointManager
+	records           map[string]pidLimitRecord
+}
+
+// newPIDLimitRecorder loads records from the checkpoint, and a corrupted checkpoint is discarded
+// since it's not worth blocking the plugin, though cgroups recorded in it won't be restored then.
+func newPIDLimitRecorder(stateDir string) (*pidLimitRecorder, error) {
+	checkpointManager, err := checkpointmanager.NewCheckpointManager(stateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize checkpoint manager: %v", err)
+	}
+
+	r := &pidLimitRecorder{
+		checkpointManager: checkpointManager,
+		records:           make(map[string]pidLimitRecord),
+	}
+
+	cp := &pidLimitCheckpoint{}
+	err = checkpointManager.GetCheckpoint(pidLimitCheckpointName, cp)
+	switch {
+	case err == nil:
+		for key, record := range cp.Records {
+			r.records[key] = record
+		}
+	case errors.Is(err, cmerrors.ErrCheckpointNotFound):
+	default:
+		klog.Errorf("[pidLimitRecorder] get checkpoint failed with error: %v, discard it", err)
+		if err := checkpointManager.RemoveCheckpoint(pidLimitCheckpointName); err != nil {
+			return nil, fmt.Errorf("remove checkpoint of pid limit failed with error: %v", err)
+		}
+	}
+	return r, nil
+}
+
+func (r *pidLimitRecorder) get(key string) (pidLimitRecord, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	record, ok := r.records[key]
+	return record, ok
+}
+
+func (r *pidLimitRecorder) keys() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	keys := make([]string, 0, len(r.records))
+	for key := range r.records {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// add records the cgroup and it must succeed before the cgroup is limited
+func (r *pidLimitRecorder) add(key string, record pidLimitRecord) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.records[key] = record
+	if err := r.storeLocked(); err != nil {
+		delete(r.records, key)
+		return err
+	}
+	return nil
+}
+
+func (r *pidLimitRecorder) remove(key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	record, ok := r.records[key]
+	if !ok {
+		return nil
+	}
+
+	delete(r.records, key)
+	if err := r.storeLocked(); err != nil {
+		r.records[key] = record
+		return err
+	}
+	return nil
+}
+
+func (r *pidLimitRecorder) storeLocked() error {
+	if len(r.records) == 0 {
+		if err := r.checkpointManager.RemoveCheckpoint(pidLimitCheckpointName); err != nil {
+			return fmt.Errorf("remove checkpoint of pid limit failed with error: %v", err)
+		}
+		return nil
+	}
+
+	cp := &pidLimitCheckpoint{Records: make(map[string]pidLimitRecord, len(r.records))}
+	for key, record := range r.records {
+		cp.Records[key] = record
+	}
+	if err := r.checkpointManager.CreateCheckpoint(pidLimitCheckpointName, cp); err != nil {
+		return fmt.Errorf("create checkpoint of pid limit failed with error: %v", err)
+	}
+	return nil
+}
+
+// setPIDLimit protects the node from pid exhaustion (e.g. fork bombs), in which
+// 1. all reclaimed_cores containers share a fraction of kernel.pid_max, and it's
+// set as pids.max of their parent cgroup since pids.max is hierarchical
+// 2. pids.max of containers is capped by the annotation of their pods if any,
+// otherwise by the limit of their QoS levels
+// cgroups without any limit configured are never touched, and limits set by the plugin
+// are restored to the original ones once they aren't configured or annotated any more
+func (p *DynamicPolicy) setPIDLimit() {
+	p.setReclaimedCoresPIDLimit()
+	p.setContainerPIDLimit()
+}
+
+// resetPIDLimit restores pids.max of cgroups limited by the plugin before when pid limit is disabled
+func (p *DynamicPolicy) resetPIDLimit() {
+	for _, key := range p.pidLimitRecorder.keys() {
+		p.restorePIDLimit(key)
+	}
+}
+
+// setReclaimedCoresPIDLimit sets pids.max of the parent cgroup of reclaimed_cores
+func (p *DynamicPolicy) setReclaimedCoresPIDLimit() {
+	if p.pidLimitConf.PIDLimitReclaimedCoresRatio <= 0 {
+		p.restorePIDLimit(pidLimitReclaimedCoresKey)
+		return
+	}
+
+	pidMax, err := p.procFS.ReadSysctlInt(sysctlPIDMax)
+	if err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.setReclaimedCoresPIDLimit] read %s failed with error: %v", sysctlPIDMax, err)
+		return
+	}
+	limit := getReclaimedCoresPIDLimit(pidMax, p.pidLimitConf.PIDLimitReclaimedCoresRatio)
+
+	absCgroupPath := common.GetAbsCgroupPath(common.CgroupSubsysPids, p.reclaimRelativeRootCgroupPath)
+	if err := p.limitPIDs(pidLimitReclaimedCoresKey, absCgroupPath, limit); err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.setReclaimedCoresPIDLimit] apply pids.max: %d to %s failed with error: %v",
+			limit, absCgroupPath, err)
+		return
+	}
+
+	klog.V(4).Infof("[MemoryDynamicPolicy.setReclaimedCoresPIDLimit] apply pids.max: %d to %s, pid_max: %d",
+		limit, absCgroupPath, pidMax)
+	_ = p.emitter.StoreInt64(metricNamePIDLimitReclaimedCores, limit, metrics.MetricTypeNameRaw)
+}
+
+// setContainerPIDLimit sets pids.max of containers with limits, and limits set before are restored for
+// containers without limits; records of containers whose pods are gone are dropped along with cgroups.
+func (p *DynamicPolicy) setContainerPIDLimit() {
+	podList, err := p.metaServer.GetPodList(context.Background(), nil)
+	if err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.setContainerPIDLimit] get pod list failed with error: %v", err)
+		return
+	}
+
+	existingKeys := sets.NewString(pidLimitReclaimedCoresKey)
+	for _, pod := range podList {
+		if pod == nil {
+			continue
+		}
+
+		for _, container := range pod.Spec.Containers {
+			existingKeys.Insert(getContainerPIDLimitKey(string(pod.UID), container.Name))
+		}
+
+		limit, err := p.getContainerPIDLimit(pod)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setContainerPIDLimit] get pid limit of pod: %s/%s failed with error: %v",
+				pod.Namespace, pod.Name, err)
+			continue
+		}
+
+		p.applyPodPIDLimit(pod, limit)
+	}
+
+	for _, key := range p.pidLimitRecorder.keys() {
+		if existingKeys.Has(key) {
+			continue
+		}
+
+		if err := p.pidLimitRecorder.remove(key); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setContainerPIDLimit] remove pid limit record of %s failed with error: %v", key, err)
+		}
+	}
+}
+
+// getContainerPIDLimit returns pids.max of each container in the pod, in which the annotation
+// takes precedence over the QoS level, and -1 is returned if there is no limit
+func (p *DynamicPolicy) getContainerPIDLimit(pod *v1.Pod) (int64, error) {
+	if p.pidLimitConf.PIDLimitAnnotationKey != "" {
+		if value, ok := pod.Annotations[p.pidLimitConf.PIDLimitAnnotationKey]; ok {
+			return parsePIDLimit(value)
+		}
+	}
+
+	qosLevel, err := p.qosConfig.GetQoSLevelForPod(pod)
+	if err != nil {
+		return 0, err
+	}
+
+	var limit int64
+	switch qosLevel {
+	case apiconsts.PodAnnotationQoSLevelReclaimedCores:
+		limit = p.pidLimitConf.ContainerPIDLimit.ReclaimedCores
+	case apiconsts.PodAnnotationQoSLevelSharedCores:
+		limit = p.pidLimitConf.ContainerPIDLimit.SharedCores
+	case apiconsts.PodAnnotationQoSLevelDedicatedCores:
+		limit = p.pidLimitConf.ContainerPIDLimit.DedicatedCores
+	case apiconsts.PodAnnotationQoSLevelSystemCores:
+		limit = p.pidLimitConf.ContainerPIDLimit.SystemCores
+	}
+
+	if limit <= 0 {
+		return -1, nil
+	}
+	return limit, nil
+}
+
+// applyPodPIDLimit limits each container of the pod, or restores them if there is no limit
+func (p *DynamicPolicy) applyPodPIDLimit(pod *v1.Pod, limit int64) {
+	podUID := string(pod.UID)
+	for _, container := range pod.Spec.Containers {
+		key := getContainerPIDLimitKey(podUID, container.Name)
+		if limit <= 0 {
+			p.restorePIDLimit(key)
+			continue
+		}
+
+		containerID, err := p.metaServer.GetContainerID(podUID, container.Name)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.applyPodPIDLimit] get container id of pod: %s/%s container: %s failed with error: %v",
+				pod.Namespace, pod.Name, container.Name, err)
+			continue
+		}
+
+		absCgroupPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysPids, podUID, containerID)
+		if err != nil {
+			klog.V(4).Infof("[MemoryDynamicPolicy.applyPodPIDLimit] get cgroup path of pod: %s/%s container: %s failed with error: %v",
+				pod.Namespace, pod.Name, container.Name, err)
+			continue
+		}
+
+		if err := p.limitPIDs(key, absCgroupPath, limit); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.applyPodPIDLimit] apply pids.max: %d for pod: %s/%s, container: %s failed with error: %v",
+				limit, pod.Namespace, pod.Name, container.Name, err)
+		}
+	}
+}
+
+// limitPIDs sets pids.max of the cgroup, and its original pids.max is recorded before it's limited
+// for the first time; a recreated cgroup (e.g. a restarted container) is recorded again.
+func (p *DynamicPolicy) limitPIDs(key, absCgroupPath string, limit int64) error {
+	if record, ok := p.pidLimitRecorder.get(key); !ok || record.CgroupPath != absCgroupPath {
+		stats, err := p.getPidsStatsFunc(absCgroupPath)
+		if err != nil {
+			return fmt.Errorf("get original pids.max failed with error: %v", err)
+		}
+
+		originalMax := stats.Max
+		if originalMax == math.MaxInt64 {
+			originalMax = -1
+		}
+
+		if err := p.pidLimitRecorder.add(key, pidLimitRecord{CgroupPath: absCgroupPath, OriginalMax: originalMax}); err != nil {
+			return err
+		}
+	}
+
+	return p.applyPidsFunc(absCgroupPath, &common.PidsData{Max: limit})
+}
+
+// restorePIDLimit restores the original pids.max of the cgroup if it's limited by the plugin before
+func (p *DynamicPolicy) restorePIDLimit(key string) {
+	record, ok := p.pidLimitRecorder.get(key)
+	if !ok {
+		return
+	}
+
+	if _, err := os.Stat(record.CgroupPath); err == nil {
+		if err := p.applyPidsFunc(record.CgroupPath, &common.PidsData{Max: record.OriginalMax}); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.restorePIDLimit] restore pids.max: %d to %s failed with error: %v",
+				record.OriginalMax, record.CgroupPath, err)
+			return
+		}
+		klog.Infof("[MemoryDynamicPolicy.restorePIDLimit] restore pids.max: %d to %s", record.OriginalMax, record.CgroupPath)
+	} else if !os.IsNotExist(err) {
+		klog.Errorf("[MemoryDynamicPolicy.restorePIDLimit] stat %s failed with error: %v", record.CgroupPath, err)
+		return
+	}
+
+	if err := p.pidLimitRecorder.remove(key); err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.restorePIDLimit] remove pid limit record of %s failed with error: %v", key, err)
+	}
+}
+
+func getContainerPIDLimitKey(podUID, containerName string) string {
+	return podUID + "/" + containerName
+}
+
+// getReclaimedCoresPIDLimit returns the fraction of pid_max shared by reclaimed_cores,
+// and at least one pid is left so that pids.max won't be misinterpreted as not set
+func getReclaimedCoresPIDLimit(pidMax int64, ratio float64) int64 {
+	limit := int64(float64(pidMax) * ratio)
+	if limit < 1 {
+		return 1
+	}
+	return limit
+}
+
+// parsePIDLimit parses the annotated pid limit, which is either a positive number or "max";
+// -1 is returned for "max" to follow the convention of common.PidsData
+func parsePIDLimit(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == pidLimitUnlimited {
+		return -1, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	} else if limit <= 0 {
+		return 0, fmt.Errorf("invalid pid limit: %d", limit)
+	}
+	return limit, nil
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+	"github.com/kubewharf/katalyst-core/pkg/config/generic"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+func TestGetReclaimedCoresPIDLimit(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, int64(838860), getReclaimedCoresPIDLimit(4194304, 0.2))
+	require.Equal(t, int64(32768), getReclaimedCoresPIDLimit(32768, 1))
+	require.Equal(t, int64(1), getReclaimedCoresPIDLimit(32768, 0.00001))
+}
+
+func TestParsePIDLimit(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		value     string
+		expected  int64
+		expectErr bool
+	}{
+		{
+			name:     "numeric limit",
+			value:    "1024",
+			expected: 1024,
+		},
+		{
+			name:     "numeric limit with spaces",
+			value:    " 2048 ",
+			expected: 2048,
+		},
+		{
+			name:     "unlimited",
+			value:    "max",
+			expected: -1,
+		},
+		{
+			name:      "zero is invalid",
+			value:     "0",
+			expectErr: true,
+		},
+		{
+			name:      "negative is invalid",
+			value:     "-5",
+			expectErr: true,
+		},
+		{
+			name:      "non-numeric is invalid",
+			value:     "abc",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			limit, err := parsePIDLimit(tc.value)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, limit)
+		})
+	}
+}
+
+func TestGetContainerPIDLimit(t *testing.T) {
+	t.Parallel()
+
+	qosConfig := generic.NewQoSConfiguration()
+	qosConfig.SetExpandQoSLevelSelector(consts.PodAnnotationQoSLevelSharedCores, map[string]string{
+		consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+	})
+	qosConfig.SetExpandQoSLevelSelector(consts.PodAnnotationQoSLevelReclaimedCores, map[string]string{
+		consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelReclaimedCores,
+	})
+
+	dynamicPolicy := &DynamicPolicy{
+		qosConfig: qosConfig,
+		pidLimitConf: qrm.PIDLimitConfig{
+			PIDLimitAnnotationKey: "pids_max",
+			ContainerPIDLimit: qrm.ContainerPIDLimitConfig{
+				ReclaimedCores: 1024,
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    int64
+		expectErr   bool
+	}{
+		{
+			name: "limit of reclaimed_cores",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelReclaimedCores,
+			},
+			expected: 1024,
+		},
+		{
+			name: "shared_cores without limit",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+			},
+			expected: -1,
+		},
+		{
+			name: "annotation overrides limit of qos level",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelReclaimedCores,
+				"pids_max":                      "max",
+			},
+			expected: -1,
+		},
+		{
+			name: "invalid annotation",
+			annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+				"pids_max":                      "0",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			limit, err := dynamicPolicy.getContainerPIDLimit(&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+			})
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, limit)
+		})
+	}
+}
+
+func TestSetReclaimedCoresPIDLimit(t *testing.T) {
+	t.Parallel()
+
+	procRoot := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "sys", "kernel"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(procRoot, "sys", "kernel", "pid_max"), []byte("1000\n"), 0o644))
+
+	recorder, err := newPIDLimitRecorder(t.TempDir())
+	require.NoError(t, err)
+
+	var applied []int64
+	dynamicPolicy := &DynamicPolicy{
+		emitter:          metrics.DummyMetrics{},
+		procFS:           procfs.NewProcFS(procRoot),
+		pidLimitRecorder: recorder,
+		applyPidsFunc: func(_ string, data *common.PidsData) error {
+			applied = append(applied, data.Max)
+			return nil
+		},
+		getPidsStatsFunc: func(_ string) (*common.PidsStats, error) {
+			return &common.PidsStats{Max: math.MaxInt64}, nil
+		},
+	}
+
+	// pids.max is never touched if the limit is not configured
+	dynamicPolicy.setReclaimedCoresPIDLimit()
+	require.Empty(t, applied)
+	require.Empty(t, recorder.keys())
+
+	dynamicPolicy.pidLimitConf.PIDLimitReclaimedCoresRatio = 0.2
+	dynamicPolicy.setReclaimedCoresPIDLimit()
+	require.Equal(t, []int64{200}, applied)
+	record, ok := recorder.get(pidLimitReclaimedCoresKey)
+	require.True(t, ok)
+	require.Equal(t, int64(-1), record.OriginalMax)
+}
+
+func TestLimitAndRestorePIDs(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	recorder, err := newPIDLimitRecorder(stateDir)
+	require.NoError(t, err)
+
+	applied := make(map[string]int64)
+	dynamicPolicy := &DynamicPolicy{
+		pidLimitRecorder: recorder,
+		applyPidsFunc: func(absCgroupPath string, data *common.PidsData) error {
+			applied[absCgroupPath] = data.Max
+			return nil
+		},
+		getPidsStatsFunc: func(_ string) (*common.PidsStats, error) {
+			return &common.PidsStats{Max: 4096}, nil
+		},
+	}
+
+	cgroupPath := t.TempDir()
+	key := getContainerPIDLimitKey("pod-uid", "container")
+
+	// cgroups not limited by the plugin are never restored
+	dynamicPolicy.restorePIDLimit(key)
+	require.Empty(t, applied)
+
+	require.NoError(t, dynamicPolicy.limitPIDs(key, cgroupPath, 1024))
+	require.Equal(t, int64(1024), applied[cgroupPath])
+
+	// the original pids.max is kept when it's limited again
+	dynamicPolicy.getPidsStatsFunc = func(_ string) (*common.PidsStats, error) {
+		return &common.PidsStats{Max: 1024}, nil
+	}
+	require.NoError(t, dynamicPolicy.limitPIDs(key, cgroupPath, 512))
+	require.Equal(t, int64(512), applied[cgroupPath])
+
+	// records survive restarts
+	restarted, err := newPIDLimitRecorder(stateDir)
+	require.NoError(t, err)
+	record, ok := restarted.get(key)
+	require.True(t, ok)
+	require.Equal(t, pidLimitRecord{CgroupPath: cgroupPath, OriginalMax: 4096}, record)
+
+	dynamicPolicy.pidLimitRecorder = restarted
+	dynamicPolicy.resetPIDLimit()
+	require.Equal(t, int64(4096), applied[cgroupPath])
+	require.Empty(t, restarted.keys())
+
+	// records of removed cgroups are dropped without restoring
+	removedPath := filepath.Join(t.TempDir(), "removed")
+	require.NoError(t, dynamicPolicy.limitPIDs(key, removedPath, 1024))
+	delete(applied, removedPath)
+	dynamicPolicy.restorePIDLimit(key)
+	require.NotContains(t, applied, removedPath)
+	require.Empty(t, restarted.keys())
+}
+
+func TestNewPIDLimitRecorderWithCorruptedCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	stateDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(stateDir, pidLimitCheckpointName), []byte("corrupted"), 0o644))
+
+	recorder, err := newPIDLimitRecorder(stateDir)
+	require.NoError(t, err)
+	require.Empty(t, recorder.keys())
+	require.NoFileExists(t, filepath.Join(stateDir, pidLimitCheckpointName))
+}
@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	pluginapi "k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
+	"k8s.io/utils/clock"
 
 	"github.com/kubewharf/katalyst-api/pkg/consts"
 	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
@@ -38,6 +39,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/state"
 	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/util"
 	"github.com/kubewharf/katalyst-core/pkg/config"
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
 	"github.com/kubewharf/katalyst-core/pkg/config/generic"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
@@ -46,6 +48,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 	"github.com/kubewharf/katalyst-core/pkg/util/native"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
 )
 
 const (
@@ -81,13 +84,49 @@ type DynamicPolicy struct {
 
 	migrateMemoryLock sync.Mutex
 	migratingMemory   map[string]map[string]bool
-	residualHitMap    map[string]int64
+	// numaMigrationRunning is set while a round of numa migration is running
+	numaMigrationRunning int32
+	// reclaimPoolNumaLimitRunning is set while file cache of reclaim pool exceeding
+	// memory limit of numa nodes is being dropped
+	reclaimPoolNumaLimitRunning int32
+
+	dropCacheLock  sync.Mutex
+	droppingCache  map[string]map[string]bool
+	residualHitMap map[string]int64
+
+	// swapKnobLock serializes swap control with cache dropping, since dropping cache
+	// overrides swap knobs of the container temporarily and restores them afterwards
+	swapKnobLock sync.Mutex
+
+	// memoryHighContainers records containers with memory.high applied, keyed by pod uid and container name
+	memoryHighLock       sync.Mutex
+	memoryHighContainers map[string]map[string]string
 
 	allocationHandlers map[string]util.AllocationHandler
 	hintHandlers       map[string]util.HintHandler
 
 	extraStateFileAbsPath string
 	name                  string
+
+	enableMemoryAdvisor        bool
+	memoryAdvisorSocketAbsPath string
+
+	proactiveReclaimConf qrmconfig.ProactiveReclaimConfig
+	memoryProtectionConf qrmconfig.MemoryProtectionConfig
+	swapControlConf      qrmconfig.SwapControlConfig
+	rdtConf              qrmconfig.RDTConfig
+
+	watermarkTunerConf qrmconfig.WatermarkTunerConfig
+	watermarkTuner     *watermarkTuner
+
+	numaMigrationConf qrmconfig.NumaMigrationConfig
+
+	pidLimitConf                  qrmconfig.PIDLimitConfig
+	applyPidsFunc                 func(absCgroupPath string, data *common.PidsData) error
+	getPidsStatsFunc              func(absCgroupPath string) (*common.PidsStats, error)
+	pidLimitRecorder              *pidLimitRecorder
+	procFS                        *procfs.ProcFS
+	reclaimRelativeRootCgroupPath string
 }
 
 func NewDynamicPolicy(agentCtx *agent.GenericContext, conf *config.Configuration, _ interface{}, agentName string) (bool, agent.Component, error) {
@@ -109,22 +148,51 @@ func NewDynamicPolicy(agentCtx *agent.GenericContext, conf *config.Configuration
 	readonlyState = stateImpl
 	readonlyStateLock.Unlock()
 
+	watermarkTuner, err := newWatermarkTuner(conf.WatermarkTunerConfig, procfs.NewDefaultProcFS(),
+		conf.GenericQRMPluginConfiguration.StateFileDirectory)
+	if err != nil {
+		return false, agent.ComponentStub{}, fmt.Errorf("newWatermarkTuner failed with error: %v", err)
+	}
+
+	pidLimitRecorder, err := newPIDLimitRecorder(conf.GenericQRMPluginConfiguration.StateFileDirectory)
+	if err != nil {
+		return false, agent.ComponentStub{}, fmt.Errorf("newPIDLimitRecorder failed with error: %v", err)
+	}
+
 	wrappedEmitter := agentCtx.EmitterPool.GetDefaultMetricsEmitter().WithTags(agentName, metrics.MetricTag{
 		Key: util.QRMPluginPolicyTagName,
 		Val: MemoryResourcePluginPolicyNameDynamic,
 	})
 
 	policyImplement := &DynamicPolicy{
-		topology:              agentCtx.CPUTopology,
-		qosConfig:             conf.QoSConfiguration,
-		emitter:               wrappedEmitter,
-		metaServer:            agentCtx.MetaServer,
-		state:                 stateImpl,
-		stopCh:                make(chan struct{}),
-		migratingMemory:       make(map[string]map[string]bool),
-		residualHitMap:        make(map[string]int64),
-		extraStateFileAbsPath: conf.ExtraStateFileAbsPath,
-		name:                  fmt.Sprintf("%s_%s", agentName, MemoryResourcePluginPolicyNameDynamic),
+		topology:                   agentCtx.CPUTopology,
+		qosConfig:                  conf.QoSConfiguration,
+		emitter:                    wrappedEmitter,
+		metaServer:                 agentCtx.MetaServer,
+		state:                      stateImpl,
+		stopCh:                     make(chan struct{}),
+		migratingMemory:            make(map[string]map[string]bool),
+		droppingCache:              make(map[string]map[string]bool),
+		memoryHighContainers:       make(map[string]map[string]string),
+		residualHitMap:             make(map[string]int64),
+		extraStateFileAbsPath:      conf.ExtraStateFileAbsPath,
+		name:                       fmt.Sprintf("%s_%s", agentName, MemoryResourcePluginPolicyNameDynamic),
+		enableMemoryAdvisor:        conf.MemoryQRMPluginConfig.EnableSysAdvisor,
+		memoryAdvisorSocketAbsPath: conf.MemoryAdvisorSocketAbsPath,
+		proactiveReclaimConf:       conf.ProactiveReclaimConfig,
+		memoryProtectionConf:       conf.MemoryProtectionConfig,
+		swapControlConf:            conf.SwapControlConfig,
+		rdtConf:                    conf.RDTConfig,
+		watermarkTunerConf:         conf.WatermarkTunerConfig,
+		watermarkTuner:             watermarkTuner,
+		numaMigrationConf:          conf.NumaMigrationConfig,
+		pidLimitConf:               conf.PIDLimitConfig,
+		applyPidsFunc:              cgroupcmutils.ApplyPidsWithAbsolutePath,
+		getPidsStatsFunc:           cgroupcmutils.GetPidsStatsWithAbsolutePath,
+		pidLimitRecorder:           pidLimitRecorder,
+		procFS:                     procfs.NewDefaultProcFS(),
+
+		reclaimRelativeRootCgroupPath: conf.ReclaimRelativeRootCgroupPath,
 	}
 
 	policyImplement.allocationHandlers = map[string]util.AllocationHandler{
@@ -177,6 +245,61 @@ func (p *DynamicPolicy) Start() (err error) {
 	go wait.Until(p.checkMemorySet, memsetCheckPeriod, p.stopCh)
 	go wait.Until(p.setMemoryMigrate, 5*time.Second, p.stopCh)
 
+	if p.proactiveReclaimConf.EnableProactiveReclaim {
+		go wait.Until(p.proactiveReclaim, p.proactiveReclaimConf.ProactiveReclaimPeriod, p.stopCh)
+	}
+
+	if p.memoryProtectionConf.EnableMemoryProtection {
+		go wait.Until(p.setMemoryProtection, p.memoryProtectionConf.MemoryProtectionPeriod, p.stopCh)
+	}
+
+	if p.swapControlConf.EnableSwapControl {
+		go wait.Until(p.setSwapControl, p.swapControlConf.SwapControlPeriod, p.stopCh)
+	}
+
+	if p.rdtConf.EnableRDT {
+		if err := p.metaServer.InitRDT(); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.Start] init rdt failed with error: %v", err)
+		} else {
+			go wait.Until(p.setRDT, p.rdtConf.RDTPeriod, p.stopCh)
+		}
+	}
+
+	if p.enableMemoryAdvisor {
+		if p.memoryAdvisorSocketAbsPath == "" {
+			return fmt.Errorf("invalid memoryAdvisorSocketAbsPath: %s", p.memoryAdvisorSocketAbsPath)
+		}
+
+		communicateWithMemoryAdvisorServer := func() {
+			if err := p.lwMemoryAdvisorServer(p.stopCh); err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.Start] lwMemoryAdvisorServer failed with error: %v", err)
+			} else {
+				klog.Infof("[MemoryDynamicPolicy.Start] lwMemoryAdvisorServer finished")
+			}
+		}
+
+		go wait.BackoffUntil(communicateWithMemoryAdvisorServer, wait.NewExponentialBackoffManager(800*time.Millisecond,
+			30*time.Second, 2*time.Minute, 2.0, 0, &clock.RealClock{}), true, p.stopCh)
+	}
+
+	if p.watermarkTunerConf.EnableWatermarkTuner {
+		if err := p.watermarkTuner.start(); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.Start] start watermark tuner failed with error: %v", err)
+		} else {
+			go wait.Until(p.tuneWatermarks, p.watermarkTunerConf.WatermarkTunerPeriod, p.stopCh)
+		}
+	}
+
+	if p.numaMigrationConf.EnableNumaMigration {
+		go wait.Until(p.migrateNumaMemory, p.numaMigrationConf.NumaMigrationPeriod, p.stopCh)
+	}
+
+	if p.pidLimitConf.EnablePIDLimit {
+		go wait.Until(p.setPIDLimit, p.pidLimitConf.PIDLimitPeriod, p.stopCh)
+	} else {
+		go p.resetPIDLimit()
+	}
+
 	return nil
 }
 
@@ -194,6 +317,12 @@ func (p *DynamicPolicy) Stop() error {
 		return nil
 	}
 	close(p.stopCh)
+
+	if p.watermarkTuner != nil {
+		if err := p.watermarkTuner.restore(); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.Stop] restore watermarks failed with error: %v", err)
+		}
+	}
 	return nil
 }
 
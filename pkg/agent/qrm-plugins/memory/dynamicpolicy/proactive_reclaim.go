@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	pluginapi "k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+)
+
+const (
+	metricNameProactiveReclaimBytes   = "proactive_reclaim_bytes"
+	metricNameProactiveReclaimBackoff = "proactive_reclaim_backoff"
+
+	metricTagValueBackoffNode      = "node"
+	metricTagValueBackoffContainer = "container"
+)
+
+// reclaimCandidate is a reclaimed_cores container that proactive reclaim may act on
+type reclaimCandidate struct {
+	podUID        string
+	podNamespace  string
+	podName       string
+	containerName string
+	containerID   string
+
+	usage        uint64
+	inactiveFile uint64
+	target       uint64
+}
+
+// proactiveReclaim reclaims page caches of reclaimed_cores containers periodically, notice that
+// 1. targets are derived from inactive file pages and the reclaimed memory headroom reported in CNR
+// 2. the total bytes reclaimed in one round is limited to avoid hurting the whole node
+// 3. reclaim backs off when memory PSI of node or the container is above threshold
+func (p *DynamicPolicy) proactiveReclaim() {
+	conf := p.proactiveReclaimConf
+
+	if stats, err := common.GetNodePressure(string(v1.ResourceMemory)); err != nil {
+		klog.V(4).Infof("[MemoryDynamicPolicy.proactiveReclaim] get node memory pressure failed with error: %v", err)
+	} else if stats.Some.Avg10 > conf.ProactiveReclaimPSIAvg10Threshold {
+		klog.Infof("[MemoryDynamicPolicy.proactiveReclaim] back off since node memory psi avg10: %.2f exceeds threshold: %.2f",
+			stats.Some.Avg10, conf.ProactiveReclaimPSIAvg10Threshold)
+		_ = p.emitter.StoreInt64(metricNameProactiveReclaimBackoff, 1, metrics.MetricTypeNameCount,
+			metrics.MetricTag{Key: "level", Val: metricTagValueBackoffNode})
+		return
+	}
+
+	candidates := p.getProactiveReclaimCandidates()
+	if len(candidates) == 0 {
+		return
+	}
+
+	computeProactiveReclaimTargets(candidates, p.getReclaimedMemoryHeadroom(), conf)
+
+	podReclaimed := make(map[string]int64)
+	for _, candidate := range candidates {
+		if candidate.target == 0 {
+			continue
+		}
+
+		if p.isContainerUnderMemoryPressure(candidate) {
+			_ = p.emitter.StoreInt64(metricNameProactiveReclaimBackoff, 1, metrics.MetricTypeNameCount,
+				metrics.MetricTag{Key: "level", Val: metricTagValueBackoffContainer})
+			continue
+		}
+
+		reclaimed, err := cgroupcmutils.ReclaimMemoryForContainer(candidate.podUID, candidate.containerID, int64(candidate.target))
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.proactiveReclaim] reclaim memory for pod: %s/%s, container: %s failed with error: %v",
+				candidate.podNamespace, candidate.podName, candidate.containerName, err)
+			continue
+		}
+
+		klog.Infof("[MemoryDynamicPolicy.proactiveReclaim] pod: %s/%s, container: %s, inactive file: %d, target: %d, reclaimed: %d",
+			candidate.podNamespace, candidate.podName, candidate.containerName, candidate.inactiveFile, candidate.target, reclaimed)
+		podReclaimed[candidate.podUID] += reclaimed
+	}
+
+	for _, candidate := range candidates {
+		reclaimed, ok := podReclaimed[candidate.podUID]
+		if !ok {
+			continue
+		}
+		delete(podReclaimed, candidate.podUID)
+
+		_ = p.emitter.StoreInt64(metricNameProactiveReclaimBytes, reclaimed, metrics.MetricTypeNameRaw,
+			metrics.ConvertMapToTags(map[string]string{
+				"podNamespace": candidate.podNamespace,
+				"podName":      candidate.podName,
+			})...)
+	}
+}
+
+// getProactiveReclaimCandidates collects main containers of reclaimed_cores pods along with their memory metrics
+func (p *DynamicPolicy) getProactiveReclaimCandidates() []*reclaimCandidate {
+	p.RLock()
+	var candidates []*reclaimCandidate
+	for podUID, containerEntries := range p.state.GetPodResourceEntries()[v1.ResourceMemory] {
+		for containerName, allocationInfo := range containerEntries {
+			if allocationInfo == nil || containerName == "" ||
+				allocationInfo.QoSLevel != apiconsts.PodAnnotationQoSLevelReclaimedCores ||
+				allocationInfo.ContainerType != pluginapi.ContainerType_MAIN.String() {
+				continue
+			}
+
+			candidates = append(candidates, &reclaimCandidate{
+				podUID:        podUID,
+				podNamespace:  allocationInfo.PodNamespace,
+				podName:       allocationInfo.PodName,
+				containerName: containerName,
+			})
+		}
+	}
+	p.RUnlock()
+
+	validCandidates := make([]*reclaimCandidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		containerID, err := p.metaServer.GetContainerID(candidate.podUID, candidate.containerName)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.getProactiveReclaimCandidates] get container id of pod: %s container: %s failed with error: %v",
+				candidate.podUID, candidate.containerName, err)
+			continue
+		}
+		candidate.containerID = containerID
+
+		cgroupMetrics, err := cgroupcmutils.GetMetricsForContainer(candidate.podUID, containerID,
+			map[string]struct{}{common.CgroupSubsysMemory: {}})
+		if err != nil || cgroupMetrics.Memory == nil {
+			klog.Errorf("[MemoryDynamicPolicy.getProactiveReclaimCandidates] get memory metrics of pod: %s container: %s failed with error: %v",
+				candidate.podUID, candidate.containerName, err)
+			continue
+		}
+
+		candidate.usage = cgroupMetrics.Memory.UsageUsage
+		candidate.inactiveFile = cgroupMetrics.Memory.InactiveFile
+		validCandidates = append(validCandidates, candidate)
+	}
+
+	return validCandidates
+}
+
+// getReclaimedMemoryHeadroom returns the reclaimed memory reported to CNR by the memory headroom
+// policy in sys-advisor, and -1 will be returned if it's unavailable
+func (p *DynamicPolicy) getReclaimedMemoryHeadroom() int64 {
+	cnr, err := p.metaServer.GetCNR(context.Background())
+	if err != nil || cnr == nil || cnr.Status.ResourceAllocatable == nil {
+		klog.Warningf("[MemoryDynamicPolicy.getReclaimedMemoryHeadroom] get reclaimed memory from cnr failed with error: %v", err)
+		return -1
+	}
+
+	quantity, ok := (*cnr.Status.ResourceAllocatable)[apiconsts.ReclaimedResourceMemory]
+	if !ok {
+		return -1
+	}
+	return quantity.Value()
+}
+
+// isContainerUnderMemoryPressure checks cgroup-level memory PSI, and containers
+// without PSI interface files (e.g. cgroupv1 without psi enabled) are never under pressure
+func (p *DynamicPolicy) isContainerUnderMemoryPressure(candidate *reclaimCandidate) bool {
+	stats, err := cgroupcmutils.GetPressureForContainer(candidate.podUID, candidate.containerID, common.PressureResourceMemory)
+	if err != nil || stats.Some == nil {
+		return false
+	}
+
+	if stats.Some.Avg10 > p.proactiveReclaimConf.ProactiveReclaimPSIAvg10Threshold {
+		klog.Infof("[MemoryDynamicPolicy.proactiveReclaim] skip pod: %s/%s, container: %s since memory psi avg10: %.2f exceeds threshold",
+			candidate.podNamespace, candidate.podName, candidate.containerName, stats.Some.Avg10)
+		return true
+	}
+	return false
+}
+
+// computeProactiveReclaimTargets sets reclaim target for each candidate. Each container
+// contributes a ratio of its inactive file pages, and if the total usage of reclaimed_cores
+// exceeds the headroom, the excess (bounded by inactive file pages) should be reclaimed at least;
+// the total target is limited by ProactiveReclaimMaxBytesPerRound and is shared among
+// candidates in proportion to their inactive file pages.
+func computeProactiveReclaimTargets(candidates []*reclaimCandidate, headroom int64, conf qrmconfig.ProactiveReclaimConfig) {
+	var totalUsage, totalInactiveFile, totalTarget uint64
+	for _, candidate := range candidates {
+		candidate.target = 0
+		totalUsage += candidate.usage
+		totalInactiveFile += candidate.inactiveFile
+		totalTarget += uint64(float64(candidate.inactiveFile) * conf.ProactiveReclaimInactiveFileRatio)
+	}
+
+	if totalInactiveFile == 0 {
+		return
+	}
+
+	if headroom >= 0 && totalUsage > uint64(headroom) {
+		excess := totalUsage - uint64(headroom)
+		if excess > totalInactiveFile {
+			excess = totalInactiveFile
+		}
+
+		if excess > totalTarget {
+			totalTarget = excess
+		}
+	}
+
+	if conf.ProactiveReclaimMaxBytesPerRound > 0 && totalTarget > conf.ProactiveReclaimMaxBytesPerRound {
+		totalTarget = conf.ProactiveReclaimMaxBytesPerRound
+	}
+
+	for _, candidate := range candidates {
+		target := uint64(float64(totalTarget) * float64(candidate.inactiveFile) / float64(totalInactiveFile))
+		if target < conf.ProactiveReclaimMinBytes {
+			continue
+		}
+		candidate.target = target
+	}
+}
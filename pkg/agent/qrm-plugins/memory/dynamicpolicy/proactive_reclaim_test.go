@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+)
+
+func TestComputeProactiveReclaimTargets(t *testing.T) {
+	t.Parallel()
+
+	conf := qrmconfig.ProactiveReclaimConfig{
+		ProactiveReclaimInactiveFileRatio: 0.1,
+		ProactiveReclaimMaxBytesPerRound:  1000,
+		ProactiveReclaimMinBytes:          10,
+	}
+
+	testCases := []struct {
+		name            string
+		candidates      []*reclaimCandidate
+		headroom        int64
+		expectedTargets []uint64
+	}{
+		{
+			name: "ratio of inactive file without headroom",
+			candidates: []*reclaimCandidate{
+				{usage: 3000, inactiveFile: 1000},
+				{usage: 2000, inactiveFile: 500},
+			},
+			headroom:        -1,
+			expectedTargets: []uint64{100, 50},
+		},
+		{
+			name: "excess over headroom is bounded by max bytes per round",
+			candidates: []*reclaimCandidate{
+				{usage: 3000, inactiveFile: 1000},
+				{usage: 2000, inactiveFile: 1000},
+			},
+			headroom:        2000,
+			expectedTargets: []uint64{500, 500},
+		},
+		{
+			name: "excess over headroom is bounded by inactive file",
+			candidates: []*reclaimCandidate{
+				{usage: 3000, inactiveFile: 400},
+				{usage: 2000, inactiveFile: 200},
+			},
+			headroom:        1000,
+			expectedTargets: []uint64{400, 200},
+		},
+		{
+			name: "small targets are skipped",
+			candidates: []*reclaimCandidate{
+				{usage: 3000, inactiveFile: 1000},
+				{usage: 2000, inactiveFile: 50},
+			},
+			headroom:        10000,
+			expectedTargets: []uint64{100, 0},
+		},
+		{
+			name: "no inactive file",
+			candidates: []*reclaimCandidate{
+				{usage: 3000, inactiveFile: 0},
+			},
+			headroom:        0,
+			expectedTargets: []uint64{0},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			computeProactiveReclaimTargets(tc.candidates, tc.headroom, conf)
+			for i, candidate := range tc.candidates {
+				require.Equal(t, tc.expectedTargets[i], candidate.target, "candidate %d", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+)
+
+const (
+	// rdtCLOSReclaimedCores is the resctrl control group for reclaimed_cores
+	rdtCLOSReclaimedCores = apiconsts.PodAnnotationQoSLevelReclaimedCores
+)
+
+// setRDT confines reclaimed_cores containers to a slice of LLC ways and memory bandwidth,
+// and tasks of other QoS levels stay in the default resctrl group.
+func (p *DynamicPolicy) setRDT() {
+	info, err := p.metaServer.GetRDTInfo()
+	if err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.setRDT] get rdt info failed with error: %v", err)
+		return
+	}
+
+	if p.rdtConf.RDTReclaimedCoresL3Ways > 0 {
+		cbm, err := getLowestCBM(info.CBMMask, info.MinCBMBits, p.rdtConf.RDTReclaimedCoresL3Ways)
+		if err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setRDT] get cbm failed with error: %v", err)
+			return
+		}
+
+		cat := make(map[int]int, len(info.L3CacheIDs))
+		for _, cacheID := range info.L3CacheIDs {
+			cat[cacheID] = cbm
+		}
+
+		if err := p.metaServer.ApplyCAT(rdtCLOSReclaimedCores, cat); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setRDT] apply cat failed with error: %v", err)
+			return
+		}
+	}
+
+	if p.rdtConf.RDTReclaimedCoresMBPercent > 0 {
+		mba := make(map[int]int, len(info.MBDomainIDs))
+		for _, domainID := range info.MBDomainIDs {
+			mba[domainID] = p.rdtConf.RDTReclaimedCoresMBPercent
+		}
+
+		if err := p.metaServer.ApplyMBA(rdtCLOSReclaimedCores, mba); err != nil {
+			klog.Errorf("[MemoryDynamicPolicy.setRDT] apply mba failed with error: %v", err)
+			return
+		}
+	}
+
+	tasks := p.getReclaimedCoresTasks()
+	if err := p.metaServer.ApplyTasks(rdtCLOSReclaimedCores, tasks); err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.setRDT] apply tasks failed with error: %v", err)
+	}
+}
+
+// getReclaimedCoresTasks returns tasks of all reclaimed_cores containers
+func (p *DynamicPolicy) getReclaimedCoresTasks() []string {
+	p.RLock()
+	containers := make(map[string][]string)
+	for podUID, containerEntries := range p.state.GetPodResourceEntries()[v1.ResourceMemory] {
+		for containerName, allocationInfo := range containerEntries {
+			if allocationInfo == nil || containerName == "" ||
+				allocationInfo.QoSLevel != apiconsts.PodAnnotationQoSLevelReclaimedCores {
+				continue
+			}
+			containers[podUID] = append(containers[podUID], containerName)
+		}
+	}
+	p.RUnlock()
+
+	var tasks []string
+	for podUID, containerNames := range containers {
+		for _, containerName := range containerNames {
+			containerID, err := p.metaServer.GetContainerID(podUID, containerName)
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.getReclaimedCoresTasks] get container id of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+				continue
+			}
+
+			cpuAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysCPU, podUID, containerID)
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.getReclaimedCoresTasks] get cgroup path of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+				continue
+			}
+
+			containerTasks, err := cgroupcmutils.GetTasksWithAbsolutePath(cpuAbsCGPath)
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.getReclaimedCoresTasks] get tasks of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+				continue
+			}
+			tasks = append(tasks, containerTasks...)
+		}
+	}
+
+	return tasks
+}
+
+// getLowestCBM returns a contiguous bitmask with the given number of ways
+// starting from the lowest set bit of cbmMask.
+func getLowestCBM(cbmMask, minCBMBits, ways int) (int, error) {
+	if cbmMask <= 0 {
+		return 0, fmt.Errorf("invalid cbm mask: %x", cbmMask)
+	}
+
+	if ways < minCBMBits {
+		ways = minCBMBits
+	}
+
+	shift := 0
+	for cbmMask&(1<<shift) == 0 {
+		shift++
+	}
+
+	cbm := ((1 << ways) - 1) << shift
+	if cbm&cbmMask != cbm {
+		return 0, fmt.Errorf("%d ways exceed cbm mask: %x", ways, cbmMask)
+	}
+	return cbm, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLowestCBM(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		cbmMask     int
+		minCBMBits  int
+		ways        int
+		expectedCBM int
+		expectErr   bool
+	}{
+		{
+			name:        "lowest ways",
+			cbmMask:     0x7ff,
+			minCBMBits:  1,
+			ways:        3,
+			expectedCBM: 0x7,
+		},
+		{
+			name:        "mask not starting from bit 0",
+			cbmMask:     0xff0,
+			minCBMBits:  1,
+			ways:        2,
+			expectedCBM: 0x30,
+		},
+		{
+			name:        "ways less than min cbm bits",
+			cbmMask:     0x7ff,
+			minCBMBits:  2,
+			ways:        1,
+			expectedCBM: 0x3,
+		},
+		{
+			name:       "ways exceed mask",
+			cbmMask:    0xf,
+			minCBMBits: 1,
+			ways:       5,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid mask",
+			cbmMask:    0,
+			minCBMBits: 1,
+			ways:       1,
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cbm, err := getLowestCBM(tc.cbmMask, tc.minCBMBits, tc.ways)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedCBM, cbm)
+		})
+	}
+}
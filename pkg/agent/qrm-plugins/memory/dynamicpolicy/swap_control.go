@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/state"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+)
+
+// setSwapControl allows reclaimed_cores containers to swap to absorb memory bursts instead of
+// being OOM-killed or evicted, while swap is disabled for shared_cores and dedicated_cores;
+// the default behavior of each QoS level can be overridden by swap in memory enhancement.
+func (p *DynamicPolicy) setSwapControl() {
+	swapTotal, err := p.metaServer.GetNodeMetric(consts.MetricMemSwapTotalSystem)
+	if err == nil && swapTotal == 0 {
+		klog.V(4).Infof("[MemoryDynamicPolicy.setSwapControl] swap is not enabled on this node")
+		return
+	}
+
+	p.RLock()
+	swapAllowed := make(map[string]map[string]bool)
+	for podUID, containerEntries := range p.state.GetPodResourceEntries()[v1.ResourceMemory] {
+		for containerName, allocationInfo := range containerEntries {
+			if allocationInfo == nil || containerName == "" {
+				continue
+			}
+
+			if swapAllowed[podUID] == nil {
+				swapAllowed[podUID] = make(map[string]bool)
+			}
+			swapAllowed[podUID][containerName] = isSwapAllowed(allocationInfo)
+		}
+	}
+	p.RUnlock()
+
+	for podUID, containers := range swapAllowed {
+		for containerName, allowed := range containers {
+			containerID, err := p.metaServer.GetContainerID(podUID, containerName)
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.setSwapControl] get container id of pod: %s container: %s failed with error: %v",
+					podUID, containerName, err)
+				continue
+			}
+
+			p.swapKnobLock.Lock()
+			err = cgroupcmutils.ApplyMemoryForContainer(podUID, containerID, p.getSwapControlData(allowed))
+			p.swapKnobLock.Unlock()
+			if err != nil {
+				klog.Errorf("[MemoryDynamicPolicy.setSwapControl] apply swap control for pod: %s, container: %s, allowed: %v failed with error: %v",
+					podUID, containerName, allowed, err)
+			}
+		}
+	}
+}
+
+// getSwapControlData returns cgroup memory data to enable or disable swap
+func (p *DynamicPolicy) getSwapControlData(allowed bool) *common.MemoryData {
+	swapMax, swappiness := int64(0), uint64(0)
+	if allowed {
+		swapMax, swappiness = -1, p.swapControlConf.SwapSwappiness
+	}
+
+	data := &common.MemoryData{SwapMaxInBytesPtr: &swapMax}
+	// per-cgroup memory.swappiness only exists in cgroupv1
+	if !common.IsCgroup2UnifiedMode() {
+		data.SwappinessPtr = &swappiness
+	}
+	return data
+}
+
+// isSwapAllowed checks whether the container is allowed to swap
+func isSwapAllowed(allocationInfo *state.AllocationInfo) bool {
+	switch allocationInfo.Annotations[consts.PodAnnotationMemoryEnhancementSwap] {
+	case consts.PodAnnotationMemoryEnhancementSwapEnable:
+		return true
+	case consts.PodAnnotationMemoryEnhancementSwapDisable:
+		return false
+	}
+
+	return allocationInfo.QoSLevel == apiconsts.PodAnnotationQoSLevelReclaimedCores
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/state"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+)
+
+func TestIsSwapAllowed(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		qosLevel    string
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:     "reclaimed_cores is allowed by default",
+			qosLevel: apiconsts.PodAnnotationQoSLevelReclaimedCores,
+			expected: true,
+		},
+		{
+			name:     "shared_cores is disallowed by default",
+			qosLevel: apiconsts.PodAnnotationQoSLevelSharedCores,
+			expected: false,
+		},
+		{
+			name:     "dedicated_cores is disallowed by default",
+			qosLevel: apiconsts.PodAnnotationQoSLevelDedicatedCores,
+			expected: false,
+		},
+		{
+			name:     "shared_cores enabled by memory enhancement",
+			qosLevel: apiconsts.PodAnnotationQoSLevelSharedCores,
+			annotations: map[string]string{
+				consts.PodAnnotationMemoryEnhancementSwap: consts.PodAnnotationMemoryEnhancementSwapEnable,
+			},
+			expected: true,
+		},
+		{
+			name:     "reclaimed_cores disabled by memory enhancement",
+			qosLevel: apiconsts.PodAnnotationQoSLevelReclaimedCores,
+			annotations: map[string]string{
+				consts.PodAnnotationMemoryEnhancementSwap: consts.PodAnnotationMemoryEnhancementSwapDisable,
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, isSwapAllowed(&state.AllocationInfo{
+				QoSLevel:    tc.qosLevel,
+				Annotations: tc.annotations,
+			}))
+		})
+	}
+}
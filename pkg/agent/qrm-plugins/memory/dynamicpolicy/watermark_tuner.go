@@ -0,0 +1,304 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
+	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager/checksum"
+	cmerrors "k8s.io/kubernetes/pkg/kubelet/checkpointmanager/errors"
+
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+const (
+	sysctlWatermarkScaleFactor = "vm.watermark_scale_factor"
+	sysctlMinFreeKbytes        = "vm.min_free_kbytes"
+
+	// vmstatAllocstallPrefix matches allocstall in old kernels and allocstall_<zone> in new kernels
+	vmstatAllocstallPrefix = "allocstall"
+
+	metricNameWatermarkScaleFactor = "memory_watermark_scale_factor"
+	metricNameMinFreeKbytes        = "memory_min_free_kbytes"
+
+	watermarkTunerCheckpointName = "memory_plugin_watermark_tuner"
+)
+
+// watermarks are the node-level sysctls adjusted by watermarkTuner
+type watermarks struct {
+	scaleFactor   int64
+	minFreeKbytes int64
+}
+
+// watermarksCheckpoint persists the original watermarks, so that tuned watermarks
+// are not taken as the original ones after restarts
+type watermarksCheckpoint struct {
+	ScaleFactor   int64             `json:"scaleFactor"`
+	MinFreeKbytes int64             `json:"minFreeKbytes"`
+	Checksum      checksum.Checksum `json:"checksum"`
+}
+
+var _ checkpointmanager.Checkpoint = &watermarksCheckpoint{}
+
+func (cp *watermarksCheckpoint) MarshalCheckpoint() ([]byte, error) {
+	cp.Checksum = 0
+	cp.Checksum = checksum.New(cp)
+	return json.Marshal(*cp)
+}
+
+func (cp *watermarksCheckpoint) UnmarshalCheckpoint(blob []byte) error {
+	return json.Unmarshal(blob, cp)
+}
+
+func (cp *watermarksCheckpoint) VerifyChecksum() error {
+	ck := cp.Checksum
+	cp.Checksum = 0
+	err := ck.Verify(cp)
+	cp.Checksum = ck
+	return err
+}
+
+// watermarkTuner raises watermarks when allocations stall in direct reclaim, so that
+// kswapd wakes up earlier, and lowers them back when kswapd is idle; original watermarks
+// are recorded (and checkpointed) when it starts for the first time and restored when it stops.
+type watermarkTuner struct {
+	mutex sync.Mutex
+
+	conf              qrmconfig.WatermarkTunerConfig
+	procFS            *procfs.ProcFS
+	checkpointManager checkpointmanager.CheckpointManager
+
+	original *watermarks
+
+	lastUpdateTime  time.Time
+	lastAllocstall  float64
+	lastKswapdSteal float64
+}
+
+func newWatermarkTuner(conf qrmconfig.WatermarkTunerConfig, procFS *procfs.ProcFS, stateDir string) (*watermarkTuner, error) {
+	checkpointManager, err := checkpointmanager.NewCheckpointManager(stateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize checkpoint manager: %v", err)
+	}
+
+	return &watermarkTuner{
+		conf:              conf,
+		procFS:            procFS,
+		checkpointManager: checkpointManager,
+	}, nil
+}
+
+// start records the original watermarks, and it must be called before tune; the original
+// watermarks in checkpoint are preferred since current ones may have been tuned before restarts.
+func (t *watermarkTuner) start() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	cp := &watermarksCheckpoint{}
+	err := t.checkpointManager.GetCheckpoint(watermarkTunerCheckpointName, cp)
+	switch {
+	case err == nil:
+		t.original = &watermarks{scaleFactor: cp.ScaleFactor, minFreeKbytes: cp.MinFreeKbytes}
+	case errors.Is(err, cmerrors.ErrCheckpointNotFound):
+		current, err := t.readWatermarks()
+		if err != nil {
+			return err
+		}
+
+		cp = &watermarksCheckpoint{ScaleFactor: current.scaleFactor, MinFreeKbytes: current.minFreeKbytes}
+		if err := t.checkpointManager.CreateCheckpoint(watermarkTunerCheckpointName, cp); err != nil {
+			return fmt.Errorf("create checkpoint of original watermarks failed with error: %v", err)
+		}
+		t.original = current
+	default:
+		return fmt.Errorf("get checkpoint of original watermarks failed with error: %v", err)
+	}
+
+	t.lastUpdateTime = time.Time{}
+	klog.Infof("[watermarkTuner] original watermark_scale_factor: %d, min_free_kbytes: %d",
+		t.original.scaleFactor, t.original.minFreeKbytes)
+	return nil
+}
+
+// restore writes back the original watermarks
+func (t *watermarkTuner) restore() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.original == nil {
+		return nil
+	}
+
+	if err := t.writeWatermarks(t.original); err != nil {
+		return err
+	}
+
+	klog.Infof("[watermarkTuner] restore watermark_scale_factor: %d, min_free_kbytes: %d",
+		t.original.scaleFactor, t.original.minFreeKbytes)
+	t.original = nil
+
+	if err := t.checkpointManager.RemoveCheckpoint(watermarkTunerCheckpointName); err != nil {
+		return fmt.Errorf("remove checkpoint of original watermarks failed with error: %v", err)
+	}
+	return nil
+}
+
+// tune adjusts watermarks by one step according to the rates of allocation stalls and kswapd steal
+// since the last round, and the cumulative kswapd steal is passed in by the caller.
+func (t *watermarkTuner) tune(kswapdSteal float64, now time.Time) (*watermarks, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.original == nil {
+		return nil, fmt.Errorf("watermark tuner is not started")
+	}
+
+	allocstall, err := t.readAllocstall()
+	if err != nil {
+		return nil, err
+	}
+
+	lastUpdateTime, lastAllocstall, lastKswapdSteal := t.lastUpdateTime, t.lastAllocstall, t.lastKswapdSteal
+	t.lastUpdateTime, t.lastAllocstall, t.lastKswapdSteal = now, allocstall, kswapdSteal
+
+	current, err := t.readWatermarks()
+	if err != nil {
+		return nil, err
+	}
+
+	// rates are unknown in the first round, or counters are reset
+	interval := now.Sub(lastUpdateTime).Seconds()
+	if lastUpdateTime.IsZero() || interval <= 0 || allocstall < lastAllocstall || kswapdSteal < lastKswapdSteal {
+		return current, nil
+	}
+
+	allocstallRate := (allocstall - lastAllocstall) / interval
+	kswapdStealRate := (kswapdSteal - lastKswapdSteal) / interval
+
+	target := t.getTargetWatermarks(current, allocstallRate, kswapdStealRate)
+	if *target == *current {
+		return current, nil
+	}
+
+	if err := t.writeWatermarks(target); err != nil {
+		return nil, err
+	}
+
+	klog.Infof("[watermarkTuner] allocstall rate: %.2f, kswapd steal rate: %.2f, "+
+		"watermark_scale_factor: %d -> %d, min_free_kbytes: %d -> %d", allocstallRate, kswapdStealRate,
+		current.scaleFactor, target.scaleFactor, current.minFreeKbytes, target.minFreeKbytes)
+	return target, nil
+}
+
+// getTargetWatermarks raises watermark_scale_factor first and then min_free_kbytes under
+// allocation stalls, and lowers them in the reverse order when memory reclaim is quiet.
+func (t *watermarkTuner) getTargetWatermarks(current *watermarks, allocstallRate, kswapdStealRate float64) *watermarks {
+	target := *current
+
+	if allocstallRate > t.conf.WatermarkTunerAllocstallRateThreshold {
+		if current.scaleFactor < t.conf.WatermarkScaleFactorMax {
+			target.scaleFactor = general.MinInt64(current.scaleFactor+t.conf.WatermarkScaleFactorStep,
+				t.conf.WatermarkScaleFactorMax)
+		} else if t.conf.EnableMinFreeKbytesTuning && current.minFreeKbytes < t.conf.MinFreeKbytesMax {
+			target.minFreeKbytes = general.MinInt64(current.minFreeKbytes+t.conf.MinFreeKbytesStep,
+				t.conf.MinFreeKbytesMax)
+		}
+	} else if allocstallRate == 0 && kswapdStealRate < t.conf.WatermarkTunerKswapdStealRateThreshold {
+		// min_free_kbytes is never lowered below its original value,
+		// since it is usually set by administrators or the kernel on purpose
+		if current.minFreeKbytes > t.original.minFreeKbytes {
+			target.minFreeKbytes = general.MaxInt64(current.minFreeKbytes-t.conf.MinFreeKbytesStep,
+				t.original.minFreeKbytes)
+		} else if current.scaleFactor > t.conf.WatermarkScaleFactorMin {
+			target.scaleFactor = general.MaxInt64(current.scaleFactor-t.conf.WatermarkScaleFactorStep,
+				t.conf.WatermarkScaleFactorMin)
+		}
+	}
+
+	return &target
+}
+
+// readAllocstall returns the total number of allocation stalls of all zones
+func (t *watermarkTuner) readAllocstall() (float64, error) {
+	vmstat, err := t.procFS.ReadVMStat()
+	if err != nil {
+		return 0, fmt.Errorf("read vmstat failed with error: %v", err)
+	}
+
+	var allocstall float64
+	for key, value := range vmstat {
+		if strings.HasPrefix(key, vmstatAllocstallPrefix) {
+			allocstall += float64(value)
+		}
+	}
+	return allocstall, nil
+}
+
+func (t *watermarkTuner) readWatermarks() (*watermarks, error) {
+	scaleFactor, err := t.procFS.ReadSysctlInt(sysctlWatermarkScaleFactor)
+	if err != nil {
+		return nil, fmt.Errorf("read %s failed with error: %v", sysctlWatermarkScaleFactor, err)
+	}
+
+	minFreeKbytes, err := t.procFS.ReadSysctlInt(sysctlMinFreeKbytes)
+	if err != nil {
+		return nil, fmt.Errorf("read %s failed with error: %v", sysctlMinFreeKbytes, err)
+	}
+
+	return &watermarks{scaleFactor: scaleFactor, minFreeKbytes: minFreeKbytes}, nil
+}
+
+func (t *watermarkTuner) writeWatermarks(target *watermarks) error {
+	if err := t.procFS.WriteSysctlInt(sysctlWatermarkScaleFactor, target.scaleFactor); err != nil {
+		return fmt.Errorf("write %s failed with error: %v", sysctlWatermarkScaleFactor, err)
+	}
+
+	if err := t.procFS.WriteSysctlInt(sysctlMinFreeKbytes, target.minFreeKbytes); err != nil {
+		return fmt.Errorf("write %s failed with error: %v", sysctlMinFreeKbytes, err)
+	}
+	return nil
+}
+
+// tuneWatermarks is the periodic entrance of watermarkTuner
+func (p *DynamicPolicy) tuneWatermarks() {
+	kswapdSteal, err := p.metaServer.GetNodeMetric(consts.MetricMemKswapdstealSystem)
+	if err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.tuneWatermarks] get %s failed with error: %v",
+			consts.MetricMemKswapdstealSystem, err)
+		return
+	}
+
+	current, err := p.watermarkTuner.tune(kswapdSteal, time.Now())
+	if err != nil {
+		klog.Errorf("[MemoryDynamicPolicy.tuneWatermarks] tune watermarks failed with error: %v", err)
+		return
+	}
+
+	_ = p.emitter.StoreInt64(metricNameWatermarkScaleFactor, current.scaleFactor, metrics.MetricTypeNameRaw)
+	_ = p.emitter.StoreInt64(metricNameMinFreeKbytes, current.minFreeKbytes, metrics.MetricTypeNameRaw)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicpolicy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	qrmconfig "github.com/kubewharf/katalyst-core/pkg/config/agent/qrm"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+func prepareFakeProcFS(t *testing.T, scaleFactor, minFreeKbytes int64) string {
+	root, err := ioutil.TempDir("", "procfs")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys", "vm"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "sys", "vm", "watermark_scale_factor"),
+		[]byte(fmt.Sprintf("%d\n", scaleFactor)), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "sys", "vm", "min_free_kbytes"),
+		[]byte(fmt.Sprintf("%d\n", minFreeKbytes)), 0o644))
+	setFakeAllocstall(t, root, 0)
+	return root
+}
+
+func setFakeAllocstall(t *testing.T, root string, allocstall uint64) {
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "vmstat"),
+		[]byte(fmt.Sprintf("nr_free_pages 1024\nallocstall_normal %d\nallocstall_movable 0\n", allocstall)), 0o644))
+}
+
+func TestWatermarkTuner(t *testing.T) {
+	t.Parallel()
+
+	root := prepareFakeProcFS(t, 100, 65536)
+	defer os.RemoveAll(root)
+
+	stateDir, err := ioutil.TempDir("", "watermark-tuner")
+	require.NoError(t, err)
+	defer os.RemoveAll(stateDir)
+
+	conf := qrmconfig.WatermarkTunerConfig{
+		WatermarkScaleFactorMin:                10,
+		WatermarkScaleFactorMax:                140,
+		WatermarkScaleFactorStep:               20,
+		WatermarkTunerAllocstallRateThreshold:  1,
+		WatermarkTunerKswapdStealRateThreshold: 100,
+		EnableMinFreeKbytesTuning:              true,
+		MinFreeKbytesMax:                       131072,
+		MinFreeKbytesStep:                      65536,
+	}
+	tuner, err := newWatermarkTuner(conf, procfs.NewProcFS(root), stateDir)
+	require.NoError(t, err)
+
+	_, err = tuner.tune(0, time.Now())
+	require.Error(t, err)
+	require.NoError(t, tuner.start())
+
+	now := time.Now()
+	var allocstall uint64
+	var kswapdSteal float64
+	tune := func(allocstallDelta uint64, kswapdStealDelta float64) *watermarks {
+		allocstall += allocstallDelta
+		kswapdSteal += kswapdStealDelta
+		now = now.Add(10 * time.Second)
+		setFakeAllocstall(t, root, allocstall)
+
+		current, err := tuner.tune(kswapdSteal, now)
+		require.NoError(t, err)
+		return current
+	}
+
+	// rates are unknown in the first round
+	require.Equal(t, watermarks{scaleFactor: 100, minFreeKbytes: 65536}, *tune(0, 0))
+
+	// allocation stalls raise watermark_scale_factor up to its max, and then min_free_kbytes
+	require.Equal(t, watermarks{scaleFactor: 120, minFreeKbytes: 65536}, *tune(100, 0))
+	require.Equal(t, watermarks{scaleFactor: 140, minFreeKbytes: 65536}, *tune(100, 0))
+	require.Equal(t, watermarks{scaleFactor: 140, minFreeKbytes: 131072}, *tune(100, 0))
+	require.Equal(t, watermarks{scaleFactor: 140, minFreeKbytes: 131072}, *tune(100, 0))
+
+	// busy kswapd keeps watermarks unchanged
+	require.Equal(t, watermarks{scaleFactor: 140, minFreeKbytes: 131072}, *tune(0, 10000))
+
+	// original watermarks are kept in checkpoint across restarts
+	restarted, err := newWatermarkTuner(conf, procfs.NewProcFS(root), stateDir)
+	require.NoError(t, err)
+	require.NoError(t, restarted.start())
+	require.Equal(t, watermarks{scaleFactor: 100, minFreeKbytes: 65536}, *restarted.original)
+
+	// quiet reclaim lowers min_free_kbytes back to its original value first, and then watermark_scale_factor
+	require.Equal(t, watermarks{scaleFactor: 140, minFreeKbytes: 65536}, *tune(0, 0))
+	require.Equal(t, watermarks{scaleFactor: 120, minFreeKbytes: 65536}, *tune(0, 0))
+
+	require.NoError(t, tuner.restore())
+	current, err := procfs.NewProcFS(root).ReadSysctlInt(sysctlWatermarkScaleFactor)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), current)
+	current, err = procfs.NewProcFS(root).ReadSysctlInt(sysctlMinFreeKbytes)
+	require.NoError(t, err)
+	require.Equal(t, int64(65536), current)
+
+	// checkpoint is removed after watermarks are restored
+	_, err = os.Stat(filepath.Join(stateDir, watermarkTunerCheckpointName))
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcer
+
+import (
+	"sync"
+
+	"github.com/kubewharf/katalyst-core/pkg/config"
+)
+
+// BandwidthEnforcer limits egress and ingress bandwidth of containers on the NIC assigned to them,
+// and the bandwidth is admitted by the network plugin before being enforced here
+type BandwidthEnforcer interface {
+	// Name returns the name of this enforcer
+	Name() string
+
+	// SetBandwidthLimit limits bandwidth (in Mbps) of the container on the given NIC,
+	// and it should be idempotent since it will be called periodically
+	SetBandwidthLimit(podUID, containerID, ifName string, bandwidth uint64) error
+
+	// RemovePod clears bandwidth limits of all containers in the pod
+	RemovePod(podUID string) error
+}
+
+type InitFunc func(conf *config.Configuration) (BandwidthEnforcer, error)
+
+var initializers sync.Map
+
+func RegisterInitializer(name string, initFunc InitFunc) {
+	initializers.Store(name, initFunc)
+}
+
+func GetRegisteredInitializers() map[string]InitFunc {
+	res := make(map[string]InitFunc)
+	initializers.Range(func(key, value interface{}) bool {
+		res[key.(string)] = value.(InitFunc)
+		return true
+	})
+	return res
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enforcer
+
+import (
+	"github.com/kubewharf/katalyst-core/pkg/config"
+)
+
+// BandwidthEnforcerNameNoop is the name of the enforcer which only
+// accounts bandwidth without limiting it actually
+const BandwidthEnforcerNameNoop = "noop"
+
+func init() {
+	RegisterInitializer(BandwidthEnforcerNameNoop, NewNoopBandwidthEnforcer)
+}
+
+type noopBandwidthEnforcer struct{}
+
+var _ BandwidthEnforcer = &noopBandwidthEnforcer{}
+
+// NewNoopBandwidthEnforcer returns a BandwidthEnforcer doing nothing
+func NewNoopBandwidthEnforcer(_ *config.Configuration) (BandwidthEnforcer, error) {
+	return &noopBandwidthEnforcer{}, nil
+}
+
+func (e *noopBandwidthEnforcer) Name() string {
+	return BandwidthEnforcerNameNoop
+}
+
+func (e *noopBandwidthEnforcer) SetBandwidthLimit(_, _, _ string, _ uint64) error {
+	return nil
+}
+
+func (e *noopBandwidthEnforcer) RemovePod(_ string) error {
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamicpolicy
+
+import (
+	"sort"
+
+	"k8s.io/klog/v2"
+	pluginapi "k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/network/dynamicpolicy/state"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+// filterAvailableNICs returns enabled NICs sorted by name, and the speed of NICs is set
+// to defaultSpeed (in Mbps) if it's unknown (e.g. virtual NICs not reporting speed)
+func filterAvailableNICs(nics []machine.InterfaceInfo, defaultSpeed int) []machine.InterfaceInfo {
+	var availableNICs []machine.InterfaceInfo
+	for _, nic := range nics {
+		if !nic.Enable {
+			continue
+		}
+
+		if nic.Speed <= 0 && defaultSpeed > 0 {
+			klog.Infof("[NetworkDynamicPolicy] speed of NIC %s is unknown, use the default speed %d", nic.Iface, defaultSpeed)
+			nic.Speed = defaultSpeed
+		}
+		availableNICs = append(availableNICs, nic)
+	}
+
+	sort.SliceStable(availableNICs, func(i, j int) bool {
+		return availableNICs[i].Iface < availableNICs[j].Iface
+	})
+	return availableNICs
+}
+
+// getNICNumaNodes returns NUMA nodes the NIC is affinitive to, and it's
+// empty if the NUMA affinity is unknown (e.g. on machines without NUMA)
+func getNICNumaNodes(nic machine.InterfaceInfo) machine.CPUSet {
+	if nic.NumaNode < 0 {
+		return machine.NewCPUSet()
+	}
+	return machine.NewCPUSet(nic.NumaNode)
+}
+
+// selectNIC selects the NIC with the fewest containers assigned among NICs affinitive to
+// hintNodes, and NICs with higher speed are preferred if assigned containers are the same;
+// if no NIC matches hintNodes, all NICs are taken into consideration. NICs without enough
+// free bandwidth for the request are skipped, and nil is returned if no NIC is left.
+func selectNIC(machineState state.NICMap, nics []machine.InterfaceInfo, hintNodes machine.CPUSet, bandwidth uint64) *machine.InterfaceInfo {
+	var available, candidates []machine.InterfaceInfo
+	for _, nic := range nics {
+		if bandwidth > 0 && getNICFreeBandwidth(machineState, nic.Iface) < bandwidth {
+			continue
+		}
+		available = append(available, nic)
+
+		numaNodes := getNICNumaNodes(nic)
+		if hintNodes.IsEmpty() || numaNodes.IsEmpty() || numaNodes.IsSubsetOf(hintNodes) {
+			candidates = append(candidates, nic)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = available
+	}
+
+	var selected *machine.InterfaceInfo
+	selectedCount := 0
+	for i := range candidates {
+		count := machineState[candidates[i].Iface].GetContainersCount()
+		if selected == nil || count < selectedCount ||
+			(count == selectedCount && candidates[i].Speed > selected.Speed) {
+			selected, selectedCount = &candidates[i], count
+		}
+	}
+	return selected
+}
+
+// getNICFreeBandwidth returns bandwidth (in Mbps) of the NIC not allocated yet
+func getNICFreeBandwidth(machineState state.NICMap, ifName string) uint64 {
+	if nicState := machineState[ifName]; nicState != nil {
+		return nicState.FreeBandwidth
+	}
+	return 0
+}
+
+// generateNICHints generates a hint for each socket with NICs, and only the
+// socket of the selected NIC is preferred, so that containers can be aligned
+// with the NIC without being pinned to its NUMA node
+func generateNICHints(topology *machine.CPUTopology, nics []machine.InterfaceInfo, selected *machine.InterfaceInfo) []*pluginapi.TopologyHint {
+	selectedNumaNodes := getNICNumaNodes(*selected)
+	if selectedNumaNodes.IsEmpty() {
+		return nil
+	}
+
+	// without cpu topology, the NUMA node of the NIC is the only hint
+	if topology == nil {
+		return []*pluginapi.TopologyHint{
+			{
+				Nodes:     selectedNumaNodes.ToSliceUInt64(),
+				Preferred: true,
+			},
+		}
+	}
+
+	selectedSockets := topology.CPUDetails.SocketsInNUMANodes(selectedNumaNodes.ToSliceInt()...)
+	sockets := machine.NewCPUSet()
+	for _, nic := range nics {
+		if numaNodes := getNICNumaNodes(nic); !numaNodes.IsEmpty() {
+			sockets = sockets.Union(topology.CPUDetails.SocketsInNUMANodes(numaNodes.ToSliceInt()...))
+		}
+	}
+
+	hints := make([]*pluginapi.TopologyHint, 0, sockets.Size())
+	for _, socket := range sockets.ToSliceInt() {
+		hints = append(hints, &pluginapi.TopologyHint{
+			Nodes:     topology.CPUDetails.NUMANodesInSockets(socket).ToSliceUInt64(),
+			Preferred: selectedSockets.Contains(socket),
+		})
+	}
+	return hints
+}
@@ -17,6 +17,7 @@ package dynamicpolicy
 import (
 	"context"
 	"fmt"
+	"math"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +30,8 @@ import (
 	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-api/pkg/plugins/skeleton"
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/agent"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/network/dynamicpolicy/enforcer"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/network/dynamicpolicy/state"
 	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/util"
 	"github.com/kubewharf/katalyst-core/pkg/config"
 	"github.com/kubewharf/katalyst-core/pkg/config/generic"
@@ -36,7 +39,9 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
 	cgroupcmutils "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager"
+	"github.com/kubewharf/katalyst-core/pkg/util/external/network"
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 	"github.com/kubewharf/katalyst-core/pkg/util/native"
 	"github.com/kubewharf/katalyst-core/pkg/util/qos"
 )
@@ -46,6 +51,10 @@ const (
 	NetworkResourcePluginPolicyNameDynamic = "dynamic"
 	// ResourceNameNetwork is the resource name of network
 	ResourceNameNetwork = "network"
+	// ResourceNameNetBandwidth is the resource name of egress and ingress bandwidth (in Mbps) on NICs
+	ResourceNameNetBandwidth = "resource.katalyst.kubewharf.io/net_bandwidth"
+
+	networkPluginStateFileName = "network_plugin_state"
 )
 
 // DynamicPolicy is the dynamic network policy
@@ -62,8 +71,23 @@ type DynamicPolicy struct {
 	netClassMap                   map[string]uint32
 	isCgV2Env                     bool
 	applyNetClassFunc             func(podUID, containerId string, data *common.NetClsData) error
+	clearNetClassFunc             func(cgroupID uint64) error
 	podLevelNetClassAnnoKey       string
 	podLevelNetAttributesAnnoKeys []string
+	// netClassShapedByTC is set if net classes are shaped by tc on NICs, which classifies
+	// packets by cgroups of their sockets and only works for host-network pods
+	netClassShapedByTC bool
+
+	// NICs are exposed as a topology-aware resource, and each container is
+	// assigned to a NIC whose NUMA affinity is aligned with its hint
+	state                          state.State
+	topology                       *machine.CPUTopology
+	nicNameAllocationAnnotationKey string
+	nicNumaAllocationAnnotationKey string
+
+	// bandwidth requested by containers is admitted against free bandwidth of NICs,
+	// and it's limited by the enforcer after admission
+	bandwidthEnforcer enforcer.BandwidthEnforcer
 }
 
 // NewDynamicPolicy returns a dynamic network policy
@@ -73,18 +97,60 @@ func NewDynamicPolicy(agentCtx *agent.GenericContext, conf *config.Configuration
 		Val: NetworkResourcePluginPolicyNameDynamic,
 	})
 
+	var nics []machine.InterfaceInfo
+	var topology *machine.CPUTopology
+	if agentCtx.KatalystMachineInfo != nil {
+		topology = agentCtx.CPUTopology
+		if agentCtx.ExtraNetworkInfo != nil {
+			nics = filterAvailableNICs(agentCtx.ExtraNetworkInfo.Interface, conf.DefaultNICSpeed)
+		}
+	}
+
+	stateImpl, err := state.NewCheckpointState(conf.GenericQRMPluginConfiguration.StateFileDirectory, networkPluginStateFileName,
+		NetworkResourcePluginPolicyNameDynamic, nics, conf.ReservedBandwidth, conf.SkipNetworkStateCorruption)
+	if err != nil {
+		return false, agent.ComponentStub{}, fmt.Errorf("NewCheckpointState failed with error: %v", err)
+	}
+
+	// bandwidth is still admitted without being enforced if the enforcer isn't registered
+	initFunc, ok := enforcer.GetRegisteredInitializers()[conf.BandwidthEnforcerName]
+	if !ok {
+		klog.Errorf("[NetworkDynamicPolicy] bandwidth enforcer %s isn't registered, bandwidth enforcement is disabled",
+			conf.BandwidthEnforcerName)
+		initFunc = enforcer.NewNoopBandwidthEnforcer
+	}
+	bandwidthEnforcer, err := initFunc(conf)
+	if err != nil {
+		return false, agent.ComponentStub{}, fmt.Errorf("init bandwidth enforcer %s failed with error: %v", conf.BandwidthEnforcerName, err)
+	}
+
 	policyImplement := &DynamicPolicy{
-		qosConfig:   conf.QoSConfiguration,
-		emitter:     wrappedEmitter,
-		metaServer:  agentCtx.MetaServer,
-		stopCh:      make(chan struct{}),
-		name:        fmt.Sprintf("%s_%s", agentName, NetworkResourcePluginPolicyNameDynamic),
-		netClassMap: make(map[string]uint32),
+		qosConfig:                      conf.QoSConfiguration,
+		emitter:                        wrappedEmitter,
+		metaServer:                     agentCtx.MetaServer,
+		stopCh:                         make(chan struct{}),
+		name:                           fmt.Sprintf("%s_%s", agentName, NetworkResourcePluginPolicyNameDynamic),
+		netClassMap:                    make(map[string]uint32),
+		state:                          stateImpl,
+		topology:                       topology,
+		nicNameAllocationAnnotationKey: conf.NICNameAllocationAnnotationKey,
+		nicNumaAllocationAnnotationKey: conf.NICNumaAllocationAnnotationKey,
+		bandwidthEnforcer:              bandwidthEnforcer,
 	}
 
 	if common.IsCgroup2UnifiedMode() {
 		policyImplement.isCgV2Env = true
-		policyImplement.applyNetClassFunc = agentCtx.MetaServer.ExternalManager.ApplyNetClass
+
+		// net classes are shaped by tc only on NICs configured explicitly
+		networkManager := network.NetworkManager(agentCtx.MetaServer.ExternalManager)
+		if len(conf.TCShapingNICs) > 0 {
+			tcConfig := network.NewDefaultTCConfig()
+			tcConfig.NICs = conf.TCShapingNICs
+			networkManager = network.NewNetworkManagerWithConfig(tcConfig)
+			policyImplement.netClassShapedByTC = true
+		}
+		policyImplement.applyNetClassFunc = networkManager.ApplyNetClass
+		policyImplement.clearNetClassFunc = networkManager.ClearNetClass
 	} else {
 		policyImplement.isCgV2Env = false
 		policyImplement.applyNetClassFunc = cgroupcmutils.ApplyNetClsForContainer
@@ -150,6 +216,7 @@ func (p *DynamicPolicy) Start() (err error) {
 	}, time.Second*30, p.stopCh)
 
 	go wait.Until(p.applyNetClass, 5*time.Second, p.stopCh)
+	go wait.Until(p.applyBandwidthLimit, 5*time.Second, p.stopCh)
 
 	return nil
 }
@@ -184,7 +251,47 @@ func (p *DynamicPolicy) ResourceName() string {
 
 // GetTopologyHints returns hints of corresponding resources
 func (p *DynamicPolicy) GetTopologyHints(ctx context.Context, req *pluginapi.ResourceRequest) (*pluginapi.ResourceHintsResponse, error) {
-	return &pluginapi.ResourceHintsResponse{}, nil
+	if req == nil {
+		return nil, fmt.Errorf("GetTopologyHints got nil req")
+	}
+
+	p.RLock()
+	defer p.RUnlock()
+
+	nics := p.state.GetNICs()
+	if len(nics) == 0 || !needNIC(req) {
+		return util.PackResourceHintsResponse(req, ResourceNameNetwork, map[string]*pluginapi.ListOfTopologyHints{
+			ResourceNameNetwork: nil,
+		})
+	}
+
+	// hints are generated without any hint nodes, so the NIC with the fewest
+	// containers assigned is selected, and its socket is preferred in hints
+	// empty hints are returned if no NIC has enough free bandwidth, so that the container
+	// can't be admitted by topology manager, rather than failing the hints request
+	selected, err := p.selectNICForContainer(req, machine.NewCPUSet())
+	if err != nil {
+		klog.Warningf("[NetworkDynamicPolicy.GetTopologyHints] select NIC failed with error: %v", err)
+		return util.PackResourceHintsResponse(req, ResourceNameNetwork, map[string]*pluginapi.ListOfTopologyHints{
+			ResourceNameNetwork: {Hints: []*pluginapi.TopologyHint{}},
+		})
+	}
+
+	klog.InfoS("[NetworkDynamicPolicy.GetTopologyHints] NIC is selected",
+		"podNamespace", req.PodNamespace,
+		"podName", req.PodName,
+		"containerName", req.ContainerName,
+		"nic", selected.Iface,
+		"numaNode", selected.NumaNode)
+
+	var hints *pluginapi.ListOfTopologyHints
+	if nicHints := generateNICHints(p.topology, nics, selected); len(nicHints) > 0 {
+		hints = &pluginapi.ListOfTopologyHints{Hints: nicHints}
+	}
+
+	return util.PackResourceHintsResponse(req, ResourceNameNetwork, map[string]*pluginapi.ListOfTopologyHints{
+		ResourceNameNetwork: hints,
+	})
 }
 
 func (p *DynamicPolicy) RemovePod(ctx context.Context, req *pluginapi.RemovePodRequest) (*pluginapi.RemovePodResponse, error) {
@@ -200,29 +307,193 @@ func (p *DynamicPolicy) RemovePod(ctx context.Context, req *pluginapi.RemovePodR
 		}
 	}
 
+	if err := p.bandwidthEnforcer.RemovePod(req.PodUid); err != nil {
+		klog.ErrorS(err, "[NetworkDynamicPolicy.RemovePod] remove bandwidth limit failed with error", "podUID", req.PodUid)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	podEntries := p.state.GetPodEntries()
+	if _, ok := podEntries[req.PodUid]; ok {
+		delete(podEntries, req.PodUid)
+		p.state.SetPodEntries(podEntries)
+		p.state.SetMachineState(state.GenerateMachineStateFromPodEntries(p.state.GetNICs(), p.state.GetReservedBandwidth(), podEntries))
+	}
+
 	return &pluginapi.RemovePodResponse{}, nil
 }
 
 // GetResourcesAllocation returns allocation results of corresponding resources
 func (p *DynamicPolicy) GetResourcesAllocation(ctx context.Context, req *pluginapi.GetResourcesAllocationRequest) (*pluginapi.GetResourcesAllocationResponse, error) {
-	return &pluginapi.GetResourcesAllocationResponse{}, nil
+	if req == nil {
+		return nil, fmt.Errorf("GetResourcesAllocation got nil req")
+	}
+
+	p.RLock()
+	defer p.RUnlock()
+
+	podResources := make(map[string]*pluginapi.ContainerResources)
+	for podUID, containerEntries := range p.state.GetPodEntries() {
+		if podResources[podUID] == nil {
+			podResources[podUID] = &pluginapi.ContainerResources{}
+		}
+
+		for containerName, allocationInfo := range containerEntries {
+			if allocationInfo == nil {
+				continue
+			}
+
+			if podResources[podUID].ContainerResources == nil {
+				podResources[podUID].ContainerResources = make(map[string]*pluginapi.ResourceAllocation)
+			}
+
+			podResources[podUID].ContainerResources[containerName] = &pluginapi.ResourceAllocation{
+				ResourceAllocation: map[string]*pluginapi.ResourceAllocationInfo{
+					ResourceNameNetwork: p.getResourceAllocationInfo(allocationInfo, nil),
+				},
+			}
+		}
+	}
+
+	return &pluginapi.GetResourcesAllocationResponse{
+		PodResources: podResources,
+	}, nil
 }
 
-// GetTopologyAwareResources returns allocation results of corresponding resources as topology aware format
+// GetTopologyAwareResources returns allocation results of corresponding resources as topology aware format;
+// NICs are shared among containers, so each container accounts for one NIC in the NUMA node of its NIC,
+// and bandwidth of the container is accounted in the NUMA node of its NIC too
 func (p *DynamicPolicy) GetTopologyAwareResources(ctx context.Context, req *pluginapi.GetTopologyAwareResourcesRequest) (*pluginapi.GetTopologyAwareResourcesResponse, error) {
-	return &pluginapi.GetTopologyAwareResourcesResponse{}, nil
+	if req == nil {
+		return nil, fmt.Errorf("GetTopologyAwareResources got nil req")
+	}
+
+	p.RLock()
+	defer p.RUnlock()
+
+	allocationInfo := p.state.GetAllocationInfo(req.PodUid, req.ContainerName)
+	if allocationInfo == nil {
+		return &pluginapi.GetTopologyAwareResourcesResponse{}, nil
+	}
+
+	topologyAwareQuantityList := make([]*pluginapi.TopologyAwareQuantity, 0, allocationInfo.NumaNodes.Size())
+	bandwidthTopologyAwareQuantityList := make([]*pluginapi.TopologyAwareQuantity, 0, allocationInfo.NumaNodes.Size())
+	for _, numaNode := range allocationInfo.NumaNodes.ToSliceUInt64() {
+		topologyAwareQuantityList = append(topologyAwareQuantityList, &pluginapi.TopologyAwareQuantity{
+			ResourceValue: 1,
+			Node:          numaNode,
+		})
+		bandwidthTopologyAwareQuantityList = append(bandwidthTopologyAwareQuantityList, &pluginapi.TopologyAwareQuantity{
+			ResourceValue: float64(allocationInfo.Bandwidth),
+			Node:          numaNode,
+		})
+	}
+
+	return &pluginapi.GetTopologyAwareResourcesResponse{
+		PodUid:       allocationInfo.PodUid,
+		PodName:      allocationInfo.PodName,
+		PodNamespace: allocationInfo.PodNamespace,
+		ContainerTopologyAwareResources: &pluginapi.ContainerTopologyAwareResources{
+			ContainerName: allocationInfo.ContainerName,
+			AllocatedResources: map[string]*pluginapi.TopologyAwareResource{
+				ResourceNameNetwork: {
+					IsNodeResource:                    false,
+					IsScalarResource:                  true,
+					AggregatedQuantity:                1,
+					OriginalAggregatedQuantity:        1,
+					TopologyAwareQuantityList:         topologyAwareQuantityList,
+					OriginalTopologyAwareQuantityList: topologyAwareQuantityList,
+				},
+				ResourceNameNetBandwidth: {
+					IsNodeResource:                    false,
+					IsScalarResource:                  true,
+					AggregatedQuantity:                float64(allocationInfo.Bandwidth),
+					OriginalAggregatedQuantity:        float64(allocationInfo.Bandwidth),
+					TopologyAwareQuantityList:         bandwidthTopologyAwareQuantityList,
+					OriginalTopologyAwareQuantityList: bandwidthTopologyAwareQuantityList,
+				},
+			},
+		},
+	}, nil
 }
 
-// GetTopologyAwareAllocatableResources returns corresponding allocatable resources as topology aware format
+// GetTopologyAwareAllocatableResources returns corresponding allocatable resources as topology aware format,
+// and the quantity of each NUMA node is the number of NICs affinitive to it; for bandwidth, the allocatable
+// quantity excludes the reserved bandwidth while the capacity quantity is the speed of NICs
 func (p *DynamicPolicy) GetTopologyAwareAllocatableResources(ctx context.Context, req *pluginapi.GetTopologyAwareAllocatableResourcesRequest) (*pluginapi.GetTopologyAwareAllocatableResourcesResponse, error) {
-	return &pluginapi.GetTopologyAwareAllocatableResourcesResponse{}, nil
+	p.RLock()
+	defer p.RUnlock()
+
+	machineState := p.state.GetMachineState()
+	numaNodes := machine.NewCPUSet()
+	nicsPerNuma := make(map[int]int)
+	allocatableBandwidthPerNuma := make(map[int]uint64)
+	capacityBandwidthPerNuma := make(map[int]uint64)
+	var allocatableBandwidth, capacityBandwidth uint64
+	nics := p.state.GetNICs()
+	for _, nic := range nics {
+		nicState := machineState[nic.Iface]
+		if nicState != nil {
+			allocatableBandwidth += nicState.AllocatableBandwidth
+			capacityBandwidth += nicState.TotalBandwidth
+		}
+
+		for _, numaNode := range getNICNumaNodes(nic).ToSliceInt() {
+			numaNodes.Add(numaNode)
+			nicsPerNuma[numaNode]++
+			if nicState != nil {
+				allocatableBandwidthPerNuma[numaNode] += nicState.AllocatableBandwidth
+				capacityBandwidthPerNuma[numaNode] += nicState.TotalBandwidth
+			}
+		}
+	}
+
+	topologyAwareQuantityList := make([]*pluginapi.TopologyAwareQuantity, 0, len(nicsPerNuma))
+	allocatableBandwidthQuantityList := make([]*pluginapi.TopologyAwareQuantity, 0, len(nicsPerNuma))
+	capacityBandwidthQuantityList := make([]*pluginapi.TopologyAwareQuantity, 0, len(nicsPerNuma))
+	for _, numaNode := range numaNodes.ToSliceInt() {
+		topologyAwareQuantityList = append(topologyAwareQuantityList, &pluginapi.TopologyAwareQuantity{
+			ResourceValue: float64(nicsPerNuma[numaNode]),
+			Node:          uint64(numaNode),
+		})
+		allocatableBandwidthQuantityList = append(allocatableBandwidthQuantityList, &pluginapi.TopologyAwareQuantity{
+			ResourceValue: float64(allocatableBandwidthPerNuma[numaNode]),
+			Node:          uint64(numaNode),
+		})
+		capacityBandwidthQuantityList = append(capacityBandwidthQuantityList, &pluginapi.TopologyAwareQuantity{
+			ResourceValue: float64(capacityBandwidthPerNuma[numaNode]),
+			Node:          uint64(numaNode),
+		})
+	}
+
+	return &pluginapi.GetTopologyAwareAllocatableResourcesResponse{
+		AllocatableResources: map[string]*pluginapi.AllocatableTopologyAwareResource{
+			ResourceNameNetwork: {
+				IsNodeResource:                       false,
+				IsScalarResource:                     true,
+				AggregatedAllocatableQuantity:        float64(len(nics)),
+				TopologyAwareAllocatableQuantityList: topologyAwareQuantityList,
+				AggregatedCapacityQuantity:           float64(len(nics)),
+				TopologyAwareCapacityQuantityList:    topologyAwareQuantityList,
+			},
+			ResourceNameNetBandwidth: {
+				IsNodeResource:                       false,
+				IsScalarResource:                     true,
+				AggregatedAllocatableQuantity:        float64(allocatableBandwidth),
+				TopologyAwareAllocatableQuantityList: allocatableBandwidthQuantityList,
+				AggregatedCapacityQuantity:           float64(capacityBandwidth),
+				TopologyAwareCapacityQuantityList:    capacityBandwidthQuantityList,
+			},
+		},
+	}, nil
 }
 
 // GetResourcePluginOptions returns options to be communicated with Resource Manager
 func (p *DynamicPolicy) GetResourcePluginOptions(context.Context, *pluginapi.Empty) (*pluginapi.ResourcePluginOptions, error) {
 	return &pluginapi.ResourcePluginOptions{
 		PreStartRequired:      false,
-		WithTopologyAlignment: false,
+		WithTopologyAlignment: true,
 		NeedReconcile:         false,
 	}, nil
 }
@@ -231,6 +502,30 @@ func (p *DynamicPolicy) GetResourcePluginOptions(context.Context, *pluginapi.Emp
 // plugin can allocate corresponding resource for the container
 // according to resource request
 func (p *DynamicPolicy) Allocate(ctx context.Context, req *pluginapi.ResourceRequest) (resp *pluginapi.ResourceAllocationResponse, respErr error) {
+	if req == nil {
+		return nil, fmt.Errorf("Allocate got nil req")
+	}
+
+	p.Lock()
+	defer func() {
+		p.Unlock()
+
+		if respErr != nil {
+			_ = p.emitter.StoreInt64(util.MetricNameAllocateFailed, 1, metrics.MetricTypeNameRaw)
+		}
+	}()
+
+	resourceAllocationInfo := &pluginapi.ResourceAllocationInfo{}
+	if len(p.state.GetNICs()) > 0 && needNIC(req) {
+		allocationInfo, err := p.allocateNIC(req)
+		if err != nil {
+			klog.Errorf("[NetworkDynamicPolicy.Allocate] allocate NIC for pod: %s/%s, container: %s failed with error: %v",
+				req.PodNamespace, req.PodName, req.ContainerName, err)
+			return nil, err
+		}
+		resourceAllocationInfo = p.getResourceAllocationInfo(allocationInfo, req.Hint)
+	}
+
 	return &pluginapi.ResourceAllocationResponse{
 		PodUid:         req.PodUid,
 		PodNamespace:   req.PodNamespace,
@@ -243,7 +538,7 @@ func (p *DynamicPolicy) Allocate(ctx context.Context, req *pluginapi.ResourceReq
 		ResourceName:   ResourceNameNetwork,
 		AllocationResult: &pluginapi.ResourceAllocation{
 			ResourceAllocation: map[string]*pluginapi.ResourceAllocationInfo{
-				ResourceNameNetwork: {},
+				ResourceNameNetwork: resourceAllocationInfo,
 			},
 		},
 		Labels:      general.DeepCopyMap(req.Labels),
@@ -251,6 +546,106 @@ func (p *DynamicPolicy) Allocate(ctx context.Context, req *pluginapi.ResourceReq
 	}, nil
 }
 
+// allocateNIC assigns a NIC aligned with the hint and with enough free bandwidth to the container
+// and records it in state, and the former assignment is reused if the container has been allocated
+func (p *DynamicPolicy) allocateNIC(req *pluginapi.ResourceRequest) (*state.AllocationInfo, error) {
+	if allocationInfo := p.state.GetAllocationInfo(req.PodUid, req.ContainerName); allocationInfo != nil {
+		return allocationInfo, nil
+	}
+
+	qosLevel, err := util.GetKatalystQoSLevelFromResourceReq(p.qosConfig, req)
+	if err != nil {
+		return nil, fmt.Errorf("GetKatalystQoSLevelFromResourceReq failed with error: %v", err)
+	}
+
+	hintNodes := machine.NewCPUSet()
+	if req.Hint != nil {
+		hintNodes = machine.NewCPUSet(util.HintToIntArray(req.Hint)...)
+	}
+
+	nic, err := p.selectNICForContainer(req, hintNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	bandwidth := getBandwidthFromResourceReq(req)
+	nics := p.state.GetNICs()
+
+	allocationInfo := &state.AllocationInfo{
+		PodUid:         req.PodUid,
+		PodNamespace:   req.PodNamespace,
+		PodName:        req.PodName,
+		ContainerName:  req.ContainerName,
+		ContainerType:  req.ContainerType.String(),
+		ContainerIndex: req.ContainerIndex,
+		PodRole:        req.PodRole,
+		PodType:        req.PodType,
+		IfName:         nic.Iface,
+		NumaNodes:      getNICNumaNodes(*nic),
+		Bandwidth:      bandwidth,
+		Labels:         general.DeepCopyMap(req.Labels),
+		Annotations:    general.DeepCopyMap(req.Annotations),
+		QoSLevel:       qosLevel,
+	}
+
+	p.state.SetAllocationInfo(req.PodUid, req.ContainerName, allocationInfo)
+	p.state.SetMachineState(state.GenerateMachineStateFromPodEntries(nics, p.state.GetReservedBandwidth(), p.state.GetPodEntries()))
+
+	klog.InfoS("[NetworkDynamicPolicy.allocateNIC] NIC is allocated",
+		"podNamespace", req.PodNamespace,
+		"podName", req.PodName,
+		"containerName", req.ContainerName,
+		"qosLevel", qosLevel,
+		"nic", nic.Iface,
+		"numaNodes", allocationInfo.NumaNodes.String(),
+		"bandwidth", bandwidth)
+	return allocationInfo, nil
+}
+
+// selectNICForContainer selects the NIC for the container among NICs affinitive to hintNodes, and
+// the NIC already assigned to the container is reused; both GetTopologyHints and Allocate select
+// NICs with it, so that hints are generated for the same NIC as the one to be allocated.
+func (p *DynamicPolicy) selectNICForContainer(req *pluginapi.ResourceRequest, hintNodes machine.CPUSet) (*machine.InterfaceInfo, error) {
+	nics := p.state.GetNICs()
+	if allocationInfo := p.state.GetAllocationInfo(req.PodUid, req.ContainerName); allocationInfo != nil {
+		for i := range nics {
+			if nics[i].Iface == allocationInfo.IfName {
+				return &nics[i], nil
+			}
+		}
+	}
+
+	bandwidth := getBandwidthFromResourceReq(req)
+	nic := selectNIC(p.state.GetMachineState(), nics, hintNodes, bandwidth)
+	if nic == nil {
+		return nil, fmt.Errorf("no NIC has enough free bandwidth %d for pod: %s/%s, container: %s",
+			bandwidth, req.PodNamespace, req.PodName, req.ContainerName)
+	}
+	return nic, nil
+}
+
+// getResourceAllocationInfo returns the allocation result with the assigned NIC in annotations,
+// so that CNI plugins can set up the network of the container with it
+func (p *DynamicPolicy) getResourceAllocationInfo(allocationInfo *state.AllocationInfo, hint *pluginapi.TopologyHint) *pluginapi.ResourceAllocationInfo {
+	resourceAllocationInfo := &pluginapi.ResourceAllocationInfo{
+		IsNodeResource:    false,
+		IsScalarResource:  true,
+		AllocatedQuantity: float64(allocationInfo.Bandwidth),
+		AllocationResult:  allocationInfo.NumaNodes.String(),
+		Annotations: map[string]string{
+			p.nicNameAllocationAnnotationKey: allocationInfo.IfName,
+			p.nicNumaAllocationAnnotationKey: allocationInfo.NumaNodes.String(),
+		},
+	}
+
+	if hint != nil {
+		resourceAllocationInfo.ResourceHints = &pluginapi.ListOfTopologyHints{
+			Hints: []*pluginapi.TopologyHint{hint},
+		}
+	}
+	return resourceAllocationInfo
+}
+
 // PreStartContainer is called, if indicated by resource plugin during registeration phase,
 // before each container start. Resource plugin can run resource specific operations
 // such as resetting the resource before making resources available to the container
@@ -271,6 +666,12 @@ func (p *DynamicPolicy) applyNetClass() {
 	}
 
 	for _, pod := range podList {
+		if !p.isNetClassEffective(pod) {
+			klog.V(4).Infof("[NetworkDynamicPolicy.applyNetClass] net class can't take effect for non-host-network pod: %s, skip it",
+				native.GenerateUniqObjectNameKey(pod))
+			continue
+		}
+
 		classID, err := p.getNetClassID(pod, p.podLevelNetClassAnnoKey)
 		if err != nil {
 			klog.Errorf("[NetworkDynamicPolicy.applyNetClass] get net class id failed, pod: %s, err: %s", native.GenerateUniqObjectNameKey(pod), err)
@@ -324,6 +725,41 @@ func (p *DynamicPolicy) applyNetClass() {
 	}
 }
 
+// applyBandwidthLimit limits bandwidth of containers on their NICs by the enforcer
+func (p *DynamicPolicy) applyBandwidthLimit() {
+	if p.metaServer == nil {
+		klog.Errorf("[NetworkDynamicPolicy.applyBandwidthLimit] nil metaServer")
+		return
+	}
+
+	p.RLock()
+	var allocationInfos []*state.AllocationInfo
+	for _, containerEntries := range p.state.GetPodEntries() {
+		for _, allocationInfo := range containerEntries {
+			if allocationInfo != nil && allocationInfo.Bandwidth > 0 {
+				allocationInfos = append(allocationInfos, allocationInfo)
+			}
+		}
+	}
+	p.RUnlock()
+
+	for _, allocationInfo := range allocationInfos {
+		containerID, err := p.metaServer.GetContainerID(allocationInfo.PodUid, allocationInfo.ContainerName)
+		if err != nil {
+			klog.Errorf("[NetworkDynamicPolicy.applyBandwidthLimit] get container id failed, pod: %s, container: %s, err: %v",
+				allocationInfo.PodUid, allocationInfo.ContainerName, err)
+			continue
+		}
+
+		err = p.bandwidthEnforcer.SetBandwidthLimit(allocationInfo.PodUid, containerID, allocationInfo.IfName, allocationInfo.Bandwidth)
+		if err != nil {
+			klog.Errorf("[NetworkDynamicPolicy.applyBandwidthLimit] set bandwidth limit by %s failed, pod: %s, container: %s(%s), nic: %s, bandwidth: %d, err: %v",
+				p.bandwidthEnforcer.Name(), allocationInfo.PodUid, allocationInfo.ContainerName, containerID,
+				allocationInfo.IfName, allocationInfo.Bandwidth, err)
+		}
+	}
+}
+
 func (p *DynamicPolicy) removePod(podUID string) error {
 	cgIDList, err := p.metaServer.ExternalManager.ListCgroupIDsForPod(podUID)
 	if err != nil {
@@ -332,7 +768,7 @@ func (p *DynamicPolicy) removePod(podUID string) error {
 
 	for _, cgID := range cgIDList {
 		go func(cgID uint64) {
-			if err := p.metaServer.ExternalManager.ClearNetClass(cgID); err != nil {
+			if err := p.clearNetClassFunc(cgID); err != nil {
 				klog.Errorf("[NetworkDynamicPolicy.removePod] delete net class failed, cgID: %v, err: %v", cgID, err)
 				return
 			}
@@ -342,6 +778,14 @@ func (p *DynamicPolicy) removePod(podUID string) error {
 	return nil
 }
 
+// isNetClassEffective returns false if the net class of the pod can't take effect. When net classes are
+// shaped by tc, packets routed out of pod network namespaces have been orphaned from their sockets
+// before reaching NICs, so only host-network pods are classified, and others are left out instead of
+// being claimed to be shaped.
+func (p *DynamicPolicy) isNetClassEffective(pod *v1.Pod) bool {
+	return !p.netClassShapedByTC || pod.Spec.HostNetwork
+}
+
 func (p *DynamicPolicy) getNetClassID(pod *v1.Pod, podLevelNetClassAnnoKey string) (uint32, error) {
 	isPodLevelNetClassExist, classID, err := qos.GetPodNetClassID(pod, podLevelNetClassAnnoKey)
 	if err != nil {
@@ -364,3 +808,19 @@ func (p *DynamicPolicy) getNetClassIDByQoS(qosClass string) uint32 {
 
 	return p.netClassMap[qosClass]
 }
+
+// needNIC returns true if the container should be assigned a NIC; only containers requesting
+// bandwidth are network-heavy to be aligned with NICs, and others are admitted without any NIC
+func needNIC(req *pluginapi.ResourceRequest) bool {
+	return req.ContainerType != pluginapi.ContainerType_INIT && getBandwidthFromResourceReq(req) > 0
+}
+
+// getBandwidthFromResourceReq parses the requested bandwidth (in Mbps), and it's
+// zero if the container doesn't request bandwidth explicitly
+func getBandwidthFromResourceReq(req *pluginapi.ResourceRequest) uint64 {
+	bandwidth, ok := req.ResourceRequests[ResourceNameNetBandwidth]
+	if !ok || bandwidth <= 0 {
+		return 0
+	}
+	return uint64(math.Ceil(bandwidth))
+}
@@ -17,6 +17,7 @@ package dynamicpolicy
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,17 +32,24 @@ import (
 	katalyst_base "github.com/kubewharf/katalyst-core/cmd/base"
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/agent"
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/network/dynamicpolicy/enforcer"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/network/dynamicpolicy/state"
 	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/util"
 	"github.com/kubewharf/katalyst-core/pkg/config"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	metaserveragent "github.com/kubewharf/katalyst-core/pkg/metaserver/agent"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
 
 func generateTestConfiguration(t *testing.T) *config.Configuration {
 	testConfiguration, err := options.NewOptions().Config()
 	require.NoError(t, err)
 	require.NotNil(t, testConfiguration)
+
+	tmpDir, err := ioutil.TempDir("", "checkpoint-TestNetworkPolicy")
+	require.NoError(t, err)
+	testConfiguration.GenericQRMPluginConfiguration.StateFileDirectory = tmpDir
 	return testConfiguration
 }
 
@@ -63,19 +71,36 @@ func makeTestGenericContext(t *testing.T) *agent.GenericContext {
 }
 
 func makeDynamicPolicy(t *testing.T) *DynamicPolicy {
+	return makeDynamicPolicyWithNICs(t, nil, nil)
+}
+
+func makeDynamicPolicyWithNICs(t *testing.T, topology *machine.CPUTopology, nics []machine.InterfaceInfo) *DynamicPolicy {
 	agentCtx := makeTestGenericContext(t)
 	wrappedEmitter := agentCtx.EmitterPool.GetDefaultMetricsEmitter().WithTags(NetworkResourcePluginPolicyNameDynamic, metrics.MetricTag{
 		Key: util.QRMPluginPolicyTagName,
 		Val: NetworkResourcePluginPolicyNameDynamic,
 	})
 
+	conf := generateTestConfiguration(t)
+	stateImpl, err := state.NewCheckpointState(conf.GenericQRMPluginConfiguration.StateFileDirectory, networkPluginStateFileName,
+		NetworkResourcePluginPolicyNameDynamic, nics, conf.ReservedBandwidth, false)
+	require.NoError(t, err)
+
+	bandwidthEnforcer, err := enforcer.NewNoopBandwidthEnforcer(conf)
+	require.NoError(t, err)
+
 	return &DynamicPolicy{
-		qosConfig:   generateTestConfiguration(t).QoSConfiguration,
-		emitter:     wrappedEmitter,
-		metaServer:  agentCtx.MetaServer,
-		stopCh:      make(chan struct{}),
-		name:        fmt.Sprintf("%s_%s", "qrm_network_plugin", NetworkResourcePluginPolicyNameDynamic),
-		netClassMap: make(map[string]uint32),
+		qosConfig:                      conf.QoSConfiguration,
+		emitter:                        wrappedEmitter,
+		metaServer:                     agentCtx.MetaServer,
+		stopCh:                         make(chan struct{}),
+		name:                           fmt.Sprintf("%s_%s", "qrm_network_plugin", NetworkResourcePluginPolicyNameDynamic),
+		netClassMap:                    make(map[string]uint32),
+		state:                          stateImpl,
+		topology:                       topology,
+		nicNameAllocationAnnotationKey: conf.NICNameAllocationAnnotationKey,
+		nicNumaAllocationAnnotationKey: conf.NICNumaAllocationAnnotationKey,
+		bandwidthEnforcer:              bandwidthEnforcer,
 	}
 }
 
@@ -88,6 +113,34 @@ func TestNewDynamicPolicy(t *testing.T) {
 	return
 }
 
+func TestNewDynamicPolicyWithUnregisteredEnforcer(t *testing.T) {
+	conf := generateTestConfiguration(t)
+	conf.BandwidthEnforcerName = "unregistered"
+
+	needToRun, policy, err := NewDynamicPolicy(makeTestGenericContext(t), conf, nil, NetworkResourcePluginPolicyNameDynamic)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	require.True(t, needToRun)
+}
+
+func TestFilterAvailableNICs(t *testing.T) {
+	t.Parallel()
+
+	nics := []machine.InterfaceInfo{
+		{Iface: "eth1", Enable: true, Speed: -1},
+		{Iface: "eth0", Enable: true, Speed: 25000},
+		{Iface: "eth2", Enable: false, Speed: 25000},
+	}
+
+	require.Equal(t, []machine.InterfaceInfo{
+		{Iface: "eth0", Enable: true, Speed: 25000},
+		{Iface: "eth1", Enable: true, Speed: 10000},
+	}, filterAvailableNICs(nics, 10000))
+
+	// speed is kept unknown without the default speed
+	require.Equal(t, -1, filterAvailableNICs(nics, 0)[1].Speed)
+}
+
 func TestRemovePod(t *testing.T) {
 	policy := makeDynamicPolicy(t)
 	assert.NotNil(t, policy)
@@ -376,6 +429,43 @@ func TestGetNetClassID(t *testing.T) {
 	}
 }
 
+func TestIsNetClassEffective(t *testing.T) {
+	hostNetworkPod := &v1.Pod{Spec: v1.PodSpec{HostNetwork: true}}
+	podNetworkPod := &v1.Pod{}
+
+	testCases := []struct {
+		description        string
+		netClassShapedByTC bool
+		pod                *v1.Pod
+		expected           bool
+	}{
+		{
+			description:        "net class of host-network pod is shaped by tc",
+			netClassShapedByTC: true,
+			pod:                hostNetworkPod,
+			expected:           true,
+		},
+		{
+			description:        "net class of non-host-network pod can't be shaped by tc",
+			netClassShapedByTC: true,
+			pod:                podNetworkPod,
+			expected:           false,
+		},
+		{
+			description:        "net class of non-host-network pod is applied without tc",
+			netClassShapedByTC: false,
+			pod:                podNetworkPod,
+			expected:           true,
+		},
+	}
+
+	for _, tc := range testCases {
+		dynamicPolicy := makeDynamicPolicy(t)
+		dynamicPolicy.netClassShapedByTC = tc.netClassShapedByTC
+		assert.Equal(t, tc.expected, dynamicPolicy.isNetClassEffective(tc.pod), tc.description)
+	}
+}
+
 func TestName(t *testing.T) {
 	policy := makeDynamicPolicy(t)
 	assert.NotNil(t, policy)
@@ -452,7 +542,7 @@ func TestGetResourcePluginOptions(t *testing.T) {
 
 	expectedResp := &pluginapi.ResourcePluginOptions{
 		PreStartRequired:      false,
-		WithTopologyAlignment: false,
+		WithTopologyAlignment: true,
 		NeedReconcile:         false,
 	}
 
@@ -475,3 +565,199 @@ func TestPreStartContainer(t *testing.T) {
 	_, err := policy.PreStartContainer(context.TODO(), req)
 	assert.NoError(t, err)
 }
+
+func TestNICTopologyHintsAndAllocation(t *testing.T) {
+	topology, err := machine.GenerateDummyCPUTopology(16, 2, 4)
+	require.NoError(t, err)
+
+	nics := []machine.InterfaceInfo{
+		{Iface: "eth0", NumaNode: 0, Enable: true, Speed: 25000},
+		{Iface: "eth1", NumaNode: 2, Enable: true, Speed: 25000},
+	}
+	policy := makeDynamicPolicyWithNICs(t, topology, nics)
+
+	newReq := func(podUID string) *pluginapi.ResourceRequest {
+		return &pluginapi.ResourceRequest{
+			PodUid:        podUID,
+			PodNamespace:  "test",
+			PodName:       podUID,
+			ContainerName: "main",
+			ContainerType: pluginapi.ContainerType_MAIN,
+			ResourceName:  ResourceNameNetwork,
+			ResourceRequests: map[string]float64{
+				ResourceNameNetBandwidth: 1000,
+			},
+			Annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+			},
+		}
+	}
+
+	// containers without bandwidth requests don't need any NIC
+	req0 := newReq("pod0")
+	req0.ResourceRequests = make(map[string]float64)
+	hintsResp, err := policy.GetTopologyHints(context.Background(), req0)
+	require.NoError(t, err)
+	require.Nil(t, hintsResp.ResourceHints[ResourceNameNetwork])
+	resp, err := policy.Allocate(context.Background(), req0)
+	require.NoError(t, err)
+	require.Equal(t, &pluginapi.ResourceAllocationInfo{}, resp.AllocationResult.ResourceAllocation[ResourceNameNetwork])
+	require.Nil(t, policy.state.GetAllocationInfo("pod0", "main"))
+
+	// the first container prefers the socket of eth0
+	req1 := newReq("pod1")
+	hintsResp, err = policy.GetTopologyHints(context.Background(), req1)
+	require.NoError(t, err)
+	require.Equal(t, []*pluginapi.TopologyHint{
+		{Nodes: []uint64{0, 1}, Preferred: true},
+		{Nodes: []uint64{2, 3}, Preferred: false},
+	}, hintsResp.ResourceHints[ResourceNameNetwork].Hints)
+
+	req1.Hint = &pluginapi.TopologyHint{Nodes: []uint64{1}, Preferred: true}
+	resp, err = policy.Allocate(context.Background(), req1)
+	require.NoError(t, err)
+	allocation := resp.AllocationResult.ResourceAllocation[ResourceNameNetwork]
+	require.Equal(t, "eth0", allocation.Annotations[policy.nicNameAllocationAnnotationKey])
+	require.Equal(t, "0", allocation.Annotations[policy.nicNumaAllocationAnnotationKey])
+	require.Equal(t, []*pluginapi.TopologyHint{req1.Hint}, allocation.ResourceHints.Hints)
+
+	// the second container prefers the socket of eth1 since eth0 has been assigned
+	req2 := newReq("pod2")
+	hintsResp, err = policy.GetTopologyHints(context.Background(), req2)
+	require.NoError(t, err)
+	require.Equal(t, []*pluginapi.TopologyHint{
+		{Nodes: []uint64{0, 1}, Preferred: false},
+		{Nodes: []uint64{2, 3}, Preferred: true},
+	}, hintsResp.ResourceHints[ResourceNameNetwork].Hints)
+
+	// but the NIC aligned with the hint is allocated
+	req2.Hint = &pluginapi.TopologyHint{Nodes: []uint64{0, 1}, Preferred: false}
+	resp, err = policy.Allocate(context.Background(), req2)
+	require.NoError(t, err)
+	require.Equal(t, "eth0", resp.AllocationResult.ResourceAllocation[ResourceNameNetwork].Annotations[policy.nicNameAllocationAnnotationKey])
+	require.Equal(t, 2, policy.state.GetMachineState()["eth0"].GetContainersCount())
+
+	// allocation is reused for containers already assigned
+	hintsResp, err = policy.GetTopologyHints(context.Background(), req1)
+	require.NoError(t, err)
+	require.True(t, hintsResp.ResourceHints[ResourceNameNetwork].Hints[0].Preferred)
+
+	allocationResp, err := policy.GetResourcesAllocation(context.Background(), &pluginapi.GetResourcesAllocationRequest{})
+	require.NoError(t, err)
+	require.Len(t, allocationResp.PodResources, 2)
+	require.Equal(t, "0", allocationResp.PodResources["pod1"].ContainerResources["main"].ResourceAllocation[ResourceNameNetwork].AllocationResult)
+
+	topologyAwareResp, err := policy.GetTopologyAwareResources(context.Background(), &pluginapi.GetTopologyAwareResourcesRequest{
+		PodUid:        "pod1",
+		ContainerName: "main",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*pluginapi.TopologyAwareQuantity{{ResourceValue: 1, Node: 0}},
+		topologyAwareResp.ContainerTopologyAwareResources.AllocatedResources[ResourceNameNetwork].TopologyAwareQuantityList)
+
+	allocatableResp, err := policy.GetTopologyAwareAllocatableResources(context.Background(), &pluginapi.GetTopologyAwareAllocatableResourcesRequest{})
+	require.NoError(t, err)
+	require.Equal(t, float64(2), allocatableResp.AllocatableResources[ResourceNameNetwork].AggregatedAllocatableQuantity)
+	require.Equal(t, []*pluginapi.TopologyAwareQuantity{{ResourceValue: 1, Node: 0}, {ResourceValue: 1, Node: 2}},
+		allocatableResp.AllocatableResources[ResourceNameNetwork].TopologyAwareAllocatableQuantityList)
+
+	_, err = policy.RemovePod(context.Background(), &pluginapi.RemovePodRequest{PodUid: "pod1"})
+	require.NoError(t, err)
+	require.Nil(t, policy.state.GetAllocationInfo("pod1", "main"))
+	require.Equal(t, 1, policy.state.GetMachineState()["eth0"].GetContainersCount())
+}
+
+func TestSelectNIC(t *testing.T) {
+	t.Parallel()
+
+	nics := []machine.InterfaceInfo{
+		{Iface: "eth0", NumaNode: 0, Enable: true, Speed: 10000},
+		{Iface: "eth1", NumaNode: 1, Enable: true, Speed: 25000},
+		{Iface: "eth2", NumaNode: -1, Enable: true, Speed: 10000},
+	}
+	machineState := state.GetDefaultMachineState(nics, 0)
+
+	// faster NIC is preferred without hint
+	require.Equal(t, "eth1", selectNIC(machineState, nics, machine.NewCPUSet(), 0).Iface)
+
+	// NICs with unknown NUMA affinity are always candidates
+	machineState["eth0"].SetAllocationInfo("pod", "main", &state.AllocationInfo{})
+	require.Equal(t, "eth2", selectNIC(machineState, nics, machine.NewCPUSet(0), 0).Iface)
+
+	// all NICs are candidates if none matches the hint
+	require.Equal(t, "eth1", selectNIC(machineState, nics[:2], machine.NewCPUSet(3), 0).Iface)
+
+	// NICs without enough free bandwidth are skipped
+	require.Equal(t, "eth1", selectNIC(machineState, nics, machine.NewCPUSet(0), 20000).Iface)
+	require.Nil(t, selectNIC(machineState, nics, machine.NewCPUSet(), 30000))
+
+	require.Nil(t, selectNIC(machineState, nil, machine.NewCPUSet(), 0))
+}
+
+func TestBandwidthAllocation(t *testing.T) {
+	nics := []machine.InterfaceInfo{
+		{Iface: "eth0", NumaNode: 0, Enable: true, Speed: 10000},
+		{Iface: "eth1", NumaNode: 1, Enable: true, Speed: 10000},
+	}
+	policy := makeDynamicPolicyWithNICs(t, nil, nics)
+	policy.state = state.NewNetworkPluginState(nics, 2000)
+
+	newReq := func(podUID string, bandwidth float64) *pluginapi.ResourceRequest {
+		return &pluginapi.ResourceRequest{
+			PodUid:        podUID,
+			PodNamespace:  "test",
+			PodName:       podUID,
+			ContainerName: "main",
+			ContainerType: pluginapi.ContainerType_MAIN,
+			ResourceName:  ResourceNameNetwork,
+			ResourceRequests: map[string]float64{
+				ResourceNameNetBandwidth: bandwidth,
+			},
+			Annotations: map[string]string{
+				consts.PodAnnotationQoSLevelKey: consts.PodAnnotationQoSLevelSharedCores,
+			},
+			Hint: &pluginapi.TopologyHint{Nodes: []uint64{0}, Preferred: true},
+		}
+	}
+
+	// bandwidth is admitted against the speed of NIC minus the reserved bandwidth
+	resp, err := policy.Allocate(context.Background(), newReq("pod1", 6000))
+	require.NoError(t, err)
+	allocation := resp.AllocationResult.ResourceAllocation[ResourceNameNetwork]
+	require.Equal(t, "eth0", allocation.Annotations[policy.nicNameAllocationAnnotationKey])
+	require.Equal(t, float64(6000), allocation.AllocatedQuantity)
+	require.Equal(t, uint64(2000), policy.state.GetMachineState()["eth0"].FreeBandwidth)
+
+	// the NIC out of the hint is allocated if the aligned one is short of bandwidth
+	resp, err = policy.Allocate(context.Background(), newReq("pod2", 3000))
+	require.NoError(t, err)
+	require.Equal(t, "eth1", resp.AllocationResult.ResourceAllocation[ResourceNameNetwork].Annotations[policy.nicNameAllocationAnnotationKey])
+
+	// requests exceeding free bandwidth of all NICs are rejected
+	_, err = policy.Allocate(context.Background(), newReq("pod3", 6000))
+	require.Error(t, err)
+	hintsResp, err := policy.GetTopologyHints(context.Background(), newReq("pod3", 6000))
+	require.NoError(t, err)
+	require.Empty(t, hintsResp.ResourceHints[ResourceNameNetwork].Hints)
+
+	topologyAwareResp, err := policy.GetTopologyAwareResources(context.Background(), &pluginapi.GetTopologyAwareResourcesRequest{
+		PodUid:        "pod1",
+		ContainerName: "main",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []*pluginapi.TopologyAwareQuantity{{ResourceValue: 6000, Node: 0}},
+		topologyAwareResp.ContainerTopologyAwareResources.AllocatedResources[ResourceNameNetBandwidth].TopologyAwareQuantityList)
+
+	allocatableResp, err := policy.GetTopologyAwareAllocatableResources(context.Background(), &pluginapi.GetTopologyAwareAllocatableResourcesRequest{})
+	require.NoError(t, err)
+	bandwidthResource := allocatableResp.AllocatableResources[ResourceNameNetBandwidth]
+	require.Equal(t, float64(16000), bandwidthResource.AggregatedAllocatableQuantity)
+	require.Equal(t, float64(20000), bandwidthResource.AggregatedCapacityQuantity)
+	require.Equal(t, []*pluginapi.TopologyAwareQuantity{{ResourceValue: 8000, Node: 0}, {ResourceValue: 8000, Node: 1}},
+		bandwidthResource.TopologyAwareAllocatableQuantityList)
+
+	// bandwidth is released after the pod is removed
+	_, err = policy.RemovePod(context.Background(), &pluginapi.RemovePodRequest{PodUid: "pod1"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(8000), policy.state.GetMachineState()["eth0"].FreeBandwidth)
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"encoding/json"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+type AllocationInfo struct {
+	PodUid         string `json:"pod_uid,omitempty"`
+	PodNamespace   string `json:"pod_namespace,omitempty"`
+	PodName        string `json:"pod_name,omitempty"`
+	ContainerName  string `json:"container_name,omitempty"`
+	ContainerType  string `json:"container_type,omitempty"`
+	ContainerIndex uint64 `json:"container_index,omitempty"`
+	PodRole        string `json:"pod_role,omitempty"`
+	PodType        string `json:"pod_type,omitempty"`
+
+	// IfName is the name of NIC assigned to the container,
+	// and NumaNodes are NUMA nodes the NIC is affinitive to
+	IfName    string         `json:"if_name"`
+	NumaNodes machine.CPUSet `json:"numa_nodes,omitempty"`
+	// Bandwidth is the egress and ingress bandwidth (in Mbps) reserved for the container on the NIC
+	Bandwidth uint64 `json:"bandwidth"`
+
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	QoSLevel    string            `json:"qosLevel"`
+}
+
+type ContainerEntries map[string]*AllocationInfo // Keyed by container name
+type PodEntries map[string]ContainerEntries      // Keyed by pod UID
+
+// NICState records the NUMA affinity of NIC, containers assigned to it
+// and the amount of bandwidth of it (in Mbps)
+type NICState struct {
+	IfName               string     `json:"if_name"`
+	NumaNode             int        `json:"numa_node"`
+	TotalBandwidth       uint64     `json:"total_bandwidth"`
+	ReservedBandwidth    uint64     `json:"reserved_bandwidth"`
+	AllocatableBandwidth uint64     `json:"allocatable_bandwidth"`
+	AllocatedBandwidth   uint64     `json:"allocated_bandwidth"`
+	FreeBandwidth        uint64     `json:"free_bandwidth"`
+	PodEntries           PodEntries `json:"pod_entries"`
+}
+
+type NICMap map[string]*NICState // Keyed by NIC name
+
+func (ai *AllocationInfo) String() string {
+	if ai == nil {
+		return ""
+	}
+
+	contentBytes, err := json.Marshal(ai)
+	if err != nil {
+		klog.Errorf("[AllocationInfo.String] marshal AllocationInfo failed with error: %v", err)
+		return ""
+	}
+	return string(contentBytes)
+}
+
+func (ai *AllocationInfo) Clone() *AllocationInfo {
+	if ai == nil {
+		return nil
+	}
+
+	return &AllocationInfo{
+		PodUid:         ai.PodUid,
+		PodNamespace:   ai.PodNamespace,
+		PodName:        ai.PodName,
+		ContainerName:  ai.ContainerName,
+		ContainerType:  ai.ContainerType,
+		ContainerIndex: ai.ContainerIndex,
+		PodRole:        ai.PodRole,
+		PodType:        ai.PodType,
+		IfName:         ai.IfName,
+		NumaNodes:      ai.NumaNodes.Clone(),
+		Bandwidth:      ai.Bandwidth,
+		Labels:         general.DeepCopyMap(ai.Labels),
+		Annotations:    general.DeepCopyMap(ai.Annotations),
+		QoSLevel:       ai.QoSLevel,
+	}
+}
+
+func (pe PodEntries) Clone() PodEntries {
+	clone := make(PodEntries)
+	for podUID, containerEntries := range pe {
+		clone[podUID] = make(ContainerEntries)
+		for containerName, allocationInfo := range containerEntries {
+			clone[podUID][containerName] = allocationInfo.Clone()
+		}
+	}
+	return clone
+}
+
+func (pe PodEntries) String() string {
+	if pe == nil {
+		return ""
+	}
+
+	contentBytes, err := json.Marshal(pe)
+	if err != nil {
+		klog.Errorf("[PodEntries.String] marshal PodEntries failed with error: %v", err)
+		return ""
+	}
+	return string(contentBytes)
+}
+
+// ContainersCount returns the number of containers in pod entries
+func (pe PodEntries) ContainersCount() int {
+	count := 0
+	for _, containerEntries := range pe {
+		count += len(containerEntries)
+	}
+	return count
+}
+
+func (ns *NICState) Clone() *NICState {
+	if ns == nil {
+		return nil
+	}
+
+	return &NICState{
+		IfName:               ns.IfName,
+		NumaNode:             ns.NumaNode,
+		TotalBandwidth:       ns.TotalBandwidth,
+		ReservedBandwidth:    ns.ReservedBandwidth,
+		AllocatableBandwidth: ns.AllocatableBandwidth,
+		AllocatedBandwidth:   ns.AllocatedBandwidth,
+		FreeBandwidth:        ns.FreeBandwidth,
+		PodEntries:           ns.PodEntries.Clone(),
+	}
+}
+
+// GetContainersCount returns the number of containers assigned to the NIC
+func (ns *NICState) GetContainersCount() int {
+	if ns == nil {
+		return 0
+	}
+	return ns.PodEntries.ContainersCount()
+}
+
+func (ns *NICState) SetAllocationInfo(podUID string, containerName string, allocationInfo *AllocationInfo) {
+	if ns == nil {
+		return
+	}
+
+	if ns.PodEntries == nil {
+		ns.PodEntries = make(PodEntries)
+	}
+
+	if _, ok := ns.PodEntries[podUID]; !ok {
+		ns.PodEntries[podUID] = make(ContainerEntries)
+	}
+
+	ns.PodEntries[podUID][containerName] = allocationInfo.Clone()
+}
+
+func (nm NICMap) Clone() NICMap {
+	clone := make(NICMap)
+	for ifName, ns := range nm {
+		clone[ifName] = ns.Clone()
+	}
+	return clone
+}
+
+func (nm NICMap) String() string {
+	if nm == nil {
+		return ""
+	}
+
+	contentBytes, err := json.Marshal(nm)
+	if err != nil {
+		klog.Errorf("[NICMap.String] marshal NICMap failed with error: %v", err)
+		return ""
+	}
+	return string(contentBytes)
+}
+
+// reader is used to get information from local states
+type reader interface {
+	GetMachineState() NICMap
+	GetAllocationInfo(podUID, containerName string) *AllocationInfo
+	GetPodEntries() PodEntries
+}
+
+// writer is used to store information into local states,
+// and it also provides functionality to maintain the local files
+type writer interface {
+	SetMachineState(nicMap NICMap)
+	SetAllocationInfo(podUID, containerName string, allocationInfo *AllocationInfo)
+	SetPodEntries(podEntries PodEntries)
+
+	Delete(podUID, containerName string)
+	ClearState()
+}
+
+// State interface provides methods for tracking and setting pod assignments
+type State interface {
+	writer
+	ReadonlyState
+}
+
+// ReadonlyState interface only provides methods for tracking pod assignments
+type ReadonlyState interface {
+	reader
+
+	GetNICs() []machine.InterfaceInfo
+	GetReservedBandwidth() uint64
+}
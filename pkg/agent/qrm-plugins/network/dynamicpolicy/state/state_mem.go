@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+// networkPluginState is an in-memory implementation of State;
+// everytime we want to read or write states, those requests will always
+// go to in-memory State, and then go to disk State, i.e. in write-back mode
+type networkPluginState struct {
+	sync.RWMutex
+	podEntries        PodEntries
+	machineState      NICMap
+	nics              []machine.InterfaceInfo
+	reservedBandwidth uint64
+}
+
+var _ State = &networkPluginState{}
+
+func NewNetworkPluginState(nics []machine.InterfaceInfo, reservedBandwidth uint64) State {
+	klog.InfoS("[network_plugin] initializing new network plugin in-memory state store")
+
+	return &networkPluginState{
+		podEntries:        make(PodEntries),
+		machineState:      GetDefaultMachineState(nics, reservedBandwidth),
+		nics:              append([]machine.InterfaceInfo{}, nics...),
+		reservedBandwidth: reservedBandwidth,
+	}
+}
+
+func (s *networkPluginState) GetNICs() []machine.InterfaceInfo {
+	s.RLock()
+	defer s.RUnlock()
+
+	return append([]machine.InterfaceInfo{}, s.nics...)
+}
+
+func (s *networkPluginState) GetReservedBandwidth() uint64 {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.reservedBandwidth
+}
+
+func (s *networkPluginState) GetMachineState() NICMap {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.machineState.Clone()
+}
+
+func (s *networkPluginState) GetAllocationInfo(podUID, containerName string) *AllocationInfo {
+	s.RLock()
+	defer s.RUnlock()
+
+	if res, ok := s.podEntries[podUID][containerName]; ok {
+		return res.Clone()
+	}
+	return nil
+}
+
+func (s *networkPluginState) GetPodEntries() PodEntries {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.podEntries.Clone()
+}
+
+func (s *networkPluginState) SetMachineState(nicMap NICMap) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.machineState = nicMap.Clone()
+	klog.InfoS("[network_plugin] updated network plugin machine state",
+		"nicMap", nicMap.String())
+}
+
+func (s *networkPluginState) SetAllocationInfo(podUID, containerName string, allocationInfo *AllocationInfo) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.podEntries[podUID]; !ok {
+		s.podEntries[podUID] = make(ContainerEntries)
+	}
+
+	s.podEntries[podUID][containerName] = allocationInfo.Clone()
+	klog.InfoS("[network_plugin] updated network plugin pod entries",
+		"podUID", podUID,
+		"containerName", containerName,
+		"allocationInfo", allocationInfo.String())
+}
+
+func (s *networkPluginState) SetPodEntries(podEntries PodEntries) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.podEntries = podEntries.Clone()
+	klog.InfoS("[network_plugin] updated network plugin pod entries",
+		"podEntries", podEntries.String())
+}
+
+// Delete deletes corresponding container entry from pod entries
+func (s *networkPluginState) Delete(podUID, containerName string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.podEntries[podUID]; !ok {
+		return
+	}
+
+	delete(s.podEntries[podUID], containerName)
+	if len(s.podEntries[podUID]) == 0 {
+		delete(s.podEntries, podUID)
+	}
+	klog.V(2).InfoS("[network_plugin] deleted container entry", "podUID", podUID, "containerName", containerName)
+}
+
+// ClearState clears machineState and pod entries
+func (s *networkPluginState) ClearState() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.machineState = GetDefaultMachineState(s.nics, s.reservedBandwidth)
+	s.podEntries = make(PodEntries)
+
+	klog.V(2).InfoS("[network_plugin] cleared state")
+}
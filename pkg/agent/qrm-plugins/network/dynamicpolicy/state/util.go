@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+// GetDefaultMachineState returns NICMap without any container assigned, and the
+// allocatable bandwidth of each NIC is its speed minus the reserved bandwidth
+func GetDefaultMachineState(nics []machine.InterfaceInfo, reservedBandwidth uint64) NICMap {
+	defaultMachineState := make(NICMap)
+	for _, nic := range nics {
+		// speed of NIC is unknown if it's negative
+		var totalBandwidth uint64
+		if nic.Speed > 0 {
+			totalBandwidth = uint64(nic.Speed)
+		}
+
+		var allocatableBandwidth uint64
+		if totalBandwidth > reservedBandwidth {
+			allocatableBandwidth = totalBandwidth - reservedBandwidth
+		}
+
+		defaultMachineState[nic.Iface] = &NICState{
+			IfName:               nic.Iface,
+			NumaNode:             nic.NumaNode,
+			TotalBandwidth:       totalBandwidth,
+			ReservedBandwidth:    reservedBandwidth,
+			AllocatableBandwidth: allocatableBandwidth,
+			FreeBandwidth:        allocatableBandwidth,
+			PodEntries:           make(PodEntries),
+		}
+	}
+	return defaultMachineState
+}
+
+// GenerateMachineStateFromPodEntries is used to generate NICMap based on given pod entries,
+// and containers assigned to NICs which don't exist anymore are skipped
+func GenerateMachineStateFromPodEntries(nics []machine.InterfaceInfo, reservedBandwidth uint64, podEntries PodEntries) NICMap {
+	machineState := GetDefaultMachineState(nics, reservedBandwidth)
+	for podUID, containerEntries := range podEntries {
+		for containerName, allocationInfo := range containerEntries {
+			if containerName == "" || allocationInfo == nil {
+				continue
+			}
+
+			nicState, ok := machineState[allocationInfo.IfName]
+			if !ok {
+				klog.Warningf("[network_plugin] pod: %s/%s, container: %s is assigned to unknown NIC: %s",
+					allocationInfo.PodNamespace, allocationInfo.PodName, containerName, allocationInfo.IfName)
+				continue
+			}
+
+			nicState.SetAllocationInfo(podUID, containerName, allocationInfo)
+			nicState.AllocatedBandwidth += allocationInfo.Bandwidth
+		}
+	}
+
+	for _, nicState := range machineState {
+		if nicState.AllocatedBandwidth < nicState.AllocatableBandwidth {
+			nicState.FreeBandwidth = nicState.AllocatableBandwidth - nicState.AllocatedBandwidth
+		} else {
+			nicState.FreeBandwidth = 0
+		}
+	}
+	return machineState
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	metaservercnr "github.com/kubewharf/katalyst-core/pkg/metaserver/agent/cnr"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util"
 	"github.com/kubewharf/katalyst-core/pkg/util/syntax"
 )
 
@@ -89,10 +90,37 @@ func NewCNRReporter(genericClient *client.GenericClientSet, metaServer *metaserv
 	// register itself as a resource reporter in meta-server
 	metaServer.SetCNRFetcher(c)
 
-	c.mergeValueFunc = syntax.SimpleMergeTwoValues
+	c.mergeValueFunc = mergeCNRValues
 	return c, nil
 }
 
+// mergeCNRValues merges topology status by socket and numa ids, since it may be
+// reported by several plugins (e.g. numa allocations and numa reclaimed resources),
+// and other fields are merged by syntax.SimpleMergeTwoValues
+func mergeCNRValues(src reflect.Value, dst reflect.Value) error {
+	if src.Type() != dst.Type() {
+		return fmt.Errorf("type of src and dst is inconsistent")
+	}
+
+	if dst.Type() != reflect.TypeOf(&nodev1alpha1.TopologyStatus{}) {
+		return syntax.SimpleMergeTwoValues(src, dst)
+	}
+
+	// if report value is nil, we no need to update origin value
+	if src.IsNil() {
+		return nil
+	}
+
+	// initialize the origin value if it is nil
+	if dst.IsNil() {
+		dst.Set(reflect.ValueOf(&nodev1alpha1.TopologyStatus{}))
+	}
+
+	util.MergeTopologyStatus(src.Interface().(*nodev1alpha1.TopologyStatus),
+		dst.Interface().(*nodev1alpha1.TopologyStatus))
+	return nil
+}
+
 // Run start cnr reporter
 func (c *cnrReporterImpl) Run(ctx context.Context) {
 	go wait.JitterUntilWithContext(ctx, c.refreshLatestCNR, c.refreshLatestCNRPeriod, refreshLatestCNRJitterFactor, true)
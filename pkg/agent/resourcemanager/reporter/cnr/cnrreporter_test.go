@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	nodev1alpha1 "github.com/kubewharf/katalyst-api/pkg/apis/node/v1alpha1"
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-api/pkg/protocol/reporterplugin/v1alpha1"
 	katalyst_base "github.com/kubewharf/katalyst-core/cmd/base"
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
@@ -380,6 +381,88 @@ func Test_initializeCNRFields(t *testing.T) {
 	}
 }
 
+func Test_setCNRWithTopologyStatusMerged(t *testing.T) {
+	t.Parallel()
+
+	allocations := []*nodev1alpha1.Allocation{
+		{
+			Consumer: "default/pod-1/uid-1",
+			Requests: &v1.ResourceList{
+				v1.ResourceCPU: resource.MustParse("2"),
+			},
+		},
+	}
+	reclaimed := v1.ResourceList{
+		apiconsts.ReclaimedResourceMilliCPU: resource.MustParse("4k"),
+	}
+
+	cnr := &nodev1alpha1.CustomNodeResource{}
+	err := setCNR(cnr, []*v1alpha1.ReportField{
+		{
+			FieldType: v1alpha1.FieldType_Status,
+			FieldName: util.CNRFieldNameTopologyStatus,
+			Value: testMarshal(t, &nodev1alpha1.TopologyStatus{
+				Sockets: []*nodev1alpha1.SocketStatus{
+					{
+						SocketID: 0,
+						Numas: []*nodev1alpha1.NumaStatus{
+							{
+								NumaID:      0,
+								Allocations: allocations,
+							},
+						},
+					},
+				},
+			}),
+		},
+		{
+			FieldType: v1alpha1.FieldType_Status,
+			FieldName: util.CNRFieldNameTopologyStatus,
+			Value: testMarshal(t, &nodev1alpha1.TopologyStatus{
+				Sockets: []*nodev1alpha1.SocketStatus{
+					{
+						SocketID: 0,
+						Numas: []*nodev1alpha1.NumaStatus{
+							{
+								NumaID:      1,
+								Allocatable: &reclaimed,
+								Capacity:    &reclaimed,
+							},
+							{
+								NumaID:      0,
+								Allocatable: &reclaimed,
+								Capacity:    &reclaimed,
+							},
+						},
+					},
+				},
+			}),
+		},
+	}, mergeCNRValues)
+	require.NoError(t, err)
+
+	assert.True(t, apiequality.Semantic.DeepEqual(&nodev1alpha1.TopologyStatus{
+		Sockets: []*nodev1alpha1.SocketStatus{
+			{
+				SocketID: 0,
+				Numas: []*nodev1alpha1.NumaStatus{
+					{
+						NumaID:      0,
+						Allocatable: &reclaimed,
+						Capacity:    &reclaimed,
+						Allocations: allocations,
+					},
+					{
+						NumaID:      1,
+						Allocatable: &reclaimed,
+						Capacity:    &reclaimed,
+					},
+				},
+			},
+		},
+	}, cnr.Status.TopologyStatus), "got %v", cnr.Status.TopologyStatus)
+}
+
 func Test_cnrReporterImpl_Update(t *testing.T) {
 	type fields struct {
 		defaultCNR *nodev1alpha1.CustomNodeResource
@@ -61,3 +61,40 @@ func (cp *MetaCacheCheckpoint) VerifyChecksum() error {
 	cp.Checksum = ck
 	return err
 }
+
+var _ checkpointmanager.Checkpoint = &HistogramCheckpoint{}
+
+// HistogramCheckpoint is kept apart from MetaCacheCheckpoint, since histograms are
+// updated periodically and to keep compatible with existing state files
+type HistogramCheckpoint struct {
+	HistogramEntries types.HistogramCheckpointEntries `json:"histogram_entries"`
+	Checksum         checksum.Checksum                `json:"checksum"`
+}
+
+func NewHistogramCheckpoint() *HistogramCheckpoint {
+	return &HistogramCheckpoint{
+		HistogramEntries: make(types.HistogramCheckpointEntries),
+	}
+}
+
+// MarshalCheckpoint returns marshaled checkpoint
+func (cp *HistogramCheckpoint) MarshalCheckpoint() ([]byte, error) {
+	// make sure checksum wasn't set before so it doesn't affect output checksum
+	cp.Checksum = 0
+	cp.Checksum = checksum.New(cp)
+	return json.Marshal(*cp)
+}
+
+// UnmarshalCheckpoint tries to unmarshal passed bytes to checkpoint
+func (cp *HistogramCheckpoint) UnmarshalCheckpoint(blob []byte) error {
+	return json.Unmarshal(blob, cp)
+}
+
+// VerifyChecksum verifies that current checksum of checkpoint is valid
+func (cp *HistogramCheckpoint) VerifyChecksum() error {
+	ck := cp.Checksum
+	cp.Checksum = 0
+	err := ck.Verify(cp)
+	cp.Checksum = ck
+	return err
+}
@@ -19,6 +19,7 @@ package metacache
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
@@ -27,6 +28,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/util/histogram"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
 
@@ -38,19 +40,32 @@ import (
 // 3. not use omitempty in map property and must make new map to do initialization
 
 const (
-	stateFileName string = "sys_advisor_state"
+	stateFileName          string = "sys_advisor_state"
+	histogramStateFileName string = "sys_advisor_histogram_state"
 )
 
+// containerHistogramOptions defines bucketing schemes of container usage histograms,
+// which are borrowed from vpa recommender to keep relative error within 5%
+var containerHistogramOptions = map[types.ContainerHistogramName]histogram.Options{
+	// cpu usage in cores, ranging from 0.01 to 1000 cores
+	types.ContainerHistogramNameCPUUsage: mustNewExponentialOptions(1000.0, 0.01, 1.+0.05, 0.0001),
+	// memory usage in bytes, ranging from 10MB to 1TB
+	types.ContainerHistogramNameMemoryUsage: mustNewExponentialOptions(1e12, 1e7, 1.+0.05, 0.0001),
+}
+
 // MetaReader provides a standard interface to refer to metadata type
 type MetaReader interface {
 	// GetContainerEntries returns a ContainerEntry copy keyed by pod uid
 	GetContainerEntries(podUID string) (types.ContainerEntries, bool)
 	// GetContainerInfo returns a ContainerInfo copy keyed by pod uid and container name
 	GetContainerInfo(podUID string, containerName string) (*types.ContainerInfo, bool)
-	// GetContainerMetric returns the metric value of a container
+	// GetContainerMetric returns the metric value of a container, and ErrMetricDataStale
+	// is returned if the metric is older than the max age of metrics
 	GetContainerMetric(podUID string, containerName string, metricName string) (float64, error)
 	// RangeContainer applies a function to every podUID, containerName, containerInfo set
 	RangeContainer(f func(podUID string, containerName string, containerInfo *types.ContainerInfo) bool)
+	// GetContainerHistogram returns a usage histogram copy keyed by pod uid, container name and histogram name
+	GetContainerHistogram(podUID string, containerName string, histogramName types.ContainerHistogramName) (histogram.Histogram, bool)
 
 	// GetPoolInfo returns a PoolInfo copy by pool name
 	GetPoolInfo(poolName string) (*types.PoolInfo, bool)
@@ -79,6 +94,16 @@ type RawMetaWriter interface {
 	// RemovePod deletes a PodInfo keyed by pod uid. Repeatedly remove will be ignored.
 	RemovePod(podUID string) error
 
+	// AddContainerHistogramSamples adds usage samples keyed by pod uid and container name into
+	// histograms of the given name. Samples of containers not existing in cache will be ignored.
+	AddContainerHistogramSamples(histogramName types.ContainerHistogramName, samples map[string]map[string]float64, timestamp time.Time) error
+	// StoreHistogramState persists usage histograms into checkpoint, which is not done on each
+	// modification of histograms since it's expensive to serialize all of them
+	StoreHistogramState() error
+	// GCHistogramEntries deletes usage histograms of pods neither existing on node nor in cache,
+	// e.g. histograms restored from checkpoint for pods deleted while sysadvisor is down
+	GCHistogramEntries(livingPodUIDSet map[string]struct{})
+
 	// SetPoolInfo stores a PoolInfo by pool name
 	SetPoolInfo(poolName string, poolInfo *types.PoolInfo) error
 	// DeletePool deletes a PoolInfo keyed by pool name
@@ -103,15 +128,22 @@ type MetaCache interface {
 // Deep copy logic is performed during accessing metacache entries instead of directly
 // return pointer of each struct to avoid mis-overwrite.
 type MetaCacheImp struct {
-	podEntries    types.PodEntries
-	poolEntries   types.PoolEntries
-	regionEntries types.RegionEntries
-	mutex         sync.RWMutex
+	podEntries       types.PodEntries
+	poolEntries      types.PoolEntries
+	regionEntries    types.RegionEntries
+	histogramEntries types.HistogramEntries
+	mutex            sync.RWMutex
 
-	checkpointManager checkpointmanager.CheckpointManager
-	checkpointName    string
+	// histogramHalfLife is the duration for weights of histogram samples to decay by half
+	histogramHalfLife time.Duration
+
+	checkpointManager       checkpointmanager.CheckpointManager
+	checkpointName          string
+	histogramCheckpointName string
 
 	metricsFetcher metric.MetricsFetcher
+	// metricsMaxAge is the max age of container metrics returned by GetContainerMetric
+	metricsMaxAge time.Duration
 }
 
 var _ MetaCache = &MetaCacheImp{}
@@ -124,19 +156,31 @@ func NewMetaCacheImp(conf *config.Configuration, metricsFetcher metric.MetricsFe
 		return nil, fmt.Errorf("failed to initialize checkpoint manager: %v", err)
 	}
 
+	histogramHalfLife := conf.SysAdvisorPluginsConfiguration.MetaCachePluginConfiguration.ContainerHistogramHalfLife
+	if histogramHalfLife <= 0 {
+		return nil, fmt.Errorf("invalid container histogram half life: %v", histogramHalfLife)
+	}
+
 	mc := &MetaCacheImp{
-		podEntries:        make(types.PodEntries),
-		poolEntries:       make(types.PoolEntries),
-		regionEntries:     make(types.RegionEntries),
-		checkpointManager: checkpointManager,
-		checkpointName:    stateFileName,
-		metricsFetcher:    metricsFetcher,
+		podEntries:              make(types.PodEntries),
+		poolEntries:             make(types.PoolEntries),
+		regionEntries:           make(types.RegionEntries),
+		histogramEntries:        make(types.HistogramEntries),
+		histogramHalfLife:       histogramHalfLife,
+		checkpointManager:       checkpointManager,
+		checkpointName:          stateFileName,
+		histogramCheckpointName: histogramStateFileName,
+		metricsFetcher:          metricsFetcher,
+		metricsMaxAge:           conf.MetricsMaxAge,
 	}
 
 	// Restore from checkpoint before any function call to metacache api
 	if err := mc.restoreState(); err != nil {
 		return mc, err
 	}
+	if err := mc.restoreHistogramState(); err != nil {
+		return mc, err
+	}
 
 	return mc, nil
 }
@@ -180,8 +224,20 @@ func (mc *MetaCacheImp) RangeContainer(f func(podUID string, containerName strin
 	}
 }
 
+func (mc *MetaCacheImp) GetContainerHistogram(podUID string, containerName string,
+	histogramName types.ContainerHistogramName) (histogram.Histogram, bool) {
+	mc.mutex.RLock()
+	defer mc.mutex.RUnlock()
+
+	h, ok := mc.histogramEntries[podUID][containerName][histogramName]
+	if !ok {
+		return nil, false
+	}
+	return h.Clone(), true
+}
+
 func (mc *MetaCacheImp) GetContainerMetric(podUID string, containerName string, metricName string) (float64, error) {
-	return mc.metricsFetcher.GetContainerMetric(podUID, containerName, metricName)
+	return mc.metricsFetcher.GetContainerMetricWithMaxAge(podUID, containerName, metricName, mc.metricsMaxAge)
 }
 
 func (mc *MetaCacheImp) GetPoolInfo(poolName string) (*types.PoolInfo, bool) {
@@ -269,6 +325,8 @@ func (mc *MetaCacheImp) DeleteContainer(podUID string, containerName string) err
 	}
 	delete(podInfo, containerName)
 
+	delete(mc.histogramEntries[podUID], containerName)
+
 	return mc.storeState()
 }
 
@@ -301,9 +359,53 @@ func (mc *MetaCacheImp) RemovePod(podUID string) error {
 	}
 	delete(mc.podEntries, podUID)
 
+	delete(mc.histogramEntries, podUID)
+
 	return mc.storeState()
 }
 
+func (mc *MetaCacheImp) AddContainerHistogramSamples(histogramName types.ContainerHistogramName,
+	samples map[string]map[string]float64, timestamp time.Time) error {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	options, ok := containerHistogramOptions[histogramName]
+	if !ok {
+		return fmt.Errorf("unknown container histogram %v", histogramName)
+	}
+
+	for podUID, containerSamples := range samples {
+		for containerName, value := range containerSamples {
+			if _, ok := mc.podEntries[podUID][containerName]; !ok {
+				continue
+			}
+
+			if _, ok := mc.histogramEntries[podUID]; !ok {
+				mc.histogramEntries[podUID] = make(map[string]types.ContainerHistograms)
+			}
+			if _, ok := mc.histogramEntries[podUID][containerName]; !ok {
+				mc.histogramEntries[podUID][containerName] = make(types.ContainerHistograms)
+			}
+
+			h, ok := mc.histogramEntries[podUID][containerName][histogramName]
+			if !ok {
+				h = histogram.NewDecayingHistogram(options, mc.histogramHalfLife)
+				mc.histogramEntries[podUID][containerName][histogramName] = h
+			}
+			h.AddSample(value, 1, timestamp)
+		}
+	}
+
+	return nil
+}
+
+func (mc *MetaCacheImp) StoreHistogramState() error {
+	mc.mutex.RLock()
+	defer mc.mutex.RUnlock()
+
+	return mc.storeHistogramState()
+}
+
 /*
 	standard implementation for AdvisorMetaWriter
 */
@@ -339,6 +441,22 @@ func (mc *MetaCacheImp) GCPoolEntries(livingPoolNameSet map[string]struct{}) err
 	return mc.storeState()
 }
 
+func (mc *MetaCacheImp) GCHistogramEntries(livingPodUIDSet map[string]struct{}) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	for podUID := range mc.histogramEntries {
+		if _, ok := livingPodUIDSet[podUID]; ok {
+			continue
+		} else if _, ok := mc.podEntries[podUID]; ok {
+			continue
+		}
+
+		delete(mc.histogramEntries, podUID)
+		klog.Infof("[metacache] delete histograms of stale pod %v", podUID)
+	}
+}
+
 func (mc *MetaCacheImp) UpdateRegionEntries(entries types.RegionEntries) error {
 	mc.regionEntries = entries.Clone()
 	return nil
@@ -381,3 +499,77 @@ func (mc *MetaCacheImp) restoreState() error {
 
 	return nil
 }
+
+func (mc *MetaCacheImp) storeHistogramState() error {
+	checkpoint := NewHistogramCheckpoint()
+	checkpoint.HistogramEntries = mc.histogramEntries.SaveToCheckpoint()
+
+	if err := mc.checkpointManager.CreateCheckpoint(mc.histogramCheckpointName, checkpoint); err != nil {
+		klog.Errorf("[metacache] store histogram state failed: %v", err)
+		return err
+	}
+	klog.V(4).Infof("[metacache] store histogram state succeeded")
+
+	return nil
+}
+
+func (mc *MetaCacheImp) restoreHistogramState() error {
+	checkpoint := NewHistogramCheckpoint()
+
+	if err := mc.checkpointManager.GetCheckpoint(mc.histogramCheckpointName, checkpoint); err != nil {
+		if err == errors.ErrCheckpointNotFound {
+			klog.Infof("[metacache] checkpoint %v not found, create", mc.histogramCheckpointName)
+			return mc.storeHistogramState()
+		}
+		// histograms are only used to estimate resources, so a corrupted checkpoint is discarded
+		// instead of blocking sysadvisor, and histograms are built from scratch
+		klog.Errorf("[metacache] restore histogram state failed: %v, discard it", err)
+		return mc.storeHistogramState()
+	}
+
+	mc.histogramEntries = mc.loadHistogramEntries(checkpoint.HistogramEntries)
+
+	klog.Infof("[metacache] restore histogram state succeeded")
+
+	return nil
+}
+
+// loadHistogramEntries restores histograms from checkpoint, and invalid ones will be dropped
+// so that history of other containers can still be used
+func (mc *MetaCacheImp) loadHistogramEntries(checkpointEntries types.HistogramCheckpointEntries) types.HistogramEntries {
+	histogramEntries := make(types.HistogramEntries)
+	for podUID, podCheckpoints := range checkpointEntries {
+		for containerName, containerCheckpoints := range podCheckpoints {
+			for histogramName, checkpoint := range containerCheckpoints {
+				options, ok := containerHistogramOptions[histogramName]
+				if !ok {
+					klog.Warningf("[metacache] skip unknown histogram %v of %v/%v", histogramName, podUID, containerName)
+					continue
+				}
+
+				h := histogram.NewDecayingHistogram(options, mc.histogramHalfLife)
+				if err := h.LoadFromCheckpoint(checkpoint); err != nil {
+					klog.Warningf("[metacache] load histogram %v of %v/%v failed: %v", histogramName, podUID, containerName, err)
+					continue
+				}
+
+				if _, ok := histogramEntries[podUID]; !ok {
+					histogramEntries[podUID] = make(map[string]types.ContainerHistograms)
+				}
+				if _, ok := histogramEntries[podUID][containerName]; !ok {
+					histogramEntries[podUID][containerName] = make(types.ContainerHistograms)
+				}
+				histogramEntries[podUID][containerName][histogramName] = h
+			}
+		}
+	}
+	return histogramEntries
+}
+
+func mustNewExponentialOptions(maxValue, firstBucketSize, ratio, epsilon float64) histogram.Options {
+	options, err := histogram.NewExponentialOptions(maxValue, firstBucketSize, ratio, epsilon)
+	if err != nil {
+		panic(err)
+	}
+	return options
+}
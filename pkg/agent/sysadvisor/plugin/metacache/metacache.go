@@ -27,6 +27,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	metricspool "github.com/kubewharf/katalyst-core/pkg/metrics/metrics-pool"
@@ -40,13 +41,22 @@ const (
 	MetricsNamePlugMetaCacheHeartbeat = "plugin_metacache_heartbeat"
 )
 
+// containerHistogramMetrics maps container usage histograms to the metrics sampled for them
+var containerHistogramMetrics = map[types.ContainerHistogramName]string{
+	types.ContainerHistogramNameCPUUsage:    consts.MetricCPUUsageContainer,
+	types.ContainerHistogramNameMemoryUsage: consts.MetricMemRssContainer,
+}
+
 // MetaCachePlugin collects pod info from kubelet
 type MetaCachePlugin struct {
-	name   string
-	period time.Duration
+	name                      string
+	period                    time.Duration
+	histogramCheckpointPeriod time.Duration
+	metricsMaxAge             time.Duration
 
 	emitter       metrics.MetricEmitter
 	metaServer    *metaserver.MetaServer
+	metaReader    metacache.MetaReader
 	rawMetaWriter metacache.RawMetaWriter
 }
 
@@ -56,11 +66,14 @@ func NewMetaCachePlugin(conf *config.Configuration, _ interface{}, emitterPool m
 	emitter := emitterPool.GetDefaultMetricsEmitter().WithTags("advisor-metacache")
 
 	mcp := &MetaCachePlugin{
-		name:          PluginNameMetaCache,
-		period:        conf.SysAdvisorPluginsConfiguration.MetaCachePluginConfiguration.SyncPeriod,
-		emitter:       emitter,
-		metaServer:    metaServer,
-		rawMetaWriter: metaCache,
+		name:                      PluginNameMetaCache,
+		period:                    conf.SysAdvisorPluginsConfiguration.MetaCachePluginConfiguration.SyncPeriod,
+		histogramCheckpointPeriod: conf.SysAdvisorPluginsConfiguration.MetaCachePluginConfiguration.ContainerHistogramCheckpointPeriod,
+		metricsMaxAge:             conf.MetricsMaxAge,
+		emitter:                   emitter,
+		metaServer:                metaServer,
+		metaReader:                metaCache,
+		rawMetaWriter:             metaCache,
 	}
 
 	return mcp, nil
@@ -79,6 +92,9 @@ func (mcp *MetaCachePlugin) Init() error {
 // Run starts the metacache plugin
 func (mcp *MetaCachePlugin) Run(ctx context.Context) {
 	go wait.UntilWithContext(ctx, mcp.periodicWork, mcp.period)
+	if mcp.histogramCheckpointPeriod > 0 {
+		go wait.UntilWithContext(ctx, mcp.storeHistogramState, mcp.histogramCheckpointPeriod)
+	}
 }
 
 func (mcp *MetaCachePlugin) periodicWork(ctx context.Context) {
@@ -97,4 +113,61 @@ func (mcp *MetaCachePlugin) periodicWork(ctx context.Context) {
 		return true
 	}
 	mcp.rawMetaWriter.RangeAndUpdateContainer(f)
+
+	mcp.sampleContainerUsage()
+}
+
+// sampleContainerUsage records current resource usage of containers into their usage histograms,
+// and stale metrics are skipped so that histograms won't be biased by repeated samples of them
+func (mcp *MetaCachePlugin) sampleContainerUsage() {
+	now := time.Now()
+	for histogramName, metricName := range containerHistogramMetrics {
+		samples := make(map[string]map[string]float64)
+		mcp.metaReader.RangeContainer(func(podUID string, containerName string, _ *types.ContainerInfo) bool {
+			value, err := mcp.metaServer.GetContainerMetricWithMaxAge(podUID, containerName, metricName, mcp.metricsMaxAge)
+			if err != nil {
+				klog.V(4).Infof("[metacache] get metric %v failed: %v, %v/%v", metricName, err, podUID, containerName)
+				return true
+			}
+
+			if _, ok := samples[podUID]; !ok {
+				samples[podUID] = make(map[string]float64)
+			}
+			samples[podUID][containerName] = value
+			return true
+		})
+
+		if err := mcp.rawMetaWriter.AddContainerHistogramSamples(histogramName, samples, now); err != nil {
+			klog.Errorf("[metacache] add samples to histogram %v failed: %v", histogramName, err)
+		}
+	}
+}
+
+// storeHistogramState persists container usage histograms, so that usage history survives restarts,
+// and histograms of pods gone (including those restored for pods deleted during restarts) are
+// garbage-collected before that
+func (mcp *MetaCachePlugin) storeHistogramState(ctx context.Context) {
+	mcp.gcHistograms(ctx)
+
+	if err := mcp.rawMetaWriter.StoreHistogramState(); err != nil {
+		klog.Errorf("[metacache] store histogram state failed: %v", err)
+	}
+}
+
+// gcHistograms deletes histograms of pods not existing on node, and it's skipped without any pod
+// listed, since the pod list may be not ready yet and histograms are too precious to be dropped
+func (mcp *MetaCachePlugin) gcHistograms(ctx context.Context) {
+	podList, err := mcp.metaServer.GetPodList(ctx, nil)
+	if err != nil {
+		klog.Errorf("[metacache] get pod list failed: %v", err)
+		return
+	} else if len(podList) == 0 {
+		return
+	}
+
+	livingPodUIDSet := make(map[string]struct{}, len(podList))
+	for _, pod := range podList {
+		livingPodUIDSet[string(pod.UID)] = struct{}{}
+	}
+	mcp.rawMetaWriter.GCHistogramEntries(livingPodUIDSet)
 }
@@ -34,6 +34,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	metricspool "github.com/kubewharf/katalyst-core/pkg/metrics/metrics-pool"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
@@ -67,6 +68,7 @@ func generateTestMetaServer(t *testing.T, conf *config.Configuration) *metaserve
 		KatalystMachineInfo: &machine.KatalystMachineInfo{
 			CPUTopology: cpuTopology,
 		},
+		PodFetcher: &pod.PodFetcherStub{},
 	}
 
 	return metaServer
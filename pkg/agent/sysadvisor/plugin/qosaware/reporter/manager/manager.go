@@ -37,6 +37,10 @@ type HeadroomManager interface {
 	GetAllocatable() (resource.Quantity, error)
 	// GetCapacity return the capacity of this resource
 	GetCapacity() (resource.Quantity, error)
+	// GetNumaAllocatable return the allocatable resource of this resource keyed by numa id
+	GetNumaAllocatable() (map[int]resource.Quantity, error)
+	// GetNumaCapacity return the capacity of this resource keyed by numa id
+	GetNumaCapacity() (map[int]resource.Quantity, error)
 	// Run this resource manager
 	Run(ctx context.Context)
 }
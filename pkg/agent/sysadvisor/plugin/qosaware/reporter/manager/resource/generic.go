@@ -19,6 +19,7 @@ package resource
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -33,7 +34,8 @@ import (
 )
 
 const (
-	metricsNameHeadroomReportResult = "headroom_report_result"
+	metricsNameHeadroomReportResult     = "headroom_report_result"
+	metricsNameHeadroomReportNumaResult = "headroom_report_numa_result"
 )
 
 type GetGenericReclaimOptionsFunc func() GenericReclaimOptions
@@ -58,13 +60,19 @@ type GenericSlidingWindowOptions struct {
 
 type GenericHeadroomManager struct {
 	sync.RWMutex
-	lastReportResult *resource.Quantity
+	lastReportResult     *resource.Quantity
+	lastNumaReportResult map[int]resource.Quantity
 
 	headroomAdvisor     hmadvisor.ResourceAdvisor
 	emitter             metrics.MetricEmitter
 	originSlidingWindow general.SmoothWindow
 	reportSlidingWindow general.SmoothWindow
 
+	// numaReportSlidingWindows smooth report result of each numa node independently,
+	// and they are created by newSlidingWindow on demand
+	numaReportSlidingWindows map[int]general.SmoothWindow
+	newSlidingWindow         func() general.SmoothWindow
+
 	reportResultTransformer func(quantity resource.Quantity) resource.Quantity
 	resourceName            v1.ResourceName
 	syncPeriod              time.Duration
@@ -81,6 +89,14 @@ func NewGenericHeadroomManager(name v1.ResourceName, useMilliValue, reportMilliV
 	slidingWindowSize := int(slidingWindowOptions.SlidingWindowTime / syncPeriod)
 	slidingWindowTTL := slidingWindowOptions.SlidingWindowTime * 2
 
+	newSlidingWindow := func() general.SmoothWindow {
+		return general.NewCappedSmoothWindow(
+			slidingWindowOptions.MinStep,
+			slidingWindowOptions.MaxStep,
+			general.NewAverageWithTTLSmoothWindow(slidingWindowSize, slidingWindowTTL, useMilliValue),
+		)
+	}
+
 	reportResultTransformer := func(quantity resource.Quantity) resource.Quantity {
 		if reportMilliValue {
 			return *resource.NewQuantity(quantity.MilliValue(), quantity.Format)
@@ -89,22 +105,16 @@ func NewGenericHeadroomManager(name v1.ResourceName, useMilliValue, reportMilliV
 	}
 
 	return &GenericHeadroomManager{
-		resourceName:            name,
-		reportResultTransformer: reportResultTransformer,
-		syncPeriod:              syncPeriod,
-		headroomAdvisor:         headroomAdvisor,
-		originSlidingWindow: general.NewCappedSmoothWindow(
-			slidingWindowOptions.MinStep,
-			slidingWindowOptions.MaxStep,
-			general.NewAverageWithTTLSmoothWindow(slidingWindowSize, slidingWindowTTL, useMilliValue),
-		),
-		reportSlidingWindow: general.NewCappedSmoothWindow(
-			slidingWindowOptions.MinStep,
-			slidingWindowOptions.MaxStep,
-			general.NewAverageWithTTLSmoothWindow(slidingWindowSize, slidingWindowTTL, useMilliValue),
-		),
-		emitter:           emitter,
-		getReclaimOptions: getReclaimOptions,
+		resourceName:             name,
+		reportResultTransformer:  reportResultTransformer,
+		syncPeriod:               syncPeriod,
+		headroomAdvisor:          headroomAdvisor,
+		originSlidingWindow:      newSlidingWindow(),
+		reportSlidingWindow:      newSlidingWindow(),
+		numaReportSlidingWindows: make(map[int]general.SmoothWindow),
+		newSlidingWindow:         newSlidingWindow,
+		emitter:                  emitter,
+		getReclaimOptions:        getReclaimOptions,
 	}
 }
 
@@ -120,6 +130,18 @@ func (m *GenericHeadroomManager) GetCapacity() (resource.Quantity, error) {
 	return m.getLastReportResult()
 }
 
+func (m *GenericHeadroomManager) GetNumaAllocatable() (map[int]resource.Quantity, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.getLastNumaReportResult()
+}
+
+func (m *GenericHeadroomManager) GetNumaCapacity() (map[int]resource.Quantity, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.getLastNumaReportResult()
+}
+
 func (m *GenericHeadroomManager) Run(ctx context.Context) {
 	go wait.UntilWithContext(ctx, m.sync, m.syncPeriod)
 	<-ctx.Done()
@@ -140,6 +162,27 @@ func (m *GenericHeadroomManager) setLastReportResult(q resource.Quantity) {
 	m.emitResourceToMetric(metricsNameHeadroomReportResult, m.reportResultTransformer(*m.lastReportResult))
 }
 
+func (m *GenericHeadroomManager) getLastNumaReportResult() (map[int]resource.Quantity, error) {
+	if m.lastNumaReportResult == nil {
+		return nil, fmt.Errorf("resource %s last numa report value not found", m.resourceName)
+	}
+
+	res := make(map[int]resource.Quantity, len(m.lastNumaReportResult))
+	for numaID, quantity := range m.lastNumaReportResult {
+		res[numaID] = m.reportResultTransformer(quantity)
+	}
+	return res, nil
+}
+
+func (m *GenericHeadroomManager) setLastNumaReportResult(res map[int]resource.Quantity) {
+	m.lastNumaReportResult = make(map[int]resource.Quantity, len(res))
+	for numaID, quantity := range res {
+		m.lastNumaReportResult[numaID] = quantity.DeepCopy()
+		m.emitResourceToMetric(metricsNameHeadroomReportNumaResult, m.reportResultTransformer(quantity),
+			metrics.MetricTag{Key: "numa", Val: strconv.Itoa(numaID)})
+	}
+}
+
 func (m *GenericHeadroomManager) sync(_ context.Context) {
 	m.Lock()
 	defer m.Unlock()
@@ -147,9 +190,17 @@ func (m *GenericHeadroomManager) sync(_ context.Context) {
 	reclaimOptions := m.getReclaimOptions()
 	if !reclaimOptions.EnableReclaim {
 		m.setLastReportResult(resource.Quantity{})
+
+		numaResult := make(map[int]resource.Quantity, len(m.lastNumaReportResult))
+		for numaID := range m.lastNumaReportResult {
+			numaResult[numaID] = resource.Quantity{}
+		}
+		m.setLastNumaReportResult(numaResult)
 		return
 	}
 
+	m.syncNuma(reclaimOptions)
+
 	originResultFromAdvisor, err := m.headroomAdvisor.GetHeadroom(m.resourceName)
 	if err != nil {
 		klog.Errorf("get origin result %s from headroomAdvisor failed: %v", m.resourceName, err)
@@ -174,7 +225,59 @@ func (m *GenericHeadroomManager) sync(_ context.Context) {
 	m.setLastReportResult(*reportResult)
 }
 
-func (m *GenericHeadroomManager) emitResourceToMetric(metricsName string, value resource.Quantity) {
-	_ = m.emitter.StoreInt64(metricsName, value.Value(), metrics.MetricTypeNameRaw,
-		metrics.MetricTag{Key: "resourceName", Val: string(m.resourceName)})
+// syncNuma updates report result of each numa node by its own sliding window, and
+// reserved and min reclaimed resource for report are split evenly across numa nodes
+func (m *GenericHeadroomManager) syncNuma(reclaimOptions GenericReclaimOptions) {
+	originNumaResultFromAdvisor, err := m.headroomAdvisor.GetNumaHeadroom(m.resourceName)
+	if err != nil {
+		klog.Errorf("get origin numa result %s from headroomAdvisor failed: %v", m.resourceName, err)
+		return
+	} else if len(originNumaResultFromAdvisor) == 0 {
+		return
+	}
+
+	// feed all windows before checking results, so that samples of each numa keep in step
+	numaReportResult := make(map[int]*resource.Quantity, len(originNumaResultFromAdvisor))
+	for numaID, originResult := range originNumaResultFromAdvisor {
+		window, ok := m.numaReportSlidingWindows[numaID]
+		if !ok {
+			window = m.newSlidingWindow()
+			m.numaReportSlidingWindows[numaID] = window
+		}
+		numaReportResult[numaID] = window.GetWindowedResources(originResult)
+	}
+
+	numaCount := int64(len(originNumaResultFromAdvisor))
+	reservedPerNuma := resource.NewMilliQuantity(reclaimOptions.ReservedResourceForReport.MilliValue()/numaCount,
+		reclaimOptions.ReservedResourceForReport.Format)
+	minReclaimedPerNuma := resource.NewMilliQuantity(reclaimOptions.MinReclaimedResourceForReport.MilliValue()/numaCount,
+		reclaimOptions.MinReclaimedResourceForReport.Format)
+
+	res := make(map[int]resource.Quantity, len(numaReportResult))
+	for numaID, reportResult := range numaReportResult {
+		if reportResult == nil {
+			// keep the last result of the numa, so that other numas can still be updated
+			klog.Infof("skip update reclaimed resource %s of numa %d without enough valid sample", m.resourceName, numaID)
+			if lastResult, ok := m.lastNumaReportResult[numaID]; ok {
+				res[numaID] = lastResult
+			}
+			continue
+		}
+
+		reportResult.Sub(*reservedPerNuma)
+		if reportResult.Cmp(*minReclaimedPerNuma) < 0 {
+			reportResult = minReclaimedPerNuma
+		}
+		res[numaID] = *reportResult
+	}
+
+	klog.Infof("headroom manager for %s with originNumaResultFromAdvisor: %v, numaReportResult: %v",
+		m.resourceName, originNumaResultFromAdvisor, res)
+
+	m.setLastNumaReportResult(res)
+}
+
+func (m *GenericHeadroomManager) emitResourceToMetric(metricsName string, value resource.Quantity, tags ...metrics.MetricTag) {
+	tags = append(tags, metrics.MetricTag{Key: "resourceName", Val: string(m.resourceName)})
+	_ = m.emitter.StoreInt64(metricsName, value.Value(), metrics.MetricTypeNameRaw, tags...)
 }
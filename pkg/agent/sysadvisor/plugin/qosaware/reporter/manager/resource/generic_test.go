@@ -132,3 +132,56 @@ func TestGenericHeadroomManager_Allocatable(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int64(100000), capacity.MilliValue())
 }
+
+func TestGenericHeadroomManager_NumaAllocatable(t *testing.T) {
+	r := hmadvisor.NewResourceAdvisorStub()
+	reclaimOptions := GenericReclaimOptions{
+		EnableReclaim:                 true,
+		ReservedResourceForReport:     resource.MustParse("10"),
+		MinReclaimedResourceForReport: resource.MustParse("4"),
+	}
+	m := NewGenericHeadroomManager(v1.ResourceCPU, true, false,
+		30*time.Millisecond, r, metrics.DummyMetrics{},
+		GenericSlidingWindowOptions{
+			SlidingWindowTime: 180 * time.Millisecond,
+			MinStep:           resource.MustParse("0.3"),
+			MaxStep:           resource.MustParse("4"),
+		},
+		func() GenericReclaimOptions {
+			return reclaimOptions
+		},
+	)
+
+	// first get numa allocatable with notFound error return
+	_, err := m.GetNumaAllocatable()
+	require.Error(t, err)
+
+	r.SetHeadroom(v1.ResourceCPU, resource.MustParse("40"))
+	r.SetNumaHeadroom(v1.ResourceCPU, map[int]resource.Quantity{
+		0: resource.MustParse("10"),
+		1: resource.MustParse("30"),
+	})
+
+	// fill the sliding windows with enough samples
+	for i := 0; i < 7; i++ {
+		m.sync(context.Background())
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	// reserved and min reclaimed resource are split evenly across numa nodes
+	allocatable, err := m.GetNumaAllocatable()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(allocatable))
+	numa0, numa1 := allocatable[0], allocatable[1]
+	require.Equal(t, int64(5000), numa0.MilliValue())
+	require.Equal(t, int64(25000), numa1.MilliValue())
+
+	// update reclaim options to disable reclaim, return zero for each numa next getting allocatable
+	reclaimOptions.EnableReclaim = false
+	m.sync(context.Background())
+	capacity, err := m.GetNumaCapacity()
+	require.NoError(t, err)
+	numa0, numa1 = capacity[0], capacity[1]
+	require.Equal(t, int64(0), numa0.MilliValue())
+	require.Equal(t, int64(0), numa1.MilliValue())
+}
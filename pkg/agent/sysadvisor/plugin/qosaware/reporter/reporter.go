@@ -20,12 +20,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 
+	nodev1alpha1 "github.com/kubewharf/katalyst-api/pkg/apis/node/v1alpha1"
 	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-api/pkg/plugins/registration"
 	"github.com/kubewharf/katalyst-api/pkg/plugins/skeleton"
@@ -83,11 +85,18 @@ func (r *headroomReporterImpl) Run(ctx context.Context) {
 type reclaimedResource struct {
 	allocatable v1.ResourceList
 	capacity    v1.ResourceList
+
+	// numaAllocatable and numaCapacity are reclaimed resources keyed by numa id,
+	// and they may be empty if numa headroom is not available for now
+	numaAllocatable map[int]v1.ResourceList
+	numaCapacity    map[int]v1.ResourceList
 }
 
 type headroomReporterPlugin struct {
 	sync.Mutex
 	headroomManagers map[v1.ResourceName]manager.HeadroomManager
+	// numaSocketMap maps numa id to its socket id, which is used to report numa headroom into topology status
+	numaSocketMap map[int]int
 
 	ctx     context.Context
 	cancel  context.CancelFunc
@@ -115,6 +124,7 @@ func newHeadroomReporterPlugin(emitter metrics.MetricEmitter, metaServer *metase
 
 	reporter := &headroomReporterPlugin{
 		headroomManagers: headroomManagers,
+		numaSocketMap:    getNumaSocketMap(metaServer),
 	}
 	return skeleton.NewRegistrationPluginWrapper(reporter, []string{conf.PluginRegistrationDir},
 		func(key string, value int64) {
@@ -172,7 +182,7 @@ func (r *headroomReporterPlugin) GetReportContent(_ context.Context, _ *v1alpha1
 		return nil, err
 	}
 
-	reportToCNR, err := getReportReclaimedResourceForCNR(res)
+	reportToCNR, err := getReportReclaimedResourceForCNR(res, r.numaSocketMap)
 	if err != nil {
 		return nil, err
 	}
@@ -219,13 +229,63 @@ func (r *headroomReporterPlugin) getReclaimedResource() (*reclaimedResource, err
 		return nil, errors.NewAggregate(errList)
 	}
 
+	numaAllocatable, numaCapacity, err := r.getNumaReclaimedResource()
+	if err != nil {
+		// numa reclaimed resource is reported in best effort, so that
+		// it won't block reporting reclaimed resource of the whole node
+		klog.Warningf("[headroom-reporter] get numa reclaimed resource failed: %v", err)
+		numaAllocatable, numaCapacity = nil, nil
+	}
+
 	return &reclaimedResource{
-		allocatable: allocatable,
-		capacity:    capacity,
-	}, err
+		allocatable:     allocatable,
+		capacity:        capacity,
+		numaAllocatable: numaAllocatable,
+		numaCapacity:    numaCapacity,
+	}, nil
 }
 
-func getReportReclaimedResourceForCNR(reclaimedResource *reclaimedResource) (*v1alpha1.ReportContent, error) {
+func (r *headroomReporterPlugin) getNumaReclaimedResource() (map[int]v1.ResourceList, map[int]v1.ResourceList, error) {
+	var errList []error
+
+	numaAllocatable := make(map[int]v1.ResourceList)
+	numaCapacity := make(map[int]v1.ResourceList)
+	for resourceName, rm := range r.headroomManagers {
+		allocatable, err := rm.GetNumaAllocatable()
+		if err != nil {
+			errList = append(errList, fmt.Errorf("get reclaimed %s numa allocatable failed: %s", resourceName, err))
+			continue
+		}
+
+		capacity, err := rm.GetNumaCapacity()
+		if err != nil {
+			errList = append(errList, fmt.Errorf("get reclaimed %s numa capacity failed: %s", resourceName, err))
+			continue
+		}
+
+		for numaID, quantity := range allocatable {
+			if _, ok := numaAllocatable[numaID]; !ok {
+				numaAllocatable[numaID] = make(v1.ResourceList)
+			}
+			numaAllocatable[numaID][resourceName] = quantity
+		}
+
+		for numaID, quantity := range capacity {
+			if _, ok := numaCapacity[numaID]; !ok {
+				numaCapacity[numaID] = make(v1.ResourceList)
+			}
+			numaCapacity[numaID][resourceName] = quantity
+		}
+	}
+
+	if len(errList) > 0 {
+		return nil, nil, errors.NewAggregate(errList)
+	}
+
+	return numaAllocatable, numaCapacity, nil
+}
+
+func getReportReclaimedResourceForCNR(reclaimedResource *reclaimedResource, numaSocketMap map[int]int) (*v1alpha1.ReportContent, error) {
 	if reclaimedResource == nil {
 		return nil, nil
 	}
@@ -240,19 +300,99 @@ func getReportReclaimedResourceForCNR(reclaimedResource *reclaimedResource) (*v1
 		return nil, err
 	}
 
+	fields := []*v1alpha1.ReportField{
+		{
+			FieldType: v1alpha1.FieldType_Status,
+			FieldName: util.CNRFieldNameResourceAllocatable,
+			Value:     allocatable,
+		},
+		{
+			FieldType: v1alpha1.FieldType_Status,
+			FieldName: util.CNRFieldNameResourceCapacity,
+			Value:     capacity,
+		},
+	}
+
+	topologyStatus := getNumaReclaimedTopologyStatus(reclaimedResource, numaSocketMap)
+	if topologyStatus != nil {
+		value, err := json.Marshal(topologyStatus)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, &v1alpha1.ReportField{
+			FieldType: v1alpha1.FieldType_Status,
+			FieldName: util.CNRFieldNameTopologyStatus,
+			Value:     value,
+		})
+	}
+
 	return &v1alpha1.ReportContent{
 		GroupVersionKind: &util.CNRGroupVersionKind,
-		Field: []*v1alpha1.ReportField{
-			{
-				FieldType: v1alpha1.FieldType_Status,
-				FieldName: util.CNRFieldNameResourceAllocatable,
-				Value:     allocatable,
-			},
-			{
-				FieldType: v1alpha1.FieldType_Status,
-				FieldName: util.CNRFieldNameResourceCapacity,
-				Value:     capacity,
-			},
-		},
+		Field:            fields,
 	}, nil
 }
+
+// getNumaReclaimedTopologyStatus constructs topology status with reclaimed resource of each numa,
+// and it will be merged with topology status reported by other plugins by socket and numa ids
+func getNumaReclaimedTopologyStatus(reclaimedResource *reclaimedResource, numaSocketMap map[int]int) *nodev1alpha1.TopologyStatus {
+	if len(reclaimedResource.numaAllocatable) == 0 || len(numaSocketMap) == 0 {
+		return nil
+	}
+
+	socketMap := make(map[int]*nodev1alpha1.SocketStatus)
+	for numaID, allocatable := range reclaimedResource.numaAllocatable {
+		socketID, ok := numaSocketMap[numaID]
+		if !ok {
+			klog.Warningf("[headroom-reporter] socket of numa %d not found", numaID)
+			continue
+		}
+
+		socket, ok := socketMap[socketID]
+		if !ok {
+			socket = &nodev1alpha1.SocketStatus{SocketID: socketID}
+			socketMap[socketID] = socket
+		}
+
+		numaAllocatable := allocatable.DeepCopy()
+		numaStatus := &nodev1alpha1.NumaStatus{
+			NumaID:      numaID,
+			Allocatable: &numaAllocatable,
+		}
+		if capacity, ok := reclaimedResource.numaCapacity[numaID]; ok {
+			numaCapacity := capacity.DeepCopy()
+			numaStatus.Capacity = &numaCapacity
+		}
+		socket.Numas = append(socket.Numas, numaStatus)
+	}
+
+	topologyStatus := &nodev1alpha1.TopologyStatus{}
+	for _, socket := range socketMap {
+		sort.Slice(socket.Numas, func(i, j int) bool {
+			return socket.Numas[i].NumaID < socket.Numas[j].NumaID
+		})
+		topologyStatus.Sockets = append(topologyStatus.Sockets, socket)
+	}
+	sort.Slice(topologyStatus.Sockets, func(i, j int) bool {
+		return topologyStatus.Sockets[i].SocketID < topologyStatus.Sockets[j].SocketID
+	})
+
+	return topologyStatus
+}
+
+// getNumaSocketMap returns a map from numa id to socket id, and it returns
+// nil if machine info is not available
+func getNumaSocketMap(metaServer *metaserver.MetaServer) map[int]int {
+	if metaServer == nil || metaServer.MetaAgent == nil || metaServer.KatalystMachineInfo == nil ||
+		metaServer.CPUTopology == nil {
+		return nil
+	}
+
+	numaSocketMap := make(map[int]int)
+	for _, socketID := range metaServer.CPUDetails.Sockets().ToSliceInt() {
+		for _, numaID := range metaServer.CPUDetails.NUMANodesInSockets(socketID).ToSliceInt() {
+			numaSocketMap[numaID] = socketID
+		}
+	}
+	return numaSocketMap
+}
@@ -18,6 +18,7 @@ package reporter
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -35,7 +36,9 @@ import (
 	"k8s.io/kubernetes/pkg/kubelet/pluginmanager"
 	plugincache "k8s.io/kubernetes/pkg/kubelet/pluginmanager/cache"
 
+	nodev1alpha1 "github.com/kubewharf/katalyst-api/pkg/apis/node/v1alpha1"
 	internalfake "github.com/kubewharf/katalyst-api/pkg/client/clientset/versioned/fake"
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-api/pkg/plugins/registration"
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
 	"github.com/kubewharf/katalyst-core/pkg/agent/resourcemanager/fetcher"
@@ -49,6 +52,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/node"
 	dynamicconfig "github.com/kubewharf/katalyst-core/pkg/metaserver/config"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util"
 )
 
 func tmpSocketDir() (socketDir string, err error) {
@@ -156,3 +160,45 @@ func TestReclaimedResourcedReporterWithManager(t *testing.T) {
 
 	time.Sleep(1 * time.Second)
 }
+
+func TestGetReportReclaimedResourceForCNR(t *testing.T) {
+	t.Parallel()
+
+	res := &reclaimedResource{
+		allocatable: v1.ResourceList{
+			apiconsts.ReclaimedResourceMilliCPU: resource.MustParse("10k"),
+		},
+		capacity: v1.ResourceList{
+			apiconsts.ReclaimedResourceMilliCPU: resource.MustParse("10k"),
+		},
+		numaAllocatable: map[int]v1.ResourceList{
+			1: {apiconsts.ReclaimedResourceMilliCPU: resource.MustParse("6k")},
+			0: {apiconsts.ReclaimedResourceMilliCPU: resource.MustParse("4k")},
+		},
+		numaCapacity: map[int]v1.ResourceList{
+			1: {apiconsts.ReclaimedResourceMilliCPU: resource.MustParse("6k")},
+			0: {apiconsts.ReclaimedResourceMilliCPU: resource.MustParse("4k")},
+		},
+	}
+
+	// numa headroom isn't reported without numa socket map
+	content, err := getReportReclaimedResourceForCNR(res, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(content.Field))
+
+	content, err = getReportReclaimedResourceForCNR(res, map[int]int{0: 0, 1: 1})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(content.Field))
+	require.Equal(t, util.CNRFieldNameTopologyStatus, content.Field[2].FieldName)
+
+	topologyStatus := &nodev1alpha1.TopologyStatus{}
+	require.NoError(t, json.Unmarshal(content.Field[2].Value, topologyStatus))
+	require.Equal(t, 2, len(topologyStatus.Sockets))
+	for i, socket := range topologyStatus.Sockets {
+		require.Equal(t, i, socket.SocketID)
+		require.Equal(t, 1, len(socket.Numas))
+		require.Equal(t, i, socket.Numas[0].NumaID)
+		require.True(t, (*socket.Numas[0].Allocatable)[apiconsts.ReclaimedResourceMilliCPU].
+			Equal((*socket.Numas[0].Capacity)[apiconsts.ReclaimedResourceMilliCPU]))
+	}
+}
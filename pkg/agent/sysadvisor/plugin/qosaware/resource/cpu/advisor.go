@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/klog/v2"
+	"k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
 
 	"github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/cpu/dynamicpolicy/cpuadvisor"
@@ -40,6 +42,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
@@ -47,7 +50,11 @@ import (
 
 func init() {
 	provisionpolicy.RegisterInitializer(types.CPUProvisionPolicyCanonical, provisionpolicy.NewPolicyCanonical)
+	provisionpolicy.RegisterInitializer(types.CPUProvisionPolicyRama, provisionpolicy.NewPolicyRama)
+	provisionpolicy.RegisterInitializer(types.CPUProvisionPolicyDedicated, provisionpolicy.NewPolicyDedicated)
 	headroompolicy.RegisterInitializer(types.CPUHeadroomPolicyCanonical, headroompolicy.NewPolicyCanonical)
+	headroompolicy.RegisterInitializer(types.CPUHeadroomPolicyPercentile, headroompolicy.NewPolicyPercentile)
+	headroompolicy.RegisterInitializer(types.CPUHeadroomPolicyDedicated, headroompolicy.NewPolicyDedicated)
 }
 
 // todo:
@@ -64,6 +71,9 @@ const (
 // InternalCalculationResult conveys minimal calculation result to cpu server
 type InternalCalculationResult struct {
 	PoolEntries map[string]map[int]resource.Quantity // map[poolName][numaId]cores
+	// ReclaimLentEntries stores exclusive cpus of dedicated cores pods without numa binding
+	// lent to reclaim pool, which are shared by reclaim pool and the pods
+	ReclaimLentEntries map[string]map[int]resource.Quantity // map[podUID][numaId]cores
 }
 
 // cpuResourceAdvisor is the entrance of updating cpu resource provision advice for
@@ -158,16 +168,91 @@ func (cra *cpuResourceAdvisor) GetHeadroom() (resource.Quantity, error) {
 		totalHeadroom.Add(headroom)
 	}
 
-	// Add headroom of numas without numa binding pods if there is no share region
+	// Add headroom of numas without numa binding pods if there is no share region,
+	// excluding exclusive cpus of dedicated regions whose headroom has been added above
 	if !hasShareRegion {
 		reservePoolSizeOfNonBindingNumas := int64(math.Ceil(float64(reservePoolSize*cra.nonBindingNumas.Size()) / float64(cra.metaServer.NumNUMANodes)))
-		headroomOfNonBindingNumas := resource.NewQuantity(int64(cra.nonBindingNumas.Size()*cra.metaServer.CPUsPerNuma())-reservePoolSizeOfNonBindingNumas, resource.DecimalSI)
+		dedicatedCPUsOfNonBindingNumas := int64(cra.countDedicatedRegionCPUs(cra.nonBindingNumas))
+		headroomOfNonBindingNumas := resource.NewQuantity(int64(cra.nonBindingNumas.Size()*cra.metaServer.CPUsPerNuma())-
+			reservePoolSizeOfNonBindingNumas-dedicatedCPUsOfNonBindingNumas, resource.DecimalSI)
 		totalHeadroom.Add(*headroomOfNonBindingNumas)
 	}
 
 	return *totalHeadroom, nil
 }
 
+// GetNumaHeadroom returns headroom of each numa node. Headroom of a region is split across its
+// binding numas by cpus of the reclaim pool on them, and numas without share or numa binding regions
+// contribute all cpus except for the reserve pool and exclusive cpus of dedicated regions on them.
+func (cra *cpuResourceAdvisor) GetNumaHeadroom() (map[int]resource.Quantity, error) {
+	cra.mutex.RLock()
+	defer cra.mutex.RUnlock()
+
+	reservePoolInfo, ok := cra.metaCache.GetPoolInfo(state.PoolNameReserve)
+	if !ok || reservePoolInfo == nil {
+		return nil, fmt.Errorf("reserve pool not exist")
+	}
+
+	var reclaimAssignments types.TopologyAwareAssignment
+	if reclaimPoolInfo, ok := cra.metaCache.GetPoolInfo(state.PoolNameReclaim); ok && reclaimPoolInfo != nil {
+		reclaimAssignments = reclaimPoolInfo.TopologyAwareAssignments
+	}
+
+	numaHeadroomMilli := make(map[int]int64)
+	coveredNumas := machine.NewCPUSet()
+	for _, r := range cra.regionMap {
+		bindingNumas := r.GetBindingNumas()
+		if bindingNumas.Size() <= 0 {
+			continue
+		}
+		if r.Type() != types.QoSRegionTypeDedicated {
+			coveredNumas = coveredNumas.Union(bindingNumas)
+		}
+
+		headroom, err := r.GetHeadroom()
+		if err != nil {
+			return nil, err
+		}
+
+		for numaID, milli := range splitHeadroomByAssignments(headroom.MilliValue(), bindingNumas, reclaimAssignments) {
+			numaHeadroomMilli[numaID] += milli
+		}
+	}
+
+	numaHeadroom := make(map[int]resource.Quantity)
+	for _, numaID := range cra.systemNumas.ToSliceInt() {
+		milli := numaHeadroomMilli[numaID]
+		if !coveredNumas.Contains(numaID) {
+			reservePoolSize := reservePoolInfo.TopologyAwareAssignments[numaID].Size()
+			dedicatedCPUs := cra.countDedicatedRegionCPUs(machine.NewCPUSet(numaID))
+			milli += int64(cra.metaServer.CPUsPerNuma()-reservePoolSize-dedicatedCPUs) * 1000
+		}
+		numaHeadroom[numaID] = *resource.NewMilliQuantity(general.MaxInt64(milli, 0), resource.DecimalSI)
+	}
+
+	return numaHeadroom, nil
+}
+
+// splitHeadroomByAssignments splits headroom across numas in proportion to cpus assigned on each of them,
+// since regions don't estimate headroom in numa granularity; it's split evenly if no cpu is assigned.
+func splitHeadroomByAssignments(milli int64, numas machine.CPUSet, assignments types.TopologyAwareAssignment) map[int]int64 {
+	numaIDs := numas.ToSliceInt()
+	totalCPUs := 0
+	for _, numaID := range numaIDs {
+		totalCPUs += assignments[numaID].Size()
+	}
+
+	res := make(map[int]int64, len(numaIDs))
+	for _, numaID := range numaIDs {
+		if totalCPUs > 0 {
+			res[numaID] = milli * int64(assignments[numaID].Size()) / int64(totalCPUs)
+		} else {
+			res[numaID] = milli / int64(len(numaIDs))
+		}
+	}
+	return res
+}
+
 // update works in a monolith way to maintain lifecycle and trigger update actions for all regions;
 // todo: re-consider whether it's efficient or we should make start individual goroutine for each region
 func (cra *cpuResourceAdvisor) update() {
@@ -182,6 +267,13 @@ func (cra *cpuResourceAdvisor) update() {
 		return
 	}
 
+	// pods served from apiserver may lag behind the node, so the last provision and headroom
+	// are kept instead of resizing pools based on them
+	if stale, err := pod.HasStalePods(context.Background(), cra.metaServer); err != nil || stale {
+		klog.Warningf("[qosaware-cpu] skip update: pods are stale or unavailable, err: %v", err)
+		return
+	}
+
 	// assign containers to regions
 	if err := cra.assignContainersToRegions(); err != nil {
 		klog.Errorf("[qosaware-cpu] assign containers to regions failed: %v", err)
@@ -196,6 +288,10 @@ func (cra *cpuResourceAdvisor) update() {
 		// calculate region max available cpu limit,
 		// which equals the number of cpus in region numas
 		regionCPULimit := regionNumas.Size() * cra.metaServer.CPUsPerNuma()
+		if r.Type() == types.QoSRegionTypeShare {
+			// exclusive cpus of dedicated regions are not available for share regions
+			regionCPULimit -= cra.countDedicatedRegionCPUs(regionNumas)
+		}
 
 		// calculate region reserve pool size value, which equals the cpuset intersection
 		// size between region numas and node reserve pool
@@ -341,6 +437,21 @@ func (cra *cpuResourceAdvisor) assignToRegions(ci *types.ContainerInfo) ([]regio
 		}
 
 		return regions, nil
+
+	} else if ci.QoSLevel == consts.PodAnnotationQoSLevelDedicatedCores && ci.ContainerType == v1alpha1.ContainerType_MAIN {
+		// Assign dedicated cores containers without numa binding. Focus on container.
+		regions, err := cra.getContainerRegions(ci)
+		if err != nil {
+			return nil, err
+		}
+		if len(regions) > 0 {
+			return regions, nil
+		}
+
+		name := string(types.QoSRegionTypeDedicated) + regionNameSeparator + string(uuid.NewUUID())
+		r := region.NewQoSRegionDedicated(name, ci.OwnerPoolName, cra.conf, cra.extraConf, cra.metaCache, cra.metaServer, cra.emitter)
+
+		return []region.QoSRegion{r}, nil
 	}
 
 	return nil, nil
@@ -449,6 +560,22 @@ func (cra *cpuResourceAdvisor) assembleProvision() (InternalCalculationResult, e
 				provision.PoolEntries[state.PoolNameReclaim] = make(map[int]resource.Quantity)
 			}
 			provision.PoolEntries[state.PoolNameReclaim][regionNuma] = *resource.NewQuantity(int64(reclaimPoolSize), resource.DecimalSI)
+
+		} else if r.Type() == types.QoSRegionTypeDedicated {
+			// exclusive cpus of dedicated regions are excluded from reclaim pool of non-binding numas,
+			// and the part lent to reclaimed pods joins reclaim pool by overlapping with the pods
+			nonNumaBindingRequirement += cra.countRegionCPUs(r, r.GetBindingNumas())
+
+			reclaimedCPUSupplied := int(controlKnob[types.ControlKnobReclaimedCPUSupplied].Value)
+			for podUID, numaLent := range cra.splitRegionLentCPUs(r, reclaimedCPUSupplied) {
+				if provision.ReclaimLentEntries == nil {
+					provision.ReclaimLentEntries = make(map[string]map[int]resource.Quantity)
+				}
+				provision.ReclaimLentEntries[podUID] = make(map[int]resource.Quantity)
+				for numaID, lent := range numaLent {
+					provision.ReclaimLentEntries[podUID][numaID] = *resource.NewQuantity(int64(lent), resource.DecimalSI)
+				}
+			}
 		}
 	}
 
@@ -462,6 +589,91 @@ func (cra *cpuResourceAdvisor) assembleProvision() (InternalCalculationResult, e
 	return provision, nil
 }
 
+// countDedicatedRegionCPUs returns the number of exclusive cpus of all dedicated regions in the given numas
+func (cra *cpuResourceAdvisor) countDedicatedRegionCPUs(numas machine.CPUSet) int {
+	cpuSize := 0
+	for _, r := range cra.regionMap {
+		if r.Type() == types.QoSRegionTypeDedicated {
+			cpuSize += cra.countRegionCPUs(r, numas)
+		}
+	}
+	return cpuSize
+}
+
+// countRegionCPUs returns the number of cpus assigned to containers of the given region in the given numas
+func (cra *cpuResourceAdvisor) countRegionCPUs(r region.QoSRegion, numas machine.CPUSet) int {
+	cpus := machine.NewCPUSet()
+	for podUID, containerSet := range r.GetPods() {
+		for containerName := range containerSet {
+			ci, ok := cra.metaCache.GetContainerInfo(podUID, containerName)
+			if !ok || ci == nil {
+				continue
+			}
+			for _, numaID := range numas.ToSliceInt() {
+				cpus = cpus.Union(ci.TopologyAwareAssignments[numaID])
+			}
+		}
+	}
+	return cpus.Size()
+}
+
+// splitRegionLentCPUs splits cpus lent by the dedicated region across numas of its pods' cpusets,
+// and numas without any cpu lent are omitted
+func (cra *cpuResourceAdvisor) splitRegionLentCPUs(r region.QoSRegion, lent int) map[string]map[int]int {
+	res := make(map[string]map[int]int)
+	for podUID, containerSet := range r.GetPods() {
+		for containerName := range containerSet {
+			ci, ok := cra.metaCache.GetContainerInfo(podUID, containerName)
+			if !ok || ci == nil || lent <= 0 {
+				continue
+			}
+
+			for numaID, cpus := range splitCPUsByAssignments(lent, ci.TopologyAwareAssignments) {
+				if cpus <= 0 {
+					continue
+				}
+				if res[podUID] == nil {
+					res[podUID] = make(map[int]int)
+				}
+				res[podUID][numaID] = cpus
+				lent -= cpus
+			}
+		}
+	}
+	return res
+}
+
+// splitCPUsByAssignments splits cpus across numas in proportion to cpus assigned on each of them,
+// and the remainder goes to numas in ascending order as long as they have spare cpus assigned;
+// cpus beyond the assignments are dropped.
+func splitCPUsByAssignments(cpus int, assignments types.TopologyAwareAssignment) map[int]int {
+	numaIDs := make([]int, 0, len(assignments))
+	totalCPUs := 0
+	for numaID, cpuset := range assignments {
+		numaIDs = append(numaIDs, numaID)
+		totalCPUs += cpuset.Size()
+	}
+	sort.Ints(numaIDs)
+
+	res := make(map[int]int, len(numaIDs))
+	if totalCPUs <= 0 {
+		return res
+	}
+
+	cpus = general.Min(cpus, totalCPUs)
+	remainder := cpus
+	for _, numaID := range numaIDs {
+		res[numaID] = cpus * assignments[numaID].Size() / totalCPUs
+		remainder -= res[numaID]
+	}
+	for _, numaID := range numaIDs {
+		extra := general.Min(remainder, assignments[numaID].Size()-res[numaID])
+		res[numaID] += extra
+		remainder -= extra
+	}
+	return res
+}
+
 func (cra *cpuResourceAdvisor) gc() {
 	// Delete empty regions in region map
 	for regionName, r := range cra.regionMap {
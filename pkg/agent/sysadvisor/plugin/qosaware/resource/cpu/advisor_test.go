@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
 
 	"github.com/kubewharf/katalyst-api/pkg/consts"
 	katalyst_base "github.com/kubewharf/katalyst-core/cmd/base"
@@ -41,6 +42,7 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
@@ -76,6 +78,8 @@ func newTestCPUResourceAdvisor(t *testing.T, checkpointDir, stateFileDir string)
 		KatalystMachineInfo: &machine.KatalystMachineInfo{
 			CPUTopology: cpuTopology,
 		},
+		PodFetcher:     &pod.PodFetcherStub{},
+		MetricsFetcher: metric.NewFakeMetricsFetcher(metrics.DummyMetrics{}),
 	}
 
 	cra := NewCPUResourceAdvisor(conf, struct{}{}, metaCache, metaServer, nil)
@@ -100,6 +104,7 @@ func makeContainerInfo(podUID, namespace, podName, containerName, qoSLevel strin
 		PodNamespace:                     namespace,
 		PodName:                          podName,
 		ContainerName:                    containerName,
+		ContainerType:                    v1alpha1.ContainerType_MAIN,
 		ContainerIndex:                   0,
 		Labels:                           nil,
 		Annotations:                      annotations,
@@ -145,7 +150,7 @@ func TestUpdate(t *testing.T) {
 			},
 			reclaimEnabled: true,
 			wantInternalCalculationResult: InternalCalculationResult{
-				map[string]map[int]resource.Quantity{
+				PoolEntries: map[string]map[int]resource.Quantity{
 					state.PoolNameReserve: {-1: *resource.NewQuantity(2, resource.DecimalSI)},
 					state.PoolNameReclaim: {-1: *resource.NewQuantity(94, resource.DecimalSI)},
 				},
@@ -187,7 +192,7 @@ func TestUpdate(t *testing.T) {
 					}, 4),
 			},
 			wantInternalCalculationResult: InternalCalculationResult{
-				map[string]map[int]resource.Quantity{
+				PoolEntries: map[string]map[int]resource.Quantity{
 					state.PoolNameReserve: {-1: *resource.NewQuantity(2, resource.DecimalSI)},
 					state.PoolNameShare:   {-1: *resource.NewQuantity(8, resource.DecimalSI)},
 					state.PoolNameReclaim: {-1: *resource.NewQuantity(86, resource.DecimalSI)},
@@ -230,7 +235,7 @@ func TestUpdate(t *testing.T) {
 					}, 96),
 			},
 			wantInternalCalculationResult: InternalCalculationResult{
-				map[string]map[int]resource.Quantity{
+				PoolEntries: map[string]map[int]resource.Quantity{
 					state.PoolNameReserve: {-1: *resource.NewQuantity(2, resource.DecimalSI)},
 					state.PoolNameShare:   {-1: *resource.NewQuantity(90, resource.DecimalSI)},
 					state.PoolNameReclaim: {-1: *resource.NewQuantity(4, resource.DecimalSI)},
@@ -275,7 +280,7 @@ func TestUpdate(t *testing.T) {
 					}, 4),
 			},
 			wantInternalCalculationResult: InternalCalculationResult{
-				map[string]map[int]resource.Quantity{
+				PoolEntries: map[string]map[int]resource.Quantity{
 					state.PoolNameReserve: {
 						-1: *resource.NewQuantity(2, resource.DecimalSI),
 					},
@@ -325,7 +330,7 @@ func TestUpdate(t *testing.T) {
 					}, 6),
 			},
 			wantInternalCalculationResult: InternalCalculationResult{
-				map[string]map[int]resource.Quantity{
+				PoolEntries: map[string]map[int]resource.Quantity{
 					state.PoolNameReserve: {
 						-1: *resource.NewQuantity(2, resource.DecimalSI),
 					},
@@ -373,7 +378,7 @@ func TestUpdate(t *testing.T) {
 					}, 48),
 			},
 			wantInternalCalculationResult: InternalCalculationResult{
-				map[string]map[int]resource.Quantity{
+				PoolEntries: map[string]map[int]resource.Quantity{
 					state.PoolNameReserve: {
 						-1: *resource.NewQuantity(2, resource.DecimalSI),
 					},
@@ -385,6 +390,83 @@ func TestUpdate(t *testing.T) {
 			},
 			wantHeadroom: resource.MustParse(fmt.Sprintf("%d", 51)),
 		},
+		{
+			name: "dedicated without numa binding and share",
+			pools: map[string]*types.PoolInfo{
+				state.PoolNameReserve: {
+					PoolName: state.PoolNameReserve,
+					TopologyAwareAssignments: map[int]machine.CPUSet{
+						0: machine.MustParse("0"),
+						1: machine.MustParse("24"),
+					},
+					OriginalTopologyAwareAssignments: map[int]machine.CPUSet{
+						0: machine.MustParse("0"),
+						1: machine.MustParse("24"),
+					},
+				},
+				state.PoolNameShare: {
+					PoolName: state.PoolNameShare,
+					TopologyAwareAssignments: map[int]machine.CPUSet{
+						0: machine.MustParse("9-10"),
+						1: machine.MustParse("25-26"),
+					},
+					OriginalTopologyAwareAssignments: map[int]machine.CPUSet{
+						0: machine.MustParse("9-10"),
+						1: machine.MustParse("25-26"),
+					},
+				},
+			},
+			reclaimEnabled: true,
+			containers: []*types.ContainerInfo{
+				makeContainerInfo("uid1", "default", "pod1", "c1", consts.PodAnnotationQoSLevelDedicatedCores, nil,
+					map[int]machine.CPUSet{
+						0: machine.MustParse("1-8"),
+					}, 8),
+				makeContainerInfo("uid2", "default", "pod2", "c2", consts.PodAnnotationQoSLevelSharedCores, nil,
+					map[int]machine.CPUSet{
+						0: machine.MustParse("9-10"),
+						1: machine.MustParse("25-26"),
+					}, 4),
+			},
+			wantInternalCalculationResult: InternalCalculationResult{
+				PoolEntries: map[string]map[int]resource.Quantity{
+					state.PoolNameReserve: {-1: *resource.NewQuantity(2, resource.DecimalSI)},
+					state.PoolNameShare:   {-1: *resource.NewQuantity(8, resource.DecimalSI)},
+					state.PoolNameReclaim: {-1: *resource.NewQuantity(78, resource.DecimalSI)},
+				},
+			},
+			wantHeadroom: resource.MustParse(fmt.Sprintf("%d", 78)),
+		},
+		{
+			name: "dedicated without numa binding only",
+			pools: map[string]*types.PoolInfo{
+				state.PoolNameReserve: {
+					PoolName: state.PoolNameReserve,
+					TopologyAwareAssignments: map[int]machine.CPUSet{
+						0: machine.MustParse("0"),
+						1: machine.MustParse("24"),
+					},
+					OriginalTopologyAwareAssignments: map[int]machine.CPUSet{
+						0: machine.MustParse("0"),
+						1: machine.MustParse("24"),
+					},
+				},
+			},
+			reclaimEnabled: true,
+			containers: []*types.ContainerInfo{
+				makeContainerInfo("uid1", "default", "pod1", "c1", consts.PodAnnotationQoSLevelDedicatedCores, nil,
+					map[int]machine.CPUSet{
+						0: machine.MustParse("1-8"),
+					}, 8),
+			},
+			wantInternalCalculationResult: InternalCalculationResult{
+				PoolEntries: map[string]map[int]resource.Quantity{
+					state.PoolNameReserve: {-1: *resource.NewQuantity(2, resource.DecimalSI)},
+					state.PoolNameReclaim: {-1: *resource.NewQuantity(86, resource.DecimalSI)},
+				},
+			},
+			wantHeadroom: resource.MustParse(fmt.Sprintf("%d", 86)),
+		},
 	}
 
 	for _, tt := range tests {
@@ -437,9 +519,100 @@ func TestUpdate(t *testing.T) {
 				if !reflect.DeepEqual(tt.wantHeadroom.MilliValue(), headroom.MilliValue()) {
 					t.Errorf("headroom\nexpected: %+v\nactual: %+v", tt.wantHeadroom, headroom)
 				}
+
+				// Check numa headroom
+				numaHeadroom, err := advisor.GetNumaHeadroom()
+				assert.NoError(t, err)
+				assert.Equal(t, advisor.systemNumas.Size(), len(numaHeadroom))
 			}
 
 			cancel()
 		})
 	}
 }
+
+func TestSplitHeadroomByAssignments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		milli       int64
+		numas       machine.CPUSet
+		assignments types.TopologyAwareAssignment
+		want        map[int]int64
+	}{
+		{
+			name:  "split by cpus of reclaim pool",
+			milli: 12000,
+			numas: machine.NewCPUSet(0, 1),
+			assignments: types.TopologyAwareAssignment{
+				0: machine.NewCPUSet(0),
+				1: machine.NewCPUSet(24, 25, 26),
+				2: machine.NewCPUSet(48, 49),
+			},
+			want: map[int]int64{0: 3000, 1: 9000},
+		},
+		{
+			name:        "split evenly without reclaim pool",
+			milli:       12000,
+			numas:       machine.NewCPUSet(0, 1),
+			assignments: nil,
+			want:        map[int]int64{0: 6000, 1: 6000},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, splitHeadroomByAssignments(tt.milli, tt.numas, tt.assignments))
+		})
+	}
+}
+
+func TestSplitCPUsByAssignments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		cpus        int
+		assignments types.TopologyAwareAssignment
+		want        map[int]int
+	}{
+		{
+			name: "split in proportion to assigned cpus",
+			cpus: 4,
+			assignments: types.TopologyAwareAssignment{
+				0: machine.NewCPUSet(1, 2),
+				1: machine.NewCPUSet(24, 25, 26, 27, 28, 29),
+			},
+			want: map[int]int{0: 1, 1: 3},
+		},
+		{
+			name: "remainder goes to numas with spare cpus",
+			cpus: 3,
+			assignments: types.TopologyAwareAssignment{
+				0: machine.NewCPUSet(1),
+				1: machine.NewCPUSet(24),
+				2: machine.NewCPUSet(48, 49, 50, 51),
+			},
+			want: map[int]int{0: 1, 1: 0, 2: 2},
+		},
+		{
+			name: "cpus beyond assignments are dropped",
+			cpus: 10,
+			assignments: types.TopologyAwareAssignment{
+				0: machine.NewCPUSet(1, 2),
+			},
+			want: map[int]int{0: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, splitCPUsByAssignments(tt.cpus, tt.assignments))
+		})
+	}
+}
@@ -30,6 +30,8 @@ import (
 type HeadroomPolicy interface {
 	// SetPodSet overwrites policy's pod/container record
 	SetPodSet(types.PodSet)
+	// SetIndicator updates indicator metric value of different levels
+	SetIndicator(types.Indicator)
 	// SetEssentials updates essential values for policy update
 	SetEssentials(essentials types.ResourceEssentials)
 
@@ -28,6 +28,7 @@ type PolicyBase struct {
 
 	headroom   float64
 	podSet     types.PodSet
+	indicator  types.Indicator
 	essentials types.ResourceEssentials
 
 	metaReader metacache.MetaReader
@@ -40,6 +41,7 @@ func NewPolicyBase(regionName string, metaReader metacache.MetaReader, metaServe
 	cp := &PolicyBase{
 		regionName: regionName,
 		podSet:     make(types.PodSet),
+		indicator:  make(types.Indicator),
 
 		metaReader: metaReader,
 		metaServer: metaServer,
@@ -52,6 +54,10 @@ func (p *PolicyBase) SetPodSet(podSet types.PodSet) {
 	p.podSet = podSet.Clone()
 }
 
+func (p *PolicyBase) SetIndicator(v types.Indicator) {
+	p.indicator = v
+}
+
 func (p *PolicyBase) SetEssentials(essentials types.ResourceEssentials) {
 	p.essentials = essentials
 }
@@ -55,7 +55,7 @@ func (p *PolicyCanonical) Update() error {
 		}
 		cpuRequirement := controlKnobValue.Value
 		p.headroom = math.Max(float64(p.essentials.Total-p.essentials.ReservePoolSize)-cpuRequirement, 0)
-	case types.QoSRegionTypeDedicatedNumaExclusive:
+	case types.QoSRegionTypeDedicatedNumaExclusive, types.QoSRegionTypeDedicated:
 		controlKnobValue, ok = regionInfo.ControlKnobMap[types.ControlKnobReclaimedCPUSupplied]
 		if !ok {
 			return fmt.Errorf("get control knob value failed")
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroompolicy
+
+import (
+	"fmt"
+	"math"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+)
+
+// PolicyDedicated regards cpus lent by dedicated cores containers without numa binding
+// as headroom, and reports no headroom once any indicator of the region exceeds its target,
+// since the lent cpus are going to be taken back soon.
+type PolicyDedicated struct {
+	*PolicyBase
+}
+
+func NewPolicyDedicated(regionName string, _ *config.Configuration, _ interface{}, metaReader metacache.MetaReader,
+	metaServer *metaserver.MetaServer, emitter metrics.MetricEmitter) HeadroomPolicy {
+	p := &PolicyDedicated{
+		PolicyBase: NewPolicyBase(regionName, metaReader, metaServer, emitter),
+	}
+
+	return p
+}
+
+func (p *PolicyDedicated) Update() error {
+	regionInfo, ok := p.metaReader.GetRegionInfo(p.regionName)
+	if !ok {
+		return fmt.Errorf("get region info for %v failed", p.regionName)
+	}
+	if regionInfo.RegionType != types.QoSRegionTypeDedicated {
+		return fmt.Errorf("region type %v is invalid", regionInfo.RegionType)
+	}
+
+	controlKnobValue, ok := regionInfo.ControlKnobMap[types.ControlKnobReclaimedCPUSupplied]
+	if !ok {
+		return fmt.Errorf("get control knob value failed")
+	}
+	p.headroom = math.Max(controlKnobValue.Value, 0)
+
+	for indicatorName, indicatorValue := range p.indicator {
+		if indicatorValue.Target > 0 && indicatorValue.Current >= indicatorValue.Target {
+			klog.Infof("[qosaware-cpu-headroom] region %v indicator %v current %.2f reaches target %.2f",
+				p.regionName, indicatorName, indicatorValue.Current, indicatorValue.Target)
+			p.headroom = 0
+			break
+		}
+	}
+
+	return nil
+}
+
+func (p *PolicyDedicated) GetHeadroom() (float64, error) {
+	return p.headroom, nil
+}
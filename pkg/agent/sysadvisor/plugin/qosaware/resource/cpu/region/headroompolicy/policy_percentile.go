@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroompolicy
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/helper"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+// PolicyPercentile regards a percentile of cpu usage history plus a safety margin as
+// the requirement of each container, so that headroom is stable against usage spikes
+type PolicyPercentile struct {
+	*PolicyBase
+
+	percentile        float64
+	safetyMarginRatio float64
+}
+
+func NewPolicyPercentile(regionName string, conf *config.Configuration, _ interface{}, metaReader metacache.MetaReader,
+	metaServer *metaserver.MetaServer, emitter metrics.MetricEmitter) HeadroomPolicy {
+	p := &PolicyPercentile{
+		PolicyBase:        NewPolicyBase(regionName, metaReader, metaServer, emitter),
+		percentile:        conf.CPUHeadroomPolicyPercentileConfiguration.Percentile,
+		safetyMarginRatio: conf.CPUHeadroomPolicyPercentileConfiguration.SafetyMarginRatio,
+	}
+
+	return p
+}
+
+func (p *PolicyPercentile) estimateCPUUsage() (cpuEstimation float64, containerCnt uint, err error) {
+	regionInfo, ok := p.metaReader.GetRegionInfo(p.regionName)
+	if !ok {
+		return 0, 0, fmt.Errorf("get region info for %v failed", p.regionName)
+	}
+
+	for podUID, containerSet := range p.podSet {
+		for containerName := range containerSet {
+			ci, ok := p.metaReader.GetContainerInfo(podUID, containerName)
+			if !ok || ci == nil {
+				klog.Errorf("[qosaware-cpu-headroom] illegal container info of %v/%v", podUID, containerName)
+				continue
+			}
+
+			containerEstimation, err := helper.EstimateContainerResourceUsageByPercentile(ci, v1.ResourceCPU, p.metaReader,
+				p.essentials.EnableReclaim, p.percentile, p.safetyMarginRatio)
+			if err != nil {
+				return 0, 0, err
+			}
+			// split cpu usage evenly across the binding numas of container, the same as canonical provision policy
+			if regionInfo.BindingNumas.Size() > 0 {
+				if cpuCnt := machine.CountCPUAssignmentCPUs(ci.TopologyAwareAssignments); cpuCnt > 0 {
+					cpuSize := 0
+					for _, numaID := range regionInfo.BindingNumas.ToSliceInt() {
+						cpuSize += ci.TopologyAwareAssignments[numaID].Size()
+					}
+					containerEstimation = containerEstimation * float64(cpuSize) / float64(cpuCnt)
+				}
+			}
+
+			cpuEstimation += containerEstimation
+			containerCnt += 1
+		}
+	}
+	return
+}
+
+func (p *PolicyPercentile) Update() error {
+	cpuEstimation, containerCnt, err := p.estimateCPUUsage()
+	if err != nil {
+		return err
+	}
+	klog.Infof("[qosaware-cpu-headroom] region %v cpu requirement percentile estimation: %.2f, #container %v",
+		p.regionName, cpuEstimation, containerCnt)
+
+	p.headroom = math.Max(float64(p.essentials.Total-p.essentials.ReservePoolSize)-cpuEstimation, 0)
+	return nil
+}
+
+func (p *PolicyPercentile) GetHeadroom() (float64, error) {
+	return p.headroom, nil
+}
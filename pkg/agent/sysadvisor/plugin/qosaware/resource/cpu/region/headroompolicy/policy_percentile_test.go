@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroompolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+func TestPolicyPercentile(t *testing.T) {
+	t.Parallel()
+
+	conf, err := options.NewOptions().Config()
+	require.NoError(t, err)
+	conf.GenericSysAdvisorConfiguration.StateFileDirectory = t.TempDir()
+	conf.CPUHeadroomPolicyPercentileConfiguration.Percentile = 0.95
+	conf.CPUHeadroomPolicyPercentileConfiguration.SafetyMarginRatio = 0.1
+
+	metaCache, err := metacache.NewMetaCacheImp(conf, metric.NewFakeMetricsFetcher(metrics.DummyMetrics{}))
+	require.NoError(t, err)
+	require.NoError(t, metaCache.UpdateRegionEntries(types.RegionEntries{
+		"share": {RegionType: types.QoSRegionTypeShare, BindingNumas: machine.NewCPUSet()},
+	}))
+	for _, podUID := range []string{"pod1", "pod2"} {
+		require.NoError(t, metaCache.SetContainerInfo(podUID, "c1", &types.ContainerInfo{
+			PodUID:                   podUID,
+			PodName:                  podUID,
+			ContainerName:            "c1",
+			QoSLevel:                 consts.PodAnnotationQoSLevelSharedCores,
+			CPURequest:               2,
+			TopologyAwareAssignments: types.TopologyAwareAssignment{0: machine.NewCPUSet(0, 1, 2, 3)},
+		}))
+	}
+
+	// cpu usage of pod1 is uniformly distributed in [0.1, 10], and pod2 has no usage history
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		require.NoError(t, metaCache.AddContainerHistogramSamples(types.ContainerHistogramNameCPUUsage,
+			map[string]map[string]float64{"pod1": {"c1": float64(i) / 10}}, now.Add(time.Duration(i)*time.Minute)))
+	}
+
+	policy := NewPolicyPercentile("share", conf, nil, metaCache, nil, metrics.DummyMetrics{})
+	policy.SetEssentials(types.ResourceEssentials{
+		EnableReclaim:   true,
+		Total:           48,
+		ReservePoolSize: 2,
+	})
+
+	policy.SetPodSet(types.PodSet{"pod1": {"c1": {}}})
+	require.NoError(t, policy.Update())
+	headroom, err := policy.GetHeadroom()
+	require.NoError(t, err)
+	require.InDelta(t, 48-2-9.5*1.1, headroom, 0.6)
+
+	// fall back to canonical estimation without usage history, which is the request without metrics
+	policy.SetPodSet(types.PodSet{"pod1": {"c1": {}}, "pod2": {"c1": {}}})
+	require.NoError(t, policy.Update())
+	headroom, err = policy.GetHeadroom()
+	require.NoError(t, err)
+	require.InDelta(t, 48-2-9.5*1.1-2, headroom, 0.6)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisionpolicy
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/cpu/region/regulator"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/helper"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+)
+
+// PolicyDedicated estimates cpus that dedicated cores containers without numa binding
+// should retain in their exclusive cpusets, and the rest can be lent to reclaimed pods.
+// Lending is stopped once any indicator of the region exceeds the target declared by spd.
+type PolicyDedicated struct {
+	*PolicyBase
+
+	safetyMarginRatio float64
+}
+
+func NewPolicyDedicated(regionName string, conf *config.Configuration, _ interface{}, regulator *regulator.CPURegulator,
+	metaReader metacache.MetaReader, metaServer *metaserver.MetaServer, emitter metrics.MetricEmitter) ProvisionPolicy {
+	p := &PolicyDedicated{
+		PolicyBase:        NewPolicyBase(regionName, regulator, metaReader, metaServer, emitter),
+		safetyMarginRatio: conf.CPUProvisionPolicyDedicatedConfiguration.SafetyMarginRatio,
+	}
+	return p
+}
+
+func (p *PolicyDedicated) estimationCPUUsage() (cpuEstimation float64, containerCnt uint, err error) {
+	for podUID, containerSet := range p.podSet {
+		for containerName := range containerSet {
+			ci, ok := p.metaReader.GetContainerInfo(podUID, containerName)
+			if !ok || ci == nil {
+				klog.Errorf("[qosaware-cpu-provision] illegal container info of %v/%v", podUID, containerName)
+				continue
+			}
+
+			containerEstimation, err := helper.EstimateContainerResourceUsage(ci, v1.ResourceCPU, p.metaReader, p.essentials.EnableReclaim)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			cpuEstimation += containerEstimation
+			containerCnt += 1
+		}
+	}
+	return
+}
+
+// indicatorViolated returns true if any indicator has reached its target
+func (p *PolicyDedicated) indicatorViolated() bool {
+	for indicatorName, indicatorValue := range p.indicator {
+		if indicatorValue.Target > 0 && indicatorValue.Current >= indicatorValue.Target {
+			klog.Infof("[qosaware-cpu-provision] region %v indicator %v current %.2f reaches target %.2f",
+				p.regionName, indicatorName, indicatorValue.Current, indicatorValue.Target)
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PolicyDedicated) Update() error {
+	cpuEstimation, containerCnt, err := p.estimationCPUUsage()
+	if err != nil {
+		return err
+	}
+
+	cpuRequirement := cpuEstimation * (1 + p.safetyMarginRatio)
+	if !p.essentials.EnableReclaim || p.indicatorViolated() {
+		// retain all exclusive cpus and lend nothing to reclaimed pods
+		cpuRequirement = float64(p.essentials.MaxRequirement)
+	}
+	klog.Infof("[qosaware-cpu-provision] region %v cpu usage estimation: %.2f, requirement: %.2f, #container %v",
+		p.regionName, cpuEstimation, cpuRequirement, containerCnt)
+
+	p.regulator.SetLatestCPURequirement(p.requirement)
+	p.regulator.Regulate(cpuRequirement)
+	p.requirement = p.regulator.GetCPURequirement()
+	return nil
+}
+
+func (p *PolicyDedicated) GetControlKnobAdjusted() (types.ControlKnob, error) {
+	return map[types.ControlKnobName]types.ControlKnobValue{
+		types.ControlKnobNonReclaimedCPUSetSize: {
+			Value:  float64(p.requirement),
+			Action: types.ControlKnobActionNone,
+		},
+	}, nil
+}
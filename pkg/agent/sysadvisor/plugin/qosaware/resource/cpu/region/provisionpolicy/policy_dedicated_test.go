@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisionpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	workloadapis "github.com/kubewharf/katalyst-api/pkg/apis/workload/v1alpha1"
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/cpu/region/regulator"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+func TestPolicyDedicated(t *testing.T) {
+	t.Parallel()
+
+	conf, err := options.NewOptions().Config()
+	require.NoError(t, err)
+	conf.GenericSysAdvisorConfiguration.StateFileDirectory = t.TempDir()
+	conf.CPUProvisionPolicyDedicatedConfiguration.SafetyMarginRatio = 0.2
+
+	metricsFetcher := metric.NewFakeMetricsFetcher(metrics.DummyMetrics{}).(*metric.FakeMetricsFetcher)
+	metricsFetcher.SetContainerMetric("pod1", "c1", consts.MetricCPUUsageContainer, 4)
+	metricsFetcher.SetContainerMetric("pod1", "c1", consts.MetricLoad1MinContainer, 3)
+	metricsFetcher.SetContainerMetric("pod1", "c1", consts.MetricLoad5MinContainer, 3)
+
+	metaCache, err := metacache.NewMetaCacheImp(conf, metricsFetcher)
+	require.NoError(t, err)
+	require.NoError(t, metaCache.SetContainerInfo("pod1", "c1", &types.ContainerInfo{
+		PodUID:                   "pod1",
+		PodName:                  "pod1",
+		ContainerName:            "c1",
+		QoSLevel:                 apiconsts.PodAnnotationQoSLevelDedicatedCores,
+		CPURequest:               16,
+		TopologyAwareAssignments: types.TopologyAwareAssignment{0: machine.MustParse("0-15")},
+	}))
+
+	cpuRegulator := regulator.NewCPURegulator(100, 100, 0)
+	policy := NewPolicyDedicated("dedicated", conf, nil, cpuRegulator, metaCache, nil, metrics.DummyMetrics{})
+	policy.SetPodSet(types.PodSet{"pod1": {"c1": {}}})
+	policy.SetRequirement(16)
+
+	// each step feeds cpu schedule wait with target 400 and expects the regulated requirement
+	trace := []struct {
+		name          string
+		current       float64
+		enableReclaim bool
+		expectedCPU   float64
+	}{
+		{name: "no indicator lends idle cpus", current: 0, enableReclaim: true, expectedCPU: 6},
+		{name: "below target keeps lending", current: 300, enableReclaim: true, expectedCPU: 6},
+		{name: "reaching target stops lending", current: 400, enableReclaim: true, expectedCPU: 16},
+		{name: "back below target lends again", current: 100, enableReclaim: true, expectedCPU: 6},
+		{name: "reclaim disabled stops lending", current: 100, enableReclaim: false, expectedCPU: 16},
+	}
+
+	for _, step := range trace {
+		indicator := types.Indicator{}
+		if step.current > 0 {
+			indicator[string(workloadapis.TargetIndicatorNameCPUSchedWait)] = types.IndicatorValue{Current: step.current, Target: 400}
+		}
+		policy.SetIndicator(indicator)
+		policy.SetEssentials(types.ResourceEssentials{
+			EnableReclaim:  step.enableReclaim,
+			MinRequirement: 4,
+			MaxRequirement: 16,
+		})
+		require.NoError(t, policy.Update(), step.name)
+
+		controlKnob, err := policy.GetControlKnobAdjusted()
+		require.NoError(t, err, step.name)
+		require.Equal(t, step.expectedCPU, controlKnob[types.ControlKnobNonReclaimedCPUSetSize].Value, step.name)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisionpolicy
+
+import (
+	"fmt"
+	"math"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/cpu/region/regulator"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/helper"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+)
+
+// ramaRequirementRoundingGap is the max gap between the raw requirement and the one
+// regulated from it, since regulator rounds cpu requirement up to even cores
+const ramaRequirementRoundingGap = 2
+
+// PolicyRama adjusts cpu requirement of a region by pid controllers to keep
+// service level indicators (e.g. cpu schedule wait) around their targets, and
+// the largest requirement among all indicators is adopted to satisfy all of them.
+type PolicyRama struct {
+	*PolicyBase
+
+	pidParams   map[string]types.FirstOrderPIDParams
+	controllers map[string]*helper.PIDController
+
+	// rawRequirement is the requirement (excluding the reserved part) before regulation, and it's kept
+	// as a float so that adjustments smaller than the rounding of regulator accumulate across rounds
+	// instead of being rounded back to the regulated requirement every time
+	rawRequirement    float64
+	hasRawRequirement bool
+}
+
+func NewPolicyRama(regionName string, conf *config.Configuration, _ interface{}, regulator *regulator.CPURegulator,
+	metaReader metacache.MetaReader, metaServer *metaserver.MetaServer, emitter metrics.MetricEmitter) ProvisionPolicy {
+	p := &PolicyRama{
+		PolicyBase:  NewPolicyBase(regionName, regulator, metaReader, metaServer, emitter),
+		pidParams:   conf.CPUProvisionPolicyRamaConfiguration.PIDParameters,
+		controllers: make(map[string]*helper.PIDController),
+	}
+	return p
+}
+
+func (p *PolicyRama) Update() error {
+	// the requirement passed to regulator excludes the reserved part, which will be added back by regulator;
+	// the raw requirement is bounded within the rounding gap below the regulated one, so that it neither
+	// winds up when the regulator restricts or clamps it nor drifts away from a requirement set by others
+	current := float64(p.requirement - p.essentials.ReservedForAllocate)
+	if p.hasRawRequirement {
+		current = general.Clamp(p.rawRequirement, current-ramaRequirementRoundingGap, current)
+	}
+	cpuRequirement := math.Inf(-1)
+
+	for indicatorName, indicatorValue := range p.indicator {
+		params, ok := p.pidParams[indicatorName]
+		if !ok {
+			continue
+		}
+
+		controller, ok := p.controllers[indicatorName]
+		if !ok {
+			controller = helper.NewPIDController(params)
+			p.controllers[indicatorName] = controller
+		}
+
+		adjustment := controller.Adjust(indicatorValue.Target, indicatorValue.Current)
+		klog.Infof("[qosaware-cpu-rama] region %v indicator %v current %.2f target %.2f, cpu adjustment %.2f",
+			p.regionName, indicatorName, indicatorValue.Current, indicatorValue.Target, adjustment)
+
+		cpuRequirement = math.Max(cpuRequirement, current+adjustment)
+	}
+
+	// discard states of controllers whose indicators are gone to avoid stale accumulation
+	for indicatorName := range p.controllers {
+		if _, ok := p.indicator[indicatorName]; !ok {
+			delete(p.controllers, indicatorName)
+		}
+	}
+
+	if math.IsInf(cpuRequirement, -1) {
+		return fmt.Errorf("no valid indicator for region %v", p.regionName)
+	}
+
+	p.rawRequirement, p.hasRawRequirement = cpuRequirement, true
+	p.regulator.SetLatestCPURequirement(p.requirement)
+	p.regulator.Regulate(cpuRequirement)
+	p.requirement = p.regulator.GetCPURequirement()
+	return nil
+}
+
+func (p *PolicyRama) GetControlKnobAdjusted() (types.ControlKnob, error) {
+	return map[types.ControlKnobName]types.ControlKnobValue{
+		types.ControlKnobNonReclaimedCPUSetSize: {
+			Value:  float64(p.requirement),
+			Action: types.ControlKnobActionNone,
+		},
+	}, nil
+}
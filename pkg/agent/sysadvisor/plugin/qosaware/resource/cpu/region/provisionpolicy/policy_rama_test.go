@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisionpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	workloadapis "github.com/kubewharf/katalyst-api/pkg/apis/workload/v1alpha1"
+	cpuoptions "github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options/sysadvisor/qosaware/resource/cpu"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/cpu/region/regulator"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+)
+
+func newTestPolicyRama() ProvisionPolicy {
+	conf := config.NewConfiguration()
+	conf.CPUProvisionPolicyRamaConfiguration.PIDParameters = map[string]types.FirstOrderPIDParams{
+		string(workloadapis.TargetIndicatorNameCPUSchedWait): {
+			Kpp:                  10,
+			Kpn:                  4,
+			AdjustmentUpperBound: 8,
+			AdjustmentLowerBound: -2,
+			DeadbandUpperPct:     0.02,
+			DeadbandLowerPct:     0.2,
+		},
+	}
+
+	cpuRegulator := regulator.NewCPURegulator(100, 100, 0)
+	policy := NewPolicyRama("share", conf, nil, cpuRegulator, nil, nil, metrics.DummyMetrics{})
+	policy.SetEssentials(types.ResourceEssentials{
+		EnableReclaim:  true,
+		Total:          96,
+		MinRequirement: 4,
+		MaxRequirement: 88,
+	})
+	policy.SetRequirement(10)
+	return policy
+}
+
+func TestPolicyRama(t *testing.T) {
+	t.Parallel()
+
+	policy := newTestPolicyRama()
+
+	// each step feeds cpu schedule wait with target 400 and expects the regulated requirement
+	trace := []struct {
+		name        string
+		current     float64
+		expectedCPU float64
+	}{
+		{name: "slightly above target ramps up", current: 460, expectedCPU: 12},
+		{name: "far above target ramps up faster", current: 600, expectedCPU: 18},
+		{name: "adjustment is bounded", current: 4000, expectedCPU: 26},
+		{name: "within deadband keeps", current: 405, expectedCPU: 26},
+		{name: "slightly below target keeps", current: 350, expectedCPU: 26},
+		{name: "far below target ramps down", current: 100, expectedCPU: 24},
+	}
+
+	for _, step := range trace {
+		policy.SetIndicator(types.Indicator{
+			string(workloadapis.TargetIndicatorNameCPUSchedWait): {Current: step.current, Target: 400},
+		})
+		require.NoError(t, policy.Update(), step.name)
+
+		controlKnob, err := policy.GetControlKnobAdjusted()
+		require.NoError(t, err, step.name)
+		require.Equal(t, step.expectedCPU, controlKnob[types.ControlKnobNonReclaimedCPUSetSize].Value, step.name)
+	}
+
+	// indicators not configured are ignored, and the policy fails without valid indicators
+	policy.SetIndicator(types.Indicator{
+		string(workloadapis.TargetIndicatorNameCPI): {Current: 3, Target: 1},
+	})
+	require.Error(t, policy.Update())
+
+	controlKnob, err := policy.GetControlKnobAdjusted()
+	require.NoError(t, err)
+	require.Equal(t, float64(24), controlKnob[types.ControlKnobNonReclaimedCPUSetSize].Value)
+}
+
+func TestPolicyRamaRampDownWithDefaultOptions(t *testing.T) {
+	t.Parallel()
+
+	conf := config.NewConfiguration()
+	require.NoError(t, cpuoptions.NewCPUProvisionPolicyRamaOptions().ApplyTo(conf.CPUProvisionPolicyRamaConfiguration))
+
+	cpuRegulator := regulator.NewCPURegulator(100, 100, 0)
+	policy := NewPolicyRama("share", conf, nil, cpuRegulator, nil, nil, metrics.DummyMetrics{})
+	policy.SetEssentials(types.ResourceEssentials{
+		EnableReclaim:  true,
+		Total:          96,
+		MinRequirement: 4,
+		MaxRequirement: 88,
+	})
+	policy.SetRequirement(20)
+
+	// adjustments below the rounding of regulator accumulate, so that the requirement
+	// ramps down steadily when cpu schedule wait stays far below the target
+	for _, expectedCPU := range []float64{20, 18, 18, 16, 16, 14} {
+		policy.SetIndicator(types.Indicator{
+			string(workloadapis.TargetIndicatorNameCPUSchedWait): {Current: 0, Target: 400},
+		})
+		require.NoError(t, policy.Update())
+
+		controlKnob, err := policy.GetControlKnobAdjusted()
+		require.NoError(t, err)
+		require.Equal(t, expectedCPU, controlKnob[types.ControlKnobNonReclaimedCPUSetSize].Value)
+	}
+}
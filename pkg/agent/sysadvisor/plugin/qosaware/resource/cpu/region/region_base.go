@@ -85,6 +85,11 @@ type QoSRegionBase struct {
 	headroomPolicies    []*internalHeadroomPolicy
 	headroomPolicyInUse *internalHeadroomPolicy
 
+	// indicatorTargetMergeRule decides the indicator target if services in the region have different targets
+	indicatorTargetMergeRule types.IndicatorTargetMergeRule
+	// metricsMaxAge is the max age of metrics used as current values of indicators
+	metricsMaxAge time.Duration
+
 	metaReader metacache.MetaReader
 	metaServer *metaserver.MetaServer
 	emitter    metrics.MetricEmitter
@@ -105,6 +110,9 @@ func NewQoSRegionBase(name string, ownerPoolName string, regionType types.QoSReg
 		provisionPolicies: make([]*internalProvisionPolicy, 0),
 		headroomPolicies:  make([]*internalHeadroomPolicy, 0),
 
+		indicatorTargetMergeRule: conf.IndicatorTargetMergeRule,
+		metricsMaxAge:            conf.MetricsMaxAge,
+
 		metaReader: metaReader,
 		metaServer: metaServer,
 		emitter:    emitter,
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package region
+
+import (
+	"fmt"
+	"math"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+type QoSRegionDedicated struct {
+	*QoSRegionBase
+}
+
+// NewQoSRegionDedicated returns a region instance for dedicated cores container
+// without numa binding, whose idle exclusive cpus can be lent to reclaimed pods
+func NewQoSRegionDedicated(name string, ownerPoolName string, conf *config.Configuration, extraConf interface{},
+	metaReader metacache.MetaReader, metaServer *metaserver.MetaServer, emitter metrics.MetricEmitter) QoSRegion {
+
+	r := &QoSRegionDedicated{
+		QoSRegionBase: NewQoSRegionBase(name, ownerPoolName, types.QoSRegionTypeDedicated, conf, extraConf, metaReader, metaServer, emitter),
+	}
+	return r
+}
+
+// AddContainer stores the container and regards numas of its cpuset as binding numas
+func (r *QoSRegionDedicated) AddContainer(ci *types.ContainerInfo) error {
+	if err := r.QoSRegionBase.AddContainer(ci); err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	numas := machine.NewCPUSet()
+	for numaID, cpuset := range r.containerTopologyAwareAssignment {
+		if cpuset.Size() > 0 {
+			numas = numas.Union(machine.NewCPUSet(numaID))
+		}
+	}
+	r.bindingNumas = numas
+
+	return nil
+}
+
+// SetEssentials restricts region total to the exclusive cpus of the container,
+// since neither reserve pool nor resource reserved for allocation lies in them
+func (r *QoSRegionDedicated) SetEssentials(essentials types.ResourceEssentials) {
+	r.Lock()
+	defer r.Unlock()
+
+	essentials.Total = machine.CountCPUAssignmentCPUs(r.containerTopologyAwareAssignment)
+	essentials.ReservePoolSize = 0
+	essentials.ReservedForAllocate = 0
+	r.ResourceEssentials = essentials
+}
+
+func (r *QoSRegionDedicated) TryUpdateProvision() {
+	r.Lock()
+	defer r.Unlock()
+
+	indicators := r.getIndicators()
+	for _, internal := range r.provisionPolicies {
+		internal.updateStatus = types.PolicyUpdateFailed
+
+		// set essentials for policy and regulator
+		internal.policy.SetPodSet(r.podSet)
+		internal.policy.SetIndicator(indicators)
+		internal.policy.SetEssentials(types.ResourceEssentials{
+			MinRequirement:      general.Min(minShareCPURequirement, r.Total),
+			MaxRequirement:      r.Total,
+			ReservedForAllocate: r.ReservedForAllocate,
+			EnableReclaim:       r.EnableReclaim,
+		})
+
+		// lend nothing at the beginning, and let regulator ramp down the requirement gradually
+		internal.initDoOnce.Do(func() {
+			internal.policy.SetRequirement(r.Total)
+			klog.Infof("[qosaware-cpu] set initial cpu requirement %v", r.Total)
+		})
+
+		// run an episode of policy and calculator update
+		if err := internal.policy.Update(); err != nil {
+			klog.Errorf("[qosaware-cpu] update policy %v failed: %v", internal.name, err)
+			continue
+		}
+		internal.updateStatus = types.PolicyUpdateSucceeded
+	}
+}
+
+func (r *QoSRegionDedicated) TryUpdateHeadroom() {
+	r.Lock()
+	defer r.Unlock()
+
+	indicators := r.getIndicators()
+	for _, internal := range r.headroomPolicies {
+		internal.updateStatus = types.PolicyUpdateFailed
+
+		// set essentials for policy and regulator
+		internal.policy.SetPodSet(r.podSet)
+		internal.policy.SetIndicator(indicators)
+		internal.policy.SetEssentials(r.ResourceEssentials)
+
+		// run an episode of policy and calculator update
+		if err := internal.policy.Update(); err != nil {
+			klog.Errorf("[qosaware-cpu] update policy %v failed: %v", internal.name, err)
+			continue
+		}
+		internal.updateStatus = types.PolicyUpdateSucceeded
+	}
+}
+
+func (r *QoSRegionDedicated) GetProvision() (types.ControlKnob, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, internal := range r.provisionPolicies {
+		if internal.updateStatus != types.PolicyUpdateSucceeded {
+			continue
+		}
+		controlKnobValue, err := internal.policy.GetControlKnobAdjusted()
+		if err != nil {
+			klog.Errorf("GetControlKnobAdjusted by policy %v err %v", internal.name, err)
+			continue
+		}
+		return types.ControlKnob{
+			types.ControlKnobReclaimedCPUSupplied: types.ControlKnobValue{
+				Value:  math.Max(float64(r.Total)-controlKnobValue[types.ControlKnobNonReclaimedCPUSetSize].Value, 0),
+				Action: types.ControlKnobActionNone,
+			},
+		}, nil
+	}
+	return types.ControlKnob{}, fmt.Errorf("failed to get valid provison")
+}
+
+func (r *QoSRegionDedicated) GetHeadroom() (resource.Quantity, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, internal := range r.headroomPolicies {
+		if internal.updateStatus != types.PolicyUpdateSucceeded {
+			continue
+		}
+		headroom, err := internal.policy.GetHeadroom()
+		if err != nil {
+			klog.Errorf("GetHeadroom by policy %v err %v", internal.name, err)
+			continue
+		}
+		r.headroomPolicyInUse = internal
+		return *resource.NewQuantity(int64(headroom), resource.DecimalSI), nil
+	}
+
+	return resource.Quantity{}, fmt.Errorf("failed to get valid headroom")
+}
@@ -54,11 +54,13 @@ func (r *QoSRegionDedicatedNumaExclusive) TryUpdateProvision() {
 	r.Lock()
 	defer r.Unlock()
 
+	indicators := r.getIndicators()
 	for _, internal := range r.provisionPolicies {
 		internal.updateStatus = types.PolicyUpdateFailed
 
 		// set essentials for policy and regulator
 		internal.policy.SetPodSet(r.podSet)
+		internal.policy.SetIndicator(indicators)
 		internal.policy.SetEssentials(types.ResourceEssentials{
 			MinRequirement:      minShareCPURequirement,
 			MaxRequirement:      r.Total - r.ReservePoolSize - minReclaimCPURequirement,
@@ -92,11 +94,13 @@ func (r *QoSRegionDedicatedNumaExclusive) TryUpdateHeadroom() {
 	r.Lock()
 	defer r.Unlock()
 
+	indicators := r.getIndicators()
 	for _, internal := range r.headroomPolicies {
 		internal.updateStatus = types.PolicyUpdateFailed
 
 		// set essentials for policy and regulator
 		internal.policy.SetPodSet(r.podSet)
+		internal.policy.SetIndicator(indicators)
 		internal.policy.SetEssentials(r.ResourceEssentials)
 
 		// run an episode of policy and calculator update
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package region
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"k8s.io/klog/v2"
+
+	workloadapis "github.com/kubewharf/katalyst-api/pkg/apis/workload/v1alpha1"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+)
+
+// indicatorCurrentGetter returns the current value of an indicator in the region
+type indicatorCurrentGetter func(r *QoSRegionBase) (float64, error)
+
+// indicatorCurrentGetters are getters of indicators supported by regions,
+// and indicators without getters are ignored even if they are declared in spd
+var indicatorCurrentGetters = map[string]indicatorCurrentGetter{
+	string(workloadapis.TargetIndicatorNameCPUSchedWait): getRegionCPUSchedWait,
+	string(workloadapis.TargetIndicatorNameCPI):          getRegionCPI,
+}
+
+// getIndicators collects indicator targets declared in spd of pods in the region,
+// and fills them up with current values from metric store; notice that only system
+// indicators are considered, and business indicators should be translated into
+// system indicators before taking effect.
+func (r *QoSRegionBase) getIndicators() types.Indicator {
+	indicators := make(types.Indicator)
+	for indicatorName, indicatorValue := range r.getIndicatorTargets() {
+		getter, ok := indicatorCurrentGetters[indicatorName]
+		if !ok {
+			continue
+		}
+
+		current, err := getter(r)
+		if err != nil {
+			klog.Warningf("[qosaware-cpu] get current value of indicator %v for region %v failed: %v", indicatorName, r.name, err)
+			continue
+		}
+
+		indicatorValue.Current = current
+		indicators[indicatorName] = indicatorValue
+	}
+	return indicators
+}
+
+// getIndicatorTargets returns system indicator targets of all pods in the region,
+// and targets of the same indicator are merged by the configured rule
+func (r *QoSRegionBase) getIndicatorTargets() types.Indicator {
+	targets := make(types.Indicator)
+	if r.metaServer == nil || r.metaServer.ServiceProfileManager == nil {
+		return targets
+	}
+
+	ctx := context.Background()
+	for podUID := range r.podSet {
+		pod, err := r.metaServer.GetPod(ctx, podUID)
+		if err != nil {
+			klog.Warningf("[qosaware-cpu] get pod %v failed: %v", podUID, err)
+			continue
+		}
+
+		spd, err := r.metaServer.GetSPD(ctx, pod)
+		if err != nil || spd == nil {
+			klog.V(4).Infof("[qosaware-cpu] get spd of pod %v/%v failed: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+
+		for _, systemIndicator := range spd.Spec.SystemIndicator {
+			indicatorName := string(systemIndicator.Name)
+			target, ok := getIndicatorTarget(systemIndicator.Indicators)
+			if !ok {
+				continue
+			}
+
+			if existing, ok := targets[indicatorName]; ok {
+				target = mergeIndicatorTarget(r.indicatorTargetMergeRule, existing, target)
+			}
+			targets[indicatorName] = target
+		}
+	}
+	return targets
+}
+
+// getIndicatorTarget converts indicator levels in spd to indicator values, and the lower
+// bound is regarded as the target since performance starts to degrade above it
+func getIndicatorTarget(indicators []workloadapis.Indicator) (types.IndicatorValue, bool) {
+	var value types.IndicatorValue
+	var hasLow, hasHigh bool
+	for _, indicator := range indicators {
+		switch indicator.IndicatorLevel {
+		case workloadapis.IndicatorLevelLowerBound:
+			value.Low, hasLow = float64(indicator.Value), true
+		case workloadapis.IndicatorLevelUpperBound:
+			value.High, hasHigh = float64(indicator.Value), true
+		}
+	}
+
+	switch {
+	case hasLow:
+		value.Target = value.Low
+	case hasHigh:
+		value.Target = value.High
+	default:
+		return value, false
+	}
+	return value, true
+}
+
+// mergeIndicatorTarget merges targets of the same indicator from two services
+func mergeIndicatorTarget(rule types.IndicatorTargetMergeRule, a, b types.IndicatorValue) types.IndicatorValue {
+	merge := math.Min
+	if rule == types.IndicatorTargetMergeRuleLoosest {
+		merge = math.Max
+	}
+
+	// zero means the level is not declared, so it shouldn't take part in merging
+	mergeLevel := func(x, y float64) float64 {
+		if x == 0 {
+			return y
+		} else if y == 0 {
+			return x
+		}
+		return merge(x, y)
+	}
+
+	return types.IndicatorValue{
+		Target: mergeLevel(a.Target, b.Target),
+		High:   mergeLevel(a.High, b.High),
+		Low:    mergeLevel(a.Low, b.Low),
+	}
+}
+
+// getRegionCPUSchedWait returns the average cpu schedule wait of cpus assigned to the region,
+// and stale metrics are skipped so that headroom won't be decided by outdated indicators
+func getRegionCPUSchedWait(r *QoSRegionBase) (float64, error) {
+	var sum float64
+	var count int
+	for _, cpuID := range r.containerTopologyAwareAssignment.MergeCPUSet().ToSliceNoSortInt() {
+		value, err := r.metaServer.GetCPUMetricWithMaxAge(cpuID, consts.MetricCPUSchedwait, r.metricsMaxAge)
+		if err != nil {
+			continue
+		}
+		sum += value
+		count++
+	}
+
+	if count == 0 {
+		return 0, fmt.Errorf("no valid %v metric", consts.MetricCPUSchedwait)
+	}
+	return sum / float64(count), nil
+}
+
+// getRegionCPI returns the average cpi of containers in the region
+func getRegionCPI(r *QoSRegionBase) (float64, error) {
+	var sum float64
+	var count int
+	for podUID, containerSet := range r.podSet {
+		for containerName := range containerSet {
+			value, err := r.metaServer.GetContainerMetricWithMaxAge(podUID, containerName, consts.MetricCPUCpiContainer, r.metricsMaxAge)
+			if err != nil {
+				continue
+			}
+			sum += value
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, fmt.Errorf("no valid %v metric", consts.MetricCPUCpiContainer)
+	}
+	return sum / float64(count), nil
+}
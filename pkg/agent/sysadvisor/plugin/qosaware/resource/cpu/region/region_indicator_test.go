@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package region
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	workloadapis "github.com/kubewharf/katalyst-api/pkg/apis/workload/v1alpha1"
+	advisortypes "github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+// fakeServiceProfileManager returns spd keyed by pod names
+type fakeServiceProfileManager struct {
+	spds map[string]*workloadapis.ServiceProfileDescriptor
+}
+
+func (f *fakeServiceProfileManager) GetSPD(_ context.Context, pod *v1.Pod) (*workloadapis.ServiceProfileDescriptor, error) {
+	spd, ok := f.spds[pod.Name]
+	if !ok {
+		return nil, fmt.Errorf("spd of pod %v not found", pod.Name)
+	}
+	return spd, nil
+}
+
+func (f *fakeServiceProfileManager) Run(_ context.Context) {}
+
+func makeTestSPD(lower, upper float32) *workloadapis.ServiceProfileDescriptor {
+	return &workloadapis.ServiceProfileDescriptor{
+		Spec: workloadapis.ServiceProfileDescriptorSpec{
+			SystemIndicator: []workloadapis.ServiceSystemIndicatorSpec{
+				{
+					Name: workloadapis.TargetIndicatorNameCPUSchedWait,
+					Indicators: []workloadapis.Indicator{
+						{IndicatorLevel: workloadapis.IndicatorLevelLowerBound, Value: lower},
+						{IndicatorLevel: workloadapis.IndicatorLevelUpperBound, Value: upper},
+					},
+				},
+			},
+		},
+	}
+}
+
+func makeTestRegionWithIndicators(rule advisortypes.IndicatorTargetMergeRule) *QoSRegionBase {
+	metricsFetcher := metric.NewFakeMetricsFetcher(metrics.DummyMetrics{}).(*metric.FakeMetricsFetcher)
+	metricsFetcher.SetCPUMetric(0, consts.MetricCPUSchedwait, 300)
+	metricsFetcher.SetCPUMetric(1, consts.MetricCPUSchedwait, 500)
+
+	var pods []*v1.Pod
+	for _, name := range []string{"pod1", "pod2", "pod3"} {
+		pods = append(pods, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)}})
+	}
+
+	r := &QoSRegionBase{
+		name:   "share",
+		podSet: advisortypes.PodSet{},
+		containerTopologyAwareAssignment: advisortypes.TopologyAwareAssignment{
+			0: machine.NewCPUSet(0, 1),
+		},
+		indicatorTargetMergeRule: rule,
+		metaServer: &metaserver.MetaServer{
+			MetaAgent: &agent.MetaAgent{
+				PodFetcher:     &pod.PodFetcherStub{PodList: pods},
+				MetricsFetcher: metricsFetcher,
+			},
+			ServiceProfileManager: &fakeServiceProfileManager{
+				spds: map[string]*workloadapis.ServiceProfileDescriptor{
+					"pod1": makeTestSPD(400, 800),
+					"pod2": makeTestSPD(200, 1000),
+				},
+			},
+		},
+	}
+	for _, p := range pods {
+		r.podSet.Insert(string(p.UID), "c")
+	}
+	return r
+}
+
+func TestGetIndicators(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		rule     advisortypes.IndicatorTargetMergeRule
+		expected advisortypes.Indicator
+	}{
+		{
+			name: "strictest target wins",
+			rule: advisortypes.IndicatorTargetMergeRuleStrictest,
+			expected: advisortypes.Indicator{
+				string(workloadapis.TargetIndicatorNameCPUSchedWait): {Current: 400, Target: 200, High: 800, Low: 200},
+			},
+		},
+		{
+			name: "loosest target wins",
+			rule: advisortypes.IndicatorTargetMergeRuleLoosest,
+			expected: advisortypes.Indicator{
+				string(workloadapis.TargetIndicatorNameCPUSchedWait): {Current: 400, Target: 400, High: 1000, Low: 400},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := makeTestRegionWithIndicators(tc.rule)
+			require.Equal(t, tc.expected, r.getIndicators())
+		})
+	}
+}
+
+func TestGetIndicatorTarget(t *testing.T) {
+	t.Parallel()
+
+	value, ok := getIndicatorTarget([]workloadapis.Indicator{
+		{IndicatorLevel: workloadapis.IndicatorLevelUpperBound, Value: 10},
+	})
+	require.True(t, ok)
+	require.Equal(t, advisortypes.IndicatorValue{Target: 10, High: 10}, value)
+
+	_, ok = getIndicatorTarget(nil)
+	require.False(t, ok)
+}
@@ -55,7 +55,8 @@ var resourceMetricsToGather = map[v1.ResourceName][]string{
 }
 
 // EstimateContainerResourceUsage used to estimate non-reclaimed pods resources usage.
-// If reclaim disabled or metrics missed, resource usage will be regarded as Pod resource requests.
+// If reclaim disabled or metrics missed (including stale ones), resource usage will be
+// regarded as Pod resource requests.
 func EstimateContainerResourceUsage(ci *types.ContainerInfo, resourceName v1.ResourceName,
 	metaReader metacache.MetaReader, reclaimEnable bool) (float64, error) {
 	if ci.QoSLevel != apiconsts.PodAnnotationQoSLevelSharedCores && ci.QoSLevel != apiconsts.PodAnnotationQoSLevelDedicatedCores {
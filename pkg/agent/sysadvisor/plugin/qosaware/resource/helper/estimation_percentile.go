@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+)
+
+// resourceUsageHistograms are the container usage histograms for resource estimation
+var resourceUsageHistograms = map[v1.ResourceName]types.ContainerHistogramName{
+	v1.ResourceCPU:    types.ContainerHistogramNameCPUUsage,
+	v1.ResourceMemory: types.ContainerHistogramNameMemoryUsage,
+}
+
+// EstimateContainerResourceUsageByPercentile estimates non-reclaimed container resource usage as the given
+// percentile of its usage history plus a safety margin. If reclaim disabled or usage history is missing
+// (e.g. newly created containers), it falls back to EstimateContainerResourceUsage.
+func EstimateContainerResourceUsageByPercentile(ci *types.ContainerInfo, resourceName v1.ResourceName,
+	metaReader metacache.MetaReader, reclaimEnable bool, percentile, safetyMarginRatio float64) (float64, error) {
+	if ci.QoSLevel != apiconsts.PodAnnotationQoSLevelSharedCores && ci.QoSLevel != apiconsts.PodAnnotationQoSLevelDedicatedCores {
+		return 0, nil
+	}
+	if !reclaimEnable {
+		return EstimateContainerResourceUsage(ci, resourceName, metaReader, reclaimEnable)
+	}
+
+	histogramName, ok := resourceUsageHistograms[resourceName]
+	if !ok {
+		return 0, fmt.Errorf("failed to find usage histogram for %v", resourceName)
+	}
+	if metaReader == nil {
+		return 0, fmt.Errorf("metaCache nil")
+	}
+
+	h, ok := metaReader.GetContainerHistogram(ci.PodUID, ci.ContainerName, histogramName)
+	if !ok || h.IsEmpty() {
+		klog.Infof("[qosaware-percentile] pod %v container %v histogram %v not found, fall back to canonical estimation",
+			ci.PodName, ci.ContainerName, histogramName)
+		return EstimateContainerResourceUsage(ci, resourceName, metaReader, reclaimEnable)
+	}
+
+	estimation := h.Percentile(percentile) * (1 + safetyMarginRatio)
+	klog.Infof("[qosaware-percentile] pod %v container %v histogram %v percentile %v estimation %.2f",
+		ci.PodName, ci.ContainerName, histogramName, percentile, estimation)
+	return estimation, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"math"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+)
+
+// PIDController calculates the adjustment of a control knob (e.g. cpu requirement)
+// to drive an indicator towards its target, and the positive adjustment means the
+// indicator is higher than its target and more resource should be provided.
+type PIDController struct {
+	params types.FirstOrderPIDParams
+
+	errorValue     float64
+	errorValuePrev float64
+	integral       float64
+}
+
+// NewPIDController returns a pid controller with the given parameters
+func NewPIDController(params types.FirstOrderPIDParams) *PIDController {
+	return &PIDController{
+		params: params,
+	}
+}
+
+// Adjust runs an episode of pid control and returns the adjustment of the control knob
+func (c *PIDController) Adjust(target, current float64) float64 {
+	c.errorValuePrev = c.errorValue
+	if c.inDeadband(target, current) {
+		c.errorValue = 0
+		return 0
+	}
+	c.errorValue = relativeError(target, current)
+
+	var kp, kd float64
+	if c.errorValue >= 0 {
+		kp = c.params.Kpp
+	} else {
+		kp = c.params.Kpn
+	}
+
+	errorDelta := c.errorValue - c.errorValuePrev
+	if errorDelta >= 0 {
+		kd = c.params.Kdp
+	} else {
+		kd = c.params.Kdn
+	}
+
+	integral := general.Clamp(c.integral+c.errorValue, c.params.IntegralLowerBound, c.params.IntegralUpperBound)
+	adjustment := kp*c.errorValue + kd*errorDelta + c.params.Ki*integral
+	bounded := general.Clamp(adjustment, c.params.AdjustmentLowerBound, c.params.AdjustmentUpperBound)
+
+	// conditional integration for anti-windup: the error is not accumulated if the
+	// adjustment is saturated and the error would push it further into saturation
+	if bounded == adjustment || math.Signbit(c.errorValue) != math.Signbit(adjustment) {
+		c.integral = integral
+	}
+
+	return bounded
+}
+
+// Reset clears the accumulated states of the controller
+func (c *PIDController) Reset() {
+	c.errorValue = 0
+	c.errorValuePrev = 0
+	c.integral = 0
+}
+
+func (c *PIDController) inDeadband(target, current float64) bool {
+	return current >= target*(1-c.params.DeadbandLowerPct) && current <= target*(1+c.params.DeadbandUpperPct)
+}
+
+// relativeError returns the error relative to the target, and the absolute error
+// is returned if the target is zero
+func relativeError(target, current float64) float64 {
+	if target == 0 {
+		return current
+	}
+	return (current - target) / math.Abs(target)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+)
+
+func TestPIDController(t *testing.T) {
+	t.Parallel()
+
+	controller := NewPIDController(types.FirstOrderPIDParams{
+		Kpp:                  2,
+		Kpn:                  1,
+		Kdp:                  1,
+		Kdn:                  0,
+		Ki:                   1,
+		AdjustmentUpperBound: 3,
+		AdjustmentLowerBound: -1,
+		DeadbandUpperPct:     0.1,
+		DeadbandLowerPct:     0.1,
+		IntegralUpperBound:   2,
+		IntegralLowerBound:   -2,
+	})
+
+	// within deadband
+	require.Equal(t, float64(0), controller.Adjust(100, 105))
+
+	// error 0.5: 2*0.5 + 1*0.5 + 1*0.5
+	require.InDelta(t, 2.0, controller.Adjust(100, 150), 1e-9)
+	require.InDelta(t, 0.5, controller.integral, 1e-9)
+
+	// saturated at upper bound, and the integral is frozen to avoid windup
+	require.Equal(t, float64(3), controller.Adjust(100, 300))
+	require.InDelta(t, 0.5, controller.integral, 1e-9)
+
+	// negative error unwinds the integral immediately: -1*0.5 + 0 + 1*(0.5-0.5)
+	require.InDelta(t, -0.5, controller.Adjust(100, 50), 1e-9)
+	require.InDelta(t, 0, controller.integral, 1e-9)
+
+	// saturated at lower bound
+	require.Equal(t, float64(-1), controller.Adjust(100, 0))
+
+	controller.Reset()
+	require.Equal(t, float64(0), controller.integral)
+}
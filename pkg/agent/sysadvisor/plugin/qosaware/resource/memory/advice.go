@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/memoryadvisor"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+)
+
+// calculateMemoryAdvices caps file cache of reclaimed_cores containers by memory.high, and
+// drops their cache on numa nodes triggered by provision policies (i.e. numa nodes whose free
+// memory falls below the threshold), so that page cache of online containers on the same numa
+// nodes won't be squeezed out.
+func (ra *memoryResourceAdvisor) calculateMemoryAdvices(dropCacheNumas sets.Int) map[string]map[string]map[string]string {
+	advices := make(map[string]map[string]map[string]string)
+
+	cacheLimit := ra.conf.ReclaimedCacheLimitBytes
+	if cacheLimit == 0 && dropCacheNumas.Len() == 0 {
+		return advices
+	}
+
+	f := func(podUID string, containerName string, ci *types.ContainerInfo) bool {
+		if ci.QoSLevel != apiconsts.PodAnnotationQoSLevelReclaimedCores {
+			return true
+		}
+
+		values := make(map[string]string)
+		if cacheLimit > 0 {
+			rss, err := ra.metaReader.GetContainerMetric(podUID, containerName, consts.MetricMemRssContainer)
+			if err != nil {
+				klog.Warningf("[qosaware-memory] get rss of pod %v container %v failed: %v", ci.PodName, containerName, err)
+			} else {
+				values[memoryadvisor.ControlKnobKeyMemoryHigh] = strconv.FormatInt(int64(rss)+int64(cacheLimit), 10)
+			}
+		}
+
+		// cache is dropped per numa node, so that the plugin can reclaim page cache on the
+		// numa nodes under pressure instead of the ones happened to be scanned first
+		for _, numaID := range dropCacheNumas.List() {
+			cache, err := ra.metaServer.GetContainerNumaMetric(podUID, containerName, strconv.Itoa(numaID),
+				consts.MetricsMemFilePerNumaContainer)
+			if err != nil {
+				klog.Warningf("[qosaware-memory] get cache of pod %v container %v on numa %v failed: %v",
+					ci.PodName, containerName, numaID, err)
+				continue
+			}
+			if cache > 0 {
+				values[memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyDropCache, numaID)] = strconv.FormatInt(int64(cache), 10)
+			}
+		}
+
+		if len(values) > 0 {
+			if advices[podUID] == nil {
+				advices[podUID] = make(map[string]map[string]string)
+			}
+			advices[podUID][containerName] = values
+			klog.Infof("[qosaware-memory] pod %v container %v memory advices: %v", ci.PodName, containerName, values)
+		}
+		return true
+	}
+	ra.metaReader.RangeContainer(f)
+
+	return advices
+}
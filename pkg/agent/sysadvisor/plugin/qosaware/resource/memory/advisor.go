@@ -19,37 +19,61 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/cpu/dynamicpolicy/state"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/memoryadvisor"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/memory/headroompolicy"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/memory/provisionpolicy"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
 
 func init() {
 	headroompolicy.RegisterInitializer(types.MemoryHeadroomPolicyCanonical, headroompolicy.NewPolicyCanonical)
+	headroompolicy.RegisterInitializer(types.MemoryHeadroomPolicyPercentile, headroompolicy.NewPolicyPercentile)
+
+	provisionpolicy.RegisterInitializer(types.MemoryProvisionPolicyCanonical, provisionpolicy.NewPolicyCanonical)
 }
 
 const (
 	startUpPeriod time.Duration = 30 * time.Second
 )
 
-// memoryResourceAdvisor updates memory headroom for reclaimed resource
+// InternalCalculationResult conveys memory advices of containers and pools from memory advisor to memory server
+type InternalCalculationResult struct {
+	ContainerEntries map[string]map[string]map[string]string // map[podUID][containerName][controlKnob]value
+	PoolEntries      map[string]map[string]string            // map[poolName][controlKnob]value
+}
+
+// memoryResourceAdvisor updates memory headroom for reclaimed resource,
+// and calculates memory advices to be executed by memory plugin
 type memoryResourceAdvisor struct {
 	conf            *config.Configuration
 	startTime       time.Time
 	headroomPolices []headroompolicy.HeadroomPolicy
 	mutex           sync.RWMutex
+	sendCh          chan InternalCalculationResult
+
+	// regions are keyed by numa id, and memory of each numa is provisioned separately
+	regions   map[int]*memoryRegion
+	extraConf interface{}
 
 	metaReader metacache.MetaReader
 	metaServer *metaserver.MetaServer
@@ -63,6 +87,10 @@ func NewMemoryResourceAdvisor(conf *config.Configuration, extraConf interface{},
 		startTime: time.Now(),
 
 		headroomPolices: make([]headroompolicy.HeadroomPolicy, 0),
+		sendCh:          make(chan InternalCalculationResult, 1),
+
+		regions:   make(map[int]*memoryRegion),
+		extraConf: extraConf,
 
 		conf:       conf,
 		metaReader: metaCache,
@@ -91,9 +119,9 @@ func (ra *memoryResourceAdvisor) Run(ctx context.Context) {
 	}, period, ctx.Done())
 }
 
+// GetChannels returns a nil receive channel since memory advisor updates periodically by itself
 func (ra *memoryResourceAdvisor) GetChannels() (interface{}, interface{}) {
-	klog.Warningf("[qosaware-memory] get channel is not supported")
-	return nil, nil
+	return nil, ra.sendCh
 }
 
 func (ra *memoryResourceAdvisor) GetHeadroom() (resource.Quantity, error) {
@@ -112,6 +140,58 @@ func (ra *memoryResourceAdvisor) GetHeadroom() (resource.Quantity, error) {
 	return resource.Quantity{}, fmt.Errorf("failed to get valid headroom")
 }
 
+// GetNumaHeadroom estimates headroom of each numa node as its free memory plus memory used by
+// reclaimed_cores containers on it, since reclaimed memory on a numa can't exceed it; and headroom
+// of numas is scaled down in proportion if the sum of them exceeds the node headroom.
+func (ra *memoryResourceAdvisor) GetNumaHeadroom() (map[int]resource.Quantity, error) {
+	headroom, err := ra.GetHeadroom()
+	if err != nil {
+		return nil, err
+	}
+
+	numaReleasable := make(map[int]float64)
+	for _, node := range ra.metaServer.MachineInfo.Topology {
+		free, err := ra.metaServer.GetNumaMetric(node.Id, consts.MetricMemFreeNuma)
+		if err != nil {
+			return nil, fmt.Errorf("get free memory of numa %v failed: %v", node.Id, err)
+		}
+		numaReleasable[node.Id] = free
+	}
+
+	ra.metaReader.RangeContainer(func(podUID string, containerName string, ci *types.ContainerInfo) bool {
+		if ci.QoSLevel != apiconsts.PodAnnotationQoSLevelReclaimedCores {
+			return true
+		}
+
+		for numaID := range numaReleasable {
+			used, err := ra.metaServer.GetContainerNumaMetric(podUID, containerName, strconv.Itoa(numaID), consts.MetricsMemTotalPerNumaContainer)
+			if err != nil {
+				klog.V(4).Infof("[qosaware-memory] get %v of pod %v container %v numa %v failed: %v",
+					consts.MetricsMemTotalPerNumaContainer, ci.PodName, containerName, numaID, err)
+				continue
+			}
+			numaReleasable[numaID] += used
+		}
+		return true
+	})
+
+	totalReleasable := 0.0
+	for _, releasable := range numaReleasable {
+		totalReleasable += releasable
+	}
+	ratio := 1.0
+	if nodeHeadroom := headroom.AsApproximateFloat64(); totalReleasable > nodeHeadroom {
+		ratio = nodeHeadroom / totalReleasable
+	}
+
+	numaHeadroom := make(map[int]resource.Quantity)
+	for numaID, releasable := range numaReleasable {
+		numaHeadroom[numaID] = *resource.NewQuantity(int64(releasable*ratio), resource.DecimalSI)
+	}
+
+	return numaHeadroom, nil
+}
+
 func (ra *memoryResourceAdvisor) update() {
 	ra.mutex.Lock()
 	defer ra.mutex.Unlock()
@@ -122,6 +202,13 @@ func (ra *memoryResourceAdvisor) update() {
 		return
 	}
 
+	// pods served from apiserver may lag behind the node, so the last advices are kept
+	// instead of shrinking or dropping cache of reclaimed_cores based on them
+	if stale, err := pod.HasStalePods(context.Background(), ra.metaServer); err != nil || stale {
+		klog.Warningf("[qosaware-memory] skip update: pods are stale or unavailable, err: %v", err)
+		return
+	}
+
 	// Check if essential pool info exists. Skip update if not in which case sysadvisor
 	// is ignorant of pools and containers
 	reservePoolInfo, ok := ra.metaReader.GetPoolInfo(state.PoolNameReserve)
@@ -133,16 +220,111 @@ func (ra *memoryResourceAdvisor) update() {
 	reservedForAllocate := ra.conf.ReclaimedResourceConfiguration.
 		ReservedResourceForAllocate()[v1.ResourceMemory]
 
+	// capacity and reserved can both be adjusted dynamically during running process
+	essentials := types.ResourceEssentials{
+		Total:               int(ra.metaServer.MemoryCapacity),
+		ReservedForAllocate: int(reservedForAllocate.Value()),
+		EnableReclaim:       ra.conf.ReclaimedResourceConfiguration.EnableReclaim(),
+	}
+
 	for _, headroomPolicy := range ra.headroomPolices {
-		// capacity and reserved can both be adjusted dynamically during running process
-		headroomPolicy.SetEssentials(types.ResourceEssentials{
-			Total:               int(ra.metaServer.MemoryCapacity),
-			ReservedForAllocate: int(reservedForAllocate.Value()),
-			EnableReclaim:       ra.conf.ReclaimedResourceConfiguration.EnableReclaim(),
-		})
+		headroomPolicy.SetEssentials(essentials)
 
 		if err := headroomPolicy.Update(); err != nil {
 			klog.Errorf("[qosaware-memory] update headroom policy failed: %v", err)
 		}
 	}
+
+	dropCacheNumas, poolEntries := ra.updateProvision(essentials)
+	result := InternalCalculationResult{
+		ContainerEntries: ra.calculateMemoryAdvices(dropCacheNumas),
+		PoolEntries:      poolEntries,
+	}
+
+	// memory server may not be listening, so the result is buffered without blocking; and the pending
+	// result not received yet is stale, so it's replaced by the latest one. update is only called
+	// in a single goroutine, thus the buffer won't be filled by others between draining and sending.
+	select {
+	case <-ra.sendCh:
+		klog.V(4).Infof("[qosaware-memory] replace pending memory advices with the latest ones")
+	default:
+	}
+	ra.sendCh <- result
+}
+
+// updateProvision updates provision policies of each numa region, and returns numa nodes on which cache
+// of reclaimed_cores containers should be dropped, along with memory advices of reclaim pool; memory limit
+// of each numa node is advised by its region, while the total memory limit of reclaim pool is only advised
+// if provisions of all regions are valid
+func (ra *memoryResourceAdvisor) updateProvision(essentials types.ResourceEssentials) (sets.Int, map[string]map[string]string) {
+	ra.updateRegions()
+
+	numaIDs := make([]int, 0, len(ra.regions))
+	for numaID := range ra.regions {
+		numaIDs = append(numaIDs, numaID)
+	}
+	sort.Ints(numaIDs)
+
+	var (
+		dropCacheNumas = sets.NewInt()
+		poolAdvices    = make(map[string]string)
+		totalLimit     float64
+		totalValid     = len(numaIDs) > 0
+	)
+	for _, numaID := range numaIDs {
+		r := ra.regions[numaID]
+		r.tryUpdateProvision(essentials)
+
+		controlKnob, err := r.getProvision()
+		if err != nil {
+			klog.Warningf("[qosaware-memory] get provision of region %v failed: %v", r.name, err)
+			totalValid = false
+			continue
+		}
+
+		if dropCache, ok := controlKnob[types.ControlKnobReclaimedMemoryDropCache]; ok && dropCache.Value > 0 {
+			dropCacheNumas.Insert(numaID)
+		}
+
+		limit, ok := controlKnob[types.ControlKnobReclaimedMemoryLimit]
+		if !ok {
+			totalValid = false
+			continue
+		}
+		poolAdvices[memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyMemoryLimit, numaID)] =
+			strconv.FormatInt(int64(limit.Value), 10)
+		totalLimit += limit.Value
+	}
+
+	if totalValid {
+		poolAdvices[memoryadvisor.ControlKnobKeyMemoryLimit] = strconv.FormatInt(int64(totalLimit), 10)
+	}
+
+	poolEntries := make(map[string]map[string]string)
+	if len(poolAdvices) > 0 {
+		klog.Infof("[qosaware-memory] reclaim pool memory advices: %v", poolAdvices)
+		poolEntries[state.PoolNameReclaim] = poolAdvices
+	}
+
+	return dropCacheNumas, poolEntries
+}
+
+// updateRegions creates a region for each numa node, and removes regions of numa nodes no longer exist
+func (ra *memoryResourceAdvisor) updateRegions() {
+	numas := sets.NewInt()
+	for _, node := range ra.metaServer.MachineInfo.Topology {
+		numas.Insert(node.Id)
+		if _, ok := ra.regions[node.Id]; ok {
+			continue
+		}
+
+		ra.regions[node.Id] = newMemoryRegion(fmt.Sprintf("numa-%d", node.Id), machine.NewCPUSet(node.Id),
+			ra.conf, ra.extraConf, ra.metaReader, ra.metaServer, ra.emitter)
+	}
+
+	for numaID := range ra.regions {
+		if !numas.Has(numaID) {
+			delete(ra.regions, numaID)
+		}
+	}
 }
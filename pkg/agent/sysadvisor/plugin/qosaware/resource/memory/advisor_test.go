@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/kubewharf/katalyst-api/pkg/consts"
@@ -37,12 +38,15 @@ import (
 	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
 	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/cpu/dynamicpolicy/state"
 	qrmstate "github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/cpu/dynamicpolicy/state"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/memoryadvisor"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config"
+	coreconsts "github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
@@ -106,6 +110,7 @@ func newTestMemoryAdvisor(t *testing.T, checkpointDir, stateFileDir string) (*me
 				MemoryCapacity: 1000 << 30,
 			},
 		},
+		PodFetcher: &pod.PodFetcherStub{},
 	}
 
 	mra := NewMemoryResourceAdvisor(conf, struct{}{}, metaCache, metaServer, nil)
@@ -263,3 +268,226 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+func newTestMemoryAdvisorWithMetrics(t *testing.T, checkpointDir, stateFileDir string,
+	topology []info.Node) (*memoryResourceAdvisor, metacache.MetaCache, *metric.FakeMetricsFetcher) {
+	conf := generateTestConfiguration(t, checkpointDir, stateFileDir)
+	conf.ReclaimedCacheLimitBytes = 1 << 30
+	conf.DropCacheNumaFreeRatioThreshold = 0.1
+
+	metricsFetcher := metric.NewFakeMetricsFetcher(metrics.DummyMetrics{}).(*metric.FakeMetricsFetcher)
+	metaCache, err := metacache.NewMetaCacheImp(conf, metricsFetcher)
+	require.NoError(t, err)
+
+	genericCtx, err := katalyst_base.GenerateFakeGenericContext([]runtime.Object{})
+	require.NoError(t, err)
+	metaServer, err := metaserver.NewMetaServer(genericCtx.Client, metrics.DummyMetrics{}, conf)
+	require.NoError(t, err)
+
+	metaServer.MetaAgent = &agent.MetaAgent{
+		KatalystMachineInfo: &machine.KatalystMachineInfo{
+			MachineInfo: &info.MachineInfo{
+				MemoryCapacity: 1000 << 30,
+				Topology:       topology,
+			},
+		},
+		MetricsFetcher: metricsFetcher,
+		PodFetcher:     &pod.PodFetcherStub{},
+	}
+
+	mra := NewMemoryResourceAdvisor(conf, struct{}{}, metaCache, metaServer, nil)
+	require.NotNil(t, mra)
+
+	return mra, metaCache, metricsFetcher
+}
+
+func TestCalculateMemoryAdvices(t *testing.T) {
+	ckDir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(ckDir)
+
+	sfDir, err := ioutil.TempDir("", "statefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(sfDir)
+
+	advisor, metaCache, metricsFetcher := newTestMemoryAdvisorWithMetrics(t, ckDir, sfDir, []info.Node{
+		{Id: 0, Memory: 100 << 30},
+		{Id: 1, Memory: 100 << 30},
+	})
+
+	containers := []*types.ContainerInfo{
+		makeContainerInfo("uid1", "default", "pod1", "c1", consts.PodAnnotationQoSLevelReclaimedCores, nil, nil, 0),
+		makeContainerInfo("uid2", "default", "pod2", "c2", consts.PodAnnotationQoSLevelSharedCores, nil, nil, 0),
+	}
+	for _, c := range containers {
+		require.NoError(t, metaCache.SetContainerInfo(c.PodUID, c.ContainerName, c))
+	}
+
+	// numa 0 is under memory pressure
+	metricsFetcher.SetNumaMetric(0, coreconsts.MetricMemFreeNuma, 5<<30)
+	metricsFetcher.SetNumaMetric(1, coreconsts.MetricMemFreeNuma, 50<<30)
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemRssContainer, 2<<30)
+	metricsFetcher.SetContainerNumaMetric("uid1", "c1", "0", coreconsts.MetricsMemFilePerNumaContainer, 3<<30)
+	metricsFetcher.SetContainerNumaMetric("uid1", "c1", "1", coreconsts.MetricsMemFilePerNumaContainer, 1<<30)
+	metricsFetcher.SetContainerMetric("uid2", "c2", coreconsts.MetricMemRssContainer, 2<<30)
+
+	// numa 0 is triggered to drop cache by provision policy
+	dropCacheNumas, poolEntries := advisor.updateProvision(types.ResourceEssentials{EnableReclaim: true})
+	assert.Equal(t, []int{0}, dropCacheNumas.List())
+	assert.Equal(t, map[string]map[string]string{
+		state.PoolNameReclaim: {
+			memoryadvisor.ControlKnobKeyMemoryLimit:                                         fmt.Sprintf("%d", 45<<30),
+			memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyMemoryLimit, 0): "0",
+			memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyMemoryLimit, 1): fmt.Sprintf("%d", 45<<30),
+		},
+	}, poolEntries)
+
+	advices := advisor.calculateMemoryAdvices(dropCacheNumas)
+	assert.Equal(t, map[string]map[string]map[string]string{
+		"uid1": {
+			"c1": {
+				memoryadvisor.ControlKnobKeyMemoryHigh:                                        fmt.Sprintf("%d", 3<<30),
+				memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyDropCache, 0): fmt.Sprintf("%d", 3<<30),
+			},
+		},
+	}, advices)
+}
+
+func TestUpdateWithReleasableCache(t *testing.T) {
+	ckDir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(ckDir)
+
+	sfDir, err := ioutil.TempDir("", "statefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(sfDir)
+
+	advisor, metaCache, metricsFetcher := newTestMemoryAdvisorWithMetrics(t, ckDir, sfDir, nil)
+	advisor.startTime = time.Now().Add(-startUpPeriod * 2)
+	advisor.conf.ReclaimedResourceConfiguration.SetEnableReclaim(true)
+
+	require.NoError(t, metaCache.SetPoolInfo(state.PoolNameReserve, &types.PoolInfo{PoolName: state.PoolNameReserve}))
+	c := makeContainerInfo("uid1", "default", "pod1", "c1", consts.PodAnnotationQoSLevelReclaimedCores, nil, nil, 0)
+	require.NoError(t, metaCache.SetContainerInfo(c.PodUID, c.ContainerName, c))
+
+	metricsFetcher.SetNodeMetric(coreconsts.MetricMemFreeSystem, 10<<30)
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemRssContainer, 2<<30)
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemCacheContainer, 4<<30)
+
+	advisor.update()
+
+	// headroom is bounded by free memory and memory used by reclaimed_cores containers
+	headroom, err := advisor.GetHeadroom()
+	require.NoError(t, err)
+	assert.Equal(t, int64(16<<30), headroom.Value())
+}
+
+func TestUpdateReplacesPendingResult(t *testing.T) {
+	ckDir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(ckDir)
+
+	sfDir, err := ioutil.TempDir("", "statefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(sfDir)
+
+	advisor, metaCache, metricsFetcher := newTestMemoryAdvisorWithMetrics(t, ckDir, sfDir, nil)
+	advisor.startTime = time.Now().Add(-startUpPeriod * 2)
+
+	require.NoError(t, metaCache.SetPoolInfo(state.PoolNameReserve, &types.PoolInfo{PoolName: state.PoolNameReserve}))
+	c := makeContainerInfo("uid1", "default", "pod1", "c1", consts.PodAnnotationQoSLevelReclaimedCores, nil, nil, 0)
+	require.NoError(t, metaCache.SetContainerInfo(c.PodUID, c.ContainerName, c))
+
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemRssContainer, 1<<30)
+	advisor.update()
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemRssContainer, 2<<30)
+	advisor.update()
+
+	// only the latest result is pending without any receiver
+	_, sendChInterface := advisor.GetChannels()
+	sendCh := sendChInterface.(chan InternalCalculationResult)
+	require.Equal(t, 1, len(sendCh))
+	result := <-sendCh
+	assert.Equal(t, fmt.Sprintf("%d", 3<<30), result.ContainerEntries["uid1"]["c1"][memoryadvisor.ControlKnobKeyMemoryHigh])
+}
+
+func TestUpdateSkippedWithStalePods(t *testing.T) {
+	ckDir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(ckDir)
+
+	sfDir, err := ioutil.TempDir("", "statefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(sfDir)
+
+	advisor, metaCache, metricsFetcher := newTestMemoryAdvisorWithMetrics(t, ckDir, sfDir, nil)
+	advisor.startTime = time.Now().Add(-startUpPeriod * 2)
+
+	require.NoError(t, metaCache.SetPoolInfo(state.PoolNameReserve, &types.PoolInfo{PoolName: state.PoolNameReserve}))
+	c := makeContainerInfo("uid1", "default", "pod1", "c1", consts.PodAnnotationQoSLevelReclaimedCores, nil, nil, 0)
+	require.NoError(t, metaCache.SetContainerInfo(c.PodUID, c.ContainerName, c))
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemRssContainer, 1<<30)
+
+	// no advices are made if pods are served from apiserver
+	podFetcher := advisor.metaServer.PodFetcher.(*pod.PodFetcherStub)
+	podFetcher.PodList = []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:         "uid1",
+			Annotations: map[string]string{pod.PodAnnotationStaleKey: pod.PodAnnotationStaleValue},
+		},
+	}}
+	advisor.update()
+	_, sendChInterface := advisor.GetChannels()
+	sendCh := sendChInterface.(chan InternalCalculationResult)
+	require.Equal(t, 0, len(sendCh))
+
+	podFetcher.PodList[0].Annotations = nil
+	advisor.update()
+	require.Equal(t, 1, len(sendCh))
+}
+
+func TestGetNumaHeadroom(t *testing.T) {
+	ckDir, err := ioutil.TempDir("", "checkpoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(ckDir)
+
+	sfDir, err := ioutil.TempDir("", "statefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(sfDir)
+
+	advisor, metaCache, metricsFetcher := newTestMemoryAdvisorWithMetrics(t, ckDir, sfDir, []info.Node{
+		{Id: 0, Memory: 100 << 30},
+		{Id: 1, Memory: 100 << 30},
+	})
+	advisor.startTime = time.Now().Add(-startUpPeriod * 2)
+	advisor.conf.ReclaimedResourceConfiguration.SetEnableReclaim(true)
+
+	require.NoError(t, metaCache.SetPoolInfo(state.PoolNameReserve, &types.PoolInfo{PoolName: state.PoolNameReserve}))
+	c := makeContainerInfo("uid1", "default", "pod1", "c1", consts.PodAnnotationQoSLevelReclaimedCores, nil, nil, 0)
+	require.NoError(t, metaCache.SetContainerInfo(c.PodUID, c.ContainerName, c))
+
+	metricsFetcher.SetNodeMetric(coreconsts.MetricMemFreeSystem, 10<<30)
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemRssContainer, 2<<30)
+	metricsFetcher.SetContainerMetric("uid1", "c1", coreconsts.MetricMemCacheContainer, 4<<30)
+	metricsFetcher.SetNumaMetric(0, coreconsts.MetricMemFreeNuma, 2<<30)
+	metricsFetcher.SetNumaMetric(1, coreconsts.MetricMemFreeNuma, 6<<30)
+	metricsFetcher.SetContainerNumaMetric("uid1", "c1", "0", coreconsts.MetricsMemTotalPerNumaContainer, 4<<30)
+	metricsFetcher.SetContainerNumaMetric("uid1", "c1", "1", coreconsts.MetricsMemTotalPerNumaContainer, 2<<30)
+
+	// numa headroom is free memory plus memory used by reclaimed_cores containers on the numa
+	advisor.update()
+	numaHeadroom, err := advisor.GetNumaHeadroom()
+	require.NoError(t, err)
+	numa0, numa1 := numaHeadroom[0], numaHeadroom[1]
+	assert.Equal(t, int64(6<<30), numa0.Value())
+	assert.Equal(t, int64(8<<30), numa1.Value())
+
+	// numa headroom is scaled down if the sum exceeds node headroom
+	metricsFetcher.SetNodeMetric(coreconsts.MetricMemFreeSystem, 1<<30)
+	advisor.update()
+	numaHeadroom, err = advisor.GetNumaHeadroom()
+	require.NoError(t, err)
+	numa0, numa1 = numaHeadroom[0], numaHeadroom[1]
+	assert.Equal(t, int64(3<<30), numa0.Value())
+	assert.Equal(t, int64(4<<30), numa1.Value())
+}
@@ -17,35 +17,48 @@ limitations under the License.
 package headroompolicy
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
-	"k8s.io/apimachinery/pkg/util/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/helper"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
 )
 
 type PolicyCanonical struct {
 	*PolicyBase
 
+	// cacheAdviceEnabled indicates whether cache of reclaimed_cores containers is limited
+	// or dropped by memory advices, and then it can be released for reclaimed resource
+	cacheAdviceEnabled bool
+	// metricsMaxAge is the max age of node metrics used to estimate releasable memory
+	metricsMaxAge time.Duration
+
 	// memoryHeadroom is valid to be used iff updateStatus successes
 	memoryHeadroom float64
 	updateStatus   types.PolicyUpdateStatus
 }
 
-func NewPolicyCanonical(_ *config.Configuration, _ interface{}, metaReader metacache.MetaReader,
+func NewPolicyCanonical(conf *config.Configuration, _ interface{}, metaReader metacache.MetaReader,
 	metaServer *metaserver.MetaServer, _ metrics.MetricEmitter) HeadroomPolicy {
 	p := PolicyCanonical{
-		PolicyBase:   NewPolicyBase(metaReader, metaServer),
-		updateStatus: types.PolicyUpdateFailed,
+		PolicyBase:         NewPolicyBase(metaReader, metaServer),
+		updateStatus:       types.PolicyUpdateFailed,
+		cacheAdviceEnabled: conf.ReclaimedCacheLimitBytes > 0 || conf.DropCacheNumaFreeRatioThreshold > 0,
+		metricsMaxAge:      conf.MetricsMaxAge,
 	}
 
 	return &p
@@ -85,9 +98,56 @@ func (p *PolicyCanonical) Update() (err error) {
 	p.metaReader.RangeContainer(f)
 	klog.Infof("[qosaware-memory-headroom] memory requirement estimation: %.2e, #container %v", memoryEstimation, containerCnt)
 
-	p.memoryHeadroom = math.Max(float64(p.essentials.Total-p.essentials.ReservedForAllocate)-memoryEstimation, 0)
+	memoryHeadroom := math.Max(float64(p.essentials.Total-p.essentials.ReservedForAllocate)-memoryEstimation, 0)
+
+	if p.cacheAdviceEnabled && p.essentials.EnableReclaim {
+		releasable, err := p.estimateReleasableMemory()
+		if err != nil {
+			// freeze headroom as the last valid one rather than acting on stale metrics
+			if errors.Is(err, metric.ErrMetricDataStale) && p.updateStatus == types.PolicyUpdateSucceeded {
+				klog.Warningf("[qosaware-memory-headroom] freeze headroom %.2e: %v", p.memoryHeadroom, err)
+				return utilerrors.NewAggregate(errList)
+			}
+			errList = append(errList, err)
+		} else {
+			klog.Infof("[qosaware-memory-headroom] releasable memory estimation: %.2e", releasable)
+			memoryHeadroom = math.Min(memoryHeadroom, releasable)
+		}
+	}
+	p.memoryHeadroom = memoryHeadroom
+
+	return utilerrors.NewAggregate(errList)
+}
+
+// estimateReleasableMemory returns free memory plus memory used by reclaimed_cores containers, including
+// their page cache which can be released by memory advices; page cache of online containers is excluded,
+// so that reclaimed resource won't be reported at the cost of it.
+func (p *PolicyCanonical) estimateReleasableMemory() (float64, error) {
+	free, err := p.metaServer.GetNodeMetricWithMaxAge(consts.MetricMemFreeSystem, p.metricsMaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("get %v failed: %w", consts.MetricMemFreeSystem, err)
+	}
+
+	releasable := free
+	f := func(podUID string, containerName string, ci *types.ContainerInfo) bool {
+		if ci.QoSLevel != apiconsts.PodAnnotationQoSLevelReclaimedCores {
+			return true
+		}
+
+		for _, metricName := range []string{consts.MetricMemRssContainer, consts.MetricMemCacheContainer} {
+			value, err := p.metaReader.GetContainerMetric(podUID, containerName, metricName)
+			if err != nil {
+				klog.Warningf("[qosaware-memory-headroom] get %v of pod %v container %v failed: %v",
+					metricName, ci.PodName, containerName, err)
+				continue
+			}
+			releasable += value
+		}
+		return true
+	}
+	p.metaReader.RangeContainer(f)
 
-	return errors.NewAggregate(errList)
+	return releasable, nil
 }
 
 func (p *PolicyCanonical) GetHeadroom() (resource.Quantity, error) {
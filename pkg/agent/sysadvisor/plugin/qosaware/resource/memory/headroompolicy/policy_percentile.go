@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package headroompolicy
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/helper"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+)
+
+// PolicyPercentile regards a percentile of memory usage history plus a safety margin as
+// the requirement of each container, so that headroom is stable against usage spikes
+type PolicyPercentile struct {
+	*PolicyBase
+
+	percentile        float64
+	safetyMarginRatio float64
+
+	// memoryHeadroom is valid to be used iff updateStatus successes
+	memoryHeadroom float64
+	updateStatus   types.PolicyUpdateStatus
+}
+
+func NewPolicyPercentile(conf *config.Configuration, _ interface{}, metaReader metacache.MetaReader,
+	metaServer *metaserver.MetaServer, _ metrics.MetricEmitter) HeadroomPolicy {
+	p := PolicyPercentile{
+		PolicyBase:        NewPolicyBase(metaReader, metaServer),
+		percentile:        conf.MemoryHeadroomPolicyPercentileConfiguration.Percentile,
+		safetyMarginRatio: conf.MemoryHeadroomPolicyPercentileConfiguration.SafetyMarginRatio,
+		updateStatus:      types.PolicyUpdateFailed,
+	}
+
+	return &p
+}
+
+func (p *PolicyPercentile) Update() (err error) {
+	defer func() {
+		if err != nil {
+			p.updateStatus = types.PolicyUpdateFailed
+		} else {
+			p.updateStatus = types.PolicyUpdateSucceeded
+		}
+	}()
+
+	var (
+		memoryEstimation float64 = 0
+		containerCnt     float64 = 0
+		errList          []error
+	)
+
+	f := func(podUID string, containerName string, ci *types.ContainerInfo) bool {
+		containerEstimation, err := helper.EstimateContainerResourceUsageByPercentile(ci, v1.ResourceMemory, p.metaReader,
+			p.essentials.EnableReclaim, p.percentile, p.safetyMarginRatio)
+		if err != nil {
+			errList = append(errList, err)
+			return true
+		}
+
+		klog.Infof("[qosaware-memory-headroom] pod %v container %v percentile estimation %.2e", ci.PodName, containerName, containerEstimation)
+		memoryEstimation += containerEstimation
+		containerCnt += 1
+		return true
+	}
+	p.metaReader.RangeContainer(f)
+	klog.Infof("[qosaware-memory-headroom] memory requirement percentile estimation: %.2e, #container %v", memoryEstimation, containerCnt)
+
+	p.memoryHeadroom = math.Max(float64(p.essentials.Total-p.essentials.ReservedForAllocate)-memoryEstimation, 0)
+
+	return errors.NewAggregate(errList)
+}
+
+func (p *PolicyPercentile) GetHeadroom() (resource.Quantity, error) {
+	if p.updateStatus != types.PolicyUpdateSucceeded {
+		return resource.Quantity{}, fmt.Errorf("last update failed")
+	}
+
+	return *resource.NewQuantity(int64(p.memoryHeadroom), resource.DecimalSI), nil
+}
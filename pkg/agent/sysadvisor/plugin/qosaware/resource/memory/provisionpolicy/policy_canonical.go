@@ -0,0 +1,281 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisionpolicy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+)
+
+// PolicyCanonical limits memory of reclaimed_cores pods on the binding numas to their current usage
+// plus free memory, except for the reserved part. The limit shrinks when memory PSI of the binding numas
+// is above the threshold, and it won't grow as long as memory pressure happened in the pressure history.
+// The limit never drops more than the max shrink bytes below the current usage in one update, nor below
+// the configured minimum, so that reclaimed_cores pods are throttled rather than killed at once.
+type PolicyCanonical struct {
+	*PolicyBase
+
+	reservedFreeRatio           float64
+	psiAvg10Threshold           float64
+	pressureBackoffRatio        float64
+	pressureHistorySize         int
+	dropCacheFreeRatioThreshold float64
+	maxShrinkBytes              float64
+	minLimitBytes               float64
+
+	// pressureHistory records whether memory is under pressure in the latest updates
+	pressureHistory      []bool
+	getNodePressure      func(resource string) (*common.PressureStats, error)
+	getContainerPressure func(podUID, containerName string) (*common.PressureStats, error)
+
+	// reclaimedMemoryLimit and dropCache are valid to be used iff updateStatus successes
+	reclaimedMemoryLimit float64
+	dropCache            bool
+	updateStatus         types.PolicyUpdateStatus
+}
+
+func NewPolicyCanonical(regionName string, conf *config.Configuration, _ interface{},
+	metaReader metacache.MetaReader, metaServer *metaserver.MetaServer, emitter metrics.MetricEmitter) ProvisionPolicy {
+	p := &PolicyCanonical{
+		PolicyBase: NewPolicyBase(regionName, metaReader, metaServer, emitter),
+
+		reservedFreeRatio:           conf.MemoryProvisionPolicyCanonicalConfiguration.ReservedFreeRatio,
+		psiAvg10Threshold:           conf.MemoryProvisionPolicyCanonicalConfiguration.PSIAvg10Threshold,
+		pressureBackoffRatio:        conf.MemoryProvisionPolicyCanonicalConfiguration.PressureBackoffRatio,
+		pressureHistorySize:         conf.MemoryProvisionPolicyCanonicalConfiguration.PressureHistorySize,
+		dropCacheFreeRatioThreshold: conf.DropCacheNumaFreeRatioThreshold,
+		maxShrinkBytes:              float64(conf.MemoryProvisionPolicyCanonicalConfiguration.MaxShrinkBytes),
+		minLimitBytes:               float64(conf.MemoryProvisionPolicyCanonicalConfiguration.MinReclaimedMemoryLimitBytes),
+
+		pressureHistory: make([]bool, 0),
+		getNodePressure: common.GetNodePressure,
+		updateStatus:    types.PolicyUpdateFailed,
+	}
+	p.getContainerPressure = p.getContainerMemoryPressure
+	return p
+}
+
+func (p *PolicyCanonical) Update() (err error) {
+	defer func() {
+		if err != nil {
+			p.updateStatus = types.PolicyUpdateFailed
+		} else {
+			p.updateStatus = types.PolicyUpdateSucceeded
+		}
+	}()
+
+	numaTotal := make(map[int]float64)
+	for _, node := range p.metaServer.MachineInfo.Topology {
+		numaTotal[node.Id] = float64(node.Memory)
+	}
+
+	var (
+		limit         float64 = 0
+		reclaimedUsed float64 = 0
+		dropCache             = false
+	)
+	for _, numaID := range p.bindingNumas.ToSliceInt() {
+		total, ok := numaTotal[numaID]
+		if !ok || total <= 0 {
+			return fmt.Errorf("invalid memory capacity of numa %v", numaID)
+		}
+
+		free, err := p.metaServer.GetNumaMetric(numaID, consts.MetricMemFreeNuma)
+		if err != nil {
+			return fmt.Errorf("get free memory of numa %v failed: %v", numaID, err)
+		}
+
+		numaReclaimedUsed := p.getReclaimedMemoryUsage(numaID)
+		reclaimedUsed += numaReclaimedUsed
+		limit += math.Max(free+numaReclaimedUsed-total*p.reservedFreeRatio, 0)
+
+		if p.dropCacheFreeRatioThreshold > 0 && free/total < p.dropCacheFreeRatioThreshold {
+			klog.Infof("[qosaware-memory-provision] numa %v of region %v is under memory pressure, free: %.2e, total: %.2e",
+				numaID, p.regionName, free, total)
+			dropCache = true
+		}
+	}
+
+	underPressure := p.isUnderPressure()
+	if p.updateStatus == types.PolicyUpdateSucceeded {
+		if underPressure {
+			limit = math.Min(limit, p.reclaimedMemoryLimit*(1-p.pressureBackoffRatio))
+		} else if p.hasPressureHistory() {
+			limit = math.Min(limit, p.reclaimedMemoryLimit)
+		}
+	}
+	p.recordPressure(underPressure)
+	limit = math.Max(limit, p.getLimitFloor(reclaimedUsed))
+
+	klog.Infof("[qosaware-memory-provision] region %v reclaimed memory limit: %.2e, drop cache: %v, under pressure: %v",
+		p.regionName, limit, dropCache, underPressure)
+
+	p.reclaimedMemoryLimit = limit
+	p.dropCache = dropCache
+	return nil
+}
+
+func (p *PolicyCanonical) GetControlKnobAdjusted() (types.ControlKnob, error) {
+	if p.updateStatus != types.PolicyUpdateSucceeded {
+		return nil, fmt.Errorf("last update failed")
+	}
+
+	controlKnob := make(types.ControlKnob)
+	// reclaimed memory shouldn't be limited if reclaim is disabled
+	if p.essentials.EnableReclaim {
+		controlKnob[types.ControlKnobReclaimedMemoryLimit] = types.ControlKnobValue{
+			Value:  p.reclaimedMemoryLimit,
+			Action: types.ControlKnobActionNone,
+		}
+	}
+	if p.dropCache {
+		controlKnob[types.ControlKnobReclaimedMemoryDropCache] = types.ControlKnobValue{
+			Value:  1,
+			Action: types.ControlKnobActionNone,
+		}
+	}
+	return controlKnob, nil
+}
+
+// getReclaimedMemoryUsage returns memory used by reclaimed_cores containers on the numa
+func (p *PolicyCanonical) getReclaimedMemoryUsage(numaID int) float64 {
+	var used float64
+	f := func(podUID string, containerName string, ci *types.ContainerInfo) bool {
+		if ci.QoSLevel != apiconsts.PodAnnotationQoSLevelReclaimedCores {
+			return true
+		}
+
+		value, err := p.metaServer.GetContainerNumaMetric(podUID, containerName, strconv.Itoa(numaID),
+			consts.MetricsMemTotalPerNumaContainer)
+		if err != nil {
+			klog.Warningf("[qosaware-memory-provision] get memory of pod %v container %v on numa %v failed: %v",
+				ci.PodName, containerName, numaID, err)
+			return true
+		}
+		used += value
+		return true
+	}
+	p.metaReader.RangeContainer(f)
+
+	return used
+}
+
+// getLimitFloor returns the lowest reclaimed memory limit allowed, which is bounded by
+// the max shrink bytes below current usage and the configured minimum
+func (p *PolicyCanonical) getLimitFloor(reclaimedUsed float64) float64 {
+	floor := p.minLimitBytes
+	if p.maxShrinkBytes > 0 {
+		floor = math.Max(floor, reclaimedUsed-p.maxShrinkBytes)
+	}
+	return floor
+}
+
+// isUnderPressure checks memory PSI of the binding numas. Since PSI isn't accounted per numa by the kernel,
+// it's the max PSI of non-reclaimed containers assigned to the binding numas, and node PSI is used only if
+// the binding numas cover the whole node. Memory is regarded not under pressure if PSI isn't supported.
+func (p *PolicyCanonical) isUnderPressure() bool {
+	if p.bindingNumas.Size() >= len(p.metaServer.MachineInfo.Topology) {
+		stats, err := p.getNodePressure(string(v1.ResourceMemory))
+		if err != nil || stats == nil || stats.Some == nil {
+			klog.V(4).Infof("[qosaware-memory-provision] get node memory pressure failed: %v", err)
+			return false
+		}
+		return stats.Some.Avg10 > p.psiAvg10Threshold
+	}
+
+	underPressure := false
+	f := func(podUID string, containerName string, ci *types.ContainerInfo) bool {
+		if underPressure {
+			return false
+		} else if ci.QoSLevel == apiconsts.PodAnnotationQoSLevelReclaimedCores || !p.isAssignedToBindingNumas(ci) {
+			return true
+		}
+
+		stats, err := p.getContainerPressure(podUID, containerName)
+		if err != nil || stats == nil || stats.Some == nil {
+			klog.V(4).Infof("[qosaware-memory-provision] get memory pressure of pod %v container %v failed: %v",
+				ci.PodName, containerName, err)
+			return true
+		}
+
+		if stats.Some.Avg10 > p.psiAvg10Threshold {
+			klog.Infof("[qosaware-memory-provision] pod %v container %v of region %v is under memory pressure, psi avg10: %.2f",
+				ci.PodName, containerName, p.regionName, stats.Some.Avg10)
+			underPressure = true
+			return false
+		}
+		return true
+	}
+	p.metaReader.RangeContainer(f)
+
+	return underPressure
+}
+
+func (p *PolicyCanonical) isAssignedToBindingNumas(ci *types.ContainerInfo) bool {
+	for numaID := range ci.TopologyAwareAssignments {
+		if p.bindingNumas.Contains(numaID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PolicyCanonical) getContainerMemoryPressure(podUID, containerName string) (*common.PressureStats, error) {
+	containerID, err := p.metaServer.GetContainerID(podUID, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	absCgroupPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysMemory, podUID, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return common.GetCgroupPressure(absCgroupPath, common.PressureResourceMemory)
+}
+
+func (p *PolicyCanonical) hasPressureHistory() bool {
+	for _, pressure := range p.pressureHistory {
+		if pressure {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PolicyCanonical) recordPressure(underPressure bool) {
+	if p.pressureHistorySize <= 0 {
+		return
+	}
+
+	p.pressureHistory = append(p.pressureHistory, underPressure)
+	if len(p.pressureHistory) > p.pressureHistorySize {
+		p.pressureHistory = p.pressureHistory[len(p.pressureHistory)-p.pressureHistorySize:]
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisionpolicy
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/stretchr/testify/require"
+
+	apiconsts "github.com/kubewharf/katalyst-api/pkg/consts"
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+func TestPolicyCanonical(t *testing.T) {
+	t.Parallel()
+
+	sfDir, err := ioutil.TempDir("", "statefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(sfDir)
+
+	conf, err := options.NewOptions().Config()
+	require.NoError(t, err)
+	conf.GenericSysAdvisorConfiguration.StateFileDirectory = sfDir
+	conf.DropCacheNumaFreeRatioThreshold = 0.1
+	conf.MemoryProvisionPolicyCanonicalConfiguration.ReservedFreeRatio = 0.05
+	conf.MemoryProvisionPolicyCanonicalConfiguration.PSIAvg10Threshold = 10
+	conf.MemoryProvisionPolicyCanonicalConfiguration.PressureHistorySize = 2
+	conf.MemoryProvisionPolicyCanonicalConfiguration.PressureBackoffRatio = 0.5
+	conf.MemoryProvisionPolicyCanonicalConfiguration.MaxShrinkBytes = 1 << 30
+
+	metricsFetcher := metric.NewFakeMetricsFetcher(metrics.DummyMetrics{}).(*metric.FakeMetricsFetcher)
+	metaCache, err := metacache.NewMetaCacheImp(conf, metricsFetcher)
+	require.NoError(t, err)
+	require.NoError(t, metaCache.SetContainerInfo("uid1", "c1", &types.ContainerInfo{
+		PodUID:        "uid1",
+		PodName:       "pod1",
+		ContainerName: "c1",
+		QoSLevel:      apiconsts.PodAnnotationQoSLevelReclaimedCores,
+	}))
+	require.NoError(t, metaCache.SetContainerInfo("uid2", "c2", &types.ContainerInfo{
+		PodUID:                   "uid2",
+		PodName:                  "pod2",
+		ContainerName:            "c2",
+		QoSLevel:                 apiconsts.PodAnnotationQoSLevelSharedCores,
+		TopologyAwareAssignments: types.TopologyAwareAssignment{0: machine.NewCPUSet(0, 1)},
+	}))
+	require.NoError(t, metaCache.SetContainerInfo("uid3", "c3", &types.ContainerInfo{
+		PodUID:                   "uid3",
+		PodName:                  "pod3",
+		ContainerName:            "c3",
+		QoSLevel:                 apiconsts.PodAnnotationQoSLevelSharedCores,
+		TopologyAwareAssignments: types.TopologyAwareAssignment{1: machine.NewCPUSet(2, 3)},
+	}))
+
+	metaServer := &metaserver.MetaServer{
+		MetaAgent: &agent.MetaAgent{
+			KatalystMachineInfo: &machine.KatalystMachineInfo{
+				MachineInfo: &info.MachineInfo{
+					Topology: []info.Node{{Id: 0, Memory: 100 << 30}, {Id: 1, Memory: 100 << 30}},
+				},
+			},
+			MetricsFetcher: metricsFetcher,
+		},
+	}
+	metricsFetcher.SetNumaMetric(0, consts.MetricMemFreeNuma, 20<<30)
+	metricsFetcher.SetContainerNumaMetric("uid1", "c1", "0", consts.MetricsMemTotalPerNumaContainer, 10<<30)
+
+	policy := NewPolicyCanonical("numa-0", conf, nil, metaCache, metaServer, metrics.DummyMetrics{}).(*PolicyCanonical)
+	policy.SetBindingNumas(machine.NewCPUSet(0))
+	policy.SetEssentials(types.ResourceEssentials{EnableReclaim: true})
+
+	// memory PSI of the node and containers on other numas is ignored
+	psiAvg10 := 0.0
+	policy.getNodePressure = func(_ string) (*common.PressureStats, error) {
+		return &common.PressureStats{Some: &common.PressureData{Avg10: 50}}, nil
+	}
+	policy.getContainerPressure = func(podUID, _ string) (*common.PressureStats, error) {
+		if podUID == "uid2" {
+			return &common.PressureStats{Some: &common.PressureData{Avg10: psiAvg10}}, nil
+		}
+		return &common.PressureStats{Some: &common.PressureData{Avg10: 50}}, nil
+	}
+
+	// each step updates free memory of numa and memory PSI of containers on it, and expects the reclaimed memory limit
+	trace := []struct {
+		name          string
+		free          float64
+		psiAvg10      float64
+		expectedLimit float64
+		dropCache     bool
+	}{
+		{name: "limit is usage plus free except reserved", free: 20 << 30, expectedLimit: 25 << 30},
+		{name: "limit grows without pressure", free: 30 << 30, expectedLimit: 35 << 30},
+		{name: "limit backs off under pressure", free: 30 << 30, psiAvg10: 20, expectedLimit: 17.5 * (1 << 30)},
+		{name: "limit won't grow with pressure history", free: 30 << 30, expectedLimit: 17.5 * (1 << 30)},
+		{name: "limit follows free memory with pressure history", free: 5 << 30, expectedLimit: 10 << 30, dropCache: true},
+		{name: "limit grows after pressure history expires", free: 30 << 30, expectedLimit: 35 << 30},
+		{name: "limit won't shrink far below usage", free: 0, psiAvg10: 20, expectedLimit: 9 << 30, dropCache: true},
+	}
+
+	for _, step := range trace {
+		metricsFetcher.SetNumaMetric(0, consts.MetricMemFreeNuma, step.free)
+		psiAvg10 = step.psiAvg10
+		require.NoError(t, policy.Update(), step.name)
+
+		controlKnob, err := policy.GetControlKnobAdjusted()
+		require.NoError(t, err, step.name)
+		require.Equal(t, step.expectedLimit, controlKnob[types.ControlKnobReclaimedMemoryLimit].Value, step.name)
+		_, dropCache := controlKnob[types.ControlKnobReclaimedMemoryDropCache]
+		require.Equal(t, step.dropCache, dropCache, step.name)
+	}
+
+	// reclaimed memory isn't limited if reclaim is disabled
+	policy.SetEssentials(types.ResourceEssentials{EnableReclaim: false})
+	require.NoError(t, policy.Update())
+	controlKnob, err := policy.GetControlKnobAdjusted()
+	require.NoError(t, err)
+	_, ok := controlKnob[types.ControlKnobReclaimedMemoryLimit]
+	require.False(t, ok)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/memory/provisionpolicy"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+)
+
+type internalProvisionPolicy struct {
+	name         types.MemoryProvisionPolicyName
+	policy       provisionpolicy.ProvisionPolicy
+	updateStatus types.PolicyUpdateStatus
+}
+
+// memoryRegion groups numa nodes whose memory is provisioned together, and provision
+// policies are sorted by priority, i.e. the former one takes effect if it updates successfully
+type memoryRegion struct {
+	name         string
+	bindingNumas machine.CPUSet
+
+	provisionPolicies []*internalProvisionPolicy
+}
+
+func newMemoryRegion(name string, bindingNumas machine.CPUSet, conf *config.Configuration, extraConf interface{},
+	metaReader metacache.MetaReader, metaServer *metaserver.MetaServer, emitter metrics.MetricEmitter) *memoryRegion {
+	r := &memoryRegion{
+		name:              name,
+		bindingNumas:      bindingNumas,
+		provisionPolicies: make([]*internalProvisionPolicy, 0),
+	}
+
+	initializers := provisionpolicy.GetRegisteredInitializers()
+	for _, policyName := range conf.MemoryProvisionPolicies {
+		initFunc, ok := initializers[policyName]
+		if !ok {
+			klog.Errorf("failed to find registered initializer %v", policyName)
+			continue
+		}
+
+		policy := initFunc(name, conf, extraConf, metaReader, metaServer, emitter)
+		policy.SetBindingNumas(bindingNumas)
+		r.provisionPolicies = append(r.provisionPolicies, &internalProvisionPolicy{
+			name:         policyName,
+			policy:       policy,
+			updateStatus: types.PolicyUpdateFailed,
+		})
+	}
+
+	return r
+}
+
+func (r *memoryRegion) tryUpdateProvision(essentials types.ResourceEssentials) {
+	for _, internal := range r.provisionPolicies {
+		internal.updateStatus = types.PolicyUpdateFailed
+
+		internal.policy.SetEssentials(essentials)
+		if err := internal.policy.Update(); err != nil {
+			klog.Errorf("[qosaware-memory] region %v update provision policy %v failed: %v", r.name, internal.name, err)
+			continue
+		}
+		internal.updateStatus = types.PolicyUpdateSucceeded
+	}
+}
+
+func (r *memoryRegion) getProvision() (types.ControlKnob, error) {
+	for _, internal := range r.provisionPolicies {
+		if internal.updateStatus != types.PolicyUpdateSucceeded {
+			continue
+		}
+
+		controlKnob, err := internal.policy.GetControlKnobAdjusted()
+		if err != nil {
+			klog.Errorf("[qosaware-memory] region %v get control knob by policy %v failed: %v", r.name, internal.name, err)
+			continue
+		}
+		return controlKnob, nil
+	}
+
+	return nil, fmt.Errorf("failed to get valid provision of region %v", r.name)
+}
@@ -43,6 +43,9 @@ type ResourceAdvisor interface {
 
 	// GetHeadroom returns the corresponding headroom quantity according to resource name
 	GetHeadroom(resourceName v1.ResourceName) (resource.Quantity, error)
+
+	// GetNumaHeadroom returns the corresponding headroom quantity of each numa node according to resource name
+	GetNumaHeadroom(resourceName v1.ResourceName) (map[int]resource.Quantity, error)
 }
 
 // SubResourceAdvisor updates resource provision of a certain dimension based on the latest
@@ -58,6 +61,9 @@ type SubResourceAdvisor interface {
 
 	// GetHeadroom returns the latest resource headroom quantity for resource reporter
 	GetHeadroom() (resource.Quantity, error)
+
+	// GetNumaHeadroom returns the latest resource headroom quantity of each numa node for resource reporter
+	GetNumaHeadroom() (map[int]resource.Quantity, error)
 }
 
 type resourceAdvisorWrapper struct {
@@ -121,6 +127,17 @@ func (ra *resourceAdvisorWrapper) GetHeadroom(resourceName v1.ResourceName) (res
 	}
 }
 
+func (ra *resourceAdvisorWrapper) GetNumaHeadroom(resourceName v1.ResourceName) (map[int]resource.Quantity, error) {
+	switch resourceName {
+	case v1.ResourceCPU:
+		return ra.getSubAdvisorNumaHeadroom(types.QoSResourceCPU)
+	case v1.ResourceMemory:
+		return ra.getSubAdvisorNumaHeadroom(types.QoSResourceMemory)
+	default:
+		return nil, fmt.Errorf("illegal resource %v", resourceName)
+	}
+}
+
 func (ra *resourceAdvisorWrapper) getSubAdvisorHeadroom(resourceName types.QoSResourceName) (resource.Quantity, error) {
 	subAdvisor, ok := ra.subAdvisorsToRun[resourceName]
 	if !ok {
@@ -128,3 +145,11 @@ func (ra *resourceAdvisorWrapper) getSubAdvisorHeadroom(resourceName types.QoSRe
 	}
 	return subAdvisor.GetHeadroom()
 }
+
+func (ra *resourceAdvisorWrapper) getSubAdvisorNumaHeadroom(resourceName types.QoSResourceName) (map[int]resource.Quantity, error) {
+	subAdvisor, ok := ra.subAdvisorsToRun[resourceName]
+	if !ok {
+		return nil, fmt.Errorf("no sub resource advisor for %v", resourceName)
+	}
+	return subAdvisor.GetNumaHeadroom()
+}
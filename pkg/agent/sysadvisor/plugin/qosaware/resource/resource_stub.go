@@ -29,14 +29,16 @@ import (
 
 type ResourceAdvisorStub struct {
 	sync.Mutex
-	resources map[v1.ResourceName]resource.Quantity
+	resources     map[v1.ResourceName]resource.Quantity
+	numaResources map[v1.ResourceName]map[int]resource.Quantity
 }
 
 var _ ResourceAdvisor = NewResourceAdvisorStub()
 
 func NewResourceAdvisorStub() *ResourceAdvisorStub {
 	return &ResourceAdvisorStub{
-		resources: make(map[v1.ResourceName]resource.Quantity),
+		resources:     make(map[v1.ResourceName]resource.Quantity),
+		numaResources: make(map[v1.ResourceName]map[int]resource.Quantity),
 	}
 }
 
@@ -64,15 +66,34 @@ func (r *ResourceAdvisorStub) SetHeadroom(resourceName v1.ResourceName, quantity
 	r.resources[resourceName] = quantity
 }
 
+func (r *ResourceAdvisorStub) GetNumaHeadroom(resourceName v1.ResourceName) (map[int]resource.Quantity, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if quantities, ok := r.numaResources[resourceName]; ok {
+		return quantities, nil
+	}
+	return nil, fmt.Errorf("not exist")
+}
+
+func (r *ResourceAdvisorStub) SetNumaHeadroom(resourceName v1.ResourceName, quantities map[int]resource.Quantity) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.numaResources[resourceName] = quantities
+}
+
 type SubResourceAdvisorStub struct {
-	quantity resource.Quantity
+	quantity       resource.Quantity
+	numaQuantities map[int]resource.Quantity
 }
 
 var _ SubResourceAdvisor = NewSubResourceAdvisorStub()
 
 func NewSubResourceAdvisorStub() *SubResourceAdvisorStub {
 	return &SubResourceAdvisorStub{
-		quantity: resource.MustParse("0"),
+		quantity:       resource.MustParse("0"),
+		numaQuantities: make(map[int]resource.Quantity),
 	}
 }
 
@@ -94,3 +115,11 @@ func (s *SubResourceAdvisorStub) GetHeadroom() (resource.Quantity, error) {
 func (s *SubResourceAdvisorStub) SetHeadroom(quantity resource.Quantity) {
 	s.quantity = quantity
 }
+
+func (s *SubResourceAdvisorStub) GetNumaHeadroom() (map[int]resource.Quantity, error) {
+	return s.numaQuantities, nil
+}
+
+func (s *SubResourceAdvisorStub) SetNumaHeadroom(quantities map[int]resource.Quantity) {
+	s.numaQuantities = quantities
+}
@@ -208,7 +208,7 @@ func (cs *cpuServer) ListAndWatch(empty *cpuadvisor.Empty, server cpuadvisor.CPU
 
 			// Assemble pod entries
 			f := func(podUID string, containerName string, ci *types.ContainerInfo) bool {
-				if err := cs.assemblePodEntries(calculationEntriesMap, blockID2Blocks, podUID, containerName, ci); err != nil {
+				if err := cs.assemblePodEntries(&advisorResp, calculationEntriesMap, blockID2Blocks, podUID, containerName, ci); err != nil {
 					klog.Errorf("[qosaware-server-cpu] assemblePodEntries err: %v", err)
 				}
 				return true
@@ -439,8 +439,8 @@ func (cs *cpuServer) assemblePoolEntries(advisorResp *cpu.InternalCalculationRes
 	}
 }
 
-// assemblePoolEntries fills up calculationEntriesMap and blockSet based on types.ContainerInfo
-func (cs *cpuServer) assemblePodEntries(calculationEntriesMap map[string]*cpuadvisor.CalculationEntries,
+// assemblePodEntries fills up calculationEntriesMap and blockSet based on types.ContainerInfo
+func (cs *cpuServer) assemblePodEntries(advisorResp *cpu.InternalCalculationResult, calculationEntriesMap map[string]*cpuadvisor.CalculationEntries,
 	bs blockSet, podUID string, containerName string, ci *types.ContainerInfo) error {
 	poolName, ok := qosLevel2PoolName[ci.QoSLevel]
 	if !ok {
@@ -452,8 +452,8 @@ func (cs *cpuServer) assemblePodEntries(calculationEntriesMap map[string]*cpuadv
 		CalculationResultsByNumas: nil,
 	}
 
-	// currently, only pods in "dedicated_nums with numa binding" has topology aware allocations
-	if ci.IsNumaBinding() {
+	// currently, only pods in "dedicated_cores" has topology aware allocations
+	if ci.QoSLevel == consts.PodAnnotationQoSLevelDedicatedCores {
 		calculationResultsByNumas := make(map[int64]*cpuadvisor.NumaCalculationResult)
 
 		for numaID, cpuset := range ci.TopologyAwareAssignments {
@@ -473,6 +473,20 @@ func (cs *cpuServer) assemblePodEntries(calculationEntriesMap map[string]*cpuadv
 						}
 					}
 				}
+			} else if !ci.IsNumaBinding() {
+				// if this podUID appears firstly without numa binding, generate a new Block and
+				// join it with a new Block in reclaimed pool for the cpus lent to reclaimed pool
+
+				block := NewBlock(uint64(cpuset.Size()), "")
+				innerBlock := NewInnerBlock(block, int64(numaID), "", ci, numaCalculationResult)
+				numaCalculationResult.Blocks = append(numaCalculationResult.Blocks, block)
+
+				lent := advisorResp.ReclaimLentEntries[podUID][numaID]
+				if lent.Value() > 0 && lent.Value() <= int64(cpuset.Size()) {
+					innerBlock.join(cs.addReclaimPoolBlock(calculationEntriesMap, bs, int64(numaID), uint64(lent.Value())), bs)
+				} else {
+					innerBlock.join(block.BlockId, bs)
+				}
 			} else {
 				// if this podUID appears firstly, we should generate a new Block
 
@@ -516,3 +530,26 @@ func (cs *cpuServer) assemblePodEntries(calculationEntriesMap map[string]*cpuadv
 
 	return nil
 }
+
+// addReclaimPoolBlock adds a new Block of the given size to reclaimed pool on the numa, and returns its id
+func (cs *cpuServer) addReclaimPoolBlock(calculationEntriesMap map[string]*cpuadvisor.CalculationEntries,
+	bs blockSet, numaID int64, size uint64) string {
+	poolEntry, ok := calculationEntriesMap[qrmstate.PoolNameReclaim]
+	if !ok {
+		poolEntry = NewPoolCalculationEntries(qrmstate.PoolNameReclaim)
+		calculationEntriesMap[qrmstate.PoolNameReclaim] = poolEntry
+	}
+
+	numaCalculationResult, ok := poolEntry.Entries[cpuadvisor.FakedContainerID].CalculationResultsByNumas[numaID]
+	if !ok {
+		numaCalculationResult = &cpuadvisor.NumaCalculationResult{Blocks: []*cpuadvisor.Block{}}
+		poolEntry.Entries[cpuadvisor.FakedContainerID].CalculationResultsByNumas[numaID] = numaCalculationResult
+	}
+
+	block := NewBlock(size, "")
+	innerBlock := NewInnerBlock(block, numaID, qrmstate.PoolNameReclaim, nil, numaCalculationResult)
+	numaCalculationResult.Blocks = append(numaCalculationResult.Blocks, block)
+	innerBlock.join(block.BlockId, bs)
+
+	return block.BlockId
+}
@@ -387,6 +387,101 @@ func TestCPUServerListAndWatch(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "reclaim pool with dedicated pod without numa binding",
+			empty: &cpuadvisor.Empty{},
+			provision: cpu.InternalCalculationResult{
+				PoolEntries: map[string]map[int]resource.Quantity{
+					qrmstate.PoolNameReclaim: {-1: *resource.NewQuantity(4, resource.DecimalSI)},
+				},
+				ReclaimLentEntries: map[string]map[int]resource.Quantity{
+					"pod1": {0: *resource.NewQuantity(1, resource.DecimalSI)},
+				},
+			},
+			infos: []*ContainerInfo{
+				{
+					request: &cpuadvisor.AddContainerRequest{
+						PodUid:        "pod1",
+						ContainerName: "c1",
+						QosLevel:      consts.PodAnnotationQoSLevelDedicatedCores,
+					},
+					allocationInfo: &cpuadvisor.AllocationInfo{
+						OwnerPoolName: qrmstate.PoolNameDedicated,
+						TopologyAwareAssignments: map[uint64]string{
+							0: "0-3",
+							1: "24-27",
+						},
+					},
+				},
+			},
+			wantErr: false,
+			wantRes: &cpuadvisor.ListAndWatchResponse{
+				Entries: map[string]*cpuadvisor.CalculationEntries{
+					qrmstate.PoolNameReclaim: {
+						Entries: map[string]*cpuadvisor.CalculationInfo{
+							"": {
+								OwnerPoolName: qrmstate.PoolNameReclaim,
+								CalculationResultsByNumas: map[int64]*cpuadvisor.NumaCalculationResult{
+									-1: {
+										Blocks: []*cpuadvisor.Block{
+											{
+												Result: 4,
+											},
+										},
+									},
+									0: {
+										Blocks: []*cpuadvisor.Block{
+											{
+												Result: 1,
+												OverlapTargets: []*cpuadvisor.OverlapTarget{
+													{
+														OverlapTargetPodUid:        "pod1",
+														OverlapTargetContainerName: "c1",
+														OverlapType:                cpuadvisor.OverlapType_OverlapWithPod,
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					"pod1": {
+						Entries: map[string]*cpuadvisor.CalculationInfo{
+							"c1": {
+								OwnerPoolName: qrmstate.PoolNameDedicated,
+								CalculationResultsByNumas: map[int64]*cpuadvisor.NumaCalculationResult{
+									0: {
+										Blocks: []*cpuadvisor.Block{
+											{
+												Result: 1,
+												OverlapTargets: []*cpuadvisor.OverlapTarget{
+													{
+														OverlapTargetPoolName: qrmstate.PoolNameReclaim,
+														OverlapType:           cpuadvisor.OverlapType_OverlapWithPool,
+													},
+												},
+											},
+											{
+												Result: 3,
+											},
+										},
+									},
+									1: {
+										Blocks: []*cpuadvisor.Block{
+											{
+												Result: 4,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name:  "reclaim pool colocated with dedicated pod(2 containers)",
 			empty: &cpuadvisor.Empty{},
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/memoryadvisor"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/memory"
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/process"
+)
+
+const (
+	memoryServerName string = "memory-server"
+)
+
+// Metric names for memory server
+const (
+	metricMemoryServerStartCalled             = "memoryserver_start_called"
+	metricMemoryServerStopCalled              = "memoryserver_stop_called"
+	metricMemoryServerLWCalled                = "memoryserver_lw_called"
+	metricMemoryServerLWSendResponseFailed    = "memoryserver_lw_send_response_failed"
+	metricMemoryServerLWSendResponseSucceeded = "memoryserver_lw_send_response_succeeded"
+)
+
+// memoryServer serves memory advices calculated by memory advisor to memory plugin
+type memoryServer struct {
+	name                    string
+	period                  time.Duration
+	memoryAdvisorSocketPath string
+	recvCh                  chan memory.InternalCalculationResult
+	stopCh                  chan struct{}
+
+	emitter metrics.MetricEmitter
+
+	server *grpc.Server
+	memoryadvisor.UnimplementedMemoryAdvisorServer
+}
+
+func NewMemoryServer(recvCh chan memory.InternalCalculationResult, conf *config.Configuration,
+	emitter metrics.MetricEmitter) (*memoryServer, error) {
+	return &memoryServer{
+		name:                    memoryServerName,
+		period:                  conf.QoSAwarePluginConfiguration.SyncPeriod,
+		memoryAdvisorSocketPath: conf.MemoryAdvisorSocketAbsPath,
+		recvCh:                  recvCh,
+		stopCh:                  make(chan struct{}),
+		emitter:                 emitter,
+	}, nil
+}
+
+func (ms *memoryServer) Name() string {
+	return ms.name
+}
+
+func (ms *memoryServer) Start() error {
+	_ = ms.emitter.StoreInt64(metricMemoryServerStartCalled, int64(ms.period.Seconds()), metrics.MetricTypeNameCount)
+
+	if err := ms.serve(); err != nil {
+		klog.Errorf("[qosaware-server-memory] start memory server failed: %v", err)
+		_ = ms.Stop()
+		return err
+	}
+	klog.Infof("[qosaware-server-memory] started memory server")
+
+	return nil
+}
+
+func (ms *memoryServer) Stop() error {
+	close(ms.stopCh)
+	_ = ms.emitter.StoreInt64(metricMemoryServerStopCalled, int64(ms.period.Seconds()), metrics.MetricTypeNameCount)
+
+	if ms.server != nil {
+		ms.server.Stop()
+		klog.Infof("[qosaware-server-memory] stopped memory server")
+	}
+
+	if err := os.Remove(ms.memoryAdvisorSocketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove %v failed: %v", ms.memoryAdvisorSocketPath, err)
+	}
+
+	return nil
+}
+
+func (ms *memoryServer) ListAndWatch(_ *memoryadvisor.Empty, server memoryadvisor.MemoryAdvisor_ListAndWatchServer) error {
+	_ = ms.emitter.StoreInt64(metricMemoryServerLWCalled, int64(ms.period.Seconds()), metrics.MetricTypeNameCount)
+
+	for {
+		select {
+		case <-ms.stopCh:
+			klog.Infof("[qosaware-server-memory] lw stopped because memory server stopped")
+			return nil
+		case <-server.Context().Done():
+			klog.Infof("[qosaware-server-memory] lw stopped because client cancelled")
+			return nil
+		case advisorResp, more := <-ms.recvCh:
+			if !more {
+				klog.Infof("[qosaware-server-memory] recv channel is closed")
+				return nil
+			}
+
+			resp := memoryadvisor.NewListAndWatchResponse(advisorResp.ContainerEntries, advisorResp.PoolEntries)
+			if err := server.Send(resp); err != nil {
+				klog.Errorf("[qosaware-server-memory] send response failed: %v", err)
+				_ = ms.emitter.StoreInt64(metricMemoryServerLWSendResponseFailed, int64(ms.period.Seconds()), metrics.MetricTypeNameCount)
+				return err
+			}
+			klog.Infof("[qosaware-server-memory] send memory advices: %v", general.ToString(resp.Entries))
+			_ = ms.emitter.StoreInt64(metricMemoryServerLWSendResponseSucceeded, int64(ms.period.Seconds()), metrics.MetricTypeNameCount)
+		}
+	}
+}
+
+func (ms *memoryServer) serve() error {
+	memoryAdvisorSocketDir := path.Dir(ms.memoryAdvisorSocketPath)
+
+	err := general.EnsureDirectory(memoryAdvisorSocketDir)
+	if err != nil {
+		return fmt.Errorf("ensure memoryAdvisorSocketDir: %s failed with error: %v",
+			memoryAdvisorSocketDir, err)
+	}
+
+	if err := os.Remove(ms.memoryAdvisorSocketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove %v failed: %v", ms.memoryAdvisorSocketPath, err)
+	}
+
+	sock, err := net.Listen("unix", ms.memoryAdvisorSocketPath)
+	if err != nil {
+		return fmt.Errorf("listen %s failed: %v", ms.memoryAdvisorSocketPath, err)
+	}
+
+	klog.Infof("[qosaware-server-memory] listen at: %s successfully", ms.memoryAdvisorSocketPath)
+
+	grpcServer := grpc.NewServer()
+	memoryadvisor.RegisterMemoryAdvisorServer(grpcServer, ms)
+	ms.server = grpcServer
+
+	go func() {
+		klog.Infof("[qosaware-server-memory] starting grpc server at %v", ms.memoryAdvisorSocketPath)
+		if err := grpcServer.Serve(sock); err != nil {
+			klog.Errorf("[qosaware-server-memory] grpc server at %v exited: %v", ms.memoryAdvisorSocketPath, err)
+		}
+	}()
+
+	if conn, err := process.Dial(ms.memoryAdvisorSocketPath, ms.period); err != nil {
+		return fmt.Errorf("dial check at %v failed: %v", ms.memoryAdvisorSocketPath, err)
+	} else {
+		_ = conn.Close()
+	}
+
+	return nil
+}
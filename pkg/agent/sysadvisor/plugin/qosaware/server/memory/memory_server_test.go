@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/agent/qrm-plugins/memory/dynamicpolicy/memoryadvisor"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/memory"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/process"
+)
+
+func newTestMemoryServer(t *testing.T, socketDir string) (*memoryServer, chan memory.InternalCalculationResult) {
+	conf, err := options.NewOptions().Config()
+	require.NoError(t, err)
+	conf.QRMAdvisorConfiguration.MemoryAdvisorSocketAbsPath = filepath.Join(socketDir, "memory_advisor.sock")
+
+	recvCh := make(chan memory.InternalCalculationResult)
+	ms, err := NewMemoryServer(recvCh, conf, metrics.DummyMetrics{})
+	require.NoError(t, err)
+	require.NotNil(t, ms)
+
+	return ms, recvCh
+}
+
+func TestMemoryServerStartAndStop(t *testing.T) {
+	socketDir, err := ioutil.TempDir("", "memory-server")
+	require.NoError(t, err)
+	defer os.RemoveAll(socketDir)
+
+	ms, _ := newTestMemoryServer(t, socketDir)
+
+	assert.NoError(t, ms.Start())
+	assert.NoError(t, ms.Stop())
+}
+
+func TestMemoryServerListAndWatch(t *testing.T) {
+	socketDir, err := ioutil.TempDir("", "memory-server")
+	require.NoError(t, err)
+	defer os.RemoveAll(socketDir)
+
+	ms, recvCh := newTestMemoryServer(t, socketDir)
+	require.NoError(t, ms.Start())
+	defer func() { _ = ms.Stop() }()
+
+	conn, err := process.Dial(ms.memoryAdvisorSocketPath, time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := memoryadvisor.NewMemoryAdvisorClient(conn).ListAndWatch(context.Background(), &memoryadvisor.Empty{})
+	require.NoError(t, err)
+
+	advices := map[string]map[string]map[string]string{
+		"pod1": {
+			"c1": {
+				memoryadvisor.ControlKnobKeyMemoryHigh:                                        "1073741824",
+				memoryadvisor.GetNumaControlKnobKey(memoryadvisor.ControlKnobKeyDropCache, 0): "4096",
+			},
+		},
+	}
+	recvCh <- memory.InternalCalculationResult{ContainerEntries: advices}
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, advices["pod1"]["c1"], resp.Entries["pod1"].Entries["c1"].Values)
+}
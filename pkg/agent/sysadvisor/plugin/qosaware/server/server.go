@@ -26,7 +26,9 @@ import (
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/metacache"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource"
 	resourcecpu "github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/cpu"
+	resourcememory "github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/resource/memory"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/server/cpu"
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/plugin/qosaware/server/memory"
 	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
 	"github.com/kubewharf/katalyst-core/pkg/config"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
@@ -99,6 +101,14 @@ func newSubQRMServer(resourceName v1.ResourceName, advisorWrapper resource.Resou
 		advisorRecvCh := advisorRecvChInterface.(chan struct{})
 		advisorSendCh := advisorSendChInterface.(chan resourcecpu.InternalCalculationResult)
 		return cpu.NewCPUServer(advisorSendCh, advisorRecvCh, conf, metaCache, emitter)
+	case v1.ResourceMemory:
+		subAdvisor, err := advisorWrapper.GetSubAdvisor(types.QoSResourceMemory)
+		if err != nil {
+			return nil, err
+		}
+		_, advisorSendChInterface := subAdvisor.GetChannels()
+		advisorSendCh := advisorSendChInterface.(chan resourcememory.InternalCalculationResult)
+		return memory.NewMemoryServer(advisorSendCh, conf, emitter)
 	default:
 		return nil, fmt.Errorf("illegal resource %v", resourceName)
 	}
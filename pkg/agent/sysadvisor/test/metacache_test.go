@@ -18,7 +18,9 @@ package util
 
 import (
 	"io/ioutil"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -101,3 +103,97 @@ func TestPool(t *testing.T) {
 	_, ok = metaCache.GetPoolInfo("pool-2")
 	assert.True(t, ok)
 }
+
+func TestContainerHistogram(t *testing.T) {
+	conf := generateMachineConfig(t)
+	metaCache, err := metacache.NewMetaCacheImp(conf, nil)
+	require.NoError(t, err)
+
+	err = metaCache.SetContainerInfo("pod-0", "container-0", &types.ContainerInfo{})
+	assert.Nil(t, err)
+	err = metaCache.SetContainerInfo("pod-1", "container-1", &types.ContainerInfo{})
+	assert.Nil(t, err)
+
+	now := time.Now()
+	for i := 1; i <= 100; i++ {
+		err = metaCache.AddContainerHistogramSamples(types.ContainerHistogramNameCPUUsage, map[string]map[string]float64{
+			"pod-0": {"container-0": float64(i) / 10},
+			"pod-1": {"container-1": 4},
+			"pod-2": {"container-2": 4},
+		}, now.Add(time.Duration(i)*time.Minute))
+		assert.Nil(t, err)
+	}
+	err = metaCache.AddContainerHistogramSamples("unknown", map[string]map[string]float64{}, now)
+	assert.Error(t, err)
+
+	h, ok := metaCache.GetContainerHistogram("pod-0", "container-0", types.ContainerHistogramNameCPUUsage)
+	require.True(t, ok)
+	assert.InDelta(t, 9.5, h.Percentile(0.95), 9.5*0.05)
+	_, ok = metaCache.GetContainerHistogram("pod-0", "container-0", types.ContainerHistogramNameMemoryUsage)
+	assert.False(t, ok)
+	_, ok = metaCache.GetContainerHistogram("pod-2", "container-2", types.ContainerHistogramNameCPUUsage)
+	assert.False(t, ok)
+
+	// histograms should be restored from checkpoint after restart
+	require.NoError(t, metaCache.StoreHistogramState())
+	restoredMetaCache, err := metacache.NewMetaCacheImp(conf, nil)
+	require.NoError(t, err)
+	restored, ok := restoredMetaCache.GetContainerHistogram("pod-0", "container-0", types.ContainerHistogramNameCPUUsage)
+	require.True(t, ok)
+	assert.InDelta(t, h.Percentile(0.95), restored.Percentile(0.95), 1e-9)
+
+	err = restoredMetaCache.RemovePod("pod-0")
+	assert.Nil(t, err)
+	err = restoredMetaCache.DeleteContainer("pod-1", "container-1")
+	assert.Nil(t, err)
+	_, ok = restoredMetaCache.GetContainerHistogram("pod-0", "container-0", types.ContainerHistogramNameCPUUsage)
+	assert.False(t, ok)
+	_, ok = restoredMetaCache.GetContainerHistogram("pod-1", "container-1", types.ContainerHistogramNameCPUUsage)
+	assert.False(t, ok)
+}
+
+func TestGCContainerHistogram(t *testing.T) {
+	conf := generateMachineConfig(t)
+	metaCache, err := metacache.NewMetaCacheImp(conf, nil)
+	require.NoError(t, err)
+
+	samples := make(map[string]map[string]float64)
+	for _, podUID := range []string{"pod-0", "pod-1", "pod-2"} {
+		require.NoError(t, metaCache.SetContainerInfo(podUID, "container", &types.ContainerInfo{}))
+		samples[podUID] = map[string]float64{"container": 4}
+	}
+	require.NoError(t, metaCache.AddContainerHistogramSamples(types.ContainerHistogramNameCPUUsage, samples, time.Now()))
+	require.NoError(t, metaCache.StoreHistogramState())
+
+	// pod-2 is deleted while sysadvisor is down, so its histogram is restored without the pod
+	require.NoError(t, metaCache.RemovePod("pod-2"))
+	restoredMetaCache, err := metacache.NewMetaCacheImp(conf, nil)
+	require.NoError(t, err)
+	_, ok := restoredMetaCache.GetContainerHistogram("pod-2", "container", types.ContainerHistogramNameCPUUsage)
+	require.True(t, ok)
+
+	// histograms of pods in cache are kept even if they're not listed yet
+	restoredMetaCache.GCHistogramEntries(map[string]struct{}{"pod-0": {}})
+	_, ok = restoredMetaCache.GetContainerHistogram("pod-0", "container", types.ContainerHistogramNameCPUUsage)
+	assert.True(t, ok)
+	_, ok = restoredMetaCache.GetContainerHistogram("pod-1", "container", types.ContainerHistogramNameCPUUsage)
+	assert.True(t, ok)
+	_, ok = restoredMetaCache.GetContainerHistogram("pod-2", "container", types.ContainerHistogramNameCPUUsage)
+	assert.False(t, ok)
+}
+
+func TestCorruptedHistogramCheckpoint(t *testing.T) {
+	conf := generateMachineConfig(t)
+	checkpointPath := filepath.Join(conf.GenericSysAdvisorConfiguration.StateFileDirectory, "sys_advisor_histogram_state")
+	require.NoError(t, ioutil.WriteFile(checkpointPath, []byte("corrupted"), 0o644))
+
+	// the corrupted checkpoint is discarded and histograms start from scratch
+	metaCache, err := metacache.NewMetaCacheImp(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, metaCache.SetContainerInfo("pod-0", "container-0", &types.ContainerInfo{}))
+	_, ok := metaCache.GetContainerHistogram("pod-0", "container-0", types.ContainerHistogramNameCPUUsage)
+	assert.False(t, ok)
+
+	_, err = metacache.NewMetaCacheImp(conf, nil)
+	require.NoError(t, err)
+}
@@ -20,9 +20,11 @@ import (
 	"reflect"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
 
 	"github.com/kubewharf/katalyst-api/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/histogram"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
 
@@ -198,3 +200,30 @@ func (ck ControlKnob) Clone() ControlKnob {
 	}
 	return clone
 }
+
+// SaveToCheckpoint converts histograms into persistable checkpoints, and histograms
+// failed to be saved will be logged and skipped
+func (he HistogramEntries) SaveToCheckpoint() HistogramCheckpointEntries {
+	checkpointEntries := make(HistogramCheckpointEntries)
+	for podUID, podHistograms := range he {
+		for containerName, containerHistograms := range podHistograms {
+			for histogramName, h := range containerHistograms {
+				checkpoint, err := h.SaveToCheckpoint()
+				if err != nil {
+					klog.Errorf("[metacache] save histogram %v of pod %v container %v to checkpoint failed: %v",
+						histogramName, podUID, containerName, err)
+					continue
+				}
+
+				if _, ok := checkpointEntries[podUID]; !ok {
+					checkpointEntries[podUID] = make(map[string]map[ContainerHistogramName]*histogram.Checkpoint)
+				}
+				if _, ok := checkpointEntries[podUID][containerName]; !ok {
+					checkpointEntries[podUID][containerName] = make(map[ContainerHistogramName]*histogram.Checkpoint)
+				}
+				checkpointEntries[podUID][containerName][histogramName] = checkpoint
+			}
+		}
+	}
+	return checkpointEntries
+}
@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/kubelet/pkg/apis/resourceplugin/v1alpha1"
 
+	"github.com/kubewharf/katalyst-core/pkg/util/histogram"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
 
@@ -45,22 +46,34 @@ const (
 	CPUProvisionPolicyNone      CPUProvisionPolicyName = "none"
 	CPUProvisionPolicyCanonical CPUProvisionPolicyName = "canonical"
 	CPUProvisionPolicyRama      CPUProvisionPolicyName = "rama"
+	CPUProvisionPolicyDedicated CPUProvisionPolicyName = "dedicated"
 )
 
 // CPUHeadroomPolicyName defines policy names for cpu advisor headroom estimation
 type CPUHeadroomPolicyName string
 
 const (
-	CPUHeadroomPolicyNone      CPUHeadroomPolicyName = "none"
-	CPUHeadroomPolicyCanonical CPUHeadroomPolicyName = "canonical"
+	CPUHeadroomPolicyNone       CPUHeadroomPolicyName = "none"
+	CPUHeadroomPolicyCanonical  CPUHeadroomPolicyName = "canonical"
+	CPUHeadroomPolicyPercentile CPUHeadroomPolicyName = "percentile"
+	CPUHeadroomPolicyDedicated  CPUHeadroomPolicyName = "dedicated"
 )
 
 // MemoryHeadroomPolicyName defines policy names for memory advisor headroom estimation
 type MemoryHeadroomPolicyName string
 
 const (
-	MemoryHeadroomPolicyNone      MemoryHeadroomPolicyName = "none"
-	MemoryHeadroomPolicyCanonical MemoryHeadroomPolicyName = "canonical"
+	MemoryHeadroomPolicyNone       MemoryHeadroomPolicyName = "none"
+	MemoryHeadroomPolicyCanonical  MemoryHeadroomPolicyName = "canonical"
+	MemoryHeadroomPolicyPercentile MemoryHeadroomPolicyName = "percentile"
+)
+
+// MemoryProvisionPolicyName defines policy names for memory advisor resource provision
+type MemoryProvisionPolicyName string
+
+const (
+	MemoryProvisionPolicyNone      MemoryProvisionPolicyName = "none"
+	MemoryProvisionPolicyCanonical MemoryProvisionPolicyName = "canonical"
 )
 
 // QoSRegionType declares pre-defined region types
@@ -74,6 +87,9 @@ const (
 	// and numa exclusive container
 	QoSRegionTypeDedicatedNumaExclusive QoSRegionType = "dedicated-numa-exclusive"
 
+	// QoSRegionTypeDedicated for each dedicated core container without numa binding
+	QoSRegionTypeDedicated QoSRegionType = "dedicated"
+
 	// QoSRegionTypeEmpty works as a wrapper for empty numas
 	QoSRegionTypeEmpty QoSRegionType = "empty"
 )
@@ -137,6 +153,23 @@ type PoolEntries map[string]*PoolInfo
 // RegionEntries stores region info keyed by region name
 type RegionEntries map[string]*RegionInfo
 
+// ContainerHistogramName defines names of resource usage histograms kept for each container
+type ContainerHistogramName string
+
+const (
+	ContainerHistogramNameCPUUsage    ContainerHistogramName = "cpu-usage"
+	ContainerHistogramNameMemoryUsage ContainerHistogramName = "memory-usage"
+)
+
+// ContainerHistograms stores usage histograms of a container keyed by histogram name
+type ContainerHistograms map[ContainerHistogramName]histogram.Histogram
+
+// HistogramEntries stores usage histograms keyed by pod uid and container name
+type HistogramEntries map[string]map[string]ContainerHistograms
+
+// HistogramCheckpointEntries stores persistable form of HistogramEntries
+type HistogramCheckpointEntries map[string]map[string]map[ContainerHistogramName]*histogram.Checkpoint
+
 // PodSet stores container names keyed by pod uid
 type PodSet map[string]sets.String
 
@@ -152,6 +185,13 @@ const (
 
 	// ControlKnobReclaimedCPUSupplied refers to the cpu resource could be supplied to the pods with reclaimed_cores QoS level
 	ControlKnobReclaimedCPUSupplied ControlKnobName = "reclaimed-cpu-supplied"
+
+	// ControlKnobReclaimedMemoryLimit refers to the memory in bytes could be used by the pods with reclaimed_cores QoS level
+	ControlKnobReclaimedMemoryLimit ControlKnobName = "reclaimed-memory-limit"
+
+	// ControlKnobReclaimedMemoryDropCache refers to whether cache of the pods with reclaimed_cores QoS level
+	// should be dropped, and positive value means to drop
+	ControlKnobReclaimedMemoryDropCache ControlKnobName = "reclaimed-memory-drop-cache"
 )
 
 // ResourceEssentials defines essential (const) variables, and those variables may be adjusted by KCC
@@ -190,6 +230,44 @@ type IndicatorValue struct {
 	Low     float64
 }
 
+// IndicatorTargetMergeRule defines how to merge targets of the same indicator
+// from multiple services in a region, and indicators are all assumed to be
+// the lower the better, such as cpu schedule wait and cpi
+type IndicatorTargetMergeRule string
+
+const (
+	// IndicatorTargetMergeRuleStrictest adopts the lowest target to satisfy all services
+	IndicatorTargetMergeRuleStrictest IndicatorTargetMergeRule = "strictest"
+	// IndicatorTargetMergeRuleLoosest adopts the highest target to save resources
+	IndicatorTargetMergeRuleLoosest IndicatorTargetMergeRule = "loosest"
+)
+
+// FirstOrderPIDParams holds parameters of pid controller to regulate a certain indicator,
+// and the error is measured relatively to the target so that the gains are unitless
+type FirstOrderPIDParams struct {
+	// Kpp and Kpn are proportional gains when the error is positive and negative
+	Kpp float64
+	Kpn float64
+	// Kdp and Kdn are derivative gains when the error delta is positive and negative
+	Kdp float64
+	Kdn float64
+	// Ki is the integral gain
+	Ki float64
+
+	// AdjustmentUpperBound and AdjustmentLowerBound restrict adjustment in each round
+	AdjustmentUpperBound float64
+	AdjustmentLowerBound float64
+
+	// the indicator is regarded as on target if it falls into the deadband, i.e.
+	// [target * (1 - DeadbandLowerPct), target * (1 + DeadbandUpperPct)]
+	DeadbandUpperPct float64
+	DeadbandLowerPct float64
+
+	// IntegralUpperBound and IntegralLowerBound restrict the accumulated error for anti-windup
+	IntegralUpperBound float64
+	IntegralLowerBound float64
+}
+
 // PolicyUpdateStatus works as a flag indicating update result
 type PolicyUpdateStatus string
 
@@ -50,6 +50,7 @@ type EvictionPluginsConfiguration struct {
 	*ReclaimedResourcesEvictionPluginConfiguration
 	*MemoryPressureEvictionPluginConfiguration
 	*CPUPressureEvictionPluginConfiguration
+	*PIDPressureEvictionPluginConfiguration
 }
 
 func NewGenericEvictionConfiguration() *GenericEvictionConfiguration {
@@ -67,6 +68,7 @@ func NewEvictionPluginsConfiguration() *EvictionPluginsConfiguration {
 		ReclaimedResourcesEvictionPluginConfiguration: NewReclaimedResourcesEvictionPluginConfiguration(),
 		MemoryPressureEvictionPluginConfiguration:     NewMemoryPressureEvictionPluginConfiguration(),
 		CPUPressureEvictionPluginConfiguration:        NewCPUPressureEvictionPluginConfiguration(),
+		PIDPressureEvictionPluginConfiguration:        NewPIDPressureEvictionPluginConfiguration(),
 	}
 }
 
@@ -74,4 +76,5 @@ func (c *EvictionPluginsConfiguration) ApplyConfiguration(defaultConf *EvictionP
 	c.ReclaimedResourcesEvictionPluginConfiguration.ApplyConfiguration(defaultConf.ReclaimedResourcesEvictionPluginConfiguration, conf)
 	c.MemoryPressureEvictionPluginConfiguration.ApplyConfiguration(defaultConf.MemoryPressureEvictionPluginConfiguration, conf)
 	c.CPUPressureEvictionPluginConfiguration.ApplyConfiguration(defaultConf.CPUPressureEvictionPluginConfiguration, conf)
+	c.PIDPressureEvictionPluginConfiguration.ApplyConfiguration(defaultConf.PIDPressureEvictionPluginConfiguration, conf)
 }
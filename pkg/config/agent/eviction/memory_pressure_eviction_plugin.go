@@ -25,6 +25,9 @@ import (
 const (
 	FakeMetricQoSLevel = "qos.pod"
 	FakeMetricPriority = "priority.pod"
+	// FakeMetricSwapUsage is read from cgroup directly, and it can be used to rank
+	// reclaimed_cores pods which are allowed to swap
+	FakeMetricSwapUsage = "swap.pod"
 )
 
 const (
@@ -0,0 +1,37 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eviction
+
+import (
+	"github.com/kubewharf/katalyst-core/pkg/config/dynamic"
+)
+
+// PIDPressureEvictionPluginConfiguration is the config of PIDPressureEvictionPlugin
+type PIDPressureEvictionPluginConfiguration struct {
+	// PIDPressureEvictionThreshold is the ratio of allocated pids in kernel.pid_max,
+	// above which reclaimed_cores pods with most pids will be evicted
+	PIDPressureEvictionThreshold             float64
+	PIDPressureEvictionPodGracePeriodSeconds int64
+}
+
+// NewPIDPressureEvictionPluginConfiguration returns a new PIDPressureEvictionPluginConfiguration
+func NewPIDPressureEvictionPluginConfiguration() *PIDPressureEvictionPluginConfiguration {
+	return &PIDPressureEvictionPluginConfiguration{}
+}
+
+// ApplyConfiguration applies dynamic.DynamicConfigCRD to PIDPressureEvictionPluginConfiguration
+func (c *PIDPressureEvictionPluginConfiguration) ApplyConfiguration(*PIDPressureEvictionPluginConfiguration,
+	*dynamic.DynamicConfigCRD) {
+}
@@ -37,14 +37,51 @@ type MetaServerConfiguration struct {
 	KubeletPodCacheSyncMaxRate   rate.Limit
 	KubeletPodCacheSyncBurstBulk int
 
+	// KubeletSecurePortEnabled is whether to talk to kubelet through its authenticated port
+	// instead of the read-only one, using the bearer token in APIAuthTokenFile
+	KubeletSecurePortEnabled bool
+	KubeletSecurePort        int
+	APIAuthTokenFile         string
+
+	// KubeletCAFile is used to verify serving certificate of kubelet authenticated port,
+	// and the verification is only skipped if KubeletInsecureSkipTLSVerify is explicitly set
+	KubeletCAFile                string
+	KubeletInsecureSkipTLSVerify bool
+
 	RemoteRuntimeEndpoint     string
 	RuntimePodCacheSyncPeriod time.Duration
 
+	// EnableAPIServerPodFallback is whether to serve pods from apiserver when kubelet is unavailable,
+	// and pods last synced from kubelet are still preferred within APIServerFallbackGracePeriod
+	// after kubelet becomes unavailable, since pods in apiserver may lag behind the node
+	EnableAPIServerPodFallback   bool
+	APIServerFallbackGracePeriod time.Duration
+
 	CheckpointManagerDir string
+
+	// MetricsFetcher is the type of metrics fetcher, i.e. malachite, native or kubelet
+	MetricsFetcher   string
+	NativeProcFSRoot string
+	NativeSysFSRoot  string
+	NativeCgroupRoot string
+
+	// ResctrlRoot is the mount point of resctrl filesystem used by the RDT manager
+	ResctrlRoot string
+
+	// MetricsSampleIntervals is the sampling interval of each metrics scope, and consumers
+	// may ask for a faster cadence which is bounded by MetricsMinSampleInterval
+	MetricsSampleIntervals   map[string]time.Duration
+	MetricsMinSampleInterval time.Duration
+
+	// MetricsMaxAge is the max age of metrics trusted by consumers, and those
+	// consumers should degrade safely instead of acting on older metrics
+	MetricsMaxAge time.Duration
 }
 
 func NewMetaServerConfiguration() *MetaServerConfiguration {
-	return &MetaServerConfiguration{}
+	return &MetaServerConfiguration{
+		MetricsSampleIntervals: make(map[string]time.Duration),
+	}
 }
 
 func (c *MetaServerConfiguration) ApplyConfiguration(*MetaServerConfiguration, *dynamic.DynamicConfigCRD) {
@@ -23,6 +23,8 @@ import (
 type QRMAdvisorConfiguration struct {
 	CPUAdvisorSocketAbsPath string
 	CPUPluginSocketAbsPath  string
+
+	MemoryAdvisorSocketAbsPath string
 }
 
 func NewQRMAdvisorConfiguration() *QRMAdvisorConfiguration {
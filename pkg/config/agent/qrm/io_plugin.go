@@ -0,0 +1,72 @@
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qrm
+
+import (
+	"time"
+
+	"github.com/kubewharf/katalyst-core/pkg/config/dynamic"
+)
+
+// IOQRMPluginConfig is the config of io QRM plugin
+type IOQRMPluginConfig struct {
+	// PolicyName is used to switch between several strategies
+	PolicyName string
+	IOWeight   IOWeightConfig
+	// PodLevelIOWeightAnnoKey is the annotation key of pod-level io weight,
+	// which overrides the io weight of its QoS level
+	PodLevelIOWeightAnnoKey string
+	// PodLevelIOMaxAnnoKey is the annotation key of pod-level io throughput limits formatted as
+	// "rbps=N wbps=N riops=N wiops=N", and limits are applied to each of IOThrottleDevices
+	PodLevelIOMaxAnnoKey string
+	// IOThrottleDevices are names of block devices (e.g. sda, nvme0n1) shared by pods, and io
+	// throughput limits of containers and dynamic io cap are applied to them
+	IOThrottleDevices []string
+
+	DynamicIOCapConfig
+}
+
+type IOWeightConfig struct {
+	// ReclaimedCores is the io weight for reclaimed_cores
+	ReclaimedCores uint64
+	// SharedCores is the io weight for shared_cores
+	SharedCores uint64
+	// DedicatedCores is the io weight for dedicated_cores
+	DedicatedCores uint64
+	// SystemCores is the io weight for system_cores
+	SystemCores uint64
+}
+
+// DynamicIOCapConfig is the config of io throughput cap for all reclaimed_cores containers,
+// and the cap is adjusted according to the utilization of devices
+type DynamicIOCapConfig struct {
+	EnableDynamicIOCap bool
+	DynamicIOCapPeriod time.Duration
+	// IOUtilThreshold is the utilization (in percentage) of a device above which the cap is halved,
+	// otherwise the cap grows by a tenth of the range between min and max cap
+	IOUtilThreshold float64
+	// ReclaimedIOCapMinBps and ReclaimedIOCapMaxBps are the range of the cap in bytes per second
+	ReclaimedIOCapMinBps uint64
+	ReclaimedIOCapMaxBps uint64
+}
+
+// NewIOQRMPluginConfig returns an IOQRMPluginConfig
+func NewIOQRMPluginConfig() *IOQRMPluginConfig {
+	return &IOQRMPluginConfig{}
+}
+
+// ApplyConfiguration applies the DynamicConfigCRD to IOQRMPluginConfig
+func (c *IOQRMPluginConfig) ApplyConfiguration(defaultConf *IOQRMPluginConfig, conf *dynamic.DynamicConfigCRD) {
+}
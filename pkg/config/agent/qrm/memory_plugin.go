@@ -17,6 +17,8 @@ limitations under the License.
 package qrm
 
 import (
+	"time"
+
 	"github.com/kubewharf/katalyst-core/pkg/config/dynamic"
 )
 
@@ -27,6 +29,142 @@ type MemoryQRMPluginConfig struct {
 	ReservedMemoryGB uint64
 	// skip memory state corruption and it will be used after updating state properties
 	SkipMemoryStateCorruption bool
+	// EnableSysAdvisor indicates whether to execute memory advices calculated by sys-advisor
+	EnableSysAdvisor bool
+
+	ProactiveReclaimConfig
+	MemoryProtectionConfig
+	SwapControlConfig
+	RDTConfig
+	WatermarkTunerConfig
+	NumaMigrationConfig
+	PIDLimitConfig
+}
+
+// ProactiveReclaimConfig is used to reclaim page caches of reclaimed_cores
+// containers proactively before global memory pressure occurs
+type ProactiveReclaimConfig struct {
+	// EnableProactiveReclaim indicates whether to enable proactive reclaim for reclaimed_cores
+	EnableProactiveReclaim bool
+	// ProactiveReclaimPeriod is the interval between two rounds of proactive reclaim
+	ProactiveReclaimPeriod time.Duration
+	// ProactiveReclaimInactiveFileRatio is the ratio of inactive file pages to be reclaimed for each container in one round
+	ProactiveReclaimInactiveFileRatio float64
+	// ProactiveReclaimMaxBytesPerRound limits the total bytes to be reclaimed in one round
+	ProactiveReclaimMaxBytesPerRound uint64
+	// ProactiveReclaimMinBytes is the minimum bytes worth reclaiming for a single container
+	ProactiveReclaimMinBytes uint64
+	// ProactiveReclaimPSIAvg10Threshold is the memory PSI (some avg10) threshold above which reclaim will back off
+	ProactiveReclaimPSIAvg10Threshold float64
+}
+
+// MemoryProtectionConfig is used to protect working sets of dedicated_cores and
+// shared_cores containers from being reclaimed by memory.min and memory.low
+type MemoryProtectionConfig struct {
+	// EnableMemoryProtection indicates whether to set memory protection for dedicated_cores and shared_cores
+	EnableMemoryProtection bool
+	// MemoryProtectionPeriod is the interval between two rounds of memory protection setting
+	MemoryProtectionPeriod time.Duration
+	// MemoryProtectionBySPD indicates whether to protect working sets declared in SPD rather than requests
+	MemoryProtectionBySPD bool
+	// MemoryProtectionMaxRatio is the max ratio of node memory capacity that can be protected in total,
+	// and protection of all containers will be scaled down proportionally if it's exceeded
+	MemoryProtectionMaxRatio float64
+}
+
+// SwapControlConfig is used to allow reclaimed_cores containers to swap to absorb
+// memory bursts, while swap is disabled for shared_cores and dedicated_cores containers
+type SwapControlConfig struct {
+	// EnableSwapControl indicates whether to set swap limits for containers per QoS level
+	EnableSwapControl bool
+	// SwapControlPeriod is the interval between two rounds of swap control
+	SwapControlPeriod time.Duration
+	// SwapSwappiness is the memory.swappiness for containers allowed to swap, and it only works for cgroupv1
+	SwapSwappiness uint64
+}
+
+// RDTConfig is used to confine reclaimed_cores containers to a slice of
+// LLC ways and memory bandwidth by RDT
+type RDTConfig struct {
+	// EnableRDT indicates whether to isolate LLC and memory bandwidth for reclaimed_cores
+	EnableRDT bool
+	// RDTPeriod is the interval between two rounds of rdt setting
+	RDTPeriod time.Duration
+	// RDTReclaimedCoresL3Ways is the number of LLC ways that reclaimed_cores can use, and 0 means no limit
+	RDTReclaimedCoresL3Ways int
+	// RDTReclaimedCoresMBPercent is the percentage of memory bandwidth that reclaimed_cores can use, and 0 means no limit
+	RDTReclaimedCoresMBPercent int
+}
+
+// WatermarkTunerConfig is used to adjust vm.watermark_scale_factor and vm.min_free_kbytes
+// dynamically so that kswapd starts earlier before allocations stall in direct reclaim
+type WatermarkTunerConfig struct {
+	// EnableWatermarkTuner indicates whether to tune watermarks of the node dynamically
+	EnableWatermarkTuner bool
+	// WatermarkTunerPeriod is the interval between two rounds of watermark tuning
+	WatermarkTunerPeriod time.Duration
+	// WatermarkScaleFactorMin and WatermarkScaleFactorMax are the bounds of vm.watermark_scale_factor
+	WatermarkScaleFactorMin int64
+	WatermarkScaleFactorMax int64
+	// WatermarkScaleFactorStep is the delta of vm.watermark_scale_factor in one round
+	WatermarkScaleFactorStep int64
+	// WatermarkTunerAllocstallRateThreshold is the rate (per second) of allocation stalls
+	// above which watermarks will be raised
+	WatermarkTunerAllocstallRateThreshold float64
+	// WatermarkTunerKswapdStealRateThreshold is the rate (pages per second) of kswapd steal
+	// below which watermarks will be lowered if there is no allocation stall
+	WatermarkTunerKswapdStealRateThreshold float64
+	// EnableMinFreeKbytesTuning indicates whether to raise vm.min_free_kbytes after
+	// vm.watermark_scale_factor reaches its upper bound
+	EnableMinFreeKbytesTuning bool
+	// MinFreeKbytesMax is the upper bound of vm.min_free_kbytes
+	MinFreeKbytesMax int64
+	// MinFreeKbytesStep is the delta of vm.min_free_kbytes in one round
+	MinFreeKbytesStep int64
+}
+
+// NumaMigrationConfig is used to migrate remote pages of containers towards
+// their cpuset.mems after cpusets change, which may leave pages on remote NUMAs
+type NumaMigrationConfig struct {
+	// EnableNumaMigration indicates whether to migrate remote pages for non-NUMA-binding containers
+	EnableNumaMigration bool
+	// NumaMigrationPeriod is the interval between two rounds of numa migration
+	NumaMigrationPeriod time.Duration
+	// NumaMigrationBytesPerSecond is the bandwidth budget of numa migration, and remote
+	// pages beyond the budget of a round will be left to the following rounds
+	NumaMigrationBytesPerSecond uint64
+	// NumaMigrationRemoteRatioThreshold is the ratio of remote pages in all pages of a container,
+	// above which the container will be migrated
+	NumaMigrationRemoteRatioThreshold float64
+}
+
+// PIDLimitConfig is used to prevent fork bombs or runaway thread pools in reclaimed_cores
+// containers from exhausting pids of the node by pids.max per QoS level
+type PIDLimitConfig struct {
+	// EnablePIDLimit indicates whether to set pids.max for reclaimed_cores and containers,
+	// and pids.max set by the plugin before is restored to the original one if it's disabled
+	EnablePIDLimit bool
+	// PIDLimitPeriod is the interval between two rounds of pid limit setting
+	PIDLimitPeriod time.Duration
+	// PIDLimitReclaimedCoresRatio is the ratio of kernel.pid_max shared by all reclaimed_cores
+	// containers, which is set to pids.max of their parent cgroup, and 0 means no limit
+	PIDLimitReclaimedCoresRatio float64
+	// PIDLimitAnnotationKey is the annotation key of pod-level pids.max, and it's applied to each container of the pod
+	PIDLimitAnnotationKey string
+	// ContainerPIDLimit is pids.max of each container by its QoS level, which is overridden by the annotation
+	ContainerPIDLimit ContainerPIDLimitConfig
+}
+
+// ContainerPIDLimitConfig is pids.max of each container per QoS level, and 0 means no limit
+type ContainerPIDLimitConfig struct {
+	// ReclaimedCores is pids.max of each reclaimed_cores container
+	ReclaimedCores int64
+	// SharedCores is pids.max of each shared_cores container
+	SharedCores int64
+	// DedicatedCores is pids.max of each dedicated_cores container
+	DedicatedCores int64
+	// SystemCores is pids.max of each system_cores container
+	SystemCores int64
 }
 
 func NewMemoryQRMPluginConfig() *MemoryQRMPluginConfig {
@@ -25,6 +25,25 @@ type NetworkQRMPluginConfig struct {
 	NetClass                      NetClassConfig
 	PodLevelNetClassAnnoKey       string
 	PodLevelNetAttributesAnnoKeys string
+	// SkipNetworkStateCorruption skips network state corruption and it will be used after updating state properties
+	SkipNetworkStateCorruption bool
+	// NICNameAllocationAnnotationKey is the annotation key of the NIC assigned to a container
+	// in allocation results, so that CNI plugins can set up the network with the NIC
+	NICNameAllocationAnnotationKey string
+	// NICNumaAllocationAnnotationKey is the annotation key of NUMA nodes the assigned NIC is affinitive to
+	NICNumaAllocationAnnotationKey string
+	// ReservedBandwidth is the bandwidth (in Mbps) reserved on each NIC, and it
+	// can't be allocated to containers requesting net bandwidth
+	ReservedBandwidth uint64
+	// DefaultNICSpeed is the speed (in Mbps) of NICs whose speed is unknown,
+	// and bandwidth can't be allocated on those NICs if it's not positive
+	DefaultNICSpeed int
+	// TCShapingNICs are names of NICs whose egress traffic is shaped by net classes with tc on
+	// cgroup v2, and tc shaping is disabled if it's empty since root qdiscs of NICs are replaced;
+	// only host-network pods are shaped, since packets of other pods can't be classified on NICs
+	TCShapingNICs []string
+	// BandwidthEnforcerName is the name of enforcer to limit bandwidth of containers
+	BandwidthEnforcerName string
 }
 
 type NetClassConfig struct {
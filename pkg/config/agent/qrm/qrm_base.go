@@ -31,6 +31,7 @@ type QRMPluginsConfiguration struct {
 	*CPUQRMPluginConfig
 	*MemoryQRMPluginConfig
 	*NetworkQRMPluginConfig
+	*IOQRMPluginConfig
 }
 
 func NewGenericQRMPluginConfiguration() *GenericQRMPluginConfiguration {
@@ -45,6 +46,7 @@ func NewQRMPluginsConfiguration() *QRMPluginsConfiguration {
 		CPUQRMPluginConfig:     NewCPUQRMPluginConfig(),
 		MemoryQRMPluginConfig:  NewMemoryQRMPluginConfig(),
 		NetworkQRMPluginConfig: NewNetworkQRMPluginConfig(),
+		IOQRMPluginConfig:      NewIOQRMPluginConfig(),
 	}
 }
 
@@ -52,4 +54,5 @@ func (c *QRMPluginsConfiguration) ApplyConfiguration(defaultConf *QRMPluginsConf
 	c.CPUQRMPluginConfig.ApplyConfiguration(defaultConf.CPUQRMPluginConfig, conf)
 	c.MemoryQRMPluginConfig.ApplyConfiguration(defaultConf.MemoryQRMPluginConfig, conf)
 	c.NetworkQRMPluginConfig.ApplyConfiguration(defaultConf.NetworkQRMPluginConfig, conf)
+	c.IOQRMPluginConfig.ApplyConfiguration(defaultConf.IOQRMPluginConfig, conf)
 }
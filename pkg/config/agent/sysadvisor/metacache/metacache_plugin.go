@@ -25,6 +25,13 @@ import (
 // MetaCachePluginConfiguration stores configurations of metacache Plugin
 type MetaCachePluginConfiguration struct {
 	SyncPeriod time.Duration
+
+	// ContainerHistogramHalfLife is the duration for weights of container usage
+	// histogram samples to decay by half
+	ContainerHistogramHalfLife time.Duration
+	// ContainerHistogramCheckpointPeriod is the interval to persist container usage histograms,
+	// which is much longer than SyncPeriod since it's expensive to serialize all histograms
+	ContainerHistogramCheckpointPeriod time.Duration
 }
 
 // NewMetaCachePluginConfiguration creates a new metacache Plugin configuration.
@@ -25,6 +25,14 @@ import (
 type CPUAdvisorConfiguration struct {
 	ProvisionPolicies map[types.QoSRegionType][]types.CPUProvisionPolicyName
 	HeadroomPolicies  map[types.QoSRegionType][]types.CPUHeadroomPolicyName
+
+	// IndicatorTargetMergeRule decides the indicator target of a region if services
+	// in the region have different targets for the same indicator
+	IndicatorTargetMergeRule types.IndicatorTargetMergeRule
+
+	*CPUProvisionPolicyRamaConfiguration
+	*CPUProvisionPolicyDedicatedConfiguration
+	*CPUHeadroomPolicyPercentileConfiguration
 }
 
 // NewCPUAdvisorConfiguration creates new cpu advisor configurations
@@ -32,6 +40,10 @@ func NewCPUAdvisorConfiguration() *CPUAdvisorConfiguration {
 	return &CPUAdvisorConfiguration{
 		ProvisionPolicies: map[types.QoSRegionType][]types.CPUProvisionPolicyName{},
 		HeadroomPolicies:  map[types.QoSRegionType][]types.CPUHeadroomPolicyName{},
+
+		CPUProvisionPolicyRamaConfiguration:      NewCPUProvisionPolicyRamaConfiguration(),
+		CPUProvisionPolicyDedicatedConfiguration: NewCPUProvisionPolicyDedicatedConfiguration(),
+		CPUHeadroomPolicyPercentileConfiguration: NewCPUHeadroomPolicyPercentileConfiguration(),
 	}
 }
 
@@ -0,0 +1,29 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+// CPUProvisionPolicyDedicatedConfiguration stores configurations of dedicated cpu provision policy
+type CPUProvisionPolicyDedicatedConfiguration struct {
+	// SafetyMarginRatio is added upon the cpu usage of dedicated containers,
+	// i.e. cpus retained = usage * (1 + ratio), and the rest can be lent to reclaimed pods
+	SafetyMarginRatio float64
+}
+
+// NewCPUProvisionPolicyDedicatedConfiguration creates new dedicated cpu provision policy configurations
+func NewCPUProvisionPolicyDedicatedConfiguration() *CPUProvisionPolicyDedicatedConfiguration {
+	return &CPUProvisionPolicyDedicatedConfiguration{}
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cpu
+
+import (
+	"github.com/kubewharf/katalyst-core/pkg/agent/sysadvisor/types"
+)
+
+// CPUProvisionPolicyRamaConfiguration stores configurations of rama cpu provision policy
+type CPUProvisionPolicyRamaConfiguration struct {
+	// PIDParameters are parameters of pid controllers keyed by indicator names,
+	// and only indicators configured here are taken into account
+	PIDParameters map[string]types.FirstOrderPIDParams
+}
+
+// NewCPUProvisionPolicyRamaConfiguration creates new rama cpu provision policy configurations
+func NewCPUProvisionPolicyRamaConfiguration() *CPUProvisionPolicyRamaConfiguration {
+	return &CPUProvisionPolicyRamaConfiguration{
+		PIDParameters: map[string]types.FirstOrderPIDParams{},
+	}
+}
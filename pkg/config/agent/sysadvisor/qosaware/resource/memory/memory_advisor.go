@@ -23,13 +23,28 @@ import (
 
 // MemoryAdvisorConfiguration stores configurations of memory advisors in qos aware plugin
 type MemoryAdvisorConfiguration struct {
-	MemoryHeadroomPolicies []types.MemoryHeadroomPolicyName
+	MemoryHeadroomPolicies  []types.MemoryHeadroomPolicyName
+	MemoryProvisionPolicies []types.MemoryProvisionPolicyName
+
+	// ReclaimedCacheLimitBytes caps file cache of each reclaimed_cores container by memory.high,
+	// and 0 means no limit
+	ReclaimedCacheLimitBytes uint64
+	// DropCacheNumaFreeRatioThreshold is the ratio of free memory of a numa node below which
+	// cache of reclaimed_cores containers on it will be dropped, and 0 means never drop
+	DropCacheNumaFreeRatioThreshold float64
+
+	*MemoryHeadroomPolicyPercentileConfiguration
+	*MemoryProvisionPolicyCanonicalConfiguration
 }
 
 // NewMemoryAdvisorConfiguration creates new memory advisor configurations
 func NewMemoryAdvisorConfiguration() *MemoryAdvisorConfiguration {
 	return &MemoryAdvisorConfiguration{
-		MemoryHeadroomPolicies: make([]types.MemoryHeadroomPolicyName, 0),
+		MemoryHeadroomPolicies:  make([]types.MemoryHeadroomPolicyName, 0),
+		MemoryProvisionPolicies: make([]types.MemoryProvisionPolicyName, 0),
+
+		MemoryHeadroomPolicyPercentileConfiguration: NewMemoryHeadroomPolicyPercentileConfiguration(),
+		MemoryProvisionPolicyCanonicalConfiguration: NewMemoryProvisionPolicyCanonicalConfiguration(),
 	}
 }
 
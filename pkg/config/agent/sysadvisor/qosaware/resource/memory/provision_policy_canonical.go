@@ -0,0 +1,40 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+// MemoryProvisionPolicyCanonicalConfiguration stores configurations of canonical memory provision policy
+type MemoryProvisionPolicyCanonicalConfiguration struct {
+	// ReservedFreeRatio is the ratio of memory of each numa node kept free from reclaimed_cores pods
+	ReservedFreeRatio float64
+	// PSIAvg10Threshold is the threshold of memory PSI (some avg10) of containers on the numa nodes
+	// above which memory is regarded under pressure
+	PSIAvg10Threshold float64
+	// PressureHistorySize is the number of latest updates in which memory pressure prevents reclaimed memory limit from growing
+	PressureHistorySize int
+	// PressureBackoffRatio is the ratio by which reclaimed memory limit shrinks each time memory is under pressure
+	PressureBackoffRatio float64
+	// MaxShrinkBytes bounds how far below the current usage of reclaimed_cores pods the limit can be set in one update,
+	// so that their memory is reclaimed gradually rather than killed at once; zero means unbounded
+	MaxShrinkBytes int64
+	// MinReclaimedMemoryLimitBytes is the minimum memory limit of reclaimed_cores pods
+	MinReclaimedMemoryLimitBytes int64
+}
+
+// NewMemoryProvisionPolicyCanonicalConfiguration creates new canonical memory provision policy configurations
+func NewMemoryProvisionPolicyCanonicalConfiguration() *MemoryProvisionPolicyCanonicalConfiguration {
+	return &MemoryProvisionPolicyCanonicalConfiguration{}
+}
@@ -31,6 +31,14 @@ type DynamicConfigCRD struct {
 	EvictionConfiguration *v1alpha1.EvictionConfiguration
 }
 
+const (
+	// AdminQoSConfigurationKind is the kind of AdminQoSConfiguration
+	AdminQoSConfigurationKind = "AdminQoSConfiguration"
+
+	// EvictionConfigurationKind is the kind of EvictionConfiguration
+	EvictionConfigurationKind = "EvictionConfiguration"
+)
+
 var (
 	// AdminQoSConfigurationGVR is the group version resource for AdminQoSConfiguration
 	AdminQoSConfigurationGVR = metav1.GroupVersionResource(v1alpha1.SchemeGroupVersion.WithResource(v1alpha1.ResourceNameAdminQoSConfigurations))
@@ -20,3 +20,11 @@ const (
 	// KubeletQoSResourceManagerCheckpoint is the name of the checkpoint file for kubelet QoS resource manager
 	KubeletQoSResourceManagerCheckpoint = "kubelet_qrm_checkpoint"
 )
+
+const (
+	// PodAnnotationMemoryEnhancementSwap is the key in memory enhancement to specify whether
+	// the pod is allowed to swap, and it overrides the default behavior of its QoS level
+	PodAnnotationMemoryEnhancementSwap        = "swap"
+	PodAnnotationMemoryEnhancementSwapEnable  = "true"
+	PodAnnotationMemoryEnhancementSwapDisable = "false"
+)
@@ -55,7 +55,12 @@ type MetaAgent struct {
 
 // NewMetaAgent returns the instance of MetaAgent.
 func NewMetaAgent(conf *config.Configuration, clientSet *client.GenericClientSet, emitter metrics.MetricEmitter) (*MetaAgent, error) {
-	podFetcher, err := pod.NewPodFetcher(conf, emitter)
+	podFetcher, err := pod.NewPodFetcher(conf, clientSet.KubeClient, emitter)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsFetcher, err := metric.NewMetricsFetcher(conf, podFetcher, emitter)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +74,7 @@ func NewMetaAgent(conf *config.Configuration, clientSet *client.GenericClientSet
 		start:          false,
 		PodFetcher:     podFetcher,
 		NodeFetcher:    node.NewRemoteNodeFetcher(conf.NodeName, clientSet.KubeClient.CoreV1().Nodes()),
-		MetricsFetcher: metric.NewMalachiteMetricsFetcher(emitter),
+		MetricsFetcher: metricsFetcher,
 		CNRFetcher: cnr.NewCachedCNRFetcher(conf.NodeName, conf.CNRCacheTTL,
 			clientSet.InternalClient.NodeV1alpha1().CustomNodeResources()),
 		CNCFetcher: cnc.NewCachedCNCFetcher(conf.NodeName, conf.CustomNodeConfigCacheTTL,
@@ -16,8 +16,10 @@ package metric
 
 import (
 	"context"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
 
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
@@ -32,6 +34,15 @@ func NewFakeMetricsFetcher(emitter metrics.MetricEmitter) MetricsFetcher {
 	}
 }
 
+// NewFakeMetricsFetcherWithClock returns a fake MetricsFetcher with an isolated metric store,
+// whose metric data are stamped with the given clock, so that tests can age them at will.
+func NewFakeMetricsFetcherWithClock(emitter metrics.MetricEmitter, clock clock.PassiveClock) MetricsFetcher {
+	return &FakeMetricsFetcher{
+		metricStore: metric.NewMetricStoreWithClock(clock),
+		emitter:     emitter,
+	}
+}
+
 type FakeMetricsFetcher struct {
 	metricStore *metric.MetricStore
 	emitter     metrics.MetricEmitter
@@ -93,14 +104,62 @@ func (f *FakeMetricsFetcher) SetContainerNumaMetric(podUID, containerName, numaN
 	f.metricStore.SetContainerNumaMetric(podUID, containerName, numaNode, metricName, value)
 }
 
-func (f *FakeMetricsFetcher) AggregatePodNumaMetric(podList []*v1.Pod, numaNode, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) float64 {
-	return f.metricStore.AggregatePodNumaMetric(podList, numaNode, metricName, agg, filter)
+func (f *FakeMetricsFetcher) GetNodeMetricWithTime(metricName string) (metric.MetricData, error) {
+	return f.metricStore.GetNodeMetricWithTime(metricName)
+}
+
+func (f *FakeMetricsFetcher) GetNumaMetricWithTime(numaID int, metricName string) (metric.MetricData, error) {
+	return f.metricStore.GetNumaMetricWithTime(numaID, metricName)
+}
+
+func (f *FakeMetricsFetcher) GetDeviceMetricWithTime(deviceName string, metricName string) (metric.MetricData, error) {
+	return f.metricStore.GetDeviceMetricWithTime(deviceName, metricName)
+}
+
+func (f *FakeMetricsFetcher) GetCPUMetricWithTime(coreID int, metricName string) (metric.MetricData, error) {
+	return f.metricStore.GetCPUMetricWithTime(coreID, metricName)
+}
+
+func (f *FakeMetricsFetcher) GetContainerMetricWithTime(podUID, containerName, metricName string) (metric.MetricData, error) {
+	return f.metricStore.GetContainerMetricWithTime(podUID, containerName, metricName)
+}
+
+func (f *FakeMetricsFetcher) GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName string) (metric.MetricData, error) {
+	return f.metricStore.GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName)
+}
+
+func (f *FakeMetricsFetcher) GetNodeMetricWithMaxAge(metricName string, maxAge time.Duration) (float64, error) {
+	return f.metricStore.GetNodeMetricWithMaxAge(metricName, maxAge)
+}
+
+func (f *FakeMetricsFetcher) GetNumaMetricWithMaxAge(numaID int, metricName string, maxAge time.Duration) (float64, error) {
+	return f.metricStore.GetNumaMetricWithMaxAge(numaID, metricName, maxAge)
+}
+
+func (f *FakeMetricsFetcher) GetDeviceMetricWithMaxAge(deviceName string, metricName string, maxAge time.Duration) (float64, error) {
+	return f.metricStore.GetDeviceMetricWithMaxAge(deviceName, metricName, maxAge)
+}
+
+func (f *FakeMetricsFetcher) GetCPUMetricWithMaxAge(coreID int, metricName string, maxAge time.Duration) (float64, error) {
+	return f.metricStore.GetCPUMetricWithMaxAge(coreID, metricName, maxAge)
+}
+
+func (f *FakeMetricsFetcher) GetContainerMetricWithMaxAge(podUID, containerName, metricName string, maxAge time.Duration) (float64, error) {
+	return f.metricStore.GetContainerMetricWithMaxAge(podUID, containerName, metricName, maxAge)
+}
+
+func (f *FakeMetricsFetcher) GetContainerNumaMetricWithMaxAge(podUID, containerName, numaNode, metricName string, maxAge time.Duration) (float64, error) {
+	return f.metricStore.GetContainerNumaMetricWithMaxAge(podUID, containerName, numaNode, metricName, maxAge)
+}
+
+func (f *FakeMetricsFetcher) AggregatePodNumaMetric(podList []*v1.Pod, numaNode, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) (float64, error) {
+	return f.metricStore.AggregatePodNumaMetric(podList, numaNode, metricName, agg, filter), nil
 }
 
-func (f *FakeMetricsFetcher) AggregatePodMetric(podList []*v1.Pod, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) float64 {
-	return f.metricStore.AggregatePodMetric(podList, metricName, agg, filter)
+func (f *FakeMetricsFetcher) AggregatePodMetric(podList []*v1.Pod, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) (float64, error) {
+	return f.metricStore.AggregatePodMetric(podList, metricName, agg, filter), nil
 }
 
-func (f *FakeMetricsFetcher) AggregateCoreMetric(cpuset machine.CPUSet, metricName string, agg metric.Aggregator) float64 {
-	return f.metricStore.AggregateCoreMetric(cpuset, metricName, agg)
+func (f *FakeMetricsFetcher) AggregateCoreMetric(cpuset machine.CPUSet, metricName string, agg metric.Aggregator) (float64, error) {
+	return f.metricStore.AggregateCoreMetric(cpuset, metricName, agg), nil
 }
@@ -0,0 +1,412 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	statsapi "k8s.io/kubelet/pkg/apis/stats/v1alpha1"
+
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+const (
+	metricsNameKubeletGetSummaryFailed  = "kubelet_get_summary_failed"
+	metricsNameKubeletGetCadvisorFailed = "kubelet_get_cadvisor_failed"
+
+	kubeletSummaryPath  = "/stats/summary"
+	kubeletCadvisorPath = "/metrics/cadvisor"
+)
+
+// names and labels of cadvisor metrics exposed by kubelet
+const (
+	cadvisorLabelNamespace = "namespace"
+	cadvisorLabelPod       = "pod"
+	cadvisorLabelContainer = "container"
+
+	cadvisorCPUQuota            = "container_spec_cpu_quota"
+	cadvisorCPUPeriod           = "container_spec_cpu_period"
+	cadvisorCPUShares           = "container_spec_cpu_shares"
+	cadvisorCPUPeriods          = "container_cpu_cfs_periods_total"
+	cadvisorCPUThrottledPeriods = "container_cpu_cfs_throttled_periods_total"
+	cadvisorCPUThrottledSeconds = "container_cpu_cfs_throttled_seconds_total"
+	cadvisorMemLimit            = "container_spec_memory_limit_bytes"
+	cadvisorMemCache            = "container_memory_cache"
+	cadvisorMemOOMEvents        = "container_oom_events_total"
+)
+
+// kubeletSupportedScopes are scopes of metrics provided by kubelet, and metrics in
+// other scopes (e.g. numa and cpu) are not exposed by kubelet at all
+var kubeletSupportedScopes = map[MetricsScope]bool{
+	MetricsScopeNode:      true,
+	MetricsScopeContainer: true,
+}
+
+// NewKubeletMetricsFetcher returns the implementation of MetricsFetcher which collects
+// node and container metrics from kubelet summary api and cadvisor metrics, using the
+// same kubelet endpoint and credentials as pod fetcher; node memory metrics missing in
+// kubelet summary are read from procfs directly.
+func NewKubeletMetricsFetcher(conf *config.Configuration, emitter metrics.MetricEmitter) (MetricsFetcher, error) {
+	kubeletClient, err := pod.NewKubeletClient(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &KubeletMetricsFetcher{
+		baseMetricsFetcher: newBaseMetricsFetcher(conf, metric.GetMetricStoreInstance(), emitter),
+		kubeletClient:      kubeletClient,
+		procFS:             procfs.NewProcFS(conf.NativeProcFSRoot),
+	}
+
+	k.precheck = k.refreshSummary
+	k.samplers[MetricsScopeNode] = k.updateNodeStats
+	k.samplers[MetricsScopeContainer] = k.updatePodsStats
+	return k, nil
+}
+
+// KubeletMetricsFetcher only provides metrics of node and container scopes, and
+// getting or aggregating metrics of other scopes returns ErrMetricsUnsupported.
+type KubeletMetricsFetcher struct {
+	*baseMetricsFetcher
+
+	kubeletClient *pod.KubeletClient
+	procFS        *procfs.ProcFS
+
+	// summary is fetched once in each round of sampling and shared by scopes,
+	// and it's only accessed by the sampling goroutine
+	summary *statsapi.Summary
+
+	initOnce sync.Once
+}
+
+func (k *KubeletMetricsFetcher) Run(ctx context.Context) {
+	k.initOnce.Do(func() {
+		go k.runSampling(ctx)
+	})
+}
+
+func (k *KubeletMetricsFetcher) RegisterNotifier(scope MetricsScope, req NotifiedRequest, response chan NotifiedResponse) string {
+	if !kubeletSupportedScopes[scope] {
+		klog.Warningf("[kubelet] skip registering metric %v: %v", req.MetricName, unsupportedScopeError(scope))
+		return ""
+	}
+	return k.baseMetricsFetcher.RegisterNotifier(scope, req, response)
+}
+
+func (k *KubeletMetricsFetcher) GetNumaMetric(int, string) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeNuma)
+}
+
+func (k *KubeletMetricsFetcher) GetDeviceMetric(string, string) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeDevice)
+}
+
+func (k *KubeletMetricsFetcher) GetCPUMetric(int, string) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeCPU)
+}
+
+func (k *KubeletMetricsFetcher) GetContainerNumaMetric(string, string, string, string) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeNuma)
+}
+
+func (k *KubeletMetricsFetcher) GetNumaMetricWithTime(int, string) (metric.MetricData, error) {
+	return metric.MetricData{}, unsupportedScopeError(MetricsScopeNuma)
+}
+
+func (k *KubeletMetricsFetcher) GetDeviceMetricWithTime(string, string) (metric.MetricData, error) {
+	return metric.MetricData{}, unsupportedScopeError(MetricsScopeDevice)
+}
+
+func (k *KubeletMetricsFetcher) GetCPUMetricWithTime(int, string) (metric.MetricData, error) {
+	return metric.MetricData{}, unsupportedScopeError(MetricsScopeCPU)
+}
+
+func (k *KubeletMetricsFetcher) GetContainerNumaMetricWithTime(string, string, string, string) (metric.MetricData, error) {
+	return metric.MetricData{}, unsupportedScopeError(MetricsScopeNuma)
+}
+
+func (k *KubeletMetricsFetcher) GetNumaMetricWithMaxAge(int, string, time.Duration) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeNuma)
+}
+
+func (k *KubeletMetricsFetcher) GetDeviceMetricWithMaxAge(string, string, time.Duration) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeDevice)
+}
+
+func (k *KubeletMetricsFetcher) GetCPUMetricWithMaxAge(int, string, time.Duration) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeCPU)
+}
+
+func (k *KubeletMetricsFetcher) GetContainerNumaMetricWithMaxAge(string, string, string, string, time.Duration) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeNuma)
+}
+
+func (k *KubeletMetricsFetcher) AggregatePodNumaMetric([]*v1.Pod, string, string, metric.Aggregator, metric.ContainerMetricFilter) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeNuma)
+}
+
+func (k *KubeletMetricsFetcher) AggregateCoreMetric(machine.CPUSet, string, metric.Aggregator) (float64, error) {
+	return 0, unsupportedScopeError(MetricsScopeCPU)
+}
+
+func unsupportedScopeError(scope MetricsScope) error {
+	return fmt.Errorf("%w: %v metrics are not provided by kubelet", ErrMetricsUnsupported, scope)
+}
+
+// refreshSummary fetches kubelet summary before each round of sampling, and all scopes
+// are skipped if it fails since none of them can be sampled without it
+func (k *KubeletMetricsFetcher) refreshSummary() error {
+	k.summary = nil
+	summary, err := k.getSummary()
+	if err != nil {
+		return err
+	}
+	k.summary = summary
+	return nil
+}
+
+// updateNodeStats sets node memory metrics from kubelet summary, and kubelet computes
+// available memory as capacity minus working set, so capacity is derived in reverse.
+// Metrics are stamped with the time kubelet sampled them, as well as container metrics.
+func (k *KubeletMetricsFetcher) updateNodeStats(_ context.Context, cur time.Time) error {
+	summary, err := k.getSummary()
+	if err != nil {
+		return err
+	}
+
+	memory := summary.Node.Memory
+	if memory == nil {
+		return fmt.Errorf("memory stats of node is missing in kubelet summary")
+	}
+
+	updateTime := memory.Time.Time
+	if memory.AvailableBytes != nil {
+		k.metricStore.SetNodeMetricWithTime(consts.MetricMemAvailableSystem,
+			metric.MetricData{Value: float64(*memory.AvailableBytes), Time: updateTime})
+	}
+	if memory.WorkingSetBytes != nil {
+		k.metricStore.SetNodeMetricWithTime(consts.MetricMemUsedSystem,
+			metric.MetricData{Value: float64(*memory.WorkingSetBytes), Time: updateTime})
+	}
+	if memory.AvailableBytes != nil && memory.WorkingSetBytes != nil {
+		k.metricStore.SetNodeMetricWithTime(consts.MetricMemTotalSystem,
+			metric.MetricData{Value: float64(*memory.AvailableBytes + *memory.WorkingSetBytes), Time: updateTime})
+	}
+	return k.processSystemMemoryData(cur)
+}
+
+// processSystemMemoryData sets node memory metrics which are not provided by kubelet summary,
+// i.e. free memory and watermark scale factor, which are used to detect memory pressure
+func (k *KubeletMetricsFetcher) processSystemMemoryData(updateTime time.Time) error {
+	memInfo, err := k.procFS.ReadMemInfo()
+	if err != nil {
+		return fmt.Errorf("read meminfo failed, err %v", err)
+	}
+	memFree, ok := memInfo["MemFree"]
+	if !ok {
+		return fmt.Errorf("MemFree not found in meminfo")
+	}
+	k.metricStore.SetNodeMetricWithTime(consts.MetricMemFreeSystem,
+		metric.MetricData{Value: float64(memFree << 10), Time: updateTime})
+
+	scaleFactor, err := k.procFS.ReadSysctlInt("vm.watermark_scale_factor")
+	if err != nil {
+		return fmt.Errorf("read watermark scale factor failed, err %v", err)
+	}
+	k.metricStore.SetNodeMetricWithTime(consts.MetricMemScaleFactorSystem,
+		metric.MetricData{Value: float64(scaleFactor), Time: updateTime})
+	return nil
+}
+
+// updatePodsStats sets container metrics from kubelet summary and cadvisor metrics, and GC not
+// existed pod metrics; pods in kubelet summary are taken as the whole set of existing pods.
+func (k *KubeletMetricsFetcher) updatePodsStats(_ context.Context, cur time.Time) error {
+	summary, err := k.getSummary()
+	if err != nil {
+		return err
+	}
+
+	podUIDSet := make(map[string]bool)
+	// podUIDs maps namespace/name of pods to their uid, since cadvisor metrics don't contain pod uid
+	podUIDs := make(map[string]string)
+	for _, podStats := range summary.Pods {
+		podUID := podStats.PodRef.UID
+		podUIDSet[podUID] = true
+		podUIDs[podStats.PodRef.Namespace+"/"+podStats.PodRef.Name] = podUID
+
+		for i := range podStats.Containers {
+			k.processContainerSummary(podUID, &podStats.Containers[i], cur)
+		}
+	}
+	k.metricStore.GCPodsMetric(podUIDSet)
+
+	families, err := k.getCadvisorMetrics()
+	if err != nil {
+		return err
+	}
+	k.processCadvisorMetrics(families, podUIDs)
+	return nil
+}
+
+func (k *KubeletMetricsFetcher) processContainerSummary(podUID string, stats *statsapi.ContainerStats, cur time.Time) {
+	containerName := stats.Name
+	if stats.CPU != nil && stats.CPU.UsageNanoCores != nil {
+		k.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageContainer,
+			metric.MetricData{Value: float64(*stats.CPU.UsageNanoCores) / 1e9, Time: stats.CPU.Time.Time})
+	}
+
+	if memory := stats.Memory; memory != nil {
+		if memory.UsageBytes != nil {
+			k.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageContainer,
+				metric.MetricData{Value: float64(*memory.UsageBytes), Time: memory.Time.Time})
+		}
+		if memory.RSSBytes != nil {
+			k.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemRssContainer,
+				metric.MetricData{Value: float64(*memory.RSSBytes), Time: memory.Time.Time})
+		}
+		if memory.PageFaults != nil {
+			k.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgfaultContainer,
+				metric.MetricData{Value: float64(*memory.PageFaults), Time: memory.Time.Time})
+		}
+		if memory.MajorPageFaults != nil {
+			k.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgmajfaultContainer,
+				metric.MetricData{Value: float64(*memory.MajorPageFaults), Time: memory.Time.Time})
+		}
+	}
+
+	k.metricStore.SetContainerMetric(podUID, containerName, consts.MetricUpdateTimeContainer, float64(cur.Unix()))
+}
+
+// cadvisorMetricsMapping maps cadvisor metrics onto katalyst container metrics along with the scale of values
+var cadvisorMetricsMapping = []struct {
+	cadvisorName string
+	metricName   string
+	scale        float64
+}{
+	{cadvisorCPUPeriod, consts.MetricCPUPeriodContainer, 1},
+	{cadvisorCPUQuota, consts.MetricCPUQuotaContainer, 1},
+	{cadvisorCPUShares, consts.MetricCPUShareContainer, 1},
+	{cadvisorCPUThrottledPeriods, consts.MetricCPUNrThrottledContainer, 1},
+	{cadvisorCPUPeriods, consts.MetricCPUThrottledPeriodContainer, 1},
+	{cadvisorCPUThrottledSeconds, consts.MetricCPUThrottledTimeContainer, float64(time.Second)},
+	{cadvisorMemLimit, consts.MetricMemLimitContainer, 1},
+	{cadvisorMemCache, consts.MetricMemCacheContainer, 1},
+	{cadvisorMemOOMEvents, consts.MetricMemOomContainer, 1},
+}
+
+// processCadvisorMetrics sets container metrics which are not provided by kubelet summary
+func (k *KubeletMetricsFetcher) processCadvisorMetrics(families map[string]*dto.MetricFamily, podUIDs map[string]string) {
+	for _, mapping := range cadvisorMetricsMapping {
+		family, ok := families[mapping.cadvisorName]
+		if !ok {
+			continue
+		}
+
+		for _, m := range family.Metric {
+			labels := make(map[string]string)
+			for _, label := range m.Label {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			// skip cgroups of pods and sandboxes, which have no or empty container label
+			containerName := labels[cadvisorLabelContainer]
+			if containerName == "" || containerName == "POD" {
+				continue
+			}
+
+			podUID, ok := podUIDs[labels[cadvisorLabelNamespace]+"/"+labels[cadvisorLabelPod]]
+			if !ok {
+				continue
+			}
+
+			var value float64
+			switch {
+			case m.Gauge != nil:
+				value = m.Gauge.GetValue()
+			case m.Counter != nil:
+				value = m.Counter.GetValue()
+			case m.Untyped != nil:
+				value = m.Untyped.GetValue()
+			default:
+				continue
+			}
+
+			// timestamp of cadvisor metrics is optional, and current time is used if it's absent
+			var updateTime time.Time
+			if m.TimestampMs != nil {
+				updateTime = time.UnixMilli(m.GetTimestampMs())
+			}
+			k.metricStore.SetContainerMetricWithTime(podUID, containerName, mapping.metricName,
+				metric.MetricData{Value: value * mapping.scale, Time: updateTime})
+
+			// cpu limit is derived from quota and period, and quota is set after period in the mapping
+			if mapping.metricName == consts.MetricCPUQuotaContainer && value > 0 {
+				if period, err := k.metricStore.GetContainerMetric(podUID, containerName, consts.MetricCPUPeriodContainer); err == nil && period > 0 {
+					k.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPULimitContainer,
+						metric.MetricData{Value: value / period, Time: updateTime})
+				}
+			}
+		}
+	}
+}
+
+// getSummary returns kubelet summary fetched in this round of sampling if any
+func (k *KubeletMetricsFetcher) getSummary() (*statsapi.Summary, error) {
+	if k.summary != nil {
+		return k.summary, nil
+	}
+
+	summary := &statsapi.Summary{}
+	if err := k.kubeletClient.GetAndUnmarshal(kubeletSummaryPath, summary); err != nil {
+		_ = k.emitter.StoreInt64(metricsNameKubeletGetSummaryFailed, 1, metrics.MetricTypeNameCount)
+		return nil, fmt.Errorf("get kubelet summary failed, err %v", err)
+	}
+	return summary, nil
+}
+
+func (k *KubeletMetricsFetcher) getCadvisorMetrics() (map[string]*dto.MetricFamily, error) {
+	families, err := k.fetchCadvisorMetrics()
+	if err != nil {
+		_ = k.emitter.StoreInt64(metricsNameKubeletGetCadvisorFailed, 1, metrics.MetricTypeNameCount)
+		return nil, fmt.Errorf("get kubelet cadvisor metrics failed, err %v", err)
+	}
+	return families, nil
+}
+
+func (k *KubeletMetricsFetcher) fetchCadvisorMetrics() (map[string]*dto.MetricFamily, error) {
+	resp, err := k.kubeletClient.Get(kubeletCadvisorPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
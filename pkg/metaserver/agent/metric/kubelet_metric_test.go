@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
+)
+
+const testKubeletSummary = `{
+  "node": {
+    "nodeName": "node1",
+    "memory": {"time": "2022-01-01T00:00:00Z", "availableBytes": 6144, "workingSetBytes": 10240}
+  },
+  "pods": [
+    {
+      "podRef": {"name": "pod1", "namespace": "default", "uid": "uid1"},
+      "containers": [
+        {
+          "name": "c1",
+          "cpu": {"time": "2022-01-01T00:00:00Z", "usageNanoCores": 1500000000},
+          "memory": {"time": "2022-01-01T00:00:00Z", "usageBytes": 4096, "rssBytes": 2048, "pageFaults": 10, "majorPageFaults": 1}
+        }
+      ]
+    }
+  ]
+}`
+
+const testKubeletCadvisor = `# TYPE container_spec_cpu_quota gauge
+container_spec_cpu_quota{container="c1",id="/kubepods/poduid1/cid1",namespace="default",pod="pod1"} 200000
+# TYPE container_spec_cpu_period gauge
+container_spec_cpu_period{container="c1",id="/kubepods/poduid1/cid1",namespace="default",pod="pod1"} 100000
+# TYPE container_cpu_cfs_throttled_seconds_total counter
+container_cpu_cfs_throttled_seconds_total{container="c1",id="/kubepods/poduid1/cid1",namespace="default",pod="pod1"} 1.5
+# TYPE container_memory_cache gauge
+container_memory_cache{container="",id="/kubepods/poduid1",namespace="default",pod="pod1"} 4096
+container_memory_cache{container="c1",id="/kubepods/poduid1/cid1",namespace="default",pod="pod1"} 1024
+container_memory_cache{container="c2",id="/kubepods/poduid2/cid2",namespace="default",pod="pod2"} 512
+`
+
+func TestKubeletMetricsFetcher(t *testing.T) {
+	t.Parallel()
+
+	var cadvisorUnavailable, summaryRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case kubeletSummaryPath:
+			atomic.AddInt32(&summaryRequests, 1)
+			_, _ = fmt.Fprint(w, testKubeletSummary)
+		case kubeletCadvisorPath:
+			if atomic.LoadInt32(&cadvisorUnavailable) > 0 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = fmt.Fprint(w, testKubeletCadvisor)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	conf, err := options.NewOptions().Config()
+	require.NoError(t, err)
+	conf.MetricsFetcher = MetricsFetcherKubelet
+	conf.KubeletReadOnlyPort = port
+	conf.NativeProcFSRoot = makeTestKubeletProcFS(t)
+
+	fetcher, err := NewMetricsFetcher(conf, &pod.PodFetcherStub{}, metrics.DummyMetrics{})
+	require.NoError(t, err)
+	f := fetcher.(*KubeletMetricsFetcher)
+	f.metricStore = metric.NewMetricStore()
+	f.sample(context.Background())
+	// summary is shared by all scopes in one round of sampling
+	require.Equal(t, int32(1), atomic.LoadInt32(&summaryRequests))
+
+	for name, expected := range map[string]float64{
+		consts.MetricMemAvailableSystem:   6144,
+		consts.MetricMemUsedSystem:        10240,
+		consts.MetricMemTotalSystem:       16384,
+		consts.MetricMemFreeSystem:        2048 << 10,
+		consts.MetricMemScaleFactorSystem: 10,
+	} {
+		value, err := f.GetNodeMetric(name)
+		require.NoError(t, err, name)
+		require.Equal(t, expected, value, name)
+	}
+	// metrics are stamped with the time kubelet sampled them
+	sampleTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	data, err := f.GetNodeMetricWithTime(consts.MetricMemAvailableSystem)
+	require.NoError(t, err)
+	require.True(t, sampleTime.Equal(data.Time))
+	data, err = f.GetContainerMetricWithTime("uid1", "c1", consts.MetricCPUUsageContainer)
+	require.NoError(t, err)
+	require.True(t, sampleTime.Equal(data.Time))
+	_, err = f.GetNodeMetric(consts.MetricLoad1MinSystem)
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrMetricsUnsupported))
+
+	for name, expected := range map[string]float64{
+		consts.MetricCPUUsageContainer:         1.5,
+		consts.MetricMemUsageContainer:         4096,
+		consts.MetricMemRssContainer:           2048,
+		consts.MetricMemPgfaultContainer:       10,
+		consts.MetricMemPgmajfaultContainer:    1,
+		consts.MetricCPUQuotaContainer:         200000,
+		consts.MetricCPUPeriodContainer:        100000,
+		consts.MetricCPULimitContainer:         2,
+		consts.MetricCPUThrottledTimeContainer: float64(1500 * time.Millisecond),
+		consts.MetricMemCacheContainer:         1024,
+	} {
+		value, err := f.GetContainerMetric("uid1", "c1", name)
+		require.NoError(t, err, name)
+		require.Equal(t, expected, value, name)
+	}
+	_, err = f.GetContainerMetric("uid2", "c2", consts.MetricMemCacheContainer)
+	require.Error(t, err)
+
+	// metrics of other scopes are unsupported instead of missing
+	_, err = f.GetNumaMetric(0, consts.MetricMemTotalNuma)
+	require.True(t, errors.Is(err, ErrMetricsUnsupported))
+	_, err = f.GetCPUMetricWithMaxAge(0, consts.MetricCPUUsage, time.Minute)
+	require.True(t, errors.Is(err, ErrMetricsUnsupported))
+	_, err = f.GetContainerNumaMetric("uid1", "c1", "0", consts.MetricsMemTotalPerNumaContainer)
+	require.True(t, errors.Is(err, ErrMetricsUnsupported))
+	_, err = f.AggregatePodNumaMetric(nil, "0", consts.MetricsMemTotalPerNumaContainer, metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	require.True(t, errors.Is(err, ErrMetricsUnsupported))
+	_, err = f.AggregateCoreMetric(machine.NewCPUSet(0), consts.MetricCPUUsage, metric.AggregatorSum)
+	require.True(t, errors.Is(err, ErrMetricsUnsupported))
+	sum, err := f.AggregatePodMetric(nil, consts.MetricCPUUsageContainer, metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	require.NoError(t, err)
+	require.Zero(t, sum)
+	require.Empty(t, f.RegisterNotifier(MetricsScopeNuma, NotifiedRequest{MetricName: consts.MetricMemTotalNuma}, make(chan NotifiedResponse)))
+	require.NotEmpty(t, f.RegisterNotifier(MetricsScopeNode, NotifiedRequest{MetricName: consts.MetricMemTotalSystem}, make(chan NotifiedResponse, 1)))
+
+	// summary metrics are still updated if cadvisor metrics are unavailable
+	atomic.StoreInt32(&cadvisorUnavailable, 1)
+	now := time.Now().Add(time.Hour)
+	require.Error(t, f.updatePodsStats(context.Background(), now))
+	value, err := f.GetContainerMetric("uid1", "c1", consts.MetricUpdateTimeContainer)
+	require.NoError(t, err)
+	require.Equal(t, float64(now.Unix()), value)
+}
+
+// makeTestKubeletProcFS makes a procfs root with memory files read by kubelet metrics fetcher
+func makeTestKubeletProcFS(t *testing.T) string {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys", "vm"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "meminfo"),
+		[]byte("MemTotal:          16 kB\nMemFree:         2048 kB\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "sys", "vm", "watermark_scale_factor"),
+		[]byte("10\n"), 0o644))
+	return root
+}
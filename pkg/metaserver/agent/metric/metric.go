@@ -18,21 +18,21 @@ package metric
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 
+	"github.com/kubewharf/katalyst-core/pkg/config"
 	"github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/malachite/cgroup"
-	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/malachite/client"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/malachite/system"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/general"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
@@ -45,14 +45,34 @@ const (
 	metricsNameMalachiteGetPodStatusFailed    = "malachite_get_pod_status_failed"
 )
 
+const (
+	MetricsFetcherMalachite = "malachite"
+	MetricsFetcherNative    = "native"
+	MetricsFetcherKubelet   = "kubelet"
+)
+
+// NewMetricsFetcher returns the MetricsFetcher of the type in configuration.
+func NewMetricsFetcher(conf *config.Configuration, podFetcher pod.PodFetcher, emitter metrics.MetricEmitter) (MetricsFetcher, error) {
+	switch conf.MetricsFetcher {
+	case MetricsFetcherMalachite:
+		return NewMalachiteMetricsFetcher(conf, emitter), nil
+	case MetricsFetcherNative:
+		return NewNativeMetricsFetcher(conf, podFetcher, emitter), nil
+	case MetricsFetcherKubelet:
+		return NewKubeletMetricsFetcher(conf, emitter)
+	default:
+		return nil, fmt.Errorf("unknown metrics fetcher type %v", conf.MetricsFetcher)
+	}
+}
+
 type MetricsScope string
 
 const (
-	MetricsScopeNode      MetricsScope = "node"
-	MetricsScopeNuma      MetricsScope = "numa"
-	MetricsScopeCPU       MetricsScope = "cpu"
-	MetricsScopeDevice    MetricsScope = "device"
-	MetricsScopeContainer MetricsScope = "container"
+	MetricsScopeNode      MetricsScope = metric.MetricScopeNode
+	MetricsScopeNuma      MetricsScope = metric.MetricScopeNuma
+	MetricsScopeCPU       MetricsScope = metric.MetricScopeCPU
+	MetricsScopeDevice    MetricsScope = metric.MetricScopeDevice
+	MetricsScopeContainer MetricsScope = metric.MetricScopeContainer
 )
 
 type NotifiedRequest struct {
@@ -65,6 +85,10 @@ type NotifiedRequest struct {
 	PodUID        string
 	ContainerName string
 	NumaNode      string
+
+	// Interval is the sampling interval expected by the consumer, and the fetcher samples the
+	// whole scope of the metric at the fastest cadence of all registrations within admin-set bounds
+	Interval time.Duration
 }
 
 type NotifiedResponse struct {
@@ -79,6 +103,10 @@ type NotifiedData struct {
 	response chan NotifiedResponse
 }
 
+// ErrMetricsUnsupported is returned if metrics of the scope is not provided by the
+// MetricsFetcher implementation at all, which differs from missing metrics data.
+var ErrMetricsUnsupported = errors.New("metrics unsupported by metrics fetcher")
+
 // MetricsFetcher is used to get Node and Pod metrics.
 type MetricsFetcher interface {
 	// Run starts the preparing logic to collect node metadata.
@@ -106,12 +134,31 @@ type MetricsFetcher interface {
 	// GetContainerNumaMetric get metric of container per numa.
 	GetContainerNumaMetric(podUID, containerName, numaNode, metricName string) (float64, error)
 
-	// AggregatePodNumaMetric handles numa-level metric for all pods
-	AggregatePodNumaMetric(podList []*v1.Pod, numaNode, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) float64
+	// Get*WithTime returns metric data along with the time it is stored, so that
+	// callers can tell whether the data is fresh enough for their own purposes.
+	GetNodeMetricWithTime(metricName string) (metric.MetricData, error)
+	GetNumaMetricWithTime(numaID int, metricName string) (metric.MetricData, error)
+	GetDeviceMetricWithTime(deviceName string, metricName string) (metric.MetricData, error)
+	GetCPUMetricWithTime(coreID int, metricName string) (metric.MetricData, error)
+	GetContainerMetricWithTime(podUID, containerName, metricName string) (metric.MetricData, error)
+	GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName string) (metric.MetricData, error)
+
+	// Get*WithMaxAge returns metric.ErrMetricDataStale if metric data is older than maxAge,
+	// and callers should degrade safely instead of acting on stale data in this case.
+	GetNodeMetricWithMaxAge(metricName string, maxAge time.Duration) (float64, error)
+	GetNumaMetricWithMaxAge(numaID int, metricName string, maxAge time.Duration) (float64, error)
+	GetDeviceMetricWithMaxAge(deviceName string, metricName string, maxAge time.Duration) (float64, error)
+	GetCPUMetricWithMaxAge(coreID int, metricName string, maxAge time.Duration) (float64, error)
+	GetContainerMetricWithMaxAge(podUID, containerName, metricName string, maxAge time.Duration) (float64, error)
+	GetContainerNumaMetricWithMaxAge(podUID, containerName, numaNode, metricName string, maxAge time.Duration) (float64, error)
+
+	// AggregatePodNumaMetric handles numa-level metric for all pods, and ErrMetricsUnsupported
+	// is returned if numa-level metrics are not provided by the implementation; so are others
+	AggregatePodNumaMetric(podList []*v1.Pod, numaNode, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) (float64, error)
 	// AggregatePodMetric handles metric for all pods
-	AggregatePodMetric(podList []*v1.Pod, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) float64
+	AggregatePodMetric(podList []*v1.Pod, metricName string, agg metric.Aggregator, filter metric.ContainerMetricFilter) (float64, error)
 	// AggregateCoreMetric handles metric for all cores
-	AggregateCoreMetric(cpuset machine.CPUSet, metricName string, agg metric.Aggregator) float64
+	AggregateCoreMetric(cpuset machine.CPUSet, metricName string, agg metric.Aggregator) (float64, error)
 }
 
 var (
@@ -119,438 +166,347 @@ var (
 )
 
 // NewMalachiteMetricsFetcher returns the default implementation of MetricsFetcher.
-func NewMalachiteMetricsFetcher(emitter metrics.MetricEmitter) MetricsFetcher {
-	return &MalachiteMetricsFetcher{
-		metricStore: metric.GetMetricStoreInstance(),
-		emitter:     emitter,
-		registered: map[MetricsScope]map[string]NotifiedData{
-			MetricsScopeNode:      make(map[string]NotifiedData),
-			MetricsScopeNuma:      make(map[string]NotifiedData),
-			MetricsScopeCPU:       make(map[string]NotifiedData),
-			MetricsScopeDevice:    make(map[string]NotifiedData),
-			MetricsScopeContainer: make(map[string]NotifiedData),
-		},
+func NewMalachiteMetricsFetcher(conf *config.Configuration, emitter metrics.MetricEmitter) MetricsFetcher {
+	m := &MalachiteMetricsFetcher{
+		baseMetricsFetcher: newBaseMetricsFetcher(conf, metric.GetMetricStoreInstance(), emitter),
 	}
+
+	m.precheck = m.checkMalachiteHealthy
+	m.samplers[MetricsScopeNode] = m.updateNodeStats
+	m.samplers[MetricsScopeNuma] = m.updateNumaStats
+	m.samplers[MetricsScopeCPU] = m.updateCPUStats
+	m.samplers[MetricsScopeDevice] = m.updateDeviceStats
+	m.samplers[MetricsScopeContainer] = m.updatePodsCgroupData
+	return m
 }
 
 type MalachiteMetricsFetcher struct {
-	metricStore *metric.MetricStore
-	emitter     metrics.MetricEmitter
+	*baseMetricsFetcher
 
-	sync.RWMutex
-	registered map[MetricsScope]map[string]NotifiedData
+	// system stats are fetched at most once in each round of sampling and shared by
+	// scopes, and they are only accessed by the sampling goroutine
+	systemComputeData *system.SystemComputeData
+	systemMemoryData  *system.SystemMemoryData
 }
 
 func (m *MalachiteMetricsFetcher) Run(ctx context.Context) {
 	malachiteMetricsFetcherInitOnce.Do(func() {
-		go wait.Until(func() { m.sample() }, time.Second*5, ctx.Done())
+		go m.runSampling(ctx)
 	})
 }
 
-func (m *MalachiteMetricsFetcher) RegisterNotifier(scope MetricsScope, req NotifiedRequest, response chan NotifiedResponse) string {
-	if _, ok := m.registered[scope]; !ok {
-		return ""
-	}
-
-	m.Lock()
-	defer m.Unlock()
-
-	randBytes := make([]byte, 30)
-	rand.Read(randBytes)
-	key := string(randBytes)
+// checkMalachiteHealthy is to check whether malachite is healthy before each round of sampling,
+// and system compute stats fetched by it are kept for samplers in this round
+func (m *MalachiteMetricsFetcher) checkMalachiteHealthy() error {
+	klog.V(4).Infof("[malachite] heartbeat")
 
-	m.registered[scope][key] = NotifiedData{
-		scope:    scope,
-		req:      req,
-		response: response,
+	m.systemComputeData, m.systemMemoryData = nil, nil
+	systemComputeData, err := system.GetSystemComputeStats()
+	if err != nil {
+		_ = m.emitter.StoreInt64(metricsNamMalachiteUnHealthy, 1, metrics.MetricTypeNameRaw)
+		return fmt.Errorf("malachite is unhealthy: %v", err)
 	}
-	return key
-}
-
-func (m *MalachiteMetricsFetcher) DeRegisterNotifier(scope MetricsScope, key string) {
-	m.Lock()
-	defer m.Unlock()
-
-	delete(m.registered[scope], key)
-}
-
-func (m *MalachiteMetricsFetcher) GetNodeMetric(metricName string) (float64, error) {
-	return m.metricStore.GetNodeMetric(metricName)
-}
-
-func (m *MalachiteMetricsFetcher) GetNumaMetric(numaID int, metricName string) (float64, error) {
-	return m.metricStore.GetNumaMetric(numaID, metricName)
-}
-
-func (m *MalachiteMetricsFetcher) GetDeviceMetric(deviceName string, metricName string) (float64, error) {
-	return m.metricStore.GetDeviceMetric(deviceName, metricName)
-}
-
-func (m *MalachiteMetricsFetcher) GetCPUMetric(coreID int, metricName string) (float64, error) {
-	return m.metricStore.GetCPUMetric(coreID, metricName)
-}
+	m.systemComputeData = systemComputeData
 
-func (m *MalachiteMetricsFetcher) GetContainerMetric(podUID, containerName, metricName string) (float64, error) {
-	return m.metricStore.GetContainerMetric(podUID, containerName, metricName)
+	return nil
 }
 
-func (m *MalachiteMetricsFetcher) GetContainerNumaMetric(podUID, containerName, numaNode, metricName string) (float64, error) {
-	return m.metricStore.GetContainerNumaMetric(podUID, containerName, numaNode, metricName)
-}
-
-func (m *MalachiteMetricsFetcher) AggregatePodNumaMetric(podList []*v1.Pod, numaNode, metricName string,
-	agg metric.Aggregator, filter metric.ContainerMetricFilter) float64 {
-	return m.metricStore.AggregatePodNumaMetric(podList, numaNode, metricName, agg, filter)
-}
+// Get raw system compute and memory stats by malachite sdk and set node metrics to metricStore
+func (m *MalachiteMetricsFetcher) updateNodeStats(_ context.Context, _ time.Time) error {
+	systemComputeData, err := m.getSystemComputeStats()
+	if err != nil {
+		return err
+	}
+	m.processSystemComputeData(systemComputeData)
 
-func (m *MalachiteMetricsFetcher) AggregatePodMetric(podList []*v1.Pod, metricName string,
-	agg metric.Aggregator, filter metric.ContainerMetricFilter) float64 {
-	return m.metricStore.AggregatePodMetric(podList, metricName, agg, filter)
+	systemMemoryData, err := m.getSystemMemoryStats()
+	if err != nil {
+		return err
+	}
+	m.processSystemMemoryData(systemMemoryData)
+	return nil
 }
 
-func (m *MalachiteMetricsFetcher) AggregateCoreMetric(cpuset machine.CPUSet, metricName string, agg metric.Aggregator) float64 {
-	return m.metricStore.AggregateCoreMetric(cpuset, metricName, agg)
+// Get raw system memory stats by malachite sdk and set numa metrics to metricStore
+func (m *MalachiteMetricsFetcher) updateNumaStats(_ context.Context, _ time.Time) error {
+	systemMemoryData, err := m.getSystemMemoryStats()
+	if err != nil {
+		return err
+	}
+	m.processSystemNumaData(systemMemoryData)
+	return nil
 }
 
-func (m *MalachiteMetricsFetcher) sample() {
-	klog.V(4).Infof("[malachite] heartbeat")
-
-	if !m.checkMalachiteHealthy() {
-		return
+// Get raw system compute stats by malachite sdk and set cpu metrics to metricStore
+func (m *MalachiteMetricsFetcher) updateCPUStats(_ context.Context, _ time.Time) error {
+	systemComputeData, err := m.getSystemComputeStats()
+	if err != nil {
+		return err
 	}
-
-	cur := time.Now()
-	// Update system data
-	m.updateSystemStats(cur)
-
-	// Update pod data
-	m.updatePodsCgroupData(cur)
+	m.processSystemCPUComputeData(systemComputeData)
+	return nil
 }
 
-// checkMalachiteHealthy is to check whether malachite is healthy
-func (m *MalachiteMetricsFetcher) checkMalachiteHealthy() bool {
-	_, err := client.DefaultClient.GetSystemStats(client.Compute)
+// Get raw system io stats by malachite sdk and set device metrics to metricStore
+func (m *MalachiteMetricsFetcher) updateDeviceStats(_ context.Context, _ time.Time) error {
+	systemIOData, err := system.GetSystemIOStats()
 	if err != nil {
-		klog.Errorf("[malachite] malachite is unhealthy: %v", err)
-		_ = m.emitter.StoreInt64(metricsNamMalachiteUnHealthy, 1, metrics.MetricTypeNameRaw)
-		return false
+		_ = m.emitter.StoreInt64(metricsNameMalachiteGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
+			metrics.MetricTag{Key: "kind", Val: "io"})
+		return fmt.Errorf("get system io stats failed, err %v", err)
 	}
-
-	return true
+	m.processSystemIOData(systemIOData)
+	return nil
 }
 
-// Get raw system stats by malachite sdk and set to metricStore
-func (m *MalachiteMetricsFetcher) updateSystemStats(cur time.Time) {
+// getSystemComputeStats returns system compute stats fetched in this round of sampling if any
+func (m *MalachiteMetricsFetcher) getSystemComputeStats() (*system.SystemComputeData, error) {
+	if m.systemComputeData != nil {
+		return m.systemComputeData, nil
+	}
+
 	systemComputeData, err := system.GetSystemComputeStats()
 	if err != nil {
-		klog.Errorf("[malachite] get system compute stats failed, err %v", err)
 		_ = m.emitter.StoreInt64(metricsNameMalachiteGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
 			metrics.MetricTag{Key: "kind", Val: "compute"})
-	} else {
-		m.processSystemComputeData(systemComputeData)
-		m.processSystemCPUComputeData(systemComputeData)
+		return nil, fmt.Errorf("get system compute stats failed, err %v", err)
 	}
+	m.systemComputeData = systemComputeData
+	return systemComputeData, nil
+}
 
-	systemMemoryData, err := system.GetSystemMemoryStats()
-	if err != nil {
-		klog.Errorf("[malachite] get system memory stats failed, err %v", err)
-		_ = m.emitter.StoreInt64(metricsNameMalachiteGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
-			metrics.MetricTag{Key: "kind", Val: "memory"})
-	} else {
-		m.processSystemMemoryData(systemMemoryData)
-		m.processSystemNumaData(systemMemoryData)
+// getSystemMemoryStats returns system memory stats fetched in this round of sampling if any
+func (m *MalachiteMetricsFetcher) getSystemMemoryStats() (*system.SystemMemoryData, error) {
+	if m.systemMemoryData != nil {
+		return m.systemMemoryData, nil
 	}
 
-	systemIOData, err := system.GetSystemIOStats()
+	systemMemoryData, err := system.GetSystemMemoryStats()
 	if err != nil {
-		klog.Errorf("[malachite] get system io stats failed, err %v", err)
 		_ = m.emitter.StoreInt64(metricsNameMalachiteGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
-			metrics.MetricTag{Key: "kind", Val: "io"})
-	} else {
-		m.processSystemIOData(systemIOData)
+			metrics.MetricTag{Key: "kind", Val: "memory"})
+		return nil, fmt.Errorf("get system memory stats failed, err %v", err)
 	}
-
-	m.notifySystem(cur)
+	m.systemMemoryData = systemMemoryData
+	return systemMemoryData, nil
 }
 
 // Get raw cgroup data by malachite sdk and set container metrics to metricStore, GC not existed pod metrics
-func (m *MalachiteMetricsFetcher) updatePodsCgroupData(cur time.Time) {
+func (m *MalachiteMetricsFetcher) updatePodsCgroupData(_ context.Context, _ time.Time) error {
 	podsContainersStats, err := cgroup.GetAllPodsContainersStats()
 	if err != nil {
-		klog.Errorf("[malachite] GetAllPodsContainersStats failed, error %v", err)
 		_ = m.emitter.StoreInt64(metricsNameMalachiteGetPodStatusFailed, 1, metrics.MetricTypeNameCount)
+		return fmt.Errorf("GetAllPodsContainersStats failed, error %v", err)
 	}
 
 	podUIDSet := make(map[string]bool)
 	for podUID, containerStats := range podsContainersStats {
 		podUIDSet[podUID] = true
 		for containerName, cgStats := range containerStats {
-			m.processCgroupCPUData(podUID, containerName, cgStats)
-			m.processCgroupMemoryData(podUID, containerName, cgStats)
-			m.processCgroupBlkIOData(podUID, containerName, cgStats)
-			m.processCgroupNetData(podUID, containerName, cgStats)
-			m.processCgroupPerfData(podUID, containerName, cgStats)
-			m.processCgroupPerNumaMemoryData(podUID, containerName, cgStats)
+			updateTime := malachiteCgroupSampleTime(cgStats)
+			m.processCgroupCPUData(podUID, containerName, cgStats, updateTime)
+			m.processCgroupMemoryData(podUID, containerName, cgStats, updateTime)
+			m.processCgroupBlkIOData(podUID, containerName, cgStats, updateTime)
+			m.processCgroupNetData(podUID, containerName, cgStats, updateTime)
+			m.processCgroupPerfData(podUID, containerName, cgStats, updateTime)
+			m.processCgroupPerNumaMemoryData(podUID, containerName, cgStats, updateTime)
 		}
 	}
 	m.metricStore.GCPodsMetric(podUIDSet)
-
-	m.notifyPods(cur)
+	return nil
 }
 
-// notifySystem notifies system-related data
-// todo: cur should be replaced with fetched timestamp
-func (m *MalachiteMetricsFetcher) notifySystem(cur time.Time) {
-	m.RLock()
-	defer m.RUnlock()
-
-	for _, reg := range m.registered[MetricsScopeNode] {
-		v, err := m.metricStore.GetNodeMetric(reg.req.MetricName)
-		if err != nil {
-			continue
-		}
-		reg.response <- NotifiedResponse{
-			Req:       reg.req,
-			Result:    v,
-			Timestamp: cur,
-		}
-	}
-
-	for _, reg := range m.registered[MetricsScopeDevice] {
-		v, err := m.metricStore.GetDeviceMetric(reg.req.DeviceID, reg.req.MetricName)
-		if err != nil {
-			continue
-		}
-		reg.response <- NotifiedResponse{
-			Req:       reg.req,
-			Result:    v,
-			Timestamp: cur,
-		}
-	}
-
-	for _, reg := range m.registered[MetricsScopeNuma] {
-		v, err := m.metricStore.GetNumaMetric(reg.req.NumaID, reg.req.MetricName)
-		if err != nil {
-			continue
-		}
-		reg.response <- NotifiedResponse{
-			Req:       reg.req,
-			Result:    v,
-			Timestamp: cur,
-		}
-	}
-
-	for _, reg := range m.registered[MetricsScopeCPU] {
-		v, err := m.metricStore.GetCPUMetric(reg.req.CoreID, reg.req.MetricName)
-		if err != nil {
-			continue
-		}
-		reg.response <- NotifiedResponse{
-			Req:       reg.req,
-			Result:    v,
-			Timestamp: cur,
-		}
+// malachiteSampleTime converts the update time (in unix seconds) reported by malachite
+// into the sample time of metrics, and zero time is returned if it's not reported
+func malachiteSampleTime(updateTime int64) time.Time {
+	if updateTime <= 0 {
+		return time.Time{}
 	}
+	return time.Unix(updateTime, 0)
 }
 
-// notifySystem notifies pod-related data
-// todo: cur should be replaced with fetched timestamp
-func (m *MalachiteMetricsFetcher) notifyPods(cur time.Time) {
-	m.RLock()
-	defer m.RUnlock()
-
-	for _, reg := range m.registered[MetricsScopeContainer] {
-		v, err := m.metricStore.GetContainerMetric(reg.req.PodUID, reg.req.ContainerName, reg.req.MetricName)
-		if err != nil {
-			continue
-		}
-		reg.response <- NotifiedResponse{
-			Req:       reg.req,
-			Result:    v,
-			Timestamp: cur,
-		}
-
-		if reg.req.NumaID == 0 {
-			continue
-		}
-
-		v, err = m.metricStore.GetContainerNumaMetric(reg.req.PodUID, reg.req.ContainerName, fmt.Sprintf("%v", reg.req.NumaID), reg.req.MetricName)
-		if err != nil {
-			continue
-		}
-		reg.response <- NotifiedResponse{
-			Req:       reg.req,
-			Result:    v,
-			Timestamp: cur,
-		}
+// malachiteCgroupSampleTime returns the sample time of cgroup stats, and the update
+// time of cpu stats is used since other subsystems don't report it
+func malachiteCgroupSampleTime(cgStats *cgroup.MalachiteCgroupInfo) time.Time {
+	switch {
+	case cgStats.CgroupType == "V1" && cgStats.V1 != nil && cgStats.V1.Cpu != nil:
+		return malachiteSampleTime(int64(cgStats.V1.Cpu.UpdateTime))
+	case cgStats.CgroupType == "V2" && cgStats.V2 != nil && cgStats.V2.Cpu != nil:
+		return malachiteSampleTime(int64(cgStats.V2.Cpu.UpdateTime))
 	}
+	return time.Time{}
 }
 
 func (m *MalachiteMetricsFetcher) processSystemComputeData(systemComputeData *system.SystemComputeData) {
+	updateTime := malachiteSampleTime(systemComputeData.UpdateTime)
 	load := systemComputeData.Load
-	m.metricStore.SetNodeMetric(consts.MetricLoad1MinSystem, load.One)
-	m.metricStore.SetNodeMetric(consts.MetricLoad5MinSystem, load.Five)
-	m.metricStore.SetNodeMetric(consts.MetricLoad15MinSystem, load.Fifteen)
+	m.metricStore.SetNodeMetricWithTime(consts.MetricLoad1MinSystem, metric.MetricData{Value: load.One, Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricLoad5MinSystem, metric.MetricData{Value: load.Five, Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricLoad15MinSystem, metric.MetricData{Value: load.Fifteen, Time: updateTime})
 }
 
 func (m *MalachiteMetricsFetcher) processSystemMemoryData(systemMemoryData *system.SystemMemoryData) {
+	updateTime := malachiteSampleTime(systemMemoryData.UpdateTime)
 	mem := systemMemoryData.System
-	m.metricStore.SetNodeMetric(consts.MetricMemTotalSystem, float64(mem.MemTotal<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemUsedSystem, float64(mem.MemUsed<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemFreeSystem, float64(mem.MemFree<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemShmemSystem, float64(mem.MemShm<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemBufferSystem, float64(mem.MemBuffers<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemAvailableSystem, float64(mem.MemAvailable<<10))
-
-	m.metricStore.SetNodeMetric(consts.MetricMemDirtySystem, float64(mem.MemDirtyPageCache<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemWritebackSystem, float64(mem.MemWriteBackPageCache<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemKswapdstealSystem, float64(mem.VmstatPgstealKswapd))
-
-	m.metricStore.SetNodeMetric(consts.MetricMemSwapTotalSystem, float64(mem.MemSwapTotal<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemSwapFreeSystem, float64(mem.MemSwapFree<<10))
-	m.metricStore.SetNodeMetric(consts.MetricMemSlabReclaimableSystem, float64(mem.MemSlabReclaimable<<10))
-
-	m.metricStore.SetNodeMetric(consts.MetricMemScaleFactorSystem, float64(mem.VMWatermarkScaleFactor))
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemTotalSystem, metric.MetricData{Value: float64(mem.MemTotal << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemUsedSystem, metric.MetricData{Value: float64(mem.MemUsed << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemFreeSystem, metric.MetricData{Value: float64(mem.MemFree << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemShmemSystem, metric.MetricData{Value: float64(mem.MemShm << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemBufferSystem, metric.MetricData{Value: float64(mem.MemBuffers << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemAvailableSystem, metric.MetricData{Value: float64(mem.MemAvailable << 10), Time: updateTime})
+
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemDirtySystem, metric.MetricData{Value: float64(mem.MemDirtyPageCache << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemWritebackSystem, metric.MetricData{Value: float64(mem.MemWriteBackPageCache << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemKswapdstealSystem, metric.MetricData{Value: float64(mem.VmstatPgstealKswapd), Time: updateTime})
+
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemSwapTotalSystem, metric.MetricData{Value: float64(mem.MemSwapTotal << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemSwapFreeSystem, metric.MetricData{Value: float64(mem.MemSwapFree << 10), Time: updateTime})
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemSlabReclaimableSystem, metric.MetricData{Value: float64(mem.MemSlabReclaimable << 10), Time: updateTime})
+
+	m.metricStore.SetNodeMetricWithTime(consts.MetricMemScaleFactorSystem, metric.MetricData{Value: float64(mem.VMWatermarkScaleFactor), Time: updateTime})
 }
 
 func (m *MalachiteMetricsFetcher) processSystemIOData(systemIOData *system.SystemDiskIoData) {
+	updateTime := malachiteSampleTime(systemIOData.UpdateTime)
 	for _, device := range systemIOData.DiskIo {
-		m.metricStore.SetDeviceMetric(device.DeviceName, consts.MetricIOReadSystem, float64(device.IoRead))
-		m.metricStore.SetDeviceMetric(device.DeviceName, consts.MetricIOWriteSystem, float64(device.IoWrite))
-		m.metricStore.SetDeviceMetric(device.DeviceName, consts.MetricIOBusySystem, float64(device.IoBusy))
+		m.metricStore.SetDeviceMetricWithTime(device.DeviceName, consts.MetricIOReadSystem, metric.MetricData{Value: float64(device.IoRead), Time: updateTime})
+		m.metricStore.SetDeviceMetricWithTime(device.DeviceName, consts.MetricIOWriteSystem, metric.MetricData{Value: float64(device.IoWrite), Time: updateTime})
+		m.metricStore.SetDeviceMetricWithTime(device.DeviceName, consts.MetricIOBusySystem, metric.MetricData{Value: float64(device.IoBusy), Time: updateTime})
 	}
 }
 
 func (m *MalachiteMetricsFetcher) processSystemNumaData(systemMemoryData *system.SystemMemoryData) {
+	updateTime := malachiteSampleTime(systemMemoryData.UpdateTime)
 	for _, numa := range systemMemoryData.Numa {
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemTotalNuma, float64(numa.MemTotal<<10))
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemUsedNuma, float64(numa.MemUsed<<10))
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemFreeNuma, float64(numa.MemFree<<10))
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemShmemNuma, float64(numa.MemShmem<<10))
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemAvailableNuma, float64(numa.MemAvailable<<10))
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemFilepageNuma, float64(numa.MemFilePages<<10))
-
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemBandwidthNuma, numa.MemReadBandwidthMB/1024.0+numa.MemWriteBandwidthMB/1024.0)
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemBandwidthMaxNuma, numa.MemTheoryMaxBandwidthMB*0.8/1024.0)
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemBandwidthTheoryNuma, numa.MemTheoryMaxBandwidthMB/1024.0)
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemBandwidthReadNuma, numa.MemReadBandwidthMB/1024.0)
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemBandwidthWriteNuma, numa.MemWriteBandwidthMB/1024.0)
-
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemLatencyReadNuma, numa.MemReadLatency)
-		m.metricStore.SetNumaMetric(numa.ID, consts.MetricMemLatencyWriteNuma, numa.MemWriteLatency)
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemTotalNuma, metric.MetricData{Value: float64(numa.MemTotal << 10), Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemUsedNuma, metric.MetricData{Value: float64(numa.MemUsed << 10), Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemFreeNuma, metric.MetricData{Value: float64(numa.MemFree << 10), Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemShmemNuma, metric.MetricData{Value: float64(numa.MemShmem << 10), Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemAvailableNuma, metric.MetricData{Value: float64(numa.MemAvailable << 10), Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemFilepageNuma, metric.MetricData{Value: float64(numa.MemFilePages << 10), Time: updateTime})
+
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemBandwidthNuma, metric.MetricData{Value: numa.MemReadBandwidthMB/1024.0 + numa.MemWriteBandwidthMB/1024.0, Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemBandwidthMaxNuma, metric.MetricData{Value: numa.MemTheoryMaxBandwidthMB * 0.8 / 1024.0, Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemBandwidthTheoryNuma, metric.MetricData{Value: numa.MemTheoryMaxBandwidthMB / 1024.0, Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemBandwidthReadNuma, metric.MetricData{Value: numa.MemReadBandwidthMB / 1024.0, Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemBandwidthWriteNuma, metric.MetricData{Value: numa.MemWriteBandwidthMB / 1024.0, Time: updateTime})
+
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemLatencyReadNuma, metric.MetricData{Value: numa.MemReadLatency, Time: updateTime})
+		m.metricStore.SetNumaMetricWithTime(numa.ID, consts.MetricMemLatencyWriteNuma, metric.MetricData{Value: numa.MemWriteLatency, Time: updateTime})
 	}
 }
 
 func (m *MalachiteMetricsFetcher) processSystemCPUComputeData(systemComputeData *system.SystemComputeData) {
+	updateTime := malachiteSampleTime(systemComputeData.UpdateTime)
 	for _, cpu := range systemComputeData.CPU {
 		cpuID, err := strconv.Atoi(cpu.Name[3:])
 		if err != nil {
 			klog.Errorf("[malachite] parse cpu name %v with err: %v", cpu.Name, err)
 			continue
 		}
-		m.metricStore.SetCPUMetric(cpuID, consts.MetricCPUUsage, cpu.CPUUsage)
-		m.metricStore.SetCPUMetric(cpuID, consts.MetricCPUSchedwait, cpu.CPUSchedWait)
-		m.metricStore.SetCPUMetric(cpuID, consts.MetricCPUIOWaitRatio, cpu.CPUIowaitRatio)
+		m.metricStore.SetCPUMetricWithTime(cpuID, consts.MetricCPUUsage, metric.MetricData{Value: cpu.CPUUsage, Time: updateTime})
+		m.metricStore.SetCPUMetricWithTime(cpuID, consts.MetricCPUSchedwait, metric.MetricData{Value: cpu.CPUSchedWait, Time: updateTime})
+		m.metricStore.SetCPUMetricWithTime(cpuID, consts.MetricCPUIOWaitRatio, metric.MetricData{Value: cpu.CPUIowaitRatio, Time: updateTime})
 	}
 }
 
-func (m *MalachiteMetricsFetcher) processCgroupCPUData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo) {
-	m.processContainerMemBandwidth(podUID, containerName, cgStats)
+func (m *MalachiteMetricsFetcher) processCgroupCPUData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo, updateTime time.Time) {
+	m.processContainerMemBandwidth(podUID, containerName, cgStats, updateTime)
 
 	if cgStats.CgroupType == "V1" {
 		cpu := cgStats.V1.Cpu
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPULimitContainer, float64(cpu.CfsQuotaUs)/float64(cpu.CfsPeriodUs))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUUsageContainer, float64(cpu.NewCPUBasicInfo.CPUUsage-cpu.OldCPUBasicInfo.CPUUsage)/(float64(cpu.NewCPUBasicInfo.UpdateTime-cpu.OldCPUBasicInfo.UpdateTime)*1e9))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUUsageRatioContainer, cpu.CPUUsageRatio)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUUsageUserContainer, cpu.CPUUserUsageRatio)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUUsageSysContainer, cpu.CPUSysUsageRatio)
-
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUShareContainer, float64(cpu.CPUShares))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUQuotaContainer, float64(cpu.CfsQuotaUs))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUPeriodContainer, float64(cpu.CfsPeriodUs))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUNrThrottledContainer, float64(cpu.CPUNrThrottled))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUThrottledPeriodContainer, float64(cpu.CPUNrPeriods))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUThrottledTimeContainer, float64(cpu.CPUThrottledTime))
-
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricLoad1MinContainer, cpu.Load.One)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricLoad5MinContainer, cpu.Load.Five)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricLoad15MinContainer, cpu.Load.Fifteen)
-
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricOCRReadDRAMsContainer, float64(cpu.OCRReadDRAMs))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricIMCWriteContainer, float64(cpu.IMCWrites))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricStoreAllInsContainer, float64(cpu.StoreAllInstructions))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricStoreInsContainer, float64(cpu.StoreInstructions))
-
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricUpdateTimeContainer, float64(cpu.UpdateTime))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPULimitContainer, metric.MetricData{Value: float64(cpu.CfsQuotaUs) / float64(cpu.CfsPeriodUs), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageContainer, metric.MetricData{Value: float64(cpu.NewCPUBasicInfo.CPUUsage-cpu.OldCPUBasicInfo.CPUUsage) / (float64(cpu.NewCPUBasicInfo.UpdateTime-cpu.OldCPUBasicInfo.UpdateTime) * 1e9), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageRatioContainer, metric.MetricData{Value: cpu.CPUUsageRatio, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageUserContainer, metric.MetricData{Value: cpu.CPUUserUsageRatio, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageSysContainer, metric.MetricData{Value: cpu.CPUSysUsageRatio, Time: updateTime})
+
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUShareContainer, metric.MetricData{Value: float64(cpu.CPUShares), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUQuotaContainer, metric.MetricData{Value: float64(cpu.CfsQuotaUs), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUPeriodContainer, metric.MetricData{Value: float64(cpu.CfsPeriodUs), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUNrThrottledContainer, metric.MetricData{Value: float64(cpu.CPUNrThrottled), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUThrottledPeriodContainer, metric.MetricData{Value: float64(cpu.CPUNrPeriods), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUThrottledTimeContainer, metric.MetricData{Value: float64(cpu.CPUThrottledTime), Time: updateTime})
+
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricLoad1MinContainer, metric.MetricData{Value: cpu.Load.One, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricLoad5MinContainer, metric.MetricData{Value: cpu.Load.Five, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricLoad15MinContainer, metric.MetricData{Value: cpu.Load.Fifteen, Time: updateTime})
+
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricOCRReadDRAMsContainer, metric.MetricData{Value: float64(cpu.OCRReadDRAMs), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricIMCWriteContainer, metric.MetricData{Value: float64(cpu.IMCWrites), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricStoreAllInsContainer, metric.MetricData{Value: float64(cpu.StoreAllInstructions), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricStoreInsContainer, metric.MetricData{Value: float64(cpu.StoreInstructions), Time: updateTime})
+
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricUpdateTimeContainer, metric.MetricData{Value: float64(cpu.UpdateTime), Time: updateTime})
 	} else if cgStats.CgroupType == "V2" {
 		cpu := cgStats.V2.Cpu
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUUsageRatioContainer, cpu.CPUUsageRatio)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUUsageUserContainer, cpu.CPUUserUsageRatio)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUUsageSysContainer, cpu.CPUSysUsageRatio)
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageRatioContainer, metric.MetricData{Value: cpu.CPUUsageRatio, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageUserContainer, metric.MetricData{Value: cpu.CPUUserUsageRatio, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageSysContainer, metric.MetricData{Value: cpu.CPUSysUsageRatio, Time: updateTime})
 
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricLoad1MinContainer, cpu.Load.One)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricLoad5MinContainer, cpu.Load.Five)
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricLoad15MinContainer, cpu.Load.Fifteen)
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricLoad1MinContainer, metric.MetricData{Value: cpu.Load.One, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricLoad5MinContainer, metric.MetricData{Value: cpu.Load.Five, Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricLoad15MinContainer, metric.MetricData{Value: cpu.Load.Fifteen, Time: updateTime})
 
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricOCRReadDRAMsContainer, float64(cpu.OCRReadDRAMs))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricIMCWriteContainer, float64(cpu.IMCWrites))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricStoreAllInsContainer, float64(cpu.StoreAllInstructions))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricStoreInsContainer, float64(cpu.StoreInstructions))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricOCRReadDRAMsContainer, metric.MetricData{Value: float64(cpu.OCRReadDRAMs), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricIMCWriteContainer, metric.MetricData{Value: float64(cpu.IMCWrites), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricStoreAllInsContainer, metric.MetricData{Value: float64(cpu.StoreAllInstructions), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricStoreInsContainer, metric.MetricData{Value: float64(cpu.StoreInstructions), Time: updateTime})
 
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricUpdateTimeContainer, float64(cpu.UpdateTime))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricUpdateTimeContainer, metric.MetricData{Value: float64(cpu.UpdateTime), Time: updateTime})
 	}
 }
 
-func (m *MalachiteMetricsFetcher) processCgroupMemoryData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo) {
+func (m *MalachiteMetricsFetcher) processCgroupMemoryData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo, updateTime time.Time) {
 	if cgStats.CgroupType == "V1" {
 		mem := cgStats.V1.Memory
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemLimitContainer, float64(mem.MemoryLimitInBytes))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemUsageContainer, float64(mem.MemoryUsageInBytes))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemUsageUserContainer, float64(mem.MemoryLimitInBytes-mem.KernMemoryUsageInBytes))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemUsageSysContainer, float64(mem.KernMemoryUsageInBytes))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemRssContainer, float64(mem.TotalRss))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemCacheContainer, float64(mem.TotalCache))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemShmemContainer, float64(mem.TotalShmem))
-
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemDirtyContainer, float64(mem.TotalDirty))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemWritebackContainer, float64(mem.TotalWriteback))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemPgfaultContainer, float64(mem.TotalPgfault))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemPgmajfaultContainer, float64(mem.TotalPgmajfault))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemAllocstallContainer, float64(mem.TotalAllocstall))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemKswapdstealContainer, float64(mem.KswapdSteal))
-
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemOomContainer, float64(mem.OomCnt))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemScaleFactorContainer, general.UIntPointerToFloat64(mem.WatermarkScaleFactor))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemLimitContainer, metric.MetricData{Value: float64(mem.MemoryLimitInBytes), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageContainer, metric.MetricData{Value: float64(mem.MemoryUsageInBytes), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageUserContainer, metric.MetricData{Value: float64(mem.MemoryLimitInBytes - mem.KernMemoryUsageInBytes), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageSysContainer, metric.MetricData{Value: float64(mem.KernMemoryUsageInBytes), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemRssContainer, metric.MetricData{Value: float64(mem.TotalRss), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemCacheContainer, metric.MetricData{Value: float64(mem.TotalCache), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemShmemContainer, metric.MetricData{Value: float64(mem.TotalShmem), Time: updateTime})
+
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemDirtyContainer, metric.MetricData{Value: float64(mem.TotalDirty), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemWritebackContainer, metric.MetricData{Value: float64(mem.TotalWriteback), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgfaultContainer, metric.MetricData{Value: float64(mem.TotalPgfault), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgmajfaultContainer, metric.MetricData{Value: float64(mem.TotalPgmajfault), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemAllocstallContainer, metric.MetricData{Value: float64(mem.TotalAllocstall), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemKswapdstealContainer, metric.MetricData{Value: float64(mem.KswapdSteal), Time: updateTime})
+
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemOomContainer, metric.MetricData{Value: float64(mem.OomCnt), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemScaleFactorContainer, metric.MetricData{Value: general.UIntPointerToFloat64(mem.WatermarkScaleFactor), Time: updateTime})
 	} else if cgStats.CgroupType == "V2" {
 		mem := cgStats.V2.Memory
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemUsageContainer, float64(mem.MemoryUsageInBytes))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageContainer, metric.MetricData{Value: float64(mem.MemoryUsageInBytes), Time: updateTime})
 
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemShmemContainer, float64(mem.MemStats.Shmem))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemPgfaultContainer, float64(mem.MemStats.Pgfault))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemPgmajfaultContainer, float64(mem.MemStats.Pgmajfault))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemShmemContainer, metric.MetricData{Value: float64(mem.MemStats.Shmem), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgfaultContainer, metric.MetricData{Value: float64(mem.MemStats.Pgfault), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgmajfaultContainer, metric.MetricData{Value: float64(mem.MemStats.Pgmajfault), Time: updateTime})
 
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemOomContainer, float64(mem.OomCnt))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemScaleFactorContainer, general.UInt64PointerToFloat64(mem.WatermarkScaleFactor))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemOomContainer, metric.MetricData{Value: float64(mem.OomCnt), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemScaleFactorContainer, metric.MetricData{Value: general.UInt64PointerToFloat64(mem.WatermarkScaleFactor), Time: updateTime})
 	}
 }
 
-func (m *MalachiteMetricsFetcher) processCgroupBlkIOData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo) {
+func (m *MalachiteMetricsFetcher) processCgroupBlkIOData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo, updateTime time.Time) {
 	if cgStats.CgroupType == "V1" {
 		io := cgStats.V1.Blkio
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioReadIopsContainer, float64(io.BpfFsData.FsRead-io.OldBpfFsData.FsRead))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioWriteIopsContainer, float64(io.BpfFsData.FsWrite-io.OldBpfFsData.FsWrite))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioReadBpsContainer, float64(io.BpfFsData.FsReadBytes-io.OldBpfFsData.FsReadBytes))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioWriteBpsContainer, float64(io.BpfFsData.FsWriteBytes-io.OldBpfFsData.FsWriteBytes))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioReadIopsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsRead - io.OldBpfFsData.FsRead), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioWriteIopsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsWrite - io.OldBpfFsData.FsWrite), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioReadBpsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsReadBytes - io.OldBpfFsData.FsReadBytes), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioWriteBpsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsWriteBytes - io.OldBpfFsData.FsWriteBytes), Time: updateTime})
 	} else if cgStats.CgroupType == "V2" {
 		io := cgStats.V2.Blkio
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioReadIopsContainer, float64(io.BpfFsData.FsRead-io.OldBpfFsData.FsRead))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioWriteIopsContainer, float64(io.BpfFsData.FsWrite-io.OldBpfFsData.FsWrite))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioReadBpsContainer, float64(io.BpfFsData.FsReadBytes-io.OldBpfFsData.FsReadBytes))
-		m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricBlkioWriteBpsContainer, float64(io.BpfFsData.FsWriteBytes-io.OldBpfFsData.FsWriteBytes))
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioReadIopsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsRead - io.OldBpfFsData.FsRead), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioWriteIopsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsWrite - io.OldBpfFsData.FsWrite), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioReadBpsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsReadBytes - io.OldBpfFsData.FsReadBytes), Time: updateTime})
+		m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricBlkioWriteBpsContainer, metric.MetricData{Value: float64(io.BpfFsData.FsWriteBytes - io.OldBpfFsData.FsWriteBytes), Time: updateTime})
 	}
 }
 
-func (m *MalachiteMetricsFetcher) processCgroupNetData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo) {
+func (m *MalachiteMetricsFetcher) processCgroupNetData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo, updateTime time.Time) {
 	var net *cgroup.NetClsCgData
 	if cgStats.CgroupType == "V1" {
 		net = cgStats.V1.NetCls
@@ -560,13 +516,13 @@ func (m *MalachiteMetricsFetcher) processCgroupNetData(podUID, containerName str
 	if net == nil {
 		return
 	}
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricNetTcpSendByteContainer, float64(net.BpfNetData.NetTxBytes-net.OldBpfNetData.NetTxBytes))
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricNetTcpSendPpsContainer, float64(net.BpfNetData.NetTx-net.OldBpfNetData.NetTx))
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricNetTcpRecvByteContainer, float64(net.BpfNetData.NetRxBytes-net.OldBpfNetData.NetRxBytes))
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricNetTcpRecvPpsContainer, float64(net.BpfNetData.NetRx-net.OldBpfNetData.NetRx))
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricNetTcpSendByteContainer, metric.MetricData{Value: float64(net.BpfNetData.NetTxBytes - net.OldBpfNetData.NetTxBytes), Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricNetTcpSendPpsContainer, metric.MetricData{Value: float64(net.BpfNetData.NetTx - net.OldBpfNetData.NetTx), Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricNetTcpRecvByteContainer, metric.MetricData{Value: float64(net.BpfNetData.NetRxBytes - net.OldBpfNetData.NetRxBytes), Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricNetTcpRecvPpsContainer, metric.MetricData{Value: float64(net.BpfNetData.NetRx - net.OldBpfNetData.NetRx), Time: updateTime})
 }
 
-func (m *MalachiteMetricsFetcher) processCgroupPerfData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo) {
+func (m *MalachiteMetricsFetcher) processCgroupPerfData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo, updateTime time.Time) {
 	var perf *cgroup.PerfEventData
 	if cgStats.CgroupType == "V1" {
 		perf = cgStats.V1.PerfEvent
@@ -576,31 +532,31 @@ func (m *MalachiteMetricsFetcher) processCgroupPerfData(podUID, containerName st
 	if perf == nil {
 		return
 	}
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUCpiContainer, perf.Cpi)
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUCyclesContainer, perf.Cycles)
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUInstructionsContainer, perf.Instructions)
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUICacheMissContainer, perf.IcacheMiss)
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUL2CacheMissContainer, perf.L2CacheMiss)
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricCPUL3CacheMissContainer, perf.L3CacheMiss)
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUCpiContainer, metric.MetricData{Value: perf.Cpi, Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUCyclesContainer, metric.MetricData{Value: perf.Cycles, Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUInstructionsContainer, metric.MetricData{Value: perf.Instructions, Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUICacheMissContainer, metric.MetricData{Value: perf.IcacheMiss, Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUL2CacheMissContainer, metric.MetricData{Value: perf.L2CacheMiss, Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUL3CacheMissContainer, metric.MetricData{Value: perf.L3CacheMiss, Time: updateTime})
 }
 
-func (m *MalachiteMetricsFetcher) processCgroupPerNumaMemoryData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo) {
+func (m *MalachiteMetricsFetcher) processCgroupPerNumaMemoryData(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo, updateTime time.Time) {
 	if cgStats.CgroupType == "V1" {
 		numaStats := cgStats.V1.Memory.NumaStats
 		for _, data := range numaStats {
 			numaID := strings.TrimPrefix(data.NumaName, "N")
-			m.metricStore.SetContainerNumaMetric(podUID, containerName, numaID, consts.MetricsMemTotalPerNumaContainer, float64(data.Total<<10))
-			m.metricStore.SetContainerNumaMetric(podUID, containerName, numaID, consts.MetricsMemFilePerNumaContainer, float64(data.File<<10))
-			m.metricStore.SetContainerNumaMetric(podUID, containerName, numaID, consts.MetricsMemAnonPerNumaContainer, float64(data.Anon<<10))
+			m.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaID, consts.MetricsMemTotalPerNumaContainer, metric.MetricData{Value: float64(data.Total << 10), Time: updateTime})
+			m.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaID, consts.MetricsMemFilePerNumaContainer, metric.MetricData{Value: float64(data.File << 10), Time: updateTime})
+			m.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaID, consts.MetricsMemAnonPerNumaContainer, metric.MetricData{Value: float64(data.Anon << 10), Time: updateTime})
 		}
 	} else if cgStats.CgroupType == "V2" {
 		numaStats := cgStats.V2.Memory.MemNumaStats
 		for numa, data := range numaStats {
 			numaID := strings.TrimPrefix(numa, "N")
 			total := data.Anon + data.File + data.Unevictable
-			m.metricStore.SetContainerNumaMetric(podUID, containerName, numaID, consts.MetricsMemTotalPerNumaContainer, float64(total<<10))
-			m.metricStore.SetContainerNumaMetric(podUID, containerName, numaID, consts.MetricsMemFilePerNumaContainer, float64(data.File<<10))
-			m.metricStore.SetContainerNumaMetric(podUID, containerName, numaID, consts.MetricsMemAnonPerNumaContainer, float64(data.Anon<<10))
+			m.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaID, consts.MetricsMemTotalPerNumaContainer, metric.MetricData{Value: float64(total << 10), Time: updateTime})
+			m.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaID, consts.MetricsMemFilePerNumaContainer, metric.MetricData{Value: float64(data.File << 10), Time: updateTime})
+			m.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaID, consts.MetricsMemAnonPerNumaContainer, metric.MetricData{Value: float64(data.Anon << 10), Time: updateTime})
 		}
 	}
 }
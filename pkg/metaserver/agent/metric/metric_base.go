@@ -0,0 +1,351 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
+)
+
+const (
+	metricsNameMetricStoreStaleness = "metric_store_staleness"
+	metricsNameSampleInterval       = "metrics_fetcher_sample_interval"
+	metricsNameSampleDuration       = "metrics_fetcher_sample_duration"
+	metricsNameSampleFailed         = "metrics_fetcher_sample_failed"
+
+	defaultMetricsSampleInterval    = 5 * time.Second
+	defaultMetricsMinSampleInterval = time.Second
+)
+
+// metricsScopes are all scopes of metrics, and they are sampled in this order
+var metricsScopes = []MetricsScope{MetricsScopeNode, MetricsScopeNuma, MetricsScopeCPU, MetricsScopeDevice, MetricsScopeContainer}
+
+// scopeSampler samples metrics of a scope and sets them into metric store
+type scopeSampler func(ctx context.Context, now time.Time) error
+
+// baseMetricsFetcher implements the common logic shared by MetricsFetcher
+// implementations, including reading from metric store and notifying registrations,
+// and each implementation only needs to fill up metric store by its own way
+type baseMetricsFetcher struct {
+	metricStore *metric.MetricStore
+	emitter     metrics.MetricEmitter
+
+	sync.RWMutex
+	registered map[MetricsScope]map[string]NotifiedData
+
+	// samplers and precheck are set by each implementation, and precheck (if any)
+	// is called before sampling to skip all samplers if it fails
+	samplers map[MetricsScope]scopeSampler
+	precheck func() error
+	now      func() time.Time
+
+	sampleIntervals   map[MetricsScope]time.Duration
+	minSampleInterval time.Duration
+	// lastSampleTime is only accessed by the sampling goroutine
+	lastSampleTime map[MetricsScope]time.Time
+}
+
+func newBaseMetricsFetcher(conf *config.Configuration, metricStore *metric.MetricStore, emitter metrics.MetricEmitter) *baseMetricsFetcher {
+	sampleIntervals := make(map[MetricsScope]time.Duration)
+	for scope, interval := range conf.MetricsSampleIntervals {
+		sampleIntervals[MetricsScope(scope)] = interval
+	}
+
+	minSampleInterval := conf.MetricsMinSampleInterval
+	if minSampleInterval <= 0 {
+		minSampleInterval = defaultMetricsMinSampleInterval
+	}
+
+	return &baseMetricsFetcher{
+		metricStore:       metricStore,
+		emitter:           emitter,
+		samplers:          make(map[MetricsScope]scopeSampler),
+		now:               time.Now,
+		sampleIntervals:   sampleIntervals,
+		minSampleInterval: minSampleInterval,
+		lastSampleTime:    make(map[MetricsScope]time.Time),
+		registered: map[MetricsScope]map[string]NotifiedData{
+			MetricsScopeNode:      make(map[string]NotifiedData),
+			MetricsScopeNuma:      make(map[string]NotifiedData),
+			MetricsScopeCPU:       make(map[string]NotifiedData),
+			MetricsScopeDevice:    make(map[string]NotifiedData),
+			MetricsScopeContainer: make(map[string]NotifiedData),
+		},
+	}
+}
+
+func (b *baseMetricsFetcher) RegisterNotifier(scope MetricsScope, req NotifiedRequest, response chan NotifiedResponse) string {
+	if _, ok := b.registered[scope]; !ok {
+		return ""
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	randBytes := make([]byte, 30)
+	rand.Read(randBytes)
+	key := string(randBytes)
+
+	b.registered[scope][key] = NotifiedData{
+		scope:    scope,
+		req:      req,
+		response: response,
+	}
+	return key
+}
+
+func (b *baseMetricsFetcher) DeRegisterNotifier(scope MetricsScope, key string) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.registered[scope], key)
+}
+
+func (b *baseMetricsFetcher) GetNodeMetric(metricName string) (float64, error) {
+	return b.metricStore.GetNodeMetric(metricName)
+}
+
+func (b *baseMetricsFetcher) GetNumaMetric(numaID int, metricName string) (float64, error) {
+	return b.metricStore.GetNumaMetric(numaID, metricName)
+}
+
+func (b *baseMetricsFetcher) GetDeviceMetric(deviceName string, metricName string) (float64, error) {
+	return b.metricStore.GetDeviceMetric(deviceName, metricName)
+}
+
+func (b *baseMetricsFetcher) GetCPUMetric(coreID int, metricName string) (float64, error) {
+	return b.metricStore.GetCPUMetric(coreID, metricName)
+}
+
+func (b *baseMetricsFetcher) GetContainerMetric(podUID, containerName, metricName string) (float64, error) {
+	return b.metricStore.GetContainerMetric(podUID, containerName, metricName)
+}
+
+func (b *baseMetricsFetcher) GetContainerNumaMetric(podUID, containerName, numaNode, metricName string) (float64, error) {
+	return b.metricStore.GetContainerNumaMetric(podUID, containerName, numaNode, metricName)
+}
+
+func (b *baseMetricsFetcher) GetNodeMetricWithTime(metricName string) (metric.MetricData, error) {
+	return b.metricStore.GetNodeMetricWithTime(metricName)
+}
+
+func (b *baseMetricsFetcher) GetNumaMetricWithTime(numaID int, metricName string) (metric.MetricData, error) {
+	return b.metricStore.GetNumaMetricWithTime(numaID, metricName)
+}
+
+func (b *baseMetricsFetcher) GetDeviceMetricWithTime(deviceName string, metricName string) (metric.MetricData, error) {
+	return b.metricStore.GetDeviceMetricWithTime(deviceName, metricName)
+}
+
+func (b *baseMetricsFetcher) GetCPUMetricWithTime(coreID int, metricName string) (metric.MetricData, error) {
+	return b.metricStore.GetCPUMetricWithTime(coreID, metricName)
+}
+
+func (b *baseMetricsFetcher) GetContainerMetricWithTime(podUID, containerName, metricName string) (metric.MetricData, error) {
+	return b.metricStore.GetContainerMetricWithTime(podUID, containerName, metricName)
+}
+
+func (b *baseMetricsFetcher) GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName string) (metric.MetricData, error) {
+	return b.metricStore.GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName)
+}
+
+func (b *baseMetricsFetcher) GetNodeMetricWithMaxAge(metricName string, maxAge time.Duration) (float64, error) {
+	return b.metricStore.GetNodeMetricWithMaxAge(metricName, maxAge)
+}
+
+func (b *baseMetricsFetcher) GetNumaMetricWithMaxAge(numaID int, metricName string, maxAge time.Duration) (float64, error) {
+	return b.metricStore.GetNumaMetricWithMaxAge(numaID, metricName, maxAge)
+}
+
+func (b *baseMetricsFetcher) GetDeviceMetricWithMaxAge(deviceName string, metricName string, maxAge time.Duration) (float64, error) {
+	return b.metricStore.GetDeviceMetricWithMaxAge(deviceName, metricName, maxAge)
+}
+
+func (b *baseMetricsFetcher) GetCPUMetricWithMaxAge(coreID int, metricName string, maxAge time.Duration) (float64, error) {
+	return b.metricStore.GetCPUMetricWithMaxAge(coreID, metricName, maxAge)
+}
+
+func (b *baseMetricsFetcher) GetContainerMetricWithMaxAge(podUID, containerName, metricName string, maxAge time.Duration) (float64, error) {
+	return b.metricStore.GetContainerMetricWithMaxAge(podUID, containerName, metricName, maxAge)
+}
+
+func (b *baseMetricsFetcher) GetContainerNumaMetricWithMaxAge(podUID, containerName, numaNode, metricName string, maxAge time.Duration) (float64, error) {
+	return b.metricStore.GetContainerNumaMetricWithMaxAge(podUID, containerName, numaNode, metricName, maxAge)
+}
+
+func (b *baseMetricsFetcher) AggregatePodNumaMetric(podList []*v1.Pod, numaNode, metricName string,
+	agg metric.Aggregator, filter metric.ContainerMetricFilter) (float64, error) {
+	return b.metricStore.AggregatePodNumaMetric(podList, numaNode, metricName, agg, filter), nil
+}
+
+func (b *baseMetricsFetcher) AggregatePodMetric(podList []*v1.Pod, metricName string,
+	agg metric.Aggregator, filter metric.ContainerMetricFilter) (float64, error) {
+	return b.metricStore.AggregatePodMetric(podList, metricName, agg, filter), nil
+}
+
+func (b *baseMetricsFetcher) AggregateCoreMetric(cpuset machine.CPUSet, metricName string, agg metric.Aggregator) (float64, error) {
+	return b.metricStore.AggregateCoreMetric(cpuset, metricName, agg), nil
+}
+
+// notify sends current values of metrics registered in the given scope to their channels,
+// along with the time they are sampled by the metrics source
+func (b *baseMetricsFetcher) notify(scope MetricsScope) {
+	b.RLock()
+	defer b.RUnlock()
+
+	for _, reg := range b.registered[scope] {
+		var (
+			data metric.MetricData
+			err  error
+		)
+		switch scope {
+		case MetricsScopeNode:
+			data, err = b.metricStore.GetNodeMetricWithTime(reg.req.MetricName)
+		case MetricsScopeDevice:
+			data, err = b.metricStore.GetDeviceMetricWithTime(reg.req.DeviceID, reg.req.MetricName)
+		case MetricsScopeNuma:
+			data, err = b.metricStore.GetNumaMetricWithTime(reg.req.NumaID, reg.req.MetricName)
+		case MetricsScopeCPU:
+			data, err = b.metricStore.GetCPUMetricWithTime(reg.req.CoreID, reg.req.MetricName)
+		case MetricsScopeContainer:
+			data, err = b.metricStore.GetContainerMetricWithTime(reg.req.PodUID, reg.req.ContainerName, reg.req.MetricName)
+		}
+		if err != nil {
+			continue
+		}
+		reg.response <- NotifiedResponse{
+			Req:       reg.req,
+			Result:    data.Value,
+			Timestamp: data.Time,
+		}
+
+		if scope != MetricsScopeContainer || reg.req.NumaID == 0 {
+			continue
+		}
+
+		data, err = b.metricStore.GetContainerNumaMetricWithTime(reg.req.PodUID, reg.req.ContainerName, fmt.Sprintf("%v", reg.req.NumaID), reg.req.MetricName)
+		if err != nil {
+			continue
+		}
+		reg.response <- NotifiedResponse{
+			Req:       reg.req,
+			Result:    data.Value,
+			Timestamp: data.Time,
+		}
+	}
+}
+
+// getSampleInterval returns the sampling interval of the given scope, which is the faster one
+// between the configured interval and those asked by registrations, and bounded by minSampleInterval
+func (b *baseMetricsFetcher) getSampleInterval(scope MetricsScope) time.Duration {
+	interval, ok := b.sampleIntervals[scope]
+	if !ok || interval <= 0 {
+		interval = defaultMetricsSampleInterval
+	}
+
+	b.RLock()
+	for _, reg := range b.registered[scope] {
+		if reg.req.Interval > 0 && reg.req.Interval < interval {
+			interval = reg.req.Interval
+		}
+	}
+	b.RUnlock()
+
+	return time.Duration(general.MaxInt64(int64(interval), int64(b.minSampleInterval)))
+}
+
+// runSampling triggers sampling of each scope when its interval elapses, and it
+// checks all scopes every minSampleInterval so that faster cadences take effect timely
+func (b *baseMetricsFetcher) runSampling(ctx context.Context) {
+	wait.UntilWithContext(ctx, b.sample, b.minSampleInterval)
+}
+
+// sample runs samplers of all scopes whose sampling intervals have elapsed, and notifies
+// registrations in those scopes; failures and duration of sampling are recorded per scope
+func (b *baseMetricsFetcher) sample(ctx context.Context) {
+	now := b.now()
+	defer b.emitStaleness(now)
+
+	var dueScopes []MetricsScope
+	for _, scope := range metricsScopes {
+		if _, ok := b.samplers[scope]; !ok {
+			continue
+		}
+
+		interval := b.getSampleInterval(scope)
+		if last, ok := b.lastSampleTime[scope]; ok && now.Sub(last) < interval {
+			continue
+		}
+		dueScopes = append(dueScopes, scope)
+
+		_ = b.emitter.StoreFloat64(metricsNameSampleInterval, interval.Seconds(),
+			metrics.MetricTypeNameRaw, metrics.MetricTag{Key: "scope", Val: string(scope)})
+	}
+	if len(dueScopes) == 0 {
+		return
+	}
+
+	if b.precheck != nil {
+		if err := b.precheck(); err != nil {
+			klog.Errorf("[metrics-fetcher] precheck before sampling failed: %v", err)
+			return
+		}
+	}
+
+	for _, scope := range dueScopes {
+		b.lastSampleTime[scope] = now
+
+		start := time.Now()
+		err := b.samplers[scope](ctx, now)
+		_ = b.emitter.StoreFloat64(metricsNameSampleDuration, time.Since(start).Seconds(),
+			metrics.MetricTypeNameRaw, metrics.MetricTag{Key: "scope", Val: string(scope)})
+		if err != nil {
+			klog.Errorf("[metrics-fetcher] sample metrics of scope %v failed: %v", scope, err)
+			_ = b.emitter.StoreInt64(metricsNameSampleFailed, 1,
+				metrics.MetricTypeNameCount, metrics.MetricTag{Key: "scope", Val: string(scope)})
+		}
+
+		b.notify(scope)
+	}
+}
+
+// emitStaleness emits the time (in seconds) elapsed since any metric in each scope is updated,
+// and it keeps growing if the underlying metrics source stalls.
+func (b *baseMetricsFetcher) emitStaleness(now time.Time) {
+	for _, scope := range metricsScopes {
+		updateTime, ok := b.metricStore.GetScopeUpdateTime(string(scope))
+		if !ok {
+			continue
+		}
+
+		_ = b.emitter.StoreFloat64(metricsNameMetricStoreStaleness, now.Sub(updateTime).Seconds(),
+			metrics.MetricTypeNameRaw, metrics.MetricTag{Key: "scope", Val: string(scope)})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
+)
+
+func TestBaseMetricsFetcher_getSampleInterval(t *testing.T) {
+	t.Parallel()
+
+	conf := config.NewConfiguration()
+	conf.MetricsSampleIntervals = map[string]time.Duration{
+		string(MetricsScopeNode):      10 * time.Second,
+		string(MetricsScopeContainer): 20 * time.Second,
+	}
+	conf.MetricsMinSampleInterval = 2 * time.Second
+
+	b := newBaseMetricsFetcher(conf, metric.NewMetricStore(), metrics.DummyMetrics{})
+	assert.Equal(t, 10*time.Second, b.getSampleInterval(MetricsScopeNode))
+	assert.Equal(t, 20*time.Second, b.getSampleInterval(MetricsScopeContainer))
+	assert.Equal(t, defaultMetricsSampleInterval, b.getSampleInterval(MetricsScopeNuma))
+
+	rChan := make(chan NotifiedResponse, 10)
+	key := b.RegisterNotifier(MetricsScopeNode, NotifiedRequest{MetricName: "m1", Interval: 4 * time.Second}, rChan)
+	b.RegisterNotifier(MetricsScopeNode, NotifiedRequest{MetricName: "m2"}, rChan)
+	b.RegisterNotifier(MetricsScopeContainer, NotifiedRequest{MetricName: "m3", Interval: time.Second}, rChan)
+	b.RegisterNotifier(MetricsScopeNuma, NotifiedRequest{MetricName: "m4", Interval: time.Minute}, rChan)
+
+	assert.Equal(t, 4*time.Second, b.getSampleInterval(MetricsScopeNode))
+	assert.Equal(t, 2*time.Second, b.getSampleInterval(MetricsScopeContainer))
+	assert.Equal(t, defaultMetricsSampleInterval, b.getSampleInterval(MetricsScopeNuma))
+
+	b.DeRegisterNotifier(MetricsScopeNode, key)
+	assert.Equal(t, 10*time.Second, b.getSampleInterval(MetricsScopeNode))
+}
+
+func TestBaseMetricsFetcher_sample(t *testing.T) {
+	t.Parallel()
+
+	conf := config.NewConfiguration()
+	conf.MetricsSampleIntervals = map[string]time.Duration{
+		string(MetricsScopeNode):      5 * time.Second,
+		string(MetricsScopeContainer): 10 * time.Second,
+	}
+	conf.MetricsMinSampleInterval = time.Second
+
+	b := newBaseMetricsFetcher(conf, metric.NewMetricStore(), metrics.DummyMetrics{})
+	now := time.Unix(1000, 0)
+	b.now = func() time.Time { return now }
+
+	sampled := make(map[MetricsScope]int)
+	b.samplers[MetricsScopeNode] = func(_ context.Context, _ time.Time) error {
+		sampled[MetricsScopeNode]++
+		b.metricStore.SetNodeMetric("node-metric", float64(sampled[MetricsScopeNode]))
+		return nil
+	}
+	b.samplers[MetricsScopeContainer] = func(_ context.Context, _ time.Time) error {
+		sampled[MetricsScopeContainer]++
+		return fmt.Errorf("sample failed")
+	}
+
+	rChan := make(chan NotifiedResponse, 10)
+	b.RegisterNotifier(MetricsScopeNode, NotifiedRequest{MetricName: "node-metric"}, rChan)
+
+	// all scopes are sampled in the first round
+	b.sample(context.Background())
+	assert.Equal(t, map[MetricsScope]int{MetricsScopeNode: 1, MetricsScopeContainer: 1}, sampled)
+	assert.Equal(t, float64(1), (<-rChan).Result)
+
+	now = now.Add(5 * time.Second)
+	b.sample(context.Background())
+	assert.Equal(t, map[MetricsScope]int{MetricsScopeNode: 2, MetricsScopeContainer: 1}, sampled)
+	assert.Equal(t, float64(2), (<-rChan).Result)
+
+	// failed sampling is not retried until its interval elapses
+	now = now.Add(3 * time.Second)
+	b.sample(context.Background())
+	assert.Equal(t, map[MetricsScope]int{MetricsScopeNode: 2, MetricsScopeContainer: 1}, sampled)
+
+	// a faster cadence asked by registration takes effect in the next round
+	b.RegisterNotifier(MetricsScopeContainer, NotifiedRequest{MetricName: "container-metric", Interval: time.Second}, rChan)
+	now = now.Add(time.Second)
+	b.sample(context.Background())
+	assert.Equal(t, map[MetricsScope]int{MetricsScopeNode: 2, MetricsScopeContainer: 2}, sampled)
+
+	// nothing is sampled if precheck fails
+	b.precheck = func() error { return fmt.Errorf("unhealthy") }
+	now = now.Add(time.Minute)
+	b.sample(context.Background())
+	assert.Equal(t, map[MetricsScope]int{MetricsScopeNode: 2, MetricsScopeContainer: 2}, sampled)
+}
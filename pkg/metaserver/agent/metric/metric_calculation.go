@@ -19,11 +19,14 @@ package metric
 // for those metrics need extra calculation logic,
 // we will put them in a separate file here
 import (
+	"time"
+
 	"github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/malachite/cgroup"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
 )
 
-func (m *MalachiteMetricsFetcher) processContainerMemBandwidth(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo) {
+func (m *MalachiteMetricsFetcher) processContainerMemBandwidth(podUID, containerName string, cgStats *cgroup.MalachiteCgroupInfo, updateTime time.Time) {
 	var (
 		lastOCRReadDRAMs, _ = m.metricStore.GetContainerMetric(podUID, containerName, consts.MetricOCRReadDRAMsContainer)
 		lastIMCWrites, _    = m.metricStore.GetContainerMetric(podUID, containerName, consts.MetricIMCWriteContainer)
@@ -60,6 +63,6 @@ func (m *MalachiteMetricsFetcher) processContainerMemBandwidth(podUID, container
 		}
 	}
 
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemBandwidthReadContainer, readBandwidth)
-	m.metricStore.SetContainerMetric(podUID, containerName, consts.MetricMemBandwidthWriteContainer, writeBandwidth)
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemBandwidthReadContainer, metric.MetricData{Value: readBandwidth, Time: updateTime})
+	m.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemBandwidthWriteContainer, metric.MetricData{Value: writeBandwidth, Time: updateTime})
 }
@@ -17,6 +17,10 @@ limitations under the License.
 package metric
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -24,7 +28,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/malachite/cgroup"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/malachite/client"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/malachite/system"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
 	"github.com/kubewharf/katalyst-core/pkg/util/machine"
@@ -33,7 +40,7 @@ import (
 
 func Test_noneExistMetricsFetcher(t *testing.T) {
 	var err error
-	implement := NewMalachiteMetricsFetcher(metrics.DummyMetrics{})
+	implement := NewMalachiteMetricsFetcher(config.NewConfiguration(), metrics.DummyMetrics{})
 
 	fakeSystemCompute := &system.SystemComputeData{
 		CPU: []system.CPU{
@@ -81,19 +88,19 @@ func Test_noneExistMetricsFetcher(t *testing.T) {
 	implement.(*MalachiteMetricsFetcher).processSystemNumaData(fakeSystemMemory)
 	implement.(*MalachiteMetricsFetcher).processSystemCPUComputeData(fakeSystemCompute)
 
-	implement.(*MalachiteMetricsFetcher).processCgroupCPUData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1)
-	implement.(*MalachiteMetricsFetcher).processCgroupMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1)
-	implement.(*MalachiteMetricsFetcher).processCgroupBlkIOData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1)
-	implement.(*MalachiteMetricsFetcher).processCgroupNetData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1)
-	implement.(*MalachiteMetricsFetcher).processCgroupPerfData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1)
-	implement.(*MalachiteMetricsFetcher).processCgroupPerNumaMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1)
+	implement.(*MalachiteMetricsFetcher).processCgroupCPUData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupBlkIOData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupNetData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupPerfData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupPerNumaMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV1, time.Now())
 
-	implement.(*MalachiteMetricsFetcher).processCgroupCPUData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2)
-	implement.(*MalachiteMetricsFetcher).processCgroupMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2)
-	implement.(*MalachiteMetricsFetcher).processCgroupBlkIOData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2)
-	implement.(*MalachiteMetricsFetcher).processCgroupNetData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2)
-	implement.(*MalachiteMetricsFetcher).processCgroupPerfData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2)
-	implement.(*MalachiteMetricsFetcher).processCgroupPerNumaMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2)
+	implement.(*MalachiteMetricsFetcher).processCgroupCPUData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupBlkIOData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupNetData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupPerfData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2, time.Now())
+	implement.(*MalachiteMetricsFetcher).processCgroupPerNumaMemoryData("pod-not-exist", "container-not-exist", fakeCgroupInfoV2, time.Now())
 
 	_, err = implement.GetNodeMetric("test-not-exist")
 	if err == nil {
@@ -133,7 +140,7 @@ func Test_noneExistMetricsFetcher(t *testing.T) {
 }
 
 func Test_notifySystem(t *testing.T) {
-	f := NewMalachiteMetricsFetcher(metrics.DummyMetrics{})
+	f := NewMalachiteMetricsFetcher(config.NewConfiguration(), metrics.DummyMetrics{})
 
 	rChan := make(chan NotifiedResponse, 20)
 	f.RegisterNotifier(MetricsScopeNode, NotifiedRequest{
@@ -197,7 +204,7 @@ func Test_notifySystem(t *testing.T) {
 }
 
 func TestStore_Aggregate(t *testing.T) {
-	f := NewMalachiteMetricsFetcher(metrics.DummyMetrics{}).(*MalachiteMetricsFetcher)
+	f := NewMalachiteMetricsFetcher(config.NewConfiguration(), metrics.DummyMetrics{}).(*MalachiteMetricsFetcher)
 
 	pod1 := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -231,9 +238,11 @@ func TestStore_Aggregate(t *testing.T) {
 	f.metricStore.SetContainerMetric("pod1", "container1", "test-pod-metric", 1.0)
 	f.metricStore.SetContainerMetric("pod1", "container2", "test-pod-metric", 1.0)
 	f.metricStore.SetContainerMetric("pod2", "container3", "test-pod-metric", 1.0)
-	sum := f.AggregatePodMetric([]*v1.Pod{pod1, pod2, pod3}, "test-pod-metric", metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	sum, err := f.AggregatePodMetric([]*v1.Pod{pod1, pod2, pod3}, "test-pod-metric", metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(3), sum)
-	avg := f.AggregatePodMetric([]*v1.Pod{pod1, pod2, pod3}, "test-pod-metric", metric.AggregatorAvg, metric.DefaultContainerMetricFilter)
+	avg, err := f.AggregatePodMetric([]*v1.Pod{pod1, pod2, pod3}, "test-pod-metric", metric.AggregatorAvg, metric.DefaultContainerMetricFilter)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(1.5), avg)
 
 	f.metricStore.SetContainerNumaMetric("pod1", "container1", "0", "test-pod-numa-metric", 1.0)
@@ -242,21 +251,95 @@ func TestStore_Aggregate(t *testing.T) {
 	f.metricStore.SetContainerNumaMetric("pod2", "container3", "0", "test-pod-numa-metric", 1.0)
 	f.metricStore.SetContainerNumaMetric("pod2", "container3", "1", "test-pod-numa-metric", 1.0)
 	f.metricStore.SetContainerNumaMetric("pod2", "container3", "1", "test-pod-numa-metric", 1.0)
-	sum = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "0", "test-pod-numa-metric", metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	sum, err = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "0", "test-pod-numa-metric", metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(3), sum)
-	avg = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "0", "test-pod-numa-metric", metric.AggregatorAvg, metric.DefaultContainerMetricFilter)
+	avg, err = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "0", "test-pod-numa-metric", metric.AggregatorAvg, metric.DefaultContainerMetricFilter)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(1.5), avg)
-	sum = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "1", "test-pod-numa-metric", metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	sum, err = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "1", "test-pod-numa-metric", metric.AggregatorSum, metric.DefaultContainerMetricFilter)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(2), sum)
-	avg = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "1", "test-pod-numa-metric", metric.AggregatorAvg, metric.DefaultContainerMetricFilter)
+	avg, err = f.AggregatePodNumaMetric([]*v1.Pod{pod1, pod2, pod3}, "1", "test-pod-numa-metric", metric.AggregatorAvg, metric.DefaultContainerMetricFilter)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(1), avg)
 
 	f.metricStore.SetCPUMetric(1, "test-cpu-metric", 1.0)
 	f.metricStore.SetCPUMetric(1, "test-cpu-metric", 2.0)
 	f.metricStore.SetCPUMetric(2, "test-cpu-metric", 1.0)
 	f.metricStore.SetCPUMetric(0, "test-cpu-metric", 1.0)
-	sum = f.AggregateCoreMetric(machine.NewCPUSet(0, 1, 2, 3), "test-cpu-metric", metric.AggregatorSum)
+	sum, err = f.AggregateCoreMetric(machine.NewCPUSet(0, 1, 2, 3), "test-cpu-metric", metric.AggregatorSum)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(4), sum)
-	avg = f.AggregateCoreMetric(machine.NewCPUSet(0, 1, 2, 3), "test-cpu-metric", metric.AggregatorAvg)
+	avg, err = f.AggregateCoreMetric(machine.NewCPUSet(0, 1, 2, 3), "test-cpu-metric", metric.AggregatorAvg)
+	assert.NoError(t, err)
 	assert.Equal(t, float64(4/3.), avg)
 }
+
+func TestMalachiteSampleTime(t *testing.T) {
+	t.Parallel()
+
+	m := &MalachiteMetricsFetcher{
+		baseMetricsFetcher: newBaseMetricsFetcher(config.NewConfiguration(), metric.NewMetricStore(), metrics.DummyMetrics{}),
+	}
+
+	m.processSystemMemoryData(&system.SystemMemoryData{
+		System:     system.System{MemFree: 1},
+		UpdateTime: 1000,
+	})
+	data, err := m.GetNodeMetricWithTime(consts.MetricMemFreeSystem)
+	assert.NoError(t, err)
+	assert.Equal(t, metric.MetricData{Value: 1 << 10, Time: time.Unix(1000, 0)}, data)
+
+	cgStats := &cgroup.MalachiteCgroupInfo{
+		CgroupType: "V2",
+		V2: &cgroup.MalachiteCgroupV2Info{
+			Memory: &cgroup.MemoryCgDataV2{MemoryUsageInBytes: 2},
+			Cpu:    &cgroup.CPUCgDataV2{UpdateTime: 2000},
+		},
+	}
+	m.processCgroupMemoryData("pod1", "container1", cgStats, malachiteCgroupSampleTime(cgStats))
+	data, err = m.GetContainerMetricWithTime("pod1", "container1", consts.MetricMemUsageContainer)
+	assert.NoError(t, err)
+	assert.Equal(t, metric.MetricData{Value: 2, Time: time.Unix(2000, 0)}, data)
+
+	// zero time is returned if update time is not reported, so current time is used instead
+	assert.True(t, malachiteSampleTime(0).IsZero())
+	assert.True(t, malachiteCgroupSampleTime(&cgroup.MalachiteCgroupInfo{CgroupType: "V1"}).IsZero())
+}
+
+func TestMalachiteFetchSystemStatsOnce(t *testing.T) {
+	requests := make(map[string]int)
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[r.URL.Path]++
+		mutex.Unlock()
+		_, _ = w.Write([]byte(`{"status":0,"data":{}}`))
+	}))
+	defer server.Close()
+
+	defaultClient := client.DefaultClient
+	defer func() { client.DefaultClient = defaultClient }()
+	client.DefaultClient = client.New()
+	client.DefaultClient.(*client.Client).SetURL(map[string]string{
+		client.SystemComputeResource: server.URL + "/" + client.SystemComputeResource,
+		client.SystemMemoryResource:  server.URL + "/" + client.SystemMemoryResource,
+		client.SystemIOResource:      server.URL + "/" + client.SystemIOResource,
+	})
+
+	m := NewMalachiteMetricsFetcher(config.NewConfiguration(), metrics.DummyMetrics{}).(*MalachiteMetricsFetcher)
+	m.metricStore = metric.NewMetricStore()
+	delete(m.samplers, MetricsScopeContainer)
+
+	// system stats shared by scopes are fetched once in each round of sampling, including health check
+	for round := 1; round <= 2; round++ {
+		m.lastSampleTime = make(map[MetricsScope]time.Time)
+		m.sample(context.Background())
+		assert.Equal(t, map[string]int{
+			"/" + client.SystemComputeResource: round,
+			"/" + client.SystemMemoryResource:  round,
+			"/" + client.SystemIOResource:      round,
+		}, requests)
+	}
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package native
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kubewharf/katalyst-core/pkg/util/general"
+)
+
+const (
+	// DefaultCgroupRoot is the default mount point of cgroupfs
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+
+	cgroupV2ControllersFile = "cgroup.controllers"
+
+	// userHZ is the unit of cpuacct.stat, which is 100 on almost all architectures
+	userHZ = 100
+)
+
+// NumaMemoryStats is the memory statistics of a cgroup in a numa node in bytes
+type NumaMemoryStats struct {
+	Total uint64
+	File  uint64
+	Anon  uint64
+}
+
+// CgroupStats is the statistics of a cgroup normalized for both cgroup v1 and v2,
+// and accumulated counters are kept as is so that rates are calculated by callers
+type CgroupStats struct {
+	CgroupV2 bool
+
+	// CPUUsage, CPUUserUsage, CPUSysUsage and CPUThrottledTime are in nanoseconds
+	CPUUsage         uint64
+	CPUUserUsage     uint64
+	CPUSysUsage      uint64
+	CPUNrPeriods     uint64
+	CPUNrThrottled   uint64
+	CPUThrottledTime uint64
+	// CPUQuota is -1 if unlimited, and CPUShares is converted from cpu.weight for cgroup v2
+	CPUQuota  int64
+	CPUPeriod uint64
+	CPUShares uint64
+
+	// memory statistics are in bytes except for event counters
+	MemLimit      uint64
+	MemUsage      uint64
+	MemKernUsage  uint64
+	MemRss        uint64
+	MemCache      uint64
+	MemShmem      uint64
+	MemDirty      uint64
+	MemWriteback  uint64
+	MemPgfault    uint64
+	MemPgmajfault uint64
+	MemOOMCnt     uint64
+	MemNumaStats  map[int]*NumaMemoryStats
+}
+
+// IsCgroupV2 returns true if cgroupfs mounted at the given root is in unified mode
+func IsCgroupV2(cgroupRoot string) bool {
+	return general.IsPathExists(filepath.Join(cgroupRoot, cgroupV2ControllersFile))
+}
+
+// ReadCgroupStats returns statistics of the cgroup with the given relative path
+func ReadCgroupStats(cgroupRoot, relativePath string) (*CgroupStats, error) {
+	if IsCgroupV2(cgroupRoot) {
+		return readCgroupV2Stats(filepath.Join(cgroupRoot, relativePath))
+	}
+	return readCgroupV1Stats(cgroupRoot, relativePath)
+}
+
+func readCgroupV1Stats(cgroupRoot, relativePath string) (*CgroupStats, error) {
+	var (
+		cpuDir     = filepath.Join(cgroupRoot, "cpu", relativePath)
+		cpuacctDir = filepath.Join(cgroupRoot, "cpuacct", relativePath)
+		memoryDir  = filepath.Join(cgroupRoot, "memory", relativePath)
+	)
+
+	cpuUsage, err := readUint(filepath.Join(cpuacctDir, "cpuacct.usage"))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &CgroupStats{
+		CPUUsage: cpuUsage,
+		CPUQuota: -1,
+	}
+
+	cpuacctStat := readKeyValuesIfExist(filepath.Join(cpuacctDir, "cpuacct.stat"))
+	stats.CPUUserUsage = cpuacctStat["user"] * (1e9 / userHZ)
+	stats.CPUSysUsage = cpuacctStat["system"] * (1e9 / userHZ)
+
+	cpuStat := readKeyValuesIfExist(filepath.Join(cpuDir, "cpu.stat"))
+	stats.CPUNrPeriods = cpuStat["nr_periods"]
+	stats.CPUNrThrottled = cpuStat["nr_throttled"]
+	stats.CPUThrottledTime = cpuStat["throttled_time"]
+
+	if quota, err := readInt(filepath.Join(cpuDir, "cpu.cfs_quota_us")); err == nil && quota > 0 {
+		stats.CPUQuota = quota
+	}
+	stats.CPUPeriod, _ = readUint(filepath.Join(cpuDir, "cpu.cfs_period_us"))
+	stats.CPUShares, _ = readUint(filepath.Join(cpuDir, "cpu.shares"))
+
+	stats.MemLimit, _ = readUint(filepath.Join(memoryDir, "memory.limit_in_bytes"))
+	stats.MemUsage, _ = readUint(filepath.Join(memoryDir, "memory.usage_in_bytes"))
+	stats.MemKernUsage, _ = readUint(filepath.Join(memoryDir, "memory.kmem.usage_in_bytes"))
+
+	memStat := readKeyValuesIfExist(filepath.Join(memoryDir, "memory.stat"))
+	stats.MemRss = memStat["total_rss"]
+	stats.MemCache = memStat["total_cache"]
+	stats.MemShmem = memStat["total_shmem"]
+	stats.MemDirty = memStat["total_dirty"]
+	stats.MemWriteback = memStat["total_writeback"]
+	stats.MemPgfault = memStat["total_pgfault"]
+	stats.MemPgmajfault = memStat["total_pgmajfault"]
+	stats.MemOOMCnt = readKeyValuesIfExist(filepath.Join(memoryDir, "memory.oom_control"))["oom_kill"]
+
+	// values in memory.numa_stat of cgroup v1 are in pages
+	stats.MemNumaStats, err = readNumaStat(filepath.Join(memoryDir, "memory.numa_stat"), "=", uint64(os.Getpagesize()),
+		map[string]func(*NumaMemoryStats, uint64){
+			"total": func(s *NumaMemoryStats, v uint64) { s.Total = v },
+			"file":  func(s *NumaMemoryStats, v uint64) { s.File = v },
+			"anon":  func(s *NumaMemoryStats, v uint64) { s.Anon = v },
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func readCgroupV2Stats(cgroupDir string) (*CgroupStats, error) {
+	if !general.IsPathExists(cgroupDir) {
+		return nil, fmt.Errorf("cgroup %s not exists", cgroupDir)
+	}
+
+	stats := &CgroupStats{
+		CgroupV2: true,
+		CPUQuota: -1,
+	}
+
+	// time in cpu.stat of cgroup v2 is in microseconds
+	cpuStat, err := readKeyValues(filepath.Join(cgroupDir, "cpu.stat"), " ")
+	if err != nil {
+		return nil, err
+	}
+	stats.CPUUsage = cpuStat["usage_usec"] * 1000
+	stats.CPUUserUsage = cpuStat["user_usec"] * 1000
+	stats.CPUSysUsage = cpuStat["system_usec"] * 1000
+	stats.CPUNrPeriods = cpuStat["nr_periods"]
+	stats.CPUNrThrottled = cpuStat["nr_throttled"]
+	stats.CPUThrottledTime = cpuStat["throttled_usec"] * 1000
+
+	if content, err := ioutil.ReadFile(filepath.Join(cgroupDir, "cpu.max")); err == nil {
+		parts := strings.Fields(string(content))
+		if len(parts) == 2 {
+			if quota, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+				stats.CPUQuota = quota
+			}
+			stats.CPUPeriod, _ = strconv.ParseUint(parts[1], 10, 64)
+		}
+	}
+	if weight, err := readUint(filepath.Join(cgroupDir, "cpu.weight")); err == nil && weight > 0 {
+		// the inverse of the conversion from cpu.shares to cpu.weight adopted by runc
+		stats.CPUShares = 2 + (weight-1)*262142/9999
+	}
+
+	if limit, err := readString(filepath.Join(cgroupDir, "memory.max")); err == nil {
+		if limit == "max" {
+			stats.MemLimit = math.MaxInt64
+		} else {
+			stats.MemLimit, _ = strconv.ParseUint(limit, 10, 64)
+		}
+	}
+	stats.MemUsage, _ = readUint(filepath.Join(cgroupDir, "memory.current"))
+
+	memStat := readKeyValuesIfExist(filepath.Join(cgroupDir, "memory.stat"))
+	stats.MemKernUsage = memStat["kernel"]
+	stats.MemRss = memStat["anon"]
+	stats.MemCache = memStat["file"]
+	stats.MemShmem = memStat["shmem"]
+	stats.MemDirty = memStat["file_dirty"]
+	stats.MemWriteback = memStat["file_writeback"]
+	stats.MemPgfault = memStat["pgfault"]
+	stats.MemPgmajfault = memStat["pgmajfault"]
+	stats.MemOOMCnt = readKeyValuesIfExist(filepath.Join(cgroupDir, "memory.events"))["oom_kill"]
+
+	// values in memory.numa_stat of cgroup v2 are in bytes, and total is the sum of all lru lists
+	stats.MemNumaStats, err = readNumaStat(filepath.Join(cgroupDir, "memory.numa_stat"), " ", 1,
+		map[string]func(*NumaMemoryStats, uint64){
+			"anon":        func(s *NumaMemoryStats, v uint64) { s.Anon = v; s.Total += v },
+			"file":        func(s *NumaMemoryStats, v uint64) { s.File = v; s.Total += v },
+			"unevictable": func(s *NumaMemoryStats, v uint64) { s.Total += v },
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// readNumaStat parses memory.numa_stat, in which lines are formatted as "total=N N0=N N1=N" for
+// cgroup v1 or "anon N0=N N1=N" for cgroup v2, and only keys with setters are taken into account
+func readNumaStat(file, keySeparator string, unit uint64,
+	setters map[string]func(*NumaMemoryStats, uint64)) (map[int]*NumaMemoryStats, error) {
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]*NumaMemoryStats)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		setter, ok := setters[strings.SplitN(fields[0], keySeparator, 2)[0]]
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 || !strings.HasPrefix(kv[0], "N") {
+				continue
+			}
+
+			numaID, err := strconv.Atoi(strings.TrimPrefix(kv[0], "N"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid numa id in %s of %s: %v", field, file, err)
+			}
+			value, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in %s of %s: %v", field, file, err)
+			}
+
+			if res[numaID] == nil {
+				res[numaID] = &NumaMemoryStats{}
+			}
+			setter(res[numaID], value*unit)
+		}
+	}
+
+	return res, nil
+}
+
+// readKeyValuesIfExist parses files in which each line contains a key and a value
+// separated by spaces, and returns an empty map if the file fails to be read
+func readKeyValuesIfExist(file string) map[string]uint64 {
+	res, err := readKeyValues(file, " ")
+	if err != nil {
+		return map[string]uint64{}
+	}
+	return res
+}
+
+func readKeyValues(file, separator string) (map[string]uint64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		kv := strings.SplitN(strings.TrimSpace(scanner.Text()), separator, 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		res[kv[0]] = value
+	}
+
+	return res, scanner.Err()
+}
+
+func readString(file string) (string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func readUint(file string) (uint64, error) {
+	content, err := readString(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(content, 10, 64)
+}
+
+func readInt(file string) (int64, error) {
+	content, err := readString(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(content, 10, 64)
+}
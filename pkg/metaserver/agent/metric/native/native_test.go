@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package native
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for file, content := range files {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestReadNumaMemInfo(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"devices/system/node/node0/meminfo": "Node 0 MemTotal:       32768 kB\nNode 0 MemFree:        16384 kB\n",
+		"devices/system/node/node1/meminfo": "Node 1 MemTotal:       32768 kB\nNode 1 FilePages:       1024 kB\n",
+		"devices/system/node/possible":      "0-1\n",
+	})
+
+	memInfo, err := ReadNumaMemInfo(root)
+	require.NoError(t, err)
+	require.Equal(t, map[int]map[string]uint64{
+		0: {"MemTotal": 32768, "MemFree": 16384},
+		1: {"MemTotal": 32768, "FilePages": 1024},
+	}, memInfo)
+
+	_, err = ReadNumaMemInfo(filepath.Join(root, "not-exist"))
+	require.Error(t, err)
+}
+
+func TestReadCgroupStats(t *testing.T) {
+	t.Parallel()
+
+	pageSize := uint64(os.Getpagesize())
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    *CgroupStats
+		wantErr bool
+	}{
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				"cpuacct/kubepods/pod1/c1/cpuacct.usage":             "2000000000\n",
+				"cpuacct/kubepods/pod1/c1/cpuacct.stat":              "user 150\nsystem 50\n",
+				"cpu/kubepods/pod1/c1/cpu.stat":                      "nr_periods 10\nnr_throttled 2\nthrottled_time 3000\n",
+				"cpu/kubepods/pod1/c1/cpu.cfs_quota_us":              "200000\n",
+				"cpu/kubepods/pod1/c1/cpu.cfs_period_us":             "100000\n",
+				"cpu/kubepods/pod1/c1/cpu.shares":                    "2048\n",
+				"memory/kubepods/pod1/c1/memory.limit_in_bytes":      "4096\n",
+				"memory/kubepods/pod1/c1/memory.usage_in_bytes":      "2048\n",
+				"memory/kubepods/pod1/c1/memory.kmem.usage_in_bytes": "512\n",
+				"memory/kubepods/pod1/c1/memory.stat": "cache 1\nrss 2\ntotal_cache 1024\ntotal_rss 512\ntotal_shmem 8\n" +
+					"total_dirty 4\ntotal_writeback 2\ntotal_pgfault 100\ntotal_pgmajfault 10\n",
+				"memory/kubepods/pod1/c1/memory.oom_control": "oom_kill_disable 0\nunder_oom 0\noom_kill 1\n",
+				"memory/kubepods/pod1/c1/memory.numa_stat": "total=3 N0=1 N1=2\nfile=1 N0=1 N1=0\nanon=2 N0=0 N1=2\n" +
+					"hierarchical_total=3 N0=1 N1=2\n",
+			},
+			want: &CgroupStats{
+				CPUUsage:         2000000000,
+				CPUUserUsage:     1500000000,
+				CPUSysUsage:      500000000,
+				CPUNrPeriods:     10,
+				CPUNrThrottled:   2,
+				CPUThrottledTime: 3000,
+				CPUQuota:         200000,
+				CPUPeriod:        100000,
+				CPUShares:        2048,
+				MemLimit:         4096,
+				MemUsage:         2048,
+				MemKernUsage:     512,
+				MemRss:           512,
+				MemCache:         1024,
+				MemShmem:         8,
+				MemDirty:         4,
+				MemWriteback:     2,
+				MemPgfault:       100,
+				MemPgmajfault:    10,
+				MemOOMCnt:        1,
+				MemNumaStats: map[int]*NumaMemoryStats{
+					0: {Total: pageSize, File: pageSize},
+					1: {Total: 2 * pageSize, Anon: 2 * pageSize},
+				},
+			},
+		},
+		{
+			name: "cgroup v1 unlimited without optional files",
+			files: map[string]string{
+				"cpuacct/kubepods/pod1/c1/cpuacct.usage": "1000\n",
+				"cpu/kubepods/pod1/c1/cpu.cfs_quota_us":  "-1\n",
+			},
+			want: &CgroupStats{
+				CPUUsage: 1000,
+				CPUQuota: -1,
+			},
+		},
+		{
+			name: "cgroup v1 not exist",
+			files: map[string]string{
+				"cpuacct/kubepods/pod2/c1/cpuacct.usage": "1000\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "cgroup v2",
+			files: map[string]string{
+				"cgroup.controllers":              "cpuset cpu io memory pids\n",
+				"kubepods/pod1/c1/cpu.stat":       "usage_usec 2000000\nuser_usec 1500000\nsystem_usec 500000\nnr_periods 10\nnr_throttled 2\nthrottled_usec 3\n",
+				"kubepods/pod1/c1/cpu.max":        "200000 100000\n",
+				"kubepods/pod1/c1/cpu.weight":     "79\n",
+				"kubepods/pod1/c1/memory.max":     "max\n",
+				"kubepods/pod1/c1/memory.current": "2048\n",
+				"kubepods/pod1/c1/memory.stat": "anon 512\nfile 1024\nkernel 256\nshmem 8\nfile_dirty 4\nfile_writeback 2\n" +
+					"pgfault 100\npgmajfault 10\n",
+				"kubepods/pod1/c1/memory.events":    "low 0\nhigh 0\nmax 0\noom 2\noom_kill 1\n",
+				"kubepods/pod1/c1/memory.numa_stat": "anon N0=0 N1=512\nfile N0=1024 N1=0\nunevictable N0=0 N1=8\nshmem N0=8 N1=0\n",
+			},
+			want: &CgroupStats{
+				CgroupV2:         true,
+				CPUUsage:         2000000000,
+				CPUUserUsage:     1500000000,
+				CPUSysUsage:      500000000,
+				CPUNrPeriods:     10,
+				CPUNrThrottled:   2,
+				CPUThrottledTime: 3000,
+				CPUQuota:         200000,
+				CPUPeriod:        100000,
+				CPUShares:        2046,
+				MemLimit:         math.MaxInt64,
+				MemUsage:         2048,
+				MemKernUsage:     256,
+				MemRss:           512,
+				MemCache:         1024,
+				MemShmem:         8,
+				MemDirty:         4,
+				MemWriteback:     2,
+				MemPgfault:       100,
+				MemPgmajfault:    10,
+				MemOOMCnt:        1,
+				MemNumaStats: map[int]*NumaMemoryStats{
+					0: {Total: 1024, File: 1024},
+					1: {Total: 520, Anon: 512},
+				},
+			},
+		},
+		{
+			name: "cgroup v2 not exist",
+			files: map[string]string{
+				"cgroup.controllers":        "cpuset cpu io memory pids\n",
+				"kubepods/pod2/c1/cpu.stat": "usage_usec 2000000\n",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+
+			stats, err := ReadCgroupStats(root, "kubepods/pod1/c1")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, stats)
+		})
+	}
+}
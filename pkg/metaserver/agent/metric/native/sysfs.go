@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package native collects node and container metrics directly from procfs,
+// sysfs and cgroupfs, and all root paths are configurable so that they can
+// be tested against fake file trees without any external daemon.
+package native
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSysFSRoot is the default mount point of sysfs
+	DefaultSysFSRoot = "/sys"
+
+	sysNodeDir     = "devices/system/node"
+	sysNodePrefix  = "node"
+	sysNodeMemInfo = "meminfo"
+)
+
+// ReadNumaMemInfo returns fields in meminfo of each numa node keyed by numa ids,
+// and values are in kB as is, e.g. /sys/devices/system/node/node0/meminfo
+func ReadNumaMemInfo(sysFSRoot string) (map[int]map[string]uint64, error) {
+	nodeDir := filepath.Join(sysFSRoot, sysNodeDir)
+	entries, err := ioutil.ReadDir(nodeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]map[string]uint64)
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), sysNodePrefix) {
+			continue
+		}
+
+		numaID, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), sysNodePrefix))
+		if err != nil {
+			continue
+		}
+
+		memInfo, err := readNumaMemInfoFile(filepath.Join(nodeDir, entry.Name(), sysNodeMemInfo))
+		if err != nil {
+			return nil, fmt.Errorf("read meminfo of numa %d failed with error: %v", numaID, err)
+		}
+		res[numaID] = memInfo
+	}
+
+	return res, nil
+}
+
+// readNumaMemInfoFile parses lines formatted as "Node 0 MemTotal:       32768 kB"
+func readNumaMemInfoFile(file string) (map[string]uint64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[strings.TrimSuffix(fields[2], ":")] = value
+	}
+
+	return res, scanner.Err()
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/metric/native"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
+	nativeutil "github.com/kubewharf/katalyst-core/pkg/util/native"
+	"github.com/kubewharf/katalyst-core/pkg/util/procfs"
+)
+
+const (
+	metricsNameNativeGetSystemStatusFailed = "native_get_system_status_failed"
+	metricsNameNativeGetPodStatusFailed    = "native_get_pod_status_failed"
+
+	// diskSectorSize is the unit of sectors in /proc/diskstats regardless of the physical sector size
+	diskSectorSize = 512
+)
+
+// NewNativeMetricsFetcher returns the implementation of MetricsFetcher which collects
+// metrics directly from procfs, sysfs and cgroupfs without any external daemon.
+func NewNativeMetricsFetcher(conf *config.Configuration, podFetcher pod.PodFetcher, emitter metrics.MetricEmitter) MetricsFetcher {
+	n := &NativeMetricsFetcher{
+		baseMetricsFetcher: newBaseMetricsFetcher(conf, metric.GetMetricStoreInstance(), emitter),
+		podFetcher:         podFetcher,
+		procFS:             procfs.NewProcFS(conf.NativeProcFSRoot),
+		sysFSRoot:          conf.NativeSysFSRoot,
+		cgroupRoot:         conf.NativeCgroupRoot,
+		containerStats:     make(map[string]map[string]*native.CgroupStats),
+	}
+
+	n.samplers[MetricsScopeNode] = n.updateNodeStats
+	n.samplers[MetricsScopeNuma] = n.updateNumaStats
+	n.samplers[MetricsScopeCPU] = n.updateCPUStats
+	n.samplers[MetricsScopeDevice] = n.updateDeviceStats
+	n.samplers[MetricsScopeContainer] = n.updatePodsCgroupData
+	return n
+}
+
+// NativeMetricsFetcher computes rates of accumulated counters between two rounds
+// of sampling, so those rate metrics are only available since the second round.
+type NativeMetricsFetcher struct {
+	*baseMetricsFetcher
+
+	podFetcher pod.PodFetcher
+	procFS     *procfs.ProcFS
+	sysFSRoot  string
+	cgroupRoot string
+
+	initOnce sync.Once
+
+	// raw statistics of the last round of sampling, and scopes are sampled with
+	// different intervals, so each of them keeps its own sampling time if needed
+	cpuStats           map[int]*procfs.CPUStat
+	schedStats         map[int]*procfs.SchedStat
+	diskStats          map[string]*procfs.DiskStat
+	diskStatsTime      time.Time
+	containerStats     map[string]map[string]*native.CgroupStats
+	containerStatsTime time.Time
+}
+
+func (n *NativeMetricsFetcher) Run(ctx context.Context) {
+	n.initOnce.Do(func() {
+		go n.runSampling(ctx)
+	})
+}
+
+// updateNodeStats collects memory and load metrics of the node
+func (n *NativeMetricsFetcher) updateNodeStats(_ context.Context, cur time.Time) error {
+	if err := n.processSystemMemoryData(cur); err != nil {
+		_ = n.emitter.StoreInt64(metricsNameNativeGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
+			metrics.MetricTag{Key: "kind", Val: "memory"})
+		return fmt.Errorf("get system memory stats failed, err %v", err)
+	}
+
+	if err := n.processSystemComputeData(cur); err != nil {
+		_ = n.emitter.StoreInt64(metricsNameNativeGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
+			metrics.MetricTag{Key: "kind", Val: "compute"})
+		return fmt.Errorf("get system compute stats failed, err %v", err)
+	}
+	return nil
+}
+
+func (n *NativeMetricsFetcher) updateNumaStats(_ context.Context, cur time.Time) error {
+	if err := n.processSystemNumaData(cur); err != nil {
+		_ = n.emitter.StoreInt64(metricsNameNativeGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
+			metrics.MetricTag{Key: "kind", Val: "numa"})
+		return fmt.Errorf("get system numa stats failed, err %v", err)
+	}
+	return nil
+}
+
+func (n *NativeMetricsFetcher) updateCPUStats(_ context.Context, cur time.Time) error {
+	if err := n.processSystemCPUComputeData(cur); err != nil {
+		_ = n.emitter.StoreInt64(metricsNameNativeGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
+			metrics.MetricTag{Key: "kind", Val: "cpu"})
+		return fmt.Errorf("get system cpu stats failed, err %v", err)
+	}
+	return nil
+}
+
+func (n *NativeMetricsFetcher) updateDeviceStats(_ context.Context, cur time.Time) error {
+	var elapsed time.Duration
+	if !n.diskStatsTime.IsZero() {
+		elapsed = cur.Sub(n.diskStatsTime)
+	}
+
+	if err := n.processSystemIOData(elapsed, cur); err != nil {
+		_ = n.emitter.StoreInt64(metricsNameNativeGetSystemStatusFailed, 1, metrics.MetricTypeNameCount,
+			metrics.MetricTag{Key: "kind", Val: "io"})
+		return fmt.Errorf("get system io stats failed, err %v", err)
+	}
+	n.diskStatsTime = cur
+	return nil
+}
+
+// updatePodsCgroupData collects container metrics for all containers in pod list, and GC not existed pod metrics
+func (n *NativeMetricsFetcher) updatePodsCgroupData(ctx context.Context, cur time.Time) error {
+	podList, err := n.podFetcher.GetPodList(ctx, nil)
+	if err != nil {
+		_ = n.emitter.StoreInt64(metricsNameNativeGetPodStatusFailed, 1, metrics.MetricTypeNameCount)
+		return fmt.Errorf("get pod list failed, error %v", err)
+	}
+
+	var elapsed time.Duration
+	if !n.containerStatsTime.IsZero() {
+		elapsed = cur.Sub(n.containerStatsTime)
+	}
+
+	podUIDSet := make(map[string]bool)
+	containerStats := make(map[string]map[string]*native.CgroupStats)
+	for _, p := range podList {
+		podUID := string(p.UID)
+		podUIDSet[podUID] = true
+
+		for _, containerStatus := range p.Status.ContainerStatuses {
+			stats, err := n.readContainerStats(p, containerStatus)
+			if err != nil {
+				klog.V(4).Infof("[native] read stats of %s/%s container %s failed: %v",
+					p.Namespace, p.Name, containerStatus.Name, err)
+				continue
+			}
+
+			if containerStats[podUID] == nil {
+				containerStats[podUID] = make(map[string]*native.CgroupStats)
+			}
+			containerStats[podUID][containerStatus.Name] = stats
+
+			n.processCgroupCPUData(podUID, containerStatus.Name, stats, n.containerStats[podUID][containerStatus.Name], cur, elapsed)
+			n.processCgroupMemoryData(podUID, containerStatus.Name, stats, cur)
+			n.processCgroupPerNumaMemoryData(podUID, containerStatus.Name, stats, cur)
+		}
+	}
+	n.containerStats = containerStats
+	n.containerStatsTime = cur
+	n.metricStore.GCPodsMetric(podUIDSet)
+	return nil
+}
+
+// readContainerStats reads cgroup statistics of the container by searching all kubernetes cgroup paths
+func (n *NativeMetricsFetcher) readContainerStats(p *v1.Pod, containerStatus v1.ContainerStatus) (*native.CgroupStats, error) {
+	containerID := nativeutil.TrimContainerIDPrefix(containerStatus.ContainerID)
+	if containerID == "" {
+		return nil, fmt.Errorf("container id is empty")
+	}
+
+	var errList []string
+	for _, cgroupPath := range common.GetKubernetesCgroupPathList() {
+		relativePath := filepath.Join(cgroupPath, common.PodCgroupPathPrefix+string(p.UID), containerID)
+		stats, err := native.ReadCgroupStats(n.cgroupRoot, relativePath)
+		if err == nil {
+			return stats, nil
+		}
+		errList = append(errList, err.Error())
+	}
+	return nil, fmt.Errorf("no valid cgroup found: %v", strings.Join(errList, "; "))
+}
+
+func (n *NativeMetricsFetcher) processSystemMemoryData(updateTime time.Time) error {
+	memInfo, err := n.procFS.ReadMemInfo()
+	if err != nil {
+		return err
+	}
+
+	// values in meminfo are in kB, and used memory is calculated in the same way as free(1)
+	used := memInfo["MemTotal"] - memInfo["MemFree"] - memInfo["Buffers"] - memInfo["Cached"]
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemTotalSystem, metric.MetricData{Value: float64(memInfo["MemTotal"] << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemUsedSystem, metric.MetricData{Value: float64(used << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemFreeSystem, metric.MetricData{Value: float64(memInfo["MemFree"] << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemShmemSystem, metric.MetricData{Value: float64(memInfo["Shmem"] << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemBufferSystem, metric.MetricData{Value: float64(memInfo["Buffers"] << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemAvailableSystem, metric.MetricData{Value: float64(memInfo["MemAvailable"] << 10), Time: updateTime})
+
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemDirtySystem, metric.MetricData{Value: float64(memInfo["Dirty"] << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemWritebackSystem, metric.MetricData{Value: float64(memInfo["Writeback"] << 10), Time: updateTime})
+
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemSwapTotalSystem, metric.MetricData{Value: float64(memInfo["SwapTotal"] << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemSwapFreeSystem, metric.MetricData{Value: float64(memInfo["SwapFree"] << 10), Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemSlabReclaimableSystem, metric.MetricData{Value: float64(memInfo["SReclaimable"] << 10), Time: updateTime})
+
+	vmStat, err := n.procFS.ReadVMStat()
+	if err != nil {
+		return err
+	}
+
+	// pgsteal_kswapd is split into zones before kernel 4.8, e.g. pgsteal_kswapd_normal
+	var kswapdSteal uint64
+	for key, value := range vmStat {
+		if strings.HasPrefix(key, "pgsteal_kswapd") {
+			kswapdSteal += value
+		}
+	}
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemKswapdstealSystem, metric.MetricData{Value: float64(kswapdSteal), Time: updateTime})
+
+	scaleFactor, err := n.procFS.ReadSysctlInt("vm.watermark_scale_factor")
+	if err != nil {
+		return err
+	}
+	n.metricStore.SetNodeMetricWithTime(consts.MetricMemScaleFactorSystem, metric.MetricData{Value: float64(scaleFactor), Time: updateTime})
+
+	return nil
+}
+
+func (n *NativeMetricsFetcher) processSystemNumaData(updateTime time.Time) error {
+	numaMemInfo, err := native.ReadNumaMemInfo(n.sysFSRoot)
+	if err != nil {
+		return err
+	}
+
+	for numaID, memInfo := range numaMemInfo {
+		// numa meminfo has no MemAvailable, so it's approximated by free and reclaimable memory
+		available := memInfo["MemFree"] + memInfo["Inactive(file)"] + memInfo["SReclaimable"]
+		n.metricStore.SetNumaMetricWithTime(numaID, consts.MetricMemTotalNuma, metric.MetricData{Value: float64(memInfo["MemTotal"] << 10), Time: updateTime})
+		n.metricStore.SetNumaMetricWithTime(numaID, consts.MetricMemUsedNuma, metric.MetricData{Value: float64(memInfo["MemUsed"] << 10), Time: updateTime})
+		n.metricStore.SetNumaMetricWithTime(numaID, consts.MetricMemFreeNuma, metric.MetricData{Value: float64(memInfo["MemFree"] << 10), Time: updateTime})
+		n.metricStore.SetNumaMetricWithTime(numaID, consts.MetricMemShmemNuma, metric.MetricData{Value: float64(memInfo["Shmem"] << 10), Time: updateTime})
+		n.metricStore.SetNumaMetricWithTime(numaID, consts.MetricMemAvailableNuma, metric.MetricData{Value: float64(available << 10), Time: updateTime})
+		n.metricStore.SetNumaMetricWithTime(numaID, consts.MetricMemFilepageNuma, metric.MetricData{Value: float64(memInfo["FilePages"] << 10), Time: updateTime})
+	}
+
+	return nil
+}
+
+func (n *NativeMetricsFetcher) processSystemComputeData(updateTime time.Time) error {
+	load, err := n.procFS.ReadLoadAvg()
+	if err != nil {
+		return err
+	}
+
+	n.metricStore.SetNodeMetricWithTime(consts.MetricLoad1MinSystem, metric.MetricData{Value: load.One, Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricLoad5MinSystem, metric.MetricData{Value: load.Five, Time: updateTime})
+	n.metricStore.SetNodeMetricWithTime(consts.MetricLoad15MinSystem, metric.MetricData{Value: load.Fifteen, Time: updateTime})
+	return nil
+}
+
+// processSystemCPUComputeData sets usage and iowait ratio (in percentage) of each cpu, and schedwait
+// is the average time (in microseconds) tasks waited on the runqueue of the cpu per timeslice
+func (n *NativeMetricsFetcher) processSystemCPUComputeData(updateTime time.Time) error {
+	cpuStats, err := n.procFS.ReadCPUStats()
+	if err != nil {
+		return err
+	}
+
+	for cpuID, cur := range cpuStats {
+		last, ok := n.cpuStats[cpuID]
+		if !ok || cur.Total() <= last.Total() {
+			continue
+		}
+
+		total := float64(cur.Total() - last.Total())
+		n.metricStore.SetCPUMetricWithTime(cpuID, consts.MetricCPUUsage, metric.MetricData{Value: float64(cur.Busy()-last.Busy()) / total * 100, Time: updateTime})
+		n.metricStore.SetCPUMetricWithTime(cpuID, consts.MetricCPUIOWaitRatio, metric.MetricData{Value: float64(cur.IOWait-last.IOWait) / total * 100, Time: updateTime})
+	}
+	n.cpuStats = cpuStats
+
+	schedStats, err := n.procFS.ReadSchedStats()
+	if err != nil {
+		return err
+	}
+
+	for cpuID, cur := range schedStats {
+		last, ok := n.schedStats[cpuID]
+		if !ok || cur.Timeslices <= last.Timeslices {
+			continue
+		}
+
+		n.metricStore.SetCPUMetricWithTime(cpuID, consts.MetricCPUSchedwait,
+			metric.MetricData{Value: float64(cur.RunDelay-last.RunDelay) / float64(cur.Timeslices-last.Timeslices) / 1000, Time: updateTime})
+	}
+	n.schedStats = schedStats
+
+	return nil
+}
+
+// processSystemIOData sets read and write throughput (in bytes per second) and busy ratio (in percentage) of each device
+func (n *NativeMetricsFetcher) processSystemIOData(elapsed time.Duration, updateTime time.Time) error {
+	diskStats, err := n.procFS.ReadDiskStats()
+	if err != nil {
+		return err
+	}
+
+	if elapsed > 0 {
+		for device, cur := range diskStats {
+			last, ok := n.diskStats[device]
+			if !ok {
+				continue
+			}
+
+			n.metricStore.SetDeviceMetricWithTime(device, consts.MetricIOReadSystem,
+				metric.MetricData{Value: float64(cur.ReadSectors-last.ReadSectors) * diskSectorSize / elapsed.Seconds(), Time: updateTime})
+			n.metricStore.SetDeviceMetricWithTime(device, consts.MetricIOWriteSystem,
+				metric.MetricData{Value: float64(cur.WriteSectors-last.WriteSectors) * diskSectorSize / elapsed.Seconds(), Time: updateTime})
+			n.metricStore.SetDeviceMetricWithTime(device, consts.MetricIOBusySystem,
+				metric.MetricData{Value: float64(cur.IOTicks-last.IOTicks) / float64(elapsed.Milliseconds()) * 100, Time: updateTime})
+		}
+	}
+	n.diskStats = diskStats
+
+	return nil
+}
+
+// processCgroupCPUData sets cpu metrics of the container, and cpu usage is in cores
+func (n *NativeMetricsFetcher) processCgroupCPUData(podUID, containerName string,
+	cur, last *native.CgroupStats, updateTime time.Time, elapsed time.Duration) {
+	if cur.CPUQuota > 0 && cur.CPUPeriod > 0 {
+		n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPULimitContainer, metric.MetricData{Value: float64(cur.CPUQuota) / float64(cur.CPUPeriod), Time: updateTime})
+	}
+	if last != nil && elapsed > 0 && cur.CPUUsage >= last.CPUUsage {
+		n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUUsageContainer, metric.MetricData{Value: float64(cur.CPUUsage-last.CPUUsage) / float64(elapsed.Nanoseconds()), Time: updateTime})
+	}
+
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUShareContainer, metric.MetricData{Value: float64(cur.CPUShares), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUQuotaContainer, metric.MetricData{Value: float64(cur.CPUQuota), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUPeriodContainer, metric.MetricData{Value: float64(cur.CPUPeriod), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUNrThrottledContainer, metric.MetricData{Value: float64(cur.CPUNrThrottled), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUThrottledPeriodContainer, metric.MetricData{Value: float64(cur.CPUNrPeriods), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricCPUThrottledTimeContainer, metric.MetricData{Value: float64(cur.CPUThrottledTime), Time: updateTime})
+
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricUpdateTimeContainer, metric.MetricData{Value: float64(updateTime.Unix()), Time: updateTime})
+}
+
+func (n *NativeMetricsFetcher) processCgroupMemoryData(podUID, containerName string, stats *native.CgroupStats, updateTime time.Time) {
+	var userUsage uint64
+	if stats.MemUsage > stats.MemKernUsage {
+		userUsage = stats.MemUsage - stats.MemKernUsage
+	}
+
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemLimitContainer, metric.MetricData{Value: float64(stats.MemLimit), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageContainer, metric.MetricData{Value: float64(stats.MemUsage), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageUserContainer, metric.MetricData{Value: float64(userUsage), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemUsageSysContainer, metric.MetricData{Value: float64(stats.MemKernUsage), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemRssContainer, metric.MetricData{Value: float64(stats.MemRss), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemCacheContainer, metric.MetricData{Value: float64(stats.MemCache), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemShmemContainer, metric.MetricData{Value: float64(stats.MemShmem), Time: updateTime})
+
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemDirtyContainer, metric.MetricData{Value: float64(stats.MemDirty), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemWritebackContainer, metric.MetricData{Value: float64(stats.MemWriteback), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgfaultContainer, metric.MetricData{Value: float64(stats.MemPgfault), Time: updateTime})
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemPgmajfaultContainer, metric.MetricData{Value: float64(stats.MemPgmajfault), Time: updateTime})
+
+	n.metricStore.SetContainerMetricWithTime(podUID, containerName, consts.MetricMemOomContainer, metric.MetricData{Value: float64(stats.MemOOMCnt), Time: updateTime})
+}
+
+func (n *NativeMetricsFetcher) processCgroupPerNumaMemoryData(podUID, containerName string, stats *native.CgroupStats, updateTime time.Time) {
+	for numaID, data := range stats.MemNumaStats {
+		numaNode := fmt.Sprintf("%v", numaID)
+		n.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaNode, consts.MetricsMemTotalPerNumaContainer, metric.MetricData{Value: float64(data.Total), Time: updateTime})
+		n.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaNode, consts.MetricsMemFilePerNumaContainer, metric.MetricData{Value: float64(data.File), Time: updateTime})
+		n.metricStore.SetContainerNumaMetricWithTime(podUID, containerName, numaNode, consts.MetricsMemAnonPerNumaContainer, metric.MetricData{Value: float64(data.Anon), Time: updateTime})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubewharf/katalyst-core/cmd/katalyst-agent/app/options"
+	"github.com/kubewharf/katalyst-core/pkg/consts"
+	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+	"github.com/kubewharf/katalyst-core/pkg/util/metric"
+)
+
+func writeNativeFiles(t *testing.T, root string, files map[string]string) {
+	for file, content := range files {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestNativeMetricsFetcher(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	conf, err := options.NewOptions().Config()
+	require.NoError(t, err)
+	conf.MetricsFetcher = MetricsFetcherNative
+	conf.NativeProcFSRoot = filepath.Join(root, "proc")
+	conf.NativeSysFSRoot = filepath.Join(root, "sys")
+	conf.NativeCgroupRoot = filepath.Join(root, "cgroup")
+
+	podFetcher := &pod.PodFetcherStub{PodList: []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", UID: types.UID("uid1")},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+				{Name: "c1", ContainerID: "containerd://cid1"},
+				{Name: "c2", ContainerID: "containerd://cid2"},
+			}},
+		},
+	}}
+
+	fetcher, err := NewMetricsFetcher(conf, podFetcher, metrics.DummyMetrics{})
+	require.NoError(t, err)
+	f := fetcher.(*NativeMetricsFetcher)
+	f.metricStore = metric.NewMetricStore()
+
+	now := time.Unix(1000, 0)
+	f.now = func() time.Time { return now }
+
+	writeNativeFiles(t, root, map[string]string{
+		"proc/meminfo": "MemTotal:       16384 kB\nMemFree:         4096 kB\nMemAvailable:    8192 kB\n" +
+			"Buffers:          1024 kB\nCached:           2048 kB\nShmem:             512 kB\n",
+		"proc/vmstat":                        "pgsteal_kswapd_dma 1\npgsteal_kswapd_normal 9\npgscan_kswapd 20\n",
+		"proc/sys/vm/watermark_scale_factor": "10\n",
+		"proc/loadavg":                       "1.50 1.00 0.50 2/1234 56789\n",
+		"proc/stat":                          "cpu  0 0 0 0 0 0 0 0 0 0\ncpu0 100 0 100 700 100 0 0 0 0 0\n",
+		"proc/schedstat":                     "version 15\ntimestamp 4294\ncpu0 0 0 0 0 0 0 1000 2000000 100\n",
+		"proc/diskstats":                     "   8       0 sda 100 0 1000 0 100 0 2000 0 0 1000 0 0 0 0 0\n",
+		"sys/devices/system/node/node0/meminfo": "Node 0 MemTotal:       16384 kB\nNode 0 MemFree:         4096 kB\n" +
+			"Node 0 MemUsed:        12288 kB\nNode 0 FilePages:       2048 kB\nNode 0 Inactive(file):  1024 kB\n" +
+			"Node 0 SReclaimable:     512 kB\n",
+		"cgroup/cpuacct/kubepods/poduid1/cid1/cpuacct.usage":             "1000000000\n",
+		"cgroup/cpu/kubepods/poduid1/cid1/cpu.cfs_quota_us":              "200000\n",
+		"cgroup/cpu/kubepods/poduid1/cid1/cpu.cfs_period_us":             "100000\n",
+		"cgroup/memory/kubepods/poduid1/cid1/memory.usage_in_bytes":      "4096\n",
+		"cgroup/memory/kubepods/poduid1/cid1/memory.kmem.usage_in_bytes": "1024\n",
+		"cgroup/memory/kubepods/poduid1/cid1/memory.numa_stat":           "total=2 N0=2\nfile=1 N0=1\nanon=1 N0=1\n",
+	})
+	f.sample(context.Background())
+
+	value, err := f.GetNodeMetric(consts.MetricMemUsedSystem)
+	require.NoError(t, err)
+	require.Equal(t, float64(9216<<10), value)
+	value, err = f.GetNodeMetric(consts.MetricMemKswapdstealSystem)
+	require.NoError(t, err)
+	require.Equal(t, float64(10), value)
+	value, err = f.GetNodeMetric(consts.MetricMemScaleFactorSystem)
+	require.NoError(t, err)
+	require.Equal(t, float64(10), value)
+	value, err = f.GetNodeMetric(consts.MetricLoad1MinSystem)
+	require.NoError(t, err)
+	require.Equal(t, 1.5, value)
+	value, err = f.GetNumaMetric(0, consts.MetricMemAvailableNuma)
+	require.NoError(t, err)
+	require.Equal(t, float64(5632<<10), value)
+	value, err = f.GetContainerMetric("uid1", "c1", consts.MetricCPULimitContainer)
+	require.NoError(t, err)
+	require.Equal(t, float64(2), value)
+	value, err = f.GetContainerMetric("uid1", "c1", consts.MetricMemUsageUserContainer)
+	require.NoError(t, err)
+	require.Equal(t, float64(3072), value)
+	value, err = f.GetContainerNumaMetric("uid1", "c1", "0", consts.MetricsMemTotalPerNumaContainer)
+	require.NoError(t, err)
+	require.Equal(t, float64(2*os.Getpagesize()), value)
+
+	// rates are not available until the second round of sampling
+	_, err = f.GetCPUMetric(0, consts.MetricCPUUsage)
+	require.Error(t, err)
+	_, err = f.GetDeviceMetric("sda", consts.MetricIOReadSystem)
+	require.Error(t, err)
+	_, err = f.GetContainerMetric("uid1", "c1", consts.MetricCPUUsageContainer)
+	require.Error(t, err)
+	_, err = f.GetContainerMetric("uid1", "c2", consts.MetricMemUsageContainer)
+	require.Error(t, err)
+
+	now = now.Add(10 * time.Second)
+	writeNativeFiles(t, root, map[string]string{
+		"proc/stat":      "cpu  0 0 0 0 0 0 0 0 0 0\ncpu0 200 0 200 1400 200 0 0 0 0 0\n",
+		"proc/schedstat": "version 15\ntimestamp 4294\ncpu0 0 0 0 0 0 0 1000 12000000 200\n",
+		"proc/diskstats": "   8       0 sda 200 0 21000 0 200 0 42000 0 0 6000 0 0 0 0 0\n",
+		"cgroup/cpuacct/kubepods/poduid1/cid1/cpuacct.usage": "16000000000\n",
+	})
+	f.sample(context.Background())
+
+	value, err = f.GetCPUMetric(0, consts.MetricCPUUsage)
+	require.NoError(t, err)
+	require.Equal(t, float64(20), value)
+	value, err = f.GetCPUMetric(0, consts.MetricCPUIOWaitRatio)
+	require.NoError(t, err)
+	require.Equal(t, float64(10), value)
+	value, err = f.GetCPUMetric(0, consts.MetricCPUSchedwait)
+	require.NoError(t, err)
+	require.Equal(t, float64(100), value)
+	value, err = f.GetDeviceMetric("sda", consts.MetricIOReadSystem)
+	require.NoError(t, err)
+	require.Equal(t, float64(1024000), value)
+	value, err = f.GetDeviceMetric("sda", consts.MetricIOWriteSystem)
+	require.NoError(t, err)
+	require.Equal(t, float64(2048000), value)
+	value, err = f.GetDeviceMetric("sda", consts.MetricIOBusySystem)
+	require.NoError(t, err)
+	require.Equal(t, float64(50), value)
+	value, err = f.GetContainerMetric("uid1", "c1", consts.MetricCPUUsageContainer)
+	require.NoError(t, err)
+	require.Equal(t, 1.5, value)
+
+	// metrics of pods not existing any more should be cleaned up
+	podFetcher.PodList = nil
+	now = now.Add(10 * time.Second)
+	f.sample(context.Background())
+	_, err = f.GetContainerMetric("uid1", "c1", consts.MetricMemUsageContainer)
+	require.Error(t, err)
+}
+
+func TestNewMetricsFetcher(t *testing.T) {
+	t.Parallel()
+
+	conf, err := options.NewOptions().Config()
+	require.NoError(t, err)
+
+	fetcher, err := NewMetricsFetcher(conf, &pod.PodFetcherStub{}, metrics.DummyMetrics{})
+	require.NoError(t, err)
+	require.IsType(t, &MalachiteMetricsFetcher{}, fetcher)
+
+	conf.MetricsFetcher = "unknown"
+	_, err = NewMetricsFetcher(conf, &pod.PodFetcherStub{}, metrics.DummyMetrics{})
+	require.Error(t, err)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// PodAnnotationStaleKey is set on pods served from apiserver when kubelet is unavailable,
+	// since status of those pods may lag behind the node; consumers should avoid making
+	// destructive decisions (e.g. eviction) based on them.
+	PodAnnotationStaleKey   = "meta.katalyst.kubewharf.io/pod-stale"
+	PodAnnotationStaleValue = "true"
+
+	// podAnnotationConfigMirror is set on mirror pods of static pods by kubelet,
+	// and its value is the uid of the static pod known by kubelet
+	podAnnotationConfigMirror = "kubernetes.io/config.mirror"
+
+	apiServerPodResyncPeriod = 24 * time.Hour
+)
+
+// IsPodStale returns true if the pod is served from a stale source instead of kubelet.
+func IsPodStale(pod *v1.Pod) bool {
+	return pod != nil && pod.Annotations[PodAnnotationStaleKey] == PodAnnotationStaleValue
+}
+
+// HasStalePods returns true if pods are served from a stale source by the fetcher, in which case
+// consumers should keep their last decisions instead of making destructive ones based on them.
+func HasStalePods(ctx context.Context, fetcher KubeletPodFetcher) (bool, error) {
+	stalePods, err := fetcher.GetPodList(ctx, IsPodStale)
+	if err != nil {
+		return false, err
+	}
+	return len(stalePods) > 0, nil
+}
+
+// APIServerPodFetcher is used to get pods bound to this node from apiserver.
+type APIServerPodFetcher interface {
+	KubeletPodFetcher
+
+	// Run starts the informer of pods, and it returns immediately.
+	Run(ctx context.Context)
+	// HasSynced returns true if pods in apiserver have been synced at least once.
+	HasSynced() bool
+}
+
+// apiServerPodFetcherImpl watches pods of this node through a field-selected informer.
+type apiServerPodFetcherImpl struct {
+	factory   informers.SharedInformerFactory
+	podLister corelisters.PodLister
+	hasSynced cache.InformerSynced
+}
+
+func NewAPIServerPodFetcher(nodeName string, kubeClient kubernetes.Interface) APIServerPodFetcher {
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeClient, apiServerPodResyncPeriod,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+		}))
+	podInformer := factory.Core().V1().Pods()
+
+	return &apiServerPodFetcherImpl{
+		factory:   factory,
+		podLister: podInformer.Lister(),
+		hasSynced: podInformer.Informer().HasSynced,
+	}
+}
+
+func (a *apiServerPodFetcherImpl) Run(ctx context.Context) {
+	a.factory.Start(ctx.Done())
+}
+
+func (a *apiServerPodFetcherImpl) HasSynced() bool {
+	return a.hasSynced()
+}
+
+// GetPodList returns pods in informer cache, and uid of mirror pods are replaced with
+// uid of their static pods to keep consistent with kubelet; the returned pods are copies
+// with stale annotation, since they are only used when kubelet is unavailable.
+func (a *apiServerPodFetcherImpl) GetPodList(_ context.Context, podFilter func(*v1.Pod) bool) ([]*v1.Pod, error) {
+	if !a.hasSynced() {
+		return nil, fmt.Errorf("apiserver pod informer has not synced")
+	}
+
+	pods, err := a.podLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*v1.Pod, 0, len(pods))
+	for _, p := range pods {
+		pod := p.DeepCopy()
+		if uid, ok := pod.Annotations[podAnnotationConfigMirror]; ok {
+			klog.V(4).Infof("use uid %v of static pod for mirror pod %s/%s", uid, pod.Namespace, pod.Name)
+			pod.UID = types.UID(uid)
+		}
+
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		pod.Annotations[PodAnnotationStaleKey] = PodAnnotationStaleValue
+
+		if podFilter != nil && !podFilter(pod) {
+			continue
+		}
+		res = append(res, pod)
+	}
+	return res, nil
+}
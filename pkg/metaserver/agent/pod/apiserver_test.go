@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/metrics"
+)
+
+type kubeletPodFetcherStub struct {
+	pods []*v1.Pod
+	err  error
+}
+
+func (k *kubeletPodFetcherStub) GetPodList(_ context.Context, _ func(*v1.Pod) bool) ([]*v1.Pod, error) {
+	return k.pods, k.err
+}
+
+func TestPodFetcherAPIServerFallback(t *testing.T) {
+	t.Parallel()
+
+	kubeletPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod1", UID: "uid1"},
+		Spec:       v1.PodSpec{NodeName: "node1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	mirrorPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "kube-system",
+			Name:        "static-pod1",
+			UID:         "mirror-uid",
+			Annotations: map[string]string{podAnnotationConfigMirror: "static-uid"},
+		},
+		Spec: v1.PodSpec{NodeName: "node1"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	apiServerPodFetcher := NewAPIServerPodFetcher("node1", fake.NewSimpleClientset(kubeletPod, mirrorPod))
+	apiServerPodFetcher.Run(ctx)
+	require.Eventually(t, apiServerPodFetcher.HasSynced, 10*time.Second, 10*time.Millisecond)
+
+	kubeletPodFetcher := &kubeletPodFetcherStub{pods: []*v1.Pod{kubeletPod}}
+	w := &podFetcherImpl{
+		kubeletPodFetcher:   kubeletPodFetcher,
+		apiServerPodFetcher: apiServerPodFetcher,
+		emitter:             metrics.DummyMetrics{},
+		fallbackLogLimiter:  rate.NewLimiter(rate.Every(apiServerFallbackLogInterval), 1),
+		conf:                config.NewConfiguration(),
+	}
+	w.conf.APIServerFallbackGracePeriod = time.Hour
+
+	// pods are served from kubelet if it's available
+	pods, err := w.GetPodList(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.False(t, IsPodStale(pods[0]))
+	w.checkPodCache()
+
+	// pods last synced from kubelet are still preferred within the grace period
+	kubeletPodFetcher.err = fmt.Errorf("connection refused")
+	bypassCtx := context.WithValue(ctx, BypassCacheKey, BypassCacheTrue)
+	pods, err = w.GetPodList(bypassCtx, nil)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.False(t, IsPodStale(pods[0]))
+
+	// pods are served from apiserver with stale annotation after the grace period
+	w.conf.APIServerFallbackGracePeriod = 0
+	pods, err = w.GetPodList(bypassCtx, nil)
+	require.NoError(t, err)
+	require.Len(t, pods, 2)
+	for _, p := range pods {
+		require.True(t, IsPodStale(p))
+	}
+	stale, err := HasStalePods(bypassCtx, w)
+	require.NoError(t, err)
+	require.True(t, stale)
+
+	p, err := w.GetPod(ctx, "static-uid")
+	require.NoError(t, err)
+	require.Equal(t, "static-pod1", p.Name)
+	require.NotContains(t, kubeletPod.Annotations, PodAnnotationStaleKey)
+
+	// pods last synced from kubelet are kept if apiserver is not available either
+	w.apiServerPodFetcher = nil
+	pods, err = w.GetPodList(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.False(t, IsPodStale(pods[0]))
+
+	// pods are served from kubelet again once it recovers
+	w.apiServerPodFetcher = apiServerPodFetcher
+	kubeletPodFetcher.err = nil
+	pods, err = w.GetPodList(bypassCtx, nil)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.False(t, IsPodStale(pods[0]))
+	stale, err = HasStalePods(ctx, w)
+	require.NoError(t, err)
+	require.False(t, stale)
+
+	// no pods are served if neither kubelet nor apiserver is available
+	w = &podFetcherImpl{
+		kubeletPodFetcher: &kubeletPodFetcherStub{err: fmt.Errorf("connection refused")},
+		emitter:           metrics.DummyMetrics{},
+		conf:              config.NewConfiguration(),
+	}
+	_, err = w.GetPodList(ctx, nil)
+	require.Error(t, err)
+}
@@ -18,13 +18,118 @@ package pod
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	certutil "k8s.io/client-go/util/cert"
 
-	"github.com/kubewharf/katalyst-core/pkg/util/process"
+	"github.com/kubewharf/katalyst-core/pkg/config"
 )
 
+const (
+	kubeletHost                = "localhost"
+	defaultKubeletReadOnlyPort = 10255
+	defaultKubeletSecurePort   = 10250
+	kubeletHTTPTimeout         = 10 * time.Second
+
+	kubeletPodsPath = "/pods"
+)
+
+// KubeletClient talks to kubelet through its read-only port, or its authenticated port if
+// enabled, and all components talking to kubelet should use it to share the same kubelet
+// endpoint and credentials.
+type KubeletClient struct {
+	baseURL string
+	// tokenFile is the file of bearer token used to authenticate to kubelet secure port,
+	// and it's read for each request since the token may be rotated
+	tokenFile string
+	client    *http.Client
+}
+
+// NewKubeletClient returns a KubeletClient according to kubelet endpoint configurations. Serving
+// certificate of kubelet authenticated port is verified by the configured CA file (or system CA
+// certificates if not configured), unless skipping verification is explicitly enabled.
+func NewKubeletClient(conf *config.Configuration) (*KubeletClient, error) {
+	if !conf.KubeletSecurePortEnabled {
+		port := conf.KubeletReadOnlyPort
+		if port <= 0 {
+			port = defaultKubeletReadOnlyPort
+		}
+		return &KubeletClient{
+			baseURL: fmt.Sprintf("http://%s:%d", kubeletHost, port),
+			client:  &http.Client{Timeout: kubeletHTTPTimeout},
+		}, nil
+	}
+
+	port := conf.KubeletSecurePort
+	if port <= 0 {
+		port = defaultKubeletSecurePort
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if conf.KubeletCAFile != "" {
+		pool, err := certutil.NewPool(conf.KubeletCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("load kubelet ca file %v failed: %v", conf.KubeletCAFile, err)
+		}
+		tlsConfig.RootCAs = pool
+	} else if conf.KubeletInsecureSkipTLSVerify {
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &KubeletClient{
+		baseURL:   fmt.Sprintf("https://%s:%d", kubeletHost, port),
+		tokenFile: conf.APIAuthTokenFile,
+		client:    &http.Client{Timeout: kubeletHTTPTimeout, Transport: transport},
+	}, nil
+}
+
+// Get sends a GET request to the given path of kubelet, and callers should close the response
+// body; responses with status codes other than 200 are returned as errors.
+func (k *KubeletClient) Get(path string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, k.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if k.tokenFile != "" {
+		token, err := ioutil.ReadFile(k.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("read auth token file %v failed: %v", k.tokenFile, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %v of %v", resp.StatusCode, req.URL)
+	}
+	return resp, nil
+}
+
+// GetAndUnmarshal gets the given path of kubelet and unmarshals the response into v
+func (k *KubeletClient) GetAndUnmarshal(path string, v interface{}) error {
+	resp, err := k.Get(path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
 // KubeletPodFetcher is used to get K8S kubelet pod information.
 type KubeletPodFetcher interface {
 	// GetPodList returns those latest pods, and podFilter is a function to filter a pod,
@@ -32,16 +137,16 @@ type KubeletPodFetcher interface {
 	GetPodList(ctx context.Context, podFilter func(*v1.Pod) bool) ([]*v1.Pod, error)
 }
 
-// kubeletPodFetcherImpl use kubelet 10255 pods interface to get pod directly without cache.
-type kubeletPodFetcherImpl struct{}
+// kubeletPodFetcherImpl use kubelet pods interface to get pod directly without cache.
+type kubeletPodFetcherImpl struct {
+	kubeletClient *KubeletClient
+}
 
-// GetPodList get pods from kubelet 10255/pods api, and the returned slice does not
+// GetPodList get pods from kubelet /pods api, and the returned slice does not
 // contain pods that don't pass `podFilter`
 func (k *kubeletPodFetcherImpl) GetPodList(_ context.Context, podFilter func(*v1.Pod) bool) ([]*v1.Pod, error) {
-	const podsApi = "http://localhost:10255/pods"
-
 	var podList v1.PodList
-	err := process.GetAndUnmarshal(podsApi, &podList)
+	err := k.kubeletClient.GetAndUnmarshal(kubeletPodsPath, &podList)
 	if err != nil {
 		return []*v1.Pod{}, fmt.Errorf("failed to get pod list, error: %v", err)
 	} else if len(podList.Items) == 0 {
@@ -59,6 +164,13 @@ func (k *kubeletPodFetcherImpl) GetPodList(_ context.Context, podFilter func(*v1
 	return pods, nil
 }
 
-func NewKubeletPodFetcher() KubeletPodFetcher {
-	return &kubeletPodFetcherImpl{}
+func NewKubeletPodFetcher(conf *config.Configuration) (KubeletPodFetcher, error) {
+	kubeletClient, err := NewKubeletClient(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kubeletPodFetcherImpl{
+		kubeletClient: kubeletClient,
+	}, nil
 }
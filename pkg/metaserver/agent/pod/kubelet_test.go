@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pod
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/kubewharf/katalyst-core/pkg/config"
+)
+
+func TestKubeletClientSecurePort(t *testing.T) {
+	t.Parallel()
+
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey(kubeletHost, []net.IP{net.ParseIP("127.0.0.1")}, nil)
+	require.NoError(t, err)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"items": [{"metadata": {"name": "pod1"}}]}`))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("test-token\n"), 0o600))
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, ioutil.WriteFile(caFile, certPEM, 0o600))
+
+	conf := config.NewConfiguration()
+	conf.KubeletSecurePortEnabled = true
+	conf.KubeletSecurePort = port
+	conf.APIAuthTokenFile = tokenFile
+
+	getPodList := func() ([]*v1.Pod, error) {
+		fetcher, err := NewKubeletPodFetcher(conf)
+		require.NoError(t, err)
+		return fetcher.GetPodList(context.Background(), nil)
+	}
+
+	// serving certificate can't be verified without the ca file
+	_, err = getPodList()
+	require.Error(t, err)
+
+	conf.KubeletCAFile = caFile
+	pods, err := getPodList()
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.Equal(t, "pod1", pods[0].Name)
+
+	// requests are rejected without the right token
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("wrong-token"), 0o600))
+	_, err = getPodList()
+	require.Error(t, err)
+
+	// verification is only skipped if it's explicitly enabled
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("test-token"), 0o600))
+	conf.KubeletCAFile = ""
+	conf.KubeletInsecureSkipTLSVerify = true
+	pods, err = getPodList()
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+
+	// invalid ca file fails the creation of kubelet client
+	require.NoError(t, ioutil.WriteFile(caFile, []byte("invalid"), 0o600))
+	conf.KubeletCAFile = caFile
+	_, err = NewKubeletPodFetcher(conf)
+	require.Error(t, err)
+}
@@ -26,6 +26,7 @@ import (
 	"golang.org/x/time/rate"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
 	"github.com/kubewharf/katalyst-core/pkg/config"
@@ -39,8 +40,21 @@ const (
 	metricsNamePodCacheSync       = "pod_cache_sync"
 	metricsNamePodCacheTotalCount = "pod_cache_total_count"
 	metricsNamePodCacheNotFound   = "pod_cache_not_found"
+	metricsNamePodFetcherServe    = "pod_fetcher_serve"
 )
 
+// sources of pods served by pod fetcher
+const (
+	podSourceKubelet      = "kubelet"
+	podSourceKubeletCache = "kubelet_cache"
+	podSourceAPIServer    = "apiserver"
+	podSourceNone         = "none"
+)
+
+// apiServerFallbackLogInterval bounds how often serving pods from apiserver is logged,
+// since it happens in each call when kubelet is unavailable
+const apiServerFallbackLogInterval = time.Minute
+
 type ContextKey string
 
 const (
@@ -64,31 +78,48 @@ type PodFetcher interface {
 type podFetcherImpl struct {
 	kubeletPodFetcher KubeletPodFetcher
 	runtimePodFetcher RuntimePodFetcher
+	// apiServerPodFetcher is only used as a fallback when kubelet is unavailable, and it's nil if disabled
+	apiServerPodFetcher APIServerPodFetcher
 
 	kubeletPodsCache     map[string]*v1.Pod
+	kubeletAvailable     bool
+	kubeletLastSyncTime  time.Time
 	kubeletPodsCacheLock sync.RWMutex
 	runtimePodsCache     map[string]*RuntimePod
 	runtimePodsCacheLock sync.RWMutex
 
-	emitter metrics.MetricEmitter
+	emitter            metrics.MetricEmitter
+	fallbackLogLimiter *rate.Limiter
 
 	conf            *config.Configuration
 	cgroupRootPaths []string
 }
 
-func NewPodFetcher(conf *config.Configuration, emitter metrics.MetricEmitter) (PodFetcher, error) {
+func NewPodFetcher(conf *config.Configuration, kubeClient kubernetes.Interface, emitter metrics.MetricEmitter) (PodFetcher, error) {
 	runtimePodFetcher, err := NewRuntimePodFetcher(conf)
 	if err != nil {
 		klog.Errorf("init runtime pod fetcher failed: %v", err)
 		runtimePodFetcher = nil
 	}
 
+	kubeletPodFetcher, err := NewKubeletPodFetcher(conf)
+	if err != nil {
+		return nil, fmt.Errorf("init kubelet pod fetcher failed: %v", err)
+	}
+
+	var apiServerPodFetcher APIServerPodFetcher
+	if conf.EnableAPIServerPodFallback && kubeClient != nil && conf.NodeName != "" {
+		apiServerPodFetcher = NewAPIServerPodFetcher(conf.NodeName, kubeClient)
+	}
+
 	return &podFetcherImpl{
-		kubeletPodFetcher: NewKubeletPodFetcher(),
-		runtimePodFetcher: runtimePodFetcher,
-		emitter:           emitter,
-		conf:              conf,
-		cgroupRootPaths:   common.GetKubernetesCgroupRootPathWithSubSys(common.DefaultSelectedSubsys),
+		kubeletPodFetcher:   kubeletPodFetcher,
+		runtimePodFetcher:   runtimePodFetcher,
+		apiServerPodFetcher: apiServerPodFetcher,
+		emitter:             emitter,
+		fallbackLogLimiter:  rate.NewLimiter(rate.Every(apiServerFallbackLogInterval), 1),
+		conf:                conf,
+		cgroupRootPaths:     common.GetKubernetesCgroupRootPathWithSubSys(common.DefaultSelectedSubsys),
 	}, nil
 }
 
@@ -97,9 +128,9 @@ func (w *podFetcherImpl) GetContainerSpec(podUID, containerName string) (*v1.Con
 		return nil, fmt.Errorf("get container spec from nil pod fetcher")
 	}
 
-	kubeletPodsCache, err := w.getKubeletPodsCache(context.Background())
+	kubeletPodsCache, err := w.getPodsCache(context.Background(), "GetContainerSpec")
 	if err != nil {
-		return nil, fmt.Errorf("getKubeletPodsCache failed with error: %v", err)
+		return nil, fmt.Errorf("getPodsCache failed with error: %v", err)
 	}
 
 	if kubeletPodsCache[podUID] == nil {
@@ -120,9 +151,9 @@ func (w *podFetcherImpl) GetContainerID(podUID, containerName string) (string, e
 		return "", fmt.Errorf("get container id from nil pod fetcher")
 	}
 
-	kubeletPodsCache, err := w.getKubeletPodsCache(context.Background())
+	kubeletPodsCache, err := w.getPodsCache(context.Background(), "GetContainerID")
 	if err != nil {
-		return "", fmt.Errorf("getKubeletPodsCache failed with error: %v", err)
+		return "", fmt.Errorf("getPodsCache failed with error: %v", err)
 	}
 
 	if kubeletPodsCache[podUID] == nil {
@@ -142,6 +173,10 @@ func (w *podFetcherImpl) GetContainerID(podUID, containerName string) (string, e
 }
 
 func (w *podFetcherImpl) Run(ctx context.Context) {
+	if w.apiServerPodFetcher != nil {
+		w.apiServerPodFetcher.Run(ctx)
+	}
+
 	watcherInfo := general.FileWatcherInfo{
 		Path:     w.cgroupRootPaths,
 		Filename: "",
@@ -183,9 +218,9 @@ func (w *podFetcherImpl) Run(ctx context.Context) {
 }
 
 func (w *podFetcherImpl) GetPodList(ctx context.Context, podFilter func(*v1.Pod) bool) ([]*v1.Pod, error) {
-	kubeletPodsCache, err := w.getKubeletPodsCache(ctx)
+	kubeletPodsCache, err := w.getPodsCache(ctx, "GetPodList")
 	if err != nil {
-		return nil, fmt.Errorf("getKubeletPodsCache failed with error: %v", err)
+		return nil, fmt.Errorf("getPodsCache failed with error: %v", err)
 	}
 
 	res := make([]*v1.Pod, 0, len(kubeletPodsCache))
@@ -200,9 +235,9 @@ func (w *podFetcherImpl) GetPodList(ctx context.Context, podFilter func(*v1.Pod)
 }
 
 func (w *podFetcherImpl) GetPod(ctx context.Context, podUID string) (*v1.Pod, error) {
-	kubeletPodsCache, err := w.getKubeletPodsCache(ctx)
+	kubeletPodsCache, err := w.getPodsCache(ctx, "GetPod")
 	if err != nil {
-		return nil, fmt.Errorf("getKubeletPodsCache failed with error: %v", err)
+		return nil, fmt.Errorf("getPodsCache failed with error: %v", err)
 	}
 	if pod, ok := kubeletPodsCache[podUID]; ok {
 		return pod, nil
@@ -210,6 +245,69 @@ func (w *podFetcherImpl) GetPod(ctx context.Context, podUID string) (*v1.Pod, er
 	return nil, fmt.Errorf("failed to find pod by uid %v", podUID)
 }
 
+// getPodsCache prefers pods from kubelet, and pods last synced from kubelet are still served
+// within the grace period after kubelet becomes unavailable; after that, it falls back to pods
+// from apiserver (marked as stale). The source serving each call is recorded in metrics.
+func (w *podFetcherImpl) getPodsCache(ctx context.Context, method string) (map[string]*v1.Pod, error) {
+	kubeletPodsCache, err := w.getKubeletPodsCache(ctx)
+	if err == nil {
+		available, lastSyncTime := w.getKubeletStatus()
+		if available {
+			w.emitPodSource(method, podSourceKubelet)
+			return kubeletPodsCache, nil
+		} else if time.Since(lastSyncTime) <= w.conf.APIServerFallbackGracePeriod {
+			w.emitPodSource(method, podSourceKubeletCache)
+			return kubeletPodsCache, nil
+		}
+	}
+
+	if w.apiServerPodFetcher != nil {
+		apiServerPods, apiServerErr := w.apiServerPodFetcher.GetPodList(ctx, nil)
+		if apiServerErr == nil {
+			if w.fallbackLogLimiter.Allow() {
+				klog.Warningf("kubelet is unavailable, serve %d pods from apiserver for %s", len(apiServerPods), method)
+			}
+			w.emitPodSource(method, podSourceAPIServer)
+
+			apiServerPodsCache := make(map[string]*v1.Pod, len(apiServerPods))
+			for _, p := range apiServerPods {
+				apiServerPodsCache[string(p.UID)] = p
+			}
+			return apiServerPodsCache, nil
+		}
+		klog.Errorf("get pods from apiserver failed: %v", apiServerErr)
+	}
+
+	if err != nil {
+		w.emitPodSource(method, podSourceNone)
+		return nil, err
+	}
+
+	// kubelet is unavailable and apiserver is not ready, so keep using pods last synced from kubelet
+	w.emitPodSource(method, podSourceKubeletCache)
+	return kubeletPodsCache, nil
+}
+
+func (w *podFetcherImpl) isKubeletAvailable() bool {
+	available, _ := w.getKubeletStatus()
+	return available
+}
+
+// getKubeletStatus returns whether kubelet is available and when pods were last synced from it
+func (w *podFetcherImpl) getKubeletStatus() (bool, time.Time) {
+	w.kubeletPodsCacheLock.RLock()
+	defer w.kubeletPodsCacheLock.RUnlock()
+	return w.kubeletAvailable, w.kubeletLastSyncTime
+}
+
+func (w *podFetcherImpl) emitPodSource(method, source string) {
+	_ = w.emitter.StoreInt64(metricsNamePodFetcherServe, 1, metrics.MetricTypeNameCount,
+		metrics.ConvertMapToTags(map[string]string{
+			"method": method,
+			"source": source,
+		})...)
+}
+
 func (w *podFetcherImpl) getKubeletPodsCache(ctx context.Context) (map[string]*v1.Pod, error) {
 	// if current kubelet pod cache is nil or enforce bypass, we sync cache first
 	w.kubeletPodsCacheLock.RLock()
@@ -270,6 +368,12 @@ func (w *podFetcherImpl) syncRuntimePod(_ context.Context) {
 // syncKubeletPod sync local kubelet pod cache from kubelet pod fetcher.
 func (w *podFetcherImpl) syncKubeletPod(ctx context.Context) {
 	kubeletPods, err := w.kubeletPodFetcher.GetPodList(ctx, nil)
+	if err != nil || len(kubeletPods) == 0 {
+		w.kubeletPodsCacheLock.Lock()
+		w.kubeletAvailable = false
+		w.kubeletPodsCacheLock.Unlock()
+	}
+
 	if err != nil {
 		klog.Errorf("sync kubelet pod failed: %s", err)
 		_ = w.emitter.StoreInt64(metricsNamePodCacheSync, 1, metrics.MetricTypeNameCount,
@@ -304,6 +408,8 @@ func (w *podFetcherImpl) syncKubeletPod(ctx context.Context) {
 
 	w.kubeletPodsCacheLock.Lock()
 	w.kubeletPodsCache = kubeletPodsCache
+	w.kubeletAvailable = true
+	w.kubeletLastSyncTime = time.Now()
 	w.kubeletPodsCacheLock.Unlock()
 }
 
@@ -353,4 +459,42 @@ func (w *podFetcherImpl) checkPodCache() {
 		metrics.ConvertMapToTags(map[string]string{
 			"source": "kubelet",
 		})...)
+
+	w.checkAPIServerPodCache(kubeletPodsCache)
+}
+
+// checkAPIServerPodCache reconciles pods in apiserver with those in kubelet, and sends a metric alert if they don't match.
+func (w *podFetcherImpl) checkAPIServerPodCache(kubeletPodsCache map[string]*v1.Pod) {
+	if w.apiServerPodFetcher == nil || !w.isKubeletAvailable() {
+		return
+	}
+
+	apiServerPods, err := w.apiServerPodFetcher.GetPodList(context.Background(), nil)
+	if err != nil {
+		klog.Errorf("get pods from apiserver failed: %v", err)
+		return
+	}
+
+	_ = w.emitter.StoreInt64(metricsNamePodCacheTotalCount, int64(len(apiServerPods)), metrics.MetricTypeNameRaw,
+		metrics.ConvertMapToTags(map[string]string{
+			"source": "apiserver",
+		})...)
+
+	apiServerPodsCache := make(map[string]*v1.Pod, len(apiServerPods))
+	for _, p := range apiServerPods {
+		apiServerPodsCache[string(p.UID)] = p
+	}
+
+	// pods just bound to this node may not be admitted by kubelet yet, so only running ones are taken into account
+	apiServerNotFoundPodCount := 0
+	for id, p := range kubeletPodsCache {
+		if _, ok := apiServerPodsCache[id]; !ok && p.Status.Phase == v1.PodRunning {
+			klog.Warningf("running kubelet pod %s/%s with uid %s apiserver not found", p.Namespace, p.Name, p.UID)
+			apiServerNotFoundPodCount += 1
+		}
+	}
+	_ = w.emitter.StoreInt64(metricsNamePodCacheNotFound, int64(apiServerNotFoundPodCount), metrics.MetricTypeNameRaw,
+		metrics.ConvertMapToTags(map[string]string{
+			"source": "apiserver",
+		})...)
 }
@@ -18,9 +18,11 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager/checksum"
 
@@ -29,13 +31,14 @@ import (
 
 type ConfigManagerCheckpoint interface {
 	checkpointmanager.Checkpoint
-	GetData(kind string) (reflect.Value, metav1.Time)
-	SetData(kind string, v reflect.Value, t metav1.Time)
+	// GetData unmarshals config data of the kind into obj, and returns the time it's set
+	GetData(kind string, obj runtime.Object) (metav1.Time, error)
+	SetData(kind string, obj runtime.Object, t metav1.Time) error
 }
 
 type TargetConfigData struct {
 	// Value only store spec of dynamic config crd
-	Value     *dynamic.DynamicConfigCRD
+	Value     json.RawMessage
 	Timestamp metav1.Time
 }
 
@@ -63,35 +66,95 @@ func (d *Data) UnmarshalCheckpoint(blob []byte) error {
 }
 
 func (d *Data) VerifyChecksum() error {
-	return d.Checksum.Verify(d.Data)
+	err := d.Checksum.Verify(d.Data)
+	if err != nil && d.verifyLegacyChecksum() == nil {
+		return nil
+	}
+	return err
+}
+
+// verifyLegacyChecksum verifies checksum of checkpoints in legacy format, in which config data of
+// each kind is wrapped in dynamic.DynamicConfigCRD; since checksum is computed from both values and
+// type names, data is converted to a locally declared type with the same name as the legacy one.
+func (d *Data) verifyLegacyChecksum() error {
+	type TargetConfigData struct {
+		Value     *dynamic.DynamicConfigCRD
+		Timestamp metav1.Time
+	}
+
+	legacyData := make(map[string]TargetConfigData, len(d.Data))
+	for kind, data := range d.Data {
+		value := &dynamic.DynamicConfigCRD{}
+		if err := json.Unmarshal(data.Value, value); err != nil {
+			return err
+		}
+		legacyData[kind] = TargetConfigData{Value: value, Timestamp: data.Timestamp}
+	}
+	return d.Checksum.Verify(legacyData)
 }
 
-func (d *Data) GetData(kind string) (reflect.Value, metav1.Time) {
-	if data, ok := d.Data[kind]; ok {
-		configField := reflect.ValueOf(data.Value).Elem().FieldByName(kind)
-		return configField, data.Timestamp
+func (d *Data) GetData(kind string, obj runtime.Object) (metav1.Time, error) {
+	data, ok := d.Data[kind]
+	if !ok {
+		return metav1.Time{}, fmt.Errorf("config data of kind %s not found", kind)
+	}
+
+	value, err := unwrapLegacyConfigData(kind, data.Value)
+	if err != nil {
+		return metav1.Time{}, err
 	}
 
-	return reflect.Value{}, metav1.Time{}
+	if err := json.Unmarshal(value, obj); err != nil {
+		return metav1.Time{}, fmt.Errorf("unmarshal config data of kind %s failed: %v", kind, err)
+	}
+	return data.Timestamp, nil
 }
 
-func (d *Data) SetData(kind string, val reflect.Value, t metav1.Time) {
+func (d *Data) SetData(kind string, obj runtime.Object, t metav1.Time) error {
 	if d.Data == nil {
 		d.Data = make(map[string]TargetConfigData)
 	}
 
-	// get target dynamic configField by kind
-	dynamicConfiguration := &dynamic.DynamicConfigCRD{}
-	configField := reflect.ValueOf(dynamicConfiguration).Elem().FieldByName(kind)
-	configField.Set(reflect.New(configField.Type().Elem()))
-
-	// only set target dynamic configField's spec field
-	specValue := val.Elem().FieldByName("Spec")
-	specField := configField.Elem().FieldByName("Spec")
-	specField.Set(specValue)
+	value, err := json.Marshal(specOnly(obj))
+	if err != nil {
+		return fmt.Errorf("marshal config data of kind %s failed: %v", kind, err)
+	}
 
 	d.Data[kind] = TargetConfigData{
-		Value:     dynamicConfiguration,
+		Value:     value,
 		Timestamp: metav1.Unix(t.Unix(), 0),
 	}
+	return nil
+}
+
+// unwrapLegacyConfigData returns config data of the kind in legacy format as is in current format, e.g.
+// {"EvictionConfiguration":{"spec":{...}},"AdminQoSConfiguration":null} is unwrapped to {"spec":{...}};
+// config data in current format is returned directly, since it never has a field named as the kind.
+func unwrapLegacyConfigData(kind string, value json.RawMessage) (json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, fmt.Errorf("unmarshal config data of kind %s failed: %v", kind, err)
+	}
+
+	wrapped, ok := fields[kind]
+	if !ok {
+		return value, nil
+	} else if len(wrapped) == 0 || string(wrapped) == "null" {
+		return nil, fmt.Errorf("invalid config data of kind %s in legacy format: %s", kind, string(value))
+	}
+	return wrapped, nil
+}
+
+// specOnly returns a new object with only spec field of the given one,
+// and the whole object is returned if it doesn't have a spec field.
+func specOnly(obj runtime.Object) runtime.Object {
+	objValue := reflect.ValueOf(obj).Elem()
+	specValue := objValue.FieldByName("Spec")
+	if !specValue.IsValid() {
+		return obj
+	}
+
+	newObj := reflect.New(objValue.Type())
+	newObj.Elem().FieldByName("Spec").Set(specValue)
+	return newObj.Interface().(runtime.Object)
 }
@@ -17,12 +17,14 @@ limitations under the License.
 package config
 
 import (
-	"reflect"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager/checksum"
 
 	"github.com/kubewharf/katalyst-api/pkg/apis/config/v1alpha1"
 	"github.com/kubewharf/katalyst-core/pkg/config/dynamic"
@@ -31,15 +33,16 @@ import (
 func TestNewCheckpoint(t *testing.T) {
 	now := metav1.Now()
 	kind := "EvictionConfiguration"
-	crd := &dynamic.DynamicConfigCRD{
-		EvictionConfiguration: &v1alpha1.EvictionConfiguration{
-			Spec: v1alpha1.EvictionConfigurationSpec{
-				Config: v1alpha1.EvictionConfig{
-					EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
-						ReclaimedResourcesEvictionPluginConfig: v1alpha1.ReclaimedResourcesEvictionPluginConfig{
-							EvictionThreshold: map[corev1.ResourceName]float64{
-								corev1.ResourceCPU: 5.0,
-							},
+	ec := &v1alpha1.EvictionConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "default",
+		},
+		Spec: v1alpha1.EvictionConfigurationSpec{
+			Config: v1alpha1.EvictionConfig{
+				EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
+					ReclaimedResourcesEvictionPluginConfig: v1alpha1.ReclaimedResourcesEvictionPluginConfig{
+						EvictionThreshold: map[corev1.ResourceName]float64{
+							corev1.ResourceCPU: 5.0,
 						},
 					},
 				},
@@ -47,10 +50,9 @@ func TestNewCheckpoint(t *testing.T) {
 		},
 	}
 
-	configField := reflect.ValueOf(crd).Elem().FieldByName(kind)
-
 	cp := NewCheckpoint(make(map[string]TargetConfigData))
-	cp.SetData(kind, configField, now)
+	err := cp.SetData(kind, ec, now)
+	assert.NoError(t, err)
 
 	checkpoint, err := cp.MarshalCheckpoint()
 	assert.NoError(t, err)
@@ -61,10 +63,73 @@ func TestNewCheckpoint(t *testing.T) {
 	err = cp.VerifyChecksum()
 	assert.NoError(t, err)
 
-	dynamicConfigCRD := &dynamic.DynamicConfigCRD{}
-	configData, timestamp := cp.GetData(kind)
-	configField = reflect.ValueOf(dynamicConfigCRD).Elem().FieldByName(kind)
-	configField.Set(configData)
+	// only spec of the config is kept in checkpoint
+	got := &v1alpha1.EvictionConfiguration{}
+	timestamp, err := cp.GetData(kind, got)
+	assert.NoError(t, err)
 	assert.Equal(t, metav1.Unix(now.Unix(), 0), timestamp)
-	assert.Equal(t, crd, dynamicConfigCRD)
+	assert.Equal(t, &v1alpha1.EvictionConfiguration{Spec: ec.Spec}, got)
+
+	_, err = cp.GetData("AdminQoSConfiguration", &v1alpha1.AdminQoSConfiguration{})
+	assert.Error(t, err)
+}
+
+func TestLegacyCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	// checkpoint in legacy format, in which config data is wrapped in dynamic.DynamicConfigCRD; local
+	// time zone is loaded first as in running agents, since it's taken into account by checksum
+	_, _ = time.Now().Zone()
+	type TargetConfigData struct {
+		Value     *dynamic.DynamicConfigCRD
+		Timestamp metav1.Time
+	}
+	legacyData := map[string]TargetConfigData{
+		"EvictionConfiguration": {
+			Value: &dynamic.DynamicConfigCRD{
+				EvictionConfiguration: &v1alpha1.EvictionConfiguration{
+					Spec: v1alpha1.EvictionConfigurationSpec{
+						Config: v1alpha1.EvictionConfig{
+							EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
+								ReclaimedResourcesEvictionPluginConfig: v1alpha1.ReclaimedResourcesEvictionPluginConfig{
+									EvictionThreshold: map[corev1.ResourceName]float64{corev1.ResourceCPU: 5},
+								},
+							},
+						},
+					},
+				},
+			},
+			Timestamp: metav1.Unix(1640995200, 0),
+		},
+	}
+	legacyCheckpoint, err := json.Marshal(map[string]interface{}{
+		"Data":     legacyData,
+		"Checksum": checksum.New(legacyData),
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, string(legacyCheckpoint), `"AdminQoSConfiguration":null`)
+
+	cp := NewCheckpoint(nil)
+	assert.NoError(t, cp.UnmarshalCheckpoint(legacyCheckpoint))
+	assert.NoError(t, cp.VerifyChecksum())
+
+	got := &v1alpha1.EvictionConfiguration{}
+	timestamp, err := cp.GetData("EvictionConfiguration", got)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1640995200), timestamp.Unix())
+	assert.Equal(t, map[corev1.ResourceName]float64{corev1.ResourceCPU: 5},
+		got.Spec.Config.EvictionPluginsConfig.ReclaimedResourcesEvictionPluginConfig.EvictionThreshold)
+
+	// legacy data without config of the kind is invalid
+	_, err = cp.GetData("AdminQoSConfiguration", &v1alpha1.AdminQoSConfiguration{})
+	assert.Error(t, err)
+	assert.NoError(t, cp.SetData("AdminQoSConfiguration", &v1alpha1.AdminQoSConfiguration{}, metav1.Now()))
+	data := cp.(*Data).Data["AdminQoSConfiguration"]
+	data.Value = []byte(`{"AdminQoSConfiguration":null,"EvictionConfiguration":null}`)
+	cp.(*Data).Data["AdminQoSConfiguration"] = data
+	_, err = cp.GetData("AdminQoSConfiguration", &v1alpha1.AdminQoSConfiguration{})
+	assert.Error(t, err)
+
+	// corrupted checkpoint is rejected in both formats
+	assert.Error(t, cp.VerifyChecksum())
 }
@@ -19,28 +19,21 @@ package config
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"sync"
 	"time"
 
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/errors"
-	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
 
-	"github.com/kubewharf/katalyst-api/pkg/apis/config/v1alpha1"
 	"github.com/kubewharf/katalyst-core/pkg/client"
 	pkgconfig "github.com/kubewharf/katalyst-core/pkg/config"
-	"github.com/kubewharf/katalyst-core/pkg/config/dynamic"
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/cnc"
 	"github.com/kubewharf/katalyst-core/pkg/metrics"
-	"github.com/kubewharf/katalyst-core/pkg/util/native"
 	"github.com/kubewharf/katalyst-core/pkg/util/syntax"
 )
 
@@ -52,6 +45,7 @@ const (
 const (
 	metricsNameUpdateConfig   = "metaserver_update_config"
 	metricsNameLoadCheckpoint = "metaserver_load_checkpoint"
+	metricsNameInvalidConfig  = "metaserver_invalid_config"
 
 	metricsValueStatusCheckpointNotFoundOrCorrupted = "notFoundOrCorrupted"
 	metricsValueStatusCheckpointInvalidOrExpired    = "invalidOrExpired"
@@ -63,8 +57,6 @@ const (
 )
 
 var (
-	katalystConfigGVRToGVKMap = getGVRToGVKMap()
-
 	updateConfigBackoff = wait.Backoff{
 		Duration: 5 * time.Second,
 		Factor:   2,
@@ -106,15 +98,15 @@ type DynamicConfigManager struct {
 	defaultConfig *pkgconfig.DynamicConfiguration
 	currentConfig *pkgconfig.DynamicConfiguration
 
-	// lastDynamicConfigCRD is used to record the last dynamic config CRD
+	// lastConfigs is used to record the last configurations of each kind
 	// to avoid unnecessary update
-	lastDynamicConfigCRD *dynamic.DynamicConfigCRD
+	lastConfigs map[string]runtime.Object
 
 	configLoader ConfigurationLoader
 	emitter      metrics.MetricEmitter
 
-	// resourceGVRMap records those GVR that should be interested
-	// gvrToKind maps from GVR to GVK (only kind can be used to reflect objects)
+	// resourceGVRMap records those GVR that should be interested,
+	// and all of them must be registered by RegisterConfigKind
 	mux            sync.RWMutex
 	resourceGVRMap map[string]metav1.GroupVersionResource
 
@@ -150,6 +142,10 @@ func (c *DynamicConfigManager) AddConfigWatcher(gvrs ...metav1.GroupVersionResou
 	defer c.mux.Unlock()
 
 	for _, gvr := range gvrs {
+		if _, ok := getConfigKind(gvr); !ok {
+			return fmt.Errorf("config kind of gvr %s is not registered", gvr.String())
+		}
+
 		if oldGVR, ok := c.resourceGVRMap[gvr.Resource]; ok && gvr != oldGVR {
 			return fmt.Errorf("resource %s already reggistered by gvrs %s which is different with %s",
 				gvr.Resource, oldGVR.String(), gvr.String())
@@ -216,25 +212,25 @@ func (c *DynamicConfigManager) tryUpdateConfig(ctx context.Context, skipError bo
 
 // updateConfig is used to get dynamic agent config from remote
 func (c *DynamicConfigManager) updateConfig(ctx context.Context) error {
-	dynamicConfigCRD, success, err := c.updateDynamicConfig(c.resourceGVRMap, katalystConfigGVRToGVKMap,
+	configs, success, err := c.updateDynamicConfig(c.resourceGVRMap,
 		func(gvr metav1.GroupVersionResource, conf interface{}) error {
 			return c.configLoader.LoadConfig(ctx, gvr, conf)
 		},
 	)
 	if !success {
 		return err
-	} else if apiequality.Semantic.DeepEqual(c.lastDynamicConfigCRD, dynamicConfigCRD) {
+	} else if apiequality.Semantic.DeepEqual(c.lastConfigs, configs) {
 		klog.V(4).Infof("dynamic config is not changed")
 		return nil
 	}
 
-	klog.Infof("dynamic config crd is changed from %v to %v", c.lastDynamicConfigCRD, dynamicConfigCRD)
-	applyDynamicConfig(c.defaultConfig, c.currentConfig, dynamicConfigCRD)
-	c.lastDynamicConfigCRD = dynamicConfigCRD
+	klog.Infof("dynamic config crd is changed from %v to %v", c.lastConfigs, configs)
+	applyDynamicConfig(c.defaultConfig, c.currentConfig, configs)
+	c.lastConfigs = configs
 	return err
 }
 
-func (c *DynamicConfigManager) writeCheckpoint(kind string, configData reflect.Value) {
+func (c *DynamicConfigManager) writeCheckpoint(kind string, obj runtime.Object) {
 	// read checkpoint to get config data related to other gvr
 	data, err := c.readCheckpoint()
 	if err != nil {
@@ -251,7 +247,11 @@ func (c *DynamicConfigManager) writeCheckpoint(kind string, configData reflect.V
 	}
 
 	// set config value and timestamp for kind
-	data.SetData(kind, configData, metav1.Now())
+	if err = data.SetData(kind, obj, metav1.Now()); err != nil {
+		klog.Errorf("failed to set checkpoint data of kind %s: %v", kind, err)
+		return
+	}
+
 	err = c.checkpointManager.CreateCheckpoint(configManagerCheckpoint, data)
 	if err != nil {
 		klog.Errorf("failed to write checkpoint file %q: %v", configManagerCheckpoint, err)
@@ -269,85 +269,80 @@ func (c *DynamicConfigManager) readCheckpoint() (ConfigManagerCheckpoint, error)
 	return cp, nil
 }
 
+// loadCheckpoint gets config of the kind from checkpoint if it's still in grace time
+func (c *DynamicConfigManager) loadCheckpoint(configKind *ConfigKind) (runtime.Object, error) {
+	data, err := c.readCheckpoint()
+	if err != nil {
+		_ = c.emitter.StoreInt64(metricsNameLoadCheckpoint, 1, metrics.MetricTypeNameRaw, []metrics.MetricTag{
+			{Key: "status", Val: metricsValueStatusCheckpointNotFoundOrCorrupted},
+			{Key: "kind", Val: configKind.Kind},
+		}...)
+		return nil, fmt.Errorf("failed to get targetConfigMeta from checkpoint")
+	}
+
+	obj := configKind.NewObject()
+	timestamp, err := data.GetData(configKind.Kind, obj)
+	if err != nil || !time.Now().Before(timestamp.Add(c.checkpointGraceTime)) {
+		_ = c.emitter.StoreInt64(metricsNameLoadCheckpoint, 1, metrics.MetricTypeNameRaw, []metrics.MetricTag{
+			{Key: "status", Val: metricsValueStatusCheckpointInvalidOrExpired},
+			{Key: "kind", Val: configKind.Kind},
+		}...)
+		return nil, fmt.Errorf("checkpoint data for gvr %v is empty or out of date", configKind.GVR.String())
+	}
+
+	klog.Infof("failed to load targetConfigMeta from remote, use local checkpoint instead")
+	_ = c.emitter.StoreInt64(metricsNameLoadCheckpoint, 1, metrics.MetricTypeNameRaw, []metrics.MetricTag{
+		{Key: "status", Val: metricsValueStatusCheckpointSuccess},
+		{Key: "kind", Val: configKind.Kind},
+	}...)
+	return obj, nil
+}
+
+// updateDynamicConfig loads configurations of all watched kinds, and configurations which fail
+// to be loaded or validated are replaced by those in checkpoint; it returns configurations
+// keyed by kind, and whether any of them is loaded successfully.
 func (c *DynamicConfigManager) updateDynamicConfig(resourceGVRMap map[string]metav1.GroupVersionResource,
-	gvrToKind map[schema.GroupVersionResource]schema.GroupVersionKind,
-	loader func(gvr metav1.GroupVersionResource, conf interface{}) error) (*dynamic.DynamicConfigCRD, bool, error) {
-	dynamicConfiguration := &dynamic.DynamicConfigCRD{}
+	loader func(gvr metav1.GroupVersionResource, conf interface{}) error) (map[string]runtime.Object, bool, error) {
+	configs := make(map[string]runtime.Object)
 	success := false
 
 	var errList []error
 	for _, gvr := range resourceGVRMap {
-		schemaGVR := native.ToSchemaGVR(gvr.Group, gvr.Version, gvr.Resource)
-		kind, ok := gvrToKind[schemaGVR]
+		configKind, ok := getConfigKind(gvr)
 		if !ok {
-			errList = append(errList, fmt.Errorf("gvk of gvr %s is not found", gvr))
+			errList = append(errList, fmt.Errorf("config kind of gvr %s is not registered", gvr))
 			continue
 		}
 
-		// get target dynamic config configField by kind
-		configField := reflect.ValueOf(dynamicConfiguration).Elem().FieldByName(kind.Kind)
+		newConfigData := configKind.NewObject()
+		err := loader(gvr, newConfigData)
+		if err == nil {
+			if err = configKind.validate(newConfigData); err != nil {
+				_ = c.emitter.StoreInt64(metricsNameInvalidConfig, 1, metrics.MetricTypeNameCount, metrics.MetricTag{
+					Key: "kind", Val: configKind.Kind,
+				})
+				err = fmt.Errorf("invalid config of kind %s: %v", configKind.Kind, err)
+			}
+		}
 
-		// create a new instance of this configField type
-		newConfigData := reflect.New(configField.Type().Elem())
-		err := loader(gvr, newConfigData.Interface())
 		if err != nil {
 			klog.Warningf("failed to load targetConfigMeta from targetConfigMeta fetcher: %s", err)
-			// get target dynamic configField value from checkpoint
-			data, err := c.readCheckpoint()
+
+			// get target dynamic config from checkpoint, which only contains valid configs
+			newConfigData, err = c.loadCheckpoint(configKind)
 			if err != nil {
-				_ = c.emitter.StoreInt64(metricsNameLoadCheckpoint, 1, metrics.MetricTypeNameRaw, []metrics.MetricTag{
-					{Key: "status", Val: metricsValueStatusCheckpointNotFoundOrCorrupted},
-					{Key: "kind", Val: kind.Kind},
-				}...)
-				errList = append(errList, fmt.Errorf("failed to get targetConfigMeta from checkpoint"))
+				errList = append(errList, err)
 				continue
-			} else {
-				configData, timestamp := data.GetData(kind.Kind)
-				if configData.Kind() == reflect.Ptr && !configData.IsNil() &&
-					time.Now().Before(timestamp.Add(c.checkpointGraceTime)) {
-					newConfigData = configData
-					klog.Infof("failed to load targetConfigMeta from remote, use local checkpoint instead")
-					_ = c.emitter.StoreInt64(metricsNameLoadCheckpoint, 1, metrics.MetricTypeNameRaw, []metrics.MetricTag{
-						{Key: "status", Val: metricsValueStatusCheckpointSuccess},
-						{Key: "kind", Val: kind.Kind},
-					}...)
-				} else {
-					_ = c.emitter.StoreInt64(metricsNameLoadCheckpoint, 1, metrics.MetricTypeNameRaw, []metrics.MetricTag{
-						{Key: "status", Val: metricsValueStatusCheckpointInvalidOrExpired},
-						{Key: "kind", Val: kind.Kind},
-					}...)
-					errList = append(errList, fmt.Errorf("checkpoint data for gvr %v is empty or out of date", gvr.String()))
-					continue
-				}
 			}
+		} else {
+			c.writeCheckpoint(configKind.Kind, newConfigData)
 		}
 
-		// set target dynamic configField by new config field
-		configField.Set(newConfigData)
+		configs[configKind.Kind] = newConfigData
 		success = true
-		c.writeCheckpoint(kind.Kind, newConfigData)
 	}
 
-	return dynamicConfiguration, success, errors.NewAggregate(errList)
-}
-
-func getGVRToGVKMap() map[schema.GroupVersionResource]schema.GroupVersionKind {
-	scheme := runtime.NewScheme()
-	utilruntime.Must(v1alpha1.AddToScheme(scheme))
-
-	knownTypes := scheme.AllKnownTypes()
-	gvrToKind := make(map[schema.GroupVersionResource]schema.GroupVersionKind)
-	for kind := range knownTypes {
-		plural, singular := meta.UnsafeGuessKindToResource(kind)
-		gvrToKind[plural] = kind
-		gvrToKind[singular] = kind
-	}
-	return gvrToKind
-}
-
-func applyDynamicConfig(defaultConfig, currentConfig *pkgconfig.DynamicConfiguration,
-	dynamicConfigCRD *dynamic.DynamicConfigCRD) {
-	currentConfig.ApplyConfiguration(defaultConfig, dynamicConfigCRD)
+	return configs, success, errors.NewAggregate(errList)
 }
 
 func deepCopy(src *pkgconfig.DynamicConfiguration) *pkgconfig.DynamicConfiguration {
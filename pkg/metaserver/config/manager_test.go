@@ -18,6 +18,7 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"reflect"
 	"testing"
@@ -27,7 +28,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
 
@@ -70,8 +71,52 @@ var (
 	}
 
 	testConfigCheckpointDir = "/tmp/metaserver1/checkpoint1"
+
+	testConfigurationGVR = metav1.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "testconfigurations"}
 )
 
+func init() {
+	if err := RegisterConfigKind(ConfigKind{
+		Kind:      "TestConfiguration",
+		GVR:       testConfigurationGVR,
+		NewObject: func() runtime.Object { return &testConfiguration{} },
+		Validate: func(obj runtime.Object) error {
+			if obj.(*testConfiguration).Spec.EvictionThreshold < 0 {
+				return fmt.Errorf("eviction threshold is negative")
+			}
+			return nil
+		},
+		Apply: func(conf *pkgconfig.DynamicConfiguration, obj runtime.Object) {
+			conf.ReclaimedResourcesEvictionPluginConfiguration.DynamicConf.SetEvictionThreshold(map[v1.ResourceName]float64{
+				v1.ResourceCPU: obj.(*testConfiguration).Spec.EvictionThreshold,
+			})
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+type testConfigurationSpec struct {
+	EvictionThreshold float64 `json:"evictionThreshold"`
+}
+
+type testConfiguration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec testConfigurationSpec `json:"spec"`
+}
+
+func (c *testConfiguration) DeepCopyObject() runtime.Object {
+	out := *c
+	c.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+type testOtherConfiguration struct {
+	testConfiguration
+}
+
 func generateTestConfiguration(t *testing.T, nodeName string) *pkgconfig.Configuration {
 	testConfiguration, err := options.NewOptions().Config()
 	require.NoError(t, err)
@@ -243,7 +288,7 @@ func Test_applyDynamicConfig(t *testing.T) {
 	type args struct {
 		defaultConfig *pkgconfig.DynamicConfiguration
 		currentConfig *pkgconfig.DynamicConfiguration
-		dynamicConf   *dynamic.DynamicConfigCRD
+		configs       map[string]runtime.Object
 	}
 	tests := []struct {
 		name string
@@ -287,8 +332,8 @@ func Test_applyDynamicConfig(t *testing.T) {
 					d.MemoryPressureEvictionPluginConfiguration.DynamicConf.SetGracePeriod(evictionconfig.DefaultGracePeriod)
 					return d
 				}(),
-				dynamicConf: &dynamic.DynamicConfigCRD{
-					EvictionConfiguration: &v1alpha1.EvictionConfiguration{
+				configs: map[string]runtime.Object{
+					"EvictionConfiguration": &v1alpha1.EvictionConfiguration{
 						Spec: v1alpha1.EvictionConfigurationSpec{
 							Config: v1alpha1.EvictionConfig{
 								EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
@@ -353,8 +398,8 @@ func Test_applyDynamicConfig(t *testing.T) {
 					d.MemoryPressureEvictionPluginConfiguration.DynamicConf.SetGracePeriod(nonDefaultGracePeriod)
 					return d
 				}(),
-				dynamicConf: &dynamic.DynamicConfigCRD{
-					EvictionConfiguration: &v1alpha1.EvictionConfiguration{
+				configs: map[string]runtime.Object{
+					"EvictionConfiguration": &v1alpha1.EvictionConfiguration{
 						Spec: v1alpha1.EvictionConfigurationSpec{
 							Config: v1alpha1.EvictionConfig{
 								EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
@@ -385,82 +430,98 @@ func Test_applyDynamicConfig(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			applyDynamicConfig(tt.args.defaultConfig, tt.args.currentConfig, tt.args.dynamicConf)
+			applyDynamicConfig(tt.args.defaultConfig, tt.args.currentConfig, tt.args.configs)
 			got := tt.args.currentConfig
 			require.True(t, tt.want(got))
 		})
 	}
 }
 
-func Test_getGVRToKindMap(t *testing.T) {
+func TestRegisterConfigKind(t *testing.T) {
+	t.Parallel()
+
+	for _, gvr := range []metav1.GroupVersionResource{
+		dynamic.EvictionConfigurationGVR,
+		dynamic.AdminQoSConfigurationGVR,
+		testConfigurationGVR,
+	} {
+		_, ok := getConfigKind(gvr)
+		require.True(t, ok, gvr.String())
+	}
+
 	tests := []struct {
-		name    string
-		wantGVR schema.GroupVersionResource
-		wantGVK schema.GroupVersionKind
+		name       string
+		configKind ConfigKind
 	}{
 		{
-			name: "ec",
-			wantGVR: schema.GroupVersionResource{
-				Group:    v1alpha1.SchemeGroupVersion.Group,
-				Version:  v1alpha1.SchemeGroupVersion.Version,
-				Resource: v1alpha1.ResourceNameEvictionConfigurations,
+			name: "empty kind",
+			configKind: ConfigKind{
+				GVR:       metav1.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "others"},
+				NewObject: func() runtime.Object { return &testOtherConfiguration{} },
+				Apply:     func(_ *pkgconfig.DynamicConfiguration, _ runtime.Object) {},
+			},
+		},
+		{
+			name: "duplicated gvr",
+			configKind: ConfigKind{
+				Kind:      "TestOtherConfiguration",
+				GVR:       dynamic.EvictionConfigurationGVR,
+				NewObject: func() runtime.Object { return &testOtherConfiguration{} },
+				Apply:     func(_ *pkgconfig.DynamicConfiguration, _ runtime.Object) {},
 			},
-			wantGVK: schema.GroupVersionKind{
-				Group:   v1alpha1.SchemeGroupVersion.Group,
-				Version: v1alpha1.SchemeGroupVersion.Version,
-				Kind:    "EvictionConfiguration",
+		},
+		{
+			name: "duplicated kind",
+			configKind: ConfigKind{
+				Kind:      dynamic.EvictionConfigurationKind,
+				GVR:       metav1.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "others"},
+				NewObject: func() runtime.Object { return &v1alpha1.EvictionConfiguration{} },
 			},
 		},
 		{
-			name: "ac",
-			wantGVR: schema.GroupVersionResource{
-				Group:    v1alpha1.SchemeGroupVersion.Group,
-				Version:  v1alpha1.SchemeGroupVersion.Version,
-				Resource: v1alpha1.ResourceNameAdminQoSConfigurations,
+			name: "nil object constructor",
+			configKind: ConfigKind{
+				Kind:  "TestOtherConfiguration",
+				GVR:   metav1.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "others"},
+				Apply: func(_ *pkgconfig.DynamicConfiguration, _ runtime.Object) {},
 			},
-			wantGVK: schema.GroupVersionKind{
-				Group:   v1alpha1.SchemeGroupVersion.Group,
-				Version: v1alpha1.SchemeGroupVersion.Version,
-				Kind:    "AdminQoSConfiguration",
+		},
+		{
+			name: "nil apply function for kind out of DynamicConfigCRD",
+			configKind: ConfigKind{
+				Kind:      "TestOtherConfiguration",
+				GVR:       metav1.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "others"},
+				NewObject: func() runtime.Object { return &testOtherConfiguration{} },
 			},
 		},
 	}
-
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getGVRToGVKMap(); !checkGVRToGVKMap(tt.wantGVR, tt.wantGVK, got) {
-				t.Errorf("getGVRToGVKMap() = %v, wantGVR %v wantGVK %v", got, tt.wantGVR, tt.wantGVK)
-			}
+			t.Parallel()
+			require.Error(t, RegisterConfigKind(tt.configKind))
 		})
 	}
-}
 
-func checkGVRToGVKMap(gvr schema.GroupVersionResource, wantGVK schema.GroupVersionKind,
-	m map[schema.GroupVersionResource]schema.GroupVersionKind) bool {
-	gvk, ok := m[gvr]
-	if ok && gvk == wantGVK {
-		return true
-	}
-	return false
+	_, ok := getConfigKind(metav1.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "others"})
+	require.False(t, ok)
 }
 
 func Test_updateDynamicConf(t *testing.T) {
 	type args struct {
 		resourceGVRMap map[string]metav1.GroupVersionResource
-		gvrToKind      map[schema.GroupVersionResource]schema.GroupVersionKind
 		loader         func(gvr metav1.GroupVersionResource, conf interface{}) error
 	}
 	tests := []struct {
 		name  string
 		args  args
-		want  *dynamic.DynamicConfigCRD
+		want  map[string]runtime.Object
 		want1 bool
 	}{
 		{
 			name: "test-1",
 			args: args{
 				resourceGVRMap: generateTestResourceGVRMap(),
-				gvrToKind:      getGVRToGVKMap(),
 				loader: generateTestLoader(toTestUnstructured(&v1alpha1.EvictionConfiguration{
 					ObjectMeta: metav1.ObjectMeta{
 						Name: "config-1",
@@ -482,8 +543,8 @@ func Test_updateDynamicConf(t *testing.T) {
 					},
 				})),
 			},
-			want: &dynamic.DynamicConfigCRD{
-				EvictionConfiguration: &v1alpha1.EvictionConfiguration{
+			want: map[string]runtime.Object{
+				"EvictionConfiguration": &v1alpha1.EvictionConfiguration{
 					ObjectMeta: metav1.ObjectMeta{
 						Name: "config-1",
 					},
@@ -514,7 +575,7 @@ func Test_updateDynamicConf(t *testing.T) {
 				v1.ResourceMemory: 1.3,
 			})
 			manager := constructTestDynamicConfigManager(t, "node-name", ec)
-			got, got1, _ := manager.updateDynamicConfig(tt.args.resourceGVRMap, tt.args.gvrToKind, tt.args.loader)
+			got, got1, _ := manager.updateDynamicConfig(tt.args.resourceGVRMap, tt.args.loader)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("updateDynamicConfig() got = %v, want %v", got, tt.want)
 			}
@@ -525,6 +586,45 @@ func Test_updateDynamicConf(t *testing.T) {
 	}
 }
 
+func Test_updateDynamicConf_registeredKind(t *testing.T) {
+	manager := constructTestDynamicConfigManager(t, "node-name", generateTestEvictionConfiguration(nil))
+	require.NoError(t, manager.AddConfigWatcher(testConfigurationGVR))
+	require.Error(t, manager.AddConfigWatcher(metav1.GroupVersionResource{Group: "test.io", Version: "v1", Resource: "others"}))
+
+	resourceGVRMap := map[string]metav1.GroupVersionResource{
+		testConfigurationGVR.Resource: testConfigurationGVR,
+	}
+	loader := func(threshold float64) func(gvr metav1.GroupVersionResource, conf interface{}) error {
+		return generateTestLoader(toTestUnstructured(&testConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "config-1"},
+			Spec:       testConfigurationSpec{EvictionThreshold: threshold},
+		}))
+	}
+
+	// invalid config is rejected without checkpoint to fall back on
+	_ = os.RemoveAll(testConfigCheckpointDir)
+	require.NoError(t, os.MkdirAll(testConfigCheckpointDir, os.FileMode(0755)))
+	_, success, err := manager.updateDynamicConfig(resourceGVRMap, loader(-1))
+	require.False(t, success)
+	require.Error(t, err)
+
+	// valid config is loaded and applied by the registered apply function
+	configs, success, err := manager.updateDynamicConfig(resourceGVRMap, loader(1.1))
+	require.True(t, success)
+	require.NoError(t, err)
+	require.Equal(t, 1.1, configs["TestConfiguration"].(*testConfiguration).Spec.EvictionThreshold)
+
+	applyDynamicConfig(manager.defaultConfig, manager.currentConfig, configs)
+	require.Equal(t, 1.1, manager.currentConfig.ReclaimedResourcesEvictionPluginConfiguration.
+		DynamicConf.EvictionThreshold()[v1.ResourceCPU])
+
+	// invalid config is replaced by the valid one in checkpoint
+	configs, success, err = manager.updateDynamicConfig(resourceGVRMap, loader(-1))
+	require.True(t, success)
+	require.NoError(t, err)
+	require.Equal(t, 1.1, configs["TestConfiguration"].(*testConfiguration).Spec.EvictionThreshold)
+}
+
 func generateTestResourceGVRMap() map[string]metav1.GroupVersionResource {
 	return map[string]metav1.GroupVersionResource{
 		v1alpha1.ResourceNameEvictionConfigurations: {
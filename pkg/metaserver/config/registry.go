@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubewharf/katalyst-api/pkg/apis/config/v1alpha1"
+	pkgconfig "github.com/kubewharf/katalyst-core/pkg/config"
+	"github.com/kubewharf/katalyst-core/pkg/config/dynamic"
+)
+
+// ConfigKind declares a kind of dynamic configuration CRD managed by KCC, and
+// DynamicConfigManager fetches, checkpoints and applies registered kinds generically.
+type ConfigKind struct {
+	// Kind is the kind of the CRD, and it's also the key of configurations in checkpoint.
+	Kind string
+	// GVR is the group version resource of the CRD.
+	GVR metav1.GroupVersionResource
+	// NewObject returns an empty object of the typed CRD struct to unmarshal configurations into.
+	NewObject func() runtime.Object
+	// Validate rejects bad configurations before they are applied, and it is optional.
+	Validate func(obj runtime.Object) error
+	// Apply applies the configuration onto dynamic configuration after it is reset to
+	// default, and it can be omitted only if dynamic.DynamicConfigCRD has a field of the
+	// same name as the kind, which is applied by ApplyConfiguration of each configuration.
+	Apply func(conf *pkgconfig.DynamicConfiguration, obj runtime.Object)
+}
+
+var configKindRegistry = struct {
+	sync.RWMutex
+	kinds     map[string]*ConfigKind
+	gvrToKind map[metav1.GroupVersionResource]string
+}{
+	kinds:     make(map[string]*ConfigKind),
+	gvrToKind: make(map[metav1.GroupVersionResource]string),
+}
+
+func init() {
+	for _, configKind := range []ConfigKind{
+		{
+			Kind:      dynamic.AdminQoSConfigurationKind,
+			GVR:       dynamic.AdminQoSConfigurationGVR,
+			NewObject: func() runtime.Object { return &v1alpha1.AdminQoSConfiguration{} },
+			Validate:  validateAdminQoSConfiguration,
+		},
+		{
+			Kind:      dynamic.EvictionConfigurationKind,
+			GVR:       dynamic.EvictionConfigurationGVR,
+			NewObject: func() runtime.Object { return &v1alpha1.EvictionConfiguration{} },
+			Validate:  validateEvictionConfiguration,
+		},
+	} {
+		if err := RegisterConfigKind(configKind); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterConfigKind registers a kind of dynamic configuration CRD, and components
+// should register their kinds before adding config watchers for them.
+func RegisterConfigKind(configKind ConfigKind) error {
+	kind := configKind.Kind
+	if kind == "" {
+		return fmt.Errorf("kind of gvr %s is empty", configKind.GVR)
+	} else if configKind.NewObject == nil {
+		return fmt.Errorf("object constructor of kind %s is nil", kind)
+	}
+
+	objType := reflect.TypeOf(configKind.NewObject())
+	if objType == nil || objType.Kind() != reflect.Ptr || objType.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("object constructor of kind %s must return a pointer to struct", kind)
+	}
+
+	if configKind.Apply == nil {
+		field, ok := reflect.TypeOf(dynamic.DynamicConfigCRD{}).FieldByName(kind)
+		if !ok || field.Type != objType {
+			return fmt.Errorf("apply function of kind %s is nil and it's not a field of DynamicConfigCRD", kind)
+		}
+	}
+
+	configKindRegistry.Lock()
+	defer configKindRegistry.Unlock()
+
+	if oldKind, ok := configKindRegistry.gvrToKind[configKind.GVR]; ok {
+		return fmt.Errorf("gvr %s already registered by kind %s", configKind.GVR, oldKind)
+	} else if _, ok := configKindRegistry.kinds[kind]; ok {
+		return fmt.Errorf("kind %s already registered", kind)
+	}
+
+	configKindRegistry.kinds[kind] = &configKind
+	configKindRegistry.gvrToKind[configKind.GVR] = kind
+	return nil
+}
+
+func getConfigKind(gvr metav1.GroupVersionResource) (*ConfigKind, bool) {
+	configKindRegistry.RLock()
+	defer configKindRegistry.RUnlock()
+
+	kind, ok := configKindRegistry.gvrToKind[gvr]
+	if !ok {
+		return nil, false
+	}
+	return configKindRegistry.kinds[kind], true
+}
+
+func (r *ConfigKind) validate(obj runtime.Object) error {
+	if r.Validate == nil {
+		return nil
+	}
+	return r.Validate(obj)
+}
+
+// applyDynamicConfig resets current config to default, and then applies configurations of each kind;
+// kinds in dynamic.DynamicConfigCRD are applied at once, and other kinds are applied in order of kind.
+func applyDynamicConfig(defaultConfig, currentConfig *pkgconfig.DynamicConfiguration,
+	configs map[string]runtime.Object) {
+	dynamicConfigCRD := &dynamic.DynamicConfigCRD{}
+	kinds := make([]string, 0, len(configs))
+	for kind, obj := range configs {
+		kinds = append(kinds, kind)
+
+		configField := reflect.ValueOf(dynamicConfigCRD).Elem().FieldByName(kind)
+		if configField.IsValid() && configField.Type() == reflect.TypeOf(obj) {
+			configField.Set(reflect.ValueOf(obj))
+		}
+	}
+	currentConfig.ApplyConfiguration(defaultConfig, dynamicConfigCRD)
+
+	sort.Strings(kinds)
+	configKindRegistry.RLock()
+	defer configKindRegistry.RUnlock()
+	for _, kind := range kinds {
+		if configKind, ok := configKindRegistry.kinds[kind]; ok && configKind.Apply != nil {
+			configKind.Apply(currentConfig, configs[kind])
+		}
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/kubewharf/katalyst-api/pkg/apis/config/v1alpha1"
+)
+
+// validateEvictionConfiguration rejects eviction configurations which may evict pods unexpectedly,
+// e.g. non-positive thresholds are always met.
+func validateEvictionConfiguration(obj runtime.Object) error {
+	ec, ok := obj.(*v1alpha1.EvictionConfiguration)
+	if !ok {
+		return fmt.Errorf("unexpected object type %T", obj)
+	}
+
+	var errList []error
+	pluginsConfig := ec.Spec.Config.EvictionPluginsConfig
+	for resourceName, threshold := range pluginsConfig.ReclaimedResourcesEvictionPluginConfig.EvictionThreshold {
+		if math.IsNaN(threshold) || threshold <= 0 {
+			errList = append(errList, fmt.Errorf("eviction threshold of %v must be positive, got %v", resourceName, threshold))
+		}
+	}
+
+	memoryConfig := pluginsConfig.MemoryEvictionPluginConfig
+	for name, threshold := range map[string]*int{
+		"numaFreeBelowWatermarkTimesThreshold": memoryConfig.NumaFreeBelowWatermarkTimesThreshold,
+		"systemKswapdRateThreshold":            memoryConfig.SystemKswapdRateThreshold,
+		"systemKswapdRateExceedTimesThreshold": memoryConfig.SystemKswapdRateExceedTimesThreshold,
+	} {
+		if threshold != nil && *threshold <= 0 {
+			errList = append(errList, fmt.Errorf("%s must be positive, got %v", name, *threshold))
+		}
+	}
+	if memoryConfig.GracePeriod != nil && *memoryConfig.GracePeriod < 0 {
+		errList = append(errList, fmt.Errorf("gracePeriod must be non-negative, got %v", *memoryConfig.GracePeriod))
+	}
+	for _, metrics := range [][]string{memoryConfig.NumaEvictionRankingMetrics, memoryConfig.SystemEvictionRankingMetrics} {
+		for _, metric := range metrics {
+			if metric == "" {
+				errList = append(errList, fmt.Errorf("eviction ranking metric is empty"))
+			}
+		}
+	}
+	return errors.NewAggregate(errList)
+}
+
+// validateAdminQoSConfiguration rejects admin qos configurations with negative resource quantities.
+func validateAdminQoSConfiguration(obj runtime.Object) error {
+	aqc, ok := obj.(*v1alpha1.AdminQoSConfiguration)
+	if !ok {
+		return fmt.Errorf("unexpected object type %T", obj)
+	}
+
+	var errList []error
+	reclaimedResourceConfig := aqc.Spec.Config.ReclaimedResourceConfig
+	for name, resourceList := range map[string]*v1.ResourceList{
+		"reservedResourceForReport":     reclaimedResourceConfig.ReservedResourceForReport,
+		"minReclaimedResourceForReport": reclaimedResourceConfig.MinReclaimedResourceForReport,
+		"reservedResourceForAllocate":   reclaimedResourceConfig.ReservedResourceForAllocate,
+	} {
+		if resourceList == nil {
+			continue
+		}
+
+		for resourceName, quantity := range *resourceList {
+			if quantity.Sign() < 0 {
+				errList = append(errList, fmt.Errorf("%s of %v must be non-negative, got %v", name, resourceName, quantity.String()))
+			}
+		}
+	}
+	return errors.NewAggregate(errList)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubewharf/katalyst-api/pkg/apis/config/v1alpha1"
+)
+
+func TestValidateConfigurations(t *testing.T) {
+	t.Parallel()
+
+	negativeInt := -1
+	negativeInt64 := int64(-1)
+	tests := []struct {
+		name     string
+		validate func(obj runtime.Object) error
+		obj      runtime.Object
+		wantErr  bool
+	}{
+		{
+			name:     "valid eviction configuration",
+			validate: validateEvictionConfiguration,
+			obj: generateTestEvictionConfiguration(map[corev1.ResourceName]float64{
+				corev1.ResourceCPU: 1.2,
+			}),
+		},
+		{
+			name:     "non-positive eviction threshold",
+			validate: validateEvictionConfiguration,
+			obj: generateTestEvictionConfiguration(map[corev1.ResourceName]float64{
+				corev1.ResourceCPU: 0,
+			}),
+			wantErr: true,
+		},
+		{
+			name:     "non-positive memory eviction threshold",
+			validate: validateEvictionConfiguration,
+			obj: &v1alpha1.EvictionConfiguration{Spec: v1alpha1.EvictionConfigurationSpec{Config: v1alpha1.EvictionConfig{
+				EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
+					MemoryEvictionPluginConfig: v1alpha1.MemoryEvictionPluginConfig{
+						NumaFreeBelowWatermarkTimesThreshold: &negativeInt,
+					},
+				},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:     "negative memory eviction grace period",
+			validate: validateEvictionConfiguration,
+			obj: &v1alpha1.EvictionConfiguration{Spec: v1alpha1.EvictionConfigurationSpec{Config: v1alpha1.EvictionConfig{
+				EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
+					MemoryEvictionPluginConfig: v1alpha1.MemoryEvictionPluginConfig{
+						GracePeriod: &negativeInt64,
+					},
+				},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:     "empty eviction ranking metric",
+			validate: validateEvictionConfiguration,
+			obj: &v1alpha1.EvictionConfiguration{Spec: v1alpha1.EvictionConfigurationSpec{Config: v1alpha1.EvictionConfig{
+				EvictionPluginsConfig: v1alpha1.EvictionPluginsConfig{
+					MemoryEvictionPluginConfig: v1alpha1.MemoryEvictionPluginConfig{
+						SystemEvictionRankingMetrics: []string{""},
+					},
+				},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:     "valid admin qos configuration",
+			validate: validateAdminQoSConfiguration,
+			obj: &v1alpha1.AdminQoSConfiguration{Spec: v1alpha1.AdminQoSConfigurationSpec{Config: v1alpha1.AdminQoSConfig{
+				ReclaimedResourceConfig: v1alpha1.ReclaimedResourceConfig{
+					ReservedResourceForAllocate: &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+				},
+			}}},
+		},
+		{
+			name:     "negative reserved resource",
+			validate: validateAdminQoSConfiguration,
+			obj: &v1alpha1.AdminQoSConfiguration{Spec: v1alpha1.AdminQoSConfigurationSpec{Config: v1alpha1.AdminQoSConfig{
+				ReclaimedResourceConfig: v1alpha1.ReclaimedResourceConfig{
+					ReservedResourceForReport: &corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("-1Gi")},
+				},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:     "unexpected object type",
+			validate: validateAdminQoSConfiguration,
+			obj:      &v1alpha1.EvictionConfiguration{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.wantErr, tt.validate(tt.obj) != nil)
+		})
+	}
+}
@@ -43,14 +43,15 @@ type externalManagerImpl struct {
 	rdt.RDTManager
 }
 
-// InitExternalManager initializes an externalManagerImpl
-func InitExternalManager(podFetcher pod.PodFetcher) ExternalManager {
+// InitExternalManager initializes an externalManagerImpl,
+// and resctrlRootPath is the mount point of resctrl filesystem
+func InitExternalManager(podFetcher pod.PodFetcher, resctrlRootPath string) ExternalManager {
 	initManagerOnce.Do(func() {
 		manager = &externalManagerImpl{
 			start:           false,
 			CgroupIDManager: cgroupid.NewCgroupIDManager(podFetcher),
 			NetworkManager:  network.NewNetworkManager(),
-			RDTManager:      rdt.NewDefaultManager(),
+			RDTManager:      rdt.NewResctrlManager(resctrlRootPath),
 		}
 	})
 
@@ -79,6 +80,14 @@ func (m *externalManagerImpl) SetNetworkManager(n network.NetworkManager) {
 	})
 }
 
+// SetRDTManager replaces defaultRDTManager with a custom implementation,
+// e.g. a resctrl manager with non-default root path
+func (m *externalManagerImpl) SetRDTManager(r rdt.RDTManager) {
+	m.setComponentImplementation(func() {
+		m.RDTManager = r
+	})
+}
+
 func (m *externalManagerImpl) setComponentImplementation(setter func()) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/kubewharf/katalyst-core/pkg/metaserver/agent/pod"
+	"github.com/kubewharf/katalyst-core/pkg/util/external/rdt"
 )
 
 var (
@@ -32,14 +33,14 @@ var (
 )
 
 func TestInitExternalManager(t *testing.T) {
-	externalManager := InitExternalManager(podFetcher)
+	externalManager := InitExternalManager(podFetcher, rdt.DefaultResctrlRootPath)
 	assert.NotNil(t, externalManager)
 
 	return
 }
 
 func TestSetNetworkManager(t *testing.T) {
-	externalManager := InitExternalManager(podFetcher).(*externalManagerImpl)
+	externalManager := InitExternalManager(podFetcher, rdt.DefaultResctrlRootPath).(*externalManagerImpl)
 	assert.NotNil(t, externalManager)
 
 	externalManager.start = false
@@ -48,8 +49,19 @@ func TestSetNetworkManager(t *testing.T) {
 	assert.Nil(t, externalManager.NetworkManager)
 }
 
+func TestSetRDTManager(t *testing.T) {
+	externalManager := InitExternalManager(podFetcher, rdt.DefaultResctrlRootPath).(*externalManagerImpl)
+	assert.NotNil(t, externalManager)
+
+	externalManager.start = false
+
+	rdtManager := rdt.NewResctrlManager("/fake/resctrl")
+	externalManager.SetRDTManager(rdtManager)
+	assert.Equal(t, rdtManager, externalManager.RDTManager)
+}
+
 func TestRun(t *testing.T) {
-	externalManager := InitExternalManager(podFetcher)
+	externalManager := InitExternalManager(podFetcher, rdt.DefaultResctrlRootPath)
 	assert.NotNil(t, externalManager)
 
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
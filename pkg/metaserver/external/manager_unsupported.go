@@ -45,14 +45,15 @@ func (m *unsupportedExternalManagerImpl) Run(_ context.Context) {
 
 }
 
-// InitExternalManager initializes an externalManagerImpl
-func InitExternalManager(podFetcher pod.PodFetcher) ExternalManager {
+// InitExternalManager initializes an externalManagerImpl,
+// and resctrlRootPath is the mount point of resctrl filesystem
+func InitExternalManager(podFetcher pod.PodFetcher, resctrlRootPath string) ExternalManager {
 	initUnsupportedManagerOnce.Do(func() {
 		unsupportedManager = &unsupportedExternalManagerImpl{
 			start:           false,
 			CgroupIDManager: cgroupid.NewCgroupIDManager(podFetcher),
 			NetworkManager:  network.NewNetworkManager(),
-			RDTManager:      rdt.NewDefaultManager(),
+			RDTManager:      rdt.NewResctrlManager(resctrlRootPath),
 		}
 	})
 
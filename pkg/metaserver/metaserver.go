@@ -70,7 +70,7 @@ func NewMetaServer(clientSet *client.GenericClientSet, emitter metrics.MetricEmi
 		MetaAgent:             metaAgent,
 		ConfigurationManager:  configurationManager,
 		ServiceProfileManager: serviceProfileManager,
-		ExternalManager:       external.InitExternalManager(metaAgent.PodFetcher),
+		ExternalManager:       external.InitExternalManager(metaAgent.PodFetcher, conf.ResctrlRoot),
 	}, nil
 }
 
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const hugetlbFilePrefix = "hugetlb."
+
+// GetHugetlbPageSizes returns page sizes (e.g. 2MB, 1GB) of hugetlb interface files
+// with the given suffix in the cgroup, such as limit_in_bytes for cgroupv1 and max for cgroupv2;
+// interface files of reservations (e.g. hugetlb.2MB.rsvd.max) are skipped.
+func GetHugetlbPageSizes(absCgroupPath, suffix string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(absCgroupPath, hugetlbFilePrefix+"*."+suffix))
+	if err != nil {
+		return nil, err
+	}
+
+	var pageSizes []string
+	for _, file := range files {
+		pageSize := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), hugetlbFilePrefix), "."+suffix)
+		if pageSize == "" || strings.Contains(pageSize, ".") {
+			continue
+		}
+		pageSizes = append(pageSizes, pageSize)
+	}
+	sort.Strings(pageSizes)
+	return pageSizes, nil
+}
+
+// GetHugetlbFileName returns the name of hugetlb interface file of the given page size
+func GetHugetlbFileName(pageSize, suffix string) string {
+	return hugetlbFilePrefix + pageSize + "." + suffix
+}
+
+// ParseHugetlbPageSize parses page sizes in names of hugetlb interface files (e.g. 64KB, 2MB, 1GB) to bytes
+func ParseHugetlbPageSize(pageSize string) (int64, error) {
+	for _, unit := range []struct {
+		suffix string
+		shift  uint
+	}{
+		{"KB", 10},
+		{"MB", 20},
+		{"GB", 30},
+	} {
+		if !strings.HasSuffix(pageSize, unit.suffix) {
+			continue
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSuffix(pageSize, unit.suffix), 10, 64)
+		if err != nil || size <= 0 || size > math.MaxInt64>>unit.shift {
+			return 0, fmt.Errorf("invalid hugetlb page size %q", pageSize)
+		}
+		return size << unit.shift, nil
+	}
+	return 0, fmt.Errorf("invalid hugetlb page size %q", pageSize)
+}
+
+// GetHugetlbUnlimitedValue returns the value of hugetlb.<pagesize>.limit_in_bytes without limit
+// in cgroupv1, which is math.MaxInt64 rounded down to the page size by the kernel
+func GetHugetlbUnlimitedValue(pageSize string) (int64, error) {
+	size, err := ParseHugetlbPageSize(pageSize)
+	if err != nil {
+		return 0, err
+	}
+	return math.MaxInt64 / size * size, nil
+}
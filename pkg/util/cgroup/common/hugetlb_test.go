@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHugetlbUnlimitedValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pageSize  string
+		expected  int64
+		expectErr bool
+	}{
+		{pageSize: "64KB", expected: math.MaxInt64 / (64 << 10) * (64 << 10)},
+		{pageSize: "2MB", expected: 9223372036852678656},
+		{pageSize: "1GB", expected: 9223372035781033984},
+		{pageSize: "2XB", expectErr: true},
+		{pageSize: "MB", expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.pageSize, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := GetHugetlbUnlimitedValue(tc.pageSize)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
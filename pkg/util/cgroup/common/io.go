@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// IOWeightMin, IOWeightMax and IOWeightDefault are the range and default value of io.weight
+	IOWeightMin     uint64 = 1
+	IOWeightMax     uint64 = 10000
+	IOWeightDefault uint64 = 100
+
+	// bfq weight (both io.bfq.weight and blkio.bfq.weight) is in range [1, 1000],
+	// and blkio.weight of cfq is in range [10, 1000], while they share the same default value 100
+	ioBFQWeightMin   uint64 = 1
+	ioBFQWeightMax   uint64 = 1000
+	ioBlkIOWeightMin uint64 = 10
+	ioBlkIOWeightMax uint64 = 1000
+)
+
+// ConvertIOWeightToBFQWeight converts io.weight to bfq weight by clamping, so that the default value is kept
+func ConvertIOWeightToBFQWeight(weight uint64) uint64 {
+	return clampUint64(weight, ioBFQWeightMin, ioBFQWeightMax)
+}
+
+// ConvertIOWeightToBlkIOWeight converts io.weight to blkio.weight by clamping, so that the default value is kept
+func ConvertIOWeightToBlkIOWeight(weight uint64) uint64 {
+	return clampUint64(weight, ioBlkIOWeightMin, ioBlkIOWeightMax)
+}
+
+// ParseIODefaultWeight parses the default weight from io weight files, which is either
+// formatted as "default N" followed by per-device weights, or a single number in older kernels
+func ParseIODefaultWeight(content string) (uint64, error) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 1:
+			return strconv.ParseUint(fields[0], 10, 64)
+		case len(fields) == 2 && fields[0] == "default":
+			return strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("default weight is not found in %q", content)
+}
+
+// ParseIODeviceLines parses files in which each line starts with a device number formatted as "major:minor",
+// and returns the remaining fields of each line keyed by device number
+func ParseIODeviceLines(content string) map[string][]string {
+	res := make(map[string][]string)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.Contains(fields[0], ":") {
+			continue
+		}
+		res[fields[0]] = append(res[fields[0]], fields[1:]...)
+	}
+	return res
+}
+
+func clampUint64(value, min, max uint64) uint64 {
+	if value < min {
+		return min
+	} else if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseNumaStatFields parses per-NUMA fields formatted as "N<id>=<value>" in memory.numa_stat,
+// and sets the parsed values into numaStat with the given setter.
+func ParseNumaStatFields(fields []string, numaStat map[int]*MemoryNumaMetrics,
+	setter func(metrics *MemoryNumaMetrics, value uint64)) error {
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], "N") {
+			continue
+		}
+
+		numaID, err := strconv.Atoi(strings.TrimPrefix(kv[0], "N"))
+		if err != nil {
+			return fmt.Errorf("invalid numa id in %s: %v", field, err)
+		}
+
+		value, err := strconv.ParseUint(kv[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value in %s: %v", field, err)
+		}
+
+		if numaStat[numaID] == nil {
+			numaStat[numaID] = &MemoryNumaMetrics{}
+		}
+		setter(numaStat[numaID], value)
+	}
+	return nil
+}
@@ -55,6 +55,12 @@ func InitKubernetesCGroupPath(cgroupType CgroupType, additionalK8SCGroupPath []s
 	})
 }
 
+// GetKubernetesCgroupPathList returns all cgroup paths (relative to the root of
+// each sub-system) to run containers for kubernetes
+func GetKubernetesCgroupPathList() []string {
+	return k8sCgroupPathList.List()
+}
+
 // GetCgroupRootPath get cgroupfs root path compatible with v1 and v2
 func GetCgroupRootPath(subsys string) string {
 	if IsCgroup2UnifiedMode() {
@@ -114,6 +120,21 @@ func GetContainerAbsCgroupPath(subsys, podUID, containerId string) (string, erro
 	return GetKubernetesAnyExistAbsCgroupPath(subsys, path.Join(fmt.Sprintf("%s%s", PodCgroupPathPrefix, podUID), containerId))
 }
 
+// GetContainerRelativeCgroupPath returns relative cgroup path for container level,
+// and it's the same for all sub-systems (and for cgroupv2)
+func GetContainerRelativeCgroupPath(podUID, containerId string) (string, error) {
+	containerAbsCGPath, err := GetContainerAbsCgroupPath(DefaultSelectedSubsys, podUID, containerId)
+	if err != nil {
+		return "", err
+	}
+
+	relCgroupPath, err := filepath.Rel(GetCgroupRootPath(DefaultSelectedSubsys), containerAbsCGPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join("/", relCgroupPath), nil
+}
+
 func IsContainerCgroupExist(podUID, containerID string) (bool, error) {
 	containerAbsCGPath, err := GetContainerAbsCgroupPath("", podUID, containerID)
 	if err != nil {
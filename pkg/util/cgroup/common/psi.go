@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// GetNodePressure returns node-level PSI of the given resource, i.e. memory, cpu or io
+func GetNodePressure(resource string) (*PressureStats, error) {
+	return ParsePressureFile(filepath.Join(ProcPressureRootPath, resource))
+}
+
+// GetCgroupPressure returns PSI of the given resource (i.e. memory, cpu or io) in the cgroup
+func GetCgroupPressure(absCgroupPath, resource string) (*PressureStats, error) {
+	return ParsePressureFile(filepath.Join(absCgroupPath, resource+".pressure"))
+}
+
+// GetCgroupPressureMetrics returns PSI of all resources in the cgroup,
+// and it returns nil if none of them is supported
+func GetCgroupPressureMetrics(absCgroupPath string) *PressureMetrics {
+	metrics := &PressureMetrics{}
+	metrics.CPU, _ = GetCgroupPressure(absCgroupPath, PressureResourceCPU)
+	metrics.Memory, _ = GetCgroupPressure(absCgroupPath, PressureResourceMemory)
+	metrics.IO, _ = GetCgroupPressure(absCgroupPath, PressureResourceIO)
+
+	if metrics.CPU == nil && metrics.Memory == nil && metrics.IO == nil {
+		return nil
+	}
+	return metrics
+}
+
+// ParsePressureFile parses PSI interface files with the format like
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func ParsePressureFile(file string) (*PressureStats, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &PressureStats{}
+	for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		data, err := parsePressureData(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+
+		switch fields[0] {
+		case "some":
+			stats.Some = data
+		case "full":
+			stats.Full = data
+		default:
+			return nil, fmt.Errorf("failed to parse %s: unknown pressure type %s", file, fields[0])
+		}
+	}
+
+	if stats.Some == nil {
+		return nil, fmt.Errorf("failed to parse %s: some line not found", file)
+	}
+	return stats, nil
+}
+
+func parsePressureData(fields []string) (*PressureData, error) {
+	data := &PressureData{}
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+
+		var err error
+		switch kv[0] {
+		case "avg10":
+			data.Avg10, err = strconv.ParseFloat(kv[1], 64)
+		case "avg60":
+			data.Avg60, err = strconv.ParseFloat(kv[1], 64)
+		case "avg300":
+			data.Avg300, err = strconv.ParseFloat(kv[1], 64)
+		case "total":
+			data.Total, err = strconv.ParseUint(kv[1], 10, 64)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %v", field, err)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePressureFile(t *testing.T) {
+	as := require.New(t)
+
+	tmpDir, err := ioutil.TempDir("", "psi")
+	as.Nil(err)
+	defer os.RemoveAll(tmpDir)
+
+	memoryPressure := filepath.Join(tmpDir, "memory.pressure")
+	as.Nil(ioutil.WriteFile(memoryPressure, []byte("some avg10=1.50 avg60=0.80 avg300=0.10 total=12345\n"+
+		"full avg10=0.50 avg60=0.20 avg300=0.00 total=678\n"), 0644))
+
+	stats, err := ParsePressureFile(memoryPressure)
+	as.Nil(err)
+	as.Equal(&PressureData{Avg10: 1.5, Avg60: 0.8, Avg300: 0.1, Total: 12345}, stats.Some)
+	as.Equal(&PressureData{Avg10: 0.5, Avg60: 0.2, Avg300: 0, Total: 678}, stats.Full)
+
+	cpuPressure := filepath.Join(tmpDir, "cpu.pressure")
+	as.Nil(ioutil.WriteFile(cpuPressure, []byte("some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"), 0644))
+
+	stats, err = ParsePressureFile(cpuPressure)
+	as.Nil(err)
+	as.NotNil(stats.Some)
+	as.Nil(stats.Full)
+
+	invalidPressure := filepath.Join(tmpDir, "io.pressure")
+	as.Nil(ioutil.WriteFile(invalidPressure, []byte("some avg10=abc\n"), 0644))
+
+	_, err = ParsePressureFile(invalidPressure)
+	as.NotNil(err)
+
+	_, err = ParsePressureFile(filepath.Join(tmpDir, "not-exist"))
+	as.NotNil(err)
+}
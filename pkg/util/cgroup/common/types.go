@@ -31,12 +31,28 @@ const (
 	CgroupSubsysCPU    = "cpu"
 	// CgroupSubsysNetCls is the net_cls sub-system
 	CgroupSubsysNetCls = "net_cls"
+	// CgroupSubsysBlkIO is the blkio sub-system for cgroupv1, and it's named io in cgroupv2
+	CgroupSubsysBlkIO = "blkio"
+	// CgroupSubsysPids is the pids sub-system
+	CgroupSubsysPids = "pids"
+	// CgroupSubsysHugetlb is the hugetlb sub-system
+	CgroupSubsysHugetlb = "hugetlb"
+	// CgroupSubsysCPUAcct is the cpuacct sub-system, and PSI interface files
+	// are placed in it for cgroupv1 if the kernel supports PSI for cgroupv1
+	CgroupSubsysCPUAcct = "cpuacct"
 
 	PodCgroupPathPrefix        = "pod"
 	CgroupFsRootPath           = "/kubepods"
 	CgroupFsRootPathBestEffort = "/kubepods/besteffort"
 	CgroupFsRootPathBurstable  = "/kubepods/burstable"
 
+	// ProcPressureRootPath is the directory of node-level PSI interface files
+	ProcPressureRootPath = "/proc/pressure"
+
+	PressureResourceCPU    = "cpu"
+	PressureResourceMemory = "memory"
+	PressureResourceIO     = "io"
+
 	SystemdRootPath           = "/kubepods.slice"
 	SystemdRootPathBestEffort = "/kubepods.slice/kubepods-besteffort.slice"
 	SystemdRootPathBurstable  = "/kubepods.slice/kubepods-burstable.slice"
@@ -53,8 +69,20 @@ const (
 
 // MemoryData set cgroup memory data
 type MemoryData struct {
+	// LimitInBytes is the hard limit of memory usage, zero means not to set, and -1 means unlimited
 	LimitInBytes int64
 	WmarkRatio   int32
+	// MinInBytesPtr, LowInBytesPtr and HighInBytes are only supported by cgroupv2,
+	// and for cgroupv1, LowInBytesPtr is mapped to memory.soft_limit_in_bytes;
+	// nil pointer or zero HighInBytes means not to set, and -1 means max.
+	MinInBytesPtr *int64
+	LowInBytesPtr *int64
+	HighInBytes   int64
+	// SwapMaxInBytesPtr limits the swap usage and -1 means unlimited; for cgroupv1,
+	// it's mapped to memory.memsw.limit_in_bytes based on memory.limit_in_bytes.
+	SwapMaxInBytesPtr *int64
+	// SwappinessPtr is only supported by cgroupv1
+	SwappinessPtr *uint64
 }
 
 // CPUData set cgroup cpu data
@@ -82,6 +110,45 @@ type NetClsData struct {
 	Attributes map[string]string
 }
 
+// PidsData set cgroup pids data
+type PidsData struct {
+	// Max is the max number of tasks, and -1 means max; zero value means not to set.
+	Max int64
+}
+
+// HugetlbData set cgroup hugetlb data
+type HugetlbData struct {
+	// Limits are keyed by page sizes named as in interface files (e.g. 2MB, 1GB),
+	// and values are in bytes where -1 means max.
+	Limits map[string]int64
+}
+
+// IODeviceLimit limits the io throughput of a device, and zero value means unlimited
+type IODeviceLimit struct {
+	ReadBps   uint64
+	WriteBps  uint64
+	ReadIOPS  uint64
+	WriteIOPS uint64
+}
+
+// IOData set cgroup io data
+type IOData struct {
+	// Weight is the proportional io weight in range [1, 10000] as io.weight of cgroupv2,
+	// and it's converted to the range of io.bfq.weight or blkio.weight if necessary;
+	// zero value means not to set.
+	Weight uint64
+	// DeviceLimits are keyed by device numbers formatted as "major:minor"
+	DeviceLimits map[string]*IODeviceLimit
+}
+
+// IOStat is the accumulated io statistics of a device
+type IOStat struct {
+	ReadBytes  uint64
+	WriteBytes uint64
+	ReadIOs    uint64
+	WriteIOs   uint64
+}
+
 // MemoryStats get cgroup memory data
 type MemoryStats struct {
 	Limit uint64
@@ -100,6 +167,25 @@ type CPUSetStats struct {
 	Mems string
 }
 
+// PidsStats get cgroup pids data
+type PidsStats struct {
+	Current uint64
+	// Max is math.MaxInt64 if it's unlimited
+	Max int64
+}
+
+// HugetlbStats get cgroup hugetlb data of a page size
+type HugetlbStats struct {
+	Usage uint64
+	// MaxUsage is only supported by cgroupv1
+	MaxUsage uint64
+	// Limit is math.MaxInt64 if it's unlimited, for both cgroupv1 and cgroupv2
+	Limit int64
+	// Failcnt is the number of allocation failures due to the limit, and
+	// it's counted as max events in cgroupv2
+	Failcnt uint64
+}
+
 // MemoryMetrics get memory cgroup metrics
 type MemoryMetrics struct {
 	RSS         uint64
@@ -111,6 +197,18 @@ type MemoryMetrics struct {
 	// memory.memsw.usage_in_bytes Reports the total size in
 	// bytes of the memory and swap space used by tasks in the cgroup.
 	MemSWUsage uint64
+	// SwapUsage is the size of swap space used by tasks in the cgroup
+	SwapUsage uint64
+	// InactiveFile and ActiveFile are file-backed pages in the inactive
+	// and active LRU lists, and inactive ones are the cheapest to reclaim.
+	InactiveFile uint64
+	ActiveFile   uint64
+}
+
+// MemoryNumaMetrics get per-NUMA memory cgroup metrics in bytes
+type MemoryNumaMetrics struct {
+	Anon uint64
+	File uint64
 }
 
 // PidMetrics get pid cgroup metrics
@@ -131,4 +229,32 @@ type CgroupMetrics struct {
 	CPU    *CPUMetrics
 	Memory *MemoryMetrics
 	Pid    *PidMetrics
+	// IO is keyed by device numbers formatted as "major:minor"
+	IO map[string]*IOStat
+	// Hugetlb is keyed by page sizes, e.g. 2MB and 1GB
+	Hugetlb map[string]*HugetlbStats
+	// Pressure is nil if PSI isn't supported
+	Pressure *PressureMetrics
+}
+
+// PressureData is one line (some or full) of PSI interface files
+type PressureData struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	// Total is the accumulated stall time in microseconds
+	Total uint64
+}
+
+// PressureStats get PSI data, Full is nil if the file has no full line (e.g. cpu in kernels before 5.13)
+type PressureStats struct {
+	Some *PressureData
+	Full *PressureData
+}
+
+// PressureMetrics get PSI metrics of all resources, and each of them is nil if not supported
+type PressureMetrics struct {
+	CPU    *PressureStats
+	Memory *PressureStats
+	IO     *PressureStats
 }
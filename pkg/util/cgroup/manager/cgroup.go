@@ -36,6 +36,27 @@ func ApplyMemoryWithRelativePath(relCgroupPath string, data *common.MemoryData)
 	return GetManager().ApplyMemory(absCgroupPath, data)
 }
 
+func ApplyMemoryWithAbsolutePath(absCgroupPath string, data *common.MemoryData) error {
+	if data == nil {
+		return fmt.Errorf("ApplyMemoryWithAbsolutePath with nil cgroup data")
+	}
+
+	return GetManager().ApplyMemory(absCgroupPath, data)
+}
+
+func ApplyMemoryForContainer(podUID, containerId string, data *common.MemoryData) error {
+	if data == nil {
+		return fmt.Errorf("ApplyMemoryForContainer with nil cgroup data")
+	}
+
+	memoryAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysMemory, podUID, containerId)
+	if err != nil {
+		return fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return ApplyMemoryWithAbsolutePath(memoryAbsCGPath, data)
+}
+
 func ApplyCPUWithRelativePath(relCgroupPath string, data *common.CPUData) error {
 	if data == nil {
 		return fmt.Errorf("ApplyCPUWithRelativePath with nil cgroup data")
@@ -98,6 +119,43 @@ func ApplyNetClsForContainer(podUID, containerId string, data *common.NetClsData
 	return ApplyNetClsWithRelativePath(netClsAbsCGPath, data)
 }
 
+func ApplyIOWithAbsolutePath(absCgroupPath string, data *common.IOData) error {
+	if data == nil {
+		return fmt.Errorf("ApplyIOWithAbsolutePath with nil cgroup data")
+	}
+
+	return GetManager().ApplyIO(absCgroupPath, data)
+}
+
+// ApplyIOForContainer applies the io config for a container.
+func ApplyIOForContainer(podUID, containerId string, data *common.IOData) error {
+	if data == nil {
+		return fmt.Errorf("ApplyIOForContainer with nil cgroup data")
+	}
+
+	ioAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysBlkIO, podUID, containerId)
+	if err != nil {
+		return fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return ApplyIOWithAbsolutePath(ioAbsCGPath, data)
+}
+
+func ReclaimMemoryWithAbsolutePath(absCgroupPath string, nbytes int64) (int64, error) {
+	return GetManager().ReclaimMemory(absCgroupPath, nbytes)
+}
+
+// ReclaimMemoryForContainer reclaims the given bytes of memory for a container,
+// and returns the bytes actually reclaimed.
+func ReclaimMemoryForContainer(podUID, containerId string, nbytes int64) (int64, error) {
+	memoryAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysMemory, podUID, containerId)
+	if err != nil {
+		return 0, fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return ReclaimMemoryWithAbsolutePath(memoryAbsCGPath, nbytes)
+}
+
 func GetMemoryWithRelativePath(relCgroupPath string) (*common.MemoryStats, error) {
 	absCgroupPath := common.GetAbsCgroupPath("memory", relCgroupPath)
 	return GetManager().GetMemory(absCgroupPath)
@@ -125,6 +183,41 @@ func GetMetricsWithRelativePath(relCgroupPath string, subsystems map[string]stru
 	return GetManager().GetMetrics(relCgroupPath, subsystems)
 }
 
+func GetMetricsForContainer(podUID, containerId string, subsystems map[string]struct{}) (*common.CgroupMetrics, error) {
+	relCgroupPath, err := common.GetContainerRelativeCgroupPath(podUID, containerId)
+	if err != nil {
+		return nil, fmt.Errorf("GetContainerRelativeCgroupPath failed with error: %v", err)
+	}
+
+	return GetMetricsWithRelativePath(relCgroupPath, subsystems)
+}
+
+func GetNumaStatWithAbsolutePath(absCgroupPath string) (map[int]*common.MemoryNumaMetrics, error) {
+	return GetManager().GetNumaStat(absCgroupPath)
+}
+
+func GetNumaStatForContainer(podUID, containerId string) (map[int]*common.MemoryNumaMetrics, error) {
+	memoryAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysMemory, podUID, containerId)
+	if err != nil {
+		return nil, fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return GetNumaStatWithAbsolutePath(memoryAbsCGPath)
+}
+
+func GetIOStatWithAbsolutePath(absCgroupPath string) (map[string]*common.IOStat, error) {
+	return GetManager().GetIOStat(absCgroupPath)
+}
+
+func GetIOStatForContainer(podUID, containerId string) (map[string]*common.IOStat, error) {
+	ioAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysBlkIO, podUID, containerId)
+	if err != nil {
+		return nil, fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return GetIOStatWithAbsolutePath(ioAbsCGPath)
+}
+
 func GetPidsWithRelativePath(relCgroupPath string) ([]string, error) {
 	absCgroupPath := common.GetAbsCgroupPath(common.DefaultSelectedSubsys, relCgroupPath)
 	return GetManager().GetPids(absCgroupPath)
@@ -179,3 +272,100 @@ func DropCacheWithTimeoutWithRelativePath(timeoutSecs int, absCgroupPath string)
 
 	return nil
 }
+
+func ApplyPidsWithAbsolutePath(absCgroupPath string, data *common.PidsData) error {
+	if data == nil {
+		return fmt.Errorf("ApplyPidsWithAbsolutePath with nil cgroup data")
+	}
+
+	return GetManager().ApplyPids(absCgroupPath, data)
+}
+
+// ApplyPidsForPod applies the pids config for a pod, since pids of
+// all containers in the pod are usually limited as a whole.
+func ApplyPidsForPod(podUID string, data *common.PidsData) error {
+	pidsAbsCGPath, err := common.GetPodAbsCgroupPath(common.CgroupSubsysPids, podUID)
+	if err != nil {
+		return fmt.Errorf("GetPodAbsCgroupPath failed with error: %v", err)
+	}
+
+	return ApplyPidsWithAbsolutePath(pidsAbsCGPath, data)
+}
+
+// ApplyPidsForContainer applies the pids config for a container.
+func ApplyPidsForContainer(podUID, containerId string, data *common.PidsData) error {
+	pidsAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysPids, podUID, containerId)
+	if err != nil {
+		return fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return ApplyPidsWithAbsolutePath(pidsAbsCGPath, data)
+}
+
+func ApplyHugetlbWithAbsolutePath(absCgroupPath string, data *common.HugetlbData) error {
+	if data == nil {
+		return fmt.Errorf("ApplyHugetlbWithAbsolutePath with nil cgroup data")
+	}
+
+	return GetManager().ApplyHugetlb(absCgroupPath, data)
+}
+
+// ApplyHugetlbForContainer applies the hugetlb config for a container.
+func ApplyHugetlbForContainer(podUID, containerId string, data *common.HugetlbData) error {
+	hugetlbAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysHugetlb, podUID, containerId)
+	if err != nil {
+		return fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return ApplyHugetlbWithAbsolutePath(hugetlbAbsCGPath, data)
+}
+
+func GetPidsStatsWithAbsolutePath(absCgroupPath string) (*common.PidsStats, error) {
+	return GetManager().GetPidsStats(absCgroupPath)
+}
+
+func GetPidsStatsForPod(podUID string) (*common.PidsStats, error) {
+	pidsAbsCGPath, err := common.GetPodAbsCgroupPath(common.CgroupSubsysPids, podUID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPodAbsCgroupPath failed with error: %v", err)
+	}
+
+	return GetPidsStatsWithAbsolutePath(pidsAbsCGPath)
+}
+
+func GetPidsStatsForContainer(podUID, containerId string) (*common.PidsStats, error) {
+	pidsAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysPids, podUID, containerId)
+	if err != nil {
+		return nil, fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return GetPidsStatsWithAbsolutePath(pidsAbsCGPath)
+}
+
+func GetHugetlbWithAbsolutePath(absCgroupPath string) (map[string]*common.HugetlbStats, error) {
+	return GetManager().GetHugetlb(absCgroupPath)
+}
+
+func GetHugetlbForContainer(podUID, containerId string) (map[string]*common.HugetlbStats, error) {
+	hugetlbAbsCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysHugetlb, podUID, containerId)
+	if err != nil {
+		return nil, fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return GetHugetlbWithAbsolutePath(hugetlbAbsCGPath)
+}
+
+func GetPressureWithAbsolutePath(absCgroupPath, resource string) (*common.PressureStats, error) {
+	return GetManager().GetPressure(absCgroupPath, resource)
+}
+
+// GetPressureForContainer returns PSI of the given resource for a container,
+// and PSI interface files are looked up in cpuacct sub-system for cgroupv1.
+func GetPressureForContainer(podUID, containerId, resource string) (*common.PressureStats, error) {
+	absCGPath, err := common.GetContainerAbsCgroupPath(common.CgroupSubsysCPUAcct, podUID, containerId)
+	if err != nil {
+		return nil, fmt.Errorf("GetContainerAbsCgroupPath failed with error: %v", err)
+	}
+
+	return GetPressureWithAbsolutePath(absCGPath, resource)
+}
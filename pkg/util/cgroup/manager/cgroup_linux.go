@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// DropFileCacheOnCPUsWithAbsolutePath drops file cache of the cgroup in a thread bound to the given cpus;
+// since cgroup reclaim walks memory nodes starting from the one local to the running cpu, passing
+// cpus of a numa node makes the reclaim target page caches on that numa node first.
+func DropFileCacheOnCPUsWithAbsolutePath(absCgroupPath string, nbytes int64, cpus []int) (int64, error) {
+	type result struct {
+		reclaimed int64
+		err       error
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		// the thread is never unlocked, so it exits along with the goroutine
+		// instead of being reused by others with the changed affinity
+		runtime.LockOSThread()
+
+		if len(cpus) > 0 {
+			var mask unix.CPUSet
+			for _, cpu := range cpus {
+				mask.Set(cpu)
+			}
+
+			if err := unix.SchedSetaffinity(0, &mask); err != nil {
+				resultCh <- result{err: fmt.Errorf("failed to bind thread to cpus %v: %v", cpus, err)}
+				return
+			}
+		}
+
+		reclaimed, err := GetManager().DropFileCache(absCgroupPath, nbytes)
+		resultCh <- result{reclaimed: reclaimed, err: err}
+	}()
+
+	r := <-resultCh
+	return r.reclaimed, r.err
+}
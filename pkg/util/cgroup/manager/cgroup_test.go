@@ -20,9 +20,16 @@ limitations under the License.
 package manager
 
 import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
 
+	libcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
 
 	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
 	v1 "github.com/kubewharf/katalyst-core/pkg/util/cgroup/manager/v1"
@@ -46,6 +53,101 @@ func TestV2Manager(t *testing.T) {
 	testManager(t, "v2")
 }
 
+// prepareFakeCgroupFiles creates a fake cgroup directory with the given files,
+// and cgroup files can be read or written in it when libcgroups.TestMode is on
+func prepareFakeCgroupFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "fake-cgroup")
+	require.NoError(t, err)
+
+	for file, content := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0o644)
+		require.NoError(t, err)
+	}
+	return dir
+}
+
+func readFakeCgroupFile(t *testing.T, dir, file string) string {
+	content, err := ioutil.ReadFile(filepath.Join(dir, file))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestApplyMemoryProtection(t *testing.T) {
+	minBytes, lowBytes, noProtection := int64(1<<30), int64(2<<30), int64(0)
+
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.limit_in_bytes":      "9223372036854771712",
+			"memory.soft_limit_in_bytes": "9223372036854771712",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		err := ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{
+			MinInBytesPtr: &minBytes,
+			LowInBytesPtr: &lowBytes,
+			HighInBytes:   4 << 30,
+		})
+		assert.NoError(t, err)
+
+		// memory.low is mapped to soft limit, and min/high are no-ops
+		assert.Equal(t, "2147483648", readFakeCgroupFile(t, dir, "memory.soft_limit_in_bytes"))
+		assert.Equal(t, "9223372036854771712", readFakeCgroupFile(t, dir, "memory.limit_in_bytes"))
+		_, err = os.Stat(filepath.Join(dir, "memory.min"))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(dir, "memory.high"))
+		assert.True(t, os.IsNotExist(err))
+
+		// clearing memory.low removes the soft limit
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{LowInBytesPtr: &noProtection})
+		assert.NoError(t, err)
+		assert.Equal(t, "-1", readFakeCgroupFile(t, dir, "memory.soft_limit_in_bytes"))
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.max":  "max",
+			"memory.min":  "0",
+			"memory.low":  "0",
+			"memory.high": "max",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		err := ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{
+			MinInBytesPtr: &minBytes,
+			LowInBytesPtr: &lowBytes,
+			HighInBytes:   -1,
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "1073741824", readFakeCgroupFile(t, dir, "memory.min"))
+		assert.Equal(t, "2147483648", readFakeCgroupFile(t, dir, "memory.low"))
+		assert.Equal(t, "max", readFakeCgroupFile(t, dir, "memory.high"))
+		assert.Equal(t, "max", readFakeCgroupFile(t, dir, "memory.max"))
+
+		// zero values are left untouched
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{HighInBytes: 8 << 30})
+		assert.NoError(t, err)
+		assert.Equal(t, "1073741824", readFakeCgroupFile(t, dir, "memory.min"))
+		assert.Equal(t, "8589934592", readFakeCgroupFile(t, dir, "memory.high"))
+
+		// zero protection clears memory.min and memory.low
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{
+			MinInBytesPtr: &noProtection,
+			LowInBytesPtr: &noProtection,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "0", readFakeCgroupFile(t, dir, "memory.min"))
+		assert.Equal(t, "0", readFakeCgroupFile(t, dir, "memory.low"))
+	})
+}
+
 func testManager(t *testing.T, version string) {
 	t.Logf("test with version %v", version)
 
@@ -53,6 +155,10 @@ func testManager(t *testing.T, version string) {
 
 	err = ApplyMemoryWithRelativePath("/test", &common.MemoryData{})
 	assert.NoError(t, err)
+	err = ApplyMemoryWithAbsolutePath("/test", &common.MemoryData{})
+	assert.NoError(t, err)
+	err = ApplyMemoryForContainer("fake-pod", "fake-container", &common.MemoryData{})
+	assert.NotNil(t, err)
 	err = ApplyCPUWithRelativePath("/test", &common.CPUData{})
 	assert.NoError(t, err)
 	err = ApplyCPUSetWithRelativePath("/test", &common.CPUSetData{})
@@ -66,11 +172,17 @@ func testManager(t *testing.T, version string) {
 	_, _ = GetMemoryWithAbsolutePath("/")
 	_, _ = GetCPUWithRelativePath("/")
 	_, _ = GetMetricsWithRelativePath("/", map[string]struct{}{"cpu": {}})
+	_, _ = GetMetricsForContainer("fake-pod", "fake-container", map[string]struct{}{"memory": {}})
 	_, _ = GetPidsWithRelativePath("/")
 	_, _ = GetPidsWithAbsolutePath("/")
 	_, _ = GetTasksWithRelativePath("/", "cpu")
 	_, _ = GetTasksWithAbsolutePath("/")
 
+	_, err = ReclaimMemoryWithAbsolutePath("/test", 0)
+	assert.NoError(t, err)
+	_, err = ReclaimMemoryForContainer("fake-pod", "fake-container", 1024)
+	assert.NotNil(t, err)
+
 	_ = DropCacheWithTimeoutForContainer("fake-pod", "fake-container", 1)
 	_ = DropCacheWithTimeoutWithRelativePath(1, "/test")
 }
@@ -85,3 +197,424 @@ func testNetCls(t *testing.T, version string) {
 	err = ApplyNetClsForContainer("fake-pod", "fake-container", &common.NetClsData{})
 	assert.Error(t, err)
 }
+
+func TestApplyMemorySwap(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	swapMax, noSwap, unlimited := int64(1<<30), int64(0), int64(-1)
+	swappiness := uint64(60)
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.limit_in_bytes":       "4294967296",
+			"memory.memsw.limit_in_bytes": "9223372036854771712",
+			"memory.swappiness":           "0",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		err := ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{
+			SwapMaxInBytesPtr: &swapMax,
+			SwappinessPtr:     &swappiness,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "5368709120", readFakeCgroupFile(t, dir, "memory.memsw.limit_in_bytes"))
+		assert.Equal(t, "60", readFakeCgroupFile(t, dir, "memory.swappiness"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{SwapMaxInBytesPtr: &noSwap})
+		assert.NoError(t, err)
+		assert.Equal(t, "4294967296", readFakeCgroupFile(t, dir, "memory.memsw.limit_in_bytes"))
+
+		// memsw limit is raised before memory limit to keep the swap budget
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{LimitInBytes: 8 << 30})
+		assert.NoError(t, err)
+		assert.Equal(t, "8589934592", readFakeCgroupFile(t, dir, "memory.limit_in_bytes"))
+		assert.Equal(t, "8589934592", readFakeCgroupFile(t, dir, "memory.memsw.limit_in_bytes"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{LimitInBytes: 2 << 30, SwapMaxInBytesPtr: &swapMax})
+		assert.NoError(t, err)
+		assert.Equal(t, "2147483648", readFakeCgroupFile(t, dir, "memory.limit_in_bytes"))
+		assert.Equal(t, "3221225472", readFakeCgroupFile(t, dir, "memory.memsw.limit_in_bytes"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{SwapMaxInBytesPtr: &unlimited})
+		assert.NoError(t, err)
+		assert.Equal(t, "-1", readFakeCgroupFile(t, dir, "memory.memsw.limit_in_bytes"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{LimitInBytes: 4 << 30})
+		assert.NoError(t, err)
+		assert.Equal(t, "4294967296", readFakeCgroupFile(t, dir, "memory.limit_in_bytes"))
+		assert.Equal(t, "-1", readFakeCgroupFile(t, dir, "memory.memsw.limit_in_bytes"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{LimitInBytes: -1})
+		assert.NoError(t, err)
+		assert.Equal(t, "-1", readFakeCgroupFile(t, dir, "memory.limit_in_bytes"))
+		assert.Equal(t, "-1", readFakeCgroupFile(t, dir, "memory.memsw.limit_in_bytes"))
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.max":      "max",
+			"memory.swap.max": "max",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		err := ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{
+			SwapMaxInBytesPtr: &noSwap,
+			SwappinessPtr:     &swappiness,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "0", readFakeCgroupFile(t, dir, "memory.swap.max"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{SwapMaxInBytesPtr: &unlimited})
+		assert.NoError(t, err)
+		assert.Equal(t, "max", readFakeCgroupFile(t, dir, "memory.swap.max"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{LimitInBytes: 4 << 30})
+		assert.NoError(t, err)
+		assert.Equal(t, "4294967296", readFakeCgroupFile(t, dir, "memory.max"))
+
+		err = ApplyMemoryWithAbsolutePath(dir, &common.MemoryData{LimitInBytes: -1})
+		assert.NoError(t, err)
+		assert.Equal(t, "max", readFakeCgroupFile(t, dir, "memory.max"))
+	})
+}
+
+func TestGetNumaStat(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.numa_stat": "total=30 N0=10 N1=20\nfile=12 N0=4 N1=8\nanon=18 N0=6 N1=12\nunevictable=0 N0=0 N1=0\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		numaStat, err := GetNumaStatWithAbsolutePath(dir)
+		assert.NoError(t, err)
+
+		pageSize := uint64(unix.Getpagesize())
+		assert.Equal(t, map[int]*common.MemoryNumaMetrics{
+			0: {Anon: 6 * pageSize, File: 4 * pageSize},
+			1: {Anon: 12 * pageSize, File: 8 * pageSize},
+		}, numaStat)
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.numa_stat": "anon N0=4096 N1=8192\nfile N0=1024 N1=0\nkernel_stack N0=16384 N1=0\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		numaStat, err := GetNumaStatWithAbsolutePath(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[int]*common.MemoryNumaMetrics{
+			0: {Anon: 4096, File: 1024},
+			1: {Anon: 8192, File: 0},
+		}, numaStat)
+	})
+}
+
+func TestApplyIO(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	data := &common.IOData{
+		Weight: 5000,
+		DeviceLimits: map[string]*common.IODeviceLimit{
+			"8:0": {ReadBps: 1048576, WriteIOPS: 100},
+		},
+	}
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"blkio.bfq.weight":                 "100\n",
+			"blkio.throttle.read_bps_device":   "",
+			"blkio.throttle.write_bps_device":  "",
+			"blkio.throttle.read_iops_device":  "",
+			"blkio.throttle.write_iops_device": "8:0 200\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		assert.NoError(t, ApplyIOWithAbsolutePath(dir, data))
+		assert.Equal(t, "1000", readFakeCgroupFile(t, dir, "blkio.bfq.weight"))
+		assert.Equal(t, "8:0 1048576", readFakeCgroupFile(t, dir, "blkio.throttle.read_bps_device"))
+		assert.Equal(t, "", readFakeCgroupFile(t, dir, "blkio.throttle.write_bps_device"))
+		assert.Equal(t, "8:0 100", readFakeCgroupFile(t, dir, "blkio.throttle.write_iops_device"))
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"io.weight": "default 100\n",
+			"io.max":    "",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		assert.NoError(t, ApplyIOWithAbsolutePath(dir, data))
+		assert.Equal(t, "default 5000", readFakeCgroupFile(t, dir, "io.weight"))
+		assert.Equal(t, "8:0 rbps=1048576 wbps=max riops=max wiops=100", readFakeCgroupFile(t, dir, "io.max"))
+
+		// zero limits reset the device to unlimited
+		assert.NoError(t, ApplyIOWithAbsolutePath(dir, &common.IOData{
+			DeviceLimits: map[string]*common.IODeviceLimit{"8:0": {}},
+		}))
+		assert.Equal(t, "8:0 rbps=max wbps=max riops=max wiops=max", readFakeCgroupFile(t, dir, "io.max"))
+
+		// io.bfq.weight is used if io.weight doesn't exist
+		dir = prepareFakeCgroupFiles(t, map[string]string{
+			"io.bfq.weight": "default 100\n",
+		})
+		defer os.RemoveAll(dir)
+
+		assert.NoError(t, ApplyIOWithAbsolutePath(dir, &common.IOData{Weight: 50}))
+		assert.Equal(t, "50", readFakeCgroupFile(t, dir, "io.bfq.weight"))
+	})
+}
+
+func TestGetIOStat(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"blkio.throttle.io_service_bytes": "8:0 Read 4096\n8:0 Write 8192\n8:0 Sync 0\n8:0 Async 12288\n8:0 Total 12288\nTotal 12288\n",
+			"blkio.throttle.io_serviced":      "8:0 Read 1\n8:0 Write 2\n8:0 Sync 0\n8:0 Async 3\n8:0 Total 3\nTotal 3\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		ioStat, err := GetIOStatWithAbsolutePath(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*common.IOStat{
+			"8:0": {ReadBytes: 4096, WriteBytes: 8192, ReadIOs: 1, WriteIOs: 2},
+		}, ioStat)
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"io.stat": "8:0 rbytes=4096 wbytes=8192 rios=1 wios=2 dbytes=0 dios=0\n253:0 rbytes=0 wbytes=0 rios=0 wios=0 dbytes=0 dios=0\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		ioStat, err := GetIOStatWithAbsolutePath(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*common.IOStat{
+			"8:0":   {ReadBytes: 4096, WriteBytes: 8192, ReadIOs: 1, WriteIOs: 2},
+			"253:0": {},
+		}, ioStat)
+	})
+}
+
+func TestApplyPids(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	for _, version := range []string{"v1", "v2"} {
+		t.Run(version, func(t *testing.T) {
+			dir := prepareFakeCgroupFiles(t, map[string]string{
+				"pids.max":     "max\n",
+				"pids.current": "10\n",
+			})
+			defer os.RemoveAll(dir)
+
+			if version == "v1" {
+				manager = v1.NewManager()
+			} else {
+				manager = v2.NewManager()
+			}
+
+			assert.NoError(t, ApplyPidsWithAbsolutePath(dir, &common.PidsData{Max: 1024}))
+			assert.Equal(t, "1024", readFakeCgroupFile(t, dir, "pids.max"))
+
+			stats, err := GetPidsStatsWithAbsolutePath(dir)
+			assert.NoError(t, err)
+			assert.Equal(t, &common.PidsStats{Current: 10, Max: 1024}, stats)
+
+			assert.NoError(t, ApplyPidsWithAbsolutePath(dir, &common.PidsData{Max: -1}))
+			assert.Equal(t, "max", readFakeCgroupFile(t, dir, "pids.max"))
+
+			stats, err = GetPidsStatsWithAbsolutePath(dir)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(math.MaxInt64), stats.Max)
+		})
+	}
+}
+
+func TestApplyHugetlb(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	data := &common.HugetlbData{
+		Limits: map[string]int64{
+			"2MB": 1 << 30,
+			"1GB": -1,
+		},
+	}
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"hugetlb.2MB.limit_in_bytes": "9223372036852678656\n",
+			"hugetlb.1GB.limit_in_bytes": "9223372035781033984\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		assert.NoError(t, ApplyHugetlbWithAbsolutePath(dir, data))
+		assert.Equal(t, "1073741824", readFakeCgroupFile(t, dir, "hugetlb.2MB.limit_in_bytes"))
+		// unlimited value isn't rewritten
+		assert.Equal(t, "9223372035781033984\n", readFakeCgroupFile(t, dir, "hugetlb.1GB.limit_in_bytes"))
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"hugetlb.2MB.max": "max\n",
+			"hugetlb.1GB.max": "1073741824\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		assert.NoError(t, ApplyHugetlbWithAbsolutePath(dir, data))
+		assert.Equal(t, "1073741824", readFakeCgroupFile(t, dir, "hugetlb.2MB.max"))
+		assert.Equal(t, "max", readFakeCgroupFile(t, dir, "hugetlb.1GB.max"))
+	})
+}
+
+func TestGetHugetlb(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"hugetlb.2MB.limit_in_bytes":     "9223372036852678656\n",
+			"hugetlb.2MB.usage_in_bytes":     "4194304\n",
+			"hugetlb.2MB.max_usage_in_bytes": "8388608\n",
+			"hugetlb.2MB.failcnt":            "3\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		stats, err := GetHugetlbWithAbsolutePath(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*common.HugetlbStats{
+			"2MB": {Usage: 4194304, MaxUsage: 8388608, Limit: math.MaxInt64, Failcnt: 3},
+		}, stats)
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"hugetlb.1GB.max":        "2147483648\n",
+			"hugetlb.1GB.rsvd.max":   "max\n",
+			"hugetlb.1GB.current":    "1073741824\n",
+			"hugetlb.1GB.events":     "max 2\n",
+			"hugetlb.2MB.max":        "max\n",
+			"hugetlb.2MB.rsvd.max":   "max\n",
+			"hugetlb.2MB.current":    "0\n",
+			"hugetlb.2MB.events":     "max 0\n",
+			"hugetlb.2MB.events.loc": "max 0\n",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		stats, err := GetHugetlbWithAbsolutePath(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*common.HugetlbStats{
+			"1GB": {Usage: 1073741824, Limit: 2147483648, Failcnt: 2},
+			"2MB": {Usage: 0, Limit: math.MaxInt64, Failcnt: 0},
+		}, stats)
+	})
+}
+
+func TestGetPressure(t *testing.T) {
+	dir := prepareFakeCgroupFiles(t, map[string]string{
+		"memory.pressure": "some avg10=1.50 avg60=0.80 avg300=0.20 total=12345\n" +
+			"full avg10=0.50 avg60=0.30 avg300=0.10 total=6789\n",
+		"cpu.pressure": "some avg10=2.00 avg60=1.00 avg300=0.50 total=100\n",
+	})
+	defer os.RemoveAll(dir)
+
+	for _, m := range []Manager{v1.NewManager(), v2.NewManager()} {
+		manager = m
+
+		stats, err := GetPressureWithAbsolutePath(dir, common.PressureResourceMemory)
+		assert.NoError(t, err)
+		assert.Equal(t, &common.PressureStats{
+			Some: &common.PressureData{Avg10: 1.5, Avg60: 0.8, Avg300: 0.2, Total: 12345},
+			Full: &common.PressureData{Avg10: 0.5, Avg60: 0.3, Avg300: 0.1, Total: 6789},
+		}, stats)
+
+		stats, err = GetPressureWithAbsolutePath(dir, common.PressureResourceCPU)
+		assert.NoError(t, err)
+		assert.Nil(t, stats.Full)
+
+		_, err = GetPressureWithAbsolutePath(dir, common.PressureResourceIO)
+		assert.Error(t, err)
+	}
+
+	metrics := common.GetCgroupPressureMetrics(dir)
+	assert.NotNil(t, metrics)
+	assert.NotNil(t, metrics.CPU)
+	assert.NotNil(t, metrics.Memory)
+	assert.Nil(t, metrics.IO)
+}
+
+func TestDropFileCache(t *testing.T) {
+	libcgroups.TestMode = true
+	defer func() {
+		libcgroups.TestMode = false
+	}()
+
+	t.Run("v1", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.usage_in_bytes": "4096",
+			"memory.limit_in_bytes": "8192",
+			"memory.swappiness":     "60",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v1.NewManager()
+		_, err := DropFileCacheOnCPUsWithAbsolutePath(dir, 1024, []int{0})
+		assert.NoError(t, err)
+		assert.Equal(t, "8192", readFakeCgroupFile(t, dir, "memory.limit_in_bytes"))
+		assert.Equal(t, "60", readFakeCgroupFile(t, dir, "memory.swappiness"))
+
+		// all memory usage can't be dropped as file cache
+		_, err = DropFileCacheOnCPUsWithAbsolutePath(dir, 4096, nil)
+		assert.Error(t, err)
+		assert.Equal(t, "60", readFakeCgroupFile(t, dir, "memory.swappiness"))
+	})
+
+	t.Run("v2", func(t *testing.T) {
+		dir := prepareFakeCgroupFiles(t, map[string]string{
+			"memory.current":  "4096",
+			"memory.reclaim":  "",
+			"memory.swap.max": "max",
+		})
+		defer os.RemoveAll(dir)
+
+		manager = v2.NewManager()
+		_, err := DropFileCacheOnCPUsWithAbsolutePath(dir, 1024, []int{0})
+		assert.NoError(t, err)
+		assert.Equal(t, "1024", readFakeCgroupFile(t, dir, "memory.reclaim"))
+		assert.Equal(t, "max", readFakeCgroupFile(t, dir, "memory.swap.max"))
+	})
+}
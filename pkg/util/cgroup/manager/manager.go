@@ -38,14 +38,35 @@ type Manager interface {
 	ApplyCPU(absCgroupPath string, data *common.CPUData) error
 	ApplyCPUSet(absCgroupPath string, data *common.CPUSetData) error
 	ApplyNetCls(absCgroupPath string, data *common.NetClsData) error
+	ApplyIO(absCgroupPath string, data *common.IOData) error
+	ApplyPids(absCgroupPath string, data *common.PidsData) error
+	ApplyHugetlb(absCgroupPath string, data *common.HugetlbData) error
 
 	GetMemory(absCgroupPath string) (*common.MemoryStats, error)
 	GetCPU(absCgroupPath string) (*common.CPUStats, error)
 	GetCPUSet(absCgroupPath string) (*common.CPUSetStats, error)
 	GetMetrics(relCgroupPath string, subsystems map[string]struct{}) (*common.CgroupMetrics, error)
+	// GetNumaStat returns the per-NUMA anon and file memory in bytes, keyed by NUMA id.
+	GetNumaStat(absCgroupPath string) (map[int]*common.MemoryNumaMetrics, error)
+	// GetIOStat returns the accumulated io statistics keyed by device numbers formatted as "major:minor".
+	GetIOStat(absCgroupPath string) (map[string]*common.IOStat, error)
+	GetPidsStats(absCgroupPath string) (*common.PidsStats, error)
+	// GetHugetlb returns the hugetlb statistics keyed by page sizes, e.g. 2MB and 1GB.
+	GetHugetlb(absCgroupPath string) (map[string]*common.HugetlbStats, error)
+	// GetPressure returns PSI of the given resource, i.e. cpu, memory or io.
+	GetPressure(absCgroupPath, resource string) (*common.PressureStats, error)
 
 	GetPids(absCgroupPath string) ([]string, error)
 	GetTasks(absCgroupPath string) ([]string, error)
+
+	// ReclaimMemory tries to reclaim the given bytes of memory from the cgroup;
+	// it returns the bytes actually reclaimed, which may be less than requested.
+	ReclaimMemory(absCgroupPath string, nbytes int64) (int64, error)
+
+	// DropFileCache works like ReclaimMemory but only reclaims file pages, leaving
+	// anonymous pages of the cgroup untouched; swap knobs of the cgroup are overridden
+	// during the reclaim, so callers should serialize it with other writers of them.
+	DropFileCache(absCgroupPath string, nbytes int64) (int64, error)
 }
 
 // GetManager returns a cgroup instance for both v1/v2 version
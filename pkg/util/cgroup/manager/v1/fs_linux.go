@@ -20,14 +20,19 @@ limitations under the License.
 package v1
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/containerd/cgroups"
+	cgroupsstats "github.com/containerd/cgroups/stats/v1"
 	libcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
+	"golang.org/x/sys/unix"
 	"k8s.io/klog/v2"
 
 	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
@@ -42,11 +47,9 @@ func NewManager() *manager {
 }
 
 func (m *manager) ApplyMemory(absCgroupPath string, data *common.MemoryData) error {
-	if data.LimitInBytes > 0 {
-		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.limit_in_bytes", strconv.FormatInt(data.LimitInBytes, 10)); err != nil {
+	if data.LimitInBytes > 0 || data.LimitInBytes == -1 || data.SwapMaxInBytesPtr != nil {
+		if err := applyMemoryLimits(absCgroupPath, data.LimitInBytes, data.SwapMaxInBytesPtr); err != nil {
 			return err
-		} else if applied {
-			klog.Infof("[CgroupV1] apply memory limit_in_bytes successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, data.LimitInBytes, oldData)
 		}
 	}
 
@@ -59,6 +62,31 @@ func (m *manager) ApplyMemory(absCgroupPath string, data *common.MemoryData) err
 		}
 	}
 
+	// memory.min and memory.high are not supported by cgroupv1, and memory.low
+	// is mapped to memory.soft_limit_in_bytes which works in a best-effort way;
+	// no protection means no soft limit, i.e. unlimited
+	if data.LowInBytesPtr != nil {
+		softLimit := *data.LowInBytesPtr
+		if softLimit == 0 {
+			softLimit = -1
+		}
+
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.soft_limit_in_bytes", strconv.FormatInt(softLimit, 10)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV1] apply memory soft_limit_in_bytes successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, softLimit, oldData)
+		}
+	}
+
+	if data.SwappinessPtr != nil {
+		swappiness := strconv.FormatUint(*data.SwappinessPtr, 10)
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.swappiness", swappiness); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV1] apply memory swappiness successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, swappiness, oldData)
+		}
+	}
+
 	return nil
 }
 
@@ -177,6 +205,116 @@ func (m *manager) ApplyNetCls(absCgroupPath string, data *common.NetClsData) err
 	return nil
 }
 
+// ApplyIO applies blkio.weight (or blkio.bfq.weight if blkio.weight doesn't exist) and
+// blkio.throttle.*_device, and the limit of a device is written only if it's changed
+func (m *manager) ApplyIO(absCgroupPath string, data *common.IOData) error {
+	if data.Weight != 0 {
+		weightFile, weight := "blkio.weight", common.ConvertIOWeightToBlkIOWeight(data.Weight)
+		if _, err := os.Stat(filepath.Join(absCgroupPath, weightFile)); os.IsNotExist(err) {
+			weightFile, weight = "blkio.bfq.weight", common.ConvertIOWeightToBFQWeight(data.Weight)
+		}
+
+		content, err := libcgroups.ReadFile(absCgroupPath, weightFile)
+		if err != nil {
+			return err
+		}
+
+		if oldWeight, err := common.ParseIODefaultWeight(content); err != nil || oldWeight != weight {
+			if err := libcgroups.WriteFile(absCgroupPath, weightFile, strconv.FormatUint(weight, 10)); err != nil {
+				return err
+			}
+			klog.Infof("[CgroupV1] apply %s successfully, cgroupPath: %s, data: %v, old data: %v\n", weightFile, absCgroupPath, weight, content)
+		}
+	}
+
+	if len(data.DeviceLimits) == 0 {
+		return nil
+	}
+
+	for _, throttle := range []struct {
+		file     string
+		getValue func(limit *common.IODeviceLimit) uint64
+	}{
+		{"blkio.throttle.read_bps_device", func(limit *common.IODeviceLimit) uint64 { return limit.ReadBps }},
+		{"blkio.throttle.write_bps_device", func(limit *common.IODeviceLimit) uint64 { return limit.WriteBps }},
+		{"blkio.throttle.read_iops_device", func(limit *common.IODeviceLimit) uint64 { return limit.ReadIOPS }},
+		{"blkio.throttle.write_iops_device", func(limit *common.IODeviceLimit) uint64 { return limit.WriteIOPS }},
+	} {
+		content, err := libcgroups.ReadFile(absCgroupPath, throttle.file)
+		if err != nil {
+			return err
+		}
+
+		oldLimits := common.ParseIODeviceLines(content)
+		for device, limit := range data.DeviceLimits {
+			if limit == nil {
+				continue
+			}
+
+			// the kernel omits lines of devices without limit, and writing zero removes the limit
+			value := strconv.FormatUint(throttle.getValue(limit), 10)
+			oldFields, ok := oldLimits[device]
+			if (ok && strings.Join(oldFields, " ") == value) || (!ok && value == "0") {
+				continue
+			}
+
+			if err := libcgroups.WriteFile(absCgroupPath, throttle.file, device+" "+value); err != nil {
+				return err
+			}
+			klog.Infof("[CgroupV1] apply %s successfully, cgroupPath: %s, device: %s, data: %v, old data: %v\n",
+				throttle.file, absCgroupPath, device, value, oldFields)
+		}
+	}
+
+	return nil
+}
+
+func (m *manager) ApplyPids(absCgroupPath string, data *common.PidsData) error {
+	if data.Max != 0 {
+		max := "max"
+		if data.Max > 0 {
+			max = strconv.FormatInt(data.Max, 10)
+		}
+
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "pids.max", max); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV1] apply pids max successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, max, oldData)
+		}
+	}
+
+	return nil
+}
+
+// ApplyHugetlb applies hugetlb.<pagesize>.limit_in_bytes, and -1 is written
+// as it is since the kernel converts it to the max value
+func (m *manager) ApplyHugetlb(absCgroupPath string, data *common.HugetlbData) error {
+	for pageSize, limit := range data.Limits {
+		limitFile := common.GetHugetlbFileName(pageSize, "limit_in_bytes")
+		if limit < 0 {
+			unlimited, err := common.GetHugetlbUnlimitedValue(pageSize)
+			if err != nil {
+				return err
+			}
+
+			oldLimit, err := common.GetCgroupParamInt(absCgroupPath, limitFile)
+			if err != nil {
+				return err
+			} else if oldLimit >= unlimited {
+				continue
+			}
+		}
+
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, limitFile, strconv.FormatInt(limit, 10)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV1] apply %s successfully, cgroupPath: %s, data: %v, old data: %v\n", limitFile, absCgroupPath, limit, oldData)
+		}
+	}
+
+	return nil
+}
+
 func (m *manager) GetMemory(absCgroupPath string) (*common.MemoryStats, error) {
 	memoryStats := &common.MemoryStats{}
 	moduleName := "memory"
@@ -259,6 +397,7 @@ func (m *manager) GetMetrics(relCgroupPath string, subsystemMap map[string]struc
 		Memory: &common.MemoryMetrics{},
 		CPU:    &common.CPUMetrics{},
 		Pid:    &common.PidMetrics{},
+		IO:     make(map[string]*common.IOStat),
 	}
 	for subsys := range subsystems {
 		switch subsys {
@@ -273,6 +412,11 @@ func (m *manager) GetMetrics(relCgroupPath string, subsystemMap map[string]struc
 				cm.Memory.UsageUsage = stats.Memory.Usage.Usage
 				cm.Memory.KernelUsage = stats.Memory.Kernel.Usage
 				cm.Memory.MemSWUsage = stats.Memory.Swap.Usage
+				if stats.Memory.Swap.Usage > stats.Memory.Usage.Usage {
+					cm.Memory.SwapUsage = stats.Memory.Swap.Usage - stats.Memory.Usage.Usage
+				}
+				cm.Memory.InactiveFile = stats.Memory.TotalInactiveFile
+				cm.Memory.ActiveFile = stats.Memory.TotalActiveFile
 			}
 		case cgroups.Cpu:
 			if stats.CPU == nil {
@@ -289,11 +433,181 @@ func (m *manager) GetMetrics(relCgroupPath string, subsystemMap map[string]struc
 				cm.Pid.Current = stats.Pids.Current
 				cm.Pid.Limit = stats.Pids.Limit
 			}
+		case cgroups.Blkio:
+			if stats.Blkio == nil {
+				klog.Infof("[cgroupv1] get cgroup stats blkio nil, cgroupPath: %v\n", relCgroupPath)
+			} else {
+				setBlkIOMetrics(cm.IO, stats.Blkio.IoServiceBytesRecursive,
+					func(stat *common.IOStat, value uint64) { stat.ReadBytes = value },
+					func(stat *common.IOStat, value uint64) { stat.WriteBytes = value })
+				setBlkIOMetrics(cm.IO, stats.Blkio.IoServicedRecursive,
+					func(stat *common.IOStat, value uint64) { stat.ReadIOs = value },
+					func(stat *common.IOStat, value uint64) { stat.WriteIOs = value })
+			}
+		case cgroups.Hugetlb:
+			// hugetlb stats of containerd cgroups lack limits, so they are parsed here
+			cm.Hugetlb, err = m.GetHugetlb(common.GetAbsCgroupPath(common.CgroupSubsysHugetlb, relCgroupPath))
+			if err != nil {
+				klog.Infof("[cgroupv1] get cgroup stats hugetlb failed, cgroupPath: %v, err: %v\n", relCgroupPath, err)
+			}
+		case cgroups.Cpuacct:
+			cm.Pressure = common.GetCgroupPressureMetrics(common.GetAbsCgroupPath(common.CgroupSubsysCPUAcct, relCgroupPath))
 		}
 	}
 	return cm, nil
 }
 
+// GetNumaStat parses memory.numa_stat, in which lines are formatted as
+// "anon=N N0=N0 N1=N1" and values are counted in pages.
+func (m *manager) GetNumaStat(absCgroupPath string) (map[int]*common.MemoryNumaMetrics, error) {
+	content, err := fscommon.GetCgroupParamString(absCgroupPath, "memory.numa_stat")
+	if err != nil {
+		return nil, fmt.Errorf("read memory.numa_stat failed with error: %v", err)
+	}
+
+	pageSize := uint64(unix.Getpagesize())
+	numaStat := make(map[int]*common.MemoryNumaMetrics)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		var setter func(metrics *common.MemoryNumaMetrics, value uint64)
+		switch strings.SplitN(fields[0], "=", 2)[0] {
+		case "anon":
+			setter = func(metrics *common.MemoryNumaMetrics, value uint64) { metrics.Anon = value }
+		case "file":
+			setter = func(metrics *common.MemoryNumaMetrics, value uint64) { metrics.File = value }
+		default:
+			continue
+		}
+
+		if err := common.ParseNumaStatFields(fields[1:], numaStat, func(metrics *common.MemoryNumaMetrics, value uint64) {
+			setter(metrics, value*pageSize)
+		}); err != nil {
+			return nil, fmt.Errorf("parse memory.numa_stat of %s failed with error: %v", absCgroupPath, err)
+		}
+	}
+	return numaStat, nil
+}
+
+// GetIOStat parses blkio.throttle.io_service_bytes and blkio.throttle.io_serviced,
+// in which lines are formatted as "8:0 Read N" or "8:0 Write N".
+func (m *manager) GetIOStat(absCgroupPath string) (map[string]*common.IOStat, error) {
+	ioStat := make(map[string]*common.IOStat)
+	for _, serviced := range []struct {
+		file     string
+		setRead  func(stat *common.IOStat, value uint64)
+		setWrite func(stat *common.IOStat, value uint64)
+	}{
+		{
+			"blkio.throttle.io_service_bytes",
+			func(stat *common.IOStat, value uint64) { stat.ReadBytes = value },
+			func(stat *common.IOStat, value uint64) { stat.WriteBytes = value },
+		},
+		{
+			"blkio.throttle.io_serviced",
+			func(stat *common.IOStat, value uint64) { stat.ReadIOs = value },
+			func(stat *common.IOStat, value uint64) { stat.WriteIOs = value },
+		},
+	} {
+		content, err := fscommon.GetCgroupParamString(absCgroupPath, serviced.file)
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed with error: %v", serviced.file, err)
+		}
+
+		for _, line := range strings.Split(content, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+
+			var setter func(stat *common.IOStat, value uint64)
+			switch fields[1] {
+			case "Read":
+				setter = serviced.setRead
+			case "Write":
+				setter = serviced.setWrite
+			default:
+				continue
+			}
+
+			value, err := strconv.ParseUint(fields[2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s of %s failed with error: %v", serviced.file, absCgroupPath, err)
+			}
+
+			if ioStat[fields[0]] == nil {
+				ioStat[fields[0]] = &common.IOStat{}
+			}
+			setter(ioStat[fields[0]], value)
+		}
+	}
+	return ioStat, nil
+}
+
+func (m *manager) GetPidsStats(absCgroupPath string) (*common.PidsStats, error) {
+	current, err := fscommon.GetCgroupParamUint(absCgroupPath, "pids.current")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pids.current, %v", err)
+	}
+
+	max, err := common.GetCgroupParamInt(absCgroupPath, "pids.max")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pids.max, %v", err)
+	}
+
+	return &common.PidsStats{Current: current, Max: max}, nil
+}
+
+// GetHugetlb parses hugetlb.<pagesize>.{usage_in_bytes,max_usage_in_bytes,limit_in_bytes,failcnt}
+// of all page sizes supported by the kernel
+func (m *manager) GetHugetlb(absCgroupPath string) (map[string]*common.HugetlbStats, error) {
+	pageSizes, err := common.GetHugetlbPageSizes(absCgroupPath, "limit_in_bytes")
+	if err != nil {
+		return nil, err
+	}
+
+	hugetlbStats := make(map[string]*common.HugetlbStats, len(pageSizes))
+	for _, pageSize := range pageSizes {
+		stats := &common.HugetlbStats{}
+		for _, param := range []struct {
+			suffix string
+			value  *uint64
+		}{
+			{"usage_in_bytes", &stats.Usage},
+			{"max_usage_in_bytes", &stats.MaxUsage},
+			{"failcnt", &stats.Failcnt},
+		} {
+			file := common.GetHugetlbFileName(pageSize, param.suffix)
+			if *param.value, err = fscommon.GetCgroupParamUint(absCgroupPath, file); err != nil {
+				return nil, fmt.Errorf("failed to parse %s, %v", file, err)
+			}
+		}
+
+		limitFile := common.GetHugetlbFileName(pageSize, "limit_in_bytes")
+		if stats.Limit, err = common.GetCgroupParamInt(absCgroupPath, limitFile); err != nil {
+			return nil, fmt.Errorf("failed to parse %s, %v", limitFile, err)
+		}
+
+		// the unlimited value is converted to math.MaxInt64 to be consistent with cgroupv2
+		unlimited, err := common.GetHugetlbUnlimitedValue(pageSize)
+		if err != nil {
+			return nil, err
+		} else if stats.Limit >= unlimited {
+			stats.Limit = math.MaxInt64
+		}
+		hugetlbStats[pageSize] = stats
+	}
+	return hugetlbStats, nil
+}
+
+// GetPressure parses <resource>.pressure, which only exists if the kernel supports PSI for cgroupv1
+func (m *manager) GetPressure(absCgroupPath, resource string) (*common.PressureStats, error) {
+	return common.GetCgroupPressure(absCgroupPath, resource)
+}
+
 // GetPids return pids in current cgroup
 func (m *manager) GetPids(absCgroupPath string) ([]string, error) {
 	pids, err := libcgroups.GetPids(absCgroupPath)
@@ -315,6 +629,197 @@ func (m *manager) GetTasks(absCgroupPath string) ([]string, error) {
 	return tasks, nil
 }
 
+// ReclaimMemory reclaims memory by squeezing memory.limit_in_bytes below the
+// current usage and restoring it afterwards, since cgroupv1 has no memory.reclaim;
+// the kernel reclaims as much as it can and returns EBUSY instead of OOM-killing.
+// Targets covering all the usage are rejected rather than falling back to
+// memory.force_empty, which would drop all page caches of the cgroup.
+func (m *manager) ReclaimMemory(absCgroupPath string, nbytes int64) (int64, error) {
+	if nbytes <= 0 {
+		return 0, nil
+	}
+
+	before, err := fscommon.GetCgroupParamUint(absCgroupPath, "memory.usage_in_bytes")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get memory.usage_in_bytes of %s: %v", absCgroupPath, err)
+	}
+
+	if uint64(nbytes) >= before {
+		return 0, fmt.Errorf("reclaim target %d covers all memory usage %d of %s", nbytes, before, absCgroupPath)
+	}
+
+	limit, err := fscommon.GetCgroupParamString(absCgroupPath, "memory.limit_in_bytes")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get memory.limit_in_bytes of %s: %v", absCgroupPath, err)
+	}
+
+	squeezed := strconv.FormatUint(before-uint64(nbytes), 10)
+	if err := libcgroups.WriteFile(absCgroupPath, "memory.limit_in_bytes", squeezed); err != nil && !errors.Is(err, unix.EBUSY) {
+		return 0, fmt.Errorf("failed to squeeze memory.limit_in_bytes of %s: %v", absCgroupPath, err)
+	}
+
+	if err := libcgroups.WriteFile(absCgroupPath, "memory.limit_in_bytes", limit); err != nil {
+		klog.Errorf("[CgroupV1] failed to restore memory.limit_in_bytes to %s, cgroupPath: %s, err: %v", limit, absCgroupPath, err)
+		return 0, fmt.Errorf("failed to restore memory.limit_in_bytes of %s: %v", absCgroupPath, err)
+	}
+
+	after, err := fscommon.GetCgroupParamUint(absCgroupPath, "memory.usage_in_bytes")
+	if err != nil || after >= before {
+		return 0, nil
+	}
+
+	klog.Infof("[CgroupV1] reclaim memory successfully, cgroupPath: %s, target: %d, reclaimed: %d", absCgroupPath, nbytes, before-after)
+	return int64(before - after), nil
+}
+
+// DropFileCache sets memory.swappiness to 0 during the reclaim, with which
+// the kernel only scans file lru lists for cgroup reclaim.
+func (m *manager) DropFileCache(absCgroupPath string, nbytes int64) (int64, error) {
+	swappiness, err := fscommon.GetCgroupParamString(absCgroupPath, "memory.swappiness")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get memory.swappiness of %s: %v", absCgroupPath, err)
+	}
+
+	if swappiness != "0" {
+		if err := libcgroups.WriteFile(absCgroupPath, "memory.swappiness", "0"); err != nil {
+			return 0, fmt.Errorf("failed to disable memory.swappiness of %s: %v", absCgroupPath, err)
+		}
+		defer func() {
+			if err := libcgroups.WriteFile(absCgroupPath, "memory.swappiness", swappiness); err != nil {
+				klog.Errorf("[CgroupV1] failed to restore memory.swappiness to %s, cgroupPath: %s, err: %v", swappiness, absCgroupPath, err)
+			}
+		}()
+	}
+
+	return m.ReclaimMemory(absCgroupPath, nbytes)
+}
+
+// applyMemoryLimits applies memory.limit_in_bytes and memory.memsw.limit_in_bytes together, since the
+// kernel rejects any memory limit above memsw limit. If limit is zero, memory limit is kept, and -1 means
+// unlimited; if swapMax is nil, the swap budget (i.e. memsw limit minus memory limit) is kept; otherwise
+// memsw limit is set to limit swap usage to swapMax, and -1 means unlimited. Limits are written in the
+// order that keeps memsw limit no less than memory limit all the time.
+func applyMemoryLimits(absCgroupPath string, limit int64, swapMaxPtr *int64) error {
+	oldLimit, err := getMemoryLimit(absCgroupPath, "memory.limit_in_bytes")
+	if err != nil {
+		return err
+	}
+
+	newLimit := oldLimit
+	if limit > 0 || limit == -1 {
+		newLimit = limit
+	}
+
+	// memsw files don't exist if swap accounting is disabled
+	oldMemswLimit, memswErr := getMemoryLimit(absCgroupPath, "memory.memsw.limit_in_bytes")
+	newMemswLimit := oldMemswLimit
+	switch {
+	case swapMaxPtr != nil:
+		if memswErr != nil {
+			return memswErr
+		}
+
+		swapMax := *swapMaxPtr
+		if swapMax < 0 || newLimit < 0 || newLimit > math.MaxInt64-swapMax {
+			newMemswLimit = -1
+		} else {
+			newMemswLimit = newLimit + swapMax
+		}
+	case memswErr == nil && oldMemswLimit >= 0 && newLimit != oldLimit:
+		if oldLimit < 0 || newLimit < 0 || newLimit > math.MaxInt64-(oldMemswLimit-oldLimit) {
+			newMemswLimit = -1
+		} else {
+			newMemswLimit = newLimit + oldMemswLimit - oldLimit
+		}
+	}
+
+	writeLimit := func() error {
+		if newLimit == oldLimit {
+			return nil
+		}
+
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.limit_in_bytes", strconv.FormatInt(newLimit, 10)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV1] apply memory limit_in_bytes successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, newLimit, oldData)
+		}
+		return nil
+	}
+
+	writeMemswLimit := func() error {
+		if memswErr != nil || newMemswLimit == oldMemswLimit {
+			return nil
+		}
+
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.memsw.limit_in_bytes", strconv.FormatInt(newMemswLimit, 10)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV1] apply memory memsw.limit_in_bytes successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, newMemswLimit, oldData)
+		}
+		return nil
+	}
+
+	// memsw limit must be raised before memory limit, and lowered after it
+	writes := []func() error{writeLimit, writeMemswLimit}
+	if newLimit < 0 || (oldLimit >= 0 && newLimit > oldLimit) {
+		writes = []func() error{writeMemswLimit, writeLimit}
+	}
+
+	for _, write := range writes {
+		if err := write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getMemoryLimit reads a memory limit file of cgroupv1, and -1 is returned for unlimited, which
+// is reported by the kernel as the max int64 value rounded down to the page size.
+func getMemoryLimit(absCgroupPath, file string) (int64, error) {
+	content, err := fscommon.GetCgroupParamString(absCgroupPath, file)
+	if err != nil {
+		return 0, fmt.Errorf("get %s failed with error: %v", file, err)
+	} else if content == "-1" {
+		return -1, nil
+	}
+
+	value, err := strconv.ParseUint(content, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s failed with error: %v", file, err)
+	}
+
+	if value >= uint64(math.MaxInt64-os.Getpagesize()+1) {
+		return -1, nil
+	}
+	return int64(value), nil
+}
+
+// setBlkIOMetrics sets io metrics by blkio entries whose op is Read or Write
+func setBlkIOMetrics(ioMetrics map[string]*common.IOStat, entries []*cgroupsstats.BlkIOEntry,
+	setRead, setWrite func(stat *common.IOStat, value uint64)) {
+	for _, entry := range entries {
+		if entry == nil {
+			continue
+		}
+
+		var setter func(stat *common.IOStat, value uint64)
+		switch entry.Op {
+		case "Read":
+			setter = setRead
+		case "Write":
+			setter = setWrite
+		default:
+			continue
+		}
+
+		device := fmt.Sprintf("%d:%d", entry.Major, entry.Minor)
+		if ioMetrics[device] == nil {
+			ioMetrics[device] = &common.IOStat{}
+		}
+		setter(ioMetrics[device], entry.Value)
+	}
+}
+
 func newHierarchy(enabled map[cgroups.Name]struct{}) cgroups.Hierarchy {
 	return func() ([]cgroups.Subsystem, error) {
 		ss, err := cgroups.V1()
@@ -31,6 +31,7 @@ import (
 
 	cgroupsv2 "github.com/containerd/cgroups/v2"
 	"github.com/opencontainers/runc/libcontainer/cgroups/fscommon"
+	"golang.org/x/sys/unix"
 	"k8s.io/klog/v2"
 
 	libcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
@@ -47,7 +48,7 @@ func NewManager() *manager {
 }
 
 func (m *manager) ApplyMemory(absCgroupPath string, data *common.MemoryData) error {
-	if data.LimitInBytes > 0 {
+	if data.LimitInBytes > 0 || data.LimitInBytes == -1 {
 		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.max", numToStr(data.LimitInBytes)); err != nil {
 			return err
 		} else if applied {
@@ -64,6 +65,43 @@ func (m *manager) ApplyMemory(absCgroupPath string, data *common.MemoryData) err
 		}
 	}
 
+	if data.MinInBytesPtr != nil {
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.min", protectionToStr(*data.MinInBytesPtr)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV2] apply memory min successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, *data.MinInBytesPtr, oldData)
+		}
+	}
+
+	if data.LowInBytesPtr != nil {
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.low", protectionToStr(*data.LowInBytesPtr)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV2] apply memory low successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, *data.LowInBytesPtr, oldData)
+		}
+	}
+
+	if data.HighInBytes != 0 {
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.high", numToStr(data.HighInBytes)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV2] apply memory high successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, data.HighInBytes, oldData)
+		}
+	}
+
+	if data.SwapMaxInBytesPtr != nil {
+		swapMax := strconv.FormatInt(*data.SwapMaxInBytesPtr, 10)
+		if *data.SwapMaxInBytesPtr < 0 {
+			swapMax = "max"
+		}
+
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "memory.swap.max", swapMax); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV2] apply memory swap max successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, swapMax, oldData)
+		}
+	}
+
 	return nil
 }
 
@@ -160,6 +198,98 @@ func (m *manager) ApplyNetCls(_ string, _ *common.NetClsData) error {
 	return errors.New("cgroups v2 does not support net_cls cgroup, please use eBPF via external manager")
 }
 
+// ApplyIO applies io.weight (or io.bfq.weight if io.weight doesn't exist) and io.max,
+// and the io.max line of a device is written only if it's changed
+func (m *manager) ApplyIO(absCgroupPath string, data *common.IOData) error {
+	if data.Weight != 0 {
+		weightFile, weight := "io.weight", fmt.Sprintf("default %d", data.Weight)
+		if _, err := os.Stat(filepath.Join(absCgroupPath, weightFile)); os.IsNotExist(err) {
+			weightFile, weight = "io.bfq.weight", strconv.FormatUint(common.ConvertIOWeightToBFQWeight(data.Weight), 10)
+		}
+
+		content, err := libcgroups.ReadFile(absCgroupPath, weightFile)
+		if err != nil {
+			return err
+		}
+
+		oldWeight, err := common.ParseIODefaultWeight(content)
+		if err != nil || strconv.FormatUint(oldWeight, 10) != strings.TrimPrefix(weight, "default ") {
+			if err := libcgroups.WriteFile(absCgroupPath, weightFile, weight); err != nil {
+				return err
+			}
+			klog.Infof("[CgroupV2] apply %s successfully, cgroupPath: %s, data: %v, old data: %v\n", weightFile, absCgroupPath, weight, content)
+		}
+	}
+
+	if len(data.DeviceLimits) == 0 {
+		return nil
+	}
+
+	content, err := libcgroups.ReadFile(absCgroupPath, "io.max")
+	if err != nil {
+		return err
+	}
+
+	oldLimits := common.ParseIODeviceLines(content)
+	for device, limit := range data.DeviceLimits {
+		if limit == nil {
+			continue
+		}
+
+		fields := []string{
+			"rbps=" + ioMaxValue(limit.ReadBps),
+			"wbps=" + ioMaxValue(limit.WriteBps),
+			"riops=" + ioMaxValue(limit.ReadIOPS),
+			"wiops=" + ioMaxValue(limit.WriteIOPS),
+		}
+
+		// the kernel omits lines of devices without any limit
+		oldFields, ok := oldLimits[device]
+		if (ok && strings.Join(oldFields, " ") == strings.Join(fields, " ")) ||
+			(!ok && *limit == common.IODeviceLimit{}) {
+			continue
+		}
+
+		ioMax := device + " " + strings.Join(fields, " ")
+		if err := libcgroups.WriteFile(absCgroupPath, "io.max", ioMax); err != nil {
+			return err
+		}
+		klog.Infof("[CgroupV2] apply io max successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, ioMax, oldFields)
+	}
+
+	return nil
+}
+
+func (m *manager) ApplyPids(absCgroupPath string, data *common.PidsData) error {
+	if data.Max != 0 {
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, "pids.max", numToStr(data.Max)); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV2] apply pids max successfully, cgroupPath: %s, data: %v, old data: %v\n", absCgroupPath, data.Max, oldData)
+		}
+	}
+
+	return nil
+}
+
+func (m *manager) ApplyHugetlb(absCgroupPath string, data *common.HugetlbData) error {
+	for pageSize, limit := range data.Limits {
+		max := "max"
+		if limit >= 0 {
+			max = strconv.FormatInt(limit, 10)
+		}
+
+		maxFile := common.GetHugetlbFileName(pageSize, "max")
+		if err, applied, oldData := common.WriteFileIfChange(absCgroupPath, maxFile, max); err != nil {
+			return err
+		} else if applied {
+			klog.Infof("[CgroupV2] apply %s successfully, cgroupPath: %s, data: %v, old data: %v\n", maxFile, absCgroupPath, max, oldData)
+		}
+	}
+
+	return nil
+}
+
 func (m *manager) GetMemory(absCgroupPath string) (*common.MemoryStats, error) {
 	memoryStats := &common.MemoryStats{}
 	moduleName := "memory"
@@ -248,6 +378,7 @@ func (m *manager) GetMetrics(relCgroupPath string, _ map[string]struct{}) (*comm
 		Memory: &common.MemoryMetrics{},
 		CPU:    &common.CPUMetrics{},
 		Pid:    &common.PidMetrics{},
+		IO:     make(map[string]*common.IOStat),
 	}
 	if stats.Memory == nil {
 		klog.Infof("[cgroupv2] get cgroup stats memory nil, cgroupPath: %v\n", relCgroupPath)
@@ -260,6 +391,9 @@ func (m *manager) GetMetrics(relCgroupPath string, _ map[string]struct{}) (*comm
 		cm.Memory.KernelUsage = stats.Memory.KernelStack + stats.Memory.Slab + stats.Memory.Sock
 		cm.Memory.UsageUsage = stats.Memory.Usage
 		cm.Memory.MemSWUsage = stats.Memory.SwapUsage
+		cm.Memory.SwapUsage = stats.Memory.SwapUsage
+		cm.Memory.InactiveFile = stats.Memory.InactiveFile
+		cm.Memory.ActiveFile = stats.Memory.ActiveFile
 	}
 
 	if stats.CPU == nil {
@@ -277,9 +411,153 @@ func (m *manager) GetMetrics(relCgroupPath string, _ map[string]struct{}) (*comm
 		cm.Pid.Limit = stats.Pids.Limit
 	}
 
+	if stats.Io == nil {
+		klog.Infof("[cgroupv2] get cgroup stats io nil, cgroupPath: %v\n", relCgroupPath)
+	} else {
+		for _, entry := range stats.Io.Usage {
+			if entry == nil {
+				continue
+			}
+			cm.IO[fmt.Sprintf("%d:%d", entry.Major, entry.Minor)] = &common.IOStat{
+				ReadBytes:  entry.Rbytes,
+				WriteBytes: entry.Wbytes,
+				ReadIOs:    entry.Rios,
+				WriteIOs:   entry.Wios,
+			}
+		}
+	}
+
+	absCgroupPath := filepath.Join(common.CgroupFSMountPoint, relCgroupPath)
+	// hugetlb stats of containerd cgroups lack failures, so they are parsed here
+	cm.Hugetlb, err = m.GetHugetlb(absCgroupPath)
+	if err != nil {
+		klog.Infof("[cgroupv2] get cgroup stats hugetlb failed, cgroupPath: %v, err: %v\n", relCgroupPath, err)
+	}
+	cm.Pressure = common.GetCgroupPressureMetrics(absCgroupPath)
+
 	return cm, nil
 }
 
+// GetNumaStat parses memory.numa_stat, in which lines are formatted as
+// "anon N0=N0 N1=N1" and values are counted in bytes.
+func (m *manager) GetNumaStat(absCgroupPath string) (map[int]*common.MemoryNumaMetrics, error) {
+	content, err := fscommon.GetCgroupParamString(absCgroupPath, "memory.numa_stat")
+	if err != nil {
+		return nil, fmt.Errorf("read memory.numa_stat failed with error: %v", err)
+	}
+
+	numaStat := make(map[int]*common.MemoryNumaMetrics)
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		var setter func(metrics *common.MemoryNumaMetrics, value uint64)
+		switch fields[0] {
+		case "anon":
+			setter = func(metrics *common.MemoryNumaMetrics, value uint64) { metrics.Anon = value }
+		case "file":
+			setter = func(metrics *common.MemoryNumaMetrics, value uint64) { metrics.File = value }
+		default:
+			continue
+		}
+
+		if err := common.ParseNumaStatFields(fields[1:], numaStat, setter); err != nil {
+			return nil, fmt.Errorf("parse memory.numa_stat of %s failed with error: %v", absCgroupPath, err)
+		}
+	}
+	return numaStat, nil
+}
+
+// GetIOStat parses io.stat, in which lines are formatted as
+// "8:0 rbytes=N wbytes=N rios=N wios=N dbytes=N dios=N".
+func (m *manager) GetIOStat(absCgroupPath string) (map[string]*common.IOStat, error) {
+	content, err := fscommon.GetCgroupParamString(absCgroupPath, "io.stat")
+	if err != nil {
+		return nil, fmt.Errorf("read io.stat failed with error: %v", err)
+	}
+
+	ioStat := make(map[string]*common.IOStat)
+	for device, fields := range common.ParseIODeviceLines(content) {
+		stat := &common.IOStat{}
+		for _, field := range fields {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+
+			value, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse io.stat of %s failed with error: %v", absCgroupPath, err)
+			}
+
+			switch kv[0] {
+			case "rbytes":
+				stat.ReadBytes = value
+			case "wbytes":
+				stat.WriteBytes = value
+			case "rios":
+				stat.ReadIOs = value
+			case "wios":
+				stat.WriteIOs = value
+			}
+		}
+		ioStat[device] = stat
+	}
+	return ioStat, nil
+}
+
+func (m *manager) GetPidsStats(absCgroupPath string) (*common.PidsStats, error) {
+	current, err := fscommon.GetCgroupParamUint(absCgroupPath, "pids.current")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pids.current, %v", err)
+	}
+
+	max, err := common.GetCgroupParamInt(absCgroupPath, "pids.max")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pids.max, %v", err)
+	}
+
+	return &common.PidsStats{Current: current, Max: max}, nil
+}
+
+// GetHugetlb parses hugetlb.<pagesize>.{current,max,events} of all page sizes supported by the kernel,
+// and failures are counted as max events since cgroupv2 has no failcnt
+func (m *manager) GetHugetlb(absCgroupPath string) (map[string]*common.HugetlbStats, error) {
+	pageSizes, err := common.GetHugetlbPageSizes(absCgroupPath, "max")
+	if err != nil {
+		return nil, err
+	}
+
+	hugetlbStats := make(map[string]*common.HugetlbStats, len(pageSizes))
+	for _, pageSize := range pageSizes {
+		stats := &common.HugetlbStats{}
+
+		currentFile := common.GetHugetlbFileName(pageSize, "current")
+		if stats.Usage, err = fscommon.GetCgroupParamUint(absCgroupPath, currentFile); err != nil {
+			return nil, fmt.Errorf("failed to parse %s, %v", currentFile, err)
+		}
+
+		maxFile := common.GetHugetlbFileName(pageSize, "max")
+		if stats.Limit, err = common.GetCgroupParamInt(absCgroupPath, maxFile); err != nil {
+			return nil, fmt.Errorf("failed to parse %s, %v", maxFile, err)
+		}
+
+		eventsFile := common.GetHugetlbFileName(pageSize, "events")
+		if stats.Failcnt, err = fscommon.GetValueByKey(absCgroupPath, eventsFile, "max"); err != nil {
+			return nil, fmt.Errorf("failed to parse %s, %v", eventsFile, err)
+		}
+		hugetlbStats[pageSize] = stats
+	}
+	return hugetlbStats, nil
+}
+
+// GetPressure parses <resource>.pressure, which exists if the kernel is built with PSI
+func (m *manager) GetPressure(absCgroupPath, resource string) (*common.PressureStats, error) {
+	return common.GetCgroupPressure(absCgroupPath, resource)
+}
+
 // GetPids return pids in current cgroup
 func (m *manager) GetPids(absCgroupPath string) ([]string, error) {
 	pids, err := libcgroups.GetAllPids(absCgroupPath)
@@ -313,6 +591,55 @@ func (m *manager) GetTasks(absCgroupPath string) ([]string, error) {
 	return tasks, err
 }
 
+// ReclaimMemory writes to memory.reclaim to trigger proactive reclaim, and
+// a partial reclaim (EAGAIN) is not treated as an error.
+func (m *manager) ReclaimMemory(absCgroupPath string, nbytes int64) (int64, error) {
+	if nbytes <= 0 {
+		return 0, nil
+	}
+
+	before, err := fscommon.GetCgroupParamUint(absCgroupPath, "memory.current")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get memory.current of %s: %v", absCgroupPath, err)
+	}
+
+	err = libcgroups.WriteFile(absCgroupPath, "memory.reclaim", strconv.FormatInt(nbytes, 10))
+	if err != nil && !errors.Is(err, unix.EAGAIN) {
+		return 0, fmt.Errorf("failed to write memory.reclaim of %s: %v", absCgroupPath, err)
+	}
+
+	after, err := fscommon.GetCgroupParamUint(absCgroupPath, "memory.current")
+	if err != nil || after >= before {
+		return 0, nil
+	}
+
+	klog.Infof("[CgroupV2] reclaim memory successfully, cgroupPath: %s, target: %d, reclaimed: %d", absCgroupPath, nbytes, before-after)
+	return int64(before - after), nil
+}
+
+// DropFileCache sets memory.swap.max to 0 during the reclaim, with which
+// the kernel can't reclaim any anonymous page of the cgroup.
+func (m *manager) DropFileCache(absCgroupPath string, nbytes int64) (int64, error) {
+	swapMax, err := fscommon.GetCgroupParamString(absCgroupPath, "memory.swap.max")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("failed to get memory.swap.max of %s: %v", absCgroupPath, err)
+	}
+
+	// memory.swap.max doesn't exist without swap accounting, and no anonymous page can be swapped out then
+	if err == nil && swapMax != "0" {
+		if err := libcgroups.WriteFile(absCgroupPath, "memory.swap.max", "0"); err != nil {
+			return 0, fmt.Errorf("failed to disable memory.swap.max of %s: %v", absCgroupPath, err)
+		}
+		defer func() {
+			if err := libcgroups.WriteFile(absCgroupPath, "memory.swap.max", swapMax); err != nil {
+				klog.Errorf("[CgroupV2] failed to restore memory.swap.max to %s, cgroupPath: %s, err: %v", swapMax, absCgroupPath, err)
+			}
+		}()
+	}
+
+	return m.ReclaimMemory(absCgroupPath, nbytes)
+}
+
 func numToStr(value int64) (ret string) {
 	switch {
 	case value == 0:
@@ -325,3 +652,19 @@ func numToStr(value int64) (ret string) {
 
 	return ret
 }
+
+// protectionToStr formats memory.min or memory.low, and zero value means no protection
+func protectionToStr(value int64) string {
+	if value == 0 {
+		return "0"
+	}
+	return numToStr(value)
+}
+
+// ioMaxValue formats a limit of io.max, and zero value means unlimited
+func ioMaxValue(value uint64) string {
+	if value == 0 {
+		return "max"
+	}
+	return strconv.FormatUint(value, 10)
+}
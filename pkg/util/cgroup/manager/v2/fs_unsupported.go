@@ -48,6 +48,10 @@ func (m *unsupportedManager) ApplyNetCls(_ string, _ *common.NetClsData) error {
 	return fmt.Errorf("unsupported manager v2")
 }
 
+func (m *unsupportedManager) ApplyIO(_ string, _ *common.IOData) error {
+	return fmt.Errorf("unsupported manager v2")
+}
+
 func (m *unsupportedManager) GetMemory(_ string) (*common.MemoryStats, error) {
 	return nil, fmt.Errorf("unsupported manager v2")
 }
@@ -64,6 +68,14 @@ func (m *unsupportedManager) GetMetrics(_ string, _ map[string]struct{}) (*commo
 	return nil, fmt.Errorf("unsupported manager v2")
 }
 
+func (m *unsupportedManager) GetNumaStat(_ string) (map[int]*common.MemoryNumaMetrics, error) {
+	return nil, fmt.Errorf("unsupported manager v2")
+}
+
+func (m *unsupportedManager) GetIOStat(_ string) (map[string]*common.IOStat, error) {
+	return nil, fmt.Errorf("unsupported manager v2")
+}
+
 func (m *unsupportedManager) GetPids(_ string) ([]string, error) {
 	return nil, fmt.Errorf("unsupported manager v2")
 }
@@ -71,3 +83,31 @@ func (m *unsupportedManager) GetPids(_ string) ([]string, error) {
 func (m *unsupportedManager) GetTasks(_ string) ([]string, error) {
 	return nil, fmt.Errorf("unsupported manager v2")
 }
+
+func (m *unsupportedManager) ReclaimMemory(_ string, _ int64) (int64, error) {
+	return 0, fmt.Errorf("unsupported manager v2")
+}
+
+func (m *unsupportedManager) DropFileCache(_ string, _ int64) (int64, error) {
+	return 0, fmt.Errorf("unsupported manager v2")
+}
+
+func (m *unsupportedManager) ApplyPids(_ string, _ *common.PidsData) error {
+	return fmt.Errorf("unsupported manager v2")
+}
+
+func (m *unsupportedManager) ApplyHugetlb(_ string, _ *common.HugetlbData) error {
+	return fmt.Errorf("unsupported manager v2")
+}
+
+func (m *unsupportedManager) GetPidsStats(_ string) (*common.PidsStats, error) {
+	return nil, fmt.Errorf("unsupported manager v2")
+}
+
+func (m *unsupportedManager) GetHugetlb(_ string) (map[string]*common.HugetlbStats, error) {
+	return nil, fmt.Errorf("unsupported manager v2")
+}
+
+func (m *unsupportedManager) GetPressure(_, _ string) (*common.PressureStats, error) {
+	return nil, fmt.Errorf("unsupported manager v2")
+}
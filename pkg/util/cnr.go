@@ -17,6 +17,8 @@ limitations under the License.
 package util
 
 import (
+	"sort"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kubernetes/pkg/apis/core/helper"
@@ -172,3 +174,78 @@ func DeleteCNRTaint(taints []*apis.Taint, taintToDelete *apis.Taint) ([]*apis.Ta
 func MatchCNRTaint(taintToMatch, taint *apis.Taint) bool {
 	return taint.Key == taintToMatch.Key && taint.Effect == taintToMatch.Effect
 }
+
+// MergeTopologyStatus merges src topology status into dst, sockets and numa nodes are
+// matched by their ids, resources of the same name in src will override those in dst,
+// and allocations are appended, since different reporters may report the same numa.
+func MergeTopologyStatus(src, dst *apis.TopologyStatus) {
+	if src == nil || dst == nil {
+		return
+	}
+
+	for _, srcSocket := range src.Sockets {
+		if srcSocket == nil {
+			continue
+		}
+
+		var dstSocket *apis.SocketStatus
+		for _, socket := range dst.Sockets {
+			if socket != nil && socket.SocketID == srcSocket.SocketID {
+				dstSocket = socket
+				break
+			}
+		}
+		if dstSocket == nil {
+			dstSocket = &apis.SocketStatus{SocketID: srcSocket.SocketID}
+			dst.Sockets = append(dst.Sockets, dstSocket)
+		}
+
+		for _, srcNuma := range srcSocket.Numas {
+			if srcNuma == nil {
+				continue
+			}
+
+			var dstNuma *apis.NumaStatus
+			for _, numa := range dstSocket.Numas {
+				if numa != nil && numa.NumaID == srcNuma.NumaID {
+					dstNuma = numa
+					break
+				}
+			}
+			if dstNuma == nil {
+				dstNuma = &apis.NumaStatus{NumaID: srcNuma.NumaID}
+				dstSocket.Numas = append(dstSocket.Numas, dstNuma)
+			}
+
+			dstNuma.Allocatable = mergeResourceList(srcNuma.Allocatable, dstNuma.Allocatable)
+			dstNuma.Capacity = mergeResourceList(srcNuma.Capacity, dstNuma.Capacity)
+			dstNuma.Allocations = append(dstNuma.Allocations, srcNuma.Allocations...)
+		}
+
+		sort.SliceStable(dstSocket.Numas, func(i, j int) bool {
+			return dstSocket.Numas[i].NumaID < dstSocket.Numas[j].NumaID
+		})
+	}
+
+	sort.SliceStable(dst.Sockets, func(i, j int) bool {
+		return dst.Sockets[i].SocketID < dst.Sockets[j].SocketID
+	})
+}
+
+// mergeResourceList returns a resource list with resources in src overriding those in dst
+func mergeResourceList(src, dst *corev1.ResourceList) *corev1.ResourceList {
+	if src == nil {
+		return dst
+	}
+
+	res := make(corev1.ResourceList)
+	if dst != nil {
+		for name, quantity := range *dst {
+			res[name] = quantity.DeepCopy()
+		}
+	}
+	for name, quantity := range *src {
+		res[name] = quantity.DeepCopy()
+	}
+	return &res
+}
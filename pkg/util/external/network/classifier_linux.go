@@ -0,0 +1,160 @@
+//go:build linux
+// +build linux
+
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+)
+
+const (
+	bpfProgName = "prog"
+	bpfMapName  = "classid_map"
+
+	// bpfMapMaxEntries is the max number of cgroups to be classified
+	bpfMapMaxEntries = 65536
+
+	// skbTCClassIDOffset is the offset of tc_classid in struct __sk_buff
+	skbTCClassIDOffset = 72
+
+	tcActOK     = 0
+	tcActUnspec = -1
+)
+
+// bpfCgroupClassifier implements CgroupClassifier by a tc BPF program, which looks up the
+// cgroup id of the socket in a hash map and sets tc_classid of the packet with the value.
+// Only egress packets are classified, since sockets are unknown for ingress packets on tc, and
+// packets routed from pod network namespaces can't be classified either (see CgroupClassifier).
+type bpfCgroupClassifier struct {
+	pinPath  string
+	classMap *ebpf.Map
+}
+
+// NewBPFCgroupClassifier returns a CgroupClassifier pinned in the given path of bpffs.
+func NewBPFCgroupClassifier(pinPath string) CgroupClassifier {
+	return &bpfCgroupClassifier{
+		pinPath: pinPath,
+	}
+}
+
+// Load reuses the pinned map if it exists so that classifications survive restarts,
+// and the program is always reloaded and pinned again.
+func (c *bpfCgroupClassifier) Load() (string, error) {
+	if err := os.MkdirAll(c.pinPath, 0o755); err != nil {
+		return "", fmt.Errorf("create pin path %s failed with error: %v", c.pinPath, err)
+	}
+
+	mapPath := filepath.Join(c.pinPath, bpfMapName)
+	classMap, err := ebpf.LoadPinnedMap(mapPath, nil)
+	if err != nil {
+		classMap, err = ebpf.NewMap(&ebpf.MapSpec{
+			Name:       bpfMapName,
+			Type:       ebpf.Hash,
+			KeySize:    8,
+			ValueSize:  4,
+			MaxEntries: bpfMapMaxEntries,
+		})
+		if err != nil {
+			return "", fmt.Errorf("create bpf map failed with error: %v", err)
+		}
+
+		if err := classMap.Pin(mapPath); err != nil {
+			_ = classMap.Close()
+			return "", fmt.Errorf("pin bpf map failed with error: %v", err)
+		}
+	}
+
+	prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+		Name:         bpfProgName,
+		Type:         ebpf.SchedCLS,
+		Instructions: generateClassifierInstructions(classMap.FD()),
+		License:      "GPL",
+	})
+	if err != nil {
+		_ = classMap.Close()
+		return "", fmt.Errorf("load bpf program failed with error: %v", err)
+	}
+	defer prog.Close()
+
+	progPath := filepath.Join(c.pinPath, bpfProgName)
+	if err := os.Remove(progPath); err != nil && !os.IsNotExist(err) {
+		_ = classMap.Close()
+		return "", fmt.Errorf("remove pinned bpf program failed with error: %v", err)
+	}
+
+	if err := prog.Pin(progPath); err != nil {
+		_ = classMap.Close()
+		return "", fmt.Errorf("pin bpf program failed with error: %v", err)
+	}
+
+	c.classMap = classMap
+	return progPath, nil
+}
+
+func (c *bpfCgroupClassifier) SetClassID(cgroupID uint64, classID uint32) error {
+	if c.classMap == nil {
+		return fmt.Errorf("classifier is not loaded")
+	}
+	return c.classMap.Update(cgroupID, classID, ebpf.UpdateAny)
+}
+
+func (c *bpfCgroupClassifier) DeleteClassID(cgroupID uint64) error {
+	if c.classMap == nil {
+		return fmt.Errorf("classifier is not loaded")
+	}
+
+	if err := c.classMap.Delete(cgroupID); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return err
+	}
+	return nil
+}
+
+// generateClassifierInstructions generates the program equivalent to
+//
+//	__u64 cgroup_id = bpf_skb_cgroup_id(skb);
+//	__u32 *class_id = bpf_map_lookup_elem(&classid_map, &cgroup_id);
+//	if (!class_id)
+//		return TC_ACT_UNSPEC;
+//	skb->tc_classid = *class_id;
+//	return TC_ACT_OK;
+//
+// and it works as a direct-action classifier, so that TC_ACT_UNSPEC lets
+// packets fall through to the default class of htb.
+func generateClassifierInstructions(mapFD int) asm.Instructions {
+	return asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+		asm.FnSkbCgroupId.Call(),
+		asm.StoreMem(asm.RFP, -8, asm.R0, asm.DWord),
+		asm.LoadMapPtr(asm.R1, mapFD),
+		asm.Mov.Reg(asm.R2, asm.RFP),
+		asm.Add.Imm(asm.R2, -8),
+		asm.FnMapLookupElem.Call(),
+		asm.JEq.Imm(asm.R0, 0, "miss"),
+		asm.LoadMem(asm.R1, asm.R0, 0, asm.Word),
+		asm.StoreMem(asm.R6, skbTCClassIDOffset, asm.R1, asm.Word),
+		asm.Mov.Imm(asm.R0, tcActOK),
+		asm.Return(),
+		asm.Mov.Imm(asm.R0, tcActUnspec).Sym("miss"),
+		asm.Return(),
+	}
+}
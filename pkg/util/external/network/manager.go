@@ -15,11 +15,91 @@
 package network
 
 import (
+	"time"
+
 	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
 )
 
+const (
+	// DefaultTCRootHandle is the major number of the root htb qdisc on NICs
+	DefaultTCRootHandle uint16 = 1
+	// DefaultBPFPinPath is the directory to pin the BPF classifier and its map in bpffs
+	DefaultBPFPinPath = "/sys/fs/bpf/katalyst/net_cls"
+	// DefaultNICSpeed is the speed (in Mbps) of NICs whose speed is unknown
+	DefaultNICSpeed uint64 = 10000
+
+	// nicRevalidationPeriod is the period to check qdiscs and filters of NICs again, since
+	// they may be changed by others or lost when links are recreated
+	nicRevalidationPeriod = time.Minute
+)
+
 // NetworkManager provides methods that control network resources.
 type NetworkManager interface {
 	ApplyNetClass(podUID, containerId string, data *common.NetClsData) error
 	ClearNetClass(cgroupID uint64) error
 }
+
+// TrafficController programs tc qdiscs, classes and filters on NICs.
+type TrafficController interface {
+	// EnsureHTBQdisc ensures the root qdisc of the NIC is htb with the given handle,
+	// and unclassified packets are sent directly without being shaped. It returns true
+	// if the root qdisc is replaced, in which case all classes under it are flushed.
+	EnsureHTBQdisc(ifName string, handle uint16) (bool, error)
+	// EnsureHTBClass creates or updates the htb class under the root qdisc, and rate and ceil are in Mbps.
+	EnsureHTBClass(ifName string, classID uint32, rate, ceil uint64, priority uint32) error
+	// EnsureBPFFilter attaches the pinned BPF program as a direct-action classifier of the qdisc.
+	EnsureBPFFilter(ifName string, parent uint16, progPath string) error
+}
+
+// CgroupClassifier classifies packets into tc classes by the cgroup v2 ids of their sockets,
+// so it only works for cgroup v2, and net_cls is used for cgroup v1 instead. Notice that the
+// classifier runs on NICs of the host network namespace, so only packets still carrying their
+// sockets there are classified, e.g. packets of host-network pods; packets routed from pod
+// network namespaces (e.g. through veth) have been orphaned from sockets when crossing namespaces,
+// and they fall through to the default class without being shaped. Therefore, callers should only
+// apply net classes of host-network pods, rather than claiming other pods to be shaped.
+type CgroupClassifier interface {
+	// Load prepares the classifier and returns the path of its pinned BPF program.
+	Load() (string, error)
+	// SetClassID makes packets of the cgroup classified into the class.
+	SetClassID(cgroupID uint64, classID uint32) error
+	// DeleteClassID removes the classification of the cgroup, and it's a no-op if not existed.
+	DeleteClassID(cgroupID uint64) error
+}
+
+// TCClassConfig describes the htb class of a net class id, and rates are
+// percentages of the NIC speed.
+type TCClassConfig struct {
+	RatePercent uint64
+	CeilPercent uint64
+	// Priority is the priority of the htb class, and classes with lower priority are served first.
+	Priority uint32
+}
+
+// TCConfig describes how tc is programmed on NICs.
+type TCConfig struct {
+	// NICs are names of NICs to be shaped, and tc shaping is disabled if it's empty, since
+	// root qdiscs of NICs are replaced by htb ones and it should be enabled explicitly.
+	NICs []string
+	// RootHandle is the major number of the root htb qdisc, and net class ids are
+	// used as minor numbers of htb classes.
+	RootHandle uint16
+	// Classes are htb class configs keyed by net class ids (i.e. each QoS level has its own class),
+	// and DefaultClass is used for net class ids without configs.
+	Classes      map[uint32]TCClassConfig
+	DefaultClass TCClassConfig
+}
+
+// NewDefaultTCConfig returns a TCConfig without any NIC to be shaped, and each net class
+// is guaranteed with 10% of bandwidth and is able to borrow up to the NIC speed.
+func NewDefaultTCConfig() TCConfig {
+	return TCConfig{
+		RootHandle: DefaultTCRootHandle,
+		Classes:    make(map[uint32]TCClassConfig),
+		DefaultClass: TCClassConfig{
+			RatePercent: 10,
+			CeilPercent: 100,
+			Priority:    4,
+		},
+	}
+}
@@ -18,26 +18,258 @@
 package network
 
 import (
-	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
 
-type defaultNetworkManager struct{}
+// defaultNetworkManager shapes egress traffic of containers by tc: an htb qdisc is set up as
+// the root qdisc of each NIC with an htb class for each net class id, and packets are classified
+// into classes by a BPF classifier according to the cgroup ids of their sockets. It only shapes
+// NICs configured explicitly, and it does nothing if no NIC is configured.
+type defaultNetworkManager struct {
+	mutex sync.Mutex
+
+	config     TCConfig
+	tc         TrafficController
+	classifier CgroupClassifier
+	getNICs    func() ([]machine.InterfaceInfo, error)
+	clock      clock.PassiveClock
+
+	// progPath is the path of the pinned BPF program, and it's empty before the classifier is loaded;
+	// ensuredNICs records NICs along with the time they're validated to be set up, and ensuredClasses
+	// records classes that have been set up on NICs.
+	progPath       string
+	ensuredNICs    map[string]time.Time
+	ensuredClasses map[string]map[uint32]bool
+}
 
-// NewNetworkManager returns a defaultNetworkManager.
+// NewNetworkManager returns a defaultNetworkManager without any NIC to be shaped.
 func NewNetworkManager() NetworkManager {
-	return &defaultNetworkManager{}
+	return NewNetworkManagerWithConfig(NewDefaultTCConfig())
+}
+
+// NewNetworkManagerWithConfig returns a defaultNetworkManager with the given tc config.
+func NewNetworkManagerWithConfig(config TCConfig) NetworkManager {
+	return NewTCNetworkManager(config, NewCommandTrafficController(),
+		NewBPFCgroupClassifier(DefaultBPFPinPath), getEnabledNICs)
+}
+
+// NewTCNetworkManager returns a defaultNetworkManager with the given tc config and implementations,
+// and getNICs is used to discover NICs along with their speeds.
+func NewTCNetworkManager(config TCConfig, tc TrafficController, classifier CgroupClassifier,
+	getNICs func() ([]machine.InterfaceInfo, error)) NetworkManager {
+	return &defaultNetworkManager{
+		config:         config,
+		tc:             tc,
+		classifier:     classifier,
+		getNICs:        getNICs,
+		clock:          clock.RealClock{},
+		ensuredNICs:    make(map[string]time.Time),
+		ensuredClasses: make(map[string]map[uint32]bool),
+	}
 }
 
 // ApplyNetClass applies the net class config for a container.
-func (*defaultNetworkManager) ApplyNetClass(podUID, containerId string, data *common.NetClsData) error {
-	// TODO: implement traffic tagging by using eBPF
-	return errors.New("not implemented yet")
+func (m *defaultNetworkManager) ApplyNetClass(podUID, containerId string, data *common.NetClsData) error {
+	if data == nil {
+		return fmt.Errorf("nil net class data")
+	} else if len(m.config.NICs) == 0 {
+		klog.V(4).Infof("[network] no NIC is configured to be shaped, skip applying net class of pod: %s, container: %s",
+			podUID, containerId)
+		return nil
+	} else if data.CgroupID == 0 {
+		return fmt.Errorf("cgroup v2 id of pod: %s, container: %s is required", podUID, containerId)
+	}
+
+	classID, err := m.getTCClassID(data.ClassID)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.ensureClassifier(); err != nil {
+		return err
+	}
+
+	nics, err := m.getTargetNICs()
+	if err != nil {
+		return err
+	}
+
+	for _, nic := range nics {
+		if err := m.ensureNIC(nic.Iface); err != nil {
+			return err
+		}
+
+		if err := m.ensureClass(nic, data.ClassID, classID); err != nil {
+			return err
+		}
+	}
+
+	if err := m.classifier.SetClassID(data.CgroupID, classID); err != nil {
+		return fmt.Errorf("set class id %x for cgroup %d failed with error: %v", classID, data.CgroupID, err)
+	}
+	return nil
 }
 
 // ClearNetClass clears the net class config for a container.
-func (*defaultNetworkManager) ClearNetClass(cgroupID uint64) error {
-	// TODO: clear the eBPF map when a pod is removed
-	return errors.New("not implemented yet")
+func (m *defaultNetworkManager) ClearNetClass(cgroupID uint64) error {
+	if len(m.config.NICs) == 0 {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.ensureClassifier(); err != nil {
+		return err
+	}
+
+	if err := m.classifier.DeleteClassID(cgroupID); err != nil {
+		return fmt.Errorf("delete class id for cgroup %d failed with error: %v", cgroupID, err)
+	}
+	return nil
+}
+
+// getTCClassID returns the handle of the htb class for the net class id, and the
+// net class id is used as the minor number under the root qdisc.
+func (m *defaultNetworkManager) getTCClassID(netClassID uint32) (uint32, error) {
+	minor := netClassID & 0xffff
+	if minor == 0 {
+		return 0, fmt.Errorf("invalid net class id %d", netClassID)
+	}
+	return uint32(m.config.RootHandle)<<16 | minor, nil
+}
+
+// ensureClassifier loads the classifier only once
+func (m *defaultNetworkManager) ensureClassifier() error {
+	if m.progPath != "" {
+		return nil
+	}
+
+	progPath, err := m.classifier.Load()
+	if err != nil {
+		return fmt.Errorf("load cgroup classifier failed with error: %v", err)
+	}
+
+	m.progPath = progPath
+	klog.Infof("[network] load cgroup classifier successfully, prog: %s", progPath)
+	return nil
+}
+
+// getTargetNICs returns the NICs to be shaped
+func (m *defaultNetworkManager) getTargetNICs() ([]machine.InterfaceInfo, error) {
+	nics, err := m.getNICs()
+	if err != nil {
+		return nil, fmt.Errorf("get NICs failed with error: %v", err)
+	}
+
+	nicsByName := make(map[string]machine.InterfaceInfo, len(nics))
+	for _, nic := range nics {
+		nicsByName[nic.Iface] = nic
+	}
+
+	targetNICs := make([]machine.InterfaceInfo, 0, len(m.config.NICs))
+	for _, name := range m.config.NICs {
+		nic, ok := nicsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("NIC %s is not found", name)
+		}
+		targetNICs = append(targetNICs, nic)
+	}
+	return targetNICs, nil
+}
+
+// ensureNIC sets up the root htb qdisc and the BPF classifier of the NIC, and they're validated
+// again periodically; classes of the NIC are set up again if the root qdisc is replaced.
+func (m *defaultNetworkManager) ensureNIC(ifName string) error {
+	if validatedTime, ok := m.ensuredNICs[ifName]; ok && m.clock.Since(validatedTime) < nicRevalidationPeriod {
+		return nil
+	}
+
+	replaced, err := m.tc.EnsureHTBQdisc(ifName, m.config.RootHandle)
+	if err != nil {
+		return fmt.Errorf("ensure htb qdisc on %s failed with error: %v", ifName, err)
+	}
+
+	if replaced {
+		delete(m.ensuredClasses, ifName)
+	}
+
+	if err := m.tc.EnsureBPFFilter(ifName, m.config.RootHandle, m.progPath); err != nil {
+		return fmt.Errorf("ensure bpf filter on %s failed with error: %v", ifName, err)
+	}
+
+	m.ensuredNICs[ifName] = m.clock.Now()
+	klog.V(4).Infof("[network] set up tc on %s successfully, root qdisc replaced: %v", ifName, replaced)
+	return nil
+}
+
+// ensureClass sets up the htb class of the net class id on the NIC
+func (m *defaultNetworkManager) ensureClass(nic machine.InterfaceInfo, netClassID, classID uint32) error {
+	if m.ensuredClasses[nic.Iface][classID] {
+		return nil
+	}
+
+	classConfig, ok := m.config.Classes[netClassID]
+	if !ok {
+		classConfig = m.config.DefaultClass
+	}
+
+	speed := DefaultNICSpeed
+	if nic.Speed > 0 {
+		speed = uint64(nic.Speed)
+	}
+
+	rate, ceil := getClassRates(speed, classConfig)
+	if err := m.tc.EnsureHTBClass(nic.Iface, classID, rate, ceil, classConfig.Priority); err != nil {
+		return fmt.Errorf("ensure htb class %x on %s failed with error: %v", classID, nic.Iface, err)
+	}
+
+	if m.ensuredClasses[nic.Iface] == nil {
+		m.ensuredClasses[nic.Iface] = make(map[uint32]bool)
+	}
+	m.ensuredClasses[nic.Iface][classID] = true
+	klog.Infof("[network] set up htb class %x on %s successfully, rate: %dMbit, ceil: %dMbit", classID, nic.Iface, rate, ceil)
+	return nil
+}
+
+// getClassRates returns rate and ceil (in Mbps) of the class, and
+// both of them are at least 1Mbps as required by htb
+func getClassRates(speed uint64, classConfig TCClassConfig) (uint64, uint64) {
+	rate := speed * classConfig.RatePercent / 100
+	if rate == 0 {
+		rate = 1
+	}
+
+	ceil := speed * classConfig.CeilPercent / 100
+	if ceil < rate {
+		ceil = rate
+	}
+	return rate, ceil
+}
+
+// getEnabledNICs returns all enabled NICs of the machine
+func getEnabledNICs() ([]machine.InterfaceInfo, error) {
+	networkInfo, err := machine.GetExtraNetworkInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	var nics []machine.InterfaceInfo
+	for _, nic := range networkInfo.Interface {
+		if nic.Enable {
+			nics = append(nics, nic)
+		}
+	}
+	return nics, nil
 }
@@ -18,11 +18,16 @@
 package network
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	testingclock "k8s.io/utils/clock/testing"
 
 	"github.com/kubewharf/katalyst-core/pkg/util/cgroup/common"
+	"github.com/kubewharf/katalyst-core/pkg/util/machine"
 )
 
 var (
@@ -32,6 +37,81 @@ var (
 	cgID        uint64 = 1
 )
 
+type fakeTrafficController struct {
+	qdiscs  map[string]uint16
+	classes map[string]map[uint32]string
+	filters map[string]string
+	calls   int
+}
+
+func newFakeTrafficController() *fakeTrafficController {
+	return &fakeTrafficController{
+		qdiscs:  make(map[string]uint16),
+		classes: make(map[string]map[uint32]string),
+		filters: make(map[string]string),
+	}
+}
+
+func (f *fakeTrafficController) EnsureHTBQdisc(ifName string, handle uint16) (bool, error) {
+	f.calls++
+	if existing, ok := f.qdiscs[ifName]; ok && existing == handle {
+		return false, nil
+	}
+
+	f.qdiscs[ifName] = handle
+	delete(f.classes, ifName)
+	return true, nil
+}
+
+func (f *fakeTrafficController) EnsureHTBClass(ifName string, classID uint32, rate, ceil uint64, priority uint32) error {
+	f.calls++
+	if f.classes[ifName] == nil {
+		f.classes[ifName] = make(map[uint32]string)
+	}
+	f.classes[ifName][classID] = fmt.Sprintf("rate %d ceil %d prio %d", rate, ceil, priority)
+	return nil
+}
+
+func (f *fakeTrafficController) EnsureBPFFilter(ifName string, parent uint16, progPath string) error {
+	f.calls++
+	f.filters[ifName] = progPath
+	return nil
+}
+
+type fakeCgroupClassifier struct {
+	loaded   int
+	classIDs map[uint64]uint32
+}
+
+func (f *fakeCgroupClassifier) Load() (string, error) {
+	f.loaded++
+	return "/fake/bpf/prog", nil
+}
+
+func (f *fakeCgroupClassifier) SetClassID(cgroupID uint64, classID uint32) error {
+	f.classIDs[cgroupID] = classID
+	return nil
+}
+
+func (f *fakeCgroupClassifier) DeleteClassID(cgroupID uint64) error {
+	delete(f.classIDs, cgroupID)
+	return nil
+}
+
+func newFakeNetworkManager(config TCConfig) (*defaultNetworkManager, *fakeTrafficController, *fakeCgroupClassifier) {
+	tc := newFakeTrafficController()
+	classifier := &fakeCgroupClassifier{classIDs: make(map[uint64]uint32)}
+	getNICs := func() ([]machine.InterfaceInfo, error) {
+		return []machine.InterfaceInfo{
+			{Iface: "eth0", Speed: 25000, Enable: true},
+			{Iface: "eth1", Speed: -1, Enable: true},
+		}, nil
+	}
+	manager := NewTCNetworkManager(config, tc, classifier, getNICs).(*defaultNetworkManager)
+	manager.clock = testingclock.NewFakeClock(time.Now())
+	return manager, tc, classifier
+}
+
 func TestNewDefaultManager(t *testing.T) {
 	defaultManager := NewNetworkManager()
 	assert.NotNil(t, defaultManager)
@@ -40,11 +120,49 @@ func TestNewDefaultManager(t *testing.T) {
 }
 
 func TestApplyNetClass(t *testing.T) {
-	defaultManager := NewNetworkManager()
-	assert.NotNil(t, defaultManager)
+	config := NewDefaultTCConfig()
+	config.NICs = []string{"eth0", "eth1"}
+	config.Classes[2] = TCClassConfig{RatePercent: 50, CeilPercent: 80, Priority: 0}
+	manager, tc, classifier := newFakeNetworkManager(config)
+
+	err := manager.ApplyNetClass(podUID, containerID, &common.NetClsData{
+		ClassID:  classID,
+		CgroupID: cgID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, classifier.loaded)
+	assert.Equal(t, map[uint64]uint32{cgID: 0x10001}, classifier.classIDs)
+	assert.Equal(t, map[string]uint16{"eth0": 1, "eth1": 1}, tc.qdiscs)
+	assert.Equal(t, map[string]string{"eth0": "/fake/bpf/prog", "eth1": "/fake/bpf/prog"}, tc.filters)
+	assert.Equal(t, map[string]map[uint32]string{
+		"eth0": {0x10001: "rate 2500 ceil 25000 prio 4"},
+		"eth1": {0x10001: "rate 1000 ceil 10000 prio 4"},
+	}, tc.classes)
 
-	err := defaultManager.ApplyNetClass(podUID, containerID, &common.NetClsData{
+	// tc is programmed only once for the same class
+	calls := tc.calls
+	err = manager.ApplyNetClass(podUID, "container-id-2", &common.NetClsData{
 		ClassID:  classID,
+		CgroupID: 2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, calls, tc.calls)
+	assert.Equal(t, 1, classifier.loaded)
+
+	err = manager.ApplyNetClass(podUID, "container-id-3", &common.NetClsData{
+		ClassID:  2,
+		CgroupID: 3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "rate 12500 ceil 20000 prio 0", tc.classes["eth0"][0x10002])
+	assert.Equal(t, uint32(0x10002), classifier.classIDs[3])
+
+	err = manager.ApplyNetClass(podUID, containerID, &common.NetClsData{
+		ClassID: classID,
+	})
+	assert.Error(t, err)
+
+	err = manager.ApplyNetClass(podUID, containerID, &common.NetClsData{
 		CgroupID: cgID,
 	})
 	assert.Error(t, err)
@@ -52,12 +170,125 @@ func TestApplyNetClass(t *testing.T) {
 	return
 }
 
-func TestClearNetClass(t *testing.T) {
-	defaultManager := NewNetworkManager()
-	assert.NotNil(t, defaultManager)
+func TestApplyNetClassWithConfiguredNICs(t *testing.T) {
+	config := NewDefaultTCConfig()
+	config.NICs = []string{"eth1"}
+	manager, tc, _ := newFakeNetworkManager(config)
+
+	err := manager.ApplyNetClass(podUID, containerID, &common.NetClsData{
+		ClassID:  classID,
+		CgroupID: cgID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint16{"eth1": 1}, tc.qdiscs)
 
-	err := defaultManager.ClearNetClass(cgID)
+	config.NICs = []string{"eth2"}
+	manager, _, _ = newFakeNetworkManager(config)
+	err = manager.ApplyNetClass(podUID, containerID, &common.NetClsData{
+		ClassID:  classID,
+		CgroupID: cgID,
+	})
 	assert.Error(t, err)
+}
+
+func TestApplyNetClassWithoutNICs(t *testing.T) {
+	manager, tc, classifier := newFakeNetworkManager(NewDefaultTCConfig())
+
+	err := manager.ApplyNetClass(podUID, containerID, &common.NetClsData{
+		ClassID:  classID,
+		CgroupID: cgID,
+	})
+	require.NoError(t, err)
+	require.NoError(t, manager.ClearNetClass(cgID))
+	assert.Equal(t, 0, tc.calls)
+	assert.Equal(t, 0, classifier.loaded)
+}
+
+func TestRevalidateNIC(t *testing.T) {
+	config := NewDefaultTCConfig()
+	config.NICs = []string{"eth0"}
+	manager, tc, _ := newFakeNetworkManager(config)
+	fakeClock := manager.clock.(*testingclock.FakeClock)
+
+	data := &common.NetClsData{ClassID: classID, CgroupID: cgID}
+	require.NoError(t, manager.ApplyNetClass(podUID, containerID, data))
+	assert.Contains(t, tc.classes["eth0"], uint32(0x10001))
+
+	// the root qdisc is lost (e.g. the link is recreated), and it isn't noticed until revalidation
+	delete(tc.qdiscs, "eth0")
+	delete(tc.classes, "eth0")
+	require.NoError(t, manager.ApplyNetClass(podUID, containerID, data))
+	assert.NotContains(t, tc.qdiscs, "eth0")
+
+	fakeClock.Step(nicRevalidationPeriod)
+	require.NoError(t, manager.ApplyNetClass(podUID, containerID, data))
+	assert.Equal(t, map[string]uint16{"eth0": 1}, tc.qdiscs)
+	assert.Contains(t, tc.classes["eth0"], uint32(0x10001))
+
+	// classes are kept if the root qdisc is still the expected one
+	calls := tc.calls
+	fakeClock.Step(nicRevalidationPeriod)
+	require.NoError(t, manager.ApplyNetClass(podUID, containerID, data))
+	assert.Equal(t, calls+2, tc.calls)
+}
+
+func TestClearNetClass(t *testing.T) {
+	config := NewDefaultTCConfig()
+	config.NICs = []string{"eth0"}
+	manager, _, classifier := newFakeNetworkManager(config)
+
+	err := manager.ApplyNetClass(podUID, containerID, &common.NetClsData{
+		ClassID:  classID,
+		CgroupID: cgID,
+	})
+	require.NoError(t, err)
+
+	err = manager.ClearNetClass(cgID)
+	assert.NoError(t, err)
+	assert.Empty(t, classifier.classIDs)
 
 	return
 }
+
+func TestCommandTrafficController(t *testing.T) {
+	var commands []string
+	qdisc := "qdisc noqueue 0: root refcnt 2"
+	tc := &commandTrafficController{
+		run: func(args ...string) (string, error) {
+			commands = append(commands, fmt.Sprint(args))
+			return qdisc, nil
+		},
+	}
+
+	replaced, err := tc.EnsureHTBQdisc("eth0", 1)
+	require.NoError(t, err)
+	assert.True(t, replaced)
+	require.NoError(t, tc.EnsureHTBClass("eth0", 0x1000a, 100, 1000, 2))
+	require.NoError(t, tc.EnsureBPFFilter("eth0", 1, "/fake/bpf/prog"))
+	assert.Equal(t, []string{
+		"[qdisc show dev eth0 root]",
+		"[qdisc replace dev eth0 root handle 1: htb default 0]",
+		"[class replace dev eth0 parent 1: classid 1:a htb rate 100mbit ceil 1000mbit prio 2]",
+		"[filter replace dev eth0 parent 1: protocol all prio 1 handle 1 bpf direct-action object-pinned /fake/bpf/prog]",
+	}, commands)
+
+	// the root qdisc isn't replaced if it's already the expected one
+	commands = nil
+	qdisc = "qdisc htb 1: root refcnt 2 r2q 10 default 0 direct_packets_stat 0"
+	replaced, err = tc.EnsureHTBQdisc("eth0", 1)
+	require.NoError(t, err)
+	assert.False(t, replaced)
+	assert.Equal(t, []string{"[qdisc show dev eth0 root]"}, commands)
+}
+
+func TestGetClassRates(t *testing.T) {
+	t.Parallel()
+
+	rate, ceil := getClassRates(1000, TCClassConfig{RatePercent: 10, CeilPercent: 50})
+	assert.Equal(t, uint64(100), rate)
+	assert.Equal(t, uint64(500), ceil)
+
+	rate, ceil = getClassRates(1000, TCClassConfig{RatePercent: 0, CeilPercent: 0})
+	assert.Equal(t, uint64(1), rate)
+	assert.Equal(t, uint64(1), ceil)
+}
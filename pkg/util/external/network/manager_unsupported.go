@@ -28,6 +28,11 @@ func NewNetworkManager() NetworkManager {
 	return &unsupportedNetworkManager{}
 }
 
+// NewNetworkManagerWithConfig returns an unsupportedNetworkManager.
+func NewNetworkManagerWithConfig(_ TCConfig) NetworkManager {
+	return &unsupportedNetworkManager{}
+}
+
 // ApplyNetClass applies the net class config for a container.
 func (*unsupportedNetworkManager) ApplyNetClass(podUID, containerId string, data *common.NetClsData) error {
 	return nil
@@ -0,0 +1,93 @@
+//go:build linux
+// +build linux
+
+// Copyright 2022 The Katalyst Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	tcCommand = "tc"
+
+	// bpfFilterPriority and bpfFilterHandle are fixed so that the filter can be replaced in place
+	bpfFilterPriority = "1"
+	bpfFilterHandle   = "1"
+)
+
+// commandTrafficController implements TrafficController by running tc of iproute2.
+type commandTrafficController struct {
+	run func(args ...string) (string, error)
+}
+
+// NewCommandTrafficController returns a TrafficController based on tc command.
+func NewCommandTrafficController() TrafficController {
+	return &commandTrafficController{
+		run: runTCCommand,
+	}
+}
+
+// EnsureHTBQdisc replaces the root qdisc only if it's not the expected htb qdisc,
+// since replacing the root qdisc will flush all classes under it.
+func (c *commandTrafficController) EnsureHTBQdisc(ifName string, handle uint16) (bool, error) {
+	output, err := c.run("qdisc", "show", "dev", ifName, "root")
+	if err != nil {
+		return false, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(output), fmt.Sprintf("qdisc htb %x: root", handle)) {
+		return false, nil
+	}
+
+	// default class 0 means unclassified packets are sent directly
+	_, err = c.run("qdisc", "replace", "dev", ifName, "root", "handle", fmt.Sprintf("%x:", handle), "htb", "default", "0")
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EnsureHTBClass creates or updates the htb class in place.
+func (c *commandTrafficController) EnsureHTBClass(ifName string, classID uint32, rate, ceil uint64, priority uint32) error {
+	_, err := c.run("class", "replace", "dev", ifName, "parent", fmt.Sprintf("%x:", classID>>16),
+		"classid", formatTCHandle(classID), "htb", "rate", fmt.Sprintf("%dmbit", rate),
+		"ceil", fmt.Sprintf("%dmbit", ceil), "prio", fmt.Sprintf("%d", priority))
+	return err
+}
+
+// EnsureBPFFilter creates or updates the BPF filter in place.
+func (c *commandTrafficController) EnsureBPFFilter(ifName string, parent uint16, progPath string) error {
+	_, err := c.run("filter", "replace", "dev", ifName, "parent", fmt.Sprintf("%x:", parent),
+		"protocol", "all", "prio", bpfFilterPriority, "handle", bpfFilterHandle,
+		"bpf", "direct-action", "object-pinned", progPath)
+	return err
+}
+
+// formatTCHandle formats the handle as major:minor in hex, which is the format accepted by tc
+func formatTCHandle(handle uint32) string {
+	return fmt.Sprintf("%x:%x", handle>>16, handle&0xffff)
+}
+
+func runTCCommand(args ...string) (string, error) {
+	output, err := exec.Command(tcCommand, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("run tc %s failed with error: %v, output: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
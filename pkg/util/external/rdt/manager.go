@@ -14,13 +14,42 @@
 
 package rdt
 
+const (
+	// DefaultResctrlRootPath is the default mount point of the resctrl filesystem
+	DefaultResctrlRootPath = "/sys/fs/resctrl"
+)
+
 // RDTManager provides methods that control RDT related resources.
 // Note: OCI Spec and runC already support the configuration of RDT-related parameters, but CRI and containerd do not yet support it.
 // Therefore, we plan to support the configuration of RDT-related parameters through NRI or CRI in the future.
 type RDTManager interface {
 	CheckSupportRDT() (bool, error)
 	InitRDT() error
+	GetRDTInfo() (*RDTInfo, error)
 	ApplyTasks(clos string, tasks []string) error
 	ApplyCAT(clos string, cat map[int]int) error
 	ApplyMBA(clos string, mba map[int]int) error
+	GetMonData(clos string) (map[int]*MonData, error)
+}
+
+// RDTInfo describes the RDT capabilities of the node
+type RDTInfo struct {
+	// CBMMask is the bitmask of all available L3 cache ways
+	CBMMask int
+	// MinCBMBits is the minimum number of consecutive bits that must be set in a CBM
+	MinCBMBits int
+	// L3CacheIDs are ids of L3 cache domains
+	L3CacheIDs []int
+	// MBDomainIDs are ids of memory bandwidth domains
+	MBDomainIDs []int
+}
+
+// MonData is the monitoring data of a CLOS in one L3 cache domain
+type MonData struct {
+	// LLCOccupancy is the LLC occupancy in bytes
+	LLCOccupancy uint64
+	// MBMTotalBytes is the accumulated total memory bandwidth in bytes
+	MBMTotalBytes uint64
+	// MBMLocalBytes is the accumulated local memory bandwidth in bytes
+	MBMLocalBytes uint64
 }
@@ -19,41 +19,322 @@ package rdt
 
 import (
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
 )
 
-type defaultRDTManager struct{}
+const (
+	resctrlFileSchemata = "schemata"
+	resctrlFileTasks    = "tasks"
+
+	resctrlDirInfo    = "info"
+	resctrlDirMonData = "mon_data"
+
+	resctrlResourceL3 = "L3"
+	resctrlResourceMB = "MB"
+
+	resctrlFileCBMMask    = "cbm_mask"
+	resctrlFileMinCBMBits = "min_cbm_bits"
 
-// NewDefaultManager returns a defaultRDTManager.
+	resctrlFileLLCOccupancy  = "llc_occupancy"
+	resctrlFileMBMTotalBytes = "mbm_total_bytes"
+	resctrlFileMBMLocalBytes = "mbm_local_bytes"
+
+	resctrlMonL3DirPrefix = "mon_L3_"
+)
+
+type defaultRDTManager struct {
+	mutex sync.RWMutex
+	// rootPath is the mount point of resctrl filesystem
+	rootPath string
+	info     *RDTInfo
+}
+
+// NewDefaultManager returns a defaultRDTManager based on resctrl filesystem.
 func NewDefaultManager() RDTManager {
-	return &defaultRDTManager{}
+	return NewResctrlManager(DefaultResctrlRootPath)
+}
+
+// NewResctrlManager returns a defaultRDTManager with the given resctrl root path.
+func NewResctrlManager(rootPath string) RDTManager {
+	return &defaultRDTManager{
+		rootPath: rootPath,
+	}
 }
 
-// CheckSupportRDT checks whether RDT is supported by the CPU and the kernel.
-func (*defaultRDTManager) CheckSupportRDT() (bool, error) {
-	// TODO: implement CheckSupportRDT
-	return false, errors.New("not implemented yet")
+// CheckSupportRDT checks whether RDT is supported by the CPU and the kernel,
+// and resctrl filesystem must be mounted at the root path.
+func (m *defaultRDTManager) CheckSupportRDT() (bool, error) {
+	for _, file := range []string{resctrlDirInfo, resctrlFileSchemata} {
+		if _, err := os.Stat(filepath.Join(m.rootPath, file)); err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("stat %s failed with error: %v", file, err)
+		}
+	}
+
+	return true, nil
 }
 
 // InitRDT performs some RDT-related initializations.
-func (*defaultRDTManager) InitRDT() error {
-	// TODO: implement InitRDT
-	return errors.New("not implemented yet")
+func (m *defaultRDTManager) InitRDT() error {
+	supported, err := m.CheckSupportRDT()
+	if err != nil {
+		return err
+	} else if !supported {
+		return fmt.Errorf("resctrl is not mounted at %s", m.rootPath)
+	}
+
+	info, err := m.loadRDTInfo()
+	if err != nil {
+		return fmt.Errorf("load rdt info failed with error: %v", err)
+	}
+
+	m.mutex.Lock()
+	m.info = info
+	m.mutex.Unlock()
+
+	klog.Infof("[rdt] init rdt successfully, root: %s, info: %+v", m.rootPath, *info)
+	return nil
+}
+
+// GetRDTInfo returns the RDT capabilities of the node.
+func (m *defaultRDTManager) GetRDTInfo() (*RDTInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.info == nil {
+		return nil, errors.New("rdt is not initialized")
+	}
+	return m.info, nil
 }
 
 // ApplyTasks synchronizes the tasks of each CLOS.
-func (*defaultRDTManager) ApplyTasks(clos string, tasks []string) error {
-	// TODO: implement ApplyTasks
-	return errors.New("not implemented yet")
+func (m *defaultRDTManager) ApplyTasks(clos string, tasks []string) error {
+	closPath, err := m.ensureCLOS(clos)
+	if err != nil {
+		return err
+	}
+
+	// resctrl only accepts one task for each write
+	for _, task := range tasks {
+		err := writeResctrlFile(filepath.Join(closPath, resctrlFileTasks), task+"\n", os.O_APPEND)
+		if err != nil && !errors.Is(err, unix.ESRCH) {
+			return fmt.Errorf("apply task %s to clos %s failed with error: %v", task, clos, err)
+		}
+	}
+
+	return nil
 }
 
 // ApplyCAT applies the CAT configurations for each CLOS.
-func (*defaultRDTManager) ApplyCAT(clos string, cat map[int]int) error {
-	// TODO: implement ApplyCAT
-	return errors.New("not implemented yet")
+func (m *defaultRDTManager) ApplyCAT(clos string, cat map[int]int) error {
+	domains := make(map[int]string, len(cat))
+	for cacheID, cbm := range cat {
+		domains[cacheID] = strconv.FormatInt(int64(cbm), 16)
+	}
+
+	return m.applySchemata(clos, resctrlResourceL3, domains)
 }
 
 // ApplyMBA applies the MBA configurations for each CLOS.
-func (*defaultRDTManager) ApplyMBA(clos string, mba map[int]int) error {
-	// TODO: implement ApplyMBA
-	return errors.New("not implemented yet")
+func (m *defaultRDTManager) ApplyMBA(clos string, mba map[int]int) error {
+	domains := make(map[int]string, len(mba))
+	for domainID, percent := range mba {
+		domains[domainID] = strconv.Itoa(percent)
+	}
+
+	return m.applySchemata(clos, resctrlResourceMB, domains)
+}
+
+// GetMonData returns the monitoring data of each L3 cache domain for a CLOS.
+func (m *defaultRDTManager) GetMonData(clos string) (map[int]*MonData, error) {
+	monDataPath := filepath.Join(m.getCLOSPath(clos), resctrlDirMonData)
+	entries, err := ioutil.ReadDir(monDataPath)
+	if err != nil {
+		return nil, fmt.Errorf("read mon_data of clos %s failed with error: %v", clos, err)
+	}
+
+	res := make(map[int]*MonData)
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), resctrlMonL3DirPrefix) {
+			continue
+		}
+
+		cacheID, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), resctrlMonL3DirPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("invalid mon_data dir %s", entry.Name())
+		}
+
+		monData := &MonData{}
+		for file, value := range map[string]*uint64{
+			resctrlFileLLCOccupancy:  &monData.LLCOccupancy,
+			resctrlFileMBMTotalBytes: &monData.MBMTotalBytes,
+			resctrlFileMBMLocalBytes: &monData.MBMLocalBytes,
+		} {
+			// some monitoring features may be unsupported by the CPU
+			content, err := ioutil.ReadFile(filepath.Join(monDataPath, entry.Name(), file))
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("read %s of clos %s failed with error: %v", file, clos, err)
+			}
+
+			if *value, err = strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64); err != nil {
+				return nil, fmt.Errorf("parse %s of clos %s failed with error: %v", file, clos, err)
+			}
+		}
+		res[cacheID] = monData
+	}
+
+	return res, nil
+}
+
+// applySchemata writes the schemata line of the given resource for a CLOS
+func (m *defaultRDTManager) applySchemata(clos, resource string, domains map[int]string) error {
+	if len(domains) == 0 {
+		return nil
+	}
+
+	closPath, err := m.ensureCLOS(clos)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int, 0, len(domains))
+	for id := range domains {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	items := make([]string, 0, len(ids))
+	for _, id := range ids {
+		items = append(items, fmt.Sprintf("%d=%s", id, domains[id]))
+	}
+	schemata := fmt.Sprintf("%s:%s\n", resource, strings.Join(items, ";"))
+
+	if err := writeResctrlFile(filepath.Join(closPath, resctrlFileSchemata), schemata, os.O_TRUNC); err != nil {
+		return fmt.Errorf("apply schemata %q to clos %s failed with error: %v", schemata, clos, err)
+	}
+
+	klog.Infof("[rdt] apply schemata successfully, clos: %s, schemata: %q", clos, schemata)
+	return nil
+}
+
+// ensureCLOS creates the control group for a CLOS if it doesn't exist
+func (m *defaultRDTManager) ensureCLOS(clos string) (string, error) {
+	closPath := m.getCLOSPath(clos)
+	if err := os.MkdirAll(closPath, 0o755); err != nil {
+		return "", fmt.Errorf("create clos %s failed with error: %v", clos, err)
+	}
+	return closPath, nil
+}
+
+// getCLOSPath returns the path of a CLOS, and the empty CLOS refers to the default group
+func (m *defaultRDTManager) getCLOSPath(clos string) string {
+	return filepath.Join(m.rootPath, clos)
+}
+
+// loadRDTInfo reads RDT capabilities from info directory and schemata of the default group
+func (m *defaultRDTManager) loadRDTInfo() (*RDTInfo, error) {
+	info := &RDTInfo{}
+
+	l3InfoPath := filepath.Join(m.rootPath, resctrlDirInfo, resctrlResourceL3)
+	if _, err := os.Stat(l3InfoPath); err == nil {
+		cbmMask, err := readResctrlInt(filepath.Join(l3InfoPath, resctrlFileCBMMask), 16)
+		if err != nil {
+			return nil, err
+		}
+		info.CBMMask = cbmMask
+
+		minCBMBits, err := readResctrlInt(filepath.Join(l3InfoPath, resctrlFileMinCBMBits), 10)
+		if err != nil {
+			return nil, err
+		}
+		info.MinCBMBits = minCBMBits
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(m.rootPath, resctrlFileSchemata))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		resource, ids, err := parseSchemataLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch resource {
+		case resctrlResourceL3:
+			info.L3CacheIDs = ids
+		case resctrlResourceMB:
+			info.MBDomainIDs = ids
+		}
+	}
+
+	return info, nil
+}
+
+// parseSchemataLine parses a schemata line like "L3:0=7ff;1=7ff", and returns the resource and domain ids
+func parseSchemataLine(line string) (string, []int, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", nil, nil
+	}
+
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("invalid schemata line %q", line)
+	}
+
+	var ids []int
+	for _, item := range strings.Split(parts[1], ";") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return "", nil, fmt.Errorf("invalid schemata line %q", line)
+		}
+
+		id, err := strconv.Atoi(strings.TrimSpace(kv[0]))
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid schemata line %q", line)
+		}
+		ids = append(ids, id)
+	}
+
+	return strings.TrimSpace(parts[0]), ids, nil
+}
+
+func readResctrlInt(file string, base int) (int, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseInt(strings.TrimSpace(string(content)), base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s failed with error: %v", file, err)
+	}
+	return int(value), nil
+}
+
+func writeResctrlFile(file, data string, flag int) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|flag, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(data)
+	return err
 }
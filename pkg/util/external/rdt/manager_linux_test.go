@@ -18,10 +18,14 @@
 package rdt
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -31,63 +35,129 @@ var (
 	defaultMBAValue = 100
 )
 
+// makeFakeResctrl creates a fake resctrl directory tree with two cache domains
+func makeFakeResctrl(t *testing.T) string {
+	root, err := ioutil.TempDir("", "fake-resctrl")
+	require.NoError(t, err)
+
+	files := map[string]string{
+		"schemata":              "    L3:0=7ff;1=7ff\n    MB:0=100;1=100\n",
+		"tasks":                 "",
+		"info/L3/cbm_mask":      "7ff\n",
+		"info/L3/min_cbm_bits":  "1\n",
+		"info/MB/min_bandwidth": "10\n",
+		"fake-clos/mon_data/mon_L3_00/llc_occupancy":   "1048576\n",
+		"fake-clos/mon_data/mon_L3_00/mbm_total_bytes": "4096\n",
+		"fake-clos/mon_data/mon_L3_00/mbm_local_bytes": "2048\n",
+		"fake-clos/mon_data/mon_L3_01/llc_occupancy":   "2097152\n",
+	}
+	for file, content := range files {
+		path := filepath.Join(root, file)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return root
+}
+
+func readFakeResctrlFile(t *testing.T, root, file string) string {
+	content, err := ioutil.ReadFile(filepath.Join(root, file))
+	require.NoError(t, err)
+	return string(content)
+}
+
 func TestNewDefaultManager(t *testing.T) {
 	defaultManager := NewDefaultManager()
 	assert.NotNil(t, defaultManager)
 }
 
 func TestCheckSupportRDT(t *testing.T) {
-	defaultManager := NewDefaultManager()
-	assert.NotNil(t, defaultManager)
+	root := makeFakeResctrl(t)
+	defer os.RemoveAll(root)
 
-	isSupport, err := defaultManager.CheckSupportRDT()
-	assert.Error(t, err)
+	isSupport, err := NewResctrlManager(root).CheckSupportRDT()
+	assert.NoError(t, err)
+	assert.True(t, isSupport)
+
+	isSupport, err = NewResctrlManager(filepath.Join(root, "non-exist")).CheckSupportRDT()
+	assert.NoError(t, err)
 	assert.False(t, isSupport)
 }
 
 func TestInitRDT(t *testing.T) {
-	defaultManager := NewDefaultManager()
-	assert.NotNil(t, defaultManager)
+	root := makeFakeResctrl(t)
+	defer os.RemoveAll(root)
+
+	manager := NewResctrlManager(root)
+	_, err := manager.GetRDTInfo()
+	assert.Error(t, err)
+
+	err = manager.InitRDT()
+	assert.NoError(t, err)
 
-	err := defaultManager.InitRDT()
+	info, err := manager.GetRDTInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, &RDTInfo{
+		CBMMask:     0x7ff,
+		MinCBMBits:  1,
+		L3CacheIDs:  []int{0, 1},
+		MBDomainIDs: []int{0, 1},
+	}, info)
+
+	err = NewResctrlManager(filepath.Join(root, "non-exist")).InitRDT()
 	assert.Error(t, err)
 }
 
 func TestApplyTasks(t *testing.T) {
-	defaultManager := NewDefaultManager()
-	assert.NotNil(t, defaultManager)
+	root := makeFakeResctrl(t)
+	defer os.RemoveAll(root)
 
-	err := defaultManager.ApplyTasks(clos, tasks)
-	assert.Error(t, err)
+	err := NewResctrlManager(root).ApplyTasks(clos, tasks)
+	assert.NoError(t, err)
+	assert.Equal(t, "0\n1\n", readFakeResctrlFile(t, root, "fake-clos/tasks"))
 }
 
 func TestApplyCAT(t *testing.T) {
-	defaultManager := NewDefaultManager()
-	assert.NotNil(t, defaultManager)
+	root := makeFakeResctrl(t)
+	defer os.RemoveAll(root)
 
 	catInt64, err := strconv.ParseInt(defaultCATValue, 16, 32)
 	assert.NoError(t, err)
 
 	cat := map[int]int{
 		0: int(catInt64),
-		1: int(catInt64),
+		1: 0xf,
 	}
-	err = defaultManager.ApplyCAT(clos, cat)
-	assert.Error(t, err)
-
-	return
+	err = NewResctrlManager(root).ApplyCAT(clos, cat)
+	assert.NoError(t, err)
+	assert.Equal(t, "L3:0=7ff;1=f\n", readFakeResctrlFile(t, root, "fake-clos/schemata"))
 }
 
 func TestApplyMBA(t *testing.T) {
-	defaultManager := NewDefaultManager()
-	assert.NotNil(t, defaultManager)
+	root := makeFakeResctrl(t)
+	defer os.RemoveAll(root)
 
 	mba := map[int]int{
 		0: defaultMBAValue,
-		1: defaultMBAValue,
+		1: 30,
 	}
-	err := defaultManager.ApplyMBA(clos, mba)
-	assert.Error(t, err)
+	err := NewResctrlManager(root).ApplyMBA("new-clos", mba)
+	assert.NoError(t, err)
+	assert.Equal(t, "MB:0=100;1=30\n", readFakeResctrlFile(t, root, "new-clos/schemata"))
+}
 
-	return
+func TestGetMonData(t *testing.T) {
+	root := makeFakeResctrl(t)
+	defer os.RemoveAll(root)
+
+	manager := NewResctrlManager(root)
+	monData, err := manager.GetMonData(clos)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]*MonData{
+		0: {LLCOccupancy: 1048576, MBMTotalBytes: 4096, MBMLocalBytes: 2048},
+		1: {LLCOccupancy: 2097152},
+	}, monData)
+
+	_, err = manager.GetMonData("non-exist")
+	assert.Error(t, err)
 }
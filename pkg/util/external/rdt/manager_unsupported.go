@@ -24,6 +24,11 @@ func NewDefaultManager() RDTManager {
 	return &unsupportedRDTManager{}
 }
 
+// NewResctrlManager returns a defaultRDTManager, and the root path is ignored.
+func NewResctrlManager(_ string) RDTManager {
+	return &unsupportedRDTManager{}
+}
+
 // CheckSupportRDT checks whether RDT is supported by the CPU and the kernel.
 func (*unsupportedRDTManager) CheckSupportRDT() (bool, error) {
 	return false, nil
@@ -34,6 +39,11 @@ func (*unsupportedRDTManager) InitRDT() error {
 	return nil
 }
 
+// GetRDTInfo returns the RDT capabilities of the node.
+func (*unsupportedRDTManager) GetRDTInfo() (*RDTInfo, error) {
+	return &RDTInfo{}, nil
+}
+
 // ApplyTasks synchronizes the tasks of each CLOS.
 func (*unsupportedRDTManager) ApplyTasks(clos string, tasks []string) error {
 	return nil
@@ -48,3 +58,8 @@ func (*unsupportedRDTManager) ApplyCAT(clos string, cat map[int]int) error {
 func (*unsupportedRDTManager) ApplyMBA(clos string, mba map[int]int) error {
 	return nil
 }
+
+// GetMonData returns the monitoring data of each L3 cache domain for a CLOS.
+func (*unsupportedRDTManager) GetMonData(clos string) (map[int]*MonData, error) {
+	return map[int]*MonData{}, nil
+}
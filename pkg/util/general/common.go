@@ -67,6 +67,24 @@ func MaxInt64(a, b int64) int64 {
 	}
 }
 
+func MinInt64(a, b int64) int64 {
+	if a <= b {
+		return a
+	} else {
+		return b
+	}
+}
+
+// Clamp restricts the value in the range of [lower, upper]
+func Clamp(value, lower, upper float64) float64 {
+	if value < lower {
+		return lower
+	} else if value > upper {
+		return upper
+	}
+	return value
+}
+
 // GetValueWithDefault gets value from the given map, and returns default if key not exist
 func GetValueWithDefault(m map[string]string, key, defaultV string) string {
 	if _, ok := m[key]; !ok {
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package histogram
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// MaxCheckpointWeight is the weight of the heaviest bucket in checkpoint,
+	// and weights of other buckets are normalized against it
+	MaxCheckpointWeight uint32 = 10000
+
+	// maxDecayExponent bounds the exponent of decay factor to avoid float64 overflow,
+	// and reference timestamp will be shifted forward once it's exceeded
+	maxDecayExponent = 100
+)
+
+// Histogram aggregates weighted samples and estimates percentiles of them
+type Histogram interface {
+	// AddSample adds a sample with the given value, weight and timestamp
+	AddSample(value float64, weight float64, timestamp time.Time)
+	// Percentile returns an approximation of the given percentile in range [0, 1],
+	// and 0 is returned if the histogram is empty
+	Percentile(percentile float64) float64
+	// IsEmpty returns true iff all buckets are empty
+	IsEmpty() bool
+	// Clone returns a deep copy of the histogram
+	Clone() Histogram
+
+	// SaveToCheckpoint returns a representation of the histogram to be persisted
+	SaveToCheckpoint() (*Checkpoint, error)
+	// LoadFromCheckpoint overwrites the histogram with the given checkpoint
+	LoadFromCheckpoint(checkpoint *Checkpoint) error
+}
+
+// Checkpoint is the persistable form of a histogram, and it's kept json-friendly
+// so that checksum of it remains the same after marshal and unmarshal
+type Checkpoint struct {
+	// ReferenceTimestamp is the unix timestamp in seconds that bucket weights are decayed against
+	ReferenceTimestamp int64 `json:"reference_timestamp"`
+	// BucketWeights holds normalized weights of non-empty buckets keyed by bucket index
+	BucketWeights map[int]uint32 `json:"bucket_weights"`
+	// TotalWeight is the sum of bucket weights before normalization
+	TotalWeight float64 `json:"total_weight"`
+}
+
+func (c *Checkpoint) Clone() *Checkpoint {
+	if c == nil {
+		return nil
+	}
+	clone := &Checkpoint{
+		ReferenceTimestamp: c.ReferenceTimestamp,
+		BucketWeights:      make(map[int]uint32, len(c.BucketWeights)),
+		TotalWeight:        c.TotalWeight,
+	}
+	for bucket, weight := range c.BucketWeights {
+		clone.BucketWeights[bucket] = weight
+	}
+	return clone
+}
+
+// decayingHistogram gives exponentially less weight to older samples, i.e. the weight
+// of a sample halves every halfLife, so that the histogram reflects recent history
+// without storing samples. It's not thread-safe.
+type decayingHistogram struct {
+	options  Options
+	halfLife time.Duration
+
+	// referenceTimestamp is the time when the decay factor equals 1; instead of decaying
+	// existing weights, weights of newer samples are scaled up by 2^((t-reference)/halfLife)
+	referenceTimestamp time.Time
+	bucketWeight       []float64
+	totalWeight        float64
+}
+
+var _ Histogram = &decayingHistogram{}
+
+// NewDecayingHistogram returns an empty histogram which halves sample weights every halfLife
+func NewDecayingHistogram(options Options, halfLife time.Duration) Histogram {
+	return &decayingHistogram{
+		options:      options,
+		halfLife:     halfLife,
+		bucketWeight: make([]float64, options.NumBuckets()),
+	}
+}
+
+func (h *decayingHistogram) AddSample(value float64, weight float64, timestamp time.Time) {
+	if weight <= 0 {
+		return
+	}
+
+	decayedWeight := weight * h.decayFactor(timestamp)
+	h.bucketWeight[h.options.FindBucket(value)] += decayedWeight
+	h.totalWeight += decayedWeight
+}
+
+func (h *decayingHistogram) Percentile(percentile float64) float64 {
+	if h.IsEmpty() {
+		return 0
+	}
+
+	threshold := percentile * h.totalWeight
+	lastBucket := len(h.bucketWeight) - 1
+	partialSum := 0.0
+	bucket := 0
+	for ; bucket < lastBucket; bucket++ {
+		partialSum += h.bucketWeight[bucket]
+		if partialSum >= threshold {
+			break
+		}
+	}
+
+	// return the end of the bucket to be conservative, except for the last bucket which is unbounded
+	if bucket < lastBucket {
+		return h.options.GetBucketStart(bucket + 1)
+	}
+	return h.options.GetBucketStart(bucket)
+}
+
+func (h *decayingHistogram) IsEmpty() bool {
+	return h.totalWeight < h.options.Epsilon()
+}
+
+func (h *decayingHistogram) Clone() Histogram {
+	clone := &decayingHistogram{
+		options:            h.options,
+		halfLife:           h.halfLife,
+		referenceTimestamp: h.referenceTimestamp,
+		bucketWeight:       make([]float64, len(h.bucketWeight)),
+		totalWeight:        h.totalWeight,
+	}
+	copy(clone.bucketWeight, h.bucketWeight)
+	return clone
+}
+
+func (h *decayingHistogram) SaveToCheckpoint() (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		ReferenceTimestamp: h.referenceTimestamp.Unix(),
+		BucketWeights:      make(map[int]uint32),
+		TotalWeight:        h.totalWeight,
+	}
+
+	maxWeight := 0.0
+	for _, weight := range h.bucketWeight {
+		maxWeight = math.Max(maxWeight, weight)
+	}
+	if maxWeight < h.options.Epsilon() {
+		return checkpoint, nil
+	}
+
+	ratio := float64(MaxCheckpointWeight) / maxWeight
+	for bucket, weight := range h.bucketWeight {
+		if normalizedWeight := uint32(math.Round(weight * ratio)); normalizedWeight > 0 {
+			checkpoint.BucketWeights[bucket] = normalizedWeight
+		}
+	}
+	return checkpoint, nil
+}
+
+func (h *decayingHistogram) LoadFromCheckpoint(checkpoint *Checkpoint) error {
+	if checkpoint == nil {
+		return fmt.Errorf("nil checkpoint")
+	} else if checkpoint.TotalWeight < 0 {
+		return fmt.Errorf("negative total weight %v", checkpoint.TotalWeight)
+	}
+
+	sum := 0.0
+	for bucket, weight := range checkpoint.BucketWeights {
+		if bucket < 0 || bucket >= len(h.bucketWeight) {
+			return fmt.Errorf("bucket index %v out of range [0..%v]", bucket, len(h.bucketWeight)-1)
+		}
+		sum += float64(weight)
+	}
+
+	h.bucketWeight = make([]float64, h.options.NumBuckets())
+	h.totalWeight = 0
+	h.referenceTimestamp = time.Unix(checkpoint.ReferenceTimestamp, 0)
+	if sum == 0 {
+		return nil
+	}
+
+	// restore original weights from normalized ones
+	ratio := checkpoint.TotalWeight / sum
+	for bucket, weight := range checkpoint.BucketWeights {
+		h.bucketWeight[bucket] = float64(weight) * ratio
+	}
+	h.totalWeight = checkpoint.TotalWeight
+	return nil
+}
+
+// decayFactor returns the multiplier of sample weight at the given timestamp,
+// and shifts reference timestamp if the factor is too large
+func (h *decayingHistogram) decayFactor(timestamp time.Time) float64 {
+	maxAllowedTimestamp := h.referenceTimestamp.Add(time.Duration(maxDecayExponent) * h.halfLife)
+	if timestamp.After(maxAllowedTimestamp) {
+		h.shiftReferenceTimestamp(timestamp)
+	}
+	return math.Exp2(float64(timestamp.Sub(h.referenceTimestamp)) / float64(h.halfLife))
+}
+
+// shiftReferenceTimestamp moves reference timestamp forward and scales existing weights down accordingly
+func (h *decayingHistogram) shiftReferenceTimestamp(newReference time.Time) {
+	newReference = newReference.Round(h.halfLife)
+	exponent := math.Round(float64(h.referenceTimestamp.Sub(newReference)) / float64(h.halfLife))
+	factor := math.Ldexp(1., int(exponent))
+
+	h.totalWeight = 0
+	for bucket := range h.bucketWeight {
+		h.bucketWeight[bucket] *= factor
+		h.totalWeight += h.bucketWeight[bucket]
+	}
+	h.referenceTimestamp = newReference
+}
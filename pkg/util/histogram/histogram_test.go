@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package histogram
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOptions(t *testing.T) Options {
+	options, err := NewExponentialOptions(100, 1, 1.05, 1e-3)
+	require.NoError(t, err)
+	return options
+}
+
+func TestExponentialOptions(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewExponentialOptions(100, 1, 1, 1e-3)
+	assert.Error(t, err)
+	_, err = NewExponentialOptions(0, 1, 1.05, 1e-3)
+	assert.Error(t, err)
+
+	options := newTestOptions(t)
+	for _, value := range []float64{0, 0.5, 1, 3.3, 10, 42, 99} {
+		bucket := options.FindBucket(value)
+		assert.LessOrEqual(t, options.GetBucketStart(bucket), value)
+		if bucket < options.NumBuckets()-1 {
+			assert.Greater(t, options.GetBucketStart(bucket+1), value)
+		}
+	}
+	assert.Equal(t, options.NumBuckets()-1, options.FindBucket(1e6))
+}
+
+func TestDecayingHistogramPercentile(t *testing.T) {
+	t.Parallel()
+
+	h := NewDecayingHistogram(newTestOptions(t), time.Hour)
+	assert.True(t, h.IsEmpty())
+	assert.Equal(t, 0.0, h.Percentile(0.9))
+
+	now := time.Unix(1700000000, 0)
+	for i := 1; i <= 100; i++ {
+		h.AddSample(float64(i)/2, 1, now)
+	}
+	assert.False(t, h.IsEmpty())
+	// percentile is the end of the bucket, so it's no less than the real one with bounded error
+	assert.InDelta(t, 45, h.Percentile(0.9), 45*0.05+1)
+	assert.GreaterOrEqual(t, h.Percentile(0.9), 45.0)
+	assert.InDelta(t, 25, h.Percentile(0.5), 25*0.05+1)
+}
+
+func TestDecayingHistogramDecay(t *testing.T) {
+	t.Parallel()
+
+	h := NewDecayingHistogram(newTestOptions(t), time.Hour)
+	now := time.Unix(1700000000, 0)
+
+	// samples of value 50 are 10 half-lives older than samples of value 10,
+	// so they are far less important even if the count is larger
+	for i := 0; i < 100; i++ {
+		h.AddSample(50, 1, now)
+	}
+	for i := 0; i < 10; i++ {
+		h.AddSample(10, 1, now.Add(10*time.Hour))
+	}
+	assert.Less(t, h.Percentile(0.5), 12.0)
+	assert.Greater(t, h.Percentile(0.995), 45.0)
+
+	// shifting reference timestamp far away keeps relative weights unchanged
+	clone := h.Clone()
+	clone.AddSample(0, 1e-300, now.Add(1000*time.Hour))
+	assert.Less(t, clone.Percentile(0.5), 12.0)
+}
+
+func TestDecayingHistogramCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	options := newTestOptions(t)
+	h := NewDecayingHistogram(options, time.Hour)
+	now := time.Unix(1700000000, 0)
+	for i := 1; i <= 100; i++ {
+		h.AddSample(float64(i), 1, now.Add(time.Duration(i)*time.Minute))
+	}
+
+	checkpoint, err := h.SaveToCheckpoint()
+	require.NoError(t, err)
+
+	// checkpoint must survive json round trip
+	data, err := json.Marshal(checkpoint)
+	require.NoError(t, err)
+	restoredCheckpoint := &Checkpoint{}
+	require.NoError(t, json.Unmarshal(data, restoredCheckpoint))
+	assert.Equal(t, checkpoint, restoredCheckpoint)
+	assert.Equal(t, checkpoint, checkpoint.Clone())
+
+	restored := NewDecayingHistogram(options, time.Hour)
+	require.NoError(t, restored.LoadFromCheckpoint(restoredCheckpoint))
+	for _, p := range []float64{0.1, 0.5, 0.9, 0.99} {
+		assert.InDelta(t, h.Percentile(p), restored.Percentile(p), 1e-9)
+	}
+
+	// samples added after restoring are decayed against the same reference
+	h.AddSample(100, 10, now.Add(2*time.Hour))
+	restored.AddSample(100, 10, now.Add(2*time.Hour))
+	assert.InDelta(t, h.Percentile(0.9), restored.Percentile(0.9), 1e-9)
+
+	assert.Error(t, restored.LoadFromCheckpoint(nil))
+	assert.Error(t, restored.LoadFromCheckpoint(&Checkpoint{BucketWeights: map[int]uint32{options.NumBuckets(): 1}}))
+
+	empty := NewDecayingHistogram(options, time.Hour)
+	checkpoint, err = empty.SaveToCheckpoint()
+	require.NoError(t, err)
+	require.NoError(t, restored.LoadFromCheckpoint(checkpoint))
+	assert.True(t, restored.IsEmpty())
+}
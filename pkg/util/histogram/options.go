@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package histogram
+
+import (
+	"fmt"
+	"math"
+)
+
+// Options describes the bucketing scheme of a histogram. Buckets are indexed from 0
+// to NumBuckets()-1, and each bucket covers the range [GetBucketStart(i), GetBucketStart(i+1)).
+type Options interface {
+	// NumBuckets returns the number of buckets in the histogram
+	NumBuckets() int
+	// FindBucket returns the index of the bucket for the given value
+	FindBucket(value float64) int
+	// GetBucketStart returns the start of the bucket with the given index
+	GetBucketStart(bucket int) float64
+	// Epsilon returns the minimal weight regarded as non-zero
+	Epsilon() float64
+}
+
+// exponentialOptions makes bucket sizes grow exponentially, i.e. the size of bucket i
+// is firstBucketSize * ratio^i, so that relative precision is kept for large values
+type exponentialOptions struct {
+	numBuckets      int
+	firstBucketSize float64
+	ratio           float64
+	epsilon         float64
+}
+
+var _ Options = &exponentialOptions{}
+
+// NewExponentialOptions returns Options with exponentially growing bucket sizes,
+// and values no less than maxValue all fall into the last bucket
+func NewExponentialOptions(maxValue, firstBucketSize, ratio, epsilon float64) (Options, error) {
+	if maxValue <= 0 || firstBucketSize <= 0 || epsilon <= 0 {
+		return nil, fmt.Errorf("maxValue, firstBucketSize and epsilon must be positive")
+	} else if ratio <= 1 {
+		return nil, fmt.Errorf("ratio must be larger than 1")
+	}
+
+	numBuckets := int(math.Ceil(math.Log(maxValue*(ratio-1)/firstBucketSize+1)/math.Log(ratio))) + 1
+	return &exponentialOptions{
+		numBuckets:      numBuckets,
+		firstBucketSize: firstBucketSize,
+		ratio:           ratio,
+		epsilon:         epsilon,
+	}, nil
+}
+
+func (o *exponentialOptions) NumBuckets() int {
+	return o.numBuckets
+}
+
+func (o *exponentialOptions) FindBucket(value float64) int {
+	if value < o.firstBucketSize {
+		return 0
+	}
+
+	bucket := int(math.Log(value*(o.ratio-1)/o.firstBucketSize+1) / math.Log(o.ratio))
+	if bucket >= o.numBuckets {
+		return o.numBuckets - 1
+	}
+	return bucket
+}
+
+func (o *exponentialOptions) GetBucketStart(bucket int) float64 {
+	if bucket < 0 || bucket >= o.numBuckets {
+		panic(fmt.Sprintf("index %v out of range [0..%v]", bucket, o.numBuckets-1))
+	}
+	if bucket == 0 {
+		return 0
+	}
+	return o.firstBucketSize * (math.Pow(o.ratio, float64(bucket)) - 1) / (o.ratio - 1)
+}
+
+func (o *exponentialOptions) Epsilon() float64 {
+	return o.epsilon
+}
@@ -0,0 +1,58 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// MigratePages moves all pages of the process from NUMA nodes in fromNumas
+// to NUMA nodes in toNumas by migrate_pages(2), and it returns the number of
+// pages that could not be moved.
+func MigratePages(pid int, fromNumas, toNumas CPUSet) (int, error) {
+	if fromNumas.IsEmpty() || toNumas.IsEmpty() {
+		return 0, fmt.Errorf("empty numa set, from: %s, to: %s", fromNumas.String(), toNumas.String())
+	}
+
+	numas := fromNumas.Union(toNumas).ToSliceInt()
+	maxNode := numas[len(numas)-1]
+	fromMask, toMask := toNodeMask(fromNumas, maxNode), toNodeMask(toNumas, maxNode)
+
+	// the kernel treats maxnode as the number of bits plus one
+	ret, _, errno := unix.Syscall6(unix.SYS_MIGRATE_PAGES, uintptr(pid), uintptr(len(fromMask)*64+1),
+		uintptr(unsafe.Pointer(&fromMask[0])), uintptr(unsafe.Pointer(&toMask[0])), 0, 0)
+	if errno != 0 {
+		return 0, fmt.Errorf("migrate_pages for pid %d from %s to %s failed: %v",
+			pid, fromNumas.String(), toNumas.String(), errno)
+	}
+	return int(ret), nil
+}
+
+// toNodeMask converts numa set into the bitmask format used by NUMA syscalls
+func toNodeMask(numas CPUSet, maxNode int) []uint64 {
+	mask := make([]uint64, maxNode/64+1)
+	for _, numaID := range numas.ToSliceInt() {
+		mask[numaID/64] |= 1 << uint(numaID%64)
+	}
+	return mask
+}
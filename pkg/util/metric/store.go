@@ -18,19 +18,56 @@ package metric
 
 import (
 	"errors"
+	"fmt"
 	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// scopes of metrics in MetricStore, and they are consistent with
+// those scopes used by agent.MetricsFetcher
+const (
+	MetricScopeNode      = "node"
+	MetricScopeNuma      = "numa"
+	MetricScopeCPU       = "cpu"
+	MetricScopeDevice    = "device"
+	MetricScopeContainer = "container"
 )
 
+// ErrMetricDataStale is returned if the metric data is older than the expected max age
+var ErrMetricDataStale = errors.New("[MetricStore] metric data is stale")
+
+// MetricData is the value of a metric along with the time it is stored
+type MetricData struct {
+	Value float64
+	Time  time.Time
+}
+
+// checkAge returns error if the metric data is older than the given max age,
+// and max age is ignored if it is not positive
+func (d MetricData) checkAge(now time.Time, maxAge time.Duration) (float64, error) {
+	if maxAge > 0 && now.Sub(d.Time) > maxAge {
+		return 0, fmt.Errorf("%w: updated at %v, max age %v", ErrMetricDataStale, d.Time, maxAge)
+	}
+	return d.Value, nil
+}
+
 // MetricStore stores those raw metric data items collected from
 // agent.MetricsFetcher
 type MetricStore struct {
-	nodeMetricMap             map[string]float64                                  // map[metricName]value
-	numaMetricMap             map[int]map[string]float64                          // map[numaID]map[metricName]value
-	deviceMetricMap           map[string]map[string]float64                       // map[deviceName]map[metricName]value
-	cpuMetricMap              map[int]map[string]float64                          // map[cpuID]map[metricName]value
-	podContainerMetricMap     map[string]map[string]map[string]float64            // map[podUID]map[containerName]map[metricName]value
-	podContainerNumaMetricMap map[string]map[string]map[string]map[string]float64 // map[podUID]map[containerName]map[numaNode]map[metricName]value
-	mutex                     sync.RWMutex
+	nodeMetricMap             map[string]MetricData                                  // map[metricName]data
+	numaMetricMap             map[int]map[string]MetricData                          // map[numaID]map[metricName]data
+	deviceMetricMap           map[string]map[string]MetricData                       // map[deviceName]map[metricName]data
+	cpuMetricMap              map[int]map[string]MetricData                          // map[cpuID]map[metricName]data
+	podContainerMetricMap     map[string]map[string]map[string]MetricData            // map[podUID]map[containerName]map[metricName]data
+	podContainerNumaMetricMap map[string]map[string]map[string]map[string]MetricData // map[podUID]map[containerName]map[numaNode]map[metricName]data
+
+	// scopeUpdateTime records the latest time any metric is stored in each scope
+	scopeUpdateTime map[string]time.Time
+	// now is used to get the time to stamp metric data
+	now   func() time.Time
+	mutex sync.RWMutex
 }
 
 var (
@@ -43,161 +80,306 @@ var (
 func GetMetricStoreInstance() *MetricStore {
 	metricStoreInitOnce.Do(
 		func() {
-			metricStoreInstance = &MetricStore{
-				nodeMetricMap:             make(map[string]float64),
-				numaMetricMap:             make(map[int]map[string]float64),
-				deviceMetricMap:           make(map[string]map[string]float64),
-				cpuMetricMap:              make(map[int]map[string]float64),
-				podContainerMetricMap:     make(map[string]map[string]map[string]float64),
-				podContainerNumaMetricMap: make(map[string]map[string]map[string]map[string]float64),
-			}
+			metricStoreInstance = NewMetricStore()
 		})
 	return metricStoreInstance
 }
 
+// NewMetricStore returns a new MetricStore, and it should only be
+// used when an isolated store is needed, e.g. in unit tests
+func NewMetricStore() *MetricStore {
+	return NewMetricStoreWithClock(clock.RealClock{})
+}
+
+// NewMetricStoreWithClock returns a new MetricStore which stamps metric data and
+// checks their ages with the given clock, e.g. a fake clock in unit tests
+func NewMetricStoreWithClock(clock clock.PassiveClock) *MetricStore {
+	return &MetricStore{
+		nodeMetricMap:             make(map[string]MetricData),
+		numaMetricMap:             make(map[int]map[string]MetricData),
+		deviceMetricMap:           make(map[string]map[string]MetricData),
+		cpuMetricMap:              make(map[int]map[string]MetricData),
+		podContainerMetricMap:     make(map[string]map[string]map[string]MetricData),
+		podContainerNumaMetricMap: make(map[string]map[string]map[string]map[string]MetricData),
+		scopeUpdateTime:           make(map[string]time.Time),
+		now:                       clock.Now,
+	}
+}
+
+// stamp returns the metric data with its sample time, which falls back to current time
+// if not given, and records the latest one as the update time of the scope; it must be
+// called with the write lock
+func (c *MetricStore) stamp(scope string, data MetricData) MetricData {
+	if data.Time.IsZero() {
+		data.Time = c.now()
+	}
+	if data.Time.After(c.scopeUpdateTime[scope]) {
+		c.scopeUpdateTime[scope] = data.Time
+	}
+	return data
+}
+
 func (c *MetricStore) SetNodeMetric(metricName string, value float64) {
+	c.SetNodeMetricWithTime(metricName, MetricData{Value: value})
+}
+
+func (c *MetricStore) SetNumaMetric(numaID int, metricName string, value float64) {
+	c.SetNumaMetricWithTime(numaID, metricName, MetricData{Value: value})
+}
+
+func (c *MetricStore) SetDeviceMetric(deviceName string, metricName string, value float64) {
+	c.SetDeviceMetricWithTime(deviceName, metricName, MetricData{Value: value})
+}
+
+func (c *MetricStore) SetCPUMetric(cpuID int, metricName string, value float64) {
+	c.SetCPUMetricWithTime(cpuID, metricName, MetricData{Value: value})
+}
+
+func (c *MetricStore) SetContainerMetric(podUID, containerName, metricName string, value float64) {
+	c.SetContainerMetricWithTime(podUID, containerName, metricName, MetricData{Value: value})
+}
+
+func (c *MetricStore) SetContainerNumaMetric(podUID, containerName, numaNode, metricName string, value float64) {
+	c.SetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName, MetricData{Value: value})
+}
+
+// SetNodeMetricWithTime stores the metric along with the time it is sampled by the metrics
+// source, and current time is used if data.Time is zero; so are other Set*MetricWithTime
+func (c *MetricStore) SetNodeMetricWithTime(metricName string, data MetricData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	c.nodeMetricMap[metricName] = value
+	c.nodeMetricMap[metricName] = c.stamp(MetricScopeNode, data)
 }
 
-func (c *MetricStore) SetNumaMetric(numaID int, metricName string, value float64) {
+func (c *MetricStore) SetNumaMetricWithTime(numaID int, metricName string, data MetricData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	if _, ok := c.numaMetricMap[numaID]; !ok {
-		c.numaMetricMap[numaID] = make(map[string]float64)
+		c.numaMetricMap[numaID] = make(map[string]MetricData)
 	}
-	c.numaMetricMap[numaID][metricName] = value
+	c.numaMetricMap[numaID][metricName] = c.stamp(MetricScopeNuma, data)
 }
 
-func (c *MetricStore) SetDeviceMetric(deviceName string, metricName string, value float64) {
+func (c *MetricStore) SetDeviceMetricWithTime(deviceName string, metricName string, data MetricData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	if _, ok := c.deviceMetricMap[deviceName]; !ok {
-		c.deviceMetricMap[deviceName] = make(map[string]float64)
+		c.deviceMetricMap[deviceName] = make(map[string]MetricData)
 	}
-	c.deviceMetricMap[deviceName][metricName] = value
+	c.deviceMetricMap[deviceName][metricName] = c.stamp(MetricScopeDevice, data)
 }
 
-func (c *MetricStore) SetCPUMetric(cpuID int, metricName string, value float64) {
+func (c *MetricStore) SetCPUMetricWithTime(cpuID int, metricName string, data MetricData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	if _, ok := c.cpuMetricMap[cpuID]; !ok {
-		c.cpuMetricMap[cpuID] = make(map[string]float64)
+		c.cpuMetricMap[cpuID] = make(map[string]MetricData)
 	}
-	c.cpuMetricMap[cpuID][metricName] = value
+	c.cpuMetricMap[cpuID][metricName] = c.stamp(MetricScopeCPU, data)
 }
 
-func (c *MetricStore) SetContainerMetric(podUID, containerName, metricName string, value float64) {
+func (c *MetricStore) SetContainerMetricWithTime(podUID, containerName, metricName string, data MetricData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	if _, ok := c.podContainerMetricMap[podUID]; !ok {
-		c.podContainerMetricMap[podUID] = make(map[string]map[string]float64)
+		c.podContainerMetricMap[podUID] = make(map[string]map[string]MetricData)
 	}
 
 	if _, ok := c.podContainerMetricMap[podUID][containerName]; !ok {
-		c.podContainerMetricMap[podUID][containerName] = make(map[string]float64)
+		c.podContainerMetricMap[podUID][containerName] = make(map[string]MetricData)
 	}
-	c.podContainerMetricMap[podUID][containerName][metricName] = value
+	c.podContainerMetricMap[podUID][containerName][metricName] = c.stamp(MetricScopeContainer, data)
 }
 
-func (c *MetricStore) SetContainerNumaMetric(podUID, containerName, numaNode, metricName string, value float64) {
+func (c *MetricStore) SetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName string, data MetricData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
 	if _, ok := c.podContainerNumaMetricMap[podUID]; !ok {
-		c.podContainerNumaMetricMap[podUID] = make(map[string]map[string]map[string]float64)
+		c.podContainerNumaMetricMap[podUID] = make(map[string]map[string]map[string]MetricData)
 	}
 
 	if _, ok := c.podContainerNumaMetricMap[podUID][containerName]; !ok {
-		c.podContainerNumaMetricMap[podUID][containerName] = make(map[string]map[string]float64)
+		c.podContainerNumaMetricMap[podUID][containerName] = make(map[string]map[string]MetricData)
 	}
 
 	if _, ok := c.podContainerNumaMetricMap[podUID][containerName][numaNode]; !ok {
-		c.podContainerNumaMetricMap[podUID][containerName][numaNode] = make(map[string]float64)
+		c.podContainerNumaMetricMap[podUID][containerName][numaNode] = make(map[string]MetricData)
 	}
-	c.podContainerNumaMetricMap[podUID][containerName][numaNode][metricName] = value
+	c.podContainerNumaMetricMap[podUID][containerName][numaNode][metricName] = c.stamp(MetricScopeContainer, data)
 }
 
 func (c *MetricStore) GetNodeMetric(metricName string) (float64, error) {
+	data, err := c.GetNodeMetricWithTime(metricName)
+	return data.Value, err
+}
+
+func (c *MetricStore) GetNumaMetric(numaID int, metricName string) (float64, error) {
+	data, err := c.GetNumaMetricWithTime(numaID, metricName)
+	return data.Value, err
+}
+
+func (c *MetricStore) GetDeviceMetric(deviceName string, metricName string) (float64, error) {
+	data, err := c.GetDeviceMetricWithTime(deviceName, metricName)
+	return data.Value, err
+}
+
+func (c *MetricStore) GetCPUMetric(coreID int, metricName string) (float64, error) {
+	data, err := c.GetCPUMetricWithTime(coreID, metricName)
+	return data.Value, err
+}
+
+func (c *MetricStore) GetContainerMetric(podUID, containerName, metricName string) (float64, error) {
+	data, err := c.GetContainerMetricWithTime(podUID, containerName, metricName)
+	return data.Value, err
+}
+
+func (c *MetricStore) GetContainerNumaMetric(podUID, containerName, numaNode, metricName string) (float64, error) {
+	data, err := c.GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName)
+	return data.Value, err
+}
+
+func (c *MetricStore) GetNodeMetricWithTime(metricName string) (MetricData, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
-	if value, ok := c.nodeMetricMap[metricName]; ok {
-		return value, nil
+	if data, ok := c.nodeMetricMap[metricName]; ok {
+		return data, nil
 	} else {
-		return 0, errors.New("[MetricStore] load value failed")
+		return MetricData{}, errors.New("[MetricStore] load value failed")
 	}
 }
 
-func (c *MetricStore) GetNumaMetric(numaID int, metricName string) (float64, error) {
+func (c *MetricStore) GetNumaMetricWithTime(numaID int, metricName string) (MetricData, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 	if c.numaMetricMap[numaID] != nil {
-		if value, ok := c.numaMetricMap[numaID][metricName]; ok {
-			return value, nil
+		if data, ok := c.numaMetricMap[numaID][metricName]; ok {
+			return data, nil
 		} else {
-			return 0, errors.New("[MetricStore] load value failed")
+			return MetricData{}, errors.New("[MetricStore] load value failed")
 		}
 	}
-	return 0, errors.New("[MetricStore] empty map")
+	return MetricData{}, errors.New("[MetricStore] empty map")
 }
 
-func (c *MetricStore) GetDeviceMetric(deviceName string, metricName string) (float64, error) {
+func (c *MetricStore) GetDeviceMetricWithTime(deviceName string, metricName string) (MetricData, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 	if c.deviceMetricMap[deviceName] != nil {
-		if value, ok := c.deviceMetricMap[deviceName][metricName]; ok {
-			return value, nil
+		if data, ok := c.deviceMetricMap[deviceName][metricName]; ok {
+			return data, nil
 		} else {
-			return 0, errors.New("[MetricStore] load value failed")
+			return MetricData{}, errors.New("[MetricStore] load value failed")
 		}
 	}
-	return 0, errors.New("[MetricStore] empty map")
+	return MetricData{}, errors.New("[MetricStore] empty map")
 }
 
-func (c *MetricStore) GetCPUMetric(coreID int, metricName string) (float64, error) {
+func (c *MetricStore) GetCPUMetricWithTime(coreID int, metricName string) (MetricData, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 	if c.cpuMetricMap[coreID] != nil {
-		if value, ok := c.cpuMetricMap[coreID][metricName]; ok {
-			return value, nil
+		if data, ok := c.cpuMetricMap[coreID][metricName]; ok {
+			return data, nil
 		} else {
-			return 0, errors.New("[MetricStore] load value failed")
+			return MetricData{}, errors.New("[MetricStore] load value failed")
 		}
 	}
-	return 0, errors.New("[MetricStore] empty map")
+	return MetricData{}, errors.New("[MetricStore] empty map")
 }
 
-func (c *MetricStore) GetContainerMetric(podUID, containerName, metricName string) (float64, error) {
+func (c *MetricStore) GetContainerMetricWithTime(podUID, containerName, metricName string) (MetricData, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 	if c.podContainerMetricMap[podUID] != nil {
 		if c.podContainerMetricMap[podUID][containerName] != nil {
-			if value, ok := c.podContainerMetricMap[podUID][containerName][metricName]; ok {
-				return value, nil
+			if data, ok := c.podContainerMetricMap[podUID][containerName][metricName]; ok {
+				return data, nil
 			} else {
-				return 0, errors.New("[MetricStore] load value failed")
+				return MetricData{}, errors.New("[MetricStore] load value failed")
 			}
 		}
 	}
-	return 0, errors.New("[MetricStore] empty map")
+	return MetricData{}, errors.New("[MetricStore] empty map")
 }
 
-func (c *MetricStore) GetContainerNumaMetric(podUID, containerName, numaNode, metricName string) (float64, error) {
+func (c *MetricStore) GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName string) (MetricData, error) {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 	if c.podContainerNumaMetricMap[podUID] != nil {
 		if c.podContainerNumaMetricMap[podUID][containerName] != nil {
 			if c.podContainerNumaMetricMap[podUID][containerName][numaNode] != nil {
-				if value, ok := c.podContainerNumaMetricMap[podUID][containerName][numaNode][metricName]; ok {
-					return value, nil
+				if data, ok := c.podContainerNumaMetricMap[podUID][containerName][numaNode][metricName]; ok {
+					return data, nil
 				} else {
-					return 0, errors.New("[MetricStore] load value failed")
+					return MetricData{}, errors.New("[MetricStore] load value failed")
 				}
 			}
 		}
 	}
-	return 0, errors.New("[MetricStore] empty map")
+	return MetricData{}, errors.New("[MetricStore] empty map")
+}
+
+// GetNodeMetricWithMaxAge returns ErrMetricDataStale if the metric is older than maxAge
+func (c *MetricStore) GetNodeMetricWithMaxAge(metricName string, maxAge time.Duration) (float64, error) {
+	data, err := c.GetNodeMetricWithTime(metricName)
+	if err != nil {
+		return 0, err
+	}
+	return data.checkAge(c.now(), maxAge)
+}
+
+// GetNumaMetricWithMaxAge returns ErrMetricDataStale if the metric is older than maxAge
+func (c *MetricStore) GetNumaMetricWithMaxAge(numaID int, metricName string, maxAge time.Duration) (float64, error) {
+	data, err := c.GetNumaMetricWithTime(numaID, metricName)
+	if err != nil {
+		return 0, err
+	}
+	return data.checkAge(c.now(), maxAge)
+}
+
+// GetDeviceMetricWithMaxAge returns ErrMetricDataStale if the metric is older than maxAge
+func (c *MetricStore) GetDeviceMetricWithMaxAge(deviceName string, metricName string, maxAge time.Duration) (float64, error) {
+	data, err := c.GetDeviceMetricWithTime(deviceName, metricName)
+	if err != nil {
+		return 0, err
+	}
+	return data.checkAge(c.now(), maxAge)
+}
+
+// GetCPUMetricWithMaxAge returns ErrMetricDataStale if the metric is older than maxAge
+func (c *MetricStore) GetCPUMetricWithMaxAge(coreID int, metricName string, maxAge time.Duration) (float64, error) {
+	data, err := c.GetCPUMetricWithTime(coreID, metricName)
+	if err != nil {
+		return 0, err
+	}
+	return data.checkAge(c.now(), maxAge)
+}
+
+// GetContainerMetricWithMaxAge returns ErrMetricDataStale if the metric is older than maxAge
+func (c *MetricStore) GetContainerMetricWithMaxAge(podUID, containerName, metricName string, maxAge time.Duration) (float64, error) {
+	data, err := c.GetContainerMetricWithTime(podUID, containerName, metricName)
+	if err != nil {
+		return 0, err
+	}
+	return data.checkAge(c.now(), maxAge)
+}
+
+// GetContainerNumaMetricWithMaxAge returns ErrMetricDataStale if the metric is older than maxAge
+func (c *MetricStore) GetContainerNumaMetricWithMaxAge(podUID, containerName, numaNode, metricName string, maxAge time.Duration) (float64, error) {
+	data, err := c.GetContainerNumaMetricWithTime(podUID, containerName, numaNode, metricName)
+	if err != nil {
+		return 0, err
+	}
+	return data.checkAge(c.now(), maxAge)
+}
+
+// GetScopeUpdateTime returns the latest time any metric is stored in the given scope
+func (c *MetricStore) GetScopeUpdateTime(scope string) (time.Time, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	t, ok := c.scopeUpdateTime[scope]
+	return t, ok
 }
 
 func (c *MetricStore) GCPodsMetric(livingPodUIDSet map[string]bool) {
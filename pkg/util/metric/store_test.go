@@ -17,7 +17,9 @@ limitations under the License.
 package metric
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -72,3 +74,73 @@ func TestStore_ContainerMetric(t *testing.T) {
 	value, _ = store.GetContainerMetric("pod2", "container1", "test-metric-name")
 	assert.Equal(t, 1.0, value)
 }
+
+func TestStore_MetricWithTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	store := NewMetricStore()
+	store.now = func() time.Time { return now }
+
+	_, ok := store.GetScopeUpdateTime(MetricScopeNode)
+	assert.False(t, ok)
+
+	store.SetNodeMetric("test-metric-name", 1.0)
+	store.SetContainerNumaMetric("pod1", "container1", "0", "test-metric-name", 2.0)
+
+	data, err := store.GetNodeMetricWithTime("test-metric-name")
+	assert.NoError(t, err)
+	assert.Equal(t, MetricData{Value: 1.0, Time: now}, data)
+	updateTime, ok := store.GetScopeUpdateTime(MetricScopeNode)
+	assert.True(t, ok)
+	assert.Equal(t, now, updateTime)
+	updateTime, ok = store.GetScopeUpdateTime(MetricScopeContainer)
+	assert.True(t, ok)
+	assert.Equal(t, now, updateTime)
+
+	now = now.Add(time.Minute)
+	value, err := store.GetNodeMetricWithMaxAge("test-metric-name", 2*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, value)
+	_, err = store.GetNodeMetricWithMaxAge("test-metric-name", 30*time.Second)
+	assert.True(t, errors.Is(err, ErrMetricDataStale))
+	_, err = store.GetContainerNumaMetricWithMaxAge("pod1", "container1", "0", "test-metric-name", 30*time.Second)
+	assert.True(t, errors.Is(err, ErrMetricDataStale))
+	_, err = store.GetContainerNumaMetricWithMaxAge("pod1", "container1", "1", "test-metric-name", 30*time.Second)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrMetricDataStale))
+
+	// max age is ignored if it is not positive
+	value, err = store.GetContainerNumaMetricWithMaxAge("pod1", "container1", "0", "test-metric-name", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+}
+
+func TestStore_SetMetricWithTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	store := NewMetricStore()
+	store.now = func() time.Time { return now }
+
+	sampled := now.Add(-time.Minute)
+	store.SetNodeMetricWithTime("test-metric-name", MetricData{Value: 1.0, Time: sampled})
+	store.SetContainerMetricWithTime("pod1", "container1", "test-metric-name", MetricData{Value: 2.0})
+
+	data, err := store.GetNodeMetricWithTime("test-metric-name")
+	assert.NoError(t, err)
+	assert.Equal(t, MetricData{Value: 1.0, Time: sampled}, data)
+	_, err = store.GetNodeMetricWithMaxAge("test-metric-name", 30*time.Second)
+	assert.True(t, errors.Is(err, ErrMetricDataStale))
+
+	// current time is used if sample time is not given
+	data, err = store.GetContainerMetricWithTime("pod1", "container1", "test-metric-name")
+	assert.NoError(t, err)
+	assert.Equal(t, MetricData{Value: 2.0, Time: now}, data)
+
+	// update time of the scope never goes backwards
+	store.SetNodeMetricWithTime("test-metric-name-2", MetricData{Value: 1.0, Time: sampled.Add(-time.Minute)})
+	updateTime, ok := store.GetScopeUpdateTime(MetricScopeNode)
+	assert.True(t, ok)
+	assert.Equal(t, sampled, updateTime)
+}
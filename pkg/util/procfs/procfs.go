@@ -0,0 +1,370 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package procfs provides accessors to files under procfs, and the root path
+// is configurable so that they can be tested against a fake procfs tree.
+package procfs
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultProcFSRoot is the default mount point of procfs
+	DefaultProcFSRoot = "/proc"
+
+	procSysDir    = "sys"
+	procStat      = "stat"
+	procSchedStat = "schedstat"
+	procVMStat    = "vmstat"
+	procMemInfo   = "meminfo"
+	procLoadAvg   = "loadavg"
+	procDiskStats = "diskstats"
+
+	// cpuStatMinFields is the number of fields of a cpu line in /proc/stat since kernel 2.6.11
+	cpuStatMinFields = 9
+	// schedStatCPUMinFields is the number of fields of a cpu line in /proc/schedstat since version 15
+	schedStatCPUMinFields = 10
+
+	// diskStatsMinFields is the number of fields in /proc/diskstats before kernel 4.18
+	diskStatsMinFields = 14
+)
+
+// DiskStat is the accumulated statistics of a block device in /proc/diskstats
+type DiskStat struct {
+	Major        uint64
+	Minor        uint64
+	ReadIOs      uint64
+	ReadSectors  uint64
+	WriteIOs     uint64
+	WriteSectors uint64
+	// IOTicks is the time (in milliseconds) the device has been busy
+	IOTicks uint64
+}
+
+// CPUStat is the accumulated time (in USER_HZ) a cpu spent in different modes in /proc/stat
+type CPUStat struct {
+	User    uint64
+	Nice    uint64
+	System  uint64
+	Idle    uint64
+	IOWait  uint64
+	IRQ     uint64
+	SoftIRQ uint64
+	Steal   uint64
+}
+
+// Total returns the total time of all modes
+func (s *CPUStat) Total() uint64 {
+	return s.User + s.Nice + s.System + s.Idle + s.IOWait + s.IRQ + s.SoftIRQ + s.Steal
+}
+
+// Busy returns the total time of all modes except for idle and iowait
+func (s *CPUStat) Busy() uint64 {
+	return s.Total() - s.Idle - s.IOWait
+}
+
+// SchedStat is the accumulated scheduler statistics of a cpu in /proc/schedstat
+type SchedStat struct {
+	// RunTime and RunDelay are the time (in nanoseconds) tasks spent running
+	// and waiting on the runqueue of this cpu
+	RunTime    uint64
+	RunDelay   uint64
+	Timeslices uint64
+}
+
+// LoadAvg is the load average of the node in /proc/loadavg
+type LoadAvg struct {
+	One     float64
+	Five    float64
+	Fifteen float64
+}
+
+// ProcFS is a procfs rooted at the given path
+type ProcFS struct {
+	root string
+}
+
+// NewProcFS returns a ProcFS with the given root path
+func NewProcFS(root string) *ProcFS {
+	return &ProcFS{root: root}
+}
+
+// NewDefaultProcFS returns a ProcFS rooted at /proc
+func NewDefaultProcFS() *ProcFS {
+	return NewProcFS(DefaultProcFSRoot)
+}
+
+// Root returns the root path of procfs
+func (p *ProcFS) Root() string {
+	return p.root
+}
+
+// Path returns the absolute path of the given elements under procfs
+func (p *ProcFS) Path(elem ...string) string {
+	return filepath.Join(append([]string{p.root}, elem...)...)
+}
+
+// ReadSysctl reads the value of a sysctl, e.g. vm.watermark_scale_factor
+func (p *ProcFS) ReadSysctl(name string) (string, error) {
+	content, err := ioutil.ReadFile(p.sysctlPath(name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// ReadSysctlInt reads the value of an integer sysctl
+func (p *ProcFS) ReadSysctlInt(name string) (int64, error) {
+	value, err := p.ReadSysctl(name)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse sysctl %s: %s failed with error: %v", name, value, err)
+	}
+	return res, nil
+}
+
+// WriteSysctl writes the value of a sysctl
+func (p *ProcFS) WriteSysctl(name, value string) error {
+	return ioutil.WriteFile(p.sysctlPath(name), []byte(value), 0o644)
+}
+
+// WriteSysctlInt writes the value of an integer sysctl
+func (p *ProcFS) WriteSysctlInt(name string, value int64) error {
+	return p.WriteSysctl(name, strconv.FormatInt(value, 10))
+}
+
+// ReadVMStat returns counters in /proc/vmstat
+func (p *ProcFS) ReadVMStat() (map[string]uint64, error) {
+	return p.readKeyValueFile(p.Path(procVMStat), "")
+}
+
+// ReadMemInfo returns fields in /proc/meminfo, and values are in kB as is
+func (p *ProcFS) ReadMemInfo() (map[string]uint64, error) {
+	return p.readKeyValueFile(p.Path(procMemInfo), ":")
+}
+
+// ReadDiskStats returns statistics of block devices in /proc/diskstats keyed by device names
+func (p *ProcFS) ReadDiskStats() (map[string]*DiskStat, error) {
+	f, err := os.Open(p.Path(procDiskStats))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[string]*DiskStat)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < diskStatsMinFields {
+			continue
+		}
+
+		values := make([]uint64, len(fields))
+		for i, field := range fields {
+			// the third field is the device name
+			if i == 2 {
+				continue
+			}
+
+			values[i], err = strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse diskstats of %s failed with error: %v", fields[2], err)
+			}
+		}
+
+		res[fields[2]] = &DiskStat{
+			Major:        values[0],
+			Minor:        values[1],
+			ReadIOs:      values[3],
+			ReadSectors:  values[5],
+			WriteIOs:     values[7],
+			WriteSectors: values[9],
+			IOTicks:      values[12],
+		}
+	}
+
+	return res, scanner.Err()
+}
+
+// ReadCPUStats returns statistics of each cpu in /proc/stat keyed by cpu ids
+func (p *ProcFS) ReadCPUStats() (map[int]*CPUStat, error) {
+	f, err := os.Open(p.Path(procStat))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[int]*CPUStat)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// skip the aggregated cpu line and lines of other statistics
+		if len(fields) < cpuStatMinFields || fields[0] == "cpu" || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		cpuID, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			return nil, fmt.Errorf("parse cpu id of %s failed with error: %v", fields[0], err)
+		}
+
+		values := make([]uint64, cpuStatMinFields-1)
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse stat of %s failed with error: %v", fields[0], err)
+			}
+		}
+
+		res[cpuID] = &CPUStat{
+			User:    values[0],
+			Nice:    values[1],
+			System:  values[2],
+			Idle:    values[3],
+			IOWait:  values[4],
+			IRQ:     values[5],
+			SoftIRQ: values[6],
+			Steal:   values[7],
+		}
+	}
+
+	return res, scanner.Err()
+}
+
+// ReadSchedStats returns scheduler statistics of each cpu in /proc/schedstat keyed by cpu ids
+func (p *ProcFS) ReadSchedStats() (map[int]*SchedStat, error) {
+	f, err := os.Open(p.Path(procSchedStat))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[int]*SchedStat)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < schedStatCPUMinFields || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		cpuID, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			return nil, fmt.Errorf("parse cpu id of %s failed with error: %v", fields[0], err)
+		}
+
+		// the last three values are running time, waiting time and timeslices of this cpu
+		values := make([]uint64, 3)
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[7+i], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parse schedstat of %s failed with error: %v", fields[0], err)
+			}
+		}
+
+		res[cpuID] = &SchedStat{
+			RunTime:    values[0],
+			RunDelay:   values[1],
+			Timeslices: values[2],
+		}
+	}
+
+	return res, scanner.Err()
+}
+
+// ReadLoadAvg returns the load average of the node in /proc/loadavg
+func (p *ProcFS) ReadLoadAvg() (*LoadAvg, error) {
+	content, err := ioutil.ReadFile(p.Path(procLoadAvg))
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid loadavg content: %q", string(content))
+	}
+
+	values := make([]float64, 3)
+	for i := range values {
+		values[i], err = strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse loadavg %q failed with error: %v", fields[i], err)
+		}
+	}
+	return &LoadAvg{One: values[0], Five: values[1], Fifteen: values[2]}, nil
+}
+
+// ReadThreadCount returns the number of existing threads (i.e. allocated pids)
+// on the node, which is the denominator of the fourth field in /proc/loadavg
+func (p *ProcFS) ReadThreadCount() (int64, error) {
+	content, err := ioutil.ReadFile(p.Path(procLoadAvg))
+	if err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(string(content))
+	if len(fields) < 4 {
+		return 0, fmt.Errorf("invalid loadavg content: %q", string(content))
+	}
+
+	entities := strings.Split(fields[3], "/")
+	if len(entities) != 2 {
+		return 0, fmt.Errorf("invalid scheduling entities in loadavg: %q", fields[3])
+	}
+	return strconv.ParseInt(entities[1], 10, 64)
+}
+
+// readKeyValueFile parses files in which each line contains a key and a value,
+// and the key may end with the given suffix which will be trimmed.
+func (p *ProcFS) readKeyValueFile(file, keySuffix string) (map[string]uint64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[strings.TrimSuffix(fields[0], keySuffix)] = value
+	}
+
+	return res, scanner.Err()
+}
+
+// sysctlPath converts a sysctl name to its path, e.g. vm.min_free_kbytes to /proc/sys/vm/min_free_kbytes
+func (p *ProcFS) sysctlPath(name string) string {
+	return p.Path(procSysDir, strings.ReplaceAll(name, ".", string(filepath.Separator)))
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The Katalyst Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package procfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcFS(t *testing.T) {
+	t.Parallel()
+
+	root, err := ioutil.TempDir("", "procfs")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys", "vm"), 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "sys", "vm", "watermark_scale_factor"), []byte("10\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "vmstat"),
+		[]byte("nr_free_pages 1024\nallocstall_normal 3\nallocstall_movable 4\ninvalid\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "meminfo"),
+		[]byte("MemTotal:       16384 kB\nMemFree:         8192 kB\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "diskstats"),
+		[]byte("   8       0 sda 100 5 2000 30 200 10 4000 60 0 500 90 0 0 0 0\n   8       1 sda1 1 2 3\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "loadavg"), []byte("0.52 0.58 0.59 2/1234 56789\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "stat"),
+		[]byte("cpu  30 0 20 100 5 0 0 0 0 0\ncpu0 10 0 5 50 2 0 0 0 0 0\ncpu1 20 0 15 50 3 1 1 0 0 0\nintr 12345\n"), 0o644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "schedstat"),
+		[]byte("version 15\ntimestamp 4294\ncpu0 0 0 0 0 0 0 1000 2000 30\ndomain0 00000003 0 0 0\ncpu1 0 0 0 0 0 0 3000 4000 50\n"), 0o644))
+
+	fs := NewProcFS(root)
+
+	value, err := fs.ReadSysctlInt("vm.watermark_scale_factor")
+	require.NoError(t, err)
+	require.Equal(t, int64(10), value)
+
+	require.NoError(t, fs.WriteSysctlInt("vm.watermark_scale_factor", 200))
+	value, err = fs.ReadSysctlInt("vm.watermark_scale_factor")
+	require.NoError(t, err)
+	require.Equal(t, int64(200), value)
+
+	_, err = fs.ReadSysctlInt("vm.not_exist")
+	require.Error(t, err)
+
+	vmstat, err := fs.ReadVMStat()
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"nr_free_pages": 1024, "allocstall_normal": 3, "allocstall_movable": 4}, vmstat)
+
+	meminfo, err := fs.ReadMemInfo()
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"MemTotal": 16384, "MemFree": 8192}, meminfo)
+
+	diskStats, err := fs.ReadDiskStats()
+	require.NoError(t, err)
+	require.Equal(t, map[string]*DiskStat{
+		"sda": {Major: 8, Minor: 0, ReadIOs: 100, ReadSectors: 2000, WriteIOs: 200, WriteSectors: 4000, IOTicks: 500},
+	}, diskStats)
+
+	cpuStats, err := fs.ReadCPUStats()
+	require.NoError(t, err)
+	require.Equal(t, map[int]*CPUStat{
+		0: {User: 10, System: 5, Idle: 50, IOWait: 2},
+		1: {User: 20, System: 15, Idle: 50, IOWait: 3, IRQ: 1, SoftIRQ: 1},
+	}, cpuStats)
+	require.Equal(t, uint64(37), cpuStats[1].Busy())
+
+	schedStats, err := fs.ReadSchedStats()
+	require.NoError(t, err)
+	require.Equal(t, map[int]*SchedStat{
+		0: {RunTime: 1000, RunDelay: 2000, Timeslices: 30},
+		1: {RunTime: 3000, RunDelay: 4000, Timeslices: 50},
+	}, schedStats)
+
+	loadAvg, err := fs.ReadLoadAvg()
+	require.NoError(t, err)
+	require.Equal(t, &LoadAvg{One: 0.52, Five: 0.58, Fifteen: 0.59}, loadAvg)
+
+	threads, err := fs.ReadThreadCount()
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), threads)
+}